@@ -4,8 +4,10 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/binary"
 	"fmt"
 	"reflect"
 	"sort"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/xenolf/lego/acme"
 )
 
@@ -24,6 +27,7 @@ type Account struct {
 	PrivateKey         []byte
 	DomainsCertificate DomainsCertificates
 	ChallengeCerts     map[string]*ChallengeCert
+	HTTPChallenge      map[string]map[string][]byte `json:",omitempty"`
 }
 
 // ChallengeCert stores a challenge certificate
@@ -173,6 +177,10 @@ func (dc *DomainsCertificates) renewCertificates(acmeCert *Certificate, domain D
 			if err != nil {
 				return err
 			}
+			// keep serving the current certificate if the renewed one cannot be served
+			if err = traefikTls.ValidateCertificate(&tlsCert); err != nil {
+				return fmt.Errorf("invalid renewed certificate for domain %s: %v", domain.Main, err)
+			}
 			domainsCertificate.Certificate = acmeCert
 			domainsCertificate.tlsCert = &tlsCert
 			return nil
@@ -194,6 +202,8 @@ func (dc *DomainsCertificates) addCertificateForDomains(acmeCert *Certificate, d
 	return &cert, nil
 }
 
+// getCertificateForDomain returns the certificate of the domain, a certificate with the domain itself
+// being preferred to a wildcard certificate.
 func (dc *DomainsCertificates) getCertificateForDomain(domainToFind string) (*DomainsCertificate, bool) {
 	dc.lock.RLock()
 	defer dc.lock.RUnlock()
@@ -207,9 +217,24 @@ func (dc *DomainsCertificates) getCertificateForDomain(domainToFind string) (*Do
 			}
 		}
 	}
+	for _, domainsCertificate := range dc.Certs {
+		if domainsCertificate.matches(domainToFind) {
+			return domainsCertificate, true
+		}
+	}
 	return nil, false
 }
 
+// covers checks whether each domain has a certificate.
+func (dc *DomainsCertificates) covers(domains []string) bool {
+	for _, domain := range domains {
+		if _, ok := dc.getCertificateForDomain(domain); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (dc *DomainsCertificates) exists(domainToFind Domain) (*DomainsCertificate, bool) {
 	dc.lock.RLock()
 	defer dc.lock.RUnlock()
@@ -221,6 +246,19 @@ func (dc *DomainsCertificates) exists(domainToFind Domain) (*DomainsCertificate,
 	return nil, false
 }
 
+// tlsCertificates returns the loaded certificates.
+func (dc *DomainsCertificates) tlsCertificates() []*tls.Certificate {
+	dc.lock.RLock()
+	defer dc.lock.RUnlock()
+	var certificates []*tls.Certificate
+	for _, domainsCertificate := range dc.Certs {
+		if domainsCertificate.tlsCert != nil {
+			certificates = append(certificates, domainsCertificate.tlsCert)
+		}
+	}
+	return certificates
+}
+
 // DomainsCertificate contains a certificate for multiple domains
 type DomainsCertificate struct {
 	Domains     Domain
@@ -228,18 +266,46 @@ type DomainsCertificate struct {
 	tlsCert     *tls.Certificate
 }
 
-func (dc *DomainsCertificate) needRenew() bool {
+// matches checks whether the certificate is valid for the domain, a wildcard domain matching a single label.
+func (dc *DomainsCertificate) matches(domainToFind string) bool {
+	domains := append([]string{dc.Domains.Main}, dc.Domains.SANs...)
+	for _, domain := range domains {
+		if domain == domainToFind {
+			return true
+		}
+		if isWildcard(domain) {
+			label := strings.TrimSuffix(domainToFind, domain[1:])
+			if label != domainToFind && len(label) > 0 && !strings.Contains(label, ".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// needRenew checks whether a certificate of the chain expires within the renewal window,
+// extended by a part of the jitter specific to the certificate, for the renewals not to happen all at once.
+func (dc *DomainsCertificate) needRenew(window, jitter time.Duration) bool {
+	renewBefore := window + renewalJitter(dc.tlsCert, jitter)
 	for _, c := range dc.tlsCert.Certificate {
 		crt, err := x509.ParseCertificate(c)
 		if err != nil {
 			// If there's an error, we assume the cert is broken, and needs update
 			return true
 		}
-		// <= 30 days left, renew certificate
-		if crt.NotAfter.Before(time.Now().Add(time.Duration(24 * 30 * time.Hour))) {
+		if crt.NotAfter.Before(time.Now().Add(renewBefore)) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// renewalJitter returns the part of the jitter of the certificate, derived from its leaf to stay the same between checks and restarts.
+func renewalJitter(tlsCert *tls.Certificate, jitter time.Duration) time.Duration {
+	if jitter <= 0 || len(tlsCert.Certificate) == 0 {
+		return 0
+	}
+	sum := sha256.Sum256(tlsCert.Certificate[0])
+	return time.Duration(binary.BigEndian.Uint64(sum[:8]) % uint64(jitter))
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	fmtlog "log"
+	"net"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/ty/fun"
@@ -17,13 +20,20 @@ import (
 	"github.com/containous/staert"
 	"github.com/containous/traefik/cluster"
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/metrics"
 	"github.com/containous/traefik/safe"
 	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/tls/generate"
 	"github.com/containous/traefik/types"
 	"github.com/eapache/channels"
 	"github.com/xenolf/lego/acme"
-	"github.com/xenolf/lego/providers/dns"
+)
+
+const (
+	// defaultRenewalWindow is the duration before the expiration of a certificate from which it is renewed.
+	defaultRenewalWindow = 30 * 24 * time.Hour
+	// renewalCheckInterval is the interval between two checks of the certificates to renew.
+	renewalCheckInterval = time.Hour
 )
 
 var (
@@ -33,31 +43,40 @@ var (
 
 // ACME allows to connect to lets encrypt and retrieve certs
 type ACME struct {
-	Email               string   `description:"Email address used for registration"`
-	Domains             []Domain `description:"SANs (alternative domains) to each main domain using format: --acme.domains='main.com,san1.com,san2.com' --acme.domains='main.net,san1.net,san2.net'"`
-	Storage             string   `description:"File or key used for certificates storage."`
-	StorageFile         string   // deprecated
-	OnDemand            bool     `description:"Enable on demand certificate. This will request a certificate from Let's Encrypt during the first TLS handshake for a hostname that does not yet have a certificate."`
-	OnHostRule          bool     `description:"Enable certificate generation on frontends Host rules."`
-	CAServer            string   `description:"CA server to use."`
-	EntryPoint          string   `description:"Entrypoint to proxy acme challenge to."`
-	DNSProvider         string   `description:"Use a DNS based challenge provider rather than HTTPS."`
-	DelayDontCheckDNS   int      `description:"Assume DNS propagates after a delay in seconds rather than finding and querying nameservers."`
-	ACMELogging         bool     `description:"Enable debug logging of ACME actions."`
-	client              *acme.Client
+	Email               string          `description:"Email address used for registration"`
+	Domains             []Domain        `description:"SANs (alternative domains) to each main domain using format: --acme.domains='main.com,san1.com,san2.com' --acme.domains='main.net,san1.net,san2.net'"`
+	Storage             string          `description:"File, key, s3://bucket/key or secretsmanager://name used for certificates storage."`
+	StorageFile         string          // deprecated
+	OnDemand            bool            `description:"Enable on demand certificate. This will request a certificate from Let's Encrypt during the first TLS handshake for a hostname that does not yet have a certificate."`
+	OnHostRule          bool            `description:"Enable certificate generation on frontends Host rules."`
+	CAServer            string          `description:"CA server to use."`
+	EntryPoint          string          `description:"Entrypoint to proxy acme challenge to."`
+	DNSProvider         string          `description:"Use a DNS based challenge provider rather than HTTPS."`
+	DelayDontCheckDNS   int             `description:"Assume DNS propagates after a delay in seconds rather than finding and querying nameservers."`
+	DNSPropagation      *DNSPropagation `description:"Tune the check of the propagation of the DNS challenge records."`
+	EAB                 *EAB            `description:"External Account Binding, required to register against some ACME v2 CA servers."`
+	Renewal             *Renewal        `description:"Spread the renewals of the certificates over time."`
+	HTTPChallenge       *HTTPChallenge  `description:"Use the HTTP-01 challenge, served on an entry point, rather than the TLS-SNI-01 challenge."`
+	StorageEncryption   *Encryption     `description:"Encrypt the storage at rest with a key of an environment variable, AWS KMS or Vault transit."`
+	OnDemandPolicy      *OnDemandPolicy `description:"Restrict the on demand certificates to allowed domains, at a limited rate."`
+	ACMELogging         bool            `description:"Enable debug logging of ACME actions."`
+	client              acmeClient
 	defaultCertificate  *tls.Certificate
 	store               cluster.Store
 	challengeProvider   *challengeProvider
+	httpProvider        *challengeHTTPProvider
 	checkOnDemandDomain func(domain string) bool
+	onDemandGuard       *onDemandGuard
 	jobs                *channels.InfiniteChannel
 	TLSConfig           *tls.Config `description:"TLS config in case wildcard certs are used"`
 	dynamicCerts        *safe.Safe
+	metricsRegistry     metrics.Registry
 }
 
-//Domains parse []Domain
+// Domains parse []Domain
 type Domains []Domain
 
-//Set []Domain
+// Set []Domain
 func (ds *Domains) Set(str string) error {
 	fargs := func(c rune) bool {
 		return c == ',' || c == ';'
@@ -78,21 +97,113 @@ func (ds *Domains) Set(str string) error {
 	return nil
 }
 
-//Get []Domain
+// Get []Domain
 func (ds *Domains) Get() interface{} { return []Domain(*ds) }
 
-//String returns []Domain in string
+// String returns []Domain in string
 func (ds *Domains) String() string { return fmt.Sprintf("%+v", *ds) }
 
-//SetValue sets []Domain into the parser
+// SetValue sets []Domain into the parser
 func (ds *Domains) SetValue(val interface{}) {
 	*ds = Domains(val.([]Domain))
 }
 
 // Domain holds a domain name with SANs
 type Domain struct {
-	Main string
-	SANs []string
+	Main        string
+	SANs        []string
+	EntryPoints []string
+}
+
+// withoutEntryPoints returns the domain as stored with its certificate, the entry points only being part of the configuration.
+func (d Domain) withoutEntryPoints() Domain {
+	return Domain{Main: d.Main, SANs: d.SANs}
+}
+
+// DNSPropagation holds the settings of the check of the propagation of the DNS challenge records,
+// overriding the ones of the DNS provider.
+type DNSPropagation struct {
+	Timeout   int      `description:"Maximum duration in seconds to wait for the propagation of the records. Defaults to the one of the DNS provider."`
+	Interval  int      `description:"Duration in seconds between two checks of the propagation. Defaults to the one of the DNS provider."`
+	Resolvers []string `description:"Nameservers (host:port) queried to find the authoritative nameservers of the records. Defaults to the ones of /etc/resolv.conf."`
+}
+
+// EAB holds the External Account Binding given by the CA to bind the ACME account to an account of the CA.
+type EAB struct {
+	KID  string `description:"Key identifier given by the CA."`
+	HMAC string `description:"HMAC key given by the CA, base64url encoded."`
+}
+
+// HTTPChallenge holds the entry point answering the HTTP-01 challenges, on the port 80 of the domains or behind a load balancer forwarding it.
+type HTTPChallenge struct {
+	EntryPoint string `description:"Entry point answering the HTTP-01 challenges"`
+}
+
+// Renewal holds the settings of the renewal of the certificates, spread over time to avoid bursts of ACME requests.
+type Renewal struct {
+	Window      int `description:"Number of days before the expiration of a certificate from which it is renewed. Defaults to 30."`
+	Jitter      int `description:"Maximum number of days a certificate is renewed before its renewal window, at a time of its own. Defaults to 0."`
+	Concurrency int `description:"Maximum number of certificates renewed at the same time. Defaults to 1."`
+}
+
+// settings returns the renewal window, jitter and concurrency, with their default values.
+func (r *Renewal) settings() (time.Duration, time.Duration, int) {
+	window, jitter, concurrency := defaultRenewalWindow, time.Duration(0), 1
+	if r == nil {
+		return window, jitter, concurrency
+	}
+	if r.Window > 0 {
+		window = time.Duration(r.Window) * 24 * time.Hour
+	}
+	if r.Jitter > 0 {
+		jitter = time.Duration(r.Jitter) * 24 * time.Hour
+	}
+	if r.Concurrency > 0 {
+		concurrency = r.Concurrency
+	}
+	return window, jitter, concurrency
+}
+
+// timeoutProvider overrides the propagation timeout and interval of a DNS challenge provider.
+type timeoutProvider struct {
+	acme.ChallengeProvider
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// Timeout returns the propagation timeout and interval.
+func (p *timeoutProvider) Timeout() (time.Duration, time.Duration) {
+	return p.timeout, p.interval
+}
+
+func isWildcard(domain string) bool {
+	return strings.HasPrefix(domain, "*.")
+}
+
+func containsWildcard(domains []string) bool {
+	for _, domain := range domains {
+		if isWildcard(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMetricsRegistry sets the registry counting the certificate orders and renewals.
+func (a *ACME) SetMetricsRegistry(registry metrics.Registry) {
+	a.metricsRegistry = registry
+}
+
+// countRequest counts an order or a renewal of certificate, partitioned by status.
+func (a *ACME) countRequest(requestType string, err error) {
+	if a.metricsRegistry == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	a.metricsRegistry.ACMERequestsCounter().With("type", requestType, "status", status).Add(1)
 }
 
 func (a *ACME) init() error {
@@ -112,6 +223,24 @@ func (a *ACME) init() error {
 		log.Warn("ACME.StorageFile is deprecated, use ACME.Storage instead")
 		a.Storage = a.StorageFile
 	}
+	for _, domain := range a.Domains {
+		if len(a.DNSProvider) == 0 && containsWildcard(append([]string{domain.Main}, domain.SANs...)) {
+			return fmt.Errorf("the wildcard domain %s requires a DNS challenge provider", domain.Main)
+		}
+	}
+	if a.EAB != nil && (len(a.EAB.KID) == 0 || len(a.EAB.HMAC) == 0) {
+		return errors.New("the external account binding requires a key identifier and a HMAC key")
+	}
+	if a.HTTPChallenge != nil && len(a.HTTPChallenge.EntryPoint) == 0 {
+		return errors.New("the HTTP challenge requires an entry point")
+	}
+	if a.Renewal != nil && (a.Renewal.Window < 0 || a.Renewal.Jitter < 0 || a.Renewal.Concurrency < 0) {
+		return errors.New("the renewal window, jitter and concurrency must not be negative")
+	}
+	a.onDemandGuard, err = newOnDemandGuard(a.OnDemandPolicy)
+	if err != nil {
+		return err
+	}
 	a.jobs = channels.NewInfiniteChannel()
 	return nil
 }
@@ -125,6 +254,9 @@ func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tl
 	if len(a.Storage) == 0 {
 		return errors.New("Empty Store, please provide a key for certs storage")
 	}
+	if a.StorageEncryption != nil {
+		return errors.New("the encryption of the ACME storage is not supported with a KV store")
+	}
 	a.checkOnDemandDomain = checkOnDemandDomain
 	a.dynamicCerts = certs
 	tlsConfig.Certificates = append(tlsConfig.Certificates, *a.defaultCertificate)
@@ -156,8 +288,9 @@ func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tl
 
 	a.store = datastore
 	a.challengeProvider = &challengeProvider{store: a.store}
+	a.httpProvider = &challengeHTTPProvider{store: a.store}
 
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(renewalCheckInterval)
 	leadership.Pool.AddGoCtx(func(ctx context.Context) {
 		log.Info("Starting ACME renew job...")
 		defer log.Info("Stopped ACME renew job...")
@@ -251,17 +384,29 @@ func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, certs *safe.Safe, checkO
 	tlsConfig.Certificates = append(tlsConfig.Certificates, *a.defaultCertificate)
 	tlsConfig.GetCertificate = a.getCertificate
 	a.TLSConfig = tlsConfig
-	localStore := NewLocalStore(a.Storage)
-	a.store = localStore
+	storageCipher, err := newStorageCipher(a.StorageEncryption)
+	if err != nil {
+		return err
+	}
+	store, err := newAccountStore(a.Storage, storageCipher)
+	if err != nil {
+		return err
+	}
+	a.store = store
 	a.challengeProvider = &challengeProvider{store: a.store}
+	a.httpProvider = &challengeHTTPProvider{store: a.store}
 
 	var needRegister bool
 	var account *Account
 
-	if fileInfo, fileErr := os.Stat(a.Storage); fileErr == nil && fileInfo.Size() != 0 {
+	exists, err := store.exists()
+	if err != nil {
+		return err
+	}
+	if exists {
 		log.Info("Loading ACME Account...")
 		// load account
-		object, err := localStore.Load()
+		object, err := store.Load()
 		if err != nil {
 			return err
 		}
@@ -320,7 +465,7 @@ func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, certs *safe.Safe, checkO
 	a.renewCertificates()
 	a.runJobs()
 
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(renewalCheckInterval)
 	safe.Go(func() {
 		for range ticker.C {
 			a.renewCertificates()
@@ -349,7 +494,15 @@ func (a *ACME) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificat
 		if a.checkOnDemandDomain != nil && !a.checkOnDemandDomain(domain) {
 			return nil, nil
 		}
-		return a.loadCertificateOnDemand(clientHello)
+		if err := a.onDemandGuard.allow(domain); err != nil {
+			log.Debugf("No on demand certificate for the domain %s: %v", domain, err)
+			return nil, nil
+		}
+		certificate, err := a.loadCertificateOnDemand(clientHello)
+		if err != nil {
+			a.onDemandGuard.reject(domain)
+		}
+		return certificate, err
 	}
 	log.Debugf("ACME got nothing %s", domain)
 	return nil, nil
@@ -358,7 +511,8 @@ func (a *ACME) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificat
 func (a *ACME) retrieveCertificates() {
 	a.jobs.In() <- func() {
 		log.Info("Retrieving ACME certificates...")
-		for _, domain := range a.Domains {
+		for _, configuredDomain := range a.Domains {
+			domain := configuredDomain.withoutEntryPoints()
 			// check if cert isn't already loaded
 			account := a.store.Get().(*Account)
 			if _, exists := account.DomainsCertificate.exists(domain); !exists {
@@ -395,47 +549,71 @@ func (a *ACME) retrieveCertificates() {
 func (a *ACME) renewCertificates() {
 	a.jobs.In() <- func() {
 		log.Debug("Testing certificate renew...")
+		window, jitter, concurrency := a.Renewal.settings()
 		account := a.store.Get().(*Account)
+		var certificatesToRenew []*DomainsCertificate
 		for _, certificateResource := range account.DomainsCertificate.Certs {
-			if certificateResource.needRenew() {
-				log.Debugf("Renewing certificate %+v", certificateResource.Domains)
-				renewedCert, err := a.client.RenewCertificate(acme.CertificateResource{
-					Domain:        certificateResource.Certificate.Domain,
-					CertURL:       certificateResource.Certificate.CertURL,
-					CertStableURL: certificateResource.Certificate.CertStableURL,
-					PrivateKey:    certificateResource.Certificate.PrivateKey,
-					Certificate:   certificateResource.Certificate.Certificate,
-				}, true, OSCPMustStaple)
-				if err != nil {
-					log.Errorf("Error renewing certificate: %v", err)
-					continue
-				}
-				log.Debugf("Renewed certificate %+v", certificateResource.Domains)
-				renewedACMECert := &Certificate{
-					Domain:        renewedCert.Domain,
-					CertURL:       renewedCert.CertURL,
-					CertStableURL: renewedCert.CertStableURL,
-					PrivateKey:    renewedCert.PrivateKey,
-					Certificate:   renewedCert.Certificate,
-				}
-				transaction, object, err := a.store.Begin()
-				if err != nil {
-					log.Errorf("Error renewing certificate: %v", err)
-					continue
-				}
-				account = object.(*Account)
-				err = account.DomainsCertificate.renewCertificates(renewedACMECert, certificateResource.Domains)
-				if err != nil {
-					log.Errorf("Error renewing certificate: %v", err)
-					continue
-				}
+			if certificateResource.needRenew(window, jitter) {
+				certificatesToRenew = append(certificatesToRenew, certificateResource)
+			}
+		}
 
-				if err = transaction.Commit(account); err != nil {
-					log.Errorf("Error Saving ACME account %+v: %s", account, err.Error())
-					continue
+		// the certificates are renewed from a queue, at most concurrency at the same time
+		queue := make(chan *DomainsCertificate)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency && i < len(certificatesToRenew); i++ {
+			wg.Add(1)
+			safe.Go(func() {
+				defer wg.Done()
+				for certificateResource := range queue {
+					a.renewCertificate(certificateResource)
 				}
-			}
+			})
 		}
+		for _, certificateResource := range certificatesToRenew {
+			queue <- certificateResource
+		}
+		close(queue)
+		wg.Wait()
+	}
+}
+
+func (a *ACME) renewCertificate(certificateResource *DomainsCertificate) {
+	log.Debugf("Renewing certificate %+v", certificateResource.Domains)
+	renewedCert, err := a.client.RenewCertificate(acme.CertificateResource{
+		Domain:        certificateResource.Certificate.Domain,
+		CertURL:       certificateResource.Certificate.CertURL,
+		CertStableURL: certificateResource.Certificate.CertStableURL,
+		PrivateKey:    certificateResource.Certificate.PrivateKey,
+		Certificate:   certificateResource.Certificate.Certificate,
+	}, true, OSCPMustStaple)
+	a.countRequest("renewal", err)
+	if err != nil {
+		log.Errorf("Error renewing certificate: %v", err)
+		return
+	}
+	log.Debugf("Renewed certificate %+v", certificateResource.Domains)
+	renewedACMECert := &Certificate{
+		Domain:        renewedCert.Domain,
+		CertURL:       renewedCert.CertURL,
+		CertStableURL: renewedCert.CertStableURL,
+		PrivateKey:    renewedCert.PrivateKey,
+		Certificate:   renewedCert.Certificate,
+	}
+	transaction, object, err := a.store.Begin()
+	if err != nil {
+		log.Errorf("Error renewing certificate: %v", err)
+		return
+	}
+	account := object.(*Account)
+	err = account.DomainsCertificate.renewCertificates(renewedACMECert, certificateResource.Domains)
+	if err != nil {
+		log.Errorf("Error renewing certificate: %v", err)
+		return
+	}
+
+	if err = transaction.Commit(account); err != nil {
+		log.Errorf("Error Saving ACME account %+v: %s", account, err.Error())
 	}
 }
 
@@ -453,33 +631,59 @@ func dnsOverrideDelay(delay int) error {
 	return err
 }
 
-func (a *ACME) buildACMEClient(account *Account) (*acme.Client, error) {
+func (a *ACME) buildACMEClient(account *Account) (acmeClient, error) {
 	log.Debug("Building ACME client...")
 	caServer := "https://acme-v01.api.letsencrypt.org/directory"
 	if len(a.CAServer) > 0 {
 		caServer = a.CAServer
 	}
-	client, err := acme.NewClient(caServer, account, acme.RSA4096)
+
+	directory, err := getDirectoryV2(http.DefaultClient, caServer)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(a.DNSProvider) > 0 {
-		log.Debugf("Using DNS Challenge provider: %s", a.DNSProvider)
-
-		err = dnsOverrideDelay(a.DelayDontCheckDNS)
+	if directory != nil {
+		log.Debugf("Using ACME v2 CA server %s", caServer)
+		if len(a.DNSProvider) == 0 && a.HTTPChallenge == nil {
+			return nil, fmt.Errorf("the ACME v2 CA server %s requires a DNS challenge provider or the HTTP challenge", caServer)
+		}
+		var provider acme.ChallengeProvider = a.httpProvider
+		challenge := challengeHTTP01
+		if len(a.DNSProvider) > 0 {
+			provider, err = a.buildDNSChallengeProvider()
+			if err != nil {
+				return nil, err
+			}
+			challenge = challengeDNS01
+		}
+		client, err := newClientV2(http.DefaultClient, directory, account, provider, a.EAB)
 		if err != nil {
 			return nil, err
 		}
+		client.challenge = challenge
+		return client, nil
+	}
+	if a.EAB != nil {
+		return nil, fmt.Errorf("the external account binding requires an ACME v2 CA server, %s is not", caServer)
+	}
 
+	client, err := acme.NewClient(caServer, account, acme.RSA4096)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.DNSProvider) > 0 {
 		var provider acme.ChallengeProvider
-		provider, err = dns.NewDNSChallengeProviderByName(a.DNSProvider)
+		provider, err = a.buildDNSChallengeProvider()
 		if err != nil {
 			return nil, err
 		}
 
 		client.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.TLSSNI01})
 		err = client.SetChallengeProvider(acme.DNS01, provider)
+	} else if a.HTTPChallenge != nil {
+		client.ExcludeChallenges([]acme.Challenge{acme.DNS01, acme.TLSSNI01})
+		err = client.SetChallengeProvider(acme.HTTP01, a.httpProvider)
 	} else {
 		client.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.DNS01})
 		err = client.SetChallengeProvider(acme.TLSSNI01, a.challengeProvider)
@@ -491,6 +695,47 @@ func (a *ACME) buildACMEClient(account *Account) (*acme.Client, error) {
 	return client, nil
 }
 
+// buildDNSChallengeProvider returns the DNS challenge provider, with the propagation settings of the configuration.
+func (a *ACME) buildDNSChallengeProvider() (acme.ChallengeProvider, error) {
+	log.Debugf("Using DNS Challenge provider: %s", a.DNSProvider)
+
+	err := dnsOverrideDelay(a.DelayDontCheckDNS)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := newDNSChallengeProviderByName(a.DNSProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.DNSPropagation == nil {
+		return provider, nil
+	}
+	if a.DNSPropagation.Timeout < 0 || a.DNSPropagation.Interval < 0 {
+		return nil, fmt.Errorf("invalid negative DNS propagation timeout or interval: %d, %d", a.DNSPropagation.Timeout, a.DNSPropagation.Interval)
+	}
+	if len(a.DNSPropagation.Resolvers) > 0 {
+		var resolvers []string
+		for _, resolver := range a.DNSPropagation.Resolvers {
+			if _, _, err := net.SplitHostPort(resolver); err != nil {
+				resolver = net.JoinHostPort(resolver, "53")
+			}
+			resolvers = append(resolvers, resolver)
+		}
+		acme.RecursiveNameservers = resolvers
+	}
+
+	timeout, interval := challengeProviderTimeout(provider)
+	if a.DNSPropagation.Timeout > 0 {
+		timeout = time.Duration(a.DNSPropagation.Timeout) * time.Second
+	}
+	if a.DNSPropagation.Interval > 0 {
+		interval = time.Duration(a.DNSPropagation.Interval) * time.Second
+	}
+	return &timeoutProvider{ChallengeProvider: provider, timeout: timeout, interval: interval}, nil
+}
+
 func (a *ACME) loadCertificateOnDemand(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	domain := types.CanonicalDomain(clientHello.ServerName)
 	account := a.store.Get().(*Account)
@@ -535,6 +780,12 @@ func (a *ACME) LoadCertificateForDomains(domains []string) {
 			return
 		}
 
+		// Check the ACME certificates, e.g. a wildcard certificate of the domains
+		if a.store != nil && a.store.Get().(*Account).DomainsCertificate.covers(domains) {
+			log.Debugf("ACME certificates found for domains %v", domains)
+			return
+		}
+
 		operation := func() error {
 			if a.client == nil {
 				return errors.New("ACME client still not built")
@@ -587,6 +838,33 @@ func (a *ACME) LoadCertificateForDomains(domains []string) {
 	}
 }
 
+// GetCertificateForEntryPoint returns the ACME certificate matching the server name of the handshake
+// among the ones of the domains configured with the entry point, nil if there is none.
+func (a *ACME) GetCertificateForEntryPoint(entryPoint string, clientHello *tls.ClientHelloInfo) *tls.Certificate {
+	if a.store == nil {
+		return nil
+	}
+	serverName := types.CanonicalDomain(clientHello.ServerName)
+	account := a.store.Get().(*Account)
+	for _, domain := range a.Domains {
+		if !fun.In(entryPoint, domain.EntryPoints) {
+			continue
+		}
+		if domainsCertificate, ok := account.DomainsCertificate.exists(domain.withoutEntryPoints()); ok && domainsCertificate.matches(serverName) {
+			return domainsCertificate.tlsCert
+		}
+	}
+	return nil
+}
+
+// Certificates returns the certificates of the ACME store.
+func (a *ACME) Certificates() []*tls.Certificate {
+	if a.store == nil {
+		return nil
+	}
+	return a.store.Get().(*Account).DomainsCertificate.tlsCertificates()
+}
+
 // Get provided certificate which check a domains list (Main and SANs)
 // from static and dynamic provided certificates
 func (a *ACME) getProvidedCertificate(domains []string) *tls.Certificate {
@@ -621,13 +899,19 @@ func searchProvidedCertificateForDomains(domains []string, certs map[string]*tls
 
 func (a *ACME) getDomainsCertificates(domains []string) (*Certificate, error) {
 	domains = fun.Map(types.CanonicalDomain, domains).([]string)
+	if _, ok := a.client.(*clientV2); !ok && containsWildcard(domains) {
+		return nil, fmt.Errorf("cannot obtain the wildcard certificate %v from an ACME v1 CA server", domains)
+	}
 	log.Debugf("Loading ACME certificates %s...", domains)
 	bundle := true
 	certificate, failures := a.client.ObtainCertificate(domains, bundle, nil, OSCPMustStaple)
 	if len(failures) > 0 {
 		log.Error(failures)
-		return nil, fmt.Errorf("Cannot obtain certificates %s+v", failures)
+		err := fmt.Errorf("Cannot obtain certificates %s+v", failures)
+		a.countRequest("order", err)
+		return nil, err
 	}
+	a.countRequest("order", nil)
 	log.Debugf("Loaded ACME certificates %s", domains)
 	return &Certificate{
 		Domain:        certificate.Domain,
@@ -12,6 +12,7 @@ import (
 
 	"github.com/containous/traefik/tls/generate"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/xenolf/lego/acme"
 )
 
@@ -295,3 +296,130 @@ func TestAcme_getProvidedCertificate(t *testing.T) {
 	certificate = a.getProvidedCertificate(domains)
 	assert.Nil(t, certificate)
 }
+
+func TestDomainsCertificateMatches(t *testing.T) {
+	dc := &DomainsCertificate{Domains: Domain{Main: "*.containo.us", SANs: []string{"containo.us"}}}
+
+	testCases := []struct {
+		domain   string
+		expected bool
+	}{
+		{domain: "containo.us", expected: true},
+		{domain: "traefik.containo.us", expected: true},
+		{domain: "a.traefik.containo.us"},
+		{domain: "traefikcontaino.us"},
+		{domain: ".containo.us"},
+		{domain: "traefik.io"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.domain, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, dc.matches(test.domain))
+		})
+	}
+}
+
+func TestDomainsCertificatesCovers(t *testing.T) {
+	dc := &DomainsCertificates{
+		Certs: []*DomainsCertificate{
+			{Domains: Domain{Main: "*.containo.us"}},
+			{Domains: Domain{Main: "traefik.io"}},
+		},
+	}
+
+	assert.True(t, dc.covers([]string{"traefik.containo.us", "traefik.io"}))
+	assert.False(t, dc.covers([]string{"containo.us"}))
+	assert.False(t, dc.covers([]string{"traefik.io", "www.traefik.io"}))
+}
+
+func TestDomainsCertificateNeedRenew(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		expiration time.Duration
+		window     time.Duration
+		jitter     time.Duration
+		expected   bool
+	}{
+		{
+			desc:       "expiring after the window",
+			expiration: 40 * 24 * time.Hour,
+			window:     30 * 24 * time.Hour,
+			expected:   false,
+		},
+		{
+			desc:       "expiring within the window",
+			expiration: 20 * 24 * time.Hour,
+			window:     30 * 24 * time.Hour,
+			expected:   true,
+		},
+		{
+			desc:       "expiring within a custom window",
+			expiration: 40 * 24 * time.Hour,
+			window:     45 * 24 * time.Hour,
+			expected:   true,
+		},
+		{
+			desc:       "expiring after the window and the jitter",
+			expiration: 40 * 24 * time.Hour,
+			window:     30 * 24 * time.Hour,
+			jitter:     5 * 24 * time.Hour,
+			expected:   false,
+		},
+		{
+			desc:       "expiring within the window with a jitter",
+			expiration: 29 * 24 * time.Hour,
+			window:     30 * 24 * time.Hour,
+			jitter:     5 * 24 * time.Hour,
+			expected:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			certPEM, keyPEM, err := generate.KeyPair("foo.com", time.Now().Add(test.expiration))
+			require.NoError(t, err)
+			tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+			require.NoError(t, err)
+
+			dc := &DomainsCertificate{tlsCert: &tlsCert}
+			assert.Equal(t, test.expected, dc.needRenew(test.window, test.jitter))
+		})
+	}
+}
+
+func TestRenewalJitter(t *testing.T) {
+	jitter := 10 * 24 * time.Hour
+	var jitters []time.Duration
+	for i := 0; i < 5; i++ {
+		certPEM, keyPEM, err := generate.KeyPair("foo.com", time.Now().Add(90*24*time.Hour))
+		require.NoError(t, err)
+		tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		certificateJitter := renewalJitter(&tlsCert, jitter)
+		assert.True(t, certificateJitter >= 0 && certificateJitter < jitter)
+		assert.Equal(t, certificateJitter, renewalJitter(&tlsCert, jitter), "the jitter of a certificate should not change")
+		jitters = append(jitters, certificateJitter)
+	}
+	assert.NotEqual(t, jitters[0], jitters[1], "the certificates should not be renewed at the same time")
+
+	assert.Equal(t, time.Duration(0), renewalJitter(&tls.Certificate{Certificate: [][]byte{[]byte("foo")}}, 0))
+}
+
+func TestRenewalSettings(t *testing.T) {
+	window, jitter, concurrency := (*Renewal)(nil).settings()
+	assert.Equal(t, 30*24*time.Hour, window)
+	assert.Equal(t, time.Duration(0), jitter)
+	assert.Equal(t, 1, concurrency)
+
+	window, jitter, concurrency = (&Renewal{Window: 20, Jitter: 7, Concurrency: 3}).settings()
+	assert.Equal(t, 20*24*time.Hour, window)
+	assert.Equal(t, 7*24*time.Hour, jitter)
+	assert.Equal(t, 3, concurrency)
+}
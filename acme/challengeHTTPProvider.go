@@ -0,0 +1,111 @@
+package acme
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/xenolf/lego/acme"
+)
+
+var _ acme.ChallengeProviderTimeout = (*challengeHTTPProvider)(nil)
+
+// challengeHTTPProvider stores the key authorizations of the HTTP-01 challenges in the account,
+// for any instance of a cluster to answer the challenges.
+type challengeHTTPProvider struct {
+	store cluster.Store
+	lock  sync.RWMutex
+}
+
+// getTokenValue returns the key authorization of the token of the domain, nil if the challenge is unknown.
+func (c *challengeHTTPProvider) getTokenValue(token, domain string) []byte {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	account, ok := c.store.Get().(*Account)
+	if !ok || account == nil || account.HTTPChallenge == nil {
+		return nil
+	}
+	return account.HTTPChallenge[token][domain]
+}
+
+func (c *challengeHTTPProvider) Present(domain, token, keyAuth string) error {
+	log.Debugf("HTTP challenge Present %s", domain)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	transaction, object, err := c.store.Begin()
+	if err != nil {
+		return err
+	}
+	account := object.(*Account)
+	if account.HTTPChallenge == nil {
+		account.HTTPChallenge = map[string]map[string][]byte{}
+	}
+	if account.HTTPChallenge[token] == nil {
+		account.HTTPChallenge[token] = map[string][]byte{}
+	}
+	account.HTTPChallenge[token][domain] = []byte(keyAuth)
+	return transaction.Commit(account)
+}
+
+func (c *challengeHTTPProvider) CleanUp(domain, token, keyAuth string) error {
+	log.Debugf("HTTP challenge CleanUp %s", domain)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	transaction, object, err := c.store.Begin()
+	if err != nil {
+		return err
+	}
+	account := object.(*Account)
+	if account.HTTPChallenge[token] != nil {
+		delete(account.HTTPChallenge[token], domain)
+		if len(account.HTTPChallenge[token]) == 0 {
+			delete(account.HTTPChallenge, token)
+		}
+	}
+	return transaction.Commit(account)
+}
+
+func (c *challengeHTTPProvider) Timeout() (timeout, interval time.Duration) {
+	return 60 * time.Second, 5 * time.Second
+}
+
+// AddHTTPChallengeRoute adds to the router of an entry point the route answering the HTTP-01 challenges of the resolvers,
+// the first resolver knowing the token of the domain answering.
+func AddHTTPChallengeRoute(router *mux.Router, resolvers []*ACME) {
+	router.Methods(http.MethodGet).
+		Path(acme.HTTP01ChallengePath("{token}")).
+		Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			token := mux.Vars(req)["token"]
+			domain := challengeDomain(req)
+			for _, resolver := range resolvers {
+				if resolver.httpProvider == nil {
+					continue
+				}
+				if keyAuth := resolver.httpProvider.getTokenValue(token, domain); len(keyAuth) > 0 {
+					rw.Write(keyAuth)
+					return
+				}
+			}
+			log.Debugf("Unable to find the HTTP challenge token %s of the domain %s", token, domain)
+			rw.WriteHeader(http.StatusNotFound)
+		}))
+}
+
+// challengeDomain returns the domain of a challenge request: the X-Forwarded-Host set by the load balancers in front of Træfik,
+// else the host of the request.
+func challengeDomain(req *http.Request) string {
+	host := req.Host
+	if forwardedHost := req.Header.Get("X-Forwarded-Host"); len(forwardedHost) > 0 {
+		host = strings.TrimSpace(strings.Split(forwardedHost, ",")[0])
+	}
+	if domain, _, err := net.SplitHostPort(host); err == nil {
+		host = domain
+	}
+	return types.CanonicalDomain(host)
+}
@@ -0,0 +1,118 @@
+package acme
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeHTTPProvider(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-http-challenge")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	store := NewLocalStore(filepath.Join(directory, "acme.json"))
+	store.account = &Account{}
+	provider := &challengeHTTPProvider{store: store}
+
+	require.NoError(t, provider.Present("example.com", "token", "keyAuth"))
+	require.NoError(t, provider.Present("other.example.com", "token", "otherKeyAuth"))
+	assert.Equal(t, []byte("keyAuth"), provider.getTokenValue("token", "example.com"))
+	assert.Equal(t, []byte("otherKeyAuth"), provider.getTokenValue("token", "other.example.com"))
+	assert.Nil(t, provider.getTokenValue("unknown", "example.com"))
+
+	require.NoError(t, provider.CleanUp("example.com", "token", "keyAuth"))
+	assert.Nil(t, provider.getTokenValue("token", "example.com"))
+	require.NoError(t, provider.CleanUp("other.example.com", "token", "otherKeyAuth"))
+	assert.Empty(t, store.account.HTTPChallenge)
+}
+
+func TestAddHTTPChallengeRoute(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-http-challenge")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	var resolvers []*ACME
+	for i, domain := range []string{"example.com", "other.example.com"} {
+		store := NewLocalStore(filepath.Join(directory, domain+".json"))
+		store.account = &Account{}
+		resolver := &ACME{httpProvider: &challengeHTTPProvider{store: store}}
+		require.NoError(t, resolver.httpProvider.Present(domain, "token", "keyAuth"+strconv.Itoa(i)))
+		resolvers = append(resolvers, resolver)
+	}
+	resolvers = append([]*ACME{{}}, resolvers...)
+
+	router := mux.NewRouter()
+	AddHTTPChallengeRoute(router, resolvers)
+
+	testCases := []struct {
+		desc           string
+		path           string
+		host           string
+		forwardedHost  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "host of the request",
+			path:           "/.well-known/acme-challenge/token",
+			host:           "example.com",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "keyAuth0",
+		},
+		{
+			desc:           "host of the request with a port",
+			path:           "/.well-known/acme-challenge/token",
+			host:           "Other.Example.com:8080",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "keyAuth1",
+		},
+		{
+			desc:           "forwarded host",
+			path:           "/.well-known/acme-challenge/token",
+			host:           "10.0.0.1:8080",
+			forwardedHost:  "other.example.com:80, proxy.example.com",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "keyAuth1",
+		},
+		{
+			desc:           "unknown token",
+			path:           "/.well-known/acme-challenge/unknown",
+			host:           "example.com",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "unknown domain",
+			path:           "/.well-known/acme-challenge/token",
+			host:           "unknown.example.com",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://"+test.host+test.path, nil)
+			if len(test.forwardedHost) > 0 {
+				req.Header.Set("X-Forwarded-Host", test.forwardedHost)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
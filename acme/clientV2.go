@@ -0,0 +1,650 @@
+package acme
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/xenolf/lego/acme"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	problemBadNonce = "urn:ietf:params:acme:error:badNonce"
+
+	statusValid   = "valid"
+	statusInvalid = "invalid"
+
+	challengeDNS01  = "dns-01"
+	challengeHTTP01 = "http-01"
+
+	defaultOrderTimeout = 2 * time.Minute
+)
+
+var (
+	// tlsFeatureExtensionOID and ocspMustStapleFeature request the OCSP Must Staple extension, as lego does.
+	tlsFeatureExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+	ocspMustStapleFeature  = []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+)
+
+// acmeClient is the part of the ACME clients used to register and to obtain the certificates,
+// implemented by the lego client for the ACME v1 CA servers and by clientV2 for the ACME v2 ones.
+type acmeClient interface {
+	Register() (*acme.RegistrationResource, error)
+	AgreeToTOS() error
+	QueryRegistration() (*acme.RegistrationResource, error)
+	ObtainCertificate(domains []string, bundle bool, privKey crypto.PrivateKey, mustStaple bool) (acme.CertificateResource, map[string]error)
+	RenewCertificate(cert acme.CertificateResource, bundle, mustStaple bool) (acme.CertificateResource, error)
+}
+
+// directoryV2 holds the URLs of the resources of an ACME v2 CA server.
+type directoryV2 struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+	Meta       struct {
+		TermsOfService          string `json:"termsOfService"`
+		ExternalAccountRequired bool   `json:"externalAccountRequired"`
+	} `json:"meta"`
+}
+
+type accountV2 struct {
+	TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed,omitempty"`
+	Contact                []string        `json:"contact,omitempty"`
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding,omitempty"`
+}
+
+type identifierV2 struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type orderV2 struct {
+	Status         string         `json:"status,omitempty"`
+	Identifiers    []identifierV2 `json:"identifiers"`
+	Authorizations []string       `json:"authorizations,omitempty"`
+	Finalize       string         `json:"finalize,omitempty"`
+	Certificate    string         `json:"certificate,omitempty"`
+	Error          *problemV2     `json:"error,omitempty"`
+}
+
+type authorizationV2 struct {
+	Status     string        `json:"status"`
+	Identifier identifierV2  `json:"identifier"`
+	Wildcard   bool          `json:"wildcard"`
+	Challenges []challengeV2 `json:"challenges"`
+}
+
+type challengeV2 struct {
+	Type   string     `json:"type"`
+	URL    string     `json:"url"`
+	Token  string     `json:"token"`
+	Status string     `json:"status"`
+	Error  *problemV2 `json:"error,omitempty"`
+}
+
+// problemV2 is an error returned by an ACME v2 CA server.
+type problemV2 struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (p *problemV2) Error() string {
+	return fmt.Sprintf("acme: %s: %s", p.Type, p.Detail)
+}
+
+// clientV2 obtains the certificates from an ACME v2 CA server (RFC 8555), which is required for the wildcard certificates.
+// The DNS-01 challenges are solved with the DNS providers of lego, the HTTP-01 ones by the challenge entry point.
+type clientV2 struct {
+	directory  directoryV2
+	user       acme.User
+	privateKey *rsa.PrivateKey
+	jwk        json.RawMessage
+	thumbprint string
+	provider   acme.ChallengeProvider
+	challenge  string
+	httpClient *http.Client
+	eabKeyID   string
+	eabKey     []byte
+
+	certificateKeyBits int
+	pollInterval       time.Duration
+	orderTimeout       time.Duration
+
+	lock   sync.Mutex
+	keyID  string
+	nonces []string
+}
+
+// getDirectoryV2 returns the directory of the CA server if it implements ACME v2, nil otherwise.
+func getDirectoryV2(httpClient *http.Client, caServer string) (*directoryV2, error) {
+	resp, err := httpClient.Get(caServer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the directory of the CA server %s: %v", caServer, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get the directory of the CA server %s: status %d", caServer, resp.StatusCode)
+	}
+	directory := &directoryV2{}
+	if err := json.NewDecoder(resp.Body).Decode(directory); err != nil {
+		return nil, fmt.Errorf("unable to decode the directory of the CA server %s: %v", caServer, err)
+	}
+	if len(directory.NewOrder) == 0 {
+		return nil, nil
+	}
+	return directory, nil
+}
+
+func newClientV2(httpClient *http.Client, directory *directoryV2, user acme.User, provider acme.ChallengeProvider, eab *EAB) (*clientV2, error) {
+	privateKey, ok := user.GetPrivateKey().(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the ACME account requires a RSA private key")
+	}
+
+	jwk := &jose.JsonWebKey{Key: &privateKey.PublicKey}
+	jwkJSON, err := jwk.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &clientV2{
+		directory:          *directory,
+		user:               user,
+		privateKey:         privateKey,
+		jwk:                jwkJSON,
+		thumbprint:         base64.RawURLEncoding.EncodeToString(thumbprint),
+		provider:           provider,
+		challenge:          challengeDNS01,
+		httpClient:         httpClient,
+		certificateKeyBits: 4096,
+		pollInterval:       time.Second,
+		orderTimeout:       defaultOrderTimeout,
+	}
+
+	if eab != nil {
+		// the key is base64url encoded, the CAs don't agree on the padding
+		c.eabKey, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(eab.HMAC, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid external account binding HMAC key: %v", err)
+		}
+		c.eabKeyID = eab.KID
+	} else if directory.Meta.ExternalAccountRequired {
+		return nil, errors.New("the CA server requires an external account binding")
+	}
+	return c, nil
+}
+
+// Register creates the account, or finds the account of the key if it already exists.
+func (c *clientV2) Register() (*acme.RegistrationResource, error) {
+	account := accountV2{TermsOfServiceAgreed: true}
+	if email := c.user.GetEmail(); len(email) > 0 {
+		account.Contact = []string{"mailto:" + email}
+	}
+	if len(c.eabKeyID) > 0 {
+		binding, err := c.signExternalAccountBinding()
+		if err != nil {
+			return nil, err
+		}
+		account.ExternalAccountBinding = binding
+	}
+
+	header, err := c.post(c.directory.NewAccount, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	keyID := header.Get("Location")
+	if len(keyID) == 0 {
+		return nil, errors.New("no account URL in the response of the CA server")
+	}
+
+	c.lock.Lock()
+	c.keyID = keyID
+	c.lock.Unlock()
+
+	return &acme.RegistrationResource{
+		URI:    keyID,
+		TosURL: c.directory.Meta.TermsOfService,
+		Body:   acme.Registration{Contact: account.Contact},
+	}, nil
+}
+
+// AgreeToTOS does nothing, the terms of service are agreed to when registering.
+func (c *clientV2) AgreeToTOS() error {
+	return nil
+}
+
+// QueryRegistration returns the existing account of the key.
+func (c *clientV2) QueryRegistration() (*acme.RegistrationResource, error) {
+	return c.Register()
+}
+
+// ObtainCertificate orders a certificate for the domains, the first domain being the common name.
+func (c *clientV2) ObtainCertificate(domains []string, bundle bool, privKey crypto.PrivateKey, mustStaple bool) (acme.CertificateResource, map[string]error) {
+	certificate, err := c.obtainCertificate(domains, bundle, privKey, mustStaple)
+	if err != nil {
+		return acme.CertificateResource{}, map[string]error{strings.Join(domains, ","): err}
+	}
+	return certificate, nil
+}
+
+// RenewCertificate orders a new certificate for the domains of the certificate, with the same private key.
+func (c *clientV2) RenewCertificate(cert acme.CertificateResource, bundle, mustStaple bool) (acme.CertificateResource, error) {
+	block, _ := pem.Decode(cert.Certificate)
+	if block == nil {
+		return acme.CertificateResource{}, errors.New("unable to decode the certificate to renew")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+
+	var domains []string
+	if len(leaf.Subject.CommonName) > 0 {
+		domains = append(domains, leaf.Subject.CommonName)
+	}
+	for _, domain := range leaf.DNSNames {
+		if domain != leaf.Subject.CommonName {
+			domains = append(domains, domain)
+		}
+	}
+
+	var privateKey crypto.PrivateKey
+	if len(cert.PrivateKey) > 0 {
+		privateKey, err = parsePrivateKey(cert.PrivateKey)
+		if err != nil {
+			return acme.CertificateResource{}, err
+		}
+	}
+	return c.obtainCertificate(domains, bundle, privateKey, mustStaple)
+}
+
+func (c *clientV2) obtainCertificate(domains []string, bundle bool, privateKey crypto.PrivateKey, mustStaple bool) (acme.CertificateResource, error) {
+	if len(domains) == 0 {
+		return acme.CertificateResource{}, errors.New("no domain to obtain a certificate for")
+	}
+	if err := c.ensureAccount(); err != nil {
+		return acme.CertificateResource{}, err
+	}
+
+	request := orderV2{}
+	for _, domain := range domains {
+		request.Identifiers = append(request.Identifiers, identifierV2{Type: "dns", Value: domain})
+	}
+	order := &orderV2{}
+	header, err := c.post(c.directory.NewOrder, request, order)
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+	orderURL := header.Get("Location")
+
+	for _, authorizationURL := range order.Authorizations {
+		if err := c.authorize(authorizationURL); err != nil {
+			return acme.CertificateResource{}, err
+		}
+	}
+
+	if privateKey == nil {
+		privateKey, err = rsa.GenerateKey(rand.Reader, c.certificateKeyBits)
+		if err != nil {
+			return acme.CertificateResource{}, err
+		}
+	}
+	csr, err := createCSR(privateKey, domains, mustStaple)
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+	if _, err := c.post(order.Finalize, map[string]string{"csr": base64.RawURLEncoding.EncodeToString(csr)}, order); err != nil {
+		return acme.CertificateResource{}, err
+	}
+
+	err = acme.WaitFor(c.orderTimeout, c.pollInterval, func() (bool, error) {
+		switch order.Status {
+		case statusValid:
+			return true, nil
+		case statusInvalid:
+			return false, fmt.Errorf("invalid order: %v", order.Error)
+		}
+		_, err := c.post(orderURL, nil, order)
+		return false, err
+	})
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+
+	certificates, err := c.postAsGet(order.Certificate)
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+	if !bundle {
+		block, _ := pem.Decode(certificates)
+		if block == nil {
+			return acme.CertificateResource{}, errors.New("unable to decode the certificate")
+		}
+		certificates = pem.EncodeToMemory(block)
+	}
+
+	privateKeyPEM, err := encodePrivateKey(privateKey)
+	if err != nil {
+		return acme.CertificateResource{}, err
+	}
+	return acme.CertificateResource{
+		Domain:        domains[0],
+		CertURL:       order.Certificate,
+		CertStableURL: order.Certificate,
+		PrivateKey:    privateKeyPEM,
+		Certificate:   certificates,
+	}, nil
+}
+
+// authorize solves the DNS-01 or HTTP-01 challenge of the authorization, unless the authorization is already valid.
+func (c *clientV2) authorize(authorizationURL string) error {
+	authorization := &authorizationV2{}
+	if _, err := c.post(authorizationURL, nil, authorization); err != nil {
+		return err
+	}
+	if authorization.Status == statusValid {
+		return nil
+	}
+
+	domain := authorization.Identifier.Value
+	var challenge *challengeV2
+	for i := range authorization.Challenges {
+		if authorization.Challenges[i].Type == c.challenge {
+			challenge = &authorization.Challenges[i]
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no %s challenge offered for the domain %s", c.challenge, domain)
+	}
+
+	keyAuth := challenge.Token + "." + c.thumbprint
+	if err := c.provider.Present(domain, challenge.Token, keyAuth); err != nil {
+		return fmt.Errorf("unable to present the %s challenge of the domain %s: %v", c.challenge, domain, err)
+	}
+	defer func() {
+		if err := c.provider.CleanUp(domain, challenge.Token, keyAuth); err != nil {
+			log.Errorf("Unable to clean up the %s challenge of the domain %s: %v", c.challenge, domain, err)
+		}
+	}()
+
+	timeout, interval := challengeProviderTimeout(c.provider)
+	if c.challenge == challengeDNS01 {
+		fqdn, value, _ := acme.DNS01Record(domain, keyAuth)
+		log.Debugf("Checking the propagation of the DNS challenge record %s", fqdn)
+		err := acme.WaitFor(timeout, interval, func() (bool, error) {
+			return acme.PreCheckDNS(fqdn, value)
+		})
+		if err != nil {
+			return fmt.Errorf("DNS challenge record %s not propagated: %v", fqdn, err)
+		}
+	}
+
+	if _, err := c.post(challenge.URL, struct{}{}, nil); err != nil {
+		return err
+	}
+
+	return acme.WaitFor(timeout, c.pollInterval, func() (bool, error) {
+		if _, err := c.post(authorizationURL, nil, authorization); err != nil {
+			return false, err
+		}
+		switch authorization.Status {
+		case statusValid:
+			return true, nil
+		case statusInvalid:
+			for _, challenge := range authorization.Challenges {
+				if challenge.Error != nil {
+					return false, fmt.Errorf("invalid authorization for the domain %s: %v", domain, challenge.Error)
+				}
+			}
+			return false, fmt.Errorf("invalid authorization for the domain %s", domain)
+		}
+		return false, nil
+	})
+}
+
+func (c *clientV2) ensureAccount() error {
+	c.lock.Lock()
+	keyID := c.keyID
+	c.lock.Unlock()
+
+	if len(keyID) > 0 {
+		return nil
+	}
+	_, err := c.Register()
+	return err
+}
+
+// postAsGet fetches a resource with a POST request without payload.
+func (c *clientV2) postAsGet(url string) ([]byte, error) {
+	var body []byte
+	_, err := c.post(url, nil, &body)
+	return body, err
+}
+
+// post sends a payload signed by the account key, and decodes the response in result, if any.
+// The payload is empty when nil, for the POST-as-GET requests.
+// The request is sent again once with a new nonce if the CA server rejects the nonce.
+func (c *clientV2) post(url string, payload interface{}, result interface{}) (http.Header, error) {
+	header, err := c.doPost(url, payload, result)
+	if problem, ok := err.(*problemV2); ok && problem.Type == problemBadNonce {
+		return c.doPost(url, payload, result)
+	}
+	return header, err
+}
+
+func (c *clientV2) doPost(url string, payload interface{}, result interface{}) (http.Header, error) {
+	content, err := c.sign(url, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Post(url, "application/jose+json", bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	c.pushNonce(resp.Header.Get("Replay-Nonce"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := &problemV2{}
+		if err := json.Unmarshal(body, problem); err != nil || len(problem.Type) == 0 {
+			return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		return nil, problem
+	}
+
+	switch r := result.(type) {
+	case nil:
+	case *[]byte:
+		*r = body
+	default:
+		if err := json.Unmarshal(body, result); err != nil {
+			return nil, fmt.Errorf("unable to decode the response of %s: %v", url, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// sign returns the payload as a JWS with the flattened JSON serialization,
+// the account key being identified by its URL once the account is known.
+func (c *clientV2) sign(url string, payload interface{}) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+
+	protected := map[string]interface{}{
+		"alg":   "RS256",
+		"nonce": nonce,
+		"url":   url,
+	}
+	c.lock.Lock()
+	keyID := c.keyID
+	c.lock.Unlock()
+	if len(keyID) > 0 && url != c.directory.NewAccount {
+		protected["kid"] = keyID
+	} else {
+		protected["jwk"] = c.jwk
+	}
+	protectedJSON, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	var payloadJSON []byte
+	if payload != nil {
+		payloadJSON, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	encodedProtected := base64.RawURLEncoding.EncodeToString(protectedJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+	digest := sha256.Sum256([]byte(encodedProtected + "." + encodedPayload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		"protected": encodedProtected,
+		"payload":   encodedPayload,
+		"signature": base64.RawURLEncoding.EncodeToString(signature),
+	})
+}
+
+// signExternalAccountBinding returns the JWS binding the account key to the external account, signed with its HMAC key.
+func (c *clientV2) signExternalAccountBinding() ([]byte, error) {
+	protectedJSON, err := json.Marshal(map[string]string{
+		"alg": "HS256",
+		"kid": c.eabKeyID,
+		"url": c.directory.NewAccount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encodedProtected := base64.RawURLEncoding.EncodeToString(protectedJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(c.jwk)
+	mac := hmac.New(sha256.New, c.eabKey)
+	mac.Write([]byte(encodedProtected + "." + encodedPayload))
+
+	return json.Marshal(map[string]string{
+		"protected": encodedProtected,
+		"payload":   encodedPayload,
+		"signature": base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+	})
+}
+
+func (c *clientV2) nonce() (string, error) {
+	c.lock.Lock()
+	if len(c.nonces) > 0 {
+		nonce := c.nonces[len(c.nonces)-1]
+		c.nonces = c.nonces[:len(c.nonces)-1]
+		c.lock.Unlock()
+		return nonce, nil
+	}
+	c.lock.Unlock()
+
+	resp, err := c.httpClient.Head(c.directory.NewNonce)
+	if err != nil {
+		return "", fmt.Errorf("unable to get a nonce: %v", err)
+	}
+	resp.Body.Close()
+
+	nonce := resp.Header.Get("Replay-Nonce")
+	if len(nonce) == 0 {
+		return "", errors.New("no nonce in the response of the CA server")
+	}
+	return nonce, nil
+}
+
+func (c *clientV2) pushNonce(nonce string) {
+	if len(nonce) == 0 {
+		return
+	}
+	c.lock.Lock()
+	c.nonces = append(c.nonces, nonce)
+	c.lock.Unlock()
+}
+
+// challengeProviderTimeout returns the propagation timeout and interval of the DNS provider, with the defaults of lego.
+func challengeProviderTimeout(provider acme.ChallengeProvider) (time.Duration, time.Duration) {
+	if p, ok := provider.(acme.ChallengeProviderTimeout); ok {
+		return p.Timeout()
+	}
+	return 60 * time.Second, 2 * time.Second
+}
+
+func createCSR(privateKey crypto.PrivateKey, domains []string, mustStaple bool) ([]byte, error) {
+	template := x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}
+	if mustStaple {
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+			Id:    tlsFeatureExtensionOID,
+			Value: ocspMustStapleFeature,
+		})
+	}
+	return x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
+}
+
+func encodePrivateKey(privateKey crypto.PrivateKey) ([]byte, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+	case *ecdsa.PrivateKey:
+		keyBytes, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+}
+
+func parsePrivateKey(content []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("unable to decode the private key")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("unsupported private key type %s", block.Type)
+}
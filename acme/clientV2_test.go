@@ -0,0 +1,439 @@
+package acme
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xenolf/lego/acme"
+)
+
+// fakeDNSProvider records the DNS challenges presented.
+type fakeDNSProvider struct {
+	mutex    sync.Mutex
+	records  map[string][]string
+	cleanUps int
+}
+
+func (p *fakeDNSProvider) Present(domain, token, keyAuth string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.records[domain] = append(p.records[domain], keyAuth)
+	return nil
+}
+
+func (p *fakeDNSProvider) CleanUp(domain, token, keyAuth string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.cleanUps++
+	return nil
+}
+
+func (p *fakeDNSProvider) Timeout() (time.Duration, time.Duration) {
+	return time.Second, 10 * time.Millisecond
+}
+
+func (p *fakeDNSProvider) presented(domain, keyAuth string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, record := range p.records[domain] {
+		if record == keyAuth {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeCAServer implements the parts of ACME v2 used by clientV2, with a single order,
+// and checks the signatures of the requests.
+type fakeCAServer struct {
+	t          *testing.T
+	url        string
+	accountKey *rsa.PublicKey
+	thumbprint string
+	provider   *fakeDNSProvider
+	caCert     *x509.Certificate
+	caKey      *rsa.PrivateKey
+
+	mutex          sync.Mutex
+	nonce          int
+	authorizations []*authorizationV2
+	order          *orderV2
+	certificate    []byte
+}
+
+func (s *fakeCAServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nonce++
+	rw.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", s.nonce))
+
+	switch {
+	case req.URL.Path == "/directory":
+		json.NewEncoder(rw).Encode(directoryV2{
+			NewNonce:   s.url + "/nonce",
+			NewAccount: s.url + "/account",
+			NewOrder:   s.url + "/order",
+		})
+	case req.URL.Path == "/nonce":
+	case req.URL.Path == "/account":
+		s.verify(req, true)
+		rw.Header().Set("Location", s.url+"/account/1")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte(`{"status":"valid"}`))
+	case req.URL.Path == "/order":
+		request := &orderV2{}
+		require.NoError(s.t, json.Unmarshal(s.verify(req, false), request))
+		s.order = &orderV2{Status: "pending", Identifiers: request.Identifiers, Finalize: s.url + "/finalize"}
+		for i, identifier := range request.Identifiers {
+			authorization := &authorizationV2{
+				Status:     "pending",
+				Identifier: identifierV2{Type: "dns", Value: strings.TrimPrefix(identifier.Value, "*.")},
+				Wildcard:   isWildcard(identifier.Value),
+				Challenges: []challengeV2{
+					{Type: "http-01", URL: fmt.Sprintf("%s/challenge/http/%d", s.url, i), Token: "http"},
+					{Type: "dns-01", URL: fmt.Sprintf("%s/challenge/%d", s.url, i), Token: fmt.Sprintf("token-%d", i)},
+				},
+			}
+			s.authorizations = append(s.authorizations, authorization)
+			s.order.Authorizations = append(s.order.Authorizations, fmt.Sprintf("%s/authz/%d", s.url, i))
+		}
+		rw.Header().Set("Location", s.url+"/order/1")
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(s.order)
+	case strings.HasPrefix(req.URL.Path, "/authz/"):
+		s.verify(req, false)
+		json.NewEncoder(rw).Encode(s.authorizations[s.index(req.URL.Path)])
+	case strings.HasPrefix(req.URL.Path, "/challenge/"):
+		s.verify(req, false)
+		authorization := s.authorizations[s.index(req.URL.Path)]
+		challenge := authorization.Challenges[1]
+		if strings.HasPrefix(req.URL.Path, "/challenge/http/") {
+			challenge = authorization.Challenges[0]
+		}
+		keyAuth := challenge.Token + "." + s.thumbprint
+		if s.provider.presented(authorization.Identifier.Value, keyAuth) {
+			authorization.Status = statusValid
+		} else {
+			authorization.Status = statusInvalid
+		}
+		rw.Write([]byte(`{}`))
+	case req.URL.Path == "/finalize":
+		request := map[string]string{}
+		require.NoError(s.t, json.Unmarshal(s.verify(req, false), &request))
+		for _, authorization := range s.authorizations {
+			if authorization.Status != statusValid {
+				rw.WriteHeader(http.StatusForbidden)
+				rw.Write([]byte(`{"type":"urn:ietf:params:acme:error:orderNotReady","detail":"authorizations not valid"}`))
+				return
+			}
+		}
+		csrBytes, err := base64.RawURLEncoding.DecodeString(request["csr"])
+		require.NoError(s.t, err)
+		s.certificate = s.issue(csrBytes)
+		s.order.Status = "processing"
+		json.NewEncoder(rw).Encode(s.order)
+	case req.URL.Path == "/order/1":
+		s.verify(req, false)
+		if s.order.Status == "processing" {
+			s.order.Status = statusValid
+			s.order.Certificate = s.url + "/certificate"
+		}
+		json.NewEncoder(rw).Encode(s.order)
+	case req.URL.Path == "/certificate":
+		s.verify(req, false)
+		rw.Header().Set("Content-Type", "application/pem-certificate-chain")
+		rw.Write(s.certificate)
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *fakeCAServer) index(path string) int {
+	var index int
+	_, err := fmt.Sscanf(path[strings.LastIndex(path, "/")+1:], "%d", &index)
+	require.NoError(s.t, err)
+	return index
+}
+
+// verify checks the JWS of the request and returns its payload.
+func (s *fakeCAServer) verify(req *http.Request, withJWK bool) []byte {
+	body := map[string]string{}
+	require.NoError(s.t, json.NewDecoder(req.Body).Decode(&body))
+
+	protectedJSON, err := base64.RawURLEncoding.DecodeString(body["protected"])
+	require.NoError(s.t, err)
+	protected := map[string]interface{}{}
+	require.NoError(s.t, json.Unmarshal(protectedJSON, &protected))
+
+	assert.Equal(s.t, "RS256", protected["alg"])
+	assert.Equal(s.t, s.url+req.URL.Path, protected["url"])
+	assert.NotEmpty(s.t, protected["nonce"])
+	if withJWK {
+		assert.Contains(s.t, protected, "jwk")
+		assert.NotContains(s.t, protected, "kid")
+	} else {
+		assert.Equal(s.t, s.url+"/account/1", protected["kid"])
+		assert.NotContains(s.t, protected, "jwk")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(body["signature"])
+	require.NoError(s.t, err)
+	digest := sha256.Sum256([]byte(body["protected"] + "." + body["payload"]))
+	require.NoError(s.t, rsa.VerifyPKCS1v15(s.accountKey, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(body["payload"])
+	require.NoError(s.t, err)
+	return payload
+}
+
+func (s *fakeCAServer) issue(csrBytes []byte) []byte {
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	require.NoError(s.t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey)
+	require.NoError(s.t, err)
+
+	certificates := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(certificates, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...)
+}
+
+// startFakeCAServer starts a fakeCAServer validating the challenges presented to the provider,
+// and returns it with a client registered with it.
+func startFakeCAServer(t *testing.T, provider *fakeDNSProvider) (*httptest.Server, *clientV2) {
+	accountKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	caServer := &fakeCAServer{
+		t:          t,
+		accountKey: &accountKey.PublicKey,
+		provider:   provider,
+		caCert:     caCert,
+		caKey:      caKey,
+	}
+	ts := httptest.NewServer(caServer)
+	caServer.url = ts.URL
+
+	directory, err := getDirectoryV2(ts.Client(), ts.URL+"/directory")
+	require.NoError(t, err)
+	require.NotNil(t, directory)
+
+	account := &Account{Email: "test@traefik.io", PrivateKey: x509.MarshalPKCS1PrivateKey(accountKey)}
+	client, err := newClientV2(ts.Client(), directory, account, provider, nil)
+	require.NoError(t, err)
+	client.certificateKeyBits = 2048
+	client.pollInterval = 10 * time.Millisecond
+	caServer.thumbprint = client.thumbprint
+
+	registration, err := client.Register()
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/account/1", registration.URI)
+	assert.Equal(t, []string{"mailto:test@traefik.io"}, registration.Body.Contact)
+	return ts, client
+}
+
+func TestClientV2ObtainCertificate(t *testing.T) {
+	preCheckDNS := acme.PreCheckDNS
+	acme.PreCheckDNS = func(_, _ string) (bool, error) {
+		return true, nil
+	}
+	defer func() {
+		acme.PreCheckDNS = preCheckDNS
+	}()
+
+	provider := &fakeDNSProvider{records: make(map[string][]string)}
+	ts, client := startFakeCAServer(t, provider)
+	defer ts.Close()
+
+	certificate, failures := client.ObtainCertificate([]string{"*.example.com", "example.com"}, true, nil, false)
+	require.Empty(t, failures)
+
+	assert.Equal(t, "*.example.com", certificate.Domain)
+	assert.Equal(t, ts.URL+"/certificate", certificate.CertURL)
+	// the wildcard and the domain itself are both validated with a record of the domain
+	assert.Len(t, provider.records["example.com"], 2)
+	assert.Equal(t, 2, provider.cleanUps)
+
+	leafBlock, rest := pem.Decode(certificate.Certificate)
+	require.NotNil(t, leafBlock)
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "*.example.com", leaf.Subject.CommonName)
+	assert.Equal(t, []string{"*.example.com", "example.com"}, leaf.DNSNames)
+	chainBlock, _ := pem.Decode(rest)
+	assert.NotNil(t, chainBlock, "the certificate should be bundled with the chain")
+
+	privateKey, err := parsePrivateKey(certificate.PrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, &privateKey.(*rsa.PrivateKey).PublicKey, leaf.PublicKey)
+}
+
+func TestClientV2ObtainCertificateWithHTTPChallenge(t *testing.T) {
+	preCheckDNS := acme.PreCheckDNS
+	acme.PreCheckDNS = func(_, _ string) (bool, error) {
+		t.Fatal("no DNS record is checked with the HTTP challenge")
+		return false, nil
+	}
+	defer func() {
+		acme.PreCheckDNS = preCheckDNS
+	}()
+
+	provider := &fakeDNSProvider{records: make(map[string][]string)}
+	ts, client := startFakeCAServer(t, provider)
+	defer ts.Close()
+	client.challenge = challengeHTTP01
+
+	certificate, failures := client.ObtainCertificate([]string{"example.com"}, true, nil, false)
+	require.Empty(t, failures)
+	assert.Equal(t, "example.com", certificate.Domain)
+	assert.Equal(t, []string{"http." + client.thumbprint}, provider.records["example.com"])
+	assert.Equal(t, 1, provider.cleanUps)
+}
+
+func TestClientV2RegisterWithEAB(t *testing.T) {
+	accountKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	hmacKey := []byte("external account HMAC key")
+
+	var binding map[string]string
+	var accountJWK json.RawMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Replay-Nonce", "nonce")
+		if req.Method == http.MethodHead {
+			return
+		}
+
+		body := map[string]string{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		protectedJSON, err := base64.RawURLEncoding.DecodeString(body["protected"])
+		require.NoError(t, err)
+		protected := map[string]json.RawMessage{}
+		require.NoError(t, json.Unmarshal(protectedJSON, &protected))
+		accountJWK = protected["jwk"]
+
+		payload, err := base64.RawURLEncoding.DecodeString(body["payload"])
+		require.NoError(t, err)
+		account := &accountV2{}
+		require.NoError(t, json.Unmarshal(payload, account))
+		require.NoError(t, json.Unmarshal(account.ExternalAccountBinding, &binding))
+
+		rw.Header().Set("Location", "https://ca/account/1")
+		rw.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	directory := &directoryV2{NewNonce: ts.URL + "/nonce", NewAccount: ts.URL + "/account", NewOrder: ts.URL + "/order"}
+	directory.Meta.ExternalAccountRequired = true
+	account := &Account{Email: "test@traefik.io", PrivateKey: x509.MarshalPKCS1PrivateKey(accountKey)}
+
+	_, err = newClientV2(ts.Client(), directory, account, &fakeDNSProvider{}, nil)
+	assert.Error(t, err, "the CA server requires an external account binding")
+
+	_, err = newClientV2(ts.Client(), directory, account, &fakeDNSProvider{}, &EAB{KID: "kid-1", HMAC: "not base64!"})
+	assert.Error(t, err)
+
+	eab := &EAB{KID: "kid-1", HMAC: base64.URLEncoding.EncodeToString(hmacKey)}
+	client, err := newClientV2(ts.Client(), directory, account, &fakeDNSProvider{}, eab)
+	require.NoError(t, err)
+
+	_, err = client.Register()
+	require.NoError(t, err)
+	require.NotNil(t, binding)
+
+	protectedJSON, err := base64.RawURLEncoding.DecodeString(binding["protected"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg": "HS256", "kid": "kid-1", "url": "`+ts.URL+`/account"}`, string(protectedJSON))
+
+	payload, err := base64.RawURLEncoding.DecodeString(binding["payload"])
+	require.NoError(t, err)
+	assert.JSONEq(t, string(accountJWK), string(payload), "the binding should sign the account key")
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(binding["protected"] + "." + binding["payload"]))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), binding["signature"])
+}
+
+func TestGetDirectoryV2(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		directory string
+		expected  *directoryV2
+	}{
+		{
+			desc:      "ACME v1 directory",
+			directory: `{"new-authz": "https://foo/acme/new-authz", "new-cert": "https://foo/acme/new-cert", "new-reg": "https://foo/acme/new-reg"}`,
+		},
+		{
+			desc:      "ACME v2 directory",
+			directory: `{"newNonce": "https://foo/acme/new-nonce", "newAccount": "https://foo/acme/new-acct", "newOrder": "https://foo/acme/new-order", "meta": {"termsOfService": "https://foo/tos"}}`,
+			expected: func() *directoryV2 {
+				directory := &directoryV2{
+					NewNonce:   "https://foo/acme/new-nonce",
+					NewAccount: "https://foo/acme/new-acct",
+					NewOrder:   "https://foo/acme/new-order",
+				}
+				directory.Meta.TermsOfService = "https://foo/tos"
+				return directory
+			}(),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte(test.directory))
+			}))
+			defer ts.Close()
+
+			directory, err := getDirectoryV2(ts.Client(), ts.URL)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, directory)
+		})
+	}
+}
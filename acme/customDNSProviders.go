@@ -0,0 +1,179 @@
+package acme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xenolf/lego/acme"
+	"github.com/xenolf/lego/providers/dns"
+)
+
+const (
+	// execDNSProviderName is the code of the DNS challenge provider running an external program
+	execDNSProviderName = "exec"
+	// httpreqDNSProviderName is the code of the DNS challenge provider calling an HTTP webhook
+	httpreqDNSProviderName = "httpreq"
+
+	// rawMode passes the domain, token and key authorization of the challenge to the custom DNS providers,
+	// instead of the FQDN and value of the TXT record
+	rawMode = "RAW"
+
+	httpreqTimeout = 30 * time.Second
+)
+
+// newDNSChallengeProviderByName returns the DNS challenge provider of the code,
+// the custom providers being handled before the ones of lego.
+func newDNSChallengeProviderByName(name string) (acme.ChallengeProvider, error) {
+	switch name {
+	case execDNSProviderName:
+		return newExecDNSProvider()
+	case httpreqDNSProviderName:
+		return newHTTPReqDNSProvider()
+	default:
+		return dns.NewDNSChallengeProviderByName(name)
+	}
+}
+
+// execDNSProvider presents and cleans up the TXT records of the DNS challenges by running an external program:
+// "<program> present|cleanup <fqdn> <value> <ttl>", or "<program> present|cleanup -- <domain> <token> <keyAuth>" in raw mode.
+type execDNSProvider struct {
+	program string
+	raw     bool
+}
+
+// newExecDNSProvider returns an execDNSProvider configured with the environment variables EXEC_PATH and EXEC_MODE.
+func newExecDNSProvider() (*execDNSProvider, error) {
+	program := os.Getenv("EXEC_PATH")
+	if len(program) == 0 {
+		return nil, errors.New("exec DNS provider: the program to run is missing, set EXEC_PATH")
+	}
+	return &execDNSProvider{
+		program: program,
+		raw:     strings.EqualFold(os.Getenv("EXEC_MODE"), rawMode),
+	}, nil
+}
+
+// Present creates the TXT record of the challenge.
+func (p *execDNSProvider) Present(domain, token, keyAuth string) error {
+	return p.run("present", domain, token, keyAuth)
+}
+
+// CleanUp removes the TXT record of the challenge.
+func (p *execDNSProvider) CleanUp(domain, token, keyAuth string) error {
+	return p.run("cleanup", domain, token, keyAuth)
+}
+
+func (p *execDNSProvider) run(command, domain, token, keyAuth string) error {
+	var args []string
+	if p.raw {
+		args = []string{command, "--", domain, token, keyAuth}
+	} else {
+		fqdn, value, ttl := acme.DNS01Record(domain, keyAuth)
+		args = []string{command, fqdn, value, strconv.Itoa(ttl)}
+	}
+
+	output, err := exec.Command(p.program, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("exec DNS provider: %s %s failed: %v: %s", p.program, command, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// httpreqDNSProvider presents and cleans up the TXT records of the DNS challenges by posting them to an HTTP webhook:
+// {"fqdn": ..., "value": ...}, or {"domain": ..., "token": ..., "keyAuth": ...} in raw mode, to <endpoint>/present and <endpoint>/cleanup.
+type httpreqDNSProvider struct {
+	endpoint *url.URL
+	raw      bool
+	username string
+	password string
+	client   *http.Client
+}
+
+type httpreqMessage struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+}
+
+type httpreqRawMessage struct {
+	Domain  string `json:"domain"`
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+}
+
+// newHTTPReqDNSProvider returns an httpreqDNSProvider configured with the environment variables
+// HTTPREQ_ENDPOINT, HTTPREQ_MODE, HTTPREQ_USERNAME and HTTPREQ_PASSWORD.
+func newHTTPReqDNSProvider() (*httpreqDNSProvider, error) {
+	rawEndpoint := os.Getenv("HTTPREQ_ENDPOINT")
+	if len(rawEndpoint) == 0 {
+		return nil, errors.New("httpreq DNS provider: the webhook endpoint is missing, set HTTPREQ_ENDPOINT")
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("httpreq DNS provider: invalid endpoint %s: %v", rawEndpoint, err)
+	}
+	return &httpreqDNSProvider{
+		endpoint: endpoint,
+		raw:      strings.EqualFold(os.Getenv("HTTPREQ_MODE"), rawMode),
+		username: os.Getenv("HTTPREQ_USERNAME"),
+		password: os.Getenv("HTTPREQ_PASSWORD"),
+		client:   &http.Client{Timeout: httpreqTimeout},
+	}, nil
+}
+
+// Present creates the TXT record of the challenge.
+func (p *httpreqDNSProvider) Present(domain, token, keyAuth string) error {
+	return p.post("present", domain, token, keyAuth)
+}
+
+// CleanUp removes the TXT record of the challenge.
+func (p *httpreqDNSProvider) CleanUp(domain, token, keyAuth string) error {
+	return p.post("cleanup", domain, token, keyAuth)
+}
+
+func (p *httpreqDNSProvider) post(command, domain, token, keyAuth string) error {
+	var message interface{}
+	if p.raw {
+		message = httpreqRawMessage{Domain: domain, Token: token, KeyAuth: keyAuth}
+	} else {
+		fqdn, value, _ := acme.DNS01Record(domain, keyAuth)
+		message = httpreqMessage{FQDN: fqdn, Value: value}
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	endpoint := *p.endpoint
+	endpoint.Path = path.Join(endpoint.Path, command)
+	req, err := http.NewRequest(http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(p.username) > 0 || len(p.password) > 0 {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("httpreq DNS provider: %s failed: %v", command, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		content, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("httpreq DNS provider: %s failed with status %d: %s", command, resp.StatusCode, bytes.TrimSpace(content))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package acme
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xenolf/lego/acme"
+)
+
+func TestExecDNSProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test program is a shell script")
+	}
+
+	directory, err := ioutil.TempDir("", "traefik-exec-dns")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	output := filepath.Join(directory, "output")
+	program := filepath.Join(directory, "dns.sh")
+	script := "#!/bin/sh\necho \"$@\" >> " + output + "\ncase \"$*\" in *fail.example.com*) echo \"unknown zone\"; exit 1;; esac\n"
+	require.NoError(t, ioutil.WriteFile(program, []byte(script), 0700))
+
+	fqdn, value, _ := acme.DNS01Record("example.com", "keyAuth")
+
+	testCases := []struct {
+		desc          string
+		raw           bool
+		domain        string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			desc:   "record",
+			domain: "example.com",
+			expected: []string{
+				"present " + fqdn + " " + value + " 120",
+				"cleanup " + fqdn + " " + value + " 120",
+			},
+		},
+		{
+			desc:   "raw",
+			raw:    true,
+			domain: "example.com",
+			expected: []string{
+				"present -- example.com token keyAuth",
+				"cleanup -- example.com token keyAuth",
+			},
+		},
+		{
+			desc:          "failure",
+			raw:           true,
+			domain:        "fail.example.com",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			os.Remove(output)
+			provider := &execDNSProvider{program: program, raw: test.raw}
+
+			err := provider.Present(test.domain, "token", "keyAuth")
+			if test.expectedError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "unknown zone")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, provider.CleanUp(test.domain, "token", "keyAuth"))
+
+			content, err := ioutil.ReadFile(output)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, strings.Split(strings.TrimSpace(string(content)), "\n"))
+		})
+	}
+}
+
+func TestHTTPReqDNSProvider(t *testing.T) {
+	var requests []string
+	var bodies []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		if username != "user" || password != "secret" {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body := map[string]string{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	os.Setenv("HTTPREQ_ENDPOINT", server.URL+"/dns")
+	os.Setenv("HTTPREQ_USERNAME", "user")
+	os.Setenv("HTTPREQ_PASSWORD", "secret")
+	defer func() {
+		os.Unsetenv("HTTPREQ_ENDPOINT")
+		os.Unsetenv("HTTPREQ_USERNAME")
+		os.Unsetenv("HTTPREQ_PASSWORD")
+		os.Unsetenv("HTTPREQ_MODE")
+	}()
+
+	provider, err := newDNSChallengeProviderByName("httpreq")
+	require.NoError(t, err)
+	require.NoError(t, provider.Present("example.com", "token", "keyAuth"))
+	require.NoError(t, provider.CleanUp("example.com", "token", "keyAuth"))
+
+	os.Setenv("HTTPREQ_MODE", "raw")
+	provider, err = newDNSChallengeProviderByName("httpreq")
+	require.NoError(t, err)
+	require.NoError(t, provider.Present("example.com", "token", "keyAuth"))
+
+	fqdn, value, _ := acme.DNS01Record("example.com", "keyAuth")
+	assert.Equal(t, []string{"POST /dns/present", "POST /dns/cleanup", "POST /dns/present"}, requests)
+	assert.Equal(t, []map[string]string{
+		{"fqdn": fqdn, "value": value},
+		{"fqdn": fqdn, "value": value},
+		{"domain": "example.com", "token": "token", "keyAuth": "keyAuth"},
+	}, bodies)
+
+	os.Setenv("HTTPREQ_PASSWORD", "wrong")
+	provider, err = newDNSChallengeProviderByName("httpreq")
+	require.NoError(t, err)
+	err = provider.Present("example.com", "token", "keyAuth")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestCustomDNSProvidersConfiguration(t *testing.T) {
+	os.Unsetenv("EXEC_PATH")
+	os.Unsetenv("HTTPREQ_ENDPOINT")
+
+	_, err := newDNSChallengeProviderByName("exec")
+	assert.Error(t, err, "exec without EXEC_PATH")
+
+	_, err = newDNSChallengeProviderByName("httpreq")
+	assert.Error(t, err, "httpreq without HTTPREQ_ENDPOINT")
+
+	_, err = newDNSChallengeProviderByName("unknown")
+	assert.Error(t, err)
+}
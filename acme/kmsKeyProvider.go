@@ -0,0 +1,116 @@
+package acme
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+const kmsServiceName = "kms"
+
+var _ dataKeyProvider = (*kmsKeyProvider)(nil)
+
+// kmsKeyProvider generates the data keys with an AWS KMS key, which decrypts them.
+type kmsKeyProvider struct {
+	client *client.Client
+	keyID  string
+}
+
+func newKMSKeyProvider(encryption *KMSEncryption) (*kmsKeyProvider, error) {
+	if len(encryption.KeyID) == 0 {
+		return nil, errors.New("the KMS encryption of the ACME storage requires a key ID")
+	}
+	sess, err := newAWSSession(encryption.Region)
+	if err != nil {
+		return nil, err
+	}
+	return newKMSKeyProviderWithConfig(sess, encryption.KeyID), nil
+}
+
+// The KMS API isn't part of the vendored AWS SDK, the client only has the operations used by the storage encryption.
+func newKMSKeyProviderWithConfig(configProvider client.ConfigProvider, keyID string, configs ...*aws.Config) *kmsKeyProvider {
+	config := configProvider.ClientConfig(kmsServiceName, configs...)
+	c := client.New(
+		*config.Config,
+		metadata.ClientInfo{
+			ServiceName:   kmsServiceName,
+			SigningName:   config.SigningName,
+			SigningRegion: config.SigningRegion,
+			Endpoint:      config.Endpoint,
+			APIVersion:    "2014-11-01",
+			JSONVersion:   "1.1",
+			TargetPrefix:  "TrentService",
+		},
+		config.Handlers,
+	)
+	c.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	c.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	c.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	c.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	c.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return &kmsKeyProvider{
+		client: c,
+		keyID:  keyID,
+	}
+}
+
+type generateDataKeyInput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId   *string `type:"string"`
+	KeySpec *string `type:"string"`
+}
+
+type generateDataKeyOutput struct {
+	_ struct{} `type:"structure"`
+
+	CiphertextBlob []byte `type:"blob"`
+	Plaintext      []byte `type:"blob"`
+}
+
+type decryptInput struct {
+	_ struct{} `type:"structure"`
+
+	CiphertextBlob []byte `type:"blob"`
+}
+
+type decryptOutput struct {
+	_ struct{} `type:"structure"`
+
+	Plaintext []byte `type:"blob"`
+}
+
+func (p *kmsKeyProvider) send(operation string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       operation,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return p.client.NewRequest(op, input, output).Send()
+}
+
+func (p *kmsKeyProvider) generate() ([]byte, []byte, error) {
+	output := &generateDataKeyOutput{}
+	err := p.send("GenerateDataKey", &generateDataKeyInput{
+		KeyId:   aws.String(p.keyID),
+		KeySpec: aws.String("AES_256"),
+	}, output)
+	if err != nil {
+		return nil, nil, err
+	}
+	return output.Plaintext, output.CiphertextBlob, nil
+}
+
+func (p *kmsKeyProvider) decrypt(encryptedKey []byte) ([]byte, error) {
+	output := &decryptOutput{}
+	if err := p.send("Decrypt", &decryptInput{CiphertextBlob: encryptedKey}, output); err != nil {
+		return nil, err
+	}
+	return output.Plaintext, nil
+}
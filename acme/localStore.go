@@ -1,7 +1,6 @@
 package acme
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -18,6 +17,7 @@ type LocalStore struct {
 	file        string
 	storageLock sync.RWMutex
 	account     *Account
+	cipher      *storageCipher
 }
 
 // NewLocalStore create a LocalStore
@@ -27,6 +27,17 @@ func NewLocalStore(file string) *LocalStore {
 	}
 }
 
+func (s *LocalStore) exists() (bool, error) {
+	fileInfo, err := os.Stat(s.file)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return fileInfo.Size() != 0, nil
+}
+
 // Get atomically a struct from the file storage
 func (s *LocalStore) Get() cluster.Object {
 	s.storageLock.RLock()
@@ -38,8 +49,6 @@ func (s *LocalStore) Get() cluster.Object {
 func (s *LocalStore) Load() (cluster.Object, error) {
 	s.storageLock.Lock()
 	defer s.storageLock.Unlock()
-	account := &Account{}
-
 	err := checkPermissions(s.file)
 	if err != nil {
 		return nil, err
@@ -53,7 +62,8 @@ func (s *LocalStore) Load() (cluster.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(file, &account); err != nil {
+	account, err := unmarshalAccount(file, s.cipher)
+	if err != nil {
 		return nil, err
 	}
 	account.Init()
@@ -84,7 +94,7 @@ func (t *localTransaction) Commit(object cluster.Object) error {
 	}
 
 	// write account to file
-	data, err := json.MarshalIndent(object, "", "  ")
+	data, err := marshalAccount(object, t.cipher)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,143 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultOnDemandRejectionTTL is the duration a rejected domain is rejected without being checked again.
+	defaultOnDemandRejectionTTL = 10 * time.Minute
+	// maxOnDemandRejections bounds the number of rejected domains remembered, against random host names.
+	maxOnDemandRejections = 10000
+)
+
+// OnDemandPolicy restricts the on demand certificates to the allowed domains, at a limited rate,
+// for a host name pointed at Træfik not to use up the ACME quota.
+type OnDemandPolicy struct {
+	DomainSuffixes []string `description:"Domains allowed to get an on demand certificate, with their sub-domains. Defaults to any domain."`
+	DomainRegexps  []string `description:"Regular expressions matching the domains allowed to get an on demand certificate."`
+	Rate           int      `description:"Number of on demand certificates requested per hour. Defaults to no limit."`
+	Burst          int      `description:"Maximum number of on demand certificates requested at once. Defaults to 1."`
+	RejectionTTL   int      `description:"Duration in seconds a rejected domain is rejected without being checked again. Defaults to 600."`
+}
+
+// onDemandGuard decides whether an on demand certificate is requested for a domain.
+type onDemandGuard struct {
+	suffixes     []string
+	regexps      []*regexp.Regexp
+	limiter      *rate.Limiter
+	rejectionTTL time.Duration
+	lock         sync.Mutex
+	rejections   map[string]time.Time
+}
+
+func newOnDemandGuard(policy *OnDemandPolicy) (*onDemandGuard, error) {
+	guard := &onDemandGuard{
+		limiter:      rate.NewLimiter(rate.Inf, 0),
+		rejectionTTL: defaultOnDemandRejectionTTL,
+		rejections:   make(map[string]time.Time),
+	}
+	if policy == nil {
+		return guard, nil
+	}
+	if policy.Rate < 0 || policy.Burst < 0 || policy.RejectionTTL < 0 {
+		return nil, errors.New("the on demand rate, burst and rejection TTL must not be negative")
+	}
+	for _, suffix := range policy.DomainSuffixes {
+		suffix = strings.TrimPrefix(strings.TrimPrefix(types.CanonicalDomain(suffix), "*"), ".")
+		if len(suffix) > 0 {
+			guard.suffixes = append(guard.suffixes, suffix)
+		}
+	}
+	for _, expression := range policy.DomainRegexps {
+		domainRegexp, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid on demand domain regexp %q: %v", expression, err)
+		}
+		guard.regexps = append(guard.regexps, domainRegexp)
+	}
+	if policy.Rate > 0 {
+		burst := policy.Burst
+		if burst == 0 {
+			burst = 1
+		}
+		guard.limiter = rate.NewLimiter(rate.Every(time.Hour/time.Duration(policy.Rate)), burst)
+	}
+	if policy.RejectionTTL > 0 {
+		guard.rejectionTTL = time.Duration(policy.RejectionTTL) * time.Second
+	}
+	return guard, nil
+}
+
+// allow returns why no on demand certificate can be requested for the domain, nil if it can.
+func (g *onDemandGuard) allow(domain string) error {
+	if until, ok := g.rejectedUntil(domain); ok {
+		return fmt.Errorf("domain rejected until %s", until.Format(time.RFC3339))
+	}
+	if !g.allowed(domain) {
+		g.reject(domain)
+		return errors.New("domain not allowed")
+	}
+	if !g.limiter.Allow() {
+		return errors.New("rate limit reached")
+	}
+	return nil
+}
+
+// allowed checks the domain against the allowed suffixes and regexps, any domain being allowed without them.
+func (g *onDemandGuard) allowed(domain string) bool {
+	if len(g.suffixes) == 0 && len(g.regexps) == 0 {
+		return true
+	}
+	for _, suffix := range g.suffixes {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	for _, domainRegexp := range g.regexps {
+		if domainRegexp.MatchString(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *onDemandGuard) rejectedUntil(domain string) (time.Time, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	until, ok := g.rejections[domain]
+	if !ok {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(g.rejections, domain)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// reject remembers the domain as rejected for the rejection TTL.
+func (g *onDemandGuard) reject(domain string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	now := time.Now()
+	if len(g.rejections) >= maxOnDemandRejections {
+		for rejected, until := range g.rejections {
+			if now.After(until) {
+				delete(g.rejections, rejected)
+			}
+		}
+		if len(g.rejections) >= maxOnDemandRejections {
+			return
+		}
+	}
+	g.rejections[domain] = now.Add(g.rejectionTTL)
+}
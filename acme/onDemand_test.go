@@ -0,0 +1,116 @@
+package acme
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnDemandGuardAllowed(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		policy   *OnDemandPolicy
+		domain   string
+		expected bool
+	}{
+		{
+			desc:     "no policy",
+			domain:   "example.com",
+			expected: true,
+		},
+		{
+			desc:     "no allowed domains",
+			policy:   &OnDemandPolicy{Rate: 10},
+			domain:   "example.com",
+			expected: true,
+		},
+		{
+			desc:     "suffix of the domain itself",
+			policy:   &OnDemandPolicy{DomainSuffixes: []string{"Example.com"}},
+			domain:   "example.com",
+			expected: true,
+		},
+		{
+			desc:     "suffix of a sub-domain",
+			policy:   &OnDemandPolicy{DomainSuffixes: []string{"*.example.com"}},
+			domain:   "www.example.com",
+			expected: true,
+		},
+		{
+			desc:     "suffix not on a label boundary",
+			policy:   &OnDemandPolicy{DomainSuffixes: []string{".example.com"}},
+			domain:   "badexample.com",
+			expected: false,
+		},
+		{
+			desc:     "matching regexp",
+			policy:   &OnDemandPolicy{DomainSuffixes: []string{"example.org"}, DomainRegexps: []string{`^[a-z]+\.customers\.example\.com$`}},
+			domain:   "acme.customers.example.com",
+			expected: true,
+		},
+		{
+			desc:     "not matching regexp",
+			policy:   &OnDemandPolicy{DomainRegexps: []string{`^[a-z]+\.customers\.example\.com$`}},
+			domain:   "a.b.customers.example.com",
+			expected: false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			guard, err := newOnDemandGuard(test.policy)
+			require.NoError(t, err)
+
+			err = guard.allow(test.domain)
+			if test.expected {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestOnDemandGuardInvalidPolicy(t *testing.T) {
+	_, err := newOnDemandGuard(&OnDemandPolicy{DomainRegexps: []string{"("}})
+	assert.Error(t, err)
+
+	_, err = newOnDemandGuard(&OnDemandPolicy{Rate: -1})
+	assert.Error(t, err)
+}
+
+func TestOnDemandGuardRateLimit(t *testing.T) {
+	guard, err := newOnDemandGuard(&OnDemandPolicy{Rate: 1, Burst: 2})
+	require.NoError(t, err)
+
+	assert.NoError(t, guard.allow("a.example.com"))
+	assert.NoError(t, guard.allow("b.example.com"))
+	assert.EqualError(t, guard.allow("c.example.com"), "rate limit reached")
+
+	// a rate limited domain is not rejected
+	_, rejected := guard.rejectedUntil("c.example.com")
+	assert.False(t, rejected)
+}
+
+func TestOnDemandGuardRejections(t *testing.T) {
+	guard, err := newOnDemandGuard(&OnDemandPolicy{DomainSuffixes: []string{"example.com"}, RejectionTTL: 60})
+	require.NoError(t, err)
+
+	assert.EqualError(t, guard.allow("example.org"), "domain not allowed")
+	until, rejected := guard.rejectedUntil("example.org")
+	require.True(t, rejected)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), until, 5*time.Second)
+	assert.Error(t, guard.allow("example.org"))
+
+	guard.reject("example.com")
+	assert.Error(t, guard.allow("example.com"))
+
+	guard.rejections["example.com"] = time.Now().Add(-time.Second)
+	assert.NoError(t, guard.allow("example.com"))
+	assert.NotContains(t, guard.rejections, "example.com")
+}
@@ -0,0 +1,195 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+)
+
+const (
+	s3Scheme             = "s3"
+	secretsManagerScheme = "secretsmanager"
+)
+
+var (
+	errStorageNotFound = errors.New("the ACME storage does not exist")
+	errStorageConflict = errors.New("the ACME storage has been modified concurrently")
+)
+
+// accountStore is a store of the account which may not have been created yet.
+type accountStore interface {
+	cluster.Store
+	exists() (bool, error)
+}
+
+// versionedStorage reads and writes the content of a remote storage with optimistic locking.
+type versionedStorage interface {
+	// read returns the content and its version, errStorageNotFound if there is no content yet.
+	read() ([]byte, string, error)
+	// write replaces the content of the given version, or creates it if the version is empty,
+	// and returns the new version. It returns errStorageConflict if the content has another version.
+	write(content []byte, version string) (string, error)
+}
+
+// newAccountStore creates the store of the storage, a S3 object (s3://bucket/key),
+// an AWS Secrets Manager secret (secretsmanager://name) or a file, encrypted if there is a cipher.
+func newAccountStore(storage string, storageCipher *storageCipher) (accountStore, error) {
+	storageURL, err := url.Parse(storage)
+	if err != nil || (storageURL.Scheme != s3Scheme && storageURL.Scheme != secretsManagerScheme) {
+		store := NewLocalStore(storage)
+		store.cipher = storageCipher
+		return store, nil
+	}
+
+	sess, err := newAWSSession(storageURL.Query().Get("region"))
+	if err != nil {
+		return nil, err
+	}
+
+	name := storageURL.Host + storageURL.Path
+	switch storageURL.Scheme {
+	case s3Scheme:
+		key := strings.TrimPrefix(storageURL.Path, "/")
+		if len(storageURL.Host) == 0 || len(key) == 0 {
+			return nil, fmt.Errorf("invalid S3 ACME storage %s, expected s3://bucket/key", storage)
+		}
+		return newRemoteStore(storage, newS3Storage(sess, storageURL.Host, key), storageCipher), nil
+	default:
+		if len(name) == 0 {
+			return nil, fmt.Errorf("invalid Secrets Manager ACME storage %s, expected secretsmanager://name", storage)
+		}
+		return newRemoteStore(storage, newSecretsManagerStorage(sess, name), storageCipher), nil
+	}
+}
+
+// newAWSSession creates a session with the default credentials chain, in the given region,
+// the one of the environment or else the one of the EC2 instance.
+func newAWSSession(region string) (*session.Session, error) {
+	if len(region) == 0 {
+		region = os.Getenv("AWS_REGION")
+	}
+	if len(region) == 0 {
+		log.Info("No AWS region provided for the ACME storage, querying instance metadata endpoint...")
+		identity, err := ec2metadata.New(session.New()).GetInstanceIdentityDocument()
+		if err != nil {
+			return nil, err
+		}
+		region = identity.Region
+	}
+	return session.NewSession(&aws.Config{Region: aws.String(region)})
+}
+
+var _ cluster.Store = (*remoteStore)(nil)
+
+// remoteStore is a store using a remote versioned storage, shared by several Traefik instances.
+// A transaction reloads the account, and its commit fails if another instance committed in the meantime.
+type remoteStore struct {
+	name        string
+	storage     versionedStorage
+	storageLock sync.RWMutex
+	account     *Account
+	version     string
+	cipher      *storageCipher
+}
+
+func newRemoteStore(name string, storage versionedStorage, storageCipher *storageCipher) *remoteStore {
+	return &remoteStore{
+		name:    name,
+		storage: storage,
+		cipher:  storageCipher,
+	}
+}
+
+func (s *remoteStore) exists() (bool, error) {
+	_, _, err := s.storage.read()
+	if err == errStorageNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get atomically a struct from the remote storage
+func (s *remoteStore) Get() cluster.Object {
+	s.storageLock.RLock()
+	defer s.storageLock.RUnlock()
+	return s.account
+}
+
+// Load loads the remote storage into store
+func (s *remoteStore) Load() (cluster.Object, error) {
+	s.storageLock.Lock()
+	defer s.storageLock.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	log.Infof("Loaded ACME config from store %s", s.name)
+	return s.account, nil
+}
+
+func (s *remoteStore) load() error {
+	content, version, err := s.storage.read()
+	if err != nil {
+		return err
+	}
+	account, err := unmarshalAccount(content, s.cipher)
+	if err != nil {
+		return err
+	}
+	account.Init()
+	s.account = account
+	s.version = version
+	return nil
+}
+
+// Begin creates a transaction with the remote storage, from its last version.
+func (s *remoteStore) Begin() (cluster.Transaction, cluster.Object, error) {
+	s.storageLock.Lock()
+	if len(s.version) > 0 {
+		if err := s.load(); err != nil {
+			s.storageLock.Unlock()
+			return nil, nil, fmt.Errorf("unable to reload the ACME storage %s: %v", s.name, err)
+		}
+	}
+	return &remoteTransaction{remoteStore: s}, s.account, nil
+}
+
+var _ cluster.Transaction = (*remoteTransaction)(nil)
+
+type remoteTransaction struct {
+	*remoteStore
+	dirty bool
+}
+
+// Commit allows to set an object in the remote storage
+func (t *remoteTransaction) Commit(object cluster.Object) error {
+	defer t.storageLock.Unlock()
+	if t.dirty {
+		return fmt.Errorf("transaction already used, please begin a new one")
+	}
+	t.dirty = true
+
+	data, err := marshalAccount(object, t.cipher)
+	if err != nil {
+		return err
+	}
+	version, err := t.storage.write(data, t.version)
+	if err == errStorageConflict {
+		return fmt.Errorf("%v: %s", err, t.name)
+	}
+	if err != nil {
+		return err
+	}
+	t.account = object.(*Account)
+	t.version = version
+	return nil
+}
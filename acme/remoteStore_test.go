@@ -0,0 +1,265 @@
+package acme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves one object, with the conditional writes of S3.
+type fakeS3 struct {
+	mutex   sync.Mutex
+	content []byte
+	etag    string
+	writes  int
+}
+
+func (f *fakeS3) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if req.URL.Path != "/bucket/traefik/acme.json" {
+		http.Error(rw, "unexpected object "+req.URL.Path, http.StatusBadRequest)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if f.content == nil {
+			rw.WriteHeader(http.StatusNotFound)
+			rw.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		rw.Header().Set("ETag", f.etag)
+		rw.Write(f.content)
+	case http.MethodPut:
+		if (req.Header.Get("If-None-Match") == "*" && f.content != nil) ||
+			(req.Header.Get("If-Match") != "" && req.Header.Get("If-Match") != f.etag) {
+			rw.WriteHeader(http.StatusPreconditionFailed)
+			rw.Write([]byte(`<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`))
+			return
+		}
+		f.content, _ = ioutil.ReadAll(req.Body)
+		f.writes++
+		f.etag = fmt.Sprintf(`"etag-%d"`, f.writes)
+		rw.Header().Set("ETag", f.etag)
+	}
+}
+
+// fakeSecretsManager serves one secret, with the version stages of Secrets Manager.
+type fakeSecretsManager struct {
+	mutex    sync.Mutex
+	versions map[string]string
+	current  string
+}
+
+func (f *fakeSecretsManager) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	input := map[string]interface{}{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if (input["SecretId"] != nil && input["SecretId"] != "traefik/acme") || (input["Name"] != nil && input["Name"] != "traefik/acme") {
+		http.Error(rw, "unexpected secret", http.StatusBadRequest)
+		return
+	}
+
+	fail := func(code string) {
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(map[string]string{"__type": code, "message": code})
+	}
+
+	switch req.Header.Get("X-Amz-Target") {
+	case "secretsmanager.GetSecretValue":
+		if len(f.current) == 0 {
+			fail("ResourceNotFoundException")
+			return
+		}
+		json.NewEncoder(rw).Encode(map[string]string{"SecretString": f.versions[f.current], "VersionId": f.current})
+	case "secretsmanager.CreateSecret":
+		if len(f.current) > 0 {
+			fail("ResourceExistsException")
+			return
+		}
+		f.current = input["ClientRequestToken"].(string)
+		f.versions = map[string]string{f.current: input["SecretString"].(string)}
+		json.NewEncoder(rw).Encode(map[string]string{"VersionId": f.current})
+	case "secretsmanager.PutSecretValue":
+		if stages, ok := input["VersionStages"].([]interface{}); !ok || len(stages) != 1 || stages[0] != pendingVersionStage {
+			http.Error(rw, "unexpected version stages", http.StatusBadRequest)
+			return
+		}
+		version := input["ClientRequestToken"].(string)
+		f.versions[version] = input["SecretString"].(string)
+		json.NewEncoder(rw).Encode(map[string]string{"VersionId": version})
+	case "secretsmanager.UpdateSecretVersionStage":
+		if input["VersionStage"] != currentVersionStage || input["RemoveFromVersionId"] != f.current {
+			fail("InvalidParameterException")
+			return
+		}
+		f.current = input["MoveToVersionId"].(string)
+		json.NewEncoder(rw).Encode(map[string]string{})
+	default:
+		http.Error(rw, "unexpected operation", http.StatusBadRequest)
+	}
+}
+
+func newTestStorages(t *testing.T) (map[string]versionedStorage, func()) {
+	s3Server := httptest.NewServer(&fakeS3{})
+	secretsManagerServer := httptest.NewServer(&fakeSecretsManager{})
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	require.NoError(t, err)
+
+	storages := map[string]versionedStorage{
+		"S3": newS3Storage(sess, "bucket", "traefik/acme.json",
+			&aws.Config{Endpoint: aws.String(s3Server.URL), S3ForcePathStyle: aws.Bool(true)}),
+		"Secrets Manager": newSecretsManagerStorage(sess, "traefik/acme",
+			&aws.Config{Endpoint: aws.String(secretsManagerServer.URL)}),
+	}
+	return storages, func() {
+		s3Server.Close()
+		secretsManagerServer.Close()
+	}
+}
+
+func TestNewAccountStore(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		storage       string
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			desc:     "file",
+			storage:  "/etc/traefik/acme.json",
+			expected: &LocalStore{},
+		},
+		{
+			desc:     "S3 object",
+			storage:  "s3://bucket/traefik/acme.json?region=eu-west-1",
+			expected: &s3Storage{},
+		},
+		{
+			desc:          "S3 bucket without key",
+			storage:       "s3://bucket?region=eu-west-1",
+			expectedError: true,
+		},
+		{
+			desc:     "Secrets Manager secret",
+			storage:  "secretsmanager://traefik/acme?region=eu-west-1",
+			expected: &secretsManagerStorage{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := newAccountStore(test.storage, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if remote, ok := store.(*remoteStore); ok {
+				assert.IsType(t, test.expected, remote.storage)
+			} else {
+				assert.IsType(t, test.expected, store)
+			}
+		})
+	}
+}
+
+func TestVersionedStorages(t *testing.T) {
+	storages, closeStorages := newTestStorages(t)
+	defer closeStorages()
+
+	for name, storage := range storages {
+		storage := storage
+		t.Run(name, func(t *testing.T) {
+			_, _, err := storage.read()
+			assert.Equal(t, errStorageNotFound, err)
+
+			version1, err := storage.write([]byte("content 1"), "")
+			require.NoError(t, err)
+			assert.NotEmpty(t, version1)
+
+			_, err = storage.write([]byte("created concurrently"), "")
+			assert.Equal(t, errStorageConflict, err)
+
+			version2, err := storage.write([]byte("content 2"), version1)
+			require.NoError(t, err)
+			assert.NotEqual(t, version1, version2)
+
+			_, err = storage.write([]byte("written concurrently"), version1)
+			assert.Equal(t, errStorageConflict, err)
+
+			content, version, err := storage.read()
+			require.NoError(t, err)
+			assert.Equal(t, "content 2", string(content))
+			assert.Equal(t, version2, version)
+		})
+	}
+}
+
+func TestRemoteStoreConcurrentCommits(t *testing.T) {
+	storages, closeStorages := newTestStorages(t)
+	defer closeStorages()
+
+	for name, storage := range storages {
+		storage := storage
+		t.Run(name, func(t *testing.T) {
+			store1 := newRemoteStore(name, storage, nil)
+			store2 := newRemoteStore(name, storage, nil)
+
+			exists, err := store1.exists()
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			transaction, _, err := store1.Begin()
+			require.NoError(t, err)
+			require.NoError(t, transaction.Commit(&Account{Email: "test1@traefik.io"}))
+
+			exists, err = store2.exists()
+			require.NoError(t, err)
+			assert.True(t, exists)
+			object, err := store2.Load()
+			require.NoError(t, err)
+			assert.Equal(t, "test1@traefik.io", object.(*Account).Email)
+
+			transaction2, _, err := store2.Begin()
+			require.NoError(t, err)
+
+			transaction, object, err = store1.Begin()
+			require.NoError(t, err)
+			account := object.(*Account)
+			account.Email = "test2@traefik.io"
+			require.NoError(t, transaction.Commit(account))
+
+			assert.Error(t, transaction2.Commit(&Account{Email: "test3@traefik.io"}), "the storage has been written since the transaction began")
+
+			_, object, err = store2.Begin()
+			require.NoError(t, err)
+			assert.Equal(t, "test2@traefik.io", object.(*Account).Email, "a transaction should begin from the last version")
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package acme
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var _ versionedStorage = (*s3Storage)(nil)
+
+// s3Storage stores the account in a S3 object, its ETag being its version.
+// The writes are conditional requests, failing when the ETag of the object has changed.
+type s3Storage struct {
+	client *s3.S3
+	bucket string
+	key    string
+}
+
+func newS3Storage(configProvider client.ConfigProvider, bucket, key string, configs ...*aws.Config) *s3Storage {
+	return &s3Storage{
+		client: s3.New(configProvider, configs...),
+		bucket: bucket,
+		key:    key,
+	}
+}
+
+func (s *s3Storage) read() ([]byte, string, error) {
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() == http.StatusNotFound {
+		return nil, "", errStorageNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer output.Body.Close()
+
+	content, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, aws.StringValue(output.ETag), nil
+}
+
+func (s *s3Storage) write(content []byte, version string) (string, error) {
+	req, output := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String("application/json"),
+	})
+	if len(version) > 0 {
+		req.HTTPRequest.Header.Set("If-Match", version)
+	} else {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	}
+
+	err := req.Send()
+	if failure, ok := err.(awserr.RequestFailure); ok &&
+		(failure.StatusCode() == http.StatusPreconditionFailed || failure.StatusCode() == http.StatusConflict) {
+		return "", errStorageConflict
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.ETag), nil
+}
@@ -0,0 +1,183 @@
+package acme
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+const (
+	secretsManagerServiceName = "secretsmanager"
+	// currentVersionStage labels the version of a secret returned by default.
+	currentVersionStage = "AWSCURRENT"
+	// pendingVersionStage labels the version written by Traefik before it becomes the current one.
+	pendingVersionStage = "TRAEFIKPENDING"
+)
+
+var _ versionedStorage = (*secretsManagerStorage)(nil)
+
+// secretsManagerStorage stores the account in an AWS Secrets Manager secret, its version ID being its version.
+// A new version is written with a pending stage, then the current stage is moved to it from the version read,
+// which fails if the current stage has been moved in the meantime.
+type secretsManagerStorage struct {
+	client *client.Client
+	name   string
+}
+
+// The Secrets Manager API isn't part of the vendored AWS SDK, the client only has the operations used by the storage.
+func newSecretsManagerStorage(configProvider client.ConfigProvider, name string, configs ...*aws.Config) *secretsManagerStorage {
+	config := configProvider.ClientConfig(secretsManagerServiceName, configs...)
+	c := client.New(
+		*config.Config,
+		metadata.ClientInfo{
+			ServiceName:   secretsManagerServiceName,
+			SigningName:   config.SigningName,
+			SigningRegion: config.SigningRegion,
+			Endpoint:      config.Endpoint,
+			APIVersion:    "2017-10-17",
+			JSONVersion:   "1.1",
+			TargetPrefix:  "secretsmanager",
+		},
+		config.Handlers,
+	)
+	c.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	c.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	c.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	c.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	c.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return &secretsManagerStorage{
+		client: c,
+		name:   name,
+	}
+}
+
+type getSecretValueInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId *string `type:"string"`
+}
+
+type getSecretValueOutput struct {
+	_ struct{} `type:"structure"`
+
+	SecretString *string `type:"string"`
+	VersionId    *string `type:"string"`
+}
+
+type createSecretInput struct {
+	_ struct{} `type:"structure"`
+
+	ClientRequestToken *string `type:"string"`
+	Name               *string `type:"string"`
+	SecretString       *string `type:"string"`
+}
+
+type putSecretValueInput struct {
+	_ struct{} `type:"structure"`
+
+	ClientRequestToken *string   `type:"string"`
+	SecretId           *string   `type:"string"`
+	SecretString       *string   `type:"string"`
+	VersionStages      []*string `type:"list"`
+}
+
+type updateSecretVersionStageInput struct {
+	_ struct{} `type:"structure"`
+
+	MoveToVersionId     *string `type:"string"`
+	RemoveFromVersionId *string `type:"string"`
+	SecretId            *string `type:"string"`
+	VersionStage        *string `type:"string"`
+}
+
+type secretVersionOutput struct {
+	_ struct{} `type:"structure"`
+
+	VersionId *string `type:"string"`
+}
+
+func (s *secretsManagerStorage) send(operation string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       operation,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return s.client.NewRequest(op, input, output).Send()
+}
+
+func (s *secretsManagerStorage) read() ([]byte, string, error) {
+	output := &getSecretValueOutput{}
+	err := s.send("GetSecretValue", &getSecretValueInput{SecretId: aws.String(s.name)}, output)
+	if failure, ok := err.(awserr.Error); ok && failure.Code() == "ResourceNotFoundException" {
+		return nil, "", errStorageNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(aws.StringValue(output.SecretString)), aws.StringValue(output.VersionId), nil
+}
+
+func (s *secretsManagerStorage) write(content []byte, version string) (string, error) {
+	// the token is the ID of the new version
+	token, err := newClientRequestToken()
+	if err != nil {
+		return "", err
+	}
+
+	output := &secretVersionOutput{}
+	if len(version) == 0 {
+		err = s.send("CreateSecret", &createSecretInput{
+			ClientRequestToken: aws.String(token),
+			Name:               aws.String(s.name),
+			SecretString:       aws.String(string(content)),
+		}, output)
+		if failure, ok := err.(awserr.Error); ok && failure.Code() == "ResourceExistsException" {
+			return "", errStorageConflict
+		}
+		if err != nil {
+			return "", err
+		}
+		return aws.StringValue(output.VersionId), nil
+	}
+
+	err = s.send("PutSecretValue", &putSecretValueInput{
+		ClientRequestToken: aws.String(token),
+		SecretId:           aws.String(s.name),
+		SecretString:       aws.String(string(content)),
+		VersionStages:      []*string{aws.String(pendingVersionStage)},
+	}, output)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.send("UpdateSecretVersionStage", &updateSecretVersionStageInput{
+		MoveToVersionId:     output.VersionId,
+		RemoveFromVersionId: aws.String(version),
+		SecretId:            aws.String(s.name),
+		VersionStage:        aws.String(currentVersionStage),
+	}, &secretVersionOutput{})
+	if failure, ok := err.(awserr.Error); ok && failure.Code() == "InvalidParameterException" {
+		return "", errStorageConflict
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.VersionId), nil
+}
+
+// newClientRequestToken returns a random token, of the 32 to 64 characters required by Secrets Manager.
+func newClientRequestToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,241 @@
+package acme
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+)
+
+const (
+	envKeyProviderName   = "env"
+	kmsKeyProviderName   = "kms"
+	vaultKeyProviderName = "vault"
+	// dataKeySize is the size of the AES-256 data keys.
+	dataKeySize = 32
+)
+
+// Encryption holds the key encrypting the ACME storage at rest:
+// a key given by an environment variable, or data keys encrypted by AWS KMS or by Vault transit.
+type Encryption struct {
+	KeyEnv string           `description:"Environment variable holding the base64 encoded 256-bit key encrypting the storage."`
+	KMS    *KMSEncryption   `description:"AWS KMS key encrypting the data key of the storage."`
+	Vault  *VaultEncryption `description:"Vault transit key encrypting the data key of the storage."`
+}
+
+// KMSEncryption holds the AWS KMS key encrypting the data key of the storage.
+type KMSEncryption struct {
+	KeyID  string `description:"ID, ARN or alias of the KMS key."`
+	Region string `description:"Region of the KMS key. Defaults to the one of the environment or of the EC2 instance."`
+}
+
+// VaultEncryption holds the Vault transit key encrypting the data key of the storage, the Vault token being given by VAULT_TOKEN.
+type VaultEncryption struct {
+	Address string `description:"Address of Vault. Defaults to the VAULT_ADDR environment variable."`
+	Mount   string `description:"Mount path of the transit secrets engine. Defaults to transit."`
+	Key     string `description:"Name of the transit key."`
+}
+
+// dataKeyProvider provides the data keys encrypting the storage.
+type dataKeyProvider interface {
+	// generate returns a new data key, and its encrypted form stored along the content.
+	generate() (key []byte, encryptedKey []byte, err error)
+	// decrypt returns the data key of its encrypted form.
+	decrypt(encryptedKey []byte) ([]byte, error)
+}
+
+// encryptedStorage is the content of an encrypted storage, the account encrypted with AES-GCM by a data key.
+type encryptedStorage struct {
+	Provider     string
+	EncryptedKey []byte `json:",omitempty"`
+	Nonce        []byte
+	Data         []byte
+}
+
+// storageCipher encrypts and decrypts the storage, reusing the data key as long as the storage is encrypted with it.
+type storageCipher struct {
+	provider     string
+	keys         dataKeyProvider
+	lock         sync.Mutex
+	key          []byte
+	encryptedKey []byte
+}
+
+// newStorageCipher creates the cipher of the storage encryption, nil without encryption.
+func newStorageCipher(encryption *Encryption) (*storageCipher, error) {
+	if encryption == nil {
+		return nil, nil
+	}
+
+	var sources int
+	for _, configured := range []bool{len(encryption.KeyEnv) > 0, encryption.KMS != nil, encryption.Vault != nil} {
+		if configured {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("the ACME storage encryption requires exactly one of keyEnv, kms and vault")
+	}
+
+	switch {
+	case len(encryption.KeyEnv) > 0:
+		keys, err := newEnvKeyProvider(encryption.KeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &storageCipher{provider: envKeyProviderName, keys: keys}, nil
+	case encryption.KMS != nil:
+		keys, err := newKMSKeyProvider(encryption.KMS)
+		if err != nil {
+			return nil, err
+		}
+		return &storageCipher{provider: kmsKeyProviderName, keys: keys}, nil
+	default:
+		keys, err := newVaultKeyProvider(encryption.Vault)
+		if err != nil {
+			return nil, err
+		}
+		return &storageCipher{provider: vaultKeyProviderName, keys: keys}, nil
+	}
+}
+
+func (c *storageCipher) encrypt(content []byte) (*encryptedStorage, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.key == nil {
+		key, encryptedKey, err := c.keys.generate()
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate the data key of the ACME storage: %v", err)
+		}
+		c.key, c.encryptedKey = key, encryptedKey
+	}
+
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptedStorage{
+		Provider:     c.provider,
+		EncryptedKey: c.encryptedKey,
+		Nonce:        nonce,
+		Data:         gcm.Seal(nil, nonce, content, nil),
+	}, nil
+}
+
+func (c *storageCipher) decrypt(storage *encryptedStorage) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if storage.Provider != c.provider {
+		return nil, fmt.Errorf("the ACME storage is encrypted with %s, not with %s", storage.Provider, c.provider)
+	}
+	if c.key == nil || !bytes.Equal(storage.EncryptedKey, c.encryptedKey) {
+		key, err := c.keys.decrypt(storage.EncryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt the data key of the ACME storage: %v", err)
+		}
+		c.key, c.encryptedKey = key, storage.EncryptedKey
+	}
+
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := gcm.Open(nil, storage.Nonce, storage.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt the ACME storage: %v", err)
+	}
+	return content, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// marshalAccount returns the content of the storage of the account, encrypted if there is a cipher.
+func marshalAccount(object cluster.Object, storageCipher *storageCipher) ([]byte, error) {
+	data, err := json.MarshalIndent(object, "", "  ")
+	if err != nil || storageCipher == nil {
+		return data, err
+	}
+	encrypted, err := storageCipher.encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(struct{ Encrypted *encryptedStorage }{Encrypted: encrypted}, "", "  ")
+}
+
+// unmarshalAccount returns the account of the content of the storage, decrypted if it is encrypted.
+// A storage not encrypted yet is read as is, and encrypted on its next write.
+func unmarshalAccount(content []byte, storageCipher *storageCipher) (*Account, error) {
+	stored := struct{ Encrypted *encryptedStorage }{}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Encrypted != nil {
+		if storageCipher == nil {
+			return nil, errors.New("the ACME storage is encrypted, its encryption must be configured")
+		}
+		var err error
+		content, err = storageCipher.decrypt(stored.Encrypted)
+		if err != nil {
+			return nil, err
+		}
+	} else if storageCipher != nil {
+		log.Info("The ACME storage is not encrypted yet, it will be encrypted on its next write")
+	}
+
+	account := &Account{}
+	if err := json.Unmarshal(content, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+var _ dataKeyProvider = (*envKeyProvider)(nil)
+
+// envKeyProvider provides the key of an environment variable as data key.
+type envKeyProvider struct {
+	key []byte
+}
+
+func newEnvKeyProvider(name string) (*envKeyProvider, error) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return nil, fmt.Errorf("the environment variable %s of the ACME storage encryption key is empty", name)
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("the ACME storage encryption key of %s is not base64 encoded: %v", name, err)
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("the ACME storage encryption key of %s has %d bytes instead of %d", name, len(key), dataKeySize)
+	}
+	return &envKeyProvider{key: key}, nil
+}
+
+func (p *envKeyProvider) generate() ([]byte, []byte, error) {
+	return p.key, nil, nil
+}
+
+func (p *envKeyProvider) decrypt(_ []byte) ([]byte, error) {
+	return p.key, nil
+}
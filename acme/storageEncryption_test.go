@@ -0,0 +1,276 @@
+package acme
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEncryptionKeyEnv = "TRAEFIK_TEST_ACME_STORAGE_KEY"
+
+func TestNewStorageCipher(t *testing.T) {
+	require.NoError(t, os.Setenv(testEncryptionKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, dataKeySize))))
+	defer os.Unsetenv(testEncryptionKeyEnv)
+	require.NoError(t, os.Setenv("TRAEFIK_TEST_ACME_SHORT_KEY", base64.StdEncoding.EncodeToString([]byte("short"))))
+	defer os.Unsetenv("TRAEFIK_TEST_ACME_SHORT_KEY")
+
+	testCases := []struct {
+		desc             string
+		encryption       *Encryption
+		expectedProvider string
+		expectedError    bool
+	}{
+		{
+			desc: "no encryption",
+		},
+		{
+			desc:             "environment variable",
+			encryption:       &Encryption{KeyEnv: testEncryptionKeyEnv},
+			expectedProvider: envKeyProviderName,
+		},
+		{
+			desc:          "no key",
+			encryption:    &Encryption{},
+			expectedError: true,
+		},
+		{
+			desc:          "several keys",
+			encryption:    &Encryption{KeyEnv: testEncryptionKeyEnv, Vault: &VaultEncryption{Address: "http://vault:8200", Key: "traefik"}},
+			expectedError: true,
+		},
+		{
+			desc:          "empty environment variable",
+			encryption:    &Encryption{KeyEnv: "TRAEFIK_TEST_ACME_UNKNOWN_KEY"},
+			expectedError: true,
+		},
+		{
+			desc:          "key of the wrong size",
+			encryption:    &Encryption{KeyEnv: "TRAEFIK_TEST_ACME_SHORT_KEY"},
+			expectedError: true,
+		},
+		{
+			desc:          "KMS without key ID",
+			encryption:    &Encryption{KMS: &KMSEncryption{Region: "eu-west-1"}},
+			expectedError: true,
+		},
+		{
+			desc:          "Vault without transit key",
+			encryption:    &Encryption{Vault: &VaultEncryption{Address: "http://vault:8200"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			storageCipher, err := newStorageCipher(test.encryption)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if len(test.expectedProvider) == 0 {
+				assert.Nil(t, storageCipher)
+			} else {
+				require.NotNil(t, storageCipher)
+				assert.Equal(t, test.expectedProvider, storageCipher.provider)
+			}
+		})
+	}
+}
+
+func TestLocalStoreEncryption(t *testing.T) {
+	require.NoError(t, os.Setenv(testEncryptionKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, dataKeySize))))
+	defer os.Unsetenv(testEncryptionKeyEnv)
+
+	directory, err := ioutil.TempDir("", "traefik-acme-encryption")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+	file := filepath.Join(directory, "acme.json")
+
+	// a storage not encrypted yet is read, and encrypted on its next write
+	plainStore, err := newAccountStore(file, nil)
+	require.NoError(t, err)
+	transaction, _, err := plainStore.Begin()
+	require.NoError(t, err)
+	require.NoError(t, transaction.Commit(&Account{Email: "test@traefik.io", PrivateKey: []byte("private key")}))
+
+	newEncryptedStore := func() accountStore {
+		storageCipher, err := newStorageCipher(&Encryption{KeyEnv: testEncryptionKeyEnv})
+		require.NoError(t, err)
+		store, err := newAccountStore(file, storageCipher)
+		require.NoError(t, err)
+		return store
+	}
+
+	store := newEncryptedStore()
+	object, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "test@traefik.io", object.(*Account).Email)
+
+	transaction, object, err = store.Begin()
+	require.NoError(t, err)
+	require.NoError(t, transaction.Commit(object))
+
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"Encrypted"`)
+	assert.NotContains(t, string(content), "test@traefik.io")
+	assert.NotContains(t, string(content), base64.StdEncoding.EncodeToString([]byte("private key")))
+
+	object, err = newEncryptedStore().Load()
+	require.NoError(t, err)
+	assert.Equal(t, "test@traefik.io", object.(*Account).Email)
+	assert.Equal(t, []byte("private key"), object.(*Account).PrivateKey)
+
+	_, err = plainStore.Load()
+	assert.Error(t, err, "an encrypted storage requires its encryption")
+}
+
+// fakeKMS wraps the data keys with a prefix.
+type fakeKMS struct {
+	generated int
+	decrypted int
+}
+
+func (f *fakeKMS) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	input := map[string]string{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch req.Header.Get("X-Amz-Target") {
+	case "TrentService.GenerateDataKey":
+		if input["KeyId"] != "alias/traefik" || input["KeySpec"] != "AES_256" {
+			http.Error(rw, "unexpected key", http.StatusBadRequest)
+			return
+		}
+		f.generated++
+		key := bytes.Repeat([]byte{2}, dataKeySize)
+		json.NewEncoder(rw).Encode(map[string][]byte{"Plaintext": key, "CiphertextBlob": append([]byte("wrapped:"), key...)})
+	case "TrentService.Decrypt":
+		blob, err := base64.StdEncoding.DecodeString(input["CiphertextBlob"])
+		if err != nil || !bytes.HasPrefix(blob, []byte("wrapped:")) {
+			http.Error(rw, "unexpected ciphertext", http.StatusBadRequest)
+			return
+		}
+		f.decrypted++
+		json.NewEncoder(rw).Encode(map[string][]byte{"Plaintext": bytes.TrimPrefix(blob, []byte("wrapped:"))})
+	default:
+		http.Error(rw, "unexpected operation", http.StatusBadRequest)
+	}
+}
+
+// fakeVault wraps the data keys with the prefix of the transit ciphertexts.
+type fakeVault struct {
+	encrypted int
+	decrypted int
+}
+
+func (f *fakeVault) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("X-Vault-Token") != "token" {
+		rw.WriteHeader(http.StatusForbidden)
+		rw.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	input := map[string]string{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch req.URL.Path {
+	case "/v1/transit/encrypt/traefik":
+		f.encrypted++
+		json.NewEncoder(rw).Encode(map[string]interface{}{"data": map[string]string{"ciphertext": "vault:v1:" + input["plaintext"]}})
+	case "/v1/transit/decrypt/traefik":
+		f.decrypted++
+		json.NewEncoder(rw).Encode(map[string]interface{}{"data": map[string]string{"plaintext": strings.TrimPrefix(input["ciphertext"], "vault:v1:")}})
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+func TestDataKeyProviders(t *testing.T) {
+	kms := &fakeKMS{}
+	kmsServer := httptest.NewServer(kms)
+	defer kmsServer.Close()
+	vault := &fakeVault{}
+	vaultServer := httptest.NewServer(vault)
+	defer vaultServer.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, os.Setenv("VAULT_TOKEN", "token"))
+	defer os.Unsetenv("VAULT_TOKEN")
+	vaultKeys, err := newVaultKeyProvider(&VaultEncryption{Address: vaultServer.URL + "/", Key: "traefik"})
+	require.NoError(t, err)
+
+	providers := map[string]dataKeyProvider{
+		kmsKeyProviderName:   newKMSKeyProviderWithConfig(sess, "alias/traefik", &aws.Config{Endpoint: aws.String(kmsServer.URL)}),
+		vaultKeyProviderName: vaultKeys,
+	}
+
+	for name, keys := range providers {
+		name, keys := name, keys
+		t.Run(name, func(t *testing.T) {
+			encrypter := &storageCipher{provider: name, keys: keys}
+			encrypted, err := encrypter.encrypt([]byte("content"))
+			require.NoError(t, err)
+			assert.NotEmpty(t, encrypted.EncryptedKey)
+			assert.NotContains(t, string(encrypted.Data), "content")
+
+			// the data key is reused for the next writes
+			encrypted2, err := encrypter.encrypt([]byte("content 2"))
+			require.NoError(t, err)
+			assert.Equal(t, encrypted.EncryptedKey, encrypted2.EncryptedKey)
+			assert.NotEqual(t, encrypted.Nonce, encrypted2.Nonce)
+
+			decrypter := &storageCipher{provider: name, keys: keys}
+			content, err := decrypter.decrypt(encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+			content, err = decrypter.decrypt(encrypted2)
+			require.NoError(t, err)
+			assert.Equal(t, "content 2", string(content))
+
+			encrypted.Provider = "other"
+			_, err = decrypter.decrypt(encrypted)
+			assert.Error(t, err)
+		})
+	}
+
+	// a data key is generated and decrypted once
+	assert.Equal(t, 1, kms.generated)
+	assert.Equal(t, 1, kms.decrypted)
+	assert.Equal(t, 1, vault.encrypted)
+	assert.Equal(t, 1, vault.decrypted)
+
+	t.Run("vault error", func(t *testing.T) {
+		vaultKeys := *vaultKeys
+		vaultKeys.token = "invalid"
+		_, _, err := vaultKeys.generate()
+		assert.EqualError(t, err, "Vault encrypt failed with status 403: permission denied")
+	})
+}
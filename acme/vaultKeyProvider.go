@@ -0,0 +1,107 @@
+package acme
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultVaultTransitMount = "transit"
+
+var _ dataKeyProvider = (*vaultKeyProvider)(nil)
+
+// vaultKeyProvider generates random data keys, encrypted and decrypted by a Vault transit key.
+type vaultKeyProvider struct {
+	client  *http.Client
+	address string
+	mount   string
+	key     string
+	token   string
+}
+
+func newVaultKeyProvider(encryption *VaultEncryption) (*vaultKeyProvider, error) {
+	address := encryption.Address
+	if len(address) == 0 {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if len(address) == 0 || len(encryption.Key) == 0 {
+		return nil, errors.New("the Vault encryption of the ACME storage requires an address and a transit key")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if len(token) == 0 {
+		return nil, errors.New("the Vault encryption of the ACME storage requires a token in VAULT_TOKEN")
+	}
+	mount := strings.Trim(encryption.Mount, "/")
+	if len(mount) == 0 {
+		mount = defaultVaultTransitMount
+	}
+	return &vaultKeyProvider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		address: strings.TrimSuffix(address, "/"),
+		mount:   mount,
+		key:     encryption.Key,
+		token:   token,
+	}, nil
+}
+
+func (p *vaultKeyProvider) generate() ([]byte, []byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	data, err := p.post("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data["ciphertext"]) == 0 {
+		return nil, nil, errors.New("no ciphertext returned by Vault")
+	}
+	return key, []byte(data["ciphertext"]), nil
+}
+
+func (p *vaultKeyProvider) decrypt(encryptedKey []byte) ([]byte, error) {
+	data, err := p.post("decrypt", map[string]string{"ciphertext": string(encryptedKey)})
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(data["plaintext"])
+}
+
+// post calls an operation of the transit key, and returns the data of the response.
+func (p *vaultKeyProvider) post(operation string, body map[string]string) (map[string]string, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/%s/%s/%s", p.address, p.mount, operation, p.key), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := struct {
+		Data   map[string]string
+		Errors []string
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && resp.StatusCode/100 == 2 {
+		return nil, fmt.Errorf("invalid response of Vault: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Vault %s failed with status %d: %s", operation, resp.StatusCode, strings.Join(response.Errors, ", "))
+	}
+	return response.Data, nil
+}
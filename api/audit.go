@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/audit"
+	"github.com/containous/traefik/log"
+)
+
+// getAuditHandler returns the entries of the audit log, the most recent first,
+// optionally only the ones of a provider, recorded since a time or limited in number.
+func (p Handler) getAuditHandler(response http.ResponseWriter, request *http.Request) {
+	if p.AuditLog == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	query := audit.Query{Provider: request.URL.Query().Get("provider")}
+	if since := request.URL.Query().Get("since"); len(since) > 0 {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(response, "invalid since parameter, expected an RFC 3339 time: "+since, http.StatusBadRequest)
+			return
+		}
+		query.Since = t
+	}
+	if limit := request.URL.Query().Get("limit"); len(limit) > 0 {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(response, "invalid limit parameter: "+limit, http.StatusBadRequest)
+			return
+		}
+		query.Limit = n
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.AuditLog.Entries(query))
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func (p Handler) getAuditEntryHandler(response http.ResponseWriter, request *http.Request) {
+	if p.AuditLog == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(request)["id"], 10, 64)
+	if err != nil {
+		http.NotFound(response, request)
+		return
+	}
+	entry, ok := p.AuditLog.Entry(id)
+	if !ok {
+		http.NotFound(response, request)
+		return
+	}
+
+	err = templatesRenderer.JSON(response, http.StatusOK, entry)
+	if err != nil {
+		log.Error(err)
+	}
+}
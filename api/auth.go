@@ -0,0 +1,203 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	goauth "github.com/abbot/go-http-auth"
+	"github.com/containous/mux"
+	"github.com/containous/traefik/audit"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// The roles of the users of the API.
+const (
+	roleReader = "reader"
+	roleAdmin  = "admin"
+)
+
+const authRealm = "traefik"
+
+// apiAuthenticator authenticates the users of the API with the basic authentication, a bearer token or a client certificate,
+// allows the read requests to all of them and the other requests to the admins, and audits the operations of the admins.
+type apiAuthenticator struct {
+	basic             *goauth.BasicAuth
+	tokens            map[string]string
+	clientCertificate bool
+	admins            map[string]bool
+	auditLog          *audit.Log
+	now               func() time.Time
+}
+
+func newAPIAuthenticator(config *types.APIAuth, auditLog *audit.Log) (*apiAuthenticator, error) {
+	a := &apiAuthenticator{
+		clientCertificate: config.ClientCertificate,
+		admins:            make(map[string]bool),
+		auditLog:          auditLog,
+		now:               time.Now,
+	}
+
+	if config.Basic != nil {
+		users, err := parseCredentials(config.Basic.Users, config.Basic.UsersFile)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the users: %v", err)
+		}
+		a.basic = goauth.NewBasicAuthenticator(authRealm, func(user, realm string) string {
+			return users[user]
+		})
+	}
+
+	tokens, err := parseCredentials(config.Tokens, config.TokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the tokens: %v", err)
+	}
+	a.tokens = tokens
+
+	if a.basic == nil && len(a.tokens) == 0 && !a.clientCertificate {
+		return nil, fmt.Errorf("no users, tokens nor client certificates to authenticate")
+	}
+
+	for _, admin := range config.Admins {
+		a.admins[admin] = true
+	}
+	return a, nil
+}
+
+// parseCredentials parses the name:secret lines, and the ones of the file.
+func parseCredentials(lines []string, filePath string) (map[string]string, error) {
+	if len(filePath) > 0 {
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	credentials := make(map[string]string)
+	for _, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid credentials of %q, expected name:secret", parts[0])
+		}
+		credentials[parts[0]] = parts[1]
+	}
+	return credentials, nil
+}
+
+// authenticate returns the user of the request, or an empty string if the request isn't authenticated.
+func (a *apiAuthenticator) authenticate(req *http.Request) string {
+	if authorization := req.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		var user string
+		for name, secret := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1 {
+				user = name
+			}
+		}
+		return user
+	}
+
+	if a.basic != nil {
+		if user := a.basic.CheckAuth(req); len(user) > 0 {
+			return user
+		}
+	}
+
+	if a.clientCertificate && req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		return req.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	return ""
+}
+
+func (a *apiAuthenticator) role(user string) string {
+	if a.admins[user] {
+		return roleAdmin
+	}
+	return roleReader
+}
+
+func (a *apiAuthenticator) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		user := a.authenticate(req)
+		if len(user) == 0 {
+			if a.basic != nil {
+				a.basic.RequireAuth(rw, req)
+				return
+			}
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		if a.role(user) != roleAdmin {
+			log.Warnf("Denied the %s %s operation of the read-only user %s on the API", req.Method, req.URL.Path, user)
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		a.audit(user, req, recorder.status)
+	})
+}
+
+// audit logs the operation of an admin, and records it in the audit log if enabled.
+func (a *apiAuthenticator) audit(user string, req *http.Request, status int) {
+	log.Infof("Admin operation %s %s of %s on the API: %d", req.Method, req.URL.Path, user, status)
+
+	if a.auditLog != nil {
+		a.auditLog.Record(audit.Entry{
+			Provider:  audit.ProviderAPI,
+			Timestamp: a.now().UTC(),
+			Operation: &audit.Operation{User: user, Method: req.Method, Path: req.URL.Path, Status: status},
+		})
+	}
+}
+
+// addAuthentication authenticates the routes added by addRoutes, leaving the other routes of the router as they are.
+func addAuthentication(router *mux.Router, authenticator *apiAuthenticator, addRoutes func(*mux.Router)) {
+	existing := make(map[*mux.Route]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		existing[route] = true
+		return nil
+	})
+
+	addRoutes(router)
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if !existing[route] && route.GetHandler() != nil {
+			route.Handler(authenticator.wrap(route.GetHandler()))
+		}
+		return nil
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
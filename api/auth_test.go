@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/audit"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIAuthentication(t *testing.T) {
+	auditLog, err := audit.NewLog("", "", 0, 0)
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	router := mux.NewRouter()
+	router.Methods(http.MethodGet).Path("/metrics").HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	Handler{
+		MaintenanceModes: middlewares.NewMaintenanceModes(),
+		AuditLog:         auditLog,
+		Auth: &types.APIAuth{
+			Basic:  &types.Basic{Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}},
+			Tokens: []string{"ops:s3cr3t", "ci:other"},
+			Admins: []string{"ops"},
+		},
+	}.AddRoutes(router)
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		user           string
+		password       string
+		token          string
+		expectedStatus int
+	}{
+		{
+			desc:           "anonymous",
+			method:         http.MethodGet,
+			path:           "/api/maintenance",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "wrong password",
+			method:         http.MethodGet,
+			path:           "/api/maintenance",
+			user:           "test",
+			password:       "wrong",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "wrong token",
+			method:         http.MethodGet,
+			path:           "/api/maintenance",
+			token:          "wrong",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "reader reads",
+			method:         http.MethodGet,
+			path:           "/api/maintenance",
+			user:           "test",
+			password:       "test",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "reader writes",
+			method:         http.MethodPut,
+			path:           "/api/maintenance/frontend1",
+			token:          "other",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "admin writes",
+			method:         http.MethodPut,
+			path:           "/api/maintenance/frontend1",
+			token:          "s3cr3t",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "other routes of the entry point",
+			method:         http.MethodGet,
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	// The operations are audited once all the cases ran
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(`{"enabled": true}`))
+			if len(test.user) > 0 {
+				req.SetBasicAuth(test.user, test.password)
+			}
+			if len(test.token) > 0 {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+
+	entries := auditLog.Entries(audit.Query{Provider: audit.ProviderAPI})
+	require.Len(t, entries, 1)
+	assert.Equal(t, &audit.Operation{User: "ops", Method: http.MethodPut, Path: "/api/maintenance/frontend1", Status: http.StatusOK}, entries[0].Operation)
+}
+
+func TestNewAPIAuthenticator(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.APIAuth
+		expectedError bool
+	}{
+		{
+			desc:   "tokens",
+			config: &types.APIAuth{Tokens: []string{"ops:s3cr3t"}},
+		},
+		{
+			desc:   "client certificates",
+			config: &types.APIAuth{ClientCertificate: true, Admins: []string{"ops.example.com"}},
+		},
+		{
+			desc:          "nothing to authenticate",
+			config:        &types.APIAuth{Admins: []string{"ops"}},
+			expectedError: true,
+		},
+		{
+			desc:          "token without name",
+			config:        &types.APIAuth{Tokens: []string{"s3cr3t"}},
+			expectedError: true,
+		},
+		{
+			desc:          "missing users file",
+			config:        &types.APIAuth{Basic: &types.Basic{UsersFile: "/nowhere/users"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newAPIAuthenticator(test.config, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
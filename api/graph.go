@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// Kinds of the nodes of the routing graph
+const (
+	graphNodeEntryPoint = "entrypoint"
+	graphNodeFrontend   = "frontend"
+	graphNodeMiddleware = "middleware"
+	graphNodeBackend    = "backend"
+	graphNodeServer     = "server"
+)
+
+// routingGraph is the entrypoint -> frontend -> middleware -> backend -> server graph of the configurations.
+type routingGraph struct {
+	Nodes []graphNode `json:"nodes"`
+	Edges []graphEdge `json:"edges"`
+}
+
+type graphNode struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	Label    string `json:"label"`
+	Provider string `json:"provider,omitempty"`
+}
+
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (p Handler) getGraphHandler(response http.ResponseWriter, request *http.Request) {
+	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
+	graph := buildRoutingGraph(currentConfigurations)
+
+	switch format := request.URL.Query().Get("format"); format {
+	case "", "json":
+		err := templatesRenderer.JSON(response, http.StatusOK, graph)
+		if err != nil {
+			log.Error(err)
+		}
+	case "dot":
+		response.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		response.WriteHeader(http.StatusOK)
+		if _, err := response.Write(graph.dot()); err != nil {
+			log.Error(err)
+		}
+	default:
+		http.Error(response, fmt.Sprintf("unsupported graph format %q", format), http.StatusBadRequest)
+	}
+}
+
+func buildRoutingGraph(configurations types.Configurations) *routingGraph {
+	graph := &routingGraph{}
+	nodes := make(map[string]bool)
+	addNode := func(node graphNode) {
+		if !nodes[node.ID] {
+			nodes[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+	addEdge := func(from, to string) {
+		graph.Edges = append(graph.Edges, graphEdge{From: from, To: to})
+	}
+
+	for _, providerName := range sortedKeys(configurations) {
+		configuration := configurations[providerName]
+		if configuration == nil {
+			continue
+		}
+
+		var frontendNames []string
+		for name := range configuration.Frontends {
+			frontendNames = append(frontendNames, name)
+		}
+		sort.Strings(frontendNames)
+
+		for _, frontendName := range frontendNames {
+			frontend := configuration.Frontends[frontendName]
+			if frontend == nil {
+				continue
+			}
+
+			frontendID := graphNodeFrontend + "/" + providerName + "/" + frontendName
+			addNode(graphNode{ID: frontendID, Kind: graphNodeFrontend, Label: frontendName, Provider: providerName})
+
+			for _, entryPointName := range frontend.EntryPoints {
+				entryPointID := graphNodeEntryPoint + "/" + entryPointName
+				addNode(graphNode{ID: entryPointID, Kind: graphNodeEntryPoint, Label: entryPointName})
+				addEdge(entryPointID, frontendID)
+			}
+
+			// the middlewares are chained in the order they are applied to the requests
+			previousID := frontendID
+			for _, middlewareName := range getFrontendMiddlewares(frontend) {
+				middlewareID := graphNodeMiddleware + "/" + providerName + "/" + frontendName + "/" + middlewareName
+				addNode(graphNode{ID: middlewareID, Kind: graphNodeMiddleware, Label: middlewareName, Provider: providerName})
+				addEdge(previousID, middlewareID)
+				previousID = middlewareID
+			}
+
+			if len(frontend.Backend) == 0 {
+				continue
+			}
+			backendID := graphNodeBackend + "/" + providerName + "/" + frontend.Backend
+			addNode(graphNode{ID: backendID, Kind: graphNodeBackend, Label: frontend.Backend, Provider: providerName})
+			addEdge(previousID, backendID)
+		}
+
+		var backendNames []string
+		for name := range configuration.Backends {
+			backendNames = append(backendNames, name)
+		}
+		sort.Strings(backendNames)
+
+		for _, backendName := range backendNames {
+			backend := configuration.Backends[backendName]
+			if backend == nil {
+				continue
+			}
+
+			backendID := graphNodeBackend + "/" + providerName + "/" + backendName
+			addNode(graphNode{ID: backendID, Kind: graphNodeBackend, Label: backendName, Provider: providerName})
+
+			var serverNames []string
+			for name := range backend.Servers {
+				serverNames = append(serverNames, name)
+			}
+			sort.Strings(serverNames)
+
+			for _, serverName := range serverNames {
+				serverID := graphNodeServer + "/" + providerName + "/" + backendName + "/" + serverName
+				addNode(graphNode{ID: serverID, Kind: graphNodeServer, Label: backend.Servers[serverName].URL, Provider: providerName})
+				addEdge(backendID, serverID)
+			}
+		}
+	}
+
+	return graph
+}
+
+// getFrontendMiddlewares returns the middlewares configured on the frontend, in the order they are applied.
+func getFrontendMiddlewares(frontend *types.Frontend) []string {
+	configured := map[string]bool{
+		types.MiddlewareMaintenance:   frontend.Maintenance != nil,
+		types.MiddlewareErrorPages:    len(frontend.Errors) > 0,
+		types.MiddlewareLimits:        frontend.Limits != nil,
+		types.MiddlewareCORS:          frontend.CORS != nil,
+		types.MiddlewareClientCA:      frontend.ClientCA != nil,
+		types.MiddlewareOIDC:          frontend.OIDC != nil,
+		types.MiddlewareIPWhitelist:   frontend.IPWhitelist != nil || len(frontend.WhitelistSourceRange) > 0,
+		types.MiddlewareSignedURL:     frontend.SignedURL != nil,
+		types.MiddlewareRedirect:      len(frontend.Redirect) > 0,
+		types.MiddlewareRedirectRules: len(frontend.RedirectRules) > 0,
+		types.MiddlewareRedirectMap:   frontend.RedirectMap != nil && len(frontend.RedirectMap.File) > 0,
+		types.MiddlewareBasicAuth:     len(frontend.BasicAuth) > 0 || len(frontend.BasicAuthUsersFile) > 0,
+		types.MiddlewareScript:        frontend.Script != nil,
+		types.MiddlewareBodyRewrite:   frontend.BodyRewrite != nil,
+		types.MiddlewareHeaders:       frontend.Headers.HasCustomHeadersDefined(),
+		types.MiddlewareSecureHeaders: frontend.Headers.HasSecureHeadersDefined(),
+		types.MiddlewareCache:         frontend.Cache != nil,
+		types.MiddlewareMirror:        frontend.Mirror != nil,
+		types.MiddlewareRateLimit:     frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0,
+	}
+
+	var middlewares []string
+	for _, name := range types.OrderMiddlewares(frontend.Middlewares) {
+		if configured[name] {
+			middlewares = append(middlewares, name)
+		}
+	}
+	return middlewares
+}
+
+// dot returns the graph in the Graphviz DOT format.
+func (g *routingGraph) dot() []byte {
+	shapes := map[string]string{
+		graphNodeEntryPoint: "doublecircle",
+		graphNodeFrontend:   "box",
+		graphNodeMiddleware: "cds",
+		graphNodeBackend:    "box3d",
+		graphNodeServer:     "ellipse",
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("digraph traefik {\n\trankdir=LR;\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&buffer, "\t%q [label=%q, shape=%s];\n", node.ID, node.Label, shapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&buffer, "\t%q -> %q;\n", edge.From, edge.To)
+	}
+	buffer.WriteString("}\n")
+	return buffer.Bytes()
+}
+
+func sortedKeys(configurations types.Configurations) []string {
+	var keys []string
+	for key := range configurations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRoutingGraph(t *testing.T) {
+	configurations := types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {
+					EntryPoints:          []string{"http", "https"},
+					Backend:              "backend1",
+					WhitelistSourceRange: []string{"10.0.0.0/8"},
+					BasicAuth:            []string{"test:test"},
+				},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {
+					Servers: map[string]types.Server{
+						"server1": {URL: "http://10.0.0.1:80"},
+						"server2": {URL: "http://10.0.0.2:80"},
+					},
+				},
+			},
+		},
+	}
+
+	graph := buildRoutingGraph(configurations)
+
+	var nodeIDs []string
+	for _, node := range graph.Nodes {
+		nodeIDs = append(nodeIDs, node.ID)
+	}
+	assert.Equal(t, []string{
+		"frontend/file/frontend1",
+		"entrypoint/http",
+		"entrypoint/https",
+		"middleware/file/frontend1/ipWhitelist",
+		"middleware/file/frontend1/basicAuth",
+		"backend/file/backend1",
+		"server/file/backend1/server1",
+		"server/file/backend1/server2",
+	}, nodeIDs)
+
+	assert.Equal(t, []graphEdge{
+		{From: "entrypoint/http", To: "frontend/file/frontend1"},
+		{From: "entrypoint/https", To: "frontend/file/frontend1"},
+		{From: "frontend/file/frontend1", To: "middleware/file/frontend1/ipWhitelist"},
+		{From: "middleware/file/frontend1/ipWhitelist", To: "middleware/file/frontend1/basicAuth"},
+		{From: "middleware/file/frontend1/basicAuth", To: "backend/file/backend1"},
+		{From: "backend/file/backend1", To: "server/file/backend1/server1"},
+		{From: "backend/file/backend1", To: "server/file/backend1/server2"},
+	}, graph.Edges)
+}
+
+func TestGetFrontendMiddlewares(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		frontend *types.Frontend
+		expected []string
+	}{
+		{
+			desc:     "no middleware",
+			frontend: &types.Frontend{},
+		},
+		{
+			desc: "default order",
+			frontend: &types.Frontend{
+				BasicAuth:   []string{"test:test"},
+				CORS:        &types.CORS{},
+				Cache:       &types.Cache{},
+				Limits:      &types.Limits{},
+				Maintenance: &types.Maintenance{},
+				RateLimit:   &types.RateLimit{RateSet: map[string]*types.Rate{"rate": {}}},
+			},
+			expected: []string{"maintenance", "limits", "cors", "basicAuth", "cache", "rateLimit"},
+		},
+		{
+			desc: "middlewares of the order first, the maintenance always first",
+			frontend: &types.Frontend{
+				BasicAuth:   []string{"test:test"},
+				CORS:        &types.CORS{},
+				Maintenance: &types.Maintenance{},
+				RateLimit:   &types.RateLimit{RateSet: map[string]*types.Rate{"rate": {}}},
+				Middlewares: []string{"rateLimit", "maintenance", "oidc", "basicAuth"},
+			},
+			expected: []string{"maintenance", "rateLimit", "basicAuth", "cors"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getFrontendMiddlewares(test.frontend))
+		})
+	}
+}
+
+func TestGetGraphHandler(t *testing.T) {
+	currentConfigurations := &safe.Safe{}
+	currentConfigurations.Set(types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {EntryPoints: []string{"http"}, Backend: "backend1"},
+			},
+		},
+	})
+
+	router := mux.NewRouter()
+	Handler{CurrentConfigurations: currentConfigurations}.AddRoutes(router)
+
+	testCases := []struct {
+		desc                string
+		format              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			desc:                "json",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=UTF-8",
+			expectedBody:        `"from":"entrypoint/http"`,
+		},
+		{
+			desc:                "dot",
+			format:              "dot",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/vnd.graphviz; charset=utf-8",
+			expectedBody:        `"frontend/file/frontend1" -> "backend/file/backend1";`,
+		},
+		{
+			desc:           "unknown format",
+			format:         "svg",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/graph?format="+test.format, nil))
+
+			require.Equal(t, test.expectedStatus, recorder.Code)
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+				assert.Contains(t, recorder.Body.String(), test.expectedBody)
+			}
+		})
+	}
+}
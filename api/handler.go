@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/containous/mux"
+	"github.com/containous/traefik/audit"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/safe"
@@ -19,9 +22,17 @@ type Handler struct {
 	Dashboard             bool   `description:"Activate dashboard" export:"true"`
 	Debug                 bool   `export:"true"`
 	CurrentConfigurations *safe.Safe
+	ShadowConfigurations  *safe.Safe
 	Statistics            *types.Statistics `description:"Enable more detailed statistics" export:"true"`
+	Auth                  *types.APIAuth    `description:"Authentication of the API and of the dashboard, with read-only and admin users" export:"true"`
 	Stats                 *thoas_stats.Stats
 	StatsRecorder         *middlewares.StatsRecorder
+	BackendStatsRecorder  *middlewares.BackendStatsRecorder
+	FrontendUsageRecorder *middlewares.FrontendUsageRecorder
+	BackendQueues         *middlewares.BackendQueues
+	MaintenanceModes      *middlewares.MaintenanceModes
+	ResponseCaches        *middlewares.ResponseCaches
+	AuditLog              *audit.Log
 }
 
 var (
@@ -30,8 +41,22 @@ var (
 	})
 )
 
-// AddRoutes add api routes on a router
+// AddRoutes add api routes on a router, authenticated if the authentication is configured
 func (p Handler) AddRoutes(router *mux.Router) {
+	if p.Auth == nil {
+		p.addRoutes(router)
+		return
+	}
+
+	authenticator, err := newAPIAuthenticator(p.Auth, p.AuditLog)
+	if err != nil {
+		log.Errorf("Error creating the authentication of the API, the API and the dashboard are disabled: %v", err)
+		return
+	}
+	addAuthentication(router, authenticator, p.addRoutes)
+}
+
+func (p Handler) addRoutes(router *mux.Router) {
 	if p.Debug {
 		DebugHandler{}.AddRoutes(router)
 	}
@@ -48,6 +73,29 @@ func (p Handler) AddRoutes(router *mux.Router) {
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends/{frontend}/routes").HandlerFunc(p.getRoutesHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends/{frontend}/routes/{route}").HandlerFunc(p.getRouteHandler)
 
+	router.Methods(http.MethodGet).Path("/api/graph").HandlerFunc(p.getGraphHandler)
+	router.Methods(http.MethodGet).Path("/api/shadow").HandlerFunc(p.getShadowConfigurationsHandler)
+	router.Methods(http.MethodGet).Path("/api/shadow/{provider}").HandlerFunc(p.getShadowConfigurationHandler)
+	router.Methods(http.MethodGet).Path("/api/statistics/backends").HandlerFunc(p.getBackendsStatisticsHandler)
+	router.Methods(http.MethodGet).Path("/api/statistics/backends/{backend}").HandlerFunc(p.getBackendStatisticsHandler)
+	router.Methods(http.MethodGet).Path("/api/statistics/frontends").HandlerFunc(p.getFrontendsStatisticsHandler)
+	router.Methods(http.MethodGet).Path("/api/statistics/frontends/{frontend}").HandlerFunc(p.getFrontendStatisticsHandler)
+	router.Methods(http.MethodGet).Path("/api/statistics/queues").HandlerFunc(p.getQueuesStatisticsHandler)
+
+	router.Methods(http.MethodGet).Path("/api/maintenance").HandlerFunc(p.getMaintenanceHandler)
+	router.Methods(http.MethodPut).Path("/api/maintenance/{frontend}").HandlerFunc(p.putMaintenanceHandler)
+	router.Methods(http.MethodDelete).Path("/api/maintenance/{frontend}").HandlerFunc(p.deleteMaintenanceHandler)
+
+	router.Methods(http.MethodGet).Path("/api/cache").HandlerFunc(p.getCacheHandler)
+	router.Methods(http.MethodDelete).Path("/api/cache/{frontend}").HandlerFunc(p.deleteCacheHandler)
+
+	router.Methods(http.MethodGet).Path("/api/audit").HandlerFunc(p.getAuditHandler)
+	router.Methods(http.MethodGet).Path("/api/audit/{id}").HandlerFunc(p.getAuditEntryHandler)
+
+	router.Methods(http.MethodGet).Path("/api/log/levels").HandlerFunc(p.getLogLevelsHandler)
+	router.Methods(http.MethodPut).Path("/api/log/levels/{module}").HandlerFunc(p.putLogLevelHandler)
+	router.Methods(http.MethodDelete).Path("/api/log/levels/{module}").HandlerFunc(p.deleteLogLevelHandler)
+
 	// health route
 	router.Methods(http.MethodGet).Path("/health").HandlerFunc(p.getHealthHandler)
 
@@ -248,3 +296,169 @@ func (p *Handler) getHealthHandler(response http.ResponseWriter, request *http.R
 		log.Error(err)
 	}
 }
+
+func (p Handler) getBackendsStatisticsHandler(response http.ResponseWriter, request *http.Request) {
+	if p.BackendStatsRecorder == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.BackendStatsRecorder.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func (p Handler) getBackendStatisticsHandler(response http.ResponseWriter, request *http.Request) {
+	backendID := mux.Vars(request)["backend"]
+
+	if p.BackendStatsRecorder != nil {
+		if backend, ok := p.BackendStatsRecorder.Data()[backendID]; ok {
+			err := templatesRenderer.JSON(response, http.StatusOK, backend)
+			if err != nil {
+				log.Error(err)
+			}
+			return
+		}
+	}
+	http.NotFound(response, request)
+}
+
+// getFrontendsStatisticsHandler returns the usage of the frontends, only the unused ones with the unused=true query parameter.
+func (p Handler) getFrontendsStatisticsHandler(response http.ResponseWriter, request *http.Request) {
+	if p.FrontendUsageRecorder == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	frontends := p.FrontendUsageRecorder.Data()
+	if unused, _ := strconv.ParseBool(request.URL.Query().Get("unused")); unused {
+		for frontendName, usage := range frontends {
+			if !usage.Unused {
+				delete(frontends, frontendName)
+			}
+		}
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, frontends)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func (p Handler) getFrontendStatisticsHandler(response http.ResponseWriter, request *http.Request) {
+	frontendID := mux.Vars(request)["frontend"]
+
+	if p.FrontendUsageRecorder != nil {
+		if frontend, ok := p.FrontendUsageRecorder.Data()[frontendID]; ok {
+			err := templatesRenderer.JSON(response, http.StatusOK, frontend)
+			if err != nil {
+				log.Error(err)
+			}
+			return
+		}
+	}
+	http.NotFound(response, request)
+}
+
+func (p Handler) getQueuesStatisticsHandler(response http.ResponseWriter, request *http.Request) {
+	if p.BackendQueues == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.BackendQueues.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// getMaintenanceHandler returns the maintenance modes set through the API, by frontend.
+func (p Handler) getMaintenanceHandler(response http.ResponseWriter, request *http.Request) {
+	if p.MaintenanceModes == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.MaintenanceModes.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// putMaintenanceHandler enables or disables the maintenance of a frontend, with a {"enabled": true|false} body.
+func (p Handler) putMaintenanceHandler(response http.ResponseWriter, request *http.Request) {
+	if p.MaintenanceModes == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	var mode struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(request.Body).Decode(&mode); err != nil || mode.Enabled == nil {
+		http.Error(response, `expected a {"enabled": true|false} body`, http.StatusBadRequest)
+		return
+	}
+
+	frontendID := mux.Vars(request)["frontend"]
+	p.MaintenanceModes.Set(frontendID, *mode.Enabled)
+	log.Infof("Maintenance of the frontend %s set to %t through the API", frontendID, *mode.Enabled)
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.MaintenanceModes.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// deleteMaintenanceHandler restores the maintenance mode of the configuration of a frontend.
+func (p Handler) deleteMaintenanceHandler(response http.ResponseWriter, request *http.Request) {
+	if p.MaintenanceModes == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	frontendID := mux.Vars(request)["frontend"]
+	p.MaintenanceModes.Reset(frontendID)
+	log.Infof("Maintenance of the frontend %s reset to its configuration through the API", frontendID)
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.MaintenanceModes.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// getCacheHandler returns the state of the response caches, by frontend.
+func (p Handler) getCacheHandler(response http.ResponseWriter, request *http.Request) {
+	if p.ResponseCaches == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, p.ResponseCaches.Data())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// deleteCacheHandler purges the cached responses of a frontend, or only the ones whose path starts with the path parameter.
+func (p Handler) deleteCacheHandler(response http.ResponseWriter, request *http.Request) {
+	if p.ResponseCaches == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	frontendID := mux.Vars(request)["frontend"]
+	pathPrefix := request.URL.Query().Get("path")
+	purged, ok := p.ResponseCaches.Purge(frontendID, pathPrefix)
+	if !ok {
+		http.NotFound(response, request)
+		return
+	}
+	log.Infof("Purged %d responses of the cache of the frontend %s through the API", purged, frontendID)
+
+	err := templatesRenderer.JSON(response, http.StatusOK, map[string]int{"purged": purged})
+	if err != nil {
+		log.Error(err)
+	}
+}
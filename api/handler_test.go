@@ -0,0 +1,314 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/containous/mux"
+	"github.com/containous/traefik/audit"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestMaintenanceHandlers(t *testing.T) {
+	modes := middlewares.NewMaintenanceModes()
+	router := mux.NewRouter()
+	Handler{MaintenanceModes: modes}.AddRoutes(router)
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "enable",
+			method:         http.MethodPut,
+			path:           "/api/maintenance/frontend1",
+			body:           `{"enabled": true}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"frontend1":true}`,
+		},
+		{
+			desc:           "disable",
+			method:         http.MethodPut,
+			path:           "/api/maintenance/frontend2",
+			body:           `{"enabled": false}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"frontend1":true,"frontend2":false}`,
+		},
+		{
+			desc:           "missing mode",
+			method:         http.MethodPut,
+			path:           "/api/maintenance/frontend3",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "list",
+			method:         http.MethodGet,
+			path:           "/api/maintenance",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"frontend1":true,"frontend2":false}`,
+		},
+		{
+			desc:           "reset",
+			method:         http.MethodDelete,
+			path:           "/api/maintenance/frontend1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"frontend2":false}`,
+		},
+	}
+
+	// The cases depend on the previous ones
+	for _, test := range testCases {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.expectedStatus, recorder.Code, test.desc)
+		if len(test.expectedBody) > 0 {
+			assert.JSONEq(t, test.expectedBody, recorder.Body.String(), test.desc)
+		}
+	}
+}
+
+func TestCacheHandlers(t *testing.T) {
+	caches := middlewares.NewResponseCaches()
+	cache, err := caches.Get("frontend1", &types.Cache{})
+	require.NoError(t, err)
+
+	n := negroni.New(cache)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}))
+	for _, path := range []string{"/images/a.png", "/images/b.png", "/api/items"} {
+		n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://foo.bar"+path, nil))
+	}
+
+	router := mux.NewRouter()
+	Handler{ResponseCaches: caches}.AddRoutes(router)
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "list",
+			method:         http.MethodGet,
+			path:           "/api/cache",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"frontend1":{"entries":3,"size":102,"hits":0,"misses":3}}`,
+		},
+		{
+			desc:           "purge a path",
+			method:         http.MethodDelete,
+			path:           "/api/cache/frontend1?path=/images/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"purged":2}`,
+		},
+		{
+			desc:           "purge all",
+			method:         http.MethodDelete,
+			path:           "/api/cache/frontend1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"purged":1}`,
+		},
+		{
+			desc:           "unknown frontend",
+			method:         http.MethodDelete,
+			path:           "/api/cache/frontend2",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	// The cases depend on the previous ones
+	for _, test := range testCases {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.path, nil)
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.expectedStatus, recorder.Code, test.desc)
+		if len(test.expectedBody) > 0 {
+			assert.JSONEq(t, test.expectedBody, recorder.Body.String(), test.desc)
+		}
+	}
+}
+
+func TestAuditHandlers(t *testing.T) {
+	auditLog, err := audit.NewLog("", "", 0, 0)
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	timestamp := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	auditLog.Record(audit.Entry{Provider: "file", Timestamp: timestamp, Changes: []audit.Change{{Kind: audit.KindFrontend, Name: "frontend1", Action: audit.ActionAdded}}})
+	auditLog.Record(audit.Entry{Provider: "docker", Timestamp: timestamp.Add(time.Minute), Changes: []audit.Change{{Kind: audit.KindBackend, Name: "backend1", Action: audit.ActionRemoved}}})
+
+	router := mux.NewRouter()
+	Handler{AuditLog: auditLog}.AddRoutes(router)
+
+	entry1 := `{"id":1,"provider":"file","timestamp":"2018-01-01T00:00:00Z","changes":[{"kind":"frontend","name":"frontend1","action":"added"}]}`
+	entry2 := `{"id":2,"provider":"docker","timestamp":"2018-01-01T00:01:00Z","changes":[{"kind":"backend","name":"backend1","action":"removed"}]}`
+
+	testCases := []struct {
+		desc           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "list",
+			path:           "/api/audit",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + entry2 + "," + entry1 + "]",
+		},
+		{
+			desc:           "provider",
+			path:           "/api/audit?provider=file",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + entry1 + "]",
+		},
+		{
+			desc:           "since",
+			path:           "/api/audit?since=2018-01-01T00:00:30Z",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + entry2 + "]",
+		},
+		{
+			desc:           "limit",
+			path:           "/api/audit?limit=1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + entry2 + "]",
+		},
+		{
+			desc:           "invalid since",
+			path:           "/api/audit?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "entry",
+			path:           "/api/audit/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   entry1,
+		},
+		{
+			desc:           "unknown entry",
+			path:           "/api/audit/3",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if len(test.expectedBody) > 0 {
+				assert.JSONEq(t, test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestLogLevelHandlers(t *testing.T) {
+	defaultLevel := log.GetLevel()
+	defer func() {
+		log.SetLevel(defaultLevel)
+		log.SetModuleLevels(nil)
+	}()
+	log.SetLevel(logrus.ErrorLevel)
+	log.SetModuleLevels(nil)
+
+	router := mux.NewRouter()
+	Handler{}.AddRoutes(router)
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "list",
+			method:         http.MethodGet,
+			path:           "/api/log/levels",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":"error","modules":{}}`,
+		},
+		{
+			desc:           "set a module level",
+			method:         http.MethodPut,
+			path:           "/api/log/levels/provider.ecs",
+			body:           `{"level": "DEBUG"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":"error","modules":{"provider.ecs":"debug"}}`,
+		},
+		{
+			desc:           "set the default level",
+			method:         http.MethodPut,
+			path:           "/api/log/levels/default",
+			body:           `{"level": "warn"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":"warning","modules":{"provider.ecs":"debug"}}`,
+		},
+		{
+			desc:           "invalid level",
+			method:         http.MethodPut,
+			path:           "/api/log/levels/server",
+			body:           `{"level": "verbose"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "remove a module level",
+			method:         http.MethodDelete,
+			path:           "/api/log/levels/provider.ecs",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":"warning","modules":{}}`,
+		},
+		{
+			desc:           "remove an unknown module level",
+			method:         http.MethodDelete,
+			path:           "/api/log/levels/server",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "remove the default level",
+			method:         http.MethodDelete,
+			path:           "/api/log/levels/default",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	// The cases depend on the previous ones
+	for _, test := range testCases {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.expectedStatus, recorder.Code, test.desc)
+		if len(test.expectedBody) > 0 {
+			assert.JSONEq(t, test.expectedBody, recorder.Body.String(), test.desc)
+		}
+	}
+}
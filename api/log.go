@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/containous/mux"
+	"github.com/containous/traefik/log"
+)
+
+// defaultLogModule is the name targeting the default log level in the routes of the module levels.
+const defaultLogModule = "default"
+
+type logLevelsRepresentation struct {
+	Default string            `json:"default"`
+	Modules map[string]string `json:"modules"`
+}
+
+// getLogLevelsHandler returns the default log level and the log levels of the modules.
+func (p Handler) getLogLevelsHandler(response http.ResponseWriter, request *http.Request) {
+	err := templatesRenderer.JSON(response, http.StatusOK, currentLogLevels())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// putLogLevelHandler sets the log level of a module, or the default log level, with a {"level": "debug"} body,
+// until the restart of Traefik.
+func (p Handler) putLogLevelHandler(response http.ResponseWriter, request *http.Request) {
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+		http.Error(response, `expected a {"level": "debug|info|warn|error|fatal|panic"} body`, http.StatusBadRequest)
+		return
+	}
+	level, err := logrus.ParseLevel(strings.ToLower(body.Level))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	module := mux.Vars(request)["module"]
+	if module == defaultLogModule {
+		log.SetLevel(level)
+	} else {
+		log.SetModuleLevel(module, level)
+	}
+	log.Infof("Log level of %s set to %s through the API", module, level)
+
+	err = templatesRenderer.JSON(response, http.StatusOK, currentLogLevels())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// deleteLogLevelHandler removes the log level of a module, which logs again at the level of its parent module or at the default level.
+func (p Handler) deleteLogLevelHandler(response http.ResponseWriter, request *http.Request) {
+	module := mux.Vars(request)["module"]
+	if module == defaultLogModule {
+		http.Error(response, "the default log level can't be removed", http.StatusBadRequest)
+		return
+	}
+	if _, ok := log.ModuleLevels()[module]; !ok {
+		http.NotFound(response, request)
+		return
+	}
+
+	log.ResetModuleLevel(module)
+	log.Infof("Log level of %s removed through the API", module)
+
+	err := templatesRenderer.JSON(response, http.StatusOK, currentLogLevels())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func currentLogLevels() logLevelsRepresentation {
+	levels := logLevelsRepresentation{
+		Default: log.GetLevel().String(),
+		Modules: make(map[string]string),
+	}
+	for module, level := range log.ModuleLevels() {
+		levels.Modules[module] = level.String()
+	}
+	return levels
+}
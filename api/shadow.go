@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+func (p Handler) getShadowConfigurationsHandler(response http.ResponseWriter, request *http.Request) {
+	shadowConfigurations := p.getShadowConfigurations()
+	err := templatesRenderer.JSON(response, http.StatusOK, shadowConfigurations)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func (p Handler) getShadowConfigurationHandler(response http.ResponseWriter, request *http.Request) {
+	providerID := mux.Vars(request)["provider"]
+
+	shadowConfigurations := p.getShadowConfigurations()
+	if shadow, ok := shadowConfigurations[providerID]; ok {
+		err := templatesRenderer.JSON(response, http.StatusOK, shadow)
+		if err != nil {
+			log.Error(err)
+		}
+	} else {
+		http.NotFound(response, request)
+	}
+}
+
+func (p Handler) getShadowConfigurations() types.ShadowConfigurations {
+	if p.ShadowConfigurations == nil {
+		return types.ShadowConfigurations{}
+	}
+	return p.ShadowConfigurations.Get().(types.ShadowConfigurations)
+}
@@ -0,0 +1,272 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+)
+
+// The actions of the changes.
+const (
+	ActionAdded   = "added"
+	ActionRemoved = "removed"
+	ActionChanged = "changed"
+)
+
+// The kinds of the elements of the configuration changed.
+const (
+	KindFrontend    = "frontend"
+	KindBackend     = "backend"
+	KindCertificate = "certificate"
+)
+
+const postTimeout = 10 * time.Second
+
+// ProviderAPI is the provider of the entries of the operations of the admins through the API.
+const ProviderAPI = "api"
+
+// Entry is the audit record of a configuration applied for a provider,
+// or of an operation of an admin through the API, its user and its response status.
+type Entry struct {
+	ID        int64      `json:"id"`
+	Provider  string     `json:"provider"`
+	Timestamp time.Time  `json:"timestamp"`
+	Changes   []Change   `json:"changes"`
+	Operation *Operation `json:"operation,omitempty"`
+}
+
+// Operation is an operation of an admin through the API.
+type Operation struct {
+	User   string `json:"user"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+}
+
+// Change is the change of an element of the configuration: a frontend, a backend or a certificate.
+type Change struct {
+	Kind   string        `json:"kind"`
+	Name   string        `json:"name"`
+	Action string        `json:"action"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is the change of a field of an element, identified by its path in the JSON representation of the element.
+// The previous value of an added field, and the next value of a removed field, are omitted.
+type FieldChange struct {
+	Path     string      `json:"path"`
+	Previous interface{} `json:"previous,omitempty"`
+	Next     interface{} `json:"next,omitempty"`
+}
+
+// Query selects the entries of the history.
+type Query struct {
+	Provider string
+	Since    time.Time
+	Limit    int
+}
+
+// Log records the entries in a history, limited in number and in age,
+// appends them to a file in the JSON lines format, and posts them to a URL.
+type Log struct {
+	mutex      sync.RWMutex
+	entries    []Entry
+	lastID     int64
+	maxEntries int
+	maxAge     time.Duration
+	file       *os.File
+	url        string
+	client     *http.Client
+	posts      chan Entry
+	stop       chan struct{}
+}
+
+// NewLog creates a Log, loading the history from the file if it exists.
+// A maxEntries of 0 or less, or a maxAge of 0, do not limit the history.
+func NewLog(filePath, url string, maxEntries int, maxAge time.Duration) (*Log, error) {
+	l := &Log{
+		maxEntries: maxEntries,
+		maxAge:     maxAge,
+		url:        url,
+	}
+
+	if len(filePath) > 0 {
+		if err := l.load(filePath); err != nil {
+			return nil, fmt.Errorf("error loading the audit log %s: %v", filePath, err)
+		}
+		file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("error opening the audit log %s: %v", filePath, err)
+		}
+		l.file = file
+	}
+
+	if len(url) > 0 {
+		l.client = &http.Client{Timeout: postTimeout}
+		l.posts = make(chan Entry, 100)
+		l.stop = make(chan struct{})
+		safe.Go(l.runPosts)
+	}
+	return l, nil
+}
+
+// load loads the entries of the file within the limits of the history, the malformed lines being skipped.
+func (l *Log) load(filePath string) error {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Warnf("Skipping a malformed entry of the audit log %s: %v", filePath, err)
+			continue
+		}
+		l.entries = append(l.entries, entry)
+		if entry.ID > l.lastID {
+			l.lastID = entry.ID
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	l.prune(time.Now())
+	return nil
+}
+
+// Record records an entry, giving it the next ID, and returns it.
+func (l *Log) Record(entry Entry) Entry {
+	l.mutex.Lock()
+	l.lastID++
+	entry.ID = l.lastID
+	l.entries = append(l.entries, entry)
+	l.prune(time.Now())
+	if l.file != nil {
+		if err := json.NewEncoder(l.file).Encode(entry); err != nil {
+			log.Errorf("Unable to write the entry %d to the audit log: %v", entry.ID, err)
+		}
+	}
+	l.mutex.Unlock()
+
+	if l.posts != nil {
+		select {
+		case l.posts <- entry:
+		default:
+			log.Warnf("Too many audit entries waiting to be posted, dropping the entry %d", entry.ID)
+		}
+	}
+	return entry
+}
+
+// prune removes the entries beyond the limits of the history.
+func (l *Log) prune(now time.Time) {
+	start := 0
+	if l.maxEntries > 0 && len(l.entries) > l.maxEntries {
+		start = len(l.entries) - l.maxEntries
+	}
+	if l.maxAge > 0 {
+		for start < len(l.entries) && now.Sub(l.entries[start].Timestamp) > l.maxAge {
+			start++
+		}
+	}
+	if start > 0 {
+		l.entries = append([]Entry(nil), l.entries[start:]...)
+	}
+}
+
+// Entries returns the entries of the history selected by the query, the most recent first.
+func (l *Log) Entries(query Query) []Entry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	now := time.Now()
+	entries := []Entry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entry := l.entries[i]
+		if l.maxAge > 0 && now.Sub(entry.Timestamp) > l.maxAge {
+			break
+		}
+		if !query.Since.IsZero() && entry.Timestamp.Before(query.Since) {
+			break
+		}
+		if len(query.Provider) > 0 && entry.Provider != query.Provider {
+			continue
+		}
+		entries = append(entries, entry)
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+	}
+	return entries
+}
+
+// Entry returns the entry of the history with the ID.
+func (l *Log) Entry(id int64) (Entry, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	for _, entry := range l.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Close stops the posts of the entries, and closes the file.
+func (l *Log) Close() error {
+	if l.stop != nil {
+		close(l.stop)
+	}
+	if l.file != nil {
+		return l.file.Close()
+	}
+	return nil
+}
+
+// runPosts posts the entries to the URL, one at a time in the order they were recorded.
+func (l *Log) runPosts() {
+	for {
+		select {
+		case <-l.stop:
+			return
+		case entry := <-l.posts:
+			if err := l.post(entry); err != nil {
+				log.Errorf("Unable to post the entry %d of the audit log to %s: %v", entry.ID, l.url, err)
+			}
+		}
+	}
+}
+
+func (l *Log) post(entry Entry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	resp, err := l.client.Post(l.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogEntries(t *testing.T) {
+	l, err := NewLog("", "", 3, 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	start := time.Now().UTC()
+	for i, provider := range []string{"file", "docker", "file", "docker"} {
+		l.Record(Entry{Provider: provider, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	testCases := []struct {
+		desc        string
+		query       Query
+		expectedIDs []int64
+	}{
+		{
+			desc:        "all",
+			expectedIDs: []int64{4, 3, 2},
+		},
+		{
+			desc:        "provider",
+			query:       Query{Provider: "file"},
+			expectedIDs: []int64{3},
+		},
+		{
+			desc:        "since",
+			query:       Query{Since: start.Add(2 * time.Minute)},
+			expectedIDs: []int64{4, 3},
+		},
+		{
+			desc:        "limit",
+			query:       Query{Limit: 1},
+			expectedIDs: []int64{4},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ids := []int64{}
+			for _, entry := range l.Entries(test.query) {
+				ids = append(ids, entry.ID)
+			}
+			assert.Equal(t, test.expectedIDs, ids)
+		})
+	}
+
+	_, ok := l.Entry(1)
+	assert.False(t, ok, "the oldest entry is pruned")
+	entry, ok := l.Entry(2)
+	require.True(t, ok)
+	assert.Equal(t, "docker", entry.Provider)
+}
+
+func TestLogMaxAge(t *testing.T) {
+	l, err := NewLog("", "", 0, time.Hour)
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Record(Entry{Provider: "file", Timestamp: time.Now().Add(-2 * time.Hour)})
+	l.Record(Entry{Provider: "file", Timestamp: time.Now()})
+
+	entries := l.Entries(Query{})
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 2, entries[0].ID)
+}
+
+func TestLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "audit.log")
+
+	l, err := NewLog(filePath, "", 0, 0)
+	require.NoError(t, err)
+	l.Record(Entry{Provider: "file", Timestamp: time.Now().UTC(), Changes: []Change{{Kind: KindFrontend, Name: "frontend1", Action: ActionAdded}}})
+	l.Record(Entry{Provider: "docker", Timestamp: time.Now().UTC()})
+	require.NoError(t, l.Close())
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the history is loaded, within the limits, and the IDs go on
+	l, err = NewLog(filePath, "", 1, 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	entries := l.Entries(Query{})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "docker", entries[0].Provider)
+
+	entry := l.Record(Entry{Provider: "file", Timestamp: time.Now().UTC()})
+	assert.EqualValues(t, 3, entry.ID)
+}
+
+func TestLogURL(t *testing.T) {
+	entries := make(chan Entry, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var entry Entry
+		err := json.NewDecoder(req.Body).Decode(&entry)
+		require.NoError(t, err)
+		entries <- entry
+	}))
+	defer server.Close()
+
+	l, err := NewLog("", server.URL, 0, 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Record(Entry{Provider: "file", Timestamp: time.Now().UTC(), Changes: []Change{{Kind: KindBackend, Name: "backend1", Action: ActionRemoved}}})
+
+	select {
+	case entry := <-entries:
+		assert.EqualValues(t, 1, entry.ID)
+		assert.Equal(t, []Change{{Kind: KindBackend, Name: "backend1", Action: ActionRemoved}}, entry.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the entry was not posted")
+	}
+}
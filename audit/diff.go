@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/containous/traefik/types"
+)
+
+// DiffConfigurations returns the changes of the frontends and of the backends between two configurations of a provider,
+// the changed elements listing their changed fields.
+func DiffConfigurations(previous, next *types.Configuration) []Change {
+	if previous == nil {
+		previous = &types.Configuration{}
+	}
+	if next == nil {
+		next = &types.Configuration{}
+	}
+
+	previousFrontends := make(map[string]interface{}, len(previous.Frontends))
+	for name, frontend := range previous.Frontends {
+		previousFrontends[name] = frontend
+	}
+	nextFrontends := make(map[string]interface{}, len(next.Frontends))
+	for name, frontend := range next.Frontends {
+		nextFrontends[name] = frontend
+	}
+	previousBackends := make(map[string]interface{}, len(previous.Backends))
+	for name, backend := range previous.Backends {
+		previousBackends[name] = backend
+	}
+	nextBackends := make(map[string]interface{}, len(next.Backends))
+	for name, backend := range next.Backends {
+		nextBackends[name] = backend
+	}
+
+	changes := diffElements(KindFrontend, previousFrontends, nextFrontends)
+	return append(changes, diffElements(KindBackend, previousBackends, nextBackends)...)
+}
+
+func diffElements(kind string, previous, next map[string]interface{}) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(previous, next) {
+		previousElement, inPrevious := previous[name]
+		nextElement, inNext := next[name]
+		switch {
+		case !inNext:
+			changes = append(changes, Change{Kind: kind, Name: name, Action: ActionRemoved})
+		case !inPrevious:
+			changes = append(changes, Change{Kind: kind, Name: name, Action: ActionAdded})
+		default:
+			if fields := diffFields("", toJSONValue(previousElement), toJSONValue(nextElement)); len(fields) > 0 {
+				changes = append(changes, Change{Kind: kind, Name: name, Action: ActionChanged, Fields: fields})
+			}
+		}
+	}
+	return changes
+}
+
+// diffFields compares the objects field by field, and the other values as a whole, e.g. the lists.
+func diffFields(path string, previous, next interface{}) []FieldChange {
+	previousObject, previousIsObject := previous.(map[string]interface{})
+	nextObject, nextIsObject := next.(map[string]interface{})
+	if previousIsObject && nextIsObject {
+		var fields []FieldChange
+		for _, name := range sortedKeys(previousObject, nextObject) {
+			fieldPath := name
+			if len(path) > 0 {
+				fieldPath = path + "." + name
+			}
+			fields = append(fields, diffFields(fieldPath, previousObject[name], nextObject[name])...)
+		}
+		return fields
+	}
+
+	if reflect.DeepEqual(previous, next) {
+		return nil
+	}
+	return []FieldChange{{Path: path, Previous: previous, Next: next}}
+}
+
+// toJSONValue returns the value decoded from the JSON representation of the element.
+func toJSONValue(element interface{}) interface{} {
+	data, err := json.Marshal(element)
+	if err != nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
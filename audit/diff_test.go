@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfigurations(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		previous *types.Configuration
+		next     *types.Configuration
+		expected []Change
+	}{
+		{
+			desc: "no change",
+			previous: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+			},
+			next: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+			},
+		},
+		{
+			desc: "added and removed",
+			previous: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+			},
+			next: &types.Configuration{
+				Backends: map[string]*types.Backend{"backend1": {}},
+			},
+			expected: []Change{
+				{Kind: KindFrontend, Name: "frontend1", Action: ActionRemoved},
+				{Kind: KindBackend, Name: "backend1", Action: ActionAdded},
+			},
+		},
+		{
+			desc: "changed fields",
+			previous: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {
+					Backend:  "backend1",
+					Priority: 10,
+				}},
+				Backends: map[string]*types.Backend{"backend1": {
+					LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+					Servers:      map[string]types.Server{"server1": {URL: "http://10.0.0.1:80", Weight: 1}},
+				}},
+			},
+			next: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {
+					Backend:        "backend2",
+					Priority:       10,
+					PassHostHeader: true,
+				}},
+				Backends: map[string]*types.Backend{"backend1": {
+					LoadBalancer: &types.LoadBalancer{Method: "drr"},
+					Servers:      map[string]types.Server{"server1": {URL: "http://10.0.0.2:80", Weight: 1}},
+				}},
+			},
+			expected: []Change{
+				{Kind: KindFrontend, Name: "frontend1", Action: ActionChanged, Fields: []FieldChange{
+					{Path: "backend", Previous: "backend1", Next: "backend2"},
+					{Path: "passHostHeader", Next: true},
+				}},
+				{Kind: KindBackend, Name: "backend1", Action: ActionChanged, Fields: []FieldChange{
+					{Path: "loadBalancer.method", Previous: "wrr", Next: "drr"},
+					{Path: "servers.server1.url", Previous: "http://10.0.0.1:80", Next: "http://10.0.0.2:80"},
+				}},
+			},
+		},
+		{
+			desc: "nil configurations",
+			next: &types.Configuration{
+				Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+			},
+			expected: []Change{
+				{Kind: KindFrontend, Name: "frontend1", Action: ActionAdded},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, DiffConfigurations(test.previous, test.next))
+		})
+	}
+}
@@ -1,5 +1,7 @@
 // Code generated by go-bindata.
 // sources:
+// templates/azure.tmpl
+// templates/cloudmap.tmpl
 // templates/consul_catalog.tmpl
 // templates/docker.tmpl
 // templates/ecs.tmpl
@@ -54,6 +56,110 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _templatesAzureTmpl = []byte(`[backends]{{range $serviceName, $resources := .Services}}
+  [backends.backend-{{ $serviceName }}.loadbalancer]
+    method = "{{ getLoadBalancerMethod $resources }}"
+    {{if hasStickinessLabel $resources}}
+    [backends.backend-{{ $serviceName }}.loadbalancer.stickiness]
+      cookieName = "{{ getStickinessCookieName $resources }}"
+    {{end}}
+    {{ if hasHealthCheckLabels $resources }}
+    [backends.backend-{{ $serviceName }}.healthcheck]
+      path = "{{ getHealthCheckPath $resources }}"
+      interval = "{{ getHealthCheckInterval $resources }}"
+    {{end}}
+
+  {{range $resource := $resources}}
+    [backends.backend-{{ $serviceName }}.servers.server-{{ getServerName $resource }}]
+      url = "{{ getProtocol $resource }}://{{ getHost $resource }}:{{ getPort $resource }}"
+      weight = {{ getWeight $resource }}
+  {{end}}
+{{end}}
+
+[frontends]{{range $serviceName, $resources := .Services}}
+  {{ $resource := index $resources 0 }}
+    [frontends.frontend-{{ $serviceName }}]
+      backend = "backend-{{ $serviceName }}"
+      passHostHeader = {{ getPassHostHeader $resource }}
+      priority = {{ getPriority $resource }}
+      entryPoints = [{{range getEntryPoints $resource }}
+      "{{.}}",
+    {{end}}]
+      basicAuth = [{{range getBasicAuth $resource }}
+      "{{.}}",
+    {{end}}]
+    [frontends.frontend-{{ $serviceName }}.routes.route-frontend-{{ $serviceName }}]
+      rule = "{{ getFrontendRule $resource }}"
+{{end}}
+`)
+
+func templatesAzureTmplBytes() ([]byte, error) {
+	return _templatesAzureTmpl, nil
+}
+
+func templatesAzureTmpl() (*asset, error) {
+	bytes, err := templatesAzureTmplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "templates/azure.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _templatesCloudmapTmpl = []byte(`[backends]{{range $serviceName, $instances := .Services}}
+  [backends.backend-{{ $serviceName }}.loadbalancer]
+    method = "{{ getLoadBalancerMethod $instances }}"
+    {{if hasStickinessLabel $instances}}
+    [backends.backend-{{ $serviceName }}.loadbalancer.stickiness]
+      cookieName = "{{ getStickinessCookieName $instances }}"
+    {{end}}
+    {{ if hasHealthCheckLabels $instances }}
+    [backends.backend-{{ $serviceName }}.healthcheck]
+      path = "{{ getHealthCheckPath $instances }}"
+      interval = "{{ getHealthCheckInterval $instances }}"
+    {{end}}
+
+  {{range $instance := $instances}}
+    [backends.backend-{{ $serviceName }}.servers.server-{{ getServerName $instance }}]
+      url = "{{ getProtocol $instance }}://{{ getHost $instance }}:{{ getPort $instance }}"
+      weight = {{ getWeight $instance }}
+  {{end}}
+{{end}}
+
+[frontends]{{range $serviceName, $instances := .Services}}
+  {{ $instance := index $instances 0 }}
+    [frontends.frontend-{{ $serviceName }}]
+      backend = "backend-{{ $serviceName }}"
+      passHostHeader = {{ getPassHostHeader $instance }}
+      priority = {{ getPriority $instance }}
+      entryPoints = [{{range getEntryPoints $instance }}
+      "{{.}}",
+    {{end}}]
+      basicAuth = [{{range getBasicAuth $instance }}
+      "{{.}}",
+    {{end}}]
+    [frontends.frontend-{{ $serviceName }}.routes.route-frontend-{{ $serviceName }}]
+      rule = "{{ getFrontendRule $instance }}"
+{{end}}
+`)
+
+func templatesCloudmapTmplBytes() ([]byte, error) {
+	return _templatesCloudmapTmpl, nil
+}
+
+func templatesCloudmapTmpl() (*asset, error) {
+	bytes, err := templatesCloudmapTmplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "templates/cloudmap.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _templatesConsul_catalogTmpl = []byte(`[backends]
 {{range $index, $node := .Nodes}}
   [backends."backend-{{getBackend $node}}".servers."{{getBackendName $node $index}}"]
@@ -137,6 +243,8 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
       {{if hasStickinessLabel $backend}}
       [backends.backend-{{$backendName}}.loadBalancer.stickiness]
         cookieName = "{{getStickinessCookieName $backend}}"
+        header = "{{getStickinessHeader $backend}}"
+        query = "{{getStickinessQuery $backend}}"
       {{end}}
     {{end}}
 
@@ -146,6 +254,35 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
       extractorfunc = "{{getMaxConnExtractorFunc $backend}}"
     {{end}}
 
+    {{if hasBackendTLSLabels $backend}}
+    [backends.backend-{{$backendName}}.tls]
+      cert = '''{{getBackendTLSCert $backend}}'''
+      key = '''{{getBackendTLSKey $backend}}'''
+      ca = '''{{getBackendTLSCA $backend}}'''
+      insecureSkipVerify = {{getBackendTLSInsecure $backend}}
+    {{end}}
+
+    {{if hasBackendTransportLabels $backend}}
+    [backends.backend-{{$backendName}}.transport]
+      maxIdleConnsPerHost = {{getBackendTransportMaxIdleConns $backend}}
+      idleConnTimeout = "{{getBackendTransportIdleConnTimeout $backend}}"
+      tlsHandshakeTimeout = "{{getBackendTransportTLSHandshake $backend}}"
+      responseHeaderTimeout = "{{getBackendTransportResponseHeader $backend}}"
+      disableKeepAlives = {{getBackendTransportDisableKeepAlive $backend}}
+    {{end}}
+
+    {{if hasBackendResponseTimeoutLabels $backend}}
+    [backends.backend-{{$backendName}}.responseTimeout]
+      timeout = "{{getBackendResponseTimeout $backend}}"
+      deadlineHeader = "{{getBackendDeadlineHeader $backend}}"
+    {{end}}
+
+    {{if hasFailoverLabel $backend}}
+    [backends.backend-{{$backendName}}.failover]
+      backend = "backend-{{getFailoverBackend $backend}}"
+      minHealthyPercent = {{getFailoverMinHealthyPercent $backend}}
+    {{end}}
+
     {{$servers := index $backendServers $backendName}}
     {{range $serverName, $server := $servers}}
     {{if hasServices $server}}
@@ -185,6 +322,17 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
   basicAuth = [{{range getServiceBasicAuth $container $serviceName}}
     "{{.}}",
   {{end}}]
+  {{if hasFrontendResponseTimeoutLabels $container}}
+    [frontends."frontend-{{getServiceBackend $container $serviceName}}".responseTimeout]
+    timeout = "{{getFrontendResponseTimeout $container}}"
+    deadlineHeader = "{{getFrontendDeadlineHeader $container}}"
+  {{end}}
+  {{if hasTrafficSplit $container}}
+    [frontends."frontend-{{getServiceBackend $container $serviceName}}".trafficSplit]
+    {{range $backend, $percent := getTrafficSplit $container}}
+    "backend-{{$backend}}" = {{$percent}}
+    {{end}}
+  {{end}}
     [frontends."frontend-{{getServiceBackend $container $serviceName}}".routes."service-{{$serviceName | replace "/" "" | replace "." "-"}}"]
     rule = "{{getServiceFrontendRule $container $serviceName}}"
   {{end}}
@@ -205,6 +353,30 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
   basicAuth = [{{range getBasicAuth $container}}
     "{{.}}",
   {{end}}]
+  {{if hasFrontendResponseTimeoutLabels $container}}
+  [frontends."frontend-{{$frontend}}".responseTimeout]
+  timeout = "{{getFrontendResponseTimeout $container}}"
+  deadlineHeader = "{{getFrontendDeadlineHeader $container}}"
+  {{end}}
+  {{if hasTrafficSplit $container}}
+  [frontends."frontend-{{$frontend}}".trafficSplit]
+  {{range $backend, $percent := getTrafficSplit $container}}
+  "backend-{{$backend}}" = {{$percent}}
+  {{end}}
+  {{end}}
+  {{if hasMaintenanceLabels $container}}
+  [frontends."frontend-{{$frontend}}".maintenance]
+  enabled = {{getMaintenanceEnabled $container}}
+  statusCode = {{getMaintenanceStatusCode $container}}
+  body = """{{getMaintenanceBody $container}}"""
+  file = "{{getMaintenanceFile $container}}"
+  contentType = "{{getMaintenanceContentType $container}}"
+  bypassHeader = "{{getMaintenanceBypassHeader $container}}"
+  bypassValue = "{{getMaintenanceBypassValue $container}}"
+  bypassSourceRange = [{{range getMaintenanceBypassSourceRange $container}}
+    "{{.}}",
+  {{end}}]
+  {{end}}
   [frontends."frontend-{{$frontend}}".headers]
   {{if hasSSLRedirectHeaders $container}}
   SSLRedirect = {{getSSLRedirectHeaders $container}}
@@ -320,6 +492,10 @@ var _templatesEcsTmpl = []byte(`[backends]{{range $serviceName, $instances := .S
     [backends.backend-{{ $i.Name }}.servers.server-{{ $i.Name }}{{ $i.ID }}]
       url = "{{ getProtocol $i }}://{{ getHost $i }}:{{ getPort $i }}"
       weight = {{ getWeight $i}}
+    {{ $metadata := getMetadata $i }}{{ if $metadata }}
+    [backends.backend-{{ $i.Name }}.servers.server-{{ $i.Name }}{{ $i.ID }}.metadata]{{range $key, $value := $metadata}}
+      {{ $key }} = "{{ $value }}"{{end}}
+    {{end}}
   {{end}}
 {{end}}
 
@@ -401,7 +577,29 @@ var _templatesKubernetesTmpl = []byte(`[backends]{{range $backendName, $backend
       {{if $backend.LoadBalancer.Stickiness}}
       [backends."{{$backendName}}".loadbalancer.stickiness]
         cookieName = "{{$backend.LoadBalancer.Stickiness.CookieName}}"
+        header = "{{$backend.LoadBalancer.Stickiness.Header}}"
+        query = "{{$backend.LoadBalancer.Stickiness.Query}}"
       {{end}}
+    {{if $backend.TLS}}
+    [backends."{{$backendName}}".tls]
+      cert = '''{{$backend.TLS.Cert}}'''
+      key = '''{{$backend.TLS.Key}}'''
+      ca = '''{{$backend.TLS.CA}}'''
+      insecureSkipVerify = {{$backend.TLS.InsecureSkipVerify}}
+    {{end}}
+    {{if $backend.Transport}}
+    [backends."{{$backendName}}".transport]
+      maxIdleConnsPerHost = {{$backend.Transport.MaxIdleConnsPerHost}}
+      idleConnTimeout = "{{$backend.Transport.IdleConnTimeout.String}}"
+      tlsHandshakeTimeout = "{{$backend.Transport.TLSHandshakeTimeout.String}}"
+      responseHeaderTimeout = "{{$backend.Transport.ResponseHeaderTimeout.String}}"
+      disableKeepAlives = {{$backend.Transport.DisableKeepAlives}}
+    {{end}}
+    {{if $backend.ResponseTimeout}}
+    [backends."{{$backendName}}".responseTimeout]
+      timeout = "{{$backend.ResponseTimeout.Timeout.String}}"
+      deadlineHeader = "{{$backend.ResponseTimeout.DeadlineHeader}}"
+    {{end}}
     {{range $serverName, $server := $backend.Servers}}
     [backends."{{$backendName}}".servers."{{$serverName}}"]
     url = "{{$server.URL}}"
@@ -421,6 +619,11 @@ var _templatesKubernetesTmpl = []byte(`[backends]{{range $backendName, $backend
   whitelistSourceRange = [{{range $frontend.WhitelistSourceRange}}
     "{{.}}",
   {{end}}]
+  {{if $frontend.ResponseTimeout}}
+  [frontends."{{$frontendName}}".responseTimeout]
+  timeout = "{{$frontend.ResponseTimeout.Timeout.String}}"
+  deadlineHeader = "{{$frontend.ResponseTimeout.DeadlineHeader}}"
+  {{end}}
   [frontends."{{$frontendName}}".headers]
   SSLRedirect = {{$frontend.Headers.SSLRedirect}}
   SSLTemporaryRedirect = {{$frontend.Headers.SSLTemporaryRedirect}}
@@ -532,6 +735,13 @@ var _templatesKvTmpl = []byte(`{{$frontends := List .Prefix "/frontends/" }}
 {{end}}
 {{end}}
 
+{{$failover := Get "" . "/failover/" "backend"}}
+{{with $failover}}
+[backends."{{$backendName}}".failover]
+    backend = "{{$failover}}"
+    minHealthyPercent = {{Get "0" $backend "/failover/" "minhealthypercent"}}
+{{end}}
+
 {{range $servers}}
 [backends."{{$backendName}}".servers."{{Last .}}"]
     url = "{{Get "" . "/url"}}"
@@ -549,6 +759,13 @@ var _templatesKvTmpl = []byte(`{{$frontends := List .Prefix "/frontends/" }}
     entryPoints = [{{range $entryPoints}}
       "{{.}}",
     {{end}}]
+    {{$trafficSplit := List . "/trafficsplit/"}}
+    {{with $trafficSplit}}
+    [frontends."{{$frontend}}".trafficSplit]
+        {{range $trafficSplit}}
+        "{{Last .}}" = {{Get "0" .}}
+        {{end}}
+    {{end}}
     {{$routes := List . "/routes/"}}
         {{range $routes}}
         [frontends."{{$frontend}}".routes."{{Last .}}"]
@@ -831,6 +1048,8 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"templates/azure.tmpl":          templatesAzureTmpl,
+	"templates/cloudmap.tmpl":       templatesCloudmapTmpl,
 	"templates/consul_catalog.tmpl": templatesConsul_catalogTmpl,
 	"templates/docker.tmpl":         templatesDockerTmpl,
 	"templates/ecs.tmpl":            templatesEcsTmpl,
@@ -885,6 +1104,8 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"templates": {nil, map[string]*bintree{
+		"azure.tmpl":          {templatesAzureTmpl, map[string]*bintree{}},
+		"cloudmap.tmpl":       {templatesCloudmapTmpl, map[string]*bintree{}},
 		"consul_catalog.tmpl": {templatesConsul_catalogTmpl, map[string]*bintree{}},
 		"docker.tmpl":         {templatesDockerTmpl, map[string]*bintree{}},
 		"ecs.tmpl":            {templatesEcsTmpl, map[string]*bintree{}},
@@ -9,7 +9,9 @@ import (
 	"github.com/containous/traefik/configuration"
 	"github.com/containous/traefik/middlewares/accesslog"
 	"github.com/containous/traefik/ping"
+	"github.com/containous/traefik/provider/azure"
 	"github.com/containous/traefik/provider/boltdb"
+	"github.com/containous/traefik/provider/cloudmap"
 	"github.com/containous/traefik/provider/consul"
 	"github.com/containous/traefik/provider/docker"
 	"github.com/containous/traefik/provider/dynamodb"
@@ -17,11 +19,15 @@ import (
 	"github.com/containous/traefik/provider/etcd"
 	"github.com/containous/traefik/provider/eureka"
 	"github.com/containous/traefik/provider/file"
+	"github.com/containous/traefik/provider/http"
 	"github.com/containous/traefik/provider/kubernetes"
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
+	"github.com/containous/traefik/provider/plugin"
 	"github.com/containous/traefik/provider/rancher"
 	"github.com/containous/traefik/provider/rest"
+	"github.com/containous/traefik/provider/srv"
+	"github.com/containous/traefik/provider/xds"
 	"github.com/containous/traefik/provider/zk"
 	"github.com/containous/traefik/types"
 	sf "github.com/jjcollinge/servicefabric"
@@ -42,6 +48,12 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultDocker.Endpoint = "unix:///var/run/docker.sock"
 	defaultDocker.SwarmMode = false
 
+	// default Docker Compose
+	var defaultCompose docker.ComposeProvider
+	defaultCompose.Watch = true
+	defaultCompose.ExposedByDefault = true
+	defaultCompose.Constraints = types.Constraints{}
+
 	// default File
 	var defaultFile file.Provider
 	defaultFile.Watch = true
@@ -55,7 +67,10 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	var defaultWeb configuration.WebCompatibility
 	defaultWeb.Address = ":8080"
 	defaultWeb.Statistics = &types.Statistics{
-		RecentErrors: 10,
+		RecentErrors:         10,
+		BackendWindow:        flaeg.Duration(time.Minute),
+		BackendRetention:     flaeg.Duration(5 * time.Minute),
+		FrontendUnusedWindow: flaeg.Duration(24 * time.Hour),
 	}
 
 	// TODO: Deprecated - default Metrics
@@ -73,6 +88,11 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		},
 		InfluxDB: &types.InfluxDB{
 			Address:      "localhost:8089",
+			Protocol:     "udp",
+			PushInterval: "10s",
+		},
+		InfluxDB2: &types.InfluxDB2{
+			Address:      "http://localhost:8086",
 			PushInterval: "10s",
 		},
 	}
@@ -148,6 +168,21 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultECS.RefreshSeconds = 15
 	defaultECS.Constraints = types.Constraints{}
 
+	// default Cloud Map
+	var defaultCloudMap cloudmap.Provider
+	defaultCloudMap.Watch = true
+	defaultCloudMap.ExposedByDefault = true
+	defaultCloudMap.RefreshSeconds = 15
+	defaultCloudMap.Constraints = types.Constraints{}
+
+	// default Azure
+	var defaultAzure azure.Provider
+	defaultAzure.Watch = true
+	defaultAzure.ExposedByDefault = true
+	defaultAzure.RefreshSeconds = 15
+	defaultAzure.ContainerGroups = true
+	defaultAzure.Constraints = types.Constraints{}
+
 	//default Rancher
 	var defaultRancher rancher.Provider
 	defaultRancher.Watch = true
@@ -160,11 +195,30 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultDynamoDB.RefreshSeconds = 15
 	defaultDynamoDB.TableName = "traefik"
 	defaultDynamoDB.Watch = true
+	defaultDynamoDB.ReconcileSeconds = 300
 
 	// default Eureka
 	var defaultEureka eureka.Provider
 	defaultEureka.Delay = "30s"
 
+	// default HTTP
+	var defaultHTTP http.Provider
+	defaultHTTP.PollInterval = flaeg.Duration(5 * time.Second)
+	defaultHTTP.PollTimeout = flaeg.Duration(5 * time.Second)
+
+	// default SRV
+	var defaultSRV srv.Provider
+	defaultSRV.MinRefreshSeconds = 5
+	defaultSRV.MaxRefreshSeconds = 300
+
+	// default xDS
+	var defaultXDS xds.Provider
+	defaultXDS.NodeID = "traefik"
+
+	// default Plugin
+	var defaultPlugin plugin.Provider
+	defaultPlugin.Constraints = types.Constraints{}
+
 	// default ServiceFabric
 	var defaultServiceFabric servicefabric.Provider
 	defaultServiceFabric.APIVersion = sf.DefaultAPIVersion
@@ -202,6 +256,37 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		DialTimeout: flaeg.Duration(configuration.DefaultDialTimeout),
 	}
 
+	// default DNSResolver
+	dnsResolver := configuration.DNSResolver{
+		Timeout: flaeg.Duration(configuration.DefaultDNSTimeout),
+	}
+
+	// default ConfigurationWebhooks
+	configurationWebhooks := configuration.ConfigurationWebhooks{
+		Timeout: flaeg.Duration(configuration.DefaultWebhookTimeout),
+	}
+
+	// default Audit
+	audit := configuration.Audit{
+		MaxEntries: configuration.DefaultAuditMaxEntries,
+	}
+
+	// default Alerts
+	alerts := configuration.Alerts{
+		Interval:          flaeg.Duration(configuration.DefaultAlertsInterval),
+		Timeout:           flaeg.Duration(configuration.DefaultWebhookTimeout),
+		ErrorRatio:        0.05,
+		ErrorWindow:       flaeg.Duration(configuration.DefaultAlertsErrorWindow),
+		MinRequests:       10,
+		UnhealthyServers:  1,
+		CertificateExpiry: flaeg.Duration(14 * 24 * time.Hour),
+	}
+
+	// default OCSPStapling
+	ocspStapling := configuration.OCSPStapling{
+		Timeout: flaeg.Duration(configuration.DefaultOCSPTimeout),
+	}
+
 	// default LifeCycle
 	defaultLifeCycle := configuration.LifeCycle{
 		GraceTimeOut: flaeg.Duration(configuration.DefaultGraceTimeout),
@@ -213,7 +298,10 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		Dashboard:  true,
 	}
 	defaultAPI.Statistics = &types.Statistics{
-		RecentErrors: 10,
+		RecentErrors:         10,
+		BackendWindow:        flaeg.Duration(time.Minute),
+		BackendRetention:     flaeg.Duration(5 * time.Minute),
+		FrontendUnusedWindow: flaeg.Duration(24 * time.Hour),
 	}
 
 	// default Metrics
@@ -232,37 +320,55 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		},
 		InfluxDB: &types.InfluxDB{
 			Address:      "localhost:8089",
+			Protocol:     "udp",
+			PushInterval: "10s",
+		},
+		InfluxDB2: &types.InfluxDB2{
+			Address:      "http://localhost:8086",
 			PushInterval: "10s",
 		},
 	}
 
 	defaultConfiguration := configuration.GlobalConfiguration{
-		Docker:             &defaultDocker,
-		File:               &defaultFile,
-		Web:                &defaultWeb,
-		Rest:               &defaultRest,
-		Marathon:           &defaultMarathon,
-		Consul:             &defaultConsul,
-		ConsulCatalog:      &defaultConsulCatalog,
-		Etcd:               &defaultEtcd,
-		Zookeeper:          &defaultZookeeper,
-		Boltdb:             &defaultBoltDb,
-		Kubernetes:         &defaultKubernetes,
-		Mesos:              &defaultMesos,
-		ECS:                &defaultECS,
-		Rancher:            &defaultRancher,
-		Eureka:             &defaultEureka,
-		DynamoDB:           &defaultDynamoDB,
-		Retry:              &configuration.Retry{},
-		HealthCheck:        &healthCheck,
-		RespondingTimeouts: &respondingTimeouts,
-		ForwardingTimeouts: &forwardingTimeouts,
-		TraefikLog:         &defaultTraefikLog,
-		AccessLog:          &defaultAccessLog,
-		LifeCycle:          &defaultLifeCycle,
-		Ping:               &defaultPing,
-		API:                &defaultAPI,
-		Metrics:            &defaultMetrics,
+		Docker:                &defaultDocker,
+		Compose:               &defaultCompose,
+		File:                  &defaultFile,
+		Web:                   &defaultWeb,
+		Rest:                  &defaultRest,
+		Marathon:              &defaultMarathon,
+		Consul:                &defaultConsul,
+		ConsulCatalog:         &defaultConsulCatalog,
+		Etcd:                  &defaultEtcd,
+		Zookeeper:             &defaultZookeeper,
+		Boltdb:                &defaultBoltDb,
+		Kubernetes:            &defaultKubernetes,
+		Mesos:                 &defaultMesos,
+		ECS:                   &defaultECS,
+		CloudMap:              &defaultCloudMap,
+		Azure:                 &defaultAzure,
+		Rancher:               &defaultRancher,
+		Eureka:                &defaultEureka,
+		DynamoDB:              &defaultDynamoDB,
+		HTTP:                  &defaultHTTP,
+		SRV:                   &defaultSRV,
+		XDS:                   &defaultXDS,
+		Plugin:                &defaultPlugin,
+		Retry:                 &configuration.Retry{},
+		HealthCheck:           &healthCheck,
+		RespondingTimeouts:    &respondingTimeouts,
+		ForwardingTimeouts:    &forwardingTimeouts,
+		DNSResolver:           &dnsResolver,
+		ConfigurationWebhooks: &configurationWebhooks,
+		Audit:                 &audit,
+		Alerts:                &alerts,
+		OCSPStapling:          &ocspStapling,
+		VaultPKI:              &configuration.VaultPKI{},
+		TraefikLog:            &defaultTraefikLog,
+		AccessLog:             &defaultAccessLog,
+		LifeCycle:             &defaultLifeCycle,
+		Ping:                  &defaultPing,
+		API:                   &defaultAPI,
+		Metrics:               &defaultMetrics,
 	}
 
 	return &TraefikConfiguration{
@@ -61,6 +61,9 @@ Complete documentation is available at https://traefik.io`,
 	f.AddParser(reflect.TypeOf(ecs.Clusters{}), &ecs.Clusters{})
 	f.AddParser(reflect.TypeOf([]acme.Domain{}), &acme.Domains{})
 	f.AddParser(reflect.TypeOf(types.Buckets{}), &types.Buckets{})
+	f.AddParser(reflect.TypeOf(types.MetricsLabels{}), &types.MetricsLabels{})
+	f.AddParser(reflect.TypeOf(types.FieldNames{}), &types.FieldNames{})
+	f.AddParser(reflect.TypeOf(types.LogLevels{}), &types.LogLevels{})
 
 	//add commands
 	f.AddCommand(newVersionCmd())
@@ -204,6 +207,20 @@ func configureLogging(globalConfiguration *configuration.GlobalConfiguration) {
 	}
 	log.SetLevel(level)
 
+	// configure the log levels of the modules
+	if globalConfiguration.TraefikLog != nil && len(globalConfiguration.TraefikLog.Levels) > 0 {
+		moduleLevels := make(map[string]logrus.Level, len(globalConfiguration.TraefikLog.Levels))
+		for module, moduleLevel := range globalConfiguration.TraefikLog.Levels {
+			level, err := logrus.ParseLevel(strings.ToLower(moduleLevel))
+			if err != nil {
+				log.Errorf("Error getting the level of the module %s: %v", module, err)
+				continue
+			}
+			moduleLevels[module] = level
+		}
+		log.SetModuleLevels(moduleLevels)
+	}
+
 	// configure log output file
 	logFile := globalConfiguration.TraefikLogsFile
 	if len(logFile) > 0 {
@@ -217,6 +234,7 @@ func configureLogging(globalConfiguration *configuration.GlobalConfiguration) {
 	var formatter logrus.Formatter
 	if globalConfiguration.TraefikLog != nil && globalConfiguration.TraefikLog.Format == "json" {
 		formatter = &logrus.JSONFormatter{}
+		log.SetModuleField(true)
 	} else {
 		disableColors := false
 		if len(logFile) > 0 {
@@ -11,7 +11,9 @@ import (
 	"github.com/containous/traefik/api"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/ping"
+	"github.com/containous/traefik/provider/azure"
 	"github.com/containous/traefik/provider/boltdb"
+	"github.com/containous/traefik/provider/cloudmap"
 	"github.com/containous/traefik/provider/consul"
 	"github.com/containous/traefik/provider/docker"
 	"github.com/containous/traefik/provider/dynamodb"
@@ -19,11 +21,15 @@ import (
 	"github.com/containous/traefik/provider/etcd"
 	"github.com/containous/traefik/provider/eureka"
 	"github.com/containous/traefik/provider/file"
+	"github.com/containous/traefik/provider/http"
 	"github.com/containous/traefik/provider/kubernetes"
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
+	"github.com/containous/traefik/provider/plugin"
 	"github.com/containous/traefik/provider/rancher"
 	"github.com/containous/traefik/provider/rest"
+	"github.com/containous/traefik/provider/srv"
+	"github.com/containous/traefik/provider/xds"
 	"github.com/containous/traefik/provider/zk"
 	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
@@ -42,6 +48,24 @@ const (
 	// DefaultIdleTimeout before closing an idle connection.
 	DefaultIdleTimeout = 180 * time.Second
 
+	// DefaultDNSTimeout of a DNS query resolving a backend host name.
+	DefaultDNSTimeout = 5 * time.Second
+
+	// DefaultWebhookTimeout of a request notifying a webhook of the configuration changes.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultAlertsInterval between two evaluations of the alert conditions.
+	DefaultAlertsInterval = 30 * time.Second
+
+	// DefaultAlertsErrorWindow is the rolling window of the error ratio of the backends alerted.
+	DefaultAlertsErrorWindow = 5 * time.Minute
+
+	// DefaultAuditMaxEntries kept in the history of the applied configurations.
+	DefaultAuditMaxEntries = 1000
+
+	// DefaultOCSPTimeout of a request fetching the OCSP response of a certificate.
+	DefaultOCSPTimeout = 10 * time.Second
+
 	// DefaultGraceTimeout controls how long Traefik serves pending requests
 	// prior to shutting down.
 	DefaultGraceTimeout = 10 * time.Second
@@ -64,7 +88,9 @@ type GlobalConfiguration struct {
 	Cluster                   *types.Cluster          `description:"Enable clustering" export:"true"`
 	Constraints               types.Constraints       `description:"Filter services by constraint, matching with service tags" export:"true"`
 	ACME                      *acme.ACME              `description:"Enable ACME (Let's Encrypt): automatic SSL" export:"true"`
+	ACMEResolvers             map[string]*acme.ACME   `export:"true"` // Named ACME configurations selected by the frontends, only set in the configuration file
 	DefaultEntryPoints        DefaultEntryPoints      `description:"Entrypoints to be used by frontends that do not specify any entrypoint" export:"true"`
+	ShadowProviders           []string                `description:"Providers whose configuration is compared to the active configuration without being applied" export:"true"`
 	ProvidersThrottleDuration flaeg.Duration          `description:"Backends throttle duration: minimum duration between 2 events from providers before applying a new configuration. It avoids unnecessary reloads if multiples events are sent in a short amount of time." export:"true"`
 	MaxIdleConnsPerHost       int                     `description:"If non-zero, controls the maximum idle (keep-alive) to keep per-host.  If zero, DefaultMaxIdleConnsPerHost is used" export:"true"`
 	IdleTimeout               flaeg.Duration          `description:"(Deprecated) maximum amount of time an idle (keep-alive) connection will remain idle before closing itself." export:"true"` // Deprecated
@@ -74,8 +100,15 @@ type GlobalConfiguration struct {
 	HealthCheck               *HealthCheckConfig      `description:"Health check parameters" export:"true"`
 	RespondingTimeouts        *RespondingTimeouts     `description:"Timeouts for incoming requests to the Traefik instance" export:"true"`
 	ForwardingTimeouts        *ForwardingTimeouts     `description:"Timeouts for requests forwarded to the backend servers" export:"true"`
+	DNSResolver               *DNSResolver            `description:"Resolve the backend host names with custom DNS settings instead of the OS resolver" export:"true"`
+	ConfigurationWebhooks     *ConfigurationWebhooks  `description:"Notify webhooks of the changes of the applied configuration" export:"true"`
+	Audit                     *Audit                  `description:"Record an audit log of the changes of the applied configuration" export:"true"`
+	Alerts                    *Alerts                 `description:"Post alerts to a webhook when the error ratio of a backend, its servers failing their health check or the expiry of a certificate cross thresholds" export:"true"`
+	OCSPStapling              *OCSPStapling           `description:"Staple the OCSP responses of the served certificates in the TLS handshakes" export:"true"`
+	VaultPKI                  *VaultPKI               `description:"Request the certificates of an entry point from the PKI secrets engine of Vault" export:"true"`
 	Web                       *WebCompatibility       `description:"(Deprecated) Enable Web backend with default settings" export:"true"` // Deprecated
 	Docker                    *docker.Provider        `description:"Enable Docker backend with default settings" export:"true"`
+	Compose                   *docker.ComposeProvider `description:"Enable Docker Compose backend with default settings" export:"true"`
 	File                      *file.Provider          `description:"Enable File backend with default settings" export:"true"`
 	Marathon                  *marathon.Provider      `description:"Enable Marathon backend with default settings" export:"true"`
 	Consul                    *consul.Provider        `description:"Enable Consul backend with default settings" export:"true"`
@@ -87,12 +120,20 @@ type GlobalConfiguration struct {
 	Mesos                     *mesos.Provider         `description:"Enable Mesos backend with default settings" export:"true"`
 	Eureka                    *eureka.Provider        `description:"Enable Eureka backend with default settings" export:"true"`
 	ECS                       *ecs.Provider           `description:"Enable ECS backend with default settings" export:"true"`
+	CloudMap                  *cloudmap.Provider      `description:"Enable AWS Cloud Map backend with default settings" export:"true"`
+	Azure                     *azure.Provider         `description:"Enable Azure backend with default settings" export:"true"`
 	Rancher                   *rancher.Provider       `description:"Enable Rancher backend with default settings" export:"true"`
 	DynamoDB                  *dynamodb.Provider      `description:"Enable DynamoDB backend with default settings" export:"true"`
 	ServiceFabric             *servicefabric.Provider `description:"Enable Service Fabric backend with default settings" export:"true"`
 	Rest                      *rest.Provider          `description:"Enable Rest backend with default settings" export:"true"`
+	HTTP                      *http.Provider          `description:"Enable HTTP endpoint backend with default settings" export:"true"`
+	SRV                       *srv.Provider           `description:"Enable DNS SRV backend with default settings" export:"true"`
+	XDS                       *xds.Provider           `description:"Enable xDS backend with default settings" export:"true"`
+	Plugin                    *plugin.Provider        `description:"Enable Plugin backend with default settings" export:"true"`
 	API                       *api.Handler            `description:"Enable api/dashboard" export:"true"`
 	Metrics                   *types.Metrics          `description:"Enable a metrics exporter" export:"true"`
+	CacheStatus               *types.CacheStatus      `description:"Report upstream cache hit/miss status in metrics and access logs" export:"true"`
+	RequestID                 *types.RequestID        `description:"Generate and propagate a unique ID per request" export:"true"`
 	Ping                      *ping.Handler           `description:"Enable ping" export:"true"`
 }
 
@@ -317,6 +358,21 @@ func (ep *EntryPoints) Set(value string) error {
 			Optional: optional,
 		}
 	}
+	if len(result["ca_mode"]) > 0 && configTLS != nil {
+		configTLS.ClientCA.Mode = result["ca_mode"]
+	}
+	if len(result["tls_certificatesdirectory"]) > 0 && configTLS != nil {
+		configTLS.CertificatesDirectory = result["tls_certificatesdirectory"]
+	}
+	if len(result["tls_policy"]) > 0 && configTLS != nil {
+		configTLS.Policy = result["tls_policy"]
+	}
+	if len(result["tls_defaultcertificate"]) > 0 && configTLS != nil {
+		configTLS.DefaultCertificate = result["tls_defaultcertificate"]
+	}
+	if configTLS != nil {
+		configTLS.StrictSNI = toBool(result, "tls_strictsni")
+	}
 	var redirect *Redirect
 	if len(result["redirect_entrypoint"]) > 0 || len(result["redirect_regex"]) > 0 || len(result["redirect_replacement"]) > 0 {
 		redirect = &Redirect{
@@ -426,9 +482,10 @@ type EntryPoint struct {
 	Redirect             *Redirect   `export:"true"`
 	Auth                 *types.Auth `export:"true"`
 	WhitelistSourceRange []string
-	Compress             bool              `export:"true"`
-	ProxyProtocol        *ProxyProtocol    `export:"true"`
-	ForwardedHeaders     *ForwardedHeaders `export:"true"`
+	Compress             bool               `export:"true"`
+	Compression          *types.Compression `export:"true"`
+	ProxyProtocol        *ProxyProtocol     `export:"true"`
+	ForwardedHeaders     *ForwardedHeaders  `export:"true"`
 }
 
 // Redirect configures a redirection of an entry point to another, or to an URL
@@ -440,7 +497,13 @@ type Redirect struct {
 
 // Retry contains request retry config
 type Retry struct {
-	Attempts int `description:"Number of attempts" export:"true"`
+	Attempts        int            `description:"Number of attempts" export:"true"`
+	InitialInterval flaeg.Duration `description:"Wait before the first retry, doubled at each attempt with a random jitter (default: no wait)" export:"true"`
+	MaxInterval     flaeg.Duration `description:"Max wait between two attempts (default 10s)" export:"true"`
+	Budget          int            `description:"Max percentage of the requests retried, over 10 seconds (default: no budget)" export:"true"`
+	StatusCodes     []string       `description:"Response status codes retried in addition to the network errors, e.g. 503 or 502-504" export:"true"`
+	IdempotentOnly  bool           `description:"Retry only the requests with an idempotent method" export:"true"`
+	MaxBodySize     int64          `description:"Retry only the requests with a known body size up to this size, in bytes" export:"true"`
 }
 
 // HealthCheckConfig contains health check configuration parameters.
@@ -461,6 +524,85 @@ type ForwardingTimeouts struct {
 	ResponseHeaderTimeout flaeg.Duration `description:"The amount of time to wait for a server's response headers after fully writing the request (including its body, if any). If zero, no timeout exists" export:"true"`
 }
 
+// DNSResolver contains the DNS configuration used to resolve the backend host names.
+type DNSResolver struct {
+	Nameservers         []string       `description:"DNS servers (host:port) queried in order. Defaults to the ones of /etc/resolv.conf" export:"true"`
+	SearchDomains       []string       `description:"Domains appended to the backend host names without dot" export:"true"`
+	Timeout             flaeg.Duration `description:"The amount of time to wait for the answer of a DNS server. Defaults to 5 seconds" export:"true"`
+	MinTTL              flaeg.Duration `description:"Minimum duration the resolved addresses are cached, whatever the TTL of the records" export:"true"`
+	MaxTTL              flaeg.Duration `description:"Maximum duration the resolved addresses are cached. If zero, the TTL of the records is used" export:"true"`
+	NegativeTTL         flaeg.Duration `description:"Duration a host name which does not exist is cached. If zero, it is resolved again on each connection" export:"true"`
+	FallbackToLastKnown bool           `description:"Use the last known addresses of a host name when its resolution fails" export:"true"`
+	Refresh             bool           `description:"Re-resolve the host names in use when their TTL expires, and spread the connections across all their IPv4 and IPv6 addresses" export:"true"`
+}
+
+// ConfigurationWebhooks contains the webhooks notified of the frontends, backends and certificates
+// added, removed or changed each time a new configuration is applied.
+type ConfigurationWebhooks struct {
+	URLs    []string       `description:"URLs the changes are posted to"`
+	Secret  string         `description:"Secret used to sign the payloads with HMAC-SHA256"`
+	Timeout flaeg.Duration `description:"The amount of time to wait for the response of a webhook. Defaults to 10 seconds" export:"true"`
+}
+
+// Audit contains the configuration of the audit log recording, field by field, the changes
+// of the configuration applied for each provider.
+type Audit struct {
+	FilePath   string         `description:"File the audit entries are appended to, and the history is loaded from at startup" export:"true"`
+	URL        string         `description:"URL the audit entries are posted to"`
+	MaxEntries int            `description:"Maximum number of entries kept in the history. Defaults to 1000" export:"true"`
+	MaxAge     flaeg.Duration `description:"Maximum age of the entries kept in the history. If zero, the entries are kept whatever their age" export:"true"`
+}
+
+// Alerts contains the thresholds of the alerts posted to a webhook, and the payload template of the webhook.
+// An alert is posted when its condition starts, and again when it is resolved.
+type Alerts struct {
+	URL               string         `description:"URL the alerts are posted to"`
+	Template          string         `description:"Payload of the alerts: json, slack or pagerduty. Defaults to json" export:"true"`
+	RoutingKey        string         `description:"Integration key of the PagerDuty service, with the pagerduty template"`
+	Secret            string         `description:"Secret used to sign the payloads with HMAC-SHA256"`
+	Interval          flaeg.Duration `description:"Interval between two evaluations of the alert conditions. Defaults to 30 seconds" export:"true"`
+	Timeout           flaeg.Duration `description:"The amount of time to wait for the response of the webhook. Defaults to 10 seconds" export:"true"`
+	ErrorRatio        float64        `description:"Ratio of 5xx responses of a backend above which an alert is posted. If zero, the error ratio is not alerted" export:"true"`
+	ErrorWindow       flaeg.Duration `description:"Rolling window of the error ratio of the backends. Defaults to 5 minutes" export:"true"`
+	MinRequests       int            `description:"Minimum number of requests of a backend over the window for its error ratio to be alerted" export:"true"`
+	UnhealthyServers  int            `description:"Number of servers of a backend failing their health check from which an alert is posted. If zero, the health checks are not alerted" export:"true"`
+	CertificateExpiry flaeg.Duration `description:"Remaining validity of a served certificate under which an alert is posted. If zero, the certificates are not alerted" export:"true"`
+}
+
+// OCSPStapling contains the configuration of the stapling of the OCSP responses of the served certificates.
+type OCSPStapling struct {
+	Timeout flaeg.Duration `description:"The amount of time to wait for the response of an OCSP responder. Defaults to 10 seconds" export:"true"`
+}
+
+// VaultPKI contains the configuration of the certificates issued by the PKI secrets engine of Vault,
+// and renewed before they expire.
+type VaultPKI struct {
+	Address     string            `description:"Address of Vault. Defaults to the VAULT_ADDR environment variable"`
+	CA          tls.FileOrContent `description:"CA verifying the certificate of Vault. Defaults to the system root CAs"`
+	Auth        *VaultAuth        `description:"Authentication to Vault. Defaults to the token of the VAULT_TOKEN environment variable"`
+	Mount       string            `description:"Mount path of the PKI secrets engine. Defaults to pki" export:"true"`
+	Role        string            `description:"Role issuing the certificates, a template of the domain such as {{.Domain}}" export:"true"`
+	CommonName  string            `description:"Common name of the certificates, a template of the domain. Defaults to the domain" export:"true"`
+	TTL         flaeg.Duration    `description:"Lifetime requested for the certificates. Defaults to the TTL of the role" export:"true"`
+	RenewBefore flaeg.Duration    `description:"Renew a certificate this long before it expires. Defaults to a third of its lifetime" export:"true"`
+	EntryPoint  string            `description:"Entry point serving the certificates" export:"true"`
+	OnHostRule  bool              `description:"Request the certificates of the domains of the Host rules of the frontends of the entry point" export:"true"`
+	Domains     []string          `description:"Domains whose certificates are requested at startup" export:"true"`
+}
+
+// VaultAuth contains the authentication to Vault, with a token or the AppRole auth method.
+type VaultAuth struct {
+	Token   string        `description:"Vault token"`
+	AppRole *VaultAppRole `description:"Log in with the AppRole auth method"`
+}
+
+// VaultAppRole contains the credentials of the AppRole auth method.
+type VaultAppRole struct {
+	Mount    string `description:"Mount path of the AppRole auth method. Defaults to approle"`
+	RoleID   string `description:"Role ID"`
+	SecretID string `description:"Secret ID. Defaults to the VAULT_SECRET_ID environment variable"`
+}
+
 // ProxyProtocol contains Proxy-Protocol configuration
 type ProxyProtocol struct {
 	Insecure   bool
@@ -478,4 +620,5 @@ type ForwardedHeaders struct {
 type LifeCycle struct {
 	RequestAcceptGraceTimeout flaeg.Duration `description:"Duration to keep accepting requests before Traefik initiates the graceful shutdown procedure"`
 	GraceTimeOut              flaeg.Duration `description:"Duration to give active requests a chance to finish before Traefik stops"`
+	DrainTimeout              flaeg.Duration `description:"Duration to give the requests in flight to the servers removed from the configuration a chance to finish before cancelling them"`
 }
@@ -198,6 +198,61 @@ func TestEntryPoints_Set(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                   "client certificates requested",
+			expression:             "Name:foo TLS CA.Mode:request",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+				TLS: &tls.TLS{
+					ClientCA: tls.ClientCA{
+						Mode: "request",
+					},
+					Certificates: tls.Certificates{},
+				},
+			},
+		},
+		{
+			name:                   "certificates directory",
+			expression:             "Name:foo TLS TLS.CertificatesDirectory:/etc/traefik/certs",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+				TLS: &tls.TLS{
+					Certificates:          tls.Certificates{},
+					CertificatesDirectory: "/etc/traefik/certs",
+				},
+			},
+		},
+		{
+			name:                   "TLS policy",
+			expression:             "Name:foo TLS TLS.Policy:modern",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+				TLS: &tls.TLS{
+					Certificates: tls.Certificates{},
+					Policy:       "modern",
+				},
+			},
+		},
+		{
+			name:                   "strict SNI and default certificate",
+			expression:             "Name:foo TLS TLS.StrictSNI:true TLS.DefaultCertificate:example.com",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+				TLS: &tls.TLS{
+					Certificates:       tls.Certificates{},
+					DefaultCertificate: "example.com",
+					StrictSNI:          true,
+				},
+			},
+		},
 		{
 			name:                   "default",
 			expression:             "Name:foo",
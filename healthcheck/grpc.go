@@ -0,0 +1,192 @@
+package healthcheck
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"golang.org/x/net/http2"
+)
+
+// grpcHealthCheckPath is the path of the Check method of the standard gRPC health checking service.
+const grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+// grpcServing is the SERVING status of the HealthCheckResponse message.
+const grpcServing = 1
+
+// grpcTransport is the HTTP/2 transport of the gRPC health checks:
+// the https servers are reached over TLS, the http ones over h2c (HTTP/2 over cleartext TCP).
+type grpcTransport struct {
+	tls *http2.Transport
+	h2c *http2.Transport
+}
+
+// NewGRPCTransport creates the transport of the gRPC health checks, reaching the https servers
+// with the given TLS configuration.
+func NewGRPCTransport(tlsConfig *tls.Config) http.RoundTripper {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	return &grpcTransport{
+		tls: &http2.Transport{TLSClientConfig: tlsConfig},
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+		},
+	}
+}
+
+func (t *grpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" {
+		return t.tls.RoundTrip(req)
+	}
+	return t.h2c.RoundTrip(req)
+}
+
+// newGRPCRequest creates the request calling the Check method of the gRPC health checking service of a server.
+func (backend *BackendHealthCheck) newGRPCRequest(serverURL *url.URL) (*http.Request, error) {
+	u := &url.URL{Scheme: serverURL.Scheme, Host: serverURL.Host, Path: grpcHealthCheckPath}
+	if backend.Port != 0 {
+		u.Host = net.JoinHostPort(serverURL.Hostname(), strconv.Itoa(backend.Port))
+	}
+
+	// HealthCheckRequest message, its field 1 being the name of the checked service
+	var message []byte
+	if len(backend.GRPCService) > 0 {
+		message = append([]byte{0x0a}, encodeVarint(uint64(len(backend.GRPCService)))...)
+		message = append(message, backend.GRPCService...)
+	}
+	// length-prefixed message: uncompressed flag and big-endian length
+	body := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(message)))
+	body = append(body, message...)
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range backend.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	return req, nil
+}
+
+// checkGRPCResponse returns an error unless the response of the gRPC health checking service reports the server as serving.
+func checkGRPCResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc") {
+		return fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+
+	// the status is in the trailers, or in the headers of a trailers-only response
+	status := resp.Trailer.Get("Grpc-Status")
+	if len(status) == 0 {
+		status = resp.Header.Get("Grpc-Status")
+	}
+	if status != "0" {
+		message := resp.Trailer.Get("Grpc-Message")
+		if len(message) == 0 {
+			message = resp.Header.Get("Grpc-Message")
+		}
+		return fmt.Errorf("gRPC status %q: %s", status, message)
+	}
+
+	if len(body) < 5 || body[0] != 0 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+		return errors.New("invalid gRPC response message")
+	}
+	servingStatus, err := decodeServingStatus(body[5:])
+	if err != nil {
+		return err
+	}
+	if servingStatus != grpcServing {
+		return fmt.Errorf("serving status %d", servingStatus)
+	}
+	return nil
+}
+
+// decodeServingStatus decodes the status (field 1) of a HealthCheckResponse message, UNKNOWN (0) when absent.
+func decodeServingStatus(message []byte) (uint64, error) {
+	var status uint64
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, errors.New("invalid gRPC response message")
+		}
+		message = message[n:]
+
+		switch key & 0x7 {
+		case 0: // varint
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, errors.New("invalid gRPC response message")
+			}
+			message = message[n:]
+			if key>>3 == 1 {
+				status = value
+			}
+		case 2: // length-delimited
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, errors.New("invalid gRPC response message")
+			}
+			message = message[n+int(length):]
+		default:
+			return 0, fmt.Errorf("unexpected wire type %d in gRPC response message", key&0x7)
+		}
+	}
+	return status, nil
+}
+
+func encodeVarint(value uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, value)]
+}
+
+func checkGRPCHealth(serverURL *url.URL, backend *BackendHealthCheck) bool {
+	client := http.Client{
+		Timeout:   backend.requestTimeout,
+		Transport: backend.Options.Transport,
+	}
+	req, err := backend.newGRPCRequest(serverURL)
+	if err != nil {
+		log.Errorf("Failed to create gRPC request [%s] for healthcheck: %s", serverURL, err)
+		return false
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Debugf("gRPC healthcheck of %s failed: %v", serverURL, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if err := checkGRPCResponse(resp); err != nil {
+		log.Debugf("gRPC healthcheck of %s failed: %v", serverURL, err)
+		return false
+	}
+	return true
+}
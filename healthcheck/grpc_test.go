@@ -0,0 +1,192 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"golang.org/x/net/http2"
+)
+
+func TestCheckGRPCHealth(t *testing.T) {
+	tests := []struct {
+		desc          string
+		service       string
+		grpcStatus    string
+		servingStatus byte
+		expected      bool
+	}{
+		{
+			desc:          "serving server",
+			grpcStatus:    "0",
+			servingStatus: 1,
+			expected:      true,
+		},
+		{
+			desc:          "serving service",
+			service:       "helloworld.Greeter",
+			grpcStatus:    "0",
+			servingStatus: 1,
+			expected:      true,
+		},
+		{
+			desc:          "not serving service",
+			service:       "helloworld.Greeter",
+			grpcStatus:    "0",
+			servingStatus: 2,
+			expected:      false,
+		},
+		{
+			desc:       "unknown service",
+			service:    "unknown",
+			grpcStatus: "5",
+			expected:   false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		for _, scheme := range []string{"http", "https"} {
+			scheme := scheme
+			t.Run(test.desc+" over "+scheme, func(t *testing.T) {
+				t.Parallel()
+
+				handler := newGRPCHealthHandler(t, test.service, test.grpcStatus, test.servingStatus)
+				var serverURL string
+				if scheme == "https" {
+					ts := httptest.NewUnstartedServer(handler)
+					if err := http2.ConfigureServer(ts.Config, nil); err != nil {
+						t.Fatal(err)
+					}
+					ts.TLS = ts.Config.TLSConfig
+					ts.StartTLS()
+					defer ts.Close()
+					serverURL = ts.URL
+				} else {
+					listener := newH2CListener(t, handler)
+					defer listener.Close()
+					serverURL = "http://" + listener.Addr().String()
+				}
+
+				backend := NewBackendHealthCheck(Options{
+					GRPC:        true,
+					GRPCService: test.service,
+					Transport:   NewGRPCTransport(&tls.Config{InsecureSkipVerify: true}),
+					Timeout:     time.Second,
+				})
+				if actual := checkHealth(testhelpers.MustParseURL(serverURL), backend); actual != test.expected {
+					t.Errorf("got healthy %t, wanted %t", actual, test.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeServingStatus(t *testing.T) {
+	tests := []struct {
+		desc        string
+		message     []byte
+		expected    uint64
+		expectedErr bool
+	}{
+		{
+			desc:     "empty message",
+			expected: 0,
+		},
+		{
+			desc:     "serving",
+			message:  []byte{0x08, 0x01},
+			expected: 1,
+		},
+		{
+			desc:     "unknown length-delimited field",
+			message:  []byte{0x12, 0x02, 'o', 'k', 0x08, 0x02},
+			expected: 2,
+		},
+		{
+			desc:        "truncated message",
+			message:     []byte{0x12, 0x05, 'o'},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := decodeServingStatus(test.message)
+			if test.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != test.expected {
+				t.Errorf("got status %d, wanted %d", actual, test.expected)
+			}
+		})
+	}
+}
+
+// newGRPCHealthHandler answers the gRPC health checks of the given service.
+func newGRPCHealthHandler(t *testing.T, service, grpcStatus string, servingStatus byte) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.ProtoMajor != 2 || req.URL.Path != grpcHealthCheckPath || req.Header.Get("Content-Type") != "application/grpc" {
+			t.Errorf("unexpected request %s %s %s", req.Proto, req.URL.Path, req.Header.Get("Content-Type"))
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil || len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("invalid request message %v: %v", body, err)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var requested string
+		if message := body[5:]; len(message) > 2 {
+			requested = string(message[2:])
+		}
+		if requested != service {
+			t.Errorf("got service %q, wanted %q", requested, service)
+		}
+
+		rw.Header().Set("Content-Type", "application/grpc")
+		rw.Header().Set("Trailer", "Grpc-Status")
+		rw.WriteHeader(http.StatusOK)
+		if grpcStatus == "0" {
+			rw.Write([]byte{0, 0, 0, 0, 2, 0x08, servingStatus})
+		}
+		rw.Header().Set("Grpc-Status", grpcStatus)
+	})
+}
+
+// newH2CListener serves the handler over HTTP/2 without TLS.
+func newH2CListener(t *testing.T, handler http.Handler) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http2.Server{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+	return listener
+}
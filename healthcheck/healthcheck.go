@@ -3,10 +3,15 @@ package healthcheck
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,28 +31,57 @@ func GetHealthCheck() *HealthCheck {
 	return singleton
 }
 
+// maxBodySize is the size of the beginning of the response body matched by the body regexp.
+const maxBodySize = 1 << 20
+
 // Options are the public health check options.
 type Options struct {
 	Path      string
 	Port      int
 	Transport http.RoundTripper
 	Interval  time.Duration
-	LB        LoadBalancer
+	Timeout   time.Duration
+	Method    string
+	// Headers are the headers of the request, the Host header setting its host
+	Headers map[string]string
+	// StatusCodes are the ranges of the status codes of a healthy server (default: 200)
+	StatusCodes [][2]int
+	// Body matches the response body of a healthy server
+	Body *regexp.Regexp
+	// GRPC checks the servers with the standard gRPC health checking protocol, instead of an HTTP request
+	GRPC bool
+	// GRPCService is the name of the service checked with the gRPC protocol (default: the whole server)
+	GRPCService string
+	// Backend is the name of the backend of the servers checked
+	Backend string
+	LB      LoadBalancer
 }
 
 func (opt Options) String() string {
-	return fmt.Sprintf("[Path: %s Port: %d Interval: %s]", opt.Path, opt.Port, opt.Interval)
+	if opt.GRPC {
+		return fmt.Sprintf("[gRPC Service: %q Port: %d Interval: %s]", opt.GRPCService, opt.Port, opt.Interval)
+	}
+	return fmt.Sprintf("[Method: %s Path: %s Port: %d Interval: %s]", opt.method(), opt.Path, opt.Port, opt.Interval)
+}
+
+func (opt Options) method() string {
+	if len(opt.Method) == 0 {
+		return http.MethodGet
+	}
+	return opt.Method
 }
 
 // BackendHealthCheck HealthCheck configuration for a backend
 type BackendHealthCheck struct {
 	Options
+	mutex          sync.RWMutex
 	disabledURLs   []*url.URL
 	requestTimeout time.Duration
 }
 
 //HealthCheck struct
 type HealthCheck struct {
+	mutex    sync.RWMutex
 	Backends map[string]*BackendHealthCheck
 	cancel   context.CancelFunc
 }
@@ -67,15 +101,21 @@ func newHealthCheck() *HealthCheck {
 
 // NewBackendHealthCheck Instantiate a new BackendHealthCheck
 func NewBackendHealthCheck(options Options) *BackendHealthCheck {
+	requestTimeout := options.Timeout
+	if requestTimeout <= 0 {
+		requestTimeout = 5 * time.Second
+	}
 	return &BackendHealthCheck{
 		Options:        options,
-		requestTimeout: 5 * time.Second,
+		requestTimeout: requestTimeout,
 	}
 }
 
 //SetBackendsConfiguration set backends configuration
 func (hc *HealthCheck) SetBackendsConfiguration(parentCtx context.Context, backends map[string]*BackendHealthCheck) {
+	hc.mutex.Lock()
 	hc.Backends = backends
+	hc.mutex.Unlock()
 	if hc.cancel != nil {
 		hc.cancel()
 	}
@@ -91,6 +131,33 @@ func (hc *HealthCheck) SetBackendsConfiguration(parentCtx context.Context, backe
 	}
 }
 
+// UnhealthyServers returns the URLs of the servers failing their health check, by backend name.
+func (hc *HealthCheck) UnhealthyServers() map[string][]string {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	unhealthy := make(map[string]map[string]bool)
+	for _, backend := range hc.Backends {
+		backend.mutex.RLock()
+		for _, disabledURL := range backend.disabledURLs {
+			if unhealthy[backend.Backend] == nil {
+				unhealthy[backend.Backend] = make(map[string]bool)
+			}
+			unhealthy[backend.Backend][disabledURL.String()] = true
+		}
+		backend.mutex.RUnlock()
+	}
+
+	servers := make(map[string][]string, len(unhealthy))
+	for backendName, urls := range unhealthy {
+		for u := range urls {
+			servers[backendName] = append(servers[backendName], u)
+		}
+		sort.Strings(servers[backendName])
+	}
+	return servers
+}
+
 func (hc *HealthCheck) execute(ctx context.Context, backendID string, backend *BackendHealthCheck) {
 	log.Debugf("Initial healthcheck for currentBackend %s ", backendID)
 	checkBackend(backend)
@@ -110,8 +177,12 @@ func (hc *HealthCheck) execute(ctx context.Context, backendID string, backend *B
 
 func checkBackend(currentBackend *BackendHealthCheck) {
 	enabledURLs := currentBackend.LB.Servers()
+	currentBackend.mutex.RLock()
+	disabledURLs := currentBackend.disabledURLs
+	currentBackend.mutex.RUnlock()
+
 	var newDisabledURLs []*url.URL
-	for _, url := range currentBackend.disabledURLs {
+	for _, url := range disabledURLs {
 		if checkHealth(url, currentBackend) {
 			log.Debugf("HealthCheck is up [%s]: Upsert in server list", url.String())
 			currentBackend.LB.UpsertServer(url, roundrobin.Weight(1))
@@ -120,32 +191,80 @@ func checkBackend(currentBackend *BackendHealthCheck) {
 			newDisabledURLs = append(newDisabledURLs, url)
 		}
 	}
-	currentBackend.disabledURLs = newDisabledURLs
 
 	for _, url := range enabledURLs {
 		if !checkHealth(url, currentBackend) {
 			log.Warnf("HealthCheck has failed [%s]: Remove from server list", url.String())
 			currentBackend.LB.RemoveServer(url)
-			currentBackend.disabledURLs = append(currentBackend.disabledURLs, url)
+			newDisabledURLs = append(newDisabledURLs, url)
 		}
 	}
+
+	currentBackend.mutex.Lock()
+	currentBackend.disabledURLs = newDisabledURLs
+	currentBackend.mutex.Unlock()
 }
 
 func (backend *BackendHealthCheck) newRequest(serverURL *url.URL) (*http.Request, error) {
-	if backend.Port == 0 {
-		return http.NewRequest(http.MethodGet, serverURL.String()+backend.Path, nil)
+	rawURL := serverURL.String() + backend.Path
+	if backend.Port != 0 {
+		// copy the url and add the port to the host
+		u := &url.URL{}
+		*u = *serverURL
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(backend.Port))
+		u.Path = u.Path + backend.Path
+		rawURL = u.String()
+	}
+
+	req, err := http.NewRequest(backend.method(), rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range backend.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
 	}
+	return req, nil
+}
 
-	// copy the url and add the port to the host
-	u := &url.URL{}
-	*u = *serverURL
-	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(backend.Port))
-	u.Path = u.Path + backend.Path
+// isHealthy returns whether the status code and the body of the response are the ones of a healthy server.
+func (backend *BackendHealthCheck) isHealthy(resp *http.Response) bool {
+	if len(backend.StatusCodes) == 0 {
+		if resp.StatusCode != http.StatusOK {
+			return false
+		}
+	} else {
+		var expected bool
+		for _, block := range backend.StatusCodes {
+			if resp.StatusCode >= block[0] && resp.StatusCode <= block[1] {
+				expected = true
+				break
+			}
+		}
+		if !expected {
+			return false
+		}
+	}
 
-	return http.NewRequest(http.MethodGet, u.String(), nil)
+	if backend.Body == nil {
+		return true
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		log.Debugf("Failed to read the healthcheck response body: %v", err)
+		return false
+	}
+	return backend.Body.Match(body)
 }
 
 func checkHealth(serverURL *url.URL, backend *BackendHealthCheck) bool {
+	if backend.GRPC {
+		return checkGRPCHealth(serverURL, backend)
+	}
+
 	client := http.Client{
 		Timeout:   backend.requestTimeout,
 		Transport: backend.Options.Transport,
@@ -157,9 +276,10 @@ func checkHealth(serverURL *url.URL, backend *BackendHealthCheck) bool {
 	}
 
 	resp, err := client.Do(req)
-
-	if err == nil {
-		defer resp.Body.Close()
+	if err != nil {
+		return false
 	}
-	return err == nil && resp.StatusCode == http.StatusOK
+	defer resp.Body.Close()
+
+	return backend.isHealthy(resp)
 }
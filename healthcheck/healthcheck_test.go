@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"regexp"
 	"sync"
 	"testing"
 	"time"
@@ -188,6 +190,124 @@ func TestNewRequest(t *testing.T) {
 	}
 }
 
+func TestUnhealthyServers(t *testing.T) {
+	backend1 := NewBackendHealthCheck(Options{Backend: "backend1"})
+	backend1.disabledURLs = []*url.URL{testhelpers.MustParseURL("http://10.0.0.2:80"), testhelpers.MustParseURL("http://10.0.0.1:80")}
+	// the same backend checked for another entry point
+	backend1https := NewBackendHealthCheck(Options{Backend: "backend1"})
+	backend1https.disabledURLs = []*url.URL{testhelpers.MustParseURL("http://10.0.0.1:80")}
+	backend2 := NewBackendHealthCheck(Options{Backend: "backend2"})
+
+	check := HealthCheck{
+		Backends: map[string]*BackendHealthCheck{
+			"httpbackend1":  backend1,
+			"httpsbackend1": backend1https,
+			"httpbackend2":  backend2,
+		},
+	}
+
+	unhealthy := check.UnhealthyServers()
+	if len(unhealthy) != 1 {
+		t.Fatalf("got %d backends with unhealthy servers, wanted 1", len(unhealthy))
+	}
+	want := []string{"http://10.0.0.1:80", "http://10.0.0.2:80"}
+	if !reflect.DeepEqual(unhealthy["backend1"], want) {
+		t.Errorf("got unhealthy servers %v, wanted %v", unhealthy["backend1"], want)
+	}
+}
+
+func TestNewRequestMethodAndHeaders(t *testing.T) {
+	backend := NewBackendHealthCheck(Options{
+		Path:    "/health",
+		Method:  http.MethodHead,
+		Headers: map[string]string{"Host": "health.local", "X-Check": "traefik"},
+	})
+
+	req, err := backend.newRequest(testhelpers.MustParseURL("http://backend1:80"))
+	if err != nil {
+		t.Fatalf("failed to create new backend request: %s", err)
+	}
+
+	if req.Method != http.MethodHead {
+		t.Errorf("got method %s, wanted %s", req.Method, http.MethodHead)
+	}
+	if req.Host != "health.local" {
+		t.Errorf("got host %s, wanted health.local", req.Host)
+	}
+	if req.Header.Get("X-Check") != "traefik" {
+		t.Errorf("got header %s, wanted traefik", req.Header.Get("X-Check"))
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	tests := []struct {
+		desc        string
+		statusCodes [][2]int
+		body        string
+		status      int
+		respBody    string
+		expected    bool
+	}{
+		{
+			desc:     "default status",
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			desc:     "default status, no content",
+			status:   http.StatusNoContent,
+			expected: false,
+		},
+		{
+			desc:        "status range",
+			statusCodes: [][2]int{{200, 299}},
+			status:      http.StatusNoContent,
+			expected:    true,
+		},
+		{
+			desc:        "status out of range",
+			statusCodes: [][2]int{{200, 299}, {301, 301}},
+			status:      http.StatusFound,
+			expected:    false,
+		},
+		{
+			desc:     "body matching",
+			body:     `"status":\s*"up"`,
+			status:   http.StatusOK,
+			respBody: `{"status": "up"}`,
+			expected: true,
+		},
+		{
+			desc:     "body not matching",
+			body:     `"status":\s*"up"`,
+			status:   http.StatusOK,
+			respBody: `{"status": "down"}`,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			options := Options{StatusCodes: test.statusCodes}
+			if len(test.body) > 0 {
+				options.Body = regexp.MustCompile(test.body)
+			}
+			backend := NewBackendHealthCheck(options)
+
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(test.status)
+			recorder.WriteString(test.respBody)
+
+			if actual := backend.isHealthy(recorder.Result()); actual != test.expected {
+				t.Errorf("got healthy %t, wanted %t", actual, test.expected)
+			}
+		})
+	}
+}
+
 type testLoadBalancer struct {
 	// RWMutex needed due to parallel test execution: Both the system-under-test
 	// and the test assertions reference the counters.
@@ -23,27 +23,36 @@ func init() {
 
 // Context sets the Context of the logger
 func Context(context interface{}) *logrus.Entry {
-	return logger.WithField("context", context)
+	return moduleEntry().WithField("context", context)
 }
 
 // SetOutput sets the standard logger output.
 func SetOutput(out io.Writer) {
 	logrus.SetOutput(out)
+	setLevelLoggersOutput(out, nil)
 }
 
 // SetFormatter sets the standard logger formatter.
 func SetFormatter(formatter logrus.Formatter) {
 	logrus.SetFormatter(formatter)
+	setLevelLoggersOutput(nil, formatter)
 }
 
-// SetLevel sets the standard logger level.
+// SetLevel sets the default level, of the modules without a level of their own.
 func SetLevel(level logrus.Level) {
-	logrus.SetLevel(level)
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	defaultLevel = level
+	applyLevels()
 }
 
-// GetLevel returns the standard logger level.
+// GetLevel returns the default level, of the modules without a level of their own.
 func GetLevel() logrus.Level {
-	return logrus.GetLevel()
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+
+	return defaultLevel
 }
 
 // AddHook adds a hook to the standard logger hooks.
@@ -53,7 +62,7 @@ func AddHook(hook logrus.Hook) {
 
 // WithError creates an entry from the standard logger and adds an error to it, using the value defined in ErrorKey as key.
 func WithError(err error) *logrus.Entry {
-	return logger.WithError(err)
+	return moduleEntry().WithError(err)
 }
 
 // WithField creates an entry from the standard logger and adds a field to
@@ -62,7 +71,7 @@ func WithError(err error) *logrus.Entry {
 // Note that it doesn't log until you call Debug, Print, Info, Warn, Fatal
 // or Panic on the Entry it returns.
 func WithField(key string, value interface{}) *logrus.Entry {
-	return logger.WithField(key, value)
+	return moduleEntry().WithField(key, value)
 }
 
 // WithFields creates an entry from the standard logger and adds multiple
@@ -72,127 +81,127 @@ func WithField(key string, value interface{}) *logrus.Entry {
 // Note that it doesn't log until you call Debug, Print, Info, Warn, Fatal
 // or Panic on the Entry it returns.
 func WithFields(fields logrus.Fields) *logrus.Entry {
-	return logger.WithFields(fields)
+	return moduleEntry().WithFields(fields)
 }
 
 // Debug logs a message at level Debug on the standard logger.
 func Debug(args ...interface{}) {
-	logger.Debug(args...)
+	moduleEntry().Debug(args...)
 }
 
 // Print logs a message at level Info on the standard logger.
 func Print(args ...interface{}) {
-	logger.Print(args...)
+	moduleEntry().Print(args...)
 }
 
 // Info logs a message at level Info on the standard logger.
 func Info(args ...interface{}) {
-	logger.Info(args...)
+	moduleEntry().Info(args...)
 }
 
 // Warn logs a message at level Warn on the standard logger.
 func Warn(args ...interface{}) {
-	logger.Warn(args...)
+	moduleEntry().Warn(args...)
 }
 
 // Warning logs a message at level Warn on the standard logger.
 func Warning(args ...interface{}) {
-	logger.Warning(args...)
+	moduleEntry().Warning(args...)
 }
 
 // Error logs a message at level Error on the standard logger.
 func Error(args ...interface{}) {
-	logger.Error(args...)
+	moduleEntry().Error(args...)
 }
 
 // Panic logs a message at level Panic on the standard logger.
 func Panic(args ...interface{}) {
-	logger.Panic(args...)
+	moduleEntry().Panic(args...)
 }
 
 // Fatal logs a message at level Fatal on the standard logger.
 func Fatal(args ...interface{}) {
-	logger.Fatal(args...)
+	moduleEntry().Fatal(args...)
 }
 
 // Debugf logs a message at level Debug on the standard logger.
 func Debugf(format string, args ...interface{}) {
-	logger.Debugf(format, args...)
+	moduleEntry().Debugf(format, args...)
 }
 
 // Printf logs a message at level Info on the standard logger.
 func Printf(format string, args ...interface{}) {
-	logger.Printf(format, args...)
+	moduleEntry().Printf(format, args...)
 }
 
 // Infof logs a message at level Info on the standard logger.
 func Infof(format string, args ...interface{}) {
-	logger.Infof(format, args...)
+	moduleEntry().Infof(format, args...)
 }
 
 // Warnf logs a message at level Warn on the standard logger.
 func Warnf(format string, args ...interface{}) {
-	logger.Warnf(format, args...)
+	moduleEntry().Warnf(format, args...)
 }
 
 // Warningf logs a message at level Warn on the standard logger.
 func Warningf(format string, args ...interface{}) {
-	logger.Warningf(format, args...)
+	moduleEntry().Warningf(format, args...)
 }
 
 // Errorf logs a message at level Error on the standard logger.
 func Errorf(format string, args ...interface{}) {
-	logger.Errorf(format, args...)
+	moduleEntry().Errorf(format, args...)
 }
 
 // Panicf logs a message at level Panic on the standard logger.
 func Panicf(format string, args ...interface{}) {
-	logger.Panicf(format, args...)
+	moduleEntry().Panicf(format, args...)
 }
 
 // Fatalf logs a message at level Fatal on the standard logger.
 func Fatalf(format string, args ...interface{}) {
-	logger.Fatalf(format, args...)
+	moduleEntry().Fatalf(format, args...)
 }
 
 // Debugln logs a message at level Debug on the standard logger.
 func Debugln(args ...interface{}) {
-	logger.Debugln(args...)
+	moduleEntry().Debugln(args...)
 }
 
 // Println logs a message at level Info on the standard logger.
 func Println(args ...interface{}) {
-	logger.Println(args...)
+	moduleEntry().Println(args...)
 }
 
 // Infoln logs a message at level Info on the standard logger.
 func Infoln(args ...interface{}) {
-	logger.Infoln(args...)
+	moduleEntry().Infoln(args...)
 }
 
 // Warnln logs a message at level Warn on the standard logger.
 func Warnln(args ...interface{}) {
-	logger.Warnln(args...)
+	moduleEntry().Warnln(args...)
 }
 
 // Warningln logs a message at level Warn on the standard logger.
 func Warningln(args ...interface{}) {
-	logger.Warningln(args...)
+	moduleEntry().Warningln(args...)
 }
 
 // Errorln logs a message at level Error on the standard logger.
 func Errorln(args ...interface{}) {
-	logger.Errorln(args...)
+	moduleEntry().Errorln(args...)
 }
 
 // Panicln logs a message at level Panic on the standard logger.
 func Panicln(args ...interface{}) {
-	logger.Panicln(args...)
+	moduleEntry().Panicln(args...)
 }
 
 // Fatalln logs a message at level Fatal on the standard logger.
 func Fatalln(args ...interface{}) {
-	logger.Fatalln(args...)
+	moduleEntry().Fatalln(args...)
 }
 
 // OpenFile opens the log file using the specified path
@@ -210,7 +219,7 @@ func OpenFile(path string) error {
 
 // CloseFile closes the log and sets the Output to stdout
 func CloseFile() error {
-	logrus.SetOutput(os.Stdout)
+	SetOutput(os.Stdout)
 
 	if logFile != nil {
 		return logFile.Close()
@@ -0,0 +1,216 @@
+package log
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// The modules are the packages of Traefik, named after their import path with dots,
+// e.g. provider.ecs for github.com/containous/traefik/provider/ecs.
+// A module logs at the level of its closest configured module, e.g. provider for provider.ecs,
+// or at the default level when none is configured.
+
+const modulePrefix = "github.com/containous/traefik/"
+
+// ModuleField is the field holding the module of the log entries, when enabled.
+const ModuleField = "module"
+
+var (
+	modulesMutex  sync.RWMutex
+	defaultLevel  = logrus.InfoLevel
+	moduleLevels  = make(map[string]logrus.Level)
+	moduleField   bool
+	levelLoggers  = make(map[logrus.Level]*logrus.Logger)
+	modulesActive int32
+	output        io.Writer = os.Stdout
+	formatter               = logrus.StandardLogger().Formatter
+
+	callersMutex  sync.RWMutex
+	callerModules = make(map[uintptr]string)
+)
+
+// SetModuleLevels replaces the log levels of the modules.
+func SetModuleLevels(levels map[string]logrus.Level) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	moduleLevels = make(map[string]logrus.Level, len(levels))
+	for module, level := range levels {
+		moduleLevels[module] = level
+	}
+	applyLevels()
+}
+
+// SetModuleLevel sets the log level of a module, and of its modules without a level of their own.
+func SetModuleLevel(module string, level logrus.Level) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	moduleLevels[module] = level
+	applyLevels()
+}
+
+// ResetModuleLevel removes the log level of a module, which logs again at the level of its parent module or at the default level.
+func ResetModuleLevel(module string) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	delete(moduleLevels, module)
+	applyLevels()
+}
+
+// ModuleLevels returns the log levels of the modules.
+func ModuleLevels() map[string]logrus.Level {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+
+	levels := make(map[string]logrus.Level, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level
+	}
+	return levels
+}
+
+// SetModuleField adds, or not, the module of the entries in the ModuleField field.
+func SetModuleField(enabled bool) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	moduleField = enabled
+	applyLevels()
+}
+
+// applyLevels sets the level of the standard logger to the most verbose level, the entries being filtered by module,
+// and drops the loggers of the levels. The caller must hold the lock.
+func applyLevels() {
+	level := defaultLevel
+	for _, moduleLevel := range moduleLevels {
+		if moduleLevel > level {
+			level = moduleLevel
+		}
+	}
+	logrus.SetLevel(level)
+
+	levelLoggers = make(map[logrus.Level]*logrus.Logger)
+
+	active := int32(0)
+	if len(moduleLevels) > 0 || moduleField {
+		active = 1
+	}
+	atomic.StoreInt32(&modulesActive, active)
+}
+
+// levelOf returns the level of the module. The caller must hold the lock.
+func levelOf(module string) logrus.Level {
+	for name := module; ; {
+		if level, ok := moduleLevels[name]; ok {
+			return level
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return defaultLevel
+		}
+		name = name[:i]
+	}
+}
+
+// moduleEntry returns the entry logging at the level of the module of the caller of the function calling it,
+// or the standard logger entry when no module level is set.
+func moduleEntry() *logrus.Entry {
+	if atomic.LoadInt32(&modulesActive) == 0 {
+		return logger
+	}
+
+	module := callerModule(3)
+
+	modulesMutex.RLock()
+	level := levelOf(module)
+	levelLogger, ok := levelLoggers[level]
+	withField := moduleField
+	modulesMutex.RUnlock()
+
+	if !ok {
+		levelLogger = newLevelLogger(level)
+	}
+
+	entry := logrus.NewEntry(levelLogger)
+	if withField {
+		return entry.WithField(ModuleField, module)
+	}
+	return entry
+}
+
+// newLevelLogger returns the logger of the level, sharing the output, the formatter and the hooks of the standard logger.
+// The loggers are created again when the output or the formatter change, rather than being modified while used.
+func newLevelLogger(level logrus.Level) *logrus.Logger {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	if levelLogger, ok := levelLoggers[level]; ok {
+		return levelLogger
+	}
+
+	levelLogger := &logrus.Logger{
+		Out:       output,
+		Formatter: formatter,
+		Hooks:     logrus.StandardLogger().Hooks,
+		Level:     level,
+	}
+	levelLoggers[level] = levelLogger
+	return levelLogger
+}
+
+// callerModule returns the module of the function of the caller, skip being the number of stack frames to ascend.
+func callerModule(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+
+	callersMutex.RLock()
+	module, ok := callerModules[pc]
+	callersMutex.RUnlock()
+	if ok {
+		return module
+	}
+
+	if function := runtime.FuncForPC(pc); function != nil {
+		module = moduleName(function.Name())
+	}
+
+	callersMutex.Lock()
+	callerModules[pc] = module
+	callersMutex.Unlock()
+	return module
+}
+
+// moduleName returns the module of a function from its fully qualified name,
+// e.g. provider.ecs for github.com/containous/traefik/provider/ecs.(*Provider).Provide.
+func moduleName(function string) string {
+	slash := strings.LastIndex(function, "/")
+	pkg := function
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		pkg = function[:slash+1+dot]
+	}
+	return strings.Replace(strings.TrimPrefix(pkg, modulePrefix), "/", ".", -1)
+}
+
+// setLevelLoggersOutput sets the output and the formatter of the loggers of the levels.
+func setLevelLoggersOutput(out io.Writer, f logrus.Formatter) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	if out != nil {
+		output = out
+	}
+	if f != nil {
+		formatter = f
+	}
+	levelLoggers = make(map[logrus.Level]*logrus.Logger)
+}
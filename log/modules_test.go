@@ -0,0 +1,104 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+func TestModuleName(t *testing.T) {
+	testCases := []struct {
+		function string
+		expected string
+	}{
+		{function: "github.com/containous/traefik/provider/ecs.(*Provider).Provide", expected: "provider.ecs"},
+		{function: "github.com/containous/traefik/server.(*Server).loadConfig.func1", expected: "server"},
+		{function: "github.com/containous/traefik/log.TestModuleName", expected: "log"},
+		{function: "main.main", expected: "main"},
+		{function: "github.com/docker/libkv/store.Get", expected: "github.com.docker.libkv.store"},
+	}
+
+	for _, test := range testCases {
+		if module := moduleName(test.function); module != test.expected {
+			t.Errorf("moduleName(%q) = %q, want %q", test.function, module, test.expected)
+		}
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out)
+	SetFormatter(&logrus.JSONFormatter{})
+	defer func() {
+		SetLevel(logrus.InfoLevel)
+		SetModuleLevels(nil)
+		SetModuleField(false)
+		SetFormatter(&logrus.TextFormatter{})
+		CloseFile()
+	}()
+
+	testCases := []struct {
+		desc         string
+		defaultLevel logrus.Level
+		moduleLevels map[string]logrus.Level
+		expected     []string
+	}{
+		{
+			desc:         "default level",
+			defaultLevel: logrus.InfoLevel,
+			expected:     []string{"info", "error"},
+		},
+		{
+			desc:         "more verbose module level",
+			defaultLevel: logrus.ErrorLevel,
+			moduleLevels: map[string]logrus.Level{"log": logrus.DebugLevel},
+			expected:     []string{"debug", "info", "field debug", "error"},
+		},
+		{
+			desc:         "less verbose module level",
+			defaultLevel: logrus.DebugLevel,
+			moduleLevels: map[string]logrus.Level{"log": logrus.ErrorLevel, "server": logrus.DebugLevel},
+			expected:     []string{"error"},
+		},
+		{
+			desc:         "parent module level",
+			defaultLevel: logrus.DebugLevel,
+			moduleLevels: map[string]logrus.Level{"log.other": logrus.ErrorLevel, "lo": logrus.ErrorLevel},
+			expected:     []string{"debug", "info", "field debug", "error"},
+		},
+	}
+
+	for _, test := range testCases {
+		out.Reset()
+		SetLevel(test.defaultLevel)
+		SetModuleLevels(test.moduleLevels)
+		SetModuleField(true)
+
+		Debug("debug")
+		Infof("%s", "info")
+		WithField("key", "value").Debug("field debug")
+		Error("error")
+
+		var messages []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if len(line) == 0 {
+				continue
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("%s: invalid entry %q: %v", test.desc, line, err)
+			}
+			if entry[ModuleField] != "log" {
+				t.Errorf("%s: got the module %v, want log", test.desc, entry[ModuleField])
+			}
+			messages = append(messages, entry["msg"].(string))
+		}
+
+		if strings.Join(messages, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: got the messages %v, want %v", test.desc, messages, test.expected)
+		}
+	}
+}
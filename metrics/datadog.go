@@ -21,20 +21,45 @@ var datadogTicker *time.Ticker
 const (
 	ddMetricsReqsName    = "requests.total"
 	ddMetricsLatencyName = "request.duration"
+	ddReqSizeName        = "request.size"
+	ddRespSizeName       = "response.size"
+	ddEntryPointConnName = "entrypoint.open.connections"
+	ddBackendConnName    = "backend.open.connections"
 	ddRetriesTotalName   = "backend.retries.total"
+	ddCacheRequestsName  = "backend.cache.requests.total"
+	ddShadowDiffName     = "shadow.configuration.differences"
+	ddQueueDepthName     = "backend.queue.depth"
+	ddQueueWaitName      = "backend.queue.wait.duration"
+	ddCertExpirationName = "tls.certificate.not.after"
+	ddACMERequestsName   = "acme.requests.total"
+	ddACMECertsName      = "acme.certificates"
 )
 
 // RegisterDatadog registers the metrics pusher if this didn't happen yet and creates a datadog Registry instance.
-func RegisterDatadog(config *types.Datadog) Registry {
+// The request and retry metrics are tagged with the targets of the requests at the granularity.
+func RegisterDatadog(config *types.Datadog, granularity string) Registry {
 	if datadogTicker == nil {
 		datadogTicker = initDatadogClient(config)
 	}
 
+	targetLabels := getTargetLabels(granularity, false, false)
+
 	registry := &standardRegistry{
-		enabled:              true,
-		reqsCounter:          datadogClient.NewCounter(ddMetricsReqsName, 1.0),
-		reqDurationHistogram: datadogClient.NewHistogram(ddMetricsLatencyName, 1.0),
-		retriesCounter:       datadogClient.NewCounter(ddRetriesTotalName, 1.0),
+		enabled:                    true,
+		reqsCounter:                newFilteredCounter(datadogClient.NewCounter(ddMetricsReqsName, 1.0), getRequestLabels(defaultReqsLabels, targetLabels, nil)),
+		reqDurationHistogram:       newFilteredHistogram(datadogClient.NewHistogram(ddMetricsLatencyName, 1.0), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		reqSizeHistogram:           newFilteredHistogram(datadogClient.NewHistogram(ddReqSizeName, 1.0), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		respSizeHistogram:          newFilteredHistogram(datadogClient.NewHistogram(ddRespSizeName, 1.0), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		retriesCounter:             newFilteredCounter(datadogClient.NewCounter(ddRetriesTotalName, 1.0), getRetryLabels(targetLabels)),
+		entryPointOpenConnsGauge:   datadogClient.NewGauge(ddEntryPointConnName),
+		backendOpenConnsGauge:      datadogClient.NewGauge(ddBackendConnName),
+		cacheRequestsCounter:       datadogClient.NewCounter(ddCacheRequestsName, 1.0),
+		shadowDifferencesGauge:     datadogClient.NewGauge(ddShadowDiffName),
+		queueDepthGauge:            datadogClient.NewGauge(ddQueueDepthName),
+		queueWaitHistogram:         datadogClient.NewHistogram(ddQueueWaitName, 1.0),
+		certificateExpirationGauge: datadogClient.NewGauge(ddCertExpirationName),
+		acmeRequestsCounter:        datadogClient.NewCounter(ddACMERequestsName, 1.0),
+		acmeCertificatesGauge:      datadogClient.NewGauge(ddACMECertsName),
 	}
 
 	return registry
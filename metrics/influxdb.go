@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"bytes"
+	"fmt"
 	"time"
 
 	"github.com/containous/traefik/log"
@@ -27,20 +28,50 @@ var influxDBTicker *time.Ticker
 const (
 	influxDBMetricsReqsName    = "traefik.requests.total"
 	influxDBMetricsLatencyName = "traefik.request.duration"
+	influxDBReqSizeName        = "traefik.request.size"
+	influxDBRespSizeName       = "traefik.response.size"
+	influxDBEntryPointConnName = "traefik.entrypoint.open.connections"
+	influxDBBackendConnName    = "traefik.backend.open.connections"
 	influxDBRetriesTotalName   = "traefik.backend.retries.total"
+	influxDBCacheRequestsName  = "traefik.backend.cache.requests.total"
+	influxDBShadowDiffName     = "traefik.shadow.configuration.differences"
+	influxDBQueueDepthName     = "traefik.backend.queue.depth"
+	influxDBQueueWaitName      = "traefik.backend.queue.wait.duration"
+	influxDBCertExpirationName = "traefik.tls.certificate.not.after"
+	influxDBACMERequestsName   = "traefik.acme.requests.total"
+	influxDBACMECertsName      = "traefik.acme.certificates"
 )
 
 // RegisterInfluxDB registers the metrics pusher if this didn't happen yet and creates a InfluxDB Registry instance.
-func RegisterInfluxDB(config *types.InfluxDB) Registry {
+// The request and retry metrics are tagged with the targets of the requests at the granularity.
+func RegisterInfluxDB(config *types.InfluxDB, granularity string) Registry {
 	if influxDBTicker == nil {
 		influxDBTicker = initInfluxDBTicker(config)
 	}
 
+	return newInfluxDBRegistry(influxDBClient, granularity)
+}
+
+// newInfluxDBRegistry creates a Registry of the metrics of an InfluxDB client, shared by the InfluxDB versions.
+func newInfluxDBRegistry(client *influx.Influx, granularity string) Registry {
+	targetLabels := getTargetLabels(granularity, false, false)
+
 	return &standardRegistry{
-		enabled:              true,
-		reqsCounter:          influxDBClient.NewCounter(influxDBMetricsReqsName),
-		reqDurationHistogram: influxDBClient.NewHistogram(influxDBMetricsLatencyName),
-		retriesCounter:       influxDBClient.NewCounter(influxDBRetriesTotalName),
+		enabled:                    true,
+		reqsCounter:                newFilteredCounter(client.NewCounter(influxDBMetricsReqsName), getRequestLabels(defaultReqsLabels, targetLabels, nil)),
+		reqDurationHistogram:       newFilteredHistogram(client.NewHistogram(influxDBMetricsLatencyName), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		reqSizeHistogram:           newFilteredHistogram(client.NewHistogram(influxDBReqSizeName), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		respSizeHistogram:          newFilteredHistogram(client.NewHistogram(influxDBRespSizeName), getRequestLabels(defaultReqDurationLabels, targetLabels, nil)),
+		retriesCounter:             newFilteredCounter(client.NewCounter(influxDBRetriesTotalName), getRetryLabels(targetLabels)),
+		entryPointOpenConnsGauge:   client.NewGauge(influxDBEntryPointConnName),
+		backendOpenConnsGauge:      client.NewGauge(influxDBBackendConnName),
+		cacheRequestsCounter:       client.NewCounter(influxDBCacheRequestsName),
+		shadowDifferencesGauge:     client.NewGauge(influxDBShadowDiffName),
+		queueDepthGauge:            client.NewGauge(influxDBQueueDepthName),
+		queueWaitHistogram:         client.NewHistogram(influxDBQueueWaitName),
+		certificateExpirationGauge: client.NewGauge(influxDBCertExpirationName),
+		acmeRequestsCounter:        client.NewCounter(influxDBACMERequestsName),
+		acmeCertificatesGauge:      client.NewGauge(influxDBACMECertsName),
 	}
 }
 
@@ -70,16 +101,43 @@ func StopInfluxDB() {
 	influxDBTicker = nil
 }
 
+// Write sends the points to InfluxDB, over UDP or over HTTP in the database and retention policy of the configuration.
 func (w *influxDBWriter) Write(bp influxdb.BatchPoints) error {
-	c, err := influxdb.NewUDPClient(influxdb.UDPConfig{
-		Addr: w.config.Address,
-	})
-
+	c, err := w.initWriteClient()
 	if err != nil {
 		return err
 	}
 
 	defer c.Close()
 
+	if w.config.Protocol == "http" {
+		httpBP, err := influxdb.NewBatchPoints(influxdb.BatchPointsConfig{
+			Database:        w.config.Database,
+			RetentionPolicy: w.config.RetentionPolicy,
+		})
+		if err != nil {
+			return err
+		}
+		httpBP.AddPoints(bp.Points())
+		bp = httpBP
+	}
+
 	return c.Write(bp)
 }
+
+func (w *influxDBWriter) initWriteClient() (influxdb.Client, error) {
+	switch w.config.Protocol {
+	case "", "udp":
+		return influxdb.NewUDPClient(influxdb.UDPConfig{
+			Addr: w.config.Address,
+		})
+	case "http":
+		return influxdb.NewHTTPClient(influxdb.HTTPConfig{
+			Addr:     w.config.Address,
+			Username: w.config.Username,
+			Password: w.config.Password,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported InfluxDB protocol: %s", w.config.Protocol)
+	}
+}
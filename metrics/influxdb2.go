@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/influx"
+	influxdb "github.com/influxdata/influxdb/client/v2"
+)
+
+var influxDB2Client = influx.New(map[string]string{}, influxdb.BatchPointsConfig{}, kitlog.LoggerFunc(func(keyvals ...interface{}) error {
+	log.Info(keyvals)
+	return nil
+}))
+
+var influxDB2Ticker *time.Ticker
+
+const influxDB2WriteTimeout = 10 * time.Second
+
+// influxDB2Writer writes the points to the bucket of an InfluxDB 2.x server, through its HTTP API with token authentication.
+type influxDB2Writer struct {
+	writeURL string
+	token    string
+	client   *http.Client
+}
+
+// RegisterInfluxDB2 registers the metrics pusher if this didn't happen yet and creates a InfluxDB 2.x Registry instance.
+// The request and retry metrics are tagged with the targets of the requests at the granularity.
+func RegisterInfluxDB2(config *types.InfluxDB2, granularity string) Registry {
+	if influxDB2Ticker == nil {
+		influxDB2Ticker = initInfluxDB2Ticker(config)
+	}
+
+	return newInfluxDBRegistry(influxDB2Client, granularity)
+}
+
+// initInfluxDB2Ticker initializes metrics pusher to an InfluxDB 2.x server
+func initInfluxDB2Ticker(config *types.InfluxDB2) *time.Ticker {
+	pushInterval, err := time.ParseDuration(config.PushInterval)
+	if err != nil {
+		log.Warnf("Unable to parse %s into pushInterval, using 10s as default value", config.PushInterval)
+		pushInterval = 10 * time.Second
+	}
+
+	report := time.NewTicker(pushInterval)
+
+	safe.Go(func() {
+		influxDB2Client.WriteLoop(report.C, newInfluxDB2Writer(config))
+	})
+
+	return report
+}
+
+// StopInfluxDB2 stops internal influxDB2Ticker which controls the pushing of metrics to InfluxDB 2.x and resets it to `nil`
+func StopInfluxDB2() {
+	if influxDB2Ticker != nil {
+		influxDB2Ticker.Stop()
+	}
+	influxDB2Ticker = nil
+}
+
+func newInfluxDB2Writer(config *types.InfluxDB2) *influxDB2Writer {
+	params := url.Values{}
+	params.Set("org", config.Organization)
+	params.Set("bucket", config.Bucket)
+	params.Set("precision", "ns")
+
+	return &influxDB2Writer{
+		writeURL: strings.TrimSuffix(config.Address, "/") + "/api/v2/write?" + params.Encode(),
+		token:    config.Token,
+		client:   &http.Client{Timeout: influxDB2WriteTimeout},
+	}
+}
+
+// Write sends the points in the line protocol.
+func (w *influxDB2Writer) Write(bp influxdb.BatchPoints) error {
+	var body bytes.Buffer
+	for _, p := range bp.Points() {
+		body.WriteString(p.PrecisionString("ns"))
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.writeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if len(w.token) > 0 {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s of InfluxDB: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package metrics
 
 import (
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strconv"
 	"testing"
@@ -16,7 +18,7 @@ func TestInfluxDB(t *testing.T) {
 	// This is needed to make sure that UDP Listener listens for data a bit longer, otherwise it will quit after a millisecond
 	udp.Timeout = 5 * time.Second
 
-	influxDBRegistry := RegisterInfluxDB(&types.InfluxDB{Address: ":8089", PushInterval: "1s"})
+	influxDBRegistry := RegisterInfluxDB(&types.InfluxDB{Address: ":8089", PushInterval: "1s"}, "")
 	defer StopInfluxDB()
 
 	if !influxDBRegistry.IsEnabled() {
@@ -24,23 +26,111 @@ func TestInfluxDB(t *testing.T) {
 	}
 
 	expected := []string{
-		`(traefik\.requests\.total,code=200,method=GET,service=test count=1) [\d]{19}`,
-		`(traefik\.requests\.total,code=404,method=GET,service=test count=1) [\d]{19}`,
-		`(traefik\.request\.duration,code=200,method=GET,service=test p50=10000,p90=10000,p95=10000,p99=10000) [\d]{19}`,
-		`(traefik\.backend\.retries\.total(?:,code=[\d]{3},method=GET)?,service=test count=2) [\d]{19}`,
+		`(traefik\.requests\.total(?:,backend=test)?,code=200,method=GET,service=test count=1) [\d]{19}`,
+		`(traefik\.requests\.total(?:,backend=test)?,code=404,method=GET,service=test count=1) [\d]{19}`,
+		`(traefik\.request\.duration(?:,backend=test)?,code=200,method=GET,service=test p50=10000,p90=10000,p95=10000,p99=10000) [\d]{19}`,
+		`(traefik\.backend\.retries\.total,backend=test(?:,code=[\d]{3},method=GET,service=test)? count=2) [\d]{19}`,
 	}
 
 	msg := udp.ReceiveString(t, func() {
 		influxDBRegistry.ReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
 		influxDBRegistry.ReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusNotFound), "method", http.MethodGet).Add(1)
-		influxDBRegistry.RetriesCounter().With("service", "test").Add(1)
-		influxDBRegistry.RetriesCounter().With("service", "test").Add(1)
 		influxDBRegistry.ReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
+		influxDBRegistry.RetriesCounter().With("backend", "test").Add(1)
+		influxDBRegistry.RetriesCounter().With("backend", "test").Add(1)
 	})
 
 	assertMessage(t, msg, expected)
 }
 
+func TestInfluxDBHTTP(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- req
+		bodies <- string(body)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	influxDBRegistry := RegisterInfluxDB(&types.InfluxDB{
+		Address:         server.URL,
+		Protocol:        "http",
+		PushInterval:    "1s",
+		Database:        "traefik",
+		RetentionPolicy: "autogen",
+		Username:        "user",
+		Password:        "secret",
+	}, "")
+	defer StopInfluxDB()
+
+	influxDBRegistry.ReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
+
+	select {
+	case req := <-requests:
+		if req.URL.Path != "/write" {
+			t.Errorf("Got path %s, want /write", req.URL.Path)
+		}
+		if db := req.URL.Query().Get("db"); db != "traefik" {
+			t.Errorf("Got database %s, want traefik", db)
+		}
+		if rp := req.URL.Query().Get("rp"); rp != "autogen" {
+			t.Errorf("Got retention policy %s, want autogen", rp)
+		}
+		if user, password, _ := req.BasicAuth(); user != "user" || password != "secret" {
+			t.Errorf("Got credentials %s:%s, want user:secret", user, password)
+		}
+		assertMessage(t, <-bodies, []string{`(traefik\.requests\.total(?:,backend=test)?,code=200,method=GET,service=test count=1) [\d]{19}`})
+	case <-time.After(5 * time.Second):
+		t.Fatal("InfluxDB did not receive the metrics")
+	}
+}
+
+func TestInfluxDB2(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- req
+		bodies <- string(body)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	influxDB2Registry := RegisterInfluxDB2(&types.InfluxDB2{
+		Address:      server.URL,
+		Token:        "secret",
+		Organization: "my-org",
+		Bucket:       "traefik",
+		PushInterval: "1s",
+	}, GranularityFrontend)
+	defer StopInfluxDB2()
+
+	if !influxDB2Registry.IsEnabled() {
+		t.Fatalf("InfluxDB 2.x registry must be enabled")
+	}
+
+	influxDB2Registry.ReqsCounter().With("service", "test", "entrypoint", "http", "frontend", "web", "backend", "api", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
+
+	select {
+	case req := <-requests:
+		if req.URL.Path != "/api/v2/write" {
+			t.Errorf("Got path %s, want /api/v2/write", req.URL.Path)
+		}
+		query := req.URL.Query()
+		if query.Get("org") != "my-org" || query.Get("bucket") != "traefik" || query.Get("precision") != "ns" {
+			t.Errorf("Got query %s, want the organization, the bucket and the precision", req.URL.RawQuery)
+		}
+		if auth := req.Header.Get("Authorization"); auth != "Token secret" {
+			t.Errorf("Got authorization %q, want %q", auth, "Token secret")
+		}
+		assertMessage(t, <-bodies, []string{`(traefik\.requests\.total,code=200,entrypoint=http,frontend=web,method=GET,service=test count=1) [\d]{19}`})
+	case <-time.After(5 * time.Second):
+		t.Fatal("InfluxDB 2.x did not receive the metrics")
+	}
+}
+
 func assertMessage(t *testing.T, msg string, patterns []string) {
 	t.Helper()
 	for _, pattern := range patterns {
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"github.com/containous/traefik/log"
+	"github.com/go-kit/kit/metrics"
+)
+
+// The labels of the request metrics.
+const (
+	serviceLabel    = "service"
+	entryPointLabel = "entrypoint"
+	frontendLabel   = "frontend"
+	backendLabel    = "backend"
+	codeLabel       = "code"
+	methodLabel     = "method"
+)
+
+// The granularities of the request and retry metrics.
+const (
+	// GranularityService labels the metrics with the service only: the entry point or the backend.
+	GranularityService = "service"
+	// GranularityEntryPoint labels the metrics with the entry point too.
+	GranularityEntryPoint = "entrypoint"
+	// GranularityFrontend labels the metrics with the entry point and the frontend too.
+	GranularityFrontend = "frontend"
+	// GranularityBackend labels the metrics with the entry point, the frontend and the backend too.
+	GranularityBackend = "backend"
+)
+
+var (
+	// defaultReqsLabels are the labels of the requests counter, unless configured otherwise.
+	defaultReqsLabels = []string{serviceLabel, codeLabel, methodLabel}
+	// defaultReqDurationLabels are the labels of the request duration histogram, unless configured otherwise.
+	defaultReqDurationLabels = []string{serviceLabel, codeLabel}
+)
+
+// getTargetLabels returns the labels of the targets of the requests added to the request metrics at the granularity,
+// plus the frontend and backend labels if enabled.
+func getTargetLabels(granularity string, frontend, backend bool) []string {
+	var entryPoint bool
+	switch granularity {
+	case "", GranularityService:
+	case GranularityEntryPoint:
+		entryPoint = true
+	case GranularityFrontend:
+		entryPoint, frontend = true, true
+	case GranularityBackend:
+		entryPoint, frontend, backend = true, true, true
+	default:
+		log.Warnf("Unknown granularity %q of the metrics, using %q", granularity, GranularityService)
+	}
+
+	var labels []string
+	if entryPoint {
+		labels = append(labels, entryPointLabel)
+	}
+	if frontend {
+		labels = append(labels, frontendLabel)
+	}
+	if backend {
+		labels = append(labels, backendLabel)
+	}
+	return labels
+}
+
+// getRequestLabels returns the labels kept by a request metric: its default labels,
+// plus the target labels after the service label, minus the dropped labels.
+func getRequestLabels(defaultLabels []string, targetLabels []string, dropped []string) []string {
+	droppedLabels := make(map[string]bool)
+	for _, name := range dropped {
+		switch name {
+		case serviceLabel, entryPointLabel, frontendLabel, backendLabel, codeLabel, methodLabel:
+			droppedLabels[name] = true
+		default:
+			log.Warnf("Unknown label %q of the request metrics can't be dropped", name)
+		}
+	}
+
+	var labels []string
+	for _, name := range defaultLabels {
+		labels = append(labels, name)
+		if name == serviceLabel {
+			labels = append(labels, targetLabels...)
+		}
+	}
+
+	kept := labels[:0]
+	for _, name := range labels {
+		if !droppedLabels[name] {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// getRetryLabels returns the labels of the retries counter: the target labels, the retries being always labelled with the backend.
+func getRetryLabels(targetLabels []string) []string {
+	var labels []string
+	for _, name := range targetLabels {
+		if name != backendLabel {
+			labels = append(labels, name)
+		}
+	}
+	return append(labels, backendLabel)
+}
+
+// labelFilter gives the values of the label names it keeps, in order, from label values,
+// the names without value getting an empty one.
+type labelFilter []string
+
+func (f labelFilter) filter(labelValues []string) []string {
+	values := make(map[string]string)
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		values[labelValues[i]] = labelValues[i+1]
+	}
+
+	filtered := make([]string, 0, 2*len(f))
+	for _, name := range f {
+		filtered = append(filtered, name, values[name])
+	}
+	return filtered
+}
+
+// filteredCounter is a counter keeping only some of its labels.
+type filteredCounter struct {
+	counter     metrics.Counter
+	filter      labelFilter
+	labelValues []string
+}
+
+func newFilteredCounter(counter metrics.Counter, labels []string) metrics.Counter {
+	return &filteredCounter{counter: counter, filter: labels}
+}
+
+func (c *filteredCounter) With(labelValues ...string) metrics.Counter {
+	return &filteredCounter{
+		counter:     c.counter,
+		filter:      c.filter,
+		labelValues: append(append([]string{}, c.labelValues...), labelValues...),
+	}
+}
+
+func (c *filteredCounter) Add(delta float64) {
+	c.counter.With(c.filter.filter(c.labelValues)...).Add(delta)
+}
+
+// filteredHistogram is a histogram keeping only some of its labels.
+type filteredHistogram struct {
+	histogram   metrics.Histogram
+	filter      labelFilter
+	labelValues []string
+}
+
+func newFilteredHistogram(histogram metrics.Histogram, labels []string) metrics.Histogram {
+	return &filteredHistogram{histogram: histogram, filter: labels}
+}
+
+func (h *filteredHistogram) With(labelValues ...string) metrics.Histogram {
+	return &filteredHistogram{
+		histogram:   h.histogram,
+		filter:      h.filter,
+		labelValues: append(append([]string{}, h.labelValues...), labelValues...),
+	}
+}
+
+func (h *filteredHistogram) Observe(value float64) {
+	h.histogram.With(h.filter.filter(h.labelValues)...).Observe(value)
+}
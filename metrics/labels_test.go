@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTargetLabels(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		granularity string
+		frontend    bool
+		backend     bool
+		expected    []string
+	}{
+		{
+			desc: "default granularity",
+		},
+		{
+			desc:        "service granularity with backend label",
+			granularity: GranularityService,
+			backend:     true,
+			expected:    []string{"backend"},
+		},
+		{
+			desc:        "entry point granularity",
+			granularity: GranularityEntryPoint,
+			expected:    []string{"entrypoint"},
+		},
+		{
+			desc:        "frontend granularity",
+			granularity: GranularityFrontend,
+			expected:    []string{"entrypoint", "frontend"},
+		},
+		{
+			desc:        "entry point granularity with frontend label",
+			granularity: GranularityEntryPoint,
+			frontend:    true,
+			expected:    []string{"entrypoint", "frontend"},
+		},
+		{
+			desc:        "backend granularity",
+			granularity: GranularityBackend,
+			expected:    []string{"entrypoint", "frontend", "backend"},
+		},
+		{
+			desc:        "unknown granularity",
+			granularity: "server",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			labels := getTargetLabels(test.granularity, test.frontend, test.backend)
+			assert.Equal(t, test.expected, labels)
+		})
+	}
+}
+
+func TestGetRequestLabels(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		defaultLabels []string
+		targetLabels  []string
+		dropped       []string
+		expected      []string
+	}{
+		{
+			desc:          "default labels",
+			defaultLabels: defaultReqsLabels,
+			expected:      []string{"service", "code", "method"},
+		},
+		{
+			desc:          "frontend and backend labels",
+			defaultLabels: defaultReqsLabels,
+			targetLabels:  []string{"frontend", "backend"},
+			expected:      []string{"service", "frontend", "backend", "code", "method"},
+		},
+		{
+			desc:          "backend label without method",
+			defaultLabels: defaultReqsLabels,
+			targetLabels:  []string{"backend"},
+			dropped:       []string{"method"},
+			expected:      []string{"service", "backend", "code"},
+		},
+		{
+			desc:          "request duration without code",
+			defaultLabels: defaultReqDurationLabels,
+			targetLabels:  []string{"frontend"},
+			dropped:       []string{"code"},
+			expected:      []string{"service", "frontend"},
+		},
+		{
+			desc:          "targets without service",
+			defaultLabels: defaultReqDurationLabels,
+			targetLabels:  []string{"entrypoint", "frontend", "backend"},
+			dropped:       []string{"service"},
+			expected:      []string{"entrypoint", "frontend", "backend", "code"},
+		},
+		{
+			desc:          "unknown dropped label",
+			defaultLabels: defaultReqDurationLabels,
+			dropped:       []string{"path"},
+			expected:      []string{"service", "code"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			labels := getRequestLabels(test.defaultLabels, test.targetLabels, test.dropped)
+			assert.Equal(t, test.expected, labels)
+		})
+	}
+}
+
+func TestGetRetryLabels(t *testing.T) {
+	assert.Equal(t, []string{"backend"}, getRetryLabels(nil))
+	assert.Equal(t, []string{"entrypoint", "frontend", "backend"}, getRetryLabels([]string{"entrypoint", "frontend", "backend"}))
+	assert.Equal(t, []string{"frontend", "backend"}, getRetryLabels([]string{"backend", "frontend"}))
+}
+
+func TestFilteredCounter(t *testing.T) {
+	counter := &counterMock{}
+	filtered := newFilteredCounter(counter, []string{"service", "backend", "code"})
+
+	filtered.With("service", "http", "frontend", "web").With("code", "200", "method", "GET").Add(1)
+
+	assert.Equal(t, []string{"service", "http", "backend", "", "code", "200"}, counter.lastLabelValues)
+	assert.Equal(t, float64(1), counter.counterValue)
+}
+
+func TestFilteredHistogram(t *testing.T) {
+	histogram := &histogramMock{}
+	filtered := newFilteredHistogram(histogram, []string{"service", "code"})
+
+	filtered.With("service", "http", "backend", "api", "code", "503").Observe(2)
+
+	assert.Equal(t, []string{"service", "http", "code", "503"}, histogram.lastLabelValues)
+	assert.Equal(t, float64(2), histogram.lastHistogramValue)
+}
@@ -11,34 +11,89 @@ type Registry interface {
 	IsEnabled() bool
 	ReqsCounter() metrics.Counter
 	ReqDurationHistogram() metrics.Histogram
+	ReqSizeHistogram() metrics.Histogram
+	RespSizeHistogram() metrics.Histogram
 	RetriesCounter() metrics.Counter
+	EntryPointOpenConnsGauge() metrics.Gauge
+	BackendOpenConnsGauge() metrics.Gauge
+	CacheRequestsCounter() metrics.Counter
+	ShadowDifferencesGauge() metrics.Gauge
+	QueueDepthGauge() metrics.Gauge
+	QueueWaitHistogram() metrics.Histogram
+	CertificateExpirationGauge() metrics.Gauge
+	ACMERequestsCounter() metrics.Counter
+	ACMECertificatesGauge() metrics.Gauge
 }
 
 // NewMultiRegistry creates a new standardRegistry that wraps multiple Registries.
 func NewMultiRegistry(registries []Registry) Registry {
 	reqsCounters := []metrics.Counter{}
 	reqDurationHistograms := []metrics.Histogram{}
+	reqSizeHistograms := []metrics.Histogram{}
+	respSizeHistograms := []metrics.Histogram{}
 	retriesCounters := []metrics.Counter{}
+	entryPointOpenConnsGauges := []metrics.Gauge{}
+	backendOpenConnsGauges := []metrics.Gauge{}
+	cacheRequestsCounters := []metrics.Counter{}
+	shadowDifferencesGauges := []metrics.Gauge{}
+	queueDepthGauges := []metrics.Gauge{}
+	queueWaitHistograms := []metrics.Histogram{}
+	certificateExpirationGauges := []metrics.Gauge{}
+	acmeRequestsCounters := []metrics.Counter{}
+	acmeCertificatesGauges := []metrics.Gauge{}
 
 	for _, r := range registries {
 		reqsCounters = append(reqsCounters, r.ReqsCounter())
 		reqDurationHistograms = append(reqDurationHistograms, r.ReqDurationHistogram())
+		reqSizeHistograms = append(reqSizeHistograms, r.ReqSizeHistogram())
+		respSizeHistograms = append(respSizeHistograms, r.RespSizeHistogram())
 		retriesCounters = append(retriesCounters, r.RetriesCounter())
+		entryPointOpenConnsGauges = append(entryPointOpenConnsGauges, r.EntryPointOpenConnsGauge())
+		backendOpenConnsGauges = append(backendOpenConnsGauges, r.BackendOpenConnsGauge())
+		cacheRequestsCounters = append(cacheRequestsCounters, r.CacheRequestsCounter())
+		shadowDifferencesGauges = append(shadowDifferencesGauges, r.ShadowDifferencesGauge())
+		queueDepthGauges = append(queueDepthGauges, r.QueueDepthGauge())
+		queueWaitHistograms = append(queueWaitHistograms, r.QueueWaitHistogram())
+		certificateExpirationGauges = append(certificateExpirationGauges, r.CertificateExpirationGauge())
+		acmeRequestsCounters = append(acmeRequestsCounters, r.ACMERequestsCounter())
+		acmeCertificatesGauges = append(acmeCertificatesGauges, r.ACMECertificatesGauge())
 	}
 
 	return &standardRegistry{
-		enabled:              true,
-		reqsCounter:          multi.NewCounter(reqsCounters...),
-		reqDurationHistogram: multi.NewHistogram(reqDurationHistograms...),
-		retriesCounter:       multi.NewCounter(retriesCounters...),
+		enabled:                    true,
+		reqsCounter:                multi.NewCounter(reqsCounters...),
+		reqDurationHistogram:       multi.NewHistogram(reqDurationHistograms...),
+		reqSizeHistogram:           multi.NewHistogram(reqSizeHistograms...),
+		respSizeHistogram:          multi.NewHistogram(respSizeHistograms...),
+		retriesCounter:             multi.NewCounter(retriesCounters...),
+		entryPointOpenConnsGauge:   multi.NewGauge(entryPointOpenConnsGauges...),
+		backendOpenConnsGauge:      multi.NewGauge(backendOpenConnsGauges...),
+		cacheRequestsCounter:       multi.NewCounter(cacheRequestsCounters...),
+		shadowDifferencesGauge:     multi.NewGauge(shadowDifferencesGauges...),
+		queueDepthGauge:            multi.NewGauge(queueDepthGauges...),
+		queueWaitHistogram:         multi.NewHistogram(queueWaitHistograms...),
+		certificateExpirationGauge: multi.NewGauge(certificateExpirationGauges...),
+		acmeRequestsCounter:        multi.NewCounter(acmeRequestsCounters...),
+		acmeCertificatesGauge:      multi.NewGauge(acmeCertificatesGauges...),
 	}
 }
 
 type standardRegistry struct {
-	enabled              bool
-	reqsCounter          metrics.Counter
-	reqDurationHistogram metrics.Histogram
-	retriesCounter       metrics.Counter
+	enabled                    bool
+	reqsCounter                metrics.Counter
+	reqDurationHistogram       metrics.Histogram
+	reqSizeHistogram           metrics.Histogram
+	respSizeHistogram          metrics.Histogram
+	retriesCounter             metrics.Counter
+	entryPointOpenConnsGauge   metrics.Gauge
+	backendOpenConnsGauge      metrics.Gauge
+	cacheRequestsCounter       metrics.Counter
+	shadowDifferencesGauge     metrics.Gauge
+	queueDepthGauge            metrics.Gauge
+	queueWaitHistogram         metrics.Histogram
+	certificateExpirationGauge metrics.Gauge
+	acmeRequestsCounter        metrics.Counter
+	acmeCertificatesGauge      metrics.Gauge
 }
 
 func (r *standardRegistry) IsEnabled() bool {
@@ -53,18 +108,73 @@ func (r *standardRegistry) ReqDurationHistogram() metrics.Histogram {
 	return r.reqDurationHistogram
 }
 
+func (r *standardRegistry) ReqSizeHistogram() metrics.Histogram {
+	return r.reqSizeHistogram
+}
+
+func (r *standardRegistry) RespSizeHistogram() metrics.Histogram {
+	return r.respSizeHistogram
+}
+
 func (r *standardRegistry) RetriesCounter() metrics.Counter {
 	return r.retriesCounter
 }
 
+func (r *standardRegistry) EntryPointOpenConnsGauge() metrics.Gauge {
+	return r.entryPointOpenConnsGauge
+}
+
+func (r *standardRegistry) BackendOpenConnsGauge() metrics.Gauge {
+	return r.backendOpenConnsGauge
+}
+
+func (r *standardRegistry) CacheRequestsCounter() metrics.Counter {
+	return r.cacheRequestsCounter
+}
+
+func (r *standardRegistry) ShadowDifferencesGauge() metrics.Gauge {
+	return r.shadowDifferencesGauge
+}
+
+func (r *standardRegistry) QueueDepthGauge() metrics.Gauge {
+	return r.queueDepthGauge
+}
+
+func (r *standardRegistry) QueueWaitHistogram() metrics.Histogram {
+	return r.queueWaitHistogram
+}
+
+func (r *standardRegistry) CertificateExpirationGauge() metrics.Gauge {
+	return r.certificateExpirationGauge
+}
+
+func (r *standardRegistry) ACMERequestsCounter() metrics.Counter {
+	return r.acmeRequestsCounter
+}
+
+func (r *standardRegistry) ACMECertificatesGauge() metrics.Gauge {
+	return r.acmeCertificatesGauge
+}
+
 // NewVoidRegistry is a noop implementation of metrics.Registry.
 // It is used to avoid nil checking in components that do metric collections.
 func NewVoidRegistry() Registry {
 	return &standardRegistry{
-		enabled:              false,
-		reqsCounter:          &voidCounter{},
-		reqDurationHistogram: &voidHistogram{},
-		retriesCounter:       &voidCounter{},
+		enabled:                    false,
+		reqsCounter:                &voidCounter{},
+		reqDurationHistogram:       &voidHistogram{},
+		reqSizeHistogram:           &voidHistogram{},
+		respSizeHistogram:          &voidHistogram{},
+		retriesCounter:             &voidCounter{},
+		entryPointOpenConnsGauge:   &voidGauge{},
+		backendOpenConnsGauge:      &voidGauge{},
+		cacheRequestsCounter:       &voidCounter{},
+		shadowDifferencesGauge:     &voidGauge{},
+		queueDepthGauge:            &voidGauge{},
+		queueWaitHistogram:         &voidHistogram{},
+		certificateExpirationGauge: &voidGauge{},
+		acmeRequestsCounter:        &voidCounter{},
+		acmeCertificatesGauge:      &voidGauge{},
 	}
 }
 
@@ -73,6 +183,11 @@ type voidCounter struct{}
 func (v *voidCounter) With(labelValues ...string) metrics.Counter { return v }
 func (v *voidCounter) Add(delta float64)                          {}
 
+type voidGauge struct{}
+
+func (g *voidGauge) With(labelValues ...string) metrics.Gauge { return g }
+func (g *voidGauge) Set(value float64)                        {}
+
 type voidHistogram struct{}
 
 func (h *voidHistogram) With(labelValues ...string) metrics.Histogram { return h }
@@ -15,7 +15,18 @@ func TestNewVoidRegistry(t *testing.T) {
 	}
 	registry.ReqsCounter().With("some", "value").Add(1)
 	registry.ReqDurationHistogram().With("some", "value").Observe(1)
+	registry.ReqSizeHistogram().With("some", "value").Observe(1)
+	registry.RespSizeHistogram().With("some", "value").Observe(1)
 	registry.RetriesCounter().With("some", "value").Add(1)
+	registry.EntryPointOpenConnsGauge().With("some", "value").Set(1)
+	registry.BackendOpenConnsGauge().With("some", "value").Set(1)
+	registry.CacheRequestsCounter().With("some", "value").Add(1)
+	registry.ShadowDifferencesGauge().With("some", "value").Set(1)
+	registry.QueueDepthGauge().With("some", "value").Set(1)
+	registry.QueueWaitHistogram().With("some", "value").Observe(1)
+	registry.CertificateExpirationGauge().With("some", "value").Set(1)
+	registry.ACMERequestsCounter().With("some", "value").Add(1)
+	registry.ACMECertificatesGauge().Set(1)
 }
 
 func TestNewMultiRegistry(t *testing.T) {
@@ -25,11 +36,15 @@ func TestNewMultiRegistry(t *testing.T) {
 	registry.ReqsCounter().With("key", "requests").Add(1)
 	registry.ReqDurationHistogram().With("key", "durations").Observe(2)
 	registry.RetriesCounter().With("key", "retries").Add(3)
+	registry.ShadowDifferencesGauge().With("key", "differences").Set(4)
+	registry.BackendOpenConnsGauge().With("key", "connections").Set(5)
 
 	for _, collectingRegistry := range registries {
 		cReqsCounter := collectingRegistry.ReqsCounter().(*counterMock)
 		cReqDurationHistogram := collectingRegistry.ReqDurationHistogram().(*histogramMock)
 		cRetriesCounter := collectingRegistry.RetriesCounter().(*counterMock)
+		cShadowDifferencesGauge := collectingRegistry.ShadowDifferencesGauge().(*gaugeMock)
+		cBackendOpenConnsGauge := collectingRegistry.BackendOpenConnsGauge().(*gaugeMock)
 
 		wantCounterValue := float64(1)
 		if cReqsCounter.counterValue != wantCounterValue {
@@ -43,18 +58,39 @@ func TestNewMultiRegistry(t *testing.T) {
 		if cRetriesCounter.counterValue != wantCounterValue {
 			t.Errorf("Got value %f for RetriesCounter, want %f", cRetriesCounter.counterValue, wantCounterValue)
 		}
+		wantGaugeValue := float64(4)
+		if cShadowDifferencesGauge.gaugeValue != wantGaugeValue {
+			t.Errorf("Got value %f for ShadowDifferencesGauge, want %f", cShadowDifferencesGauge.gaugeValue, wantGaugeValue)
+		}
+		wantGaugeValue = float64(5)
+		if cBackendOpenConnsGauge.gaugeValue != wantGaugeValue {
+			t.Errorf("Got value %f for BackendOpenConnsGauge, want %f", cBackendOpenConnsGauge.gaugeValue, wantGaugeValue)
+		}
 
 		assert.Equal(t, []string{"key", "requests"}, cReqsCounter.lastLabelValues)
 		assert.Equal(t, []string{"key", "durations"}, cReqDurationHistogram.lastLabelValues)
 		assert.Equal(t, []string{"key", "retries"}, cRetriesCounter.lastLabelValues)
+		assert.Equal(t, []string{"key", "differences"}, cShadowDifferencesGauge.lastLabelValues)
+		assert.Equal(t, []string{"key", "connections"}, cBackendOpenConnsGauge.lastLabelValues)
 	}
 }
 
 func newCollectingRetryMetrics() Registry {
 	return &standardRegistry{
-		reqsCounter:          &counterMock{},
-		reqDurationHistogram: &histogramMock{},
-		retriesCounter:       &counterMock{},
+		reqsCounter:                &counterMock{},
+		reqDurationHistogram:       &histogramMock{},
+		reqSizeHistogram:           &histogramMock{},
+		respSizeHistogram:          &histogramMock{},
+		retriesCounter:             &counterMock{},
+		entryPointOpenConnsGauge:   &gaugeMock{},
+		backendOpenConnsGauge:      &gaugeMock{},
+		cacheRequestsCounter:       &counterMock{},
+		shadowDifferencesGauge:     &gaugeMock{},
+		queueDepthGauge:            &gaugeMock{},
+		queueWaitHistogram:         &histogramMock{},
+		certificateExpirationGauge: &gaugeMock{},
+		acmeRequestsCounter:        &counterMock{},
+		acmeCertificatesGauge:      &gaugeMock{},
 	}
 }
 
@@ -72,6 +108,20 @@ func (c *counterMock) Add(delta float64) {
 	c.counterValue += delta
 }
 
+type gaugeMock struct {
+	gaugeValue      float64
+	lastLabelValues []string
+}
+
+func (g *gaugeMock) With(labelValues ...string) metrics.Gauge {
+	g.lastLabelValues = labelValues
+	return g
+}
+
+func (g *gaugeMock) Set(value float64) {
+	g.gaugeValue = value
+}
+
 type histogramMock struct {
 	lastHistogramValue float64
 	lastLabelValues    []string
@@ -16,6 +16,23 @@ const (
 	reqsTotalName    = metricNamePrefix + "requests_total"
 	reqDurationName  = metricNamePrefix + "request_duration_seconds"
 	retriesTotalName = metricNamePrefix + "backend_retries_total"
+
+	reqSizeName  = metricNamePrefix + "request_size_bytes"
+	respSizeName = metricNamePrefix + "response_size_bytes"
+
+	entryPointOpenConnsName = metricNamePrefix + "entrypoint_open_connections"
+	backendOpenConnsName    = metricNamePrefix + "backend_open_connections"
+
+	cacheRequestsTotalName = metricNamePrefix + "backend_cache_requests_total"
+
+	shadowDifferencesName = metricNamePrefix + "shadow_configuration_differences"
+
+	queueDepthName    = metricNamePrefix + "backend_queue_depth"
+	queueDurationName = metricNamePrefix + "backend_queue_wait_duration_seconds"
+
+	certificateNotAfterName = metricNamePrefix + "tls_certificate_not_after"
+	acmeRequestsTotalName   = metricNamePrefix + "acme_requests_total"
+	acmeCertificatesName    = metricNamePrefix + "acme_certificates"
 )
 
 // PrometheusHandler expose Prometheus routes
@@ -28,30 +45,98 @@ func (h PrometheusHandler) AddRoutes(router *mux.Router) {
 
 // RegisterPrometheus registers all Prometheus metrics.
 // It must be called only once and failing to register the metrics will lead to a panic.
-func RegisterPrometheus(config *types.Prometheus) Registry {
+// The request and retry metrics are labelled with the targets of the requests at the granularity.
+func RegisterPrometheus(config *types.Prometheus, granularity string) Registry {
 	buckets := []float64{0.1, 0.3, 1.2, 5.0}
 	if config.Buckets != nil {
 		buckets = config.Buckets
 	}
+	sizeBuckets := []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+	if config.SizeBuckets != nil {
+		sizeBuckets = config.SizeBuckets
+	}
+
+	targetLabels := getTargetLabels(granularity, config.FrontendLabel, config.BackendLabel)
+	reqsLabels := getRequestLabels(defaultReqsLabels, targetLabels, config.DropLabels)
+	reqDurationLabels := getRequestLabels(defaultReqDurationLabels, targetLabels, config.DropLabels)
+	retryLabels := getRetryLabels(targetLabels)
 
 	reqCounter := prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Name: reqsTotalName,
 		Help: "How many HTTP requests processed, partitioned by status code and method.",
-	}, []string{"service", "code", "method"})
+	}, reqsLabels)
 	reqDurationHistogram := prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
 		Name:    reqDurationName,
 		Help:    "How long it took to process the request.",
 		Buckets: buckets,
-	}, []string{"service", "code"})
+	}, reqDurationLabels)
+	reqSizeHistogram := prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Name:    reqSizeName,
+		Help:    "How many bytes the bodies of the requests had.",
+		Buckets: sizeBuckets,
+	}, reqDurationLabels)
+	respSizeHistogram := prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Name:    respSizeName,
+		Help:    "How many bytes the bodies of the responses had.",
+		Buckets: sizeBuckets,
+	}, reqDurationLabels)
+	entryPointOpenConnsGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: entryPointOpenConnsName,
+		Help: "How many client connections are open on an entry point.",
+	}, []string{"entrypoint"})
+	backendOpenConnsGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: backendOpenConnsName,
+		Help: "How many requests are being forwarded to a backend, each holding a connection to a server.",
+	}, []string{"backend"})
 	retryCounter := prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Name: retriesTotalName,
 		Help: "How many request retries happened in total.",
-	}, []string{"service"})
+	}, retryLabels)
+	cacheRequestsCounter := prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Name: cacheRequestsTotalName,
+		Help: "How many HTTP requests were answered by an upstream cache, partitioned by cache status.",
+	}, []string{"service", "status"})
+	shadowDifferencesGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: shadowDifferencesName,
+		Help: "How many frontends and backends differ between the configuration of a shadow provider and the active configuration.",
+	}, []string{"provider"})
+	queueDepthGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: queueDepthName,
+		Help: "How many requests are waiting in the queue of a backend.",
+	}, []string{"backend"})
+	queueWaitHistogram := prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Name:    queueDurationName,
+		Help:    "How long the requests waited in the queue of a backend.",
+		Buckets: buckets,
+	}, []string{"backend"})
+	certificateExpirationGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: certificateNotAfterName,
+		Help: "The expiration date of the certificate served for a domain on an entry point, as a Unix timestamp in seconds.",
+	}, []string{"entrypoint", "domain"})
+	acmeRequestsCounter := prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Name: acmeRequestsTotalName,
+		Help: "How many ACME certificate orders and renewals happened, partitioned by type and status.",
+	}, []string{"type", "status"})
+	acmeCertificatesGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: acmeCertificatesName,
+		Help: "How many certificates are in the ACME store.",
+	}, []string{})
 
 	return &standardRegistry{
-		enabled:              true,
-		reqsCounter:          reqCounter,
-		reqDurationHistogram: reqDurationHistogram,
-		retriesCounter:       retryCounter,
+		enabled:                    true,
+		reqsCounter:                newFilteredCounter(reqCounter, reqsLabels),
+		reqDurationHistogram:       newFilteredHistogram(reqDurationHistogram, reqDurationLabels),
+		reqSizeHistogram:           newFilteredHistogram(reqSizeHistogram, reqDurationLabels),
+		respSizeHistogram:          newFilteredHistogram(respSizeHistogram, reqDurationLabels),
+		retriesCounter:             newFilteredCounter(retryCounter, retryLabels),
+		entryPointOpenConnsGauge:   entryPointOpenConnsGauge,
+		backendOpenConnsGauge:      backendOpenConnsGauge,
+		cacheRequestsCounter:       cacheRequestsCounter,
+		shadowDifferencesGauge:     shadowDifferencesGauge,
+		queueDepthGauge:            queueDepthGauge,
+		queueWaitHistogram:         queueWaitHistogram,
+		certificateExpirationGauge: certificateExpirationGauge,
+		acmeRequestsCounter:        acmeRequestsCounter,
+		acmeCertificatesGauge:      acmeCertificatesGauge,
 	}
 }
@@ -11,7 +11,7 @@ import (
 )
 
 func TestPrometheus(t *testing.T) {
-	prometheusRegistry := RegisterPrometheus(&types.Prometheus{})
+	prometheusRegistry := RegisterPrometheus(&types.Prometheus{}, "")
 
 	if !prometheusRegistry.IsEnabled() {
 		t.Errorf("PrometheusRegistry should return true for IsEnabled()")
@@ -20,7 +20,18 @@ func TestPrometheus(t *testing.T) {
 	prometheusRegistry.ReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
 	prometheusRegistry.ReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
 	prometheusRegistry.ReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
-	prometheusRegistry.RetriesCounter().With("service", "test").Add(1)
+	prometheusRegistry.ReqSizeHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(2048)
+	prometheusRegistry.RespSizeHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10)
+	prometheusRegistry.RetriesCounter().With("backend", "test").Add(1)
+	prometheusRegistry.EntryPointOpenConnsGauge().With("entrypoint", "http").Set(7)
+	prometheusRegistry.BackendOpenConnsGauge().With("backend", "test").Set(3)
+	prometheusRegistry.CacheRequestsCounter().With("service", "test", "status", "hit").Add(1)
+	prometheusRegistry.ShadowDifferencesGauge().With("provider", "ecs").Set(3)
+	prometheusRegistry.QueueDepthGauge().With("backend", "test").Set(2)
+	prometheusRegistry.QueueWaitHistogram().With("backend", "test").Observe(1)
+	prometheusRegistry.CertificateExpirationGauge().With("entrypoint", "https", "domain", "example.com").Set(1514764800)
+	prometheusRegistry.ACMERequestsCounter().With("type", "renewal", "status", "failure").Add(1)
+	prometheusRegistry.ACMECertificatesGauge().Set(4)
 
 	metricsFamilies, err := prometheus.DefaultGatherer.Gather()
 	if err != nil {
@@ -62,9 +73,63 @@ func TestPrometheus(t *testing.T) {
 			},
 		},
 		{
-			name: retriesTotalName,
+			name: reqSizeName,
+			labels: map[string]string{
+				"service": "test",
+				"code":    "200",
+			},
+			assert: func(family *dto.MetricFamily) {
+				ss := family.Metric[0].Histogram.GetSampleSum()
+				expectedSs := float64(2048)
+				if ss != expectedSs {
+					t.Errorf("gathered metrics do not contain correct sample sum for request size, got %f expected %f", ss, expectedSs)
+				}
+			},
+		},
+		{
+			name: respSizeName,
 			labels: map[string]string{
 				"service": "test",
+				"code":    "200",
+			},
+			assert: func(family *dto.MetricFamily) {
+				ss := family.Metric[0].Histogram.GetSampleSum()
+				expectedSs := float64(10)
+				if ss != expectedSs {
+					t.Errorf("gathered metrics do not contain correct sample sum for response size, got %f expected %f", ss, expectedSs)
+				}
+			},
+		},
+		{
+			name: entryPointOpenConnsName,
+			labels: map[string]string{
+				"entrypoint": "http",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(7)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for entry point open connections, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
+		{
+			name: backendOpenConnsName,
+			labels: map[string]string{
+				"backend": "test",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(3)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for backend open connections, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
+		{
+			name: retriesTotalName,
+			labels: map[string]string{
+				"backend": "test",
 			},
 			assert: func(family *dto.MetricFamily) {
 				cv := family.Metric[0].Counter.GetValue()
@@ -74,6 +139,98 @@ func TestPrometheus(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: cacheRequestsTotalName,
+			labels: map[string]string{
+				"service": "test",
+				"status":  "hit",
+			},
+			assert: func(family *dto.MetricFamily) {
+				cv := family.Metric[0].Counter.GetValue()
+				expectedCv := float64(1)
+				if cv != expectedCv {
+					t.Errorf("gathered metrics do not contain correct value for total cache requests, got %f expected %f", cv, expectedCv)
+				}
+			},
+		},
+		{
+			name: shadowDifferencesName,
+			labels: map[string]string{
+				"provider": "ecs",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(3)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for shadow configuration differences, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
+		{
+			name: queueDepthName,
+			labels: map[string]string{
+				"backend": "test",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(2)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for queue depth, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
+		{
+			name: queueDurationName,
+			labels: map[string]string{
+				"backend": "test",
+			},
+			assert: func(family *dto.MetricFamily) {
+				sc := family.Metric[0].Histogram.GetSampleCount()
+				expectedSc := uint64(1)
+				if sc != expectedSc {
+					t.Errorf("gathered metrics do not contain correct sample count for queue wait duration, got %d expected %d", sc, expectedSc)
+				}
+			},
+		},
+		{
+			name: certificateNotAfterName,
+			labels: map[string]string{
+				"entrypoint": "https",
+				"domain":     "example.com",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(1514764800)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for certificate expiration, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
+		{
+			name: acmeRequestsTotalName,
+			labels: map[string]string{
+				"type":   "renewal",
+				"status": "failure",
+			},
+			assert: func(family *dto.MetricFamily) {
+				cv := family.Metric[0].Counter.GetValue()
+				expectedCv := float64(1)
+				if cv != expectedCv {
+					t.Errorf("gathered metrics do not contain correct value for ACME requests, got %f expected %f", cv, expectedCv)
+				}
+			},
+		},
+		{
+			name:   acmeCertificatesName,
+			labels: map[string]string{},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(4)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for ACME certificates, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -1,12 +1,14 @@
 package metrics
 
 import (
+	"strings"
 	"time"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/dogstatsd"
 	"github.com/go-kit/kit/metrics/statsd"
 )
 
@@ -15,26 +17,100 @@ var statsdClient = statsd.New("traefik.", kitlog.LoggerFunc(func(keyvals ...inte
 	return nil
 }))
 
+// statsdTaggedClient sends the StatsD metrics with DogStatsD tags
+var statsdTaggedClient = dogstatsd.New("traefik.", kitlog.LoggerFunc(func(keyvals ...interface{}) error {
+	log.Info(keyvals)
+	return nil
+}))
+
 var statsdTicker *time.Ticker
 
 const (
 	statsdMetricsReqsName    = "requests.total"
 	statsdMetricsLatencyName = "request.duration"
+	statsdReqSizeName        = "request.size"
+	statsdRespSizeName       = "response.size"
+	statsdEntryPointConnName = "entrypoint.open.connections"
+	statsdBackendConnName    = "backend.open.connections"
 	statsdRetriesTotalName   = "backend.retries.total"
+	statsdCacheRequestsName  = "backend.cache.requests.total"
+	statsdShadowDiffName     = "shadow.configuration.differences"
+	statsdQueueDepthName     = "backend.queue.depth"
+	statsdQueueWaitName      = "backend.queue.wait.duration"
+	statsdCertExpirationName = "tls.certificate.not.after"
+	statsdACMERequestsName   = "acme.requests.total"
+	statsdACMECertsName      = "acme.certificates"
 )
 
 // RegisterStatsd registers the metrics pusher if this didn't happen yet and creates a statsd Registry instance.
-func RegisterStatsd(config *types.Statsd) Registry {
+// With DogStatsD tags, the request and retry metrics are tagged with the targets of the requests at the granularity.
+func RegisterStatsd(config *types.Statsd, granularity string) Registry {
 	if statsdTicker == nil {
 		statsdTicker = initStatsdTicker(config)
 	}
 
+	if config.DogStatsD {
+		return registerTaggedStatsd(config, granularity)
+	}
+
+	return &standardRegistry{
+		enabled:                    true,
+		reqsCounter:                statsdClient.NewCounter(statsdMetricsReqsName, 1.0),
+		reqDurationHistogram:       statsdClient.NewTiming(statsdMetricsLatencyName, 1.0),
+		reqSizeHistogram:           statsdClient.NewTiming(statsdReqSizeName, 1.0),
+		respSizeHistogram:          statsdClient.NewTiming(statsdRespSizeName, 1.0),
+		retriesCounter:             statsdClient.NewCounter(statsdRetriesTotalName, 1.0),
+		entryPointOpenConnsGauge:   statsdClient.NewGauge(statsdEntryPointConnName),
+		backendOpenConnsGauge:      statsdClient.NewGauge(statsdBackendConnName),
+		cacheRequestsCounter:       statsdClient.NewCounter(statsdCacheRequestsName, 1.0),
+		shadowDifferencesGauge:     statsdClient.NewGauge(statsdShadowDiffName),
+		queueDepthGauge:            statsdClient.NewGauge(statsdQueueDepthName),
+		queueWaitHistogram:         statsdClient.NewTiming(statsdQueueWaitName, 1.0),
+		certificateExpirationGauge: statsdClient.NewGauge(statsdCertExpirationName),
+		acmeRequestsCounter:        statsdClient.NewCounter(statsdACMERequestsName, 1.0),
+		acmeCertificatesGauge:      statsdClient.NewGauge(statsdACMECertsName),
+	}
+}
+
+// registerTaggedStatsd creates a statsd Registry instance sending the metrics with DogStatsD tags:
+// the global tags of the configuration, and the labels of the metrics.
+func registerTaggedStatsd(config *types.Statsd, granularity string) Registry {
+	tags := getStatsdTags(config.Tags)
+	targetLabels := getTargetLabels(granularity, config.FrontendLabel, config.BackendLabel)
+	reqsLabels := getRequestLabels(defaultReqsLabels, targetLabels, config.DropLabels)
+	reqDurationLabels := getRequestLabels(defaultReqDurationLabels, targetLabels, config.DropLabels)
+
 	return &standardRegistry{
-		enabled:              true,
-		reqsCounter:          statsdClient.NewCounter(statsdMetricsReqsName, 1.0),
-		reqDurationHistogram: statsdClient.NewTiming(statsdMetricsLatencyName, 1.0),
-		retriesCounter:       statsdClient.NewCounter(statsdRetriesTotalName, 1.0),
+		enabled:                    true,
+		reqsCounter:                newFilteredCounter(statsdTaggedClient.NewCounter(statsdMetricsReqsName, 1.0).With(tags...), reqsLabels),
+		reqDurationHistogram:       newFilteredHistogram(statsdTaggedClient.NewTiming(statsdMetricsLatencyName, 1.0).With(tags...), reqDurationLabels),
+		reqSizeHistogram:           newFilteredHistogram(statsdTaggedClient.NewHistogram(statsdReqSizeName, 1.0).With(tags...), reqDurationLabels),
+		respSizeHistogram:          newFilteredHistogram(statsdTaggedClient.NewHistogram(statsdRespSizeName, 1.0).With(tags...), reqDurationLabels),
+		retriesCounter:             newFilteredCounter(statsdTaggedClient.NewCounter(statsdRetriesTotalName, 1.0).With(tags...), getRetryLabels(targetLabels)),
+		entryPointOpenConnsGauge:   statsdTaggedClient.NewGauge(statsdEntryPointConnName).With(tags...),
+		backendOpenConnsGauge:      statsdTaggedClient.NewGauge(statsdBackendConnName).With(tags...),
+		cacheRequestsCounter:       statsdTaggedClient.NewCounter(statsdCacheRequestsName, 1.0).With(tags...),
+		shadowDifferencesGauge:     statsdTaggedClient.NewGauge(statsdShadowDiffName).With(tags...),
+		queueDepthGauge:            statsdTaggedClient.NewGauge(statsdQueueDepthName).With(tags...),
+		queueWaitHistogram:         statsdTaggedClient.NewTiming(statsdQueueWaitName, 1.0).With(tags...),
+		certificateExpirationGauge: statsdTaggedClient.NewGauge(statsdCertExpirationName).With(tags...),
+		acmeRequestsCounter:        statsdTaggedClient.NewCounter(statsdACMERequestsName, 1.0).With(tags...),
+		acmeCertificatesGauge:      statsdTaggedClient.NewGauge(statsdACMECertsName).With(tags...),
+	}
+}
+
+// getStatsdTags returns the label values of global tags like "env:production".
+func getStatsdTags(tags []string) []string {
+	var labelValues []string
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			log.Warnf("Invalid StatsD tag %q, skipping...", tag)
+			continue
+		}
+		labelValues = append(labelValues, parts[0], parts[1])
 	}
+	return labelValues
 }
 
 // initStatsdTicker initializes metrics pusher and creates a statsdClient if not created already
@@ -52,7 +128,11 @@ func initStatsdTicker(config *types.Statsd) *time.Ticker {
 	report := time.NewTicker(pushInterval)
 
 	safe.Go(func() {
-		statsdClient.SendLoop(report.C, "udp", address)
+		if config.DogStatsD {
+			statsdTaggedClient.SendLoop(report.C, "udp", address)
+		} else {
+			statsdClient.SendLoop(report.C, "udp", address)
+		}
 	})
 
 	return report
@@ -14,7 +14,7 @@ func TestStatsD(t *testing.T) {
 	// This is needed to make sure that UDP Listener listens for data a bit longer, otherwise it will quit after a millisecond
 	udp.Timeout = 5 * time.Second
 
-	statsdRegistry := RegisterStatsd(&types.Statsd{Address: ":18125", PushInterval: "1s"})
+	statsdRegistry := RegisterStatsd(&types.Statsd{Address: ":18125", PushInterval: "1s"}, "")
 	defer StopStatsd()
 
 	if !statsdRegistry.IsEnabled() {
@@ -36,3 +36,32 @@ func TestStatsD(t *testing.T) {
 		statsdRegistry.ReqDurationHistogram().With("service", "test", "code", string(http.StatusOK)).Observe(10000)
 	})
 }
+
+func TestStatsDTagged(t *testing.T) {
+	udp.SetAddr(":18126")
+	// This is needed to make sure that UDP Listener listens for data a bit longer, otherwise it will quit after a millisecond
+	udp.Timeout = 5 * time.Second
+
+	statsdRegistry := RegisterStatsd(&types.Statsd{
+		Address:       ":18126",
+		PushInterval:  "1s",
+		DogStatsD:     true,
+		Tags:          types.MetricsLabels{"env:production", "invalid"},
+		FrontendLabel: true,
+		DropLabels:    types.MetricsLabels{"method"},
+	}, "")
+	defer StopStatsd()
+
+	expected := []string{
+		"traefik.requests.total:2.000000|c|#env:production,service:test,frontend:web,code:200\n",
+		"traefik.backend.retries.total:1.000000|c|#env:production,frontend:web,backend:test\n",
+		"traefik.request.duration:10000.000000|ms|#env:production,service:test,frontend:web,code:200\n",
+	}
+
+	udp.ShouldReceiveAll(t, expected, func() {
+		statsdRegistry.ReqsCounter().With("service", "test", "frontend", "web", "backend", "test", "code", "200", "method", http.MethodGet).Add(1)
+		statsdRegistry.ReqsCounter().With("service", "test", "frontend", "web", "backend", "test", "code", "200", "method", http.MethodPost).Add(1)
+		statsdRegistry.RetriesCounter().With("entrypoint", "http", "frontend", "web", "backend", "test").Add(1)
+		statsdRegistry.ReqDurationHistogram().With("service", "test", "frontend", "web", "backend", "test", "code", "200").Observe(10000)
+	})
+}
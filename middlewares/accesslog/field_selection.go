@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/containous/traefik/types"
+)
+
+// redactedValue replaces the values of the redacted fields and headers
+const redactedValue = "REDACTED"
+
+// fieldSelection keeps, drops or redacts the fields and headers of the access log, and renames the fields.
+type fieldSelection struct {
+	defaultMode       string
+	modes             map[string]string
+	headerDefaultMode string
+	headerModes       map[string]string
+	rename            map[string]string
+}
+
+// newFieldSelection creates the fieldSelection of the configuration, keeping all the fields and headers by default.
+// The fields are only renamed in the JSON format, the common format having fixed fields.
+func newFieldSelection(config *types.AccessLogFields, format string) (*fieldSelection, error) {
+	selection := &fieldSelection{
+		defaultMode:       types.AccessLogKeep,
+		headerDefaultMode: types.AccessLogKeep,
+		headerModes:       make(map[string]string),
+	}
+	if config == nil {
+		return selection, nil
+	}
+
+	if len(config.DefaultMode) > 0 {
+		selection.defaultMode = config.DefaultMode
+	}
+	selection.modes = config.Names
+	if config.Headers != nil {
+		if len(config.Headers.DefaultMode) > 0 {
+			selection.headerDefaultMode = config.Headers.DefaultMode
+		}
+		for name, mode := range config.Headers.Names {
+			selection.headerModes[http.CanonicalHeaderKey(name)] = mode
+		}
+	}
+	if format == JSONFormat {
+		selection.rename = config.Rename
+	}
+
+	modes := []string{selection.defaultMode, selection.headerDefaultMode}
+	for _, mode := range selection.modes {
+		modes = append(modes, mode)
+	}
+	for _, mode := range selection.headerModes {
+		modes = append(modes, mode)
+	}
+	for _, mode := range modes {
+		if mode != types.AccessLogKeep && mode != types.AccessLogDrop && mode != types.AccessLogRedact {
+			return nil, fmt.Errorf("unsupported access log field mode: %s", mode)
+		}
+	}
+	return selection, nil
+}
+
+// addField adds the field to the fields of the log entry, according to its mode.
+func (s *fieldSelection) addField(fields logrus.Fields, name string, value interface{}) {
+	mode, ok := s.modes[name]
+	if !ok {
+		mode = s.defaultMode
+	}
+	add(fields, name, value, mode)
+}
+
+// addHeader adds the header to the fields of the log entry, with the prefix, according to its mode.
+func (s *fieldSelection) addHeader(fields logrus.Fields, prefix, name, value string) {
+	mode, ok := s.headerModes[http.CanonicalHeaderKey(name)]
+	if !ok {
+		mode = s.headerDefaultMode
+	}
+	add(fields, prefix+name, value, mode)
+}
+
+// renameFields renames the fields of the log entry.
+func (s *fieldSelection) renameFields(fields logrus.Fields) {
+	for name, newName := range s.rename {
+		if value, ok := fields[name]; ok {
+			delete(fields, name)
+			fields[newName] = value
+		}
+	}
+}
+
+func add(fields logrus.Fields, name string, value interface{}, mode string) {
+	switch mode {
+	case types.AccessLogKeep:
+		fields[name] = value
+	case types.AccessLogRedact:
+		fields[name] = redactedValue
+	}
+}
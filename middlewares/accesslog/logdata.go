@@ -70,6 +70,10 @@ const (
 	Overhead = "Overhead"
 	// RetryAttempts is the map key used for the amount of attempts the request was retried.
 	RetryAttempts = "RetryAttempts"
+	// CacheStatus is the map key used for the upstream cache status (hit, miss or unknown) reported by the origin response.
+	CacheStatus = "CacheStatus"
+	// RequestID is the map key used for the ID correlating the request from end to end.
+	RequestID = "RequestID"
 )
 
 // These are written out in the default case when no config is provided to specify keys of interest.
@@ -113,6 +117,8 @@ func init() {
 	allCoreKeys[StartLocal] = struct{}{}
 	allCoreKeys[Overhead] = struct{}{}
 	allCoreKeys[RetryAttempts] = struct{}{}
+	allCoreKeys[CacheStatus] = struct{}{}
+	allCoreKeys[RequestID] = struct{}{}
 }
 
 // CoreLogData holds the fields computed from the request/response.
@@ -124,4 +130,8 @@ type LogData struct {
 	Request            http.Header
 	OriginResponse     http.Header
 	DownstreamResponse http.Header
+	// BackendMetadata is the metadata provided with the backend server which handled the request.
+	BackendMetadata map[string]string
+	// sampling is the sampling of the entries of the frontend which handled the request, if any.
+	sampling *Sampling
 }
@@ -3,6 +3,7 @@ package accesslog
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/types"
 )
 
@@ -35,18 +37,16 @@ type LogHandler struct {
 	logger   *logrus.Logger
 	file     *os.File
 	filePath string
+	output   *bufferedOutput
+	fields   *fieldSelection
 	mu       sync.Mutex
 }
 
 // NewLogHandler creates a new LogHandler
 func NewLogHandler(config *types.AccessLog) (*LogHandler, error) {
-	file := os.Stdout
-	if len(config.FilePath) > 0 {
-		f, err := openAccessLogFile(config.FilePath)
-		if err != nil {
-			return nil, fmt.Errorf("error opening access log file: %s", err)
-		}
-		file = f
+	fields, err := newFieldSelection(config.Fields, config.Format)
+	if err != nil {
+		return nil, err
 	}
 
 	var formatter logrus.Formatter
@@ -60,13 +60,54 @@ func NewLogHandler(config *types.AccessLog) (*LogHandler, error) {
 		return nil, fmt.Errorf("unsupported access log format: %s", config.Format)
 	}
 
+	output, err := newOutput(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *os.File
+	var out io.Writer = output
+	if output == nil {
+		file = os.Stdout
+		if len(config.FilePath) > 0 {
+			f, err := openAccessLogFile(config.FilePath)
+			if err != nil {
+				return nil, fmt.Errorf("error opening access log file: %s", err)
+			}
+			file = f
+		}
+		out = file
+	}
+
 	logger := &logrus.Logger{
-		Out:       file,
+		Out:       out,
 		Formatter: formatter,
 		Hooks:     make(logrus.LevelHooks),
 		Level:     logrus.InfoLevel,
 	}
-	return &LogHandler{logger: logger, file: file, filePath: config.FilePath}, nil
+	return &LogHandler{logger: logger, file: file, filePath: config.FilePath, output: output, fields: fields}, nil
+}
+
+// newOutput creates the syslog or Kafka REST proxy output of the access log, if any.
+func newOutput(config *types.AccessLog) (*bufferedOutput, error) {
+	switch {
+	case config.Syslog != nil && config.KafkaRESTProxy != nil:
+		return nil, fmt.Errorf("the access log can't be sent to both syslog and a Kafka REST proxy")
+	case config.Syslog != nil:
+		sender, err := newSyslogSender(config.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("error creating the access log syslog output: %v", err)
+		}
+		return newBufferedOutput("syslog", sender, config.Syslog.Buffer)
+	case config.KafkaRESTProxy != nil:
+		sender, err := newKafkaRESTProxySender(config.KafkaRESTProxy)
+		if err != nil {
+			return nil, fmt.Errorf("error creating the access log Kafka REST proxy output: %v", err)
+		}
+		return newBufferedOutput("Kafka REST proxy", sender, config.KafkaRESTProxy.Buffer)
+	default:
+		return nil, nil
+	}
 }
 
 func openAccessLogFile(filePath string) (*os.File, error) {
@@ -107,6 +148,9 @@ func (l *LogHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next h
 	}
 
 	core[RequestCount] = nextRequestCount()
+	if requestID := middlewares.GetRequestID(req); len(requestID) > 0 {
+		core[RequestID] = requestID
+	}
 	if req.Host != "" {
 		core[RequestAddr] = req.Host
 		core[RequestHost], core[RequestPort] = silentSplitHostPort(req.Host)
@@ -142,7 +186,13 @@ func (l *LogHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next h
 }
 
 // Close closes the Logger (i.e. the file etc).
+// The entries buffered by the syslog or Kafka REST proxy output are sent first.
 func (l *LogHandler) Close() error {
+	if l.output != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.output.Close()
+	}
 	return l.file.Close()
 }
 
@@ -151,6 +201,10 @@ func (l *LogHandler) Close() error {
 func (l *LogHandler) Rotate() error {
 	var err error
 
+	if l.output != nil {
+		return nil
+	}
+
 	if l.file != nil {
 		defer func(f *os.File) {
 			f.Close()
@@ -215,24 +269,34 @@ func (l *LogHandler) logTheRoundTrip(logDataTable *LogData, crr *captureRequestR
 		core[Overhead] = total
 	}
 
+	if logDataTable.sampling != nil && !logDataTable.sampling.keep(crw.Status(), total) {
+		return
+	}
+
 	fields := logrus.Fields{}
 
 	for k, v := range logDataTable.Core {
-		fields[k] = v
+		l.fields.addField(fields, k, v)
 	}
 
 	for k := range logDataTable.Request {
-		fields["request_"+k] = logDataTable.Request.Get(k)
+		l.fields.addHeader(fields, "request_", k, logDataTable.Request.Get(k))
 	}
 
 	for k := range logDataTable.OriginResponse {
-		fields["origin_"+k] = logDataTable.OriginResponse.Get(k)
+		l.fields.addHeader(fields, "origin_", k, logDataTable.OriginResponse.Get(k))
 	}
 
 	for k := range logDataTable.DownstreamResponse {
-		fields["downstream_"+k] = logDataTable.DownstreamResponse.Get(k)
+		l.fields.addHeader(fields, "downstream_", k, logDataTable.DownstreamResponse.Get(k))
 	}
 
+	for k, v := range logDataTable.BackendMetadata {
+		l.fields.addField(fields, "backend_"+k, v)
+	}
+
+	l.fields.renameFields(fields)
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.logger.WithFields(fields).Println()
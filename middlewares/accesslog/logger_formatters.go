@@ -21,16 +21,23 @@ type CommonLogFormatter struct{}
 func (f *CommonLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	b := &bytes.Buffer{}
 
-	timestamp := entry.Data[StartUTC].(time.Time).Format(commonLogTimeFormat)
-	elapsedMillis := entry.Data[Duration].(time.Duration).Nanoseconds() / 1000000
+	// the fields may be dropped or redacted
+	timestamp := defaultValue
+	if startUTC, ok := entry.Data[StartUTC].(time.Time); ok {
+		timestamp = startUTC.Format(commonLogTimeFormat)
+	}
+	var elapsedMillis int64
+	if duration, ok := entry.Data[Duration].(time.Duration); ok {
+		elapsedMillis = duration.Nanoseconds() / 1000000
+	}
 
 	_, err := fmt.Fprintf(b, "%s - %s [%s] \"%s %s %s\" %v %v %s %s %v %s %s %dms\n",
-		entry.Data[ClientHost],
-		entry.Data[ClientUsername],
+		toLogOrDefault(entry.Data[ClientHost]),
+		toLogOrDefault(entry.Data[ClientUsername]),
 		timestamp,
-		entry.Data[RequestMethod],
-		entry.Data[RequestPath],
-		entry.Data[RequestProtocol],
+		toLogOrDefault(entry.Data[RequestMethod]),
+		toLogOrDefault(entry.Data[RequestPath]),
+		toLogOrDefault(entry.Data[RequestProtocol]),
 		toLog(entry.Data[OriginStatus]),
 		toLog(entry.Data[OriginContentSize]),
 		toLog(entry.Data["request_Referer"]),
@@ -61,6 +68,14 @@ func toLog(v interface{}) interface{} {
 
 }
 
+// toLogOrDefault returns the unquoted value, or the default value if missing.
+func toLogOrDefault(v interface{}) interface{} {
+	if v == nil {
+		return defaultValue
+	}
+	return v
+}
+
 func quoted(s string, defaultValue string) string {
 	if len(s) == 0 {
 		return defaultValue
@@ -244,6 +244,122 @@ func TestLoggerJSON(t *testing.T) {
 	assert.Equal(t, len(jsonData), assertCount, string(logData))
 }
 
+func TestLoggerJSONFields(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		fields   *types.AccessLogFields
+		expected map[string]interface{}
+		missing  []string
+	}{
+		{
+			desc: "dropped fields",
+			fields: &types.AccessLogFields{
+				Names: types.FieldNames{ClientHost: types.AccessLogDrop, ClientAddr: types.AccessLogDrop},
+			},
+			expected: map[string]interface{}{RequestHost: testHostname},
+			missing:  []string{ClientHost, ClientAddr},
+		},
+		{
+			desc: "kept fields only",
+			fields: &types.AccessLogFields{
+				DefaultMode: types.AccessLogDrop,
+				Names:       types.FieldNames{RequestMethod: types.AccessLogKeep},
+			},
+			expected: map[string]interface{}{RequestMethod: testMethod, "request_Referer": testReferer},
+			missing:  []string{RequestHost, ClientHost, FrontendName},
+		},
+		{
+			desc: "redacted field and headers",
+			fields: &types.AccessLogFields{
+				Names: types.FieldNames{ClientUsername: types.AccessLogRedact},
+				Headers: &types.FieldHeaders{
+					DefaultMode: types.AccessLogDrop,
+					Names:       types.FieldNames{"referer": types.AccessLogRedact, "User-Agent": types.AccessLogKeep},
+				},
+			},
+			expected: map[string]interface{}{
+				ClientUsername:       "REDACTED",
+				"request_Referer":    "REDACTED",
+				"request_User-Agent": testUserAgent,
+			},
+			missing: []string{"downstream_Content-Type"},
+		},
+		{
+			desc: "renamed fields",
+			fields: &types.AccessLogFields{
+				Rename: types.FieldNames{RequestMethod: "method", "request_User-Agent": "user_agent"},
+			},
+			expected: map[string]interface{}{"method": testMethod, "user_agent": testUserAgent},
+			missing:  []string{RequestMethod, "request_User-Agent"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := createTempDir(t, JSONFormat)
+			defer os.RemoveAll(tmpDir)
+
+			logFilePath := filepath.Join(tmpDir, logFileNameSuffix)
+			config := &types.AccessLog{FilePath: logFilePath, Format: JSONFormat, Fields: test.fields}
+			doLogging(t, config)
+
+			logData, err := ioutil.ReadFile(logFilePath)
+			require.NoError(t, err)
+
+			jsonData := make(map[string]interface{})
+			err = json.Unmarshal(logData, &jsonData)
+			require.NoError(t, err)
+
+			for key, value := range test.expected {
+				assert.Equal(t, value, jsonData[key], key)
+			}
+			for _, key := range test.missing {
+				assert.NotContains(t, jsonData, key)
+			}
+		})
+	}
+}
+
+func TestLoggerCLFDroppedFields(t *testing.T) {
+	tmpDir := createTempDir(t, CommonFormat)
+	defer os.RemoveAll(tmpDir)
+
+	logFilePath := filepath.Join(tmpDir, logFileNameSuffix)
+	config := &types.AccessLog{
+		FilePath: logFilePath,
+		Format:   CommonFormat,
+		Fields: &types.AccessLogFields{
+			Names: types.FieldNames{ClientHost: types.AccessLogDrop, StartUTC: types.AccessLogRedact},
+		},
+	}
+	doLogging(t, config)
+
+	logData, err := ioutil.ReadFile(logFilePath)
+	require.NoError(t, err)
+
+	tokens, err := shellwords.Parse(string(logData))
+	require.NoError(t, err)
+	// the redacted timestamp is a single token
+	require.Equal(t, 13, len(tokens), string(logData))
+	assert.Equal(t, "-", tokens[0])
+	assert.Equal(t, "[-]", tokens[3])
+	assert.Equal(t, testUsername, tokens[2])
+}
+
+func TestNewLogHandlerInvalidFieldMode(t *testing.T) {
+	config := &types.AccessLog{
+		Format: JSONFormat,
+		Fields: &types.AccessLogFields{
+			Headers: &types.FieldHeaders{DefaultMode: "hide"},
+		},
+	}
+	_, err := NewLogHandler(config)
+	assert.Error(t, err)
+}
+
 func TestNewLogHandlerOutputStdout(t *testing.T) {
 	file, restoreStdout := captureStdout(t)
 	defer restoreStdout()
@@ -0,0 +1,158 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// The defaults of the buffering of the outputs.
+const (
+	defaultBufferSize    = 1000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// The overflow policies of the outputs.
+const (
+	overflowDrop  = "drop"
+	overflowBlock = "block"
+)
+
+var errOutputClosed = errors.New("the access log output is closed")
+
+// outputEntry is an access log entry, formatted, and the time it was written at.
+type outputEntry struct {
+	time time.Time
+	line []byte
+}
+
+// entrySender sends the batches of entries of an output.
+type entrySender interface {
+	send(entries []outputEntry) error
+	close() error
+}
+
+// bufferedOutput writes the access log entries to a sender, buffering them and sending them by batches in the background.
+// When the buffer is full, the new entries are dropped, or wait for room with the block policy.
+type bufferedOutput struct {
+	name          string
+	sender        entrySender
+	entries       chan outputEntry
+	batchSize     int
+	flushInterval time.Duration
+	block         bool
+	dropped       uint64
+	closed        int32
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+func newBufferedOutput(name string, sender entrySender, config *types.AccessLogBuffer) (*bufferedOutput, error) {
+	size, batchSize, flushInterval := defaultBufferSize, defaultBatchSize, defaultFlushInterval
+	var block bool
+	if config != nil {
+		if config.Size > 0 {
+			size = config.Size
+		}
+		if config.BatchSize > 0 {
+			batchSize = config.BatchSize
+		}
+		if config.FlushInterval > 0 {
+			flushInterval = time.Duration(config.FlushInterval)
+		}
+		switch config.OverflowPolicy {
+		case "", overflowDrop:
+		case overflowBlock:
+			block = true
+		default:
+			return nil, fmt.Errorf("unsupported access log overflow policy: %s", config.OverflowPolicy)
+		}
+	}
+
+	o := &bufferedOutput{
+		name:          name,
+		sender:        sender,
+		entries:       make(chan outputEntry, size),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		block:         block,
+		done:          make(chan struct{}),
+	}
+	go o.run()
+	return o, nil
+}
+
+// Write buffers an entry, formatted by the logger.
+// It must not be called concurrently with Close.
+func (o *bufferedOutput) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&o.closed) == 1 {
+		return 0, errOutputClosed
+	}
+
+	entry := outputEntry{time: time.Now(), line: append([]byte(nil), p...)}
+	if o.block {
+		o.entries <- entry
+		return len(p), nil
+	}
+
+	select {
+	case o.entries <- entry:
+	default:
+		atomic.AddUint64(&o.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Close sends the buffered entries, and closes the sender.
+func (o *bufferedOutput) Close() error {
+	o.closeOnce.Do(func() {
+		atomic.StoreInt32(&o.closed, 1)
+		close(o.entries)
+	})
+	<-o.done
+	return o.sender.close()
+}
+
+func (o *bufferedOutput) run() {
+	defer close(o.done)
+
+	ticker := time.NewTicker(o.flushInterval)
+	defer ticker.Stop()
+
+	var batch []outputEntry
+	for {
+		select {
+		case entry, ok := <-o.entries:
+			if !ok {
+				o.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= o.batchSize {
+				o.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			o.flush(batch)
+			batch = nil
+			if dropped := atomic.SwapUint64(&o.dropped, 0); dropped > 0 {
+				log.Warnf("Dropped %d access log entries: the buffer of the %s output is full", dropped, o.name)
+			}
+		}
+	}
+}
+
+func (o *bufferedOutput) flush(batch []outputEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := o.sender.send(batch); err != nil {
+		log.Errorf("Error sending %d access log entries to the %s output: %v", len(batch), o.name, err)
+	}
+}
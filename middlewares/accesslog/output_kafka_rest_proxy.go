@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	kafkaRESTProxyContentType    = "application/vnd.kafka.json.v2+json"
+	kafkaRESTProxyRequestTimeout = 10 * time.Second
+)
+
+// kafkaRESTProxySender produces the entries to a Kafka topic through a Kafka REST proxy,
+// the entries in the JSON format being produced as JSON values, and the other ones as strings.
+type kafkaRESTProxySender struct {
+	topicURL string
+	client   *http.Client
+}
+
+type kafkaRESTProxyRecords struct {
+	Records []kafkaRESTProxyRecord `json:"records"`
+}
+
+type kafkaRESTProxyRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+func newKafkaRESTProxySender(config *types.AccessLogKafkaRESTProxy) (*kafkaRESTProxySender, error) {
+	if len(config.URL) == 0 {
+		return nil, fmt.Errorf("no Kafka REST proxy URL")
+	}
+	if len(config.Topic) == 0 {
+		return nil, fmt.Errorf("no Kafka topic")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %s: %v", config.URL, err)
+	}
+
+	return &kafkaRESTProxySender{
+		topicURL: strings.TrimSuffix(config.URL, "/") + "/topics/" + url.PathEscape(config.Topic),
+		client:   &http.Client{Timeout: kafkaRESTProxyRequestTimeout},
+	}, nil
+}
+
+func (s *kafkaRESTProxySender) send(entries []outputEntry) error {
+	records := kafkaRESTProxyRecords{Records: make([]kafkaRESTProxyRecord, 0, len(entries))}
+	for _, entry := range entries {
+		line := bytes.TrimRight(entry.line, "\n")
+		value := json.RawMessage(line)
+		if !json.Valid(line) {
+			var err error
+			if value, err = json.Marshal(string(line)); err != nil {
+				return err
+			}
+		}
+		records.Records = append(records.Records, kafkaRESTProxyRecord{Value: value})
+	}
+
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.topicURL, kafkaRESTProxyContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s of the Kafka REST proxy", resp.Status)
+	}
+	return nil
+}
+
+func (s *kafkaRESTProxySender) close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	syslogDialTimeout     = 5 * time.Second
+	syslogSeverityInfo    = 6
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var syslogFacilities = map[string]int{
+	"user":   1,
+	"daemon": 3,
+	"local0": 16,
+	"local1": 17,
+	"local2": 18,
+	"local3": 19,
+	"local4": 20,
+	"local5": 21,
+	"local6": 22,
+	"local7": 23,
+}
+
+// syslogSender sends the entries to a syslog server as RFC 5424 messages,
+// framed by octet counting (RFC 6587) on the stream networks.
+type syslogSender struct {
+	network  string
+	address  string
+	priority int
+	hostname string
+	appName  string
+	conn     net.Conn
+}
+
+func newSyslogSender(config *types.AccessLogSyslog) (*syslogSender, error) {
+	network := config.Network
+	if len(network) == 0 {
+		network = "udp"
+	}
+	switch network {
+	case "tcp", "udp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", network)
+	}
+	if len(config.Address) == 0 {
+		return nil, fmt.Errorf("no syslog address")
+	}
+
+	facilityName := config.Facility
+	if len(facilityName) == 0 {
+		facilityName = "local0"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported syslog facility: %s", facilityName)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) == 0 {
+		hostname = "-"
+	}
+	appName := config.AppName
+	if len(appName) == 0 {
+		appName = "traefik"
+	}
+
+	return &syslogSender{
+		network:  network,
+		address:  config.Address,
+		priority: facility*8 + syslogSeverityInfo,
+		hostname: hostname,
+		appName:  appName,
+	}, nil
+}
+
+func (s *syslogSender) send(entries []outputEntry) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, syslogDialTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	stream := s.network == "tcp" || s.network == "unix"
+	for _, entry := range entries {
+		message := s.format(entry)
+		if stream {
+			message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+		}
+		if _, err := s.conn.Write(message); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// format returns the RFC 5424 message of the entry, without structured data.
+func (s *syslogSender) format(entry outputEntry) []byte {
+	header := fmt.Sprintf("<%d>1 %s %s %s %d - - ", s.priority, entry.time.Format(syslogTimestampFormat), s.hostname, s.appName, os.Getpid())
+	return append([]byte(header), bytes.TrimRight(entry.line, "\n")...)
+}
+
+func (s *syslogSender) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,220 @@
+package accesslog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogOutputUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	config := &types.AccessLog{
+		Format: JSONFormat,
+		Syslog: &types.AccessLogSyslog{Network: "udp", Address: conn.LocalAddr().String(), AppName: "proxy"},
+	}
+	doLogging(t, config)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buffer := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buffer)
+	require.NoError(t, err)
+
+	message := string(buffer[:n])
+	assert.True(t, strings.HasPrefix(message, "<134>1 "), message)
+	assert.Contains(t, message, " proxy ")
+	assert.Contains(t, message, `"RequestMethod":"POST"`)
+	assert.False(t, strings.HasSuffix(message, "\n"), message)
+}
+
+func TestSyslogOutputTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	config := &types.AccessLog{
+		Format: CommonFormat,
+		Syslog: &types.AccessLogSyslog{Network: "tcp", Address: listener.Addr().String(), Facility: "local7"},
+	}
+	doLogging(t, config)
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	reader := bufio.NewReader(conn)
+	length, err := reader.ReadString(' ')
+	require.NoError(t, err)
+	size, err := strconv.Atoi(strings.TrimSpace(length))
+	require.NoError(t, err)
+
+	message := make([]byte, size)
+	_, err = io.ReadFull(reader, message)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(message), "<190>1 "), string(message))
+	assert.Contains(t, string(message), `"POST testpath HTTP/0.0"`)
+}
+
+func TestKafkaRESTProxyOutput(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		requests <- req
+		bodies <- body
+	}))
+	defer server.Close()
+
+	config := &types.AccessLog{
+		Format:         JSONFormat,
+		KafkaRESTProxy: &types.AccessLogKafkaRESTProxy{URL: server.URL + "/", Topic: "access-logs"},
+	}
+	doLogging(t, config)
+
+	req := <-requests
+	assert.Equal(t, "/topics/access-logs", req.URL.Path)
+	assert.Equal(t, kafkaRESTProxyContentType, req.Header.Get("Content-Type"))
+
+	var records struct {
+		Records []struct {
+			Value map[string]interface{} `json:"value"`
+		} `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &records))
+	require.Len(t, records.Records, 1)
+	assert.Equal(t, testMethod, records.Records[0].Value[RequestMethod])
+}
+
+func TestNewOutput(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.AccessLog
+		expectedError bool
+	}{
+		{
+			desc:   "file",
+			config: &types.AccessLog{},
+		},
+		{
+			desc:   "syslog",
+			config: &types.AccessLog{Syslog: &types.AccessLogSyslog{Address: "127.0.0.1:514"}},
+		},
+		{
+			desc:          "syslog without address",
+			config:        &types.AccessLog{Syslog: &types.AccessLogSyslog{Network: "tcp"}},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown syslog facility",
+			config:        &types.AccessLog{Syslog: &types.AccessLogSyslog{Address: "127.0.0.1:514", Facility: "kernel"}},
+			expectedError: true,
+		},
+		{
+			desc:          "Kafka REST proxy without topic",
+			config:        &types.AccessLog{KafkaRESTProxy: &types.AccessLogKafkaRESTProxy{URL: "http://127.0.0.1:8082"}},
+			expectedError: true,
+		},
+		{
+			desc: "unknown overflow policy",
+			config: &types.AccessLog{KafkaRESTProxy: &types.AccessLogKafkaRESTProxy{
+				URL:    "http://127.0.0.1:8082",
+				Topic:  "access-logs",
+				Buffer: &types.AccessLogBuffer{OverflowPolicy: "retry"},
+			}},
+			expectedError: true,
+		},
+		{
+			desc: "syslog and Kafka REST proxy",
+			config: &types.AccessLog{
+				Syslog:         &types.AccessLogSyslog{Address: "127.0.0.1:514"},
+				KafkaRESTProxy: &types.AccessLogKafkaRESTProxy{URL: "http://127.0.0.1:8082", Topic: "access-logs"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := newOutput(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if output != nil {
+				output.Close()
+			}
+		})
+	}
+}
+
+func TestBufferedOutputDropsOnOverflow(t *testing.T) {
+	sender := &blockingSender{started: make(chan struct{}, 1), release: make(chan struct{}), batches: make(chan []outputEntry, 10)}
+	output, err := newBufferedOutput("test", sender, &types.AccessLogBuffer{
+		Size:          1,
+		BatchSize:     1,
+		FlushInterval: flaeg.Duration(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the first entry is being sent, the second one is buffered, and the next ones are dropped
+	output.Write([]byte("1\n"))
+	<-sender.started
+	for i := 2; i <= 5; i++ {
+		output.Write([]byte(strconv.Itoa(i) + "\n"))
+	}
+	close(sender.release)
+	require.NoError(t, output.Close())
+
+	var sent []string
+	for len(sender.batches) > 0 {
+		for _, entry := range <-sender.batches {
+			sent = append(sent, string(entry.line))
+		}
+	}
+	assert.Equal(t, []string{"1\n", "2\n"}, sent)
+
+	_, err = output.Write([]byte("6\n"))
+	assert.Error(t, err)
+}
+
+// blockingSender blocks the sending of the batches until released.
+type blockingSender struct {
+	started chan struct{}
+	release chan struct{}
+	batches chan []outputEntry
+}
+
+func (s *blockingSender) send(entries []outputEntry) error {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.release
+	s.batches <- entries
+	return nil
+}
+
+func (s *blockingSender) close() error {
+	return nil
+}
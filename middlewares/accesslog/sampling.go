@@ -0,0 +1,63 @@
+package accesslog
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+// Sampling logs a sample of the requests of a frontend, a rate of them or one in N.
+// The errors (status >= 500) and the requests slower than the threshold are always logged.
+type Sampling struct {
+	next          http.Handler
+	rate          float64
+	oneIn         uint64
+	slowThreshold time.Duration
+	count         uint64
+}
+
+// NewSampling creates a Sampling handler.
+func NewSampling(next http.Handler, config *types.AccessLogSampling) (*Sampling, error) {
+	switch {
+	case config.Rate != 0 && config.OneIn != 0:
+		return nil, errors.New("the access log sampling can't have both a rate and one in N")
+	case config.Rate < 0 || config.Rate > 1:
+		return nil, errors.New("the access log sampling rate must be between 0 and 1")
+	case config.OneIn < 0:
+		return nil, errors.New("the access log sampling one in N must be positive")
+	case config.Rate == 0 && config.OneIn == 0:
+		return nil, errors.New("the access log sampling requires a rate or one in N")
+	}
+
+	return &Sampling{
+		next:          next,
+		rate:          config.Rate,
+		oneIn:         uint64(config.OneIn),
+		slowThreshold: time.Duration(config.SlowThreshold),
+	}, nil
+}
+
+func (s *Sampling) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if table, ok := r.Context().Value(DataTableKey).(*LogData); ok {
+		table.sampling = s
+	}
+	s.next.ServeHTTP(rw, r)
+}
+
+// keep returns whether the entry of a request is logged, once its response is known.
+func (s *Sampling) keep(status int, duration time.Duration) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	if s.slowThreshold > 0 && duration >= s.slowThreshold {
+		return true
+	}
+	if s.oneIn > 0 {
+		return (atomic.AddUint64(&s.count, 1)-1)%s.oneIn == 0
+	}
+	return rand.Float64() < s.rate
+}
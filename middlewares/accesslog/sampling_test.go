@@ -0,0 +1,167 @@
+package accesslog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSampling(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.AccessLogSampling
+		expectedError bool
+	}{
+		{
+			desc:   "rate",
+			config: &types.AccessLogSampling{Rate: 0.1},
+		},
+		{
+			desc:   "one in N",
+			config: &types.AccessLogSampling{OneIn: 10},
+		},
+		{
+			desc:          "neither rate nor one in N",
+			config:        &types.AccessLogSampling{SlowThreshold: flaeg.Duration(time.Second)},
+			expectedError: true,
+		},
+		{
+			desc:          "rate and one in N",
+			config:        &types.AccessLogSampling{Rate: 0.1, OneIn: 10},
+			expectedError: true,
+		},
+		{
+			desc:          "rate above 1",
+			config:        &types.AccessLogSampling{Rate: 1.5},
+			expectedError: true,
+		},
+		{
+			desc:          "negative one in N",
+			config:        &types.AccessLogSampling{OneIn: -1},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSampling(http.NotFoundHandler(), test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSamplingKeep(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   *types.AccessLogSampling
+		status   int
+		duration time.Duration
+		expected []bool
+	}{
+		{
+			desc:     "one in N",
+			config:   &types.AccessLogSampling{OneIn: 3},
+			status:   http.StatusOK,
+			expected: []bool{true, false, false, true, false, false},
+		},
+		{
+			desc:     "errors always logged",
+			config:   &types.AccessLogSampling{OneIn: 3},
+			status:   http.StatusBadGateway,
+			expected: []bool{true, true, true},
+		},
+		{
+			desc:     "client errors sampled",
+			config:   &types.AccessLogSampling{OneIn: 2},
+			status:   http.StatusNotFound,
+			expected: []bool{true, false, true},
+		},
+		{
+			desc:     "slow requests always logged",
+			config:   &types.AccessLogSampling{OneIn: 3, SlowThreshold: flaeg.Duration(time.Second)},
+			status:   http.StatusOK,
+			duration: 2 * time.Second,
+			expected: []bool{true, true, true},
+		},
+		{
+			desc:     "fast requests sampled",
+			config:   &types.AccessLogSampling{OneIn: 3, SlowThreshold: flaeg.Duration(time.Second)},
+			status:   http.StatusOK,
+			duration: time.Millisecond,
+			expected: []bool{true, false, false},
+		},
+		{
+			desc:     "rate of 1",
+			config:   &types.AccessLogSampling{Rate: 1},
+			status:   http.StatusOK,
+			expected: []bool{true, true, true},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			sampling, err := NewSampling(http.NotFoundHandler(), test.config)
+			require.NoError(t, err)
+
+			var kept []bool
+			for range test.expected {
+				kept = append(kept, sampling.keep(test.status, test.duration))
+			}
+			assert.Equal(t, test.expected, kept)
+		})
+	}
+}
+
+func TestSamplingLogHandler(t *testing.T) {
+	tmpDir := createTempDir(t, "sampling")
+	defer os.RemoveAll(tmpDir)
+
+	logFilePath := filepath.Join(tmpDir, "access.log")
+	logger, err := NewLogHandler(&types.AccessLog{FilePath: logFilePath, Format: CommonFormat})
+	require.NoError(t, err)
+
+	status := http.StatusOK
+	sampling, err := NewSampling(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(status)
+	}), &types.AccessLogSampling{OneIn: 4})
+	require.NoError(t, err)
+
+	for i := 0; i < 8; i++ {
+		logger.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil), sampling.ServeHTTP)
+	}
+	status = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		logger.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/error", nil), sampling.ServeHTTP)
+	}
+	require.NoError(t, logger.Close())
+
+	logData, err := ioutil.ReadFile(logFilePath)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(logData)), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "/ok")
+	assert.Contains(t, lines[1], "/ok")
+	assert.Contains(t, lines[2], "/error")
+	assert.Contains(t, lines[3], "/error")
+}
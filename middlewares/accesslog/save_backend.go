@@ -13,13 +13,16 @@ import (
 // SaveBackend sends the backend name to the logger. These are always used with a corresponding
 // SaveFrontend handler.
 type SaveBackend struct {
-	next        http.Handler
-	backendName string
+	next            http.Handler
+	backendName     string
+	serversMetadata map[string]map[string]string
 }
 
 // NewSaveBackend creates a SaveBackend handler.
-func NewSaveBackend(next http.Handler, backendName string) http.Handler {
-	return &SaveBackend{next, backendName}
+// The metadata of the servers, indexed by the host of their URL, is sent to the logger
+// for the server selected to handle the request.
+func NewSaveBackend(next http.Handler, backendName string, serversMetadata map[string]map[string]string) http.Handler {
+	return &SaveBackend{next, backendName, serversMetadata}
 }
 
 func (sb *SaveBackend) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
@@ -27,6 +30,7 @@ func (sb *SaveBackend) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	table.Core[BackendName] = sb.backendName
 	table.Core[BackendURL] = r.URL // note that this is *not* the original incoming URL
 	table.Core[BackendAddr] = r.URL.Host
+	table.BackendMetadata = sb.serversMetadata[r.URL.Host]
 
 	crw := &captureResponseWriter{rw: rw}
 	start := time.Now().UTC()
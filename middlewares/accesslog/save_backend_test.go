@@ -0,0 +1,52 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveBackendMetadata(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		url              string
+		expectedMetadata map[string]string
+	}{
+		{
+			desc:             "server with metadata",
+			url:              "http://10.0.0.1:8080/some/path",
+			expectedMetadata: map[string]string{"ecsTaskArn": "arn:aws:ecs:us-east-1:123456789012:task/abc"},
+		},
+		{
+			desc: "server without metadata",
+			url:  "http://10.0.0.2:8080/some/path",
+		},
+	}
+
+	serversMetadata := map[string]map[string]string{
+		"10.0.0.1:8080": {"ecsTaskArn": "arn:aws:ecs:us-east-1:123456789012:task/abc"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			saveBackend := NewSaveBackend(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}), "backend1", serversMetadata)
+
+			logDataTable := &LogData{Core: make(CoreLogData)}
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			reqWithDataTable := req.WithContext(context.WithValue(req.Context(), DataTableKey, logDataTable))
+
+			saveBackend.ServeHTTP(httptest.NewRecorder(), reqWithDataTable)
+
+			assert.Equal(t, "backend1", logDataTable.Core[BackendName])
+			assert.Equal(t, test.expectedMetadata, logDataTable.BackendMetadata)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package accesslog
+
+import (
+	"net/http"
+
+	"github.com/containous/traefik/middlewares"
+)
+
+// SaveCacheStatus stores in the LogDataTable the upstream cache status found in the response headers.
+type SaveCacheStatus struct {
+	headers []string
+}
+
+// NewSaveCacheStatus creates a SaveCacheStatus handler looking up the given headers.
+func NewSaveCacheStatus(headers []string) *SaveCacheStatus {
+	return &SaveCacheStatus{headers: headers}
+}
+
+func (s *SaveCacheStatus) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(rw, r)
+
+	table := GetLogDataTable(r)
+	table.Core[CacheStatus] = middlewares.GetCacheStatus(rw.Header(), s.headers)
+}
@@ -0,0 +1,25 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveCacheStatus(t *testing.T) {
+	saveCacheStatus := NewSaveCacheStatus([]string{"X-Cache"})
+
+	logDataTable := &LogData{Core: make(CoreLogData)}
+	req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+	reqWithDataTable := req.WithContext(context.WithValue(req.Context(), DataTableKey, logDataTable))
+
+	saveCacheStatus.ServeHTTP(httptest.NewRecorder(), reqWithDataTable, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Cache", "Hit from cloudfront")
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	assert.Equal(t, "hit", logDataTable.Core[CacheStatus])
+}
@@ -5,17 +5,24 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 
 	goauth "github.com/abbot/go-http-auth"
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/filereload"
 	"github.com/containous/traefik/types"
 	"github.com/urfave/negroni"
 )
 
-// Authenticator is a middleware that provides HTTP basic and digest authentication
+// Authenticator is a middleware that provides HTTP basic and digest authentication.
+// The users file is reloaded when it changes.
 type Authenticator struct {
 	handler negroni.Handler
-	users   map[string]string
+
+	mu         sync.RWMutex
+	users      map[string]string
+	usersFile  *filereload.File
+	parseUsers func() (map[string]string, error)
 }
 
 // NewAuthenticator builds a new Authenticator given a config
@@ -26,7 +33,9 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 	var err error
 	authenticator := Authenticator{}
 	if authConfig.Basic != nil {
-		authenticator.users, err = parserBasicUsers(authConfig.Basic)
+		err = authenticator.loadUsers(authConfig.Basic.UsersFile, func() (map[string]string, error) {
+			return parserBasicUsers(authConfig.Basic)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -44,7 +53,9 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 			}
 		})
 	} else if authConfig.Digest != nil {
-		authenticator.users, err = parserDigestUsers(authConfig.Digest)
+		err = authenticator.loadUsers(authConfig.Digest.UsersFile, func() (map[string]string, error) {
+			return parserDigestUsers(authConfig.Digest)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -62,13 +73,41 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 			}
 		})
 	} else if authConfig.Forward != nil {
-		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-			Forward(authConfig.Forward, w, r, next)
-		})
+		authenticator.handler, err = newForwardAuth(authConfig.Forward)
+		if err != nil {
+			return nil, err
+		}
+	} else if authConfig.Authz != nil {
+		authenticator.handler, err = newAuthz(authConfig.Authz)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return &authenticator, nil
 }
 
+func (a *Authenticator) loadUsers(usersFile string, parseUsers func() (map[string]string, error)) error {
+	a.parseUsers = parseUsers
+	if usersFile == "" {
+		return a.reloadUsers()
+	}
+
+	a.usersFile = filereload.New(usersFile, "users file", a.reloadUsers)
+	return a.usersFile.Load()
+}
+
+func (a *Authenticator) reloadUsers() error {
+	users, err := a.parseUsers()
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.users = users
+	return nil
+}
+
 func getLinesFromFile(filename string) ([]string, error) {
 	dat, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -87,6 +126,8 @@ func getLinesFromFile(filename string) ([]string, error) {
 }
 
 func (a *Authenticator) secretBasic(user, realm string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if secret, ok := a.users[user]; ok {
 		return secret
 	}
@@ -95,6 +136,8 @@ func (a *Authenticator) secretBasic(user, realm string) string {
 }
 
 func (a *Authenticator) secretDigest(user, realm string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if secret, ok := a.users[user+":"+realm]; ok {
 		return secret
 	}
@@ -103,5 +146,8 @@ func (a *Authenticator) secretDigest(user, realm string) string {
 }
 
 func (a *Authenticator) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if a.usersFile != nil {
+		a.usersFile.ReloadIfChanged()
+	}
 	a.handler.ServeHTTP(rw, r, next)
 }
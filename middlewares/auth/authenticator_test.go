@@ -7,10 +7,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
 )
 
@@ -186,3 +188,46 @@ func TestBasicAuthUserHeader(t *testing.T) {
 	assert.NoError(t, err, "there should be no error")
 	assert.Equal(t, "traefik\n", string(body), "they should be equal")
 }
+
+func TestBasicAuthUsersFileReload(t *testing.T) {
+	usersFile, err := ioutil.TempFile("", "auth-users")
+	require.NoError(t, err)
+	defer os.Remove(usersFile.Name())
+	_, err = usersFile.Write([]byte("test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/\n"))
+	require.NoError(t, err)
+	require.NoError(t, usersFile.Close())
+
+	authenticator, err := NewAuthenticator(&types.Auth{
+		Basic: &types.Basic{
+			UsersFile: usersFile.Name(),
+		},
+	})
+	require.NoError(t, err)
+
+	statusCode := func(user, password string) int {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+		req.SetBasicAuth(user, password)
+		recorder := httptest.NewRecorder()
+		authenticator.ServeHTTP(recorder, req, func(w http.ResponseWriter, r *http.Request) {})
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, statusCode("test", "test"))
+	assert.Equal(t, http.StatusUnauthorized, statusCode("bcrypt", "test"))
+
+	// bcrypt hash of "test"
+	err = ioutil.WriteFile(usersFile.Name(), []byte("bcrypt:$2a$05$0R6PdgK43So6acBUUJy2yOlnMl8qyHJgZae3j3ycfJWn/WsmHIA3O\n"), 0644)
+	require.NoError(t, err)
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(usersFile.Name(), modTime, modTime))
+	authenticator.usersFile.Expire()
+	assert.Equal(t, http.StatusUnauthorized, statusCode("test", "test"))
+	assert.Equal(t, http.StatusOK, statusCode("bcrypt", "test"))
+
+	// an invalid file keeps the previous users
+	err = ioutil.WriteFile(usersFile.Name(), []byte("invalid\n"), 0644)
+	require.NoError(t, err)
+	modTime = modTime.Add(time.Second)
+	require.NoError(t, os.Chtimes(usersFile.Name(), modTime, modTime))
+	authenticator.usersFile.Expire()
+	assert.Equal(t, http.StatusOK, statusCode("bcrypt", "test"))
+}
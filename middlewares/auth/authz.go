@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultAuthzTimeout  = 5 * time.Second
+	maxAuthzResponseSize = 1 << 20
+)
+
+// authzInput describes a request to the policy server
+type authzInput struct {
+	Method     string            `json:"method"`
+	Scheme     string            `json:"scheme"`
+	Host       string            `json:"host"`
+	Path       string            `json:"path"`
+	Query      string            `json:"query"`
+	RemoteAddr string            `json:"remoteAddr"`
+	Headers    map[string]string `json:"headers"`
+}
+
+// authzDecision is the decision of the policy server on a request
+type authzDecision struct {
+	Allow                bool              `json:"allow"`
+	Status               int               `json:"status"`
+	Body                 string            `json:"body"`
+	Headers              map[string]string `json:"headers"`
+	RequestHeaders       map[string]string `json:"requestHeaders"`
+	RemoveRequestHeaders []string          `json:"removeRequestHeaders"`
+	ResponseHeaders      map[string]string `json:"responseHeaders"`
+}
+
+// authz asks a policy server, e.g. Open Policy Agent, to allow or deny the requests,
+// and applies the headers of its decision to the requests and responses.
+type authz struct {
+	config         *types.Authz
+	httpClient     http.Client
+	failOpen       bool
+	includeHeaders map[string]bool
+}
+
+func newAuthz(config *types.Authz) (*authz, error) {
+	if len(config.Address) == 0 {
+		return nil, fmt.Errorf("missing policy server address")
+	}
+
+	a := &authz{
+		config: config,
+		httpClient: http.Client{
+			Timeout: time.Duration(config.Timeout),
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	if a.httpClient.Timeout <= 0 {
+		a.httpClient.Timeout = defaultAuthzTimeout
+	}
+
+	switch strings.ToLower(config.FailurePolicy) {
+	case "", forwardFailureClosed:
+	case forwardFailureOpen:
+		a.failOpen = true
+	default:
+		return nil, fmt.Errorf("unknown authorization failure policy %q", config.FailurePolicy)
+	}
+
+	if len(config.IncludeHeaders) > 0 {
+		a.includeHeaders = make(map[string]bool)
+		for _, name := range config.IncludeHeaders {
+			a.includeHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("impossible to configure TLS to call %s: %v", config.Address, err)
+		}
+		a.httpClient.Transport = &http.Transport{
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	return a, nil
+}
+
+func (a *authz) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	decision, err := a.call(r)
+	if err != nil {
+		log.Debug(err)
+		if a.failOpen {
+			log.Warnf("Policy server %s unavailable, allowing the request to %s", a.config.Address, r.URL.Path)
+			next(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !decision.Allow {
+		log.Debugf("Request to %s denied by the policy server %s", r.URL.Path, a.config.Address)
+		for name, value := range decision.Headers {
+			w.Header().Set(name, value)
+		}
+		statusCode := decision.Status
+		if statusCode == 0 {
+			statusCode = http.StatusForbidden
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(decision.Body)))
+		w.WriteHeader(statusCode)
+		io.WriteString(w, decision.Body)
+		return
+	}
+
+	for _, name := range decision.RemoveRequestHeaders {
+		r.Header.Del(name)
+	}
+	for name, value := range decision.RequestHeaders {
+		r.Header.Set(name, value)
+	}
+	for name, value := range decision.ResponseHeaders {
+		w.Header().Set(name, value)
+	}
+
+	r.RequestURI = r.URL.RequestURI()
+	next(w, r)
+}
+
+// call sends the description of the request to the policy server, and returns its decision.
+func (a *authz) call(r *http.Request) (*authzDecision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": a.input(r)})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.httpClient.Post(a.config.Address, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s. Cause: %s", a.config.Address, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error calling %s. Status: %s", a.config.Address, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxAuthzResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading body %s. Cause: %s", a.config.Address, err)
+	}
+
+	return parseAuthzDecision(data)
+}
+
+func (a *authz) input(r *http.Request) authzInput {
+	input := authzInput{
+		Method:  r.Method,
+		Scheme:  "http",
+		Host:    r.Host,
+		Path:    r.URL.Path,
+		Query:   r.URL.RawQuery,
+		Headers: make(map[string]string),
+	}
+	if r.TLS != nil {
+		input.Scheme = "https"
+	}
+	if clientIP, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		input.RemoteAddr = clientIP
+	}
+
+	for name, values := range r.Header {
+		if a.includeHeaders != nil && !a.includeHeaders[name] {
+			continue
+		}
+		input.Headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+	return input
+}
+
+// parseAuthzDecision reads a decision, optionally wrapped in the result of an Open Policy Agent response.
+// The decision is either a boolean or an object.
+func parseAuthzDecision(data []byte) (*authzDecision, error) {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapper); err == nil {
+		if result, ok := wrapper["result"]; ok {
+			data = result
+		}
+	}
+
+	var allow bool
+	if err := json.Unmarshal(data, &allow); err == nil {
+		return &authzDecision{Allow: allow}, nil
+	}
+
+	decision := &authzDecision{}
+	if err := json.Unmarshal(data, decision); err != nil {
+		return nil, fmt.Errorf("invalid decision of the policy server: %v", err)
+	}
+	if decision.Status != 0 && (decision.Status < 100 || decision.Status > 599) {
+		return nil, fmt.Errorf("invalid status %d in the decision of the policy server", decision.Status)
+	}
+	return decision, nil
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestNewAuthz(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.Authz
+		expectedError bool
+	}{
+		{
+			desc:   "valid configuration",
+			config: &types.Authz{Address: "http://opa:8181/v1/data/traefik/authz", FailurePolicy: "open"},
+		},
+		{
+			desc:          "missing address",
+			config:        &types.Authz{},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown failure policy",
+			config:        &types.Authz{Address: "http://opa:8181", FailurePolicy: "maybe"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewAuthenticator(&types.Auth{Authz: test.config})
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthz(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		policyStatus           int
+		decision               string
+		failurePolicy          string
+		expectedStatusCode     int
+		expectedBody           string
+		expectedHeaders        map[string]string
+		expectedBackendHeaders map[string]string
+	}{
+		{
+			desc:               "allowed by a boolean",
+			decision:           `{"result": true}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:               "denied by a boolean",
+			decision:           `{"result": false}`,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "undefined decision",
+			decision:           `{}`,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "allowed with headers",
+			decision:           `{"result": {"allow": true, "requestHeaders": {"X-User": "alice"}, "removeRequestHeaders": ["Authorization"], "responseHeaders": {"X-Policy": "v1"}}}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+			expectedHeaders:    map[string]string{"X-Policy": "v1"},
+			expectedBackendHeaders: map[string]string{
+				"X-User":        "alice",
+				"Authorization": "",
+			},
+		},
+		{
+			desc:               "denied with a response",
+			decision:           `{"allow": false, "status": 401, "body": "login required", "headers": {"WWW-Authenticate": "Bearer"}}`,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "login required",
+			expectedHeaders:    map[string]string{"WWW-Authenticate": "Bearer"},
+		},
+		{
+			desc:               "policy server error",
+			policyStatus:       http.StatusInternalServerError,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			desc:               "invalid decision",
+			decision:           `"yes"`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			desc:               "invalid status of a denied request",
+			decision:           `{"allow": false, "status": 1000}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			desc:               "policy server error with the open policy",
+			policyStatus:       http.StatusInternalServerError,
+			failurePolicy:      "open",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var input map[string]authzInput
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&input)
+				if test.policyStatus != 0 {
+					w.WriteHeader(test.policyStatus)
+					return
+				}
+				fmt.Fprint(w, test.decision)
+			}))
+			defer server.Close()
+
+			middleware, err := NewAuthenticator(&types.Auth{
+				Authz: &types.Authz{
+					Address:        server.URL,
+					IncludeHeaders: []string{"Authorization", "x-foo"},
+					FailurePolicy:  test.failurePolicy,
+				},
+			})
+			require.NoError(t, err)
+
+			backendHeaders := make(http.Header)
+			n := negroni.New(middleware)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				backendHeaders = r.Header
+				fmt.Fprint(w, "backend")
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "http://foo.bar/path?query=1", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("X-Foo", "bar")
+			req.Header.Set("X-Ignored", "baz")
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+			for name, value := range test.expectedBackendHeaders {
+				assert.Equal(t, value, backendHeaders.Get(name), name)
+			}
+
+			expectedInput := authzInput{
+				Method:     http.MethodPost,
+				Scheme:     "http",
+				Host:       "foo.bar",
+				Path:       "/path",
+				Query:      "query=1",
+				RemoteAddr: "10.0.0.1",
+				Headers:    map[string]string{"authorization": "Bearer token", "x-foo": "bar"},
+			}
+			assert.Equal(t, expectedInput, input["input"])
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -12,78 +13,163 @@ import (
 	"github.com/vulcand/oxy/utils"
 )
 
-// Forward the authentication to a external server
-func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+const (
+	forwardFailureClosed = "closed"
+	forwardFailureOpen   = "open"
+)
+
+// authResponse holds the parts of a response of the authentication server passed to the client
+type authResponse struct {
+	statusCode int
+	location   string
+	cookies    []string
+	body       []byte
+}
 
-	// Ensure our request client does not follow redirects
-	httpClient := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
+// forwardAuth forwards the authentication to an external server,
+// caching its responses and stopping to call it when it fails repeatedly.
+type forwardAuth struct {
+	config     *types.Forward
+	httpClient http.Client
+	failOpen   bool
+	cache      *forwardCache
+	breaker    *forwardBreaker
+}
+
+func newForwardAuth(config *types.Forward) (*forwardAuth, error) {
+	f := &forwardAuth{
+		config: config,
+		// Ensure our request client does not follow redirects
+		httpClient: http.Client{
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
 		},
+		cache:   newForwardCache(config.Cache),
+		breaker: newForwardBreaker(config.Address, config.CircuitBreaker),
+	}
+
+	switch strings.ToLower(config.FailurePolicy) {
+	case "", forwardFailureClosed:
+	case forwardFailureOpen:
+		f.failOpen = true
+	default:
+		return nil, fmt.Errorf("unknown forward authentication failure policy %q", config.FailurePolicy)
 	}
 
 	if config.TLS != nil {
 		tlsConfig, err := config.TLS.CreateTLSConfig()
 		if err != nil {
-			log.Debugf("Impossible to configure TLS to call %s. Cause %s", config.Address, err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("impossible to configure TLS to call %s: %v", config.Address, err)
 		}
-		httpClient.Transport = &http.Transport{
+		f.httpClient.Transport = &http.Transport{
 			TLSClientConfig: tlsConfig,
 		}
 	}
 
-	forwardReq, err := http.NewRequest(http.MethodGet, config.Address, nil)
+	return f, nil
+}
+
+func (f *forwardAuth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key, cacheable := f.cache.key(r)
+	if cacheable {
+		if response := f.cache.get(key); response != nil {
+			f.respond(w, r, next, response)
+			return
+		}
+	}
+
+	if !f.breaker.allow() {
+		log.Debugf("Circuit open toward %s", f.config.Address)
+		f.fail(w, r, next, http.StatusServiceUnavailable)
+		return
+	}
+
+	response, err := f.call(r)
+	f.breaker.record(err == nil && response.statusCode < http.StatusInternalServerError)
 	if err != nil {
-		log.Debugf("Error calling %s. Cause %s", config.Address, err)
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Debug(err)
+		f.fail(w, r, next, http.StatusInternalServerError)
 		return
 	}
+	if response.statusCode >= http.StatusInternalServerError {
+		if f.failOpen {
+			f.fail(w, r, next, response.statusCode)
+			return
+		}
+	} else if cacheable {
+		f.cache.set(key, response)
+	}
+
+	f.respond(w, r, next, response)
+}
+
+// call forwards the request to the authentication server.
+func (f *forwardAuth) call(r *http.Request) (*authResponse, error) {
+	forwardReq, err := http.NewRequest(http.MethodGet, f.config.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s. Cause %s", f.config.Address, err)
+	}
 
-	writeHeader(r, forwardReq, config.TrustForwardHeader)
+	writeHeader(r, forwardReq, f.config.TrustForwardHeader)
 
-	forwardResponse, forwardErr := httpClient.Do(forwardReq)
+	forwardResponse, forwardErr := f.httpClient.Do(forwardReq)
 	if forwardErr != nil {
-		log.Debugf("Error calling %s. Cause: %s", config.Address, forwardErr)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("error calling %s. Cause: %s", f.config.Address, forwardErr)
 	}
+	defer forwardResponse.Body.Close()
 
 	body, readError := ioutil.ReadAll(forwardResponse.Body)
 	if readError != nil {
-		log.Debugf("Error reading body %s. Cause: %s", config.Address, readError)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("error reading body %s. Cause: %s", f.config.Address, readError)
+	}
+
+	response := &authResponse{
+		statusCode: forwardResponse.StatusCode,
+		body:       body,
 	}
-	defer forwardResponse.Body.Close()
 
-	// Pass the forward response's body and selected headers if it
+	// Keep the response's body and selected headers if it
 	// didn't return a response within the range of [200, 300).
 	if forwardResponse.StatusCode < http.StatusOK || forwardResponse.StatusCode >= http.StatusMultipleChoices {
-		log.Debugf("Remote error %s. StatusCode: %d", config.Address, forwardResponse.StatusCode)
+		log.Debugf("Remote error %s. StatusCode: %d", f.config.Address, forwardResponse.StatusCode)
 
 		// Grab the location header, if any.
 		redirectURL, err := forwardResponse.Location()
 
 		if err != nil {
 			if err != http.ErrNoLocation {
-				log.Debugf("Error reading response location header %s. Cause: %s", config.Address, err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+				return nil, fmt.Errorf("error reading response location header %s. Cause: %s", f.config.Address, err)
 			}
 		} else if redirectURL.String() != "" {
+			response.location = redirectURL.String()
+		}
+
+		// Keep any Set-Cookie headers the forward auth server provides
+		for _, cookie := range forwardResponse.Cookies() {
+			response.cookies = append(response.cookies, cookie.String())
+		}
+	}
+
+	return response, nil
+}
+
+// respond passes the request to the next handler if the authentication succeeded,
+// or the response of the authentication server otherwise.
+func (f *forwardAuth) respond(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, response *authResponse) {
+	if response.statusCode < http.StatusOK || response.statusCode >= http.StatusMultipleChoices {
+		if response.location != "" {
 			// Set the location in our response if one was sent back.
-			w.Header().Add("Location", redirectURL.String())
+			w.Header().Add("Location", response.location)
 		}
 
 		// Pass any Set-Cookie headers the forward auth server provides
-		for _, cookie := range forwardResponse.Cookies() {
-			w.Header().Add("Set-Cookie", cookie.String())
+		for _, cookie := range response.cookies {
+			w.Header().Add("Set-Cookie", cookie)
 		}
 
-		w.WriteHeader(forwardResponse.StatusCode)
-		w.Write(body)
+		w.WriteHeader(response.statusCode)
+		w.Write(response.body)
 		return
 	}
 
@@ -91,6 +177,17 @@ func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next
 	next(w, r)
 }
 
+// fail allows the request when the failure policy is open, or denies it with the status code otherwise.
+func (f *forwardAuth) fail(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, statusCode int) {
+	if f.failOpen {
+		log.Warnf("Authentication server %s unavailable, allowing the request to %s", f.config.Address, r.URL.Path)
+		r.RequestURI = r.URL.RequestURI()
+		next(w, r)
+		return
+	}
+	w.WriteHeader(statusCode)
+}
+
 func writeHeader(req *http.Request, forwardReq *http.Request, trustForwardHeader bool) {
 	utils.CopyHeaders(forwardReq.Header, req.Header)
 
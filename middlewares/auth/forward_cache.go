@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultForwardCacheTTL        = 30 * time.Second
+	defaultForwardCacheMaxEntries = 10000
+	defaultForwardBreakerFailures = 5
+	defaultForwardBreakerDuration = 10 * time.Second
+)
+
+var defaultForwardCacheHeaders = []string{"Authorization", "Cookie"}
+
+type forwardCacheEntry struct {
+	response *authResponse
+	expiry   time.Time
+}
+
+// forwardCache holds the responses of the authentication server, per credentials and host.
+type forwardCache struct {
+	ttl        time.Duration
+	headers    []string
+	cookies    []string
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[string]forwardCacheEntry
+}
+
+// newForwardCache returns nil when the responses are not cached.
+func newForwardCache(config *types.ForwardCache) *forwardCache {
+	if config == nil {
+		return nil
+	}
+
+	c := &forwardCache{
+		ttl:        time.Duration(config.TTL),
+		headers:    config.Headers,
+		cookies:    config.Cookies,
+		maxEntries: config.MaxEntries,
+		entries:    make(map[string]forwardCacheEntry),
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultForwardCacheTTL
+	}
+	if len(c.headers) == 0 && len(c.cookies) == 0 {
+		c.headers = defaultForwardCacheHeaders
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultForwardCacheMaxEntries
+	}
+	return c
+}
+
+// key derives the cache key from the credentials of the request.
+// The requests without credentials are not cached.
+func (c *forwardCache) key(r *http.Request) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	var credentials bool
+	hash := sha256.New()
+	hash.Write([]byte(r.Host))
+	for _, header := range c.headers {
+		value := r.Header.Get(header)
+		credentials = credentials || len(value) > 0
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+	for _, name := range c.cookies {
+		var value string
+		if cookie, err := r.Cookie(name); err == nil {
+			value = cookie.Value
+		}
+		credentials = credentials || len(value) > 0
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), credentials
+}
+
+func (c *forwardCache) get(key string) *authResponse {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiry) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.response
+}
+
+func (c *forwardCache) set(key string, response *authResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiry) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	// Still full: evict arbitrary entries
+	for k := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+
+	c.entries[key] = forwardCacheEntry{response: response, expiry: now.Add(c.ttl)}
+}
+
+// forwardBreaker opens the circuit toward the authentication server after consecutive failures,
+// then lets a single request probe the server once the circuit has been open long enough.
+type forwardBreaker struct {
+	address      string
+	failures     int
+	openDuration time.Duration
+
+	lock        sync.Mutex
+	consecutive int
+	openUntil   time.Time
+	probing     bool
+}
+
+// newForwardBreaker returns nil when there is no circuit breaking.
+func newForwardBreaker(address string, config *types.ForwardCircuitBreaker) *forwardBreaker {
+	if config == nil {
+		return nil
+	}
+
+	b := &forwardBreaker{
+		address:      address,
+		failures:     config.Failures,
+		openDuration: time.Duration(config.OpenDuration),
+	}
+	if b.failures <= 0 {
+		b.failures = defaultForwardBreakerFailures
+	}
+	if b.openDuration <= 0 {
+		b.openDuration = defaultForwardBreakerDuration
+	}
+	return b
+}
+
+// allow returns whether the authentication server can be called.
+func (b *forwardBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.consecutive < b.failures {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record records the outcome of a call to the authentication server.
+func (b *forwardBreaker) record(success bool) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.probing = false
+	if success {
+		if b.consecutive >= b.failures {
+			log.Infof("Circuit toward the authentication server %s closed", b.address)
+		}
+		b.consecutive = 0
+		return
+	}
+
+	b.consecutive++
+	if b.consecutive >= b.failures {
+		if b.consecutive == b.failures {
+			log.Warnf("Circuit toward the authentication server %s opened after %d consecutive failures", b.address, b.failures)
+		}
+		b.openUntil = time.Now().Add(b.openDuration)
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
 )
 
@@ -239,3 +244,255 @@ func Test_writeHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestForwardAuthCache(t *testing.T) {
+	var calls int32
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") == "Bearer good" || strings.Contains(r.Header.Get("Cookie"), "session=good") {
+			fmt.Fprintln(w, "Success")
+			return
+		}
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	}))
+	defer authTs.Close()
+
+	testCases := []struct {
+		desc          string
+		cache         *types.ForwardCache
+		host          string
+		header        string
+		cookie        string
+		expectedCode  int
+		expectedCalls int32
+	}{
+		{
+			desc:          "allowed credentials",
+			cache:         &types.ForwardCache{},
+			header:        "Bearer good",
+			expectedCode:  http.StatusOK,
+			expectedCalls: 1,
+		},
+		{
+			desc:          "denied credentials",
+			cache:         &types.ForwardCache{},
+			header:        "Bearer bad",
+			expectedCode:  http.StatusForbidden,
+			expectedCalls: 1,
+		},
+		{
+			desc:          "no credentials",
+			cache:         &types.ForwardCache{},
+			expectedCode:  http.StatusForbidden,
+			expectedCalls: 3,
+		},
+		{
+			desc:          "credentials in a cookie",
+			cache:         &types.ForwardCache{Cookies: []string{"session"}},
+			cookie:        "good",
+			expectedCode:  http.StatusOK,
+			expectedCalls: 1,
+		},
+		{
+			desc:          "credentials outside of the cache key",
+			cache:         &types.ForwardCache{Cookies: []string{"session"}},
+			header:        "Bearer good",
+			expectedCode:  http.StatusOK,
+			expectedCalls: 3,
+		},
+		{
+			desc:          "expired responses",
+			cache:         &types.ForwardCache{TTL: flaeg.Duration(time.Nanosecond)},
+			header:        "Bearer good",
+			expectedCode:  http.StatusOK,
+			expectedCalls: 3,
+		},
+		{
+			desc:          "no cache",
+			header:        "Bearer good",
+			expectedCode:  http.StatusOK,
+			expectedCalls: 3,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+
+			middleware, err := NewAuthenticator(&types.Auth{
+				Forward: &types.Forward{
+					Address: authTs.URL,
+					Cache:   test.cache,
+				},
+			})
+			require.NoError(t, err)
+
+			n := negroni.New(middleware)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, "traefik")
+			}))
+
+			for i := 0; i < 3; i++ {
+				req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+				if len(test.header) > 0 {
+					req.Header.Set("Authorization", test.header)
+				}
+				if len(test.cookie) > 0 {
+					req.AddCookie(&http.Cookie{Name: "session", Value: test.cookie})
+				}
+				if i > 0 {
+					time.Sleep(time.Millisecond)
+				}
+
+				recorder := httptest.NewRecorder()
+				n.ServeHTTP(recorder, req)
+				assert.Equal(t, test.expectedCode, recorder.Code)
+			}
+			assert.Equal(t, test.expectedCalls, atomic.LoadInt32(&calls))
+
+			// The cache key includes the host
+			if test.cache != nil && test.expectedCalls == 1 {
+				req := testhelpers.MustNewRequest(http.MethodGet, "http://other.bar", nil)
+				req.Header.Set("Authorization", test.header)
+				req.AddCookie(&http.Cookie{Name: "session", Value: test.cookie})
+				n.ServeHTTP(httptest.NewRecorder(), req)
+				assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+			}
+		})
+	}
+}
+
+func TestForwardAuthFailurePolicy(t *testing.T) {
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unavailable", http.StatusBadGateway)
+	}))
+	defer authTs.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	testCases := []struct {
+		desc          string
+		address       string
+		failurePolicy string
+		expectedCode  int
+	}{
+		{
+			desc:         "server error with the default policy",
+			address:      authTs.URL,
+			expectedCode: http.StatusBadGateway,
+		},
+		{
+			desc:          "server error with the closed policy",
+			address:       authTs.URL,
+			failurePolicy: "closed",
+			expectedCode:  http.StatusBadGateway,
+		},
+		{
+			desc:          "server error with the open policy",
+			address:       authTs.URL,
+			failurePolicy: "open",
+			expectedCode:  http.StatusOK,
+		},
+		{
+			desc:          "unreachable server with the closed policy",
+			address:       unreachable.URL,
+			failurePolicy: "closed",
+			expectedCode:  http.StatusInternalServerError,
+		},
+		{
+			desc:          "unreachable server with the open policy",
+			address:       unreachable.URL,
+			failurePolicy: "Open",
+			expectedCode:  http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			middleware, err := NewAuthenticator(&types.Auth{
+				Forward: &types.Forward{
+					Address:       test.address,
+					FailurePolicy: test.failurePolicy,
+				},
+			})
+			require.NoError(t, err)
+
+			n := negroni.New(middleware)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, "traefik")
+			}))
+
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil))
+			assert.Equal(t, test.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestForwardAuthUnknownFailurePolicy(t *testing.T) {
+	_, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address:       "http://auth.bar",
+			FailurePolicy: "maybe",
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestForwardAuthCircuitBreaker(t *testing.T) {
+	var calls int32
+	var healthy int32
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			http.Error(w, "Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "Success")
+	}))
+	defer authTs.Close()
+
+	middleware, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address: authTs.URL,
+			CircuitBreaker: &types.ForwardCircuitBreaker{
+				Failures:     2,
+				OpenDuration: flaeg.Duration(100 * time.Millisecond),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	n := negroni.New(middleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "traefik")
+	}))
+
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil))
+		return recorder.Code
+	}
+
+	// The circuit opens after two failures
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// A failed probe opens the circuit again
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, http.StatusServiceUnavailable, serve())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// A successful probe closes the circuit
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+}
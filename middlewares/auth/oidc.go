@@ -0,0 +1,594 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	defaultOIDCCallbackPath  = "/oauth2/callback"
+	defaultOIDCSessionCookie = "_traefik_oidc"
+	defaultOIDCGroupsClaim   = "groups"
+	oidcStateLifetime        = 10 * time.Minute
+	oidcKeysRefreshInterval  = time.Minute
+)
+
+var defaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// oidcProviderMetadata holds the endpoints discovered from the OpenID Connect provider
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcState is stored in a cookie between the redirection to the provider and the callback
+type oidcState struct {
+	State       string `json:"state"`
+	Nonce       string `json:"nonce"`
+	RedirectURI string `json:"redirectURI"`
+	Expiry      int64  `json:"expiry"`
+}
+
+// oidcSession is stored in the session cookie once the user is authenticated
+type oidcSession struct {
+	Claims map[string]interface{} `json:"claims"`
+	Expiry int64                  `json:"expiry"`
+}
+
+// OIDC is a middleware authenticating the users of a frontend with an OpenID Connect provider.
+// The claims of the ID token are kept in an encrypted session cookie,
+// checked against the allowed groups and required claims, and forwarded to the backend as headers.
+type OIDC struct {
+	config        types.OIDC
+	issuer        string
+	scopes        []string
+	callbackPath  string
+	redirectURL   string
+	sessionCookie string
+	groupsClaim   string
+	headers       map[string]string
+	aead          cipher.AEAD
+	client        *http.Client
+
+	lock        sync.Mutex
+	metadata    *oidcProviderMetadata
+	keys        *jose.JsonWebKeySet
+	keysFetched time.Time
+}
+
+// NewOIDC builds a new OIDC middleware from the configuration of a frontend.
+// The provider is discovered on the first request, so an unreachable provider doesn't prevent the frontend to be created.
+func NewOIDC(config *types.OIDC) (*OIDC, error) {
+	if config == nil {
+		return nil, errors.New("no OpenID Connect configuration")
+	}
+	if len(config.Issuer) == 0 {
+		return nil, errors.New("the issuer is required")
+	}
+	if len(config.ClientID) == 0 {
+		return nil, errors.New("the client ID is required")
+	}
+	if len(config.SessionSecret) == 0 {
+		return nil, errors.New("the session secret is required")
+	}
+
+	o := &OIDC{
+		config:        *config,
+		issuer:        strings.TrimSuffix(config.Issuer, "/"),
+		scopes:        config.Scopes,
+		callbackPath:  config.CallbackPath,
+		sessionCookie: config.SessionCookie,
+		groupsClaim:   config.GroupsClaim,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	if len(o.scopes) == 0 {
+		o.scopes = defaultOIDCScopes
+	} else if !containsValue(o.scopes, "openid") {
+		o.scopes = append([]string{"openid"}, o.scopes...)
+	}
+	if len(config.RedirectURL) > 0 {
+		redirectURL, err := url.Parse(config.RedirectURL)
+		if err != nil || (redirectURL.Scheme != "http" && redirectURL.Scheme != "https") || len(redirectURL.Host) == 0 {
+			return nil, fmt.Errorf("the redirect URL %q must be an absolute HTTP or HTTPS URL", config.RedirectURL)
+		}
+		if len(o.callbackPath) == 0 {
+			o.callbackPath = redirectURL.Path
+		} else if o.callbackPath != redirectURL.Path {
+			return nil, fmt.Errorf("the path of the redirect URL %q must be the callback path %q", config.RedirectURL, o.callbackPath)
+		}
+		o.redirectURL = config.RedirectURL
+	}
+	if len(o.callbackPath) == 0 {
+		o.callbackPath = defaultOIDCCallbackPath
+	}
+	if !strings.HasPrefix(o.callbackPath, "/") {
+		return nil, fmt.Errorf("the callback path %q must start with /", o.callbackPath)
+	}
+	if len(config.LogoutPath) > 0 && !strings.HasPrefix(config.LogoutPath, "/") {
+		return nil, fmt.Errorf("the logout path %q must start with /", config.LogoutPath)
+	}
+	if len(o.sessionCookie) == 0 {
+		o.sessionCookie = defaultOIDCSessionCookie
+	}
+	if len(o.groupsClaim) == 0 {
+		o.groupsClaim = defaultOIDCGroupsClaim
+	}
+
+	o.headers = map[string]string{
+		"X-Forwarded-User":   "sub",
+		"X-Forwarded-Email":  "email",
+		"X-Forwarded-Groups": o.groupsClaim,
+	}
+	for header, claim := range config.Headers {
+		o.headers[http.CanonicalHeaderKey(header)] = claim
+	}
+
+	key := sha256.Sum256([]byte(config.SessionSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	o.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+func (o *OIDC) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	// The identity headers only come from the session, never from the client
+	for header := range o.headers {
+		r.Header.Del(header)
+	}
+
+	switch {
+	case r.URL.Path == o.callbackPath:
+		o.callback(rw, r)
+		return
+	case len(o.config.LogoutPath) > 0 && r.URL.Path == o.config.LogoutPath:
+		http.SetCookie(rw, o.expiredCookie(o.sessionCookie, r))
+		http.Redirect(rw, r, "/", http.StatusFound)
+		return
+	}
+
+	session, err := o.readSession(r)
+	if err != nil {
+		log.Debugf("Invalid OpenID Connect session: %v", err)
+	}
+	if session == nil {
+		o.authenticate(rw, r)
+		return
+	}
+
+	if !o.authorized(session.Claims) {
+		log.Debugf("OpenID Connect user %v is not allowed to access %s", session.Claims["sub"], r.URL.Path)
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	for header, claim := range o.headers {
+		if values := claimValues(session.Claims[claim]); len(values) > 0 {
+			r.Header.Set(header, strings.Join(values, ","))
+		}
+	}
+	next.ServeHTTP(rw, r)
+}
+
+// authenticate redirects the user to the provider, or rejects the requests which can't follow a redirection.
+func (o *OIDC) authenticate(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	metadata, err := o.getMetadata()
+	if err != nil {
+		log.Errorf("Unable to discover the OpenID Connect provider %s: %v", o.issuer, err)
+		http.Error(rw, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	state := oidcState{
+		State:       randomString(),
+		Nonce:       randomString(),
+		RedirectURI: r.URL.RequestURI(),
+		Expiry:      time.Now().Add(oidcStateLifetime).Unix(),
+	}
+	value, err := o.seal(o.stateCookie(), state)
+	if err != nil {
+		log.Errorf("Unable to create the OpenID Connect state: %v", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(rw, o.cookie(o.stateCookie(), value, time.Unix(state.Expiry, 0), r))
+
+	authURL := o.oauth2Config(metadata, r).AuthCodeURL(state.State, oauth2.SetAuthURLParam("nonce", state.Nonce))
+	http.Redirect(rw, r, authURL, http.StatusFound)
+}
+
+// callback exchanges the authorization code returned by the provider, and opens the session.
+func (o *OIDC) callback(rw http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(o.stateCookie())
+	if err != nil {
+		http.Error(rw, "Missing OpenID Connect state", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(rw, o.expiredCookie(o.stateCookie(), r))
+
+	state := oidcState{}
+	if err = o.open(o.stateCookie(), cookie.Value, &state); err != nil || time.Now().Unix() > state.Expiry {
+		http.Error(rw, "Invalid OpenID Connect state", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("state") != state.State {
+		http.Error(rw, "Invalid OpenID Connect state", http.StatusBadRequest)
+		return
+	}
+	if providerErr := r.URL.Query().Get("error"); len(providerErr) > 0 {
+		log.Debugf("OpenID Connect provider %s refused the authentication: %s", o.issuer, providerErr)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	metadata, err := o.getMetadata()
+	if err != nil {
+		log.Errorf("Unable to discover the OpenID Connect provider %s: %v", o.issuer, err)
+		http.Error(rw, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, o.client)
+	token, err := o.oauth2Config(metadata, r).Exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		log.Errorf("Unable to exchange the OpenID Connect authorization code with %s: %v", o.issuer, err)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if len(rawIDToken) == 0 {
+		log.Errorf("No ID token returned by the OpenID Connect provider %s", o.issuer)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := o.verifyIDToken(metadata, rawIDToken, state.Nonce)
+	if err != nil {
+		log.Errorf("Invalid ID token returned by the OpenID Connect provider %s: %v", o.issuer, err)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	session := oidcSession{
+		Claims: o.sessionClaims(claims),
+		Expiry: claimTime(claims["exp"]),
+	}
+	value, err := o.seal(o.sessionCookie, session)
+	if err != nil {
+		log.Errorf("Unable to create the OpenID Connect session: %v", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(rw, o.cookie(o.sessionCookie, value, time.Unix(session.Expiry, 0), r))
+
+	redirectURI := state.RedirectURI
+	if !strings.HasPrefix(redirectURI, "/") || strings.HasPrefix(redirectURI, "//") {
+		redirectURI = "/"
+	}
+	http.Redirect(rw, r, redirectURI, http.StatusFound)
+}
+
+// verifyIDToken checks the signature, the issuer, the audience, the expiration and the nonce of an ID token,
+// and returns its claims.
+func (o *OIDC) verifyIDToken(metadata *oidcProviderMetadata, rawIDToken string, nonce string) (map[string]interface{}, error) {
+	signed, err := jose.ParseSigned(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(signed.Signatures) != 1 {
+		return nil, errors.New("the ID token must have exactly one signature")
+	}
+
+	keys, err := o.getKeys(metadata, signed.Signatures[0].Header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	for _, key := range keys {
+		switch key.Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			continue
+		}
+		if payload, err = signed.Verify(key.Key); err == nil {
+			break
+		}
+	}
+	if payload == nil {
+		return nil, errors.New("invalid signature")
+	}
+
+	claims := make(map[string]interface{})
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != metadata.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if !containsValue(claimValues(claims["aud"]), o.config.ClientID) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+	if exp := claimTime(claims["exp"]); exp == 0 || time.Now().Unix() > exp {
+		return nil, errors.New("the ID token is expired")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("unexpected nonce")
+	}
+
+	return claims, nil
+}
+
+// authorized checks the claims of a session against the allowed groups and the required claims.
+func (o *OIDC) authorized(claims map[string]interface{}) bool {
+	if len(o.config.AllowedGroups) > 0 {
+		var member bool
+		for _, group := range claimValues(claims[o.groupsClaim]) {
+			if containsValue(o.config.AllowedGroups, group) {
+				member = true
+				break
+			}
+		}
+		if !member {
+			return false
+		}
+	}
+
+	for claim, value := range o.config.RequiredClaims {
+		if !containsValue(claimValues(claims[claim]), value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sessionClaims keeps only the claims used by the middleware, to keep the session cookie small.
+func (o *OIDC) sessionClaims(claims map[string]interface{}) map[string]interface{} {
+	names := []string{"sub", "email", o.groupsClaim}
+	for claim := range o.config.RequiredClaims {
+		names = append(names, claim)
+	}
+	for _, claim := range o.headers {
+		names = append(names, claim)
+	}
+
+	kept := make(map[string]interface{})
+	for _, name := range names {
+		if value, ok := claims[name]; ok {
+			kept[name] = value
+		}
+	}
+	return kept
+}
+
+func (o *OIDC) readSession(r *http.Request) (*oidcSession, error) {
+	cookie, err := r.Cookie(o.sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+
+	session := &oidcSession{}
+	if err = o.open(o.sessionCookie, cookie.Value, session); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > session.Expiry {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// oauth2Config returns the OAuth2 configuration of a request, the callback being on the host and scheme of the request
+// when no redirect URL is configured.
+func (o *OIDC) oauth2Config(metadata *oidcProviderMetadata, r *http.Request) *oauth2.Config {
+	redirectURL := o.redirectURL
+	if len(redirectURL) == 0 {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		redirectURL = scheme + "://" + r.Host + o.callbackPath
+	}
+
+	return &oauth2.Config{
+		ClientID:     o.config.ClientID,
+		ClientSecret: o.config.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  metadata.AuthorizationEndpoint,
+			TokenURL: metadata.TokenEndpoint,
+		},
+		RedirectURL: redirectURL,
+		Scopes:      o.scopes,
+	}
+}
+
+// getMetadata discovers the endpoints of the provider, and caches them once discovered.
+func (o *OIDC) getMetadata() (*oidcProviderMetadata, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.metadata != nil {
+		return o.metadata, nil
+	}
+
+	metadata := &oidcProviderMetadata{}
+	if err := o.getJSON(o.issuer+"/.well-known/openid-configuration", metadata); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != o.issuer {
+		return nil, fmt.Errorf("the provider announces the issuer %q", metadata.Issuer)
+	}
+	if len(metadata.AuthorizationEndpoint) == 0 || len(metadata.TokenEndpoint) == 0 || len(metadata.JWKSURI) == 0 {
+		return nil, errors.New("incomplete provider metadata")
+	}
+
+	o.metadata = metadata
+	return o.metadata, nil
+}
+
+// getKeys returns the signing keys of the provider matching a key ID,
+// fetching them again when the key ID is unknown, in case the provider rotated its keys.
+func (o *OIDC) getKeys(metadata *oidcProviderMetadata, keyID string) ([]jose.JsonWebKey, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.keys != nil {
+		if keys := o.matchingKeys(keyID); len(keys) > 0 || time.Since(o.keysFetched) < oidcKeysRefreshInterval {
+			return keys, nil
+		}
+	}
+
+	keySet := &jose.JsonWebKeySet{}
+	if err := o.getJSON(metadata.JWKSURI, keySet); err != nil {
+		return nil, err
+	}
+	o.keys = keySet
+	o.keysFetched = time.Now()
+
+	return o.matchingKeys(keyID), nil
+}
+
+func (o *OIDC) matchingKeys(keyID string) []jose.JsonWebKey {
+	if len(keyID) == 0 {
+		return o.keys.Keys
+	}
+	return o.keys.Key(keyID)
+}
+
+func (o *OIDC) getJSON(url string, value interface{}) error {
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// seal encrypts a value for a cookie, authenticating the cookie name so that cookies can't be swapped.
+func (o *OIDC) seal(name string, value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, o.aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(o.aead.Seal(nonce, nonce, plaintext, []byte(name))), nil
+}
+
+func (o *OIDC) open(name string, sealed string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return err
+	}
+	if len(data) < o.aead.NonceSize() {
+		return errors.New("cookie too short")
+	}
+
+	plaintext, err := o.aead.Open(nil, data[:o.aead.NonceSize()], data[o.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, value)
+}
+
+func (o *OIDC) stateCookie() string {
+	return o.sessionCookie + "_state"
+}
+
+func (o *OIDC) cookie(name string, value string, expires time.Time, r *http.Request) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   r.TLS != nil || strings.HasPrefix(o.redirectURL, "https://"),
+		HttpOnly: true,
+	}
+}
+
+func (o *OIDC) expiredCookie(name string, r *http.Request) *http.Cookie {
+	cookie := o.cookie(name, "", time.Unix(0, 0), r)
+	cookie.MaxAge = -1
+	return cookie
+}
+
+// claimValues returns the values of a claim as strings, a claim being either a single value or an array.
+func claimValues(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{claim}
+	case []interface{}:
+		var values []string
+		for _, value := range claim {
+			values = append(values, fmt.Sprint(value))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(claim)}
+	}
+}
+
+func claimTime(claim interface{}) int64 {
+	if value, ok := claim.(float64); ok {
+		return int64(value)
+	}
+	return 0
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomString() string {
+	data := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,500 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+	"gopkg.in/square/go-jose.v1"
+)
+
+// fakeOIDCProvider issues the ID tokens of the authorization code "code" with a generated key.
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key    *jose.JsonWebKey
+	nonce  string
+	claims map[string]interface{}
+}
+
+func newFakeOIDCProvider(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) *fakeOIDCProvider {
+	provider := &fakeOIDCProvider{
+		key:    &jose.JsonWebKey{Key: key, KeyID: "key1", Algorithm: string(jose.RS256)},
+		claims: claims,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]string{
+			"issuer":                 provider.URL,
+			"authorization_endpoint": provider.URL + "/authorize",
+			"token_endpoint":         provider.URL + "/token",
+			"jwks_uri":               provider.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(jose.JsonWebKeySet{
+			Keys: []jose.JsonWebKey{{Key: &key.PublicKey, KeyID: "key1", Algorithm: string(jose.RS256), Use: "sig"}},
+		})
+	})
+	mux.HandleFunc("/token", func(rw http.ResponseWriter, req *http.Request) {
+		clientID, clientSecret, _ := req.BasicAuth()
+		if req.FormValue("code") != "code" || clientID != "traefik" || clientSecret != "secret" {
+			http.Error(rw, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     provider.idToken(t),
+		})
+	})
+	provider.Server = httptest.NewServer(mux)
+
+	return provider
+}
+
+func (p *fakeOIDCProvider) idToken(t *testing.T) string {
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"aud":   "traefik",
+		"sub":   "user",
+		"email": "user@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": p.nonce,
+	}
+	for name, value := range p.claims {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signer, err := jose.NewSigner(jose.RS256, p.key)
+	require.NoError(t, err)
+	signed, err := signer.Sign(payload)
+	require.NoError(t, err)
+	idToken, err := signed.CompactSerialize()
+	require.NoError(t, err)
+
+	return idToken
+}
+
+func TestOIDCLogin(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                   string
+		config                 types.OIDC
+		claims                 map[string]interface{}
+		expectedCallbackStatus int
+		expectedStatus         int
+		expectedHeaders        map[string]string
+		expectedRedirectURI    string
+	}{
+		{
+			desc:                   "authenticated user",
+			claims:                 map[string]interface{}{"groups": []string{"dev", "ops"}},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-User":   "user",
+				"X-Forwarded-Email":  "user@example.com",
+				"X-Forwarded-Groups": "dev,ops",
+			},
+		},
+		{
+			desc:                   "audience in an array",
+			claims:                 map[string]interface{}{"aud": []string{"other", "traefik"}},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-User":   "user",
+				"X-Forwarded-Groups": "",
+			},
+		},
+		{
+			desc: "allowed group",
+			config: types.OIDC{
+				GroupsClaim:   "roles",
+				AllowedGroups: []string{"admin", "ops"},
+			},
+			claims:                 map[string]interface{}{"roles": []string{"dev", "ops"}},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Groups": "dev,ops",
+			},
+		},
+		{
+			desc: "group not allowed",
+			config: types.OIDC{
+				AllowedGroups: []string{"admin"},
+			},
+			claims:                 map[string]interface{}{"groups": []string{"dev", "ops"}},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			desc: "required claims",
+			config: types.OIDC{
+				RequiredClaims: map[string]string{"email_verified": "true", "hd": "example.com"},
+			},
+			claims:                 map[string]interface{}{"email_verified": true, "hd": "example.com"},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			desc: "required claim mismatch",
+			config: types.OIDC{
+				RequiredClaims: map[string]string{"email_verified": "true"},
+			},
+			claims:                 map[string]interface{}{"email_verified": false},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			desc: "custom headers",
+			config: types.OIDC{
+				Headers: map[string]string{"x-forwarded-name": "name", "X-Forwarded-User": "preferred_username"},
+			},
+			claims:                 map[string]interface{}{"name": "John Doe", "preferred_username": "john"},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Name": "John Doe",
+				"X-Forwarded-User": "john",
+			},
+		},
+		{
+			desc: "redirect URL",
+			config: types.OIDC{
+				RedirectURL: "https://app.example.com/auth/callback",
+			},
+			expectedCallbackStatus: http.StatusFound,
+			expectedStatus:         http.StatusOK,
+			expectedRedirectURI:    "https://app.example.com/auth/callback",
+		},
+		{
+			desc:                   "wrong audience",
+			claims:                 map[string]interface{}{"aud": "other"},
+			expectedCallbackStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:                   "wrong issuer",
+			claims:                 map[string]interface{}{"iss": "https://other.example.com"},
+			expectedCallbackStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:                   "wrong nonce",
+			claims:                 map[string]interface{}{"nonce": "other"},
+			expectedCallbackStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:                   "expired token",
+			claims:                 map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()},
+			expectedCallbackStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:                   "wrong client secret",
+			config:                 types.OIDC{ClientSecret: "wrong"},
+			expectedCallbackStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := newFakeOIDCProvider(t, key, test.claims)
+			defer provider.Close()
+
+			config := test.config
+			config.Issuer = provider.URL
+			config.ClientID = "traefik"
+			if len(config.ClientSecret) == 0 {
+				config.ClientSecret = "secret"
+			}
+			config.SessionSecret = "session secret"
+
+			handler := newOIDCTestHandler(t, &config)
+
+			// Redirection to the provider
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://example.com/app?page=1", nil)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			require.Equal(t, http.StatusFound, recorder.Code)
+
+			location, err := url.Parse(recorder.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, provider.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+			assert.Equal(t, "traefik", location.Query().Get("client_id"))
+			expectedRedirectURI := test.expectedRedirectURI
+			if len(expectedRedirectURI) == 0 {
+				expectedRedirectURI = "http://example.com/oauth2/callback"
+			}
+			assert.Equal(t, expectedRedirectURI, location.Query().Get("redirect_uri"))
+			assert.Equal(t, "openid profile email", location.Query().Get("scope"))
+			provider.nonce = location.Query().Get("nonce")
+
+			// Callback from the provider
+			redirectURI, err := url.Parse(expectedRedirectURI)
+			require.NoError(t, err)
+			req = testhelpers.MustNewRequest(http.MethodGet, "http://example.com"+redirectURI.Path+"?code=code&state="+url.QueryEscape(location.Query().Get("state")), nil)
+			addCookies(req, recorder)
+			recorder = httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			require.Equal(t, test.expectedCallbackStatus, recorder.Code)
+			if test.expectedCallbackStatus != http.StatusFound {
+				return
+			}
+			assert.Equal(t, "/app?page=1", recorder.Header().Get("Location"))
+
+			// Authenticated request
+			req = testhelpers.MustNewRequest(http.MethodGet, "http://example.com/app", nil)
+			req.Header.Set("X-Forwarded-Groups", "admin")
+			addCookies(req, recorder)
+			recorder = httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get("Upstream-"+header), header)
+			}
+		})
+	}
+}
+
+func TestOIDCUnauthenticated(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	provider := newFakeOIDCProvider(t, key, nil)
+	defer provider.Close()
+
+	handler := newOIDCTestHandler(t, &types.OIDC{
+		Issuer:        provider.URL,
+		ClientID:      "traefik",
+		SessionSecret: "session secret",
+		LogoutPath:    "/logout",
+	})
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		cookies        []*http.Cookie
+		expectedStatus int
+	}{
+		{
+			desc:           "POST request",
+			method:         http.MethodPost,
+			path:           "/app",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "forged session",
+			method:         http.MethodGet,
+			path:           "/app",
+			cookies:        []*http.Cookie{{Name: "_traefik_oidc", Value: "Zm9yZ2Vk"}},
+			expectedStatus: http.StatusFound,
+		},
+		{
+			desc:           "callback without state",
+			method:         http.MethodGet,
+			path:           "/oauth2/callback?code=code&state=state",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "callback with a forged state",
+			method:         http.MethodGet,
+			path:           "/oauth2/callback?code=code&state=state",
+			cookies:        []*http.Cookie{{Name: "_traefik_oidc_state", Value: "Zm9yZ2Vk"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "logout",
+			method:         http.MethodGet,
+			path:           "/logout",
+			expectedStatus: http.StatusFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			req := testhelpers.MustNewRequest(test.method, "http://example.com"+test.path, nil)
+			for _, cookie := range test.cookies {
+				req.AddCookie(cookie)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestOIDCStateMismatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	provider := newFakeOIDCProvider(t, key, nil)
+	defer provider.Close()
+
+	handler := newOIDCTestHandler(t, &types.OIDC{
+		Issuer:        provider.URL,
+		ClientID:      "traefik",
+		ClientSecret:  "secret",
+		SessionSecret: "session secret",
+	})
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://example.com/app", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusFound, recorder.Code)
+
+	req = testhelpers.MustNewRequest(http.MethodGet, "http://example.com/oauth2/callback?code=code&state=other", nil)
+	addCookies(req, recorder)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestNewOIDC(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.OIDC
+		expectedError bool
+	}{
+		{
+			desc: "valid configuration",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+			},
+		},
+		{
+			desc:          "nil configuration",
+			expectedError: true,
+		},
+		{
+			desc: "missing issuer",
+			config: &types.OIDC{
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+			},
+			expectedError: true,
+		},
+		{
+			desc: "missing client ID",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				SessionSecret: "secret",
+			},
+			expectedError: true,
+		},
+		{
+			desc: "missing session secret",
+			config: &types.OIDC{
+				Issuer:   "https://accounts.example.com",
+				ClientID: "traefik",
+			},
+			expectedError: true,
+		},
+		{
+			desc: "redirect URL",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+				RedirectURL:   "https://app.example.com/oauth2/callback",
+			},
+		},
+		{
+			desc: "relative redirect URL",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+				RedirectURL:   "/oauth2/callback",
+			},
+			expectedError: true,
+		},
+		{
+			desc: "redirect URL not matching the callback path",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+				CallbackPath:  "/callback",
+				RedirectURL:   "https://app.example.com/oauth2/callback",
+			},
+			expectedError: true,
+		},
+		{
+			desc: "relative callback path",
+			config: &types.OIDC{
+				Issuer:        "https://accounts.example.com",
+				ClientID:      "traefik",
+				SessionSecret: "secret",
+				CallbackPath:  "callback",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewOIDC(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// newOIDCTestHandler returns the headers received by the backend prefixed with Upstream-.
+func newOIDCTestHandler(t *testing.T, config *types.OIDC) http.Handler {
+	middleware, err := NewOIDC(config)
+	require.NoError(t, err)
+
+	n := negroni.New(middleware)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		for header, values := range req.Header {
+			if strings.HasPrefix(header, "X-Forwarded-") {
+				rw.Header().Set("Upstream-"+header, strings.Join(values, ","))
+			}
+		}
+		fmt.Fprintln(rw, "traefik")
+	}))
+	return n
+}
+
+func addCookies(req *http.Request, recorder *httptest.ResponseRecorder) {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			req.AddCookie(cookie)
+		}
+	}
+}
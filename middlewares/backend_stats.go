@@ -0,0 +1,205 @@
+package middlewares
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBackendStatsWindow = time.Minute
+	backendStatsBuckets       = 12
+)
+
+// BackendStatsRecorder records rolling latency and error statistics for each server of each backend.
+// The statistics are kept across configuration reloads, the ones of the servers removed from the
+// configuration are forgotten once the retention duration is elapsed.
+type BackendStatsRecorder struct {
+	mutex          sync.RWMutex
+	bucketDuration time.Duration
+	retention      time.Duration
+	backends       map[string]map[string]*serverStats
+	now            func() time.Time
+}
+
+// NewBackendStatsRecorder returns a new BackendStatsRecorder using the given rolling window.
+// The default window is used when the window is too short to be split in buckets.
+func NewBackendStatsRecorder(window, retention time.Duration) *BackendStatsRecorder {
+	if window < backendStatsBuckets {
+		window = defaultBackendStatsWindow
+	}
+	return &BackendStatsRecorder{
+		bucketDuration: window / backendStatsBuckets,
+		retention:      retention,
+		backends:       make(map[string]map[string]*serverStats),
+		now:            time.Now,
+	}
+}
+
+// BackendServerStats holds the statistics of a backend server over the rolling window.
+type BackendServerStats struct {
+	Requests          int64   `json:"requests"`
+	Errors            int64   `json:"errors"`
+	ErrorRatio        float64 `json:"error_ratio"`
+	AverageLatencySec float64 `json:"average_latency_sec"`
+	MaxLatencySec     float64 `json:"max_latency_sec"`
+	Removed           bool    `json:"removed,omitempty"`
+}
+
+type serverStats struct {
+	buckets   [backendStatsBuckets]statsBucket
+	removedAt time.Time
+}
+
+type statsBucket struct {
+	start      time.Time
+	requests   int64
+	errors     int64
+	latencySum time.Duration
+	latencyMax time.Duration
+}
+
+// Handler returns a handler recording the statistics of the requests forwarded by next
+// to the servers of the given backend.
+// The server is identified by the scheme and host of the request URL, as set by the load-balancer.
+func (s *BackendStatsRecorder) Handler(next http.Handler, backendName string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := s.now()
+		responseWriter := &responseRecorder{rw, http.StatusOK}
+		next.ServeHTTP(responseWriter, r)
+
+		server := r.URL.Scheme + "://" + r.URL.Host
+		s.Record(backendName, server, s.now().Sub(start), responseWriter.statusCode >= http.StatusInternalServerError)
+	})
+}
+
+// Record adds a request to the statistics of a backend server.
+func (s *BackendStatsRecorder) Record(backendName, server string, latency time.Duration, isError bool) {
+	now := s.now()
+	start := now.Truncate(s.bucketDuration)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	servers, ok := s.backends[backendName]
+	if !ok {
+		servers = make(map[string]*serverStats)
+		s.backends[backendName] = servers
+	}
+	stats, ok := servers[server]
+	if !ok {
+		stats = &serverStats{}
+		servers[server] = stats
+	}
+
+	bucket := &stats.buckets[(start.UnixNano()/int64(s.bucketDuration))%backendStatsBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = statsBucket{start: start}
+	}
+	bucket.requests++
+	if isError {
+		bucket.errors++
+	}
+	bucket.latencySum += latency
+	if latency > bucket.latencyMax {
+		bucket.latencyMax = latency
+	}
+}
+
+// ServerStats returns the statistics of a backend server over the rolling window.
+func (s *BackendStatsRecorder) ServerStats(backendName, server string) (BackendServerStats, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	stats, ok := s.backends[backendName][server]
+	if !ok || s.expired(stats, now) {
+		return BackendServerStats{}, false
+	}
+	return s.aggregate(stats, now), true
+}
+
+// Data returns the statistics of all the backend servers, by backend and server.
+func (s *BackendStatsRecorder) Data() map[string]map[string]BackendServerStats {
+	now := s.now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	data := make(map[string]map[string]BackendServerStats)
+	for backendName, servers := range s.backends {
+		for server, stats := range servers {
+			if s.expired(stats, now) {
+				continue
+			}
+			if data[backendName] == nil {
+				data[backendName] = make(map[string]BackendServerStats)
+			}
+			data[backendName][server] = s.aggregate(stats, now)
+		}
+	}
+	return data
+}
+
+// Retain flags the servers which are not part of the given backend servers as removed,
+// and forgets the ones which have been removed for longer than the retention duration.
+func (s *BackendStatsRecorder) Retain(backendServers map[string][]string) {
+	now := s.now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for backendName, servers := range s.backends {
+		active := make(map[string]bool)
+		for _, server := range backendServers[backendName] {
+			active[server] = true
+		}
+
+		for server, stats := range servers {
+			switch {
+			case active[server]:
+				stats.removedAt = time.Time{}
+			case stats.removedAt.IsZero():
+				stats.removedAt = now
+			}
+
+			if s.expired(stats, now) {
+				delete(servers, server)
+			}
+		}
+
+		if len(servers) == 0 {
+			delete(s.backends, backendName)
+		}
+	}
+}
+
+// expired returns true if the server has been removed from the configuration for longer than the retention duration.
+func (s *BackendStatsRecorder) expired(stats *serverStats, now time.Time) bool {
+	return !stats.removedAt.IsZero() && now.Sub(stats.removedAt) >= s.retention
+}
+
+func (s *BackendStatsRecorder) aggregate(stats *serverStats, now time.Time) BackendServerStats {
+	windowStart := now.Truncate(s.bucketDuration).Add(-s.bucketDuration * (backendStatsBuckets - 1))
+
+	result := BackendServerStats{Removed: !stats.removedAt.IsZero()}
+	var latencySum, latencyMax time.Duration
+	for _, bucket := range stats.buckets {
+		if bucket.start.Before(windowStart) {
+			continue
+		}
+		result.Requests += bucket.requests
+		result.Errors += bucket.errors
+		latencySum += bucket.latencySum
+		if bucket.latencyMax > latencyMax {
+			latencyMax = bucket.latencyMax
+		}
+	}
+
+	if result.Requests > 0 {
+		result.ErrorRatio = float64(result.Errors) / float64(result.Requests)
+		result.AverageLatencySec = latencySum.Seconds() / float64(result.Requests)
+	}
+	result.MaxLatencySec = latencyMax.Seconds()
+	return result
+}
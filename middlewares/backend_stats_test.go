@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendStatsRecorderRollingWindow(t *testing.T) {
+	now := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	recorder := NewBackendStatsRecorder(time.Minute, 0)
+	recorder.now = func() time.Time { return now }
+
+	recorder.Record("backend1", "http://10.0.0.1:80", 100*time.Millisecond, false)
+	recorder.Record("backend1", "http://10.0.0.1:80", 300*time.Millisecond, true)
+
+	now = now.Add(30 * time.Second)
+	recorder.Record("backend1", "http://10.0.0.1:80", 200*time.Millisecond, false)
+
+	stats, ok := recorder.ServerStats("backend1", "http://10.0.0.1:80")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.InDelta(t, 1.0/3, stats.ErrorRatio, 0.0001)
+	assert.InDelta(t, 0.2, stats.AverageLatencySec, 0.0001)
+	assert.InDelta(t, 0.3, stats.MaxLatencySec, 0.0001)
+
+	// the first requests are out of the window
+	now = now.Add(45 * time.Second)
+	stats, ok = recorder.ServerStats("backend1", "http://10.0.0.1:80")
+	require.True(t, ok)
+	assert.Equal(t, BackendServerStats{Requests: 1, AverageLatencySec: 0.2, MaxLatencySec: 0.2}, stats)
+
+	now = now.Add(time.Hour)
+	stats, ok = recorder.ServerStats("backend1", "http://10.0.0.1:80")
+	require.True(t, ok)
+	assert.Equal(t, BackendServerStats{}, stats)
+
+	_, ok = recorder.ServerStats("backend1", "http://10.0.0.2:80")
+	assert.False(t, ok)
+}
+
+func TestNewBackendStatsRecorderWindow(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		window                 time.Duration
+		expectedBucketDuration time.Duration
+	}{
+		{
+			desc:                   "window",
+			window:                 time.Minute,
+			expectedBucketDuration: 5 * time.Second,
+		},
+		{
+			desc:                   "no window",
+			expectedBucketDuration: 5 * time.Second,
+		},
+		{
+			desc:                   "window too short to be split in buckets",
+			window:                 10 * time.Nanosecond,
+			expectedBucketDuration: 5 * time.Second,
+		},
+		{
+			desc:                   "shortest window",
+			window:                 12 * time.Nanosecond,
+			expectedBucketDuration: time.Nanosecond,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := NewBackendStatsRecorder(test.window, 0)
+			assert.Equal(t, test.expectedBucketDuration, recorder.bucketDuration)
+
+			// recording must not divide by a zero bucket duration
+			recorder.Record("backend1", "http://10.0.0.1:80", time.Millisecond, false)
+		})
+	}
+}
+
+func TestBackendStatsRecorderRetain(t *testing.T) {
+	now := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	recorder := NewBackendStatsRecorder(time.Minute, 5*time.Minute)
+	recorder.now = func() time.Time { return now }
+
+	recorder.Record("backend1", "http://10.0.0.1:80", time.Millisecond, false)
+	recorder.Record("backend1", "http://10.0.0.2:80", time.Millisecond, false)
+	recorder.Record("backend2", "http://10.0.0.3:80", time.Millisecond, false)
+
+	recorder.Retain(map[string][]string{"backend1": {"http://10.0.0.1:80"}})
+
+	data := recorder.Data()
+	assert.False(t, data["backend1"]["http://10.0.0.1:80"].Removed)
+	assert.True(t, data["backend1"]["http://10.0.0.2:80"].Removed)
+	assert.True(t, data["backend2"]["http://10.0.0.3:80"].Removed)
+
+	// the server comes back before the end of the retention
+	now = now.Add(time.Minute)
+	recorder.Retain(map[string][]string{"backend1": {"http://10.0.0.1:80", "http://10.0.0.2:80"}})
+	assert.False(t, recorder.Data()["backend1"]["http://10.0.0.2:80"].Removed)
+
+	now = now.Add(5 * time.Minute)
+	assert.NotContains(t, recorder.Data(), "backend2")
+
+	recorder.Retain(map[string][]string{"backend1": {"http://10.0.0.1:80", "http://10.0.0.2:80"}})
+	assert.NotContains(t, recorder.backends, "backend2")
+	assert.Len(t, recorder.backends["backend1"], 2)
+}
+
+func TestBackendStatsRecorderHandler(t *testing.T) {
+	recorder := NewBackendStatsRecorder(time.Minute, 0)
+
+	handler := recorder.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rw.WriteHeader(http.StatusNotFound)
+	}), "backend1")
+
+	for _, path := range []string{"/", "/error"} {
+		req := httptest.NewRequest(http.MethodGet, "http://10.0.0.1:80"+path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	stats, ok := recorder.ServerStats("backend1", "http://10.0.0.1:80")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), stats.Requests)
+	assert.Equal(t, int64(1), stats.Errors)
+}
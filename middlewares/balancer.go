@@ -0,0 +1,463 @@
+package middlewares
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/roundrobin"
+	"github.com/vulcand/oxy/utils"
+)
+
+const (
+	// defaultBalancerDecay is the time after which the latency observed on a server has lost most of its weight in its average.
+	defaultBalancerDecay = 10 * time.Second
+	defaultHashKey       = "client.ip"
+	defaultHashReplicas  = 100
+	// minSlowStartRamp is the share of its weight a server starts with during its slow start.
+	minSlowStartRamp = 0.1
+)
+
+// Balancer is a load balancer forwarding each request to the server with the lowest cost:
+// its number of requests in flight (least connections), or its average latency weighted
+// by its number of requests in flight (EWMA and peak EWMA), divided by its weight.
+// The ties are broken in turn, so that the idle servers all get requests.
+// With consistent hashing, the server is the first one found on a ring from the hash of a key of the request,
+// skipping the servers with too many requests in flight when the load is bounded.
+// With weighted round robin, the requests are spread smoothly among the servers, in proportion to their weights.
+// During their slow start, the weight of the new servers grows linearly from a tenth of their weight.
+// With the stickiness keyed on a header or a query parameter, the requests with the same key are forwarded
+// to the same server, chosen by rendezvous hashing: a key only moves when its server leaves the pool,
+// or to a new server getting its share of the keys.
+type Balancer struct {
+	next        http.Handler
+	method      types.LoadBalancerMethod
+	decay       time.Duration
+	sticky      *roundrobin.StickySession
+	affinityKey func(req *http.Request) string
+	slowStart   time.Duration
+
+	hashKey    utils.SourceExtractor
+	replicas   int
+	loadFactor float64
+
+	mutex    sync.Mutex
+	servers  []*balancedServer
+	index    int
+	ring     []ringPoint
+	inFlight int
+}
+
+// ringPoint is a point of a server on the consistent hashing ring.
+type ringPoint struct {
+	hash   uint64
+	server *balancedServer
+}
+
+type balancedServer struct {
+	url      *url.URL
+	weight   int
+	inFlight int
+	// latency is the moving average of the response times, in nanoseconds.
+	latency   float64
+	updatedAt time.Time
+	// current is the current weight of the smooth weighted round robin.
+	current   float64
+	startedAt time.Time
+}
+
+// NewBalancer creates a load balancer forwarding the requests to the next handler, with the URL of the chosen server.
+func NewBalancer(next http.Handler, config *types.LoadBalancer, sticky *roundrobin.StickySession) (*Balancer, error) {
+	method, err := types.NewLoadBalancerMethod(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Balancer{
+		next:      next,
+		method:    method,
+		decay:     defaultBalancerDecay,
+		sticky:    sticky,
+		slowStart: time.Duration(config.SlowStart),
+	}
+
+	if stickiness := config.Stickiness; stickiness != nil {
+		switch {
+		case len(stickiness.Header) > 0 && len(stickiness.Query) > 0:
+			return nil, errors.New("the stickiness can't be keyed on both a header and a query parameter")
+		case len(stickiness.Header) > 0:
+			b.affinityKey = func(req *http.Request) string {
+				return req.Header.Get(stickiness.Header)
+			}
+		case len(stickiness.Query) > 0:
+			b.affinityKey = func(req *http.Request) string {
+				return req.URL.Query().Get(stickiness.Query)
+			}
+		}
+	}
+
+	if method == types.ConsistentHashing {
+		hashConfig := config.ConsistentHash
+		if hashConfig == nil {
+			hashConfig = &types.ConsistentHash{}
+		}
+		key := hashConfig.Key
+		if len(key) == 0 {
+			key = defaultHashKey
+		}
+		b.hashKey, err = NewRateLimitExtractor(key)
+		if err != nil {
+			return nil, err
+		}
+		b.replicas = hashConfig.Replicas
+		if b.replicas <= 0 {
+			b.replicas = defaultHashReplicas
+		}
+		if hashConfig.LoadFactor != 0 && hashConfig.LoadFactor < 1 {
+			return nil, errors.New("the load factor must be greater than 1")
+		}
+		b.loadFactor = hashConfig.LoadFactor
+	}
+	return b, nil
+}
+
+func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	server, stuck := b.acquire(req)
+	if server == nil {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		rw.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+		return
+	}
+	if b.sticky != nil && !stuck {
+		b.sticky.StickBackend(server.url, &rw)
+	}
+
+	// make shallow copy of request before changing anything to avoid side effects
+	newReq := *req
+	newReq.URL = utils.CopyURL(server.url)
+
+	start := time.Now()
+	defer func() {
+		b.release(server, time.Since(start))
+	}()
+	b.next.ServeHTTP(rw, &newReq)
+}
+
+// acquire chooses the server of the request, and counts the request in its requests in flight.
+func (b *Balancer) acquire(req *http.Request) (*balancedServer, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.sticky != nil {
+		cookieURL, present, err := b.sticky.GetBackend(req, b.urls())
+		if err != nil {
+			log.Infof("Error using server from cookie: %v", err)
+		}
+		if present {
+			if server, _ := b.findServer(cookieURL); server != nil {
+				server.inFlight++
+				b.inFlight++
+				return server, true
+			}
+		}
+	}
+
+	if b.affinityKey != nil {
+		if server := b.affinityServer(req); server != nil {
+			server.inFlight++
+			b.inFlight++
+			return server, true
+		}
+	}
+
+	if b.method == types.ConsistentHashing {
+		if server := b.hashServer(req); server != nil {
+			server.inFlight++
+			b.inFlight++
+			return server, false
+		}
+	}
+
+	now := time.Now()
+	if b.method == types.Wrr {
+		chosen := b.nextWeighted(now)
+		if chosen == nil {
+			return nil, false
+		}
+		chosen.inFlight++
+		b.inFlight++
+		return chosen, false
+	}
+
+	var chosen *balancedServer
+	var chosenCost float64
+	for i := range b.servers {
+		server := b.servers[(b.index+i)%len(b.servers)]
+		cost := b.cost(server, now)
+		if chosen == nil || cost < chosenCost {
+			chosen = server
+			chosenCost = cost
+		}
+	}
+	if chosen == nil {
+		return nil, false
+	}
+	b.index = (b.index + 1) % len(b.servers)
+	chosen.inFlight++
+	b.inFlight++
+	return chosen, false
+}
+
+// nextWeighted returns the next server of the smooth weighted round robin.
+// It must be called with the lock held.
+func (b *Balancer) nextWeighted(now time.Time) *balancedServer {
+	var chosen *balancedServer
+	var total float64
+	for _, server := range b.servers {
+		weight := b.effectiveWeight(server, now)
+		server.current += weight
+		total += weight
+		if chosen == nil || server.current > chosen.current {
+			chosen = server
+		}
+	}
+	if chosen != nil {
+		chosen.current -= total
+	}
+	return chosen
+}
+
+// hashServer returns the server of the key of the request on the ring, or nil when the request has no key.
+// It must be called with the lock held.
+func (b *Balancer) hashServer(req *http.Request) *balancedServer {
+	if len(b.ring) == 0 {
+		return nil
+	}
+	key, _, err := b.hashKey.Extract(req)
+	if err != nil {
+		log.Debugf("Error extracting the hash key of the request: %v", err)
+		return nil
+	}
+	if len(key) == 0 {
+		return nil
+	}
+
+	hash := hashString(key)
+	start := sort.Search(len(b.ring), func(i int) bool { return b.ring[i].hash >= hash })
+	for i := 0; i < len(b.ring); i++ {
+		server := b.ring[(start+i)%len(b.ring)].server
+		if b.underLoadBound(server) {
+			return server
+		}
+	}
+	return nil
+}
+
+// affinityServer returns the server with the highest weighted rendezvous score for the affinity key of the request,
+// or nil when the request has no key.
+// It must be called with the lock held.
+func (b *Balancer) affinityServer(req *http.Request) *balancedServer {
+	key := b.affinityKey(req)
+	if len(key) == 0 {
+		return nil
+	}
+
+	var chosen *balancedServer
+	var chosenScore float64
+	for _, server := range b.servers {
+		// the hash mapped into (0, 1) gives the score -weight/ln(hash), the highest one winning in proportion to the weight
+		hash := (float64(hashString(key+"\x00"+server.url.String())>>11) + 0.5) / (1 << 53)
+		score := -float64(server.weight) / math.Log(hash)
+		if chosen == nil || score > chosenScore {
+			chosen = server
+			chosenScore = score
+		}
+	}
+	return chosen
+}
+
+// underLoadBound returns whether a new request wouldn't exceed the share of the requests in flight of the server.
+// It must be called with the lock held.
+func (b *Balancer) underLoadBound(server *balancedServer) bool {
+	if b.loadFactor == 0 {
+		return true
+	}
+	var totalWeight int
+	for _, s := range b.servers {
+		totalWeight += s.weight
+	}
+	bound := math.Ceil(b.loadFactor * float64(b.inFlight+1) * float64(server.weight) / float64(totalWeight))
+	return float64(server.inFlight+1) <= bound
+}
+
+// buildRing must be called with the lock held.
+func (b *Balancer) buildRing() {
+	if b.method != types.ConsistentHashing {
+		return
+	}
+	b.ring = nil
+	for _, server := range b.servers {
+		for i := 0; i < server.weight*b.replicas; i++ {
+			b.ring = append(b.ring, ringPoint{hash: hashString(server.url.String() + "-" + strconv.Itoa(i)), server: server})
+		}
+	}
+	sort.Slice(b.ring, func(i, j int) bool { return b.ring[i].hash < b.ring[j].hash })
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// the finalizer of murmur3 spreads the hashes of the similar strings over the ring
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// cost must be called with the lock held.
+func (b *Balancer) cost(server *balancedServer, now time.Time) float64 {
+	load := float64(server.inFlight + 1)
+	if b.method == types.EWMA || b.method == types.PeakEWMA {
+		// an average decaying toward the latest observation, so that a server which was slow
+		// and hasn't been used since then gets a chance again
+		load *= server.latency * b.decayWeight(now.Sub(server.updatedAt))
+	}
+	return load / b.effectiveWeight(server, now)
+}
+
+// effectiveWeight returns the weight of the server, lowered during its slow start.
+func (b *Balancer) effectiveWeight(server *balancedServer, now time.Time) float64 {
+	weight := float64(server.weight)
+	if b.slowStart <= 0 || server.startedAt.IsZero() {
+		return weight
+	}
+	ramp := float64(now.Sub(server.startedAt)) / float64(b.slowStart)
+	if ramp >= 1 {
+		return weight
+	}
+	return weight * math.Max(ramp, minSlowStartRamp)
+}
+
+// SetStartTimes sets when the servers, indexed by URL, were added to the backend, the zero time meaning before the slow start.
+// The servers added to the load balancer later on, e.g. by the health checks, start when they are added.
+func (b *Balancer) SetStartTimes(startTimes map[string]time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, server := range b.servers {
+		if startedAt, ok := startTimes[server.url.String()]; ok {
+			server.startedAt = startedAt
+		}
+	}
+}
+
+// decayWeight returns the weight of an observation of the given age in the average.
+func (b *Balancer) decayWeight(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp(-float64(age) / float64(b.decay))
+}
+
+// release records the end of a request forwarded to the server.
+func (b *Balancer) release(server *balancedServer, latency time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	server.inFlight--
+	b.inFlight--
+	if b.method != types.EWMA && b.method != types.PeakEWMA {
+		return
+	}
+
+	now := time.Now()
+	observed := float64(latency)
+	if b.method == types.PeakEWMA && observed > server.latency {
+		// the latency spikes are taken into account at once
+		server.latency = observed
+	} else {
+		w := b.decayWeight(now.Sub(server.updatedAt))
+		server.latency = server.latency*w + observed*(1-w)
+	}
+	server.updatedAt = now
+}
+
+// urls must be called with the lock held.
+func (b *Balancer) urls() []*url.URL {
+	urls := make([]*url.URL, len(b.servers))
+	for i, server := range b.servers {
+		urls[i] = server.url
+	}
+	return urls
+}
+
+// findServer must be called with the lock held.
+func (b *Balancer) findServer(u *url.URL) (*balancedServer, int) {
+	for i, server := range b.servers {
+		if server.url.Scheme == u.Scheme && server.url.Host == u.Host && server.url.Path == u.Path {
+			return server, i
+		}
+	}
+	return nil, -1
+}
+
+// Servers returns the URLs of the servers of the load balancer.
+func (b *Balancer) Servers() []*url.URL {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.urls()
+}
+
+// RemoveServer removes a server from the load balancer. Its requests in flight are not interrupted.
+func (b *Balancer) RemoveServer(u *url.URL) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	_, index := b.findServer(u)
+	if index < 0 {
+		return errors.New("server not found")
+	}
+	b.servers = append(b.servers[:index], b.servers[index+1:]...)
+	b.buildRing()
+	return nil
+}
+
+// UpsertServer adds a server to the load balancer, or updates its weight.
+func (b *Balancer) UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error {
+	if u == nil {
+		return errors.New("server URL can't be nil")
+	}
+
+	// the options of the servers can only be applied by a round robin
+	rr, err := roundrobin.New(b.next)
+	if err != nil {
+		return err
+	}
+	if err := rr.UpsertServer(u, options...); err != nil {
+		return err
+	}
+	weight, _ := rr.ServerWeight(u)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if server, _ := b.findServer(u); server != nil {
+		server.weight = weight
+	} else {
+		b.servers = append(b.servers, &balancedServer{url: utils.CopyURL(u), weight: weight, startedAt: time.Now()})
+	}
+	b.buildRing()
+	return nil
+}
@@ -0,0 +1,421 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func newTestBalancer(t *testing.T, config *types.LoadBalancer, weights map[string]int) *Balancer {
+	balancer, err := NewBalancer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", req.URL.Host)
+	}), config, nil)
+	require.NoError(t, err)
+	for host, weight := range weights {
+		require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://"+host), roundrobin.Weight(weight)))
+	}
+	return balancer
+}
+
+func TestBalancerLeastConn(t *testing.T) {
+	balancer := newTestBalancer(t, &types.LoadBalancer{Method: "leastconn"}, map[string]int{"a": 1, "b": 1})
+
+	first, _ := balancer.acquire(httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+	for i := 0; i < 5; i++ {
+		recorder := httptest.NewRecorder()
+		balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+		assert.NotEqual(t, first.url.Host, recorder.Header().Get("server"))
+	}
+
+	balancer.release(first, time.Millisecond)
+	served := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+		served[recorder.Header().Get("server")]++
+	}
+	assert.Equal(t, map[string]int{"a": 5, "b": 5}, served)
+}
+
+func TestBalancerLeastConnWeight(t *testing.T) {
+	balancer := newTestBalancer(t, &types.LoadBalancer{Method: "leastconn"}, map[string]int{"a": 3, "b": 1})
+
+	acquired := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		server, _ := balancer.acquire(httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+		acquired[server.url.Host]++
+	}
+	assert.Equal(t, map[string]int{"a": 6, "b": 2}, acquired)
+}
+
+func TestBalancerEWMA(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		method         string
+		latencies      []time.Duration
+		expectedServer string
+	}{
+		{
+			desc:           "EWMA, a slower",
+			method:         "ewma",
+			latencies:      []time.Duration{100 * time.Millisecond, 10 * time.Millisecond},
+			expectedServer: "b",
+		},
+		{
+			desc:           "EWMA, a faster",
+			method:         "ewma",
+			latencies:      []time.Duration{10 * time.Millisecond, 100 * time.Millisecond},
+			expectedServer: "a",
+		},
+		{
+			desc:           "peak EWMA, spike on a",
+			method:         "peakewma",
+			latencies:      []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, time.Second, 20 * time.Millisecond},
+			expectedServer: "b",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			balancer := newTestBalancer(t, &types.LoadBalancer{Method: test.method}, map[string]int{"a": 1, "b": 1})
+			servers := make(map[string]*balancedServer)
+			for _, server := range balancer.servers {
+				servers[server.url.Host] = server
+			}
+
+			// the latencies alternate between a and b
+			for i, latency := range test.latencies {
+				server := servers[[]string{"a", "b"}[i%2]]
+				server.inFlight++
+				balancer.release(server, latency)
+			}
+
+			latency := servers[test.expectedServer].latency
+			for i := 0; i < 4; i++ {
+				recorder := httptest.NewRecorder()
+				balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+				assert.Equal(t, test.expectedServer, recorder.Header().Get("server"))
+				// the latencies of the test requests don't count
+				servers[test.expectedServer].latency = latency
+			}
+		})
+	}
+}
+
+func TestBalancerSticky(t *testing.T) {
+	balancer, err := NewBalancer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", req.URL.Host)
+	}), &types.LoadBalancer{Method: "leastconn"}, roundrobin.NewStickySession("test"))
+	require.NoError(t, err)
+	require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://a")))
+	require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://b")))
+
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://foo.bar", nil)
+		req.AddCookie(&http.Cookie{Name: "test", Value: "http://b"})
+		recorder := httptest.NewRecorder()
+		balancer.ServeHTTP(recorder, req)
+		assert.Equal(t, "b", recorder.Header().Get("server"))
+		assert.Empty(t, recorder.Header().Get("Set-Cookie"))
+	}
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+	assert.Equal(t, "test=http://"+recorder.Header().Get("server")+"; Path=/", recorder.Header().Get("Set-Cookie"))
+}
+
+func TestBalancerServers(t *testing.T) {
+	balancer := newTestBalancer(t, &types.LoadBalancer{Method: "leastconn"}, map[string]int{"a": 1})
+
+	require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://a"), roundrobin.Weight(2)))
+	require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://b")))
+	assert.Equal(t, []*url.URL{testhelpers.MustParseURL("http://a"), testhelpers.MustParseURL("http://b")}, balancer.Servers())
+	assert.Equal(t, 2, balancer.servers[0].weight)
+	assert.Equal(t, 1, balancer.servers[1].weight)
+
+	require.NoError(t, balancer.RemoveServer(testhelpers.MustParseURL("http://a")))
+	assert.Error(t, balancer.RemoveServer(testhelpers.MustParseURL("http://a")))
+	require.NoError(t, balancer.RemoveServer(testhelpers.MustParseURL("http://b")))
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestNewBalancer(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        types.LoadBalancer
+		expectedError bool
+	}{
+		{
+			desc:   "least connections",
+			config: types.LoadBalancer{Method: "leastconn"},
+		},
+		{
+			desc:   "consistent hash defaults",
+			config: types.LoadBalancer{Method: "consistenthash"},
+		},
+		{
+			desc:   "consistent hash",
+			config: types.LoadBalancer{Method: "consistenthash", ConsistentHash: &types.ConsistentHash{Key: "request.header.X-User", Replicas: 10, LoadFactor: 1.25}},
+		},
+		{
+			desc:          "invalid hash key",
+			config:        types.LoadBalancer{Method: "consistenthash", ConsistentHash: &types.ConsistentHash{Key: "request.cookie."}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid load factor",
+			config:        types.LoadBalancer{Method: "consistenthash", ConsistentHash: &types.ConsistentHash{LoadFactor: 0.5}},
+			expectedError: true,
+		},
+		{
+			desc:   "stickiness keyed on a header",
+			config: types.LoadBalancer{Method: "wrr", Stickiness: &types.Stickiness{Header: "X-Session"}},
+		},
+		{
+			desc:          "stickiness keyed on both a header and a query parameter",
+			config:        types.LoadBalancer{Method: "wrr", Stickiness: &types.Stickiness{Header: "X-Session", Query: "session"}},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown method",
+			config:        types.LoadBalancer{Method: "random"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewBalancer(http.NotFoundHandler(), &test.config, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBalancerConsistentHash(t *testing.T) {
+	config := &types.LoadBalancer{Method: "consistenthash", ConsistentHash: &types.ConsistentHash{Key: "request.header.X-User"}}
+	balancer := newTestBalancer(t, config, map[string]int{"a": 1, "b": 1, "c": 1})
+
+	serve := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, "http://foo.bar", nil)
+		req.Header.Set("X-User", user)
+		recorder := httptest.NewRecorder()
+		balancer.ServeHTTP(recorder, req)
+		return recorder.Header().Get("server")
+	}
+
+	servers := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		user := "user" + strconv.Itoa(i)
+		servers[user] = serve(user)
+		counts[servers[user]]++
+		assert.Equal(t, servers[user], serve(user))
+	}
+	for _, server := range []string{"a", "b", "c"} {
+		assert.InDelta(t, 100, counts[server], 40, server)
+	}
+
+	// only the keys of the removed server move
+	require.NoError(t, balancer.RemoveServer(testhelpers.MustParseURL("http://c")))
+	for user, server := range servers {
+		if server != "c" {
+			assert.Equal(t, server, serve(user))
+		} else {
+			assert.NotEqual(t, "c", serve(user))
+		}
+	}
+}
+
+func TestBalancerKeyedAffinity(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		stickiness *types.Stickiness
+		request    func(session string) *http.Request
+	}{
+		{
+			desc:       "header",
+			stickiness: &types.Stickiness{Header: "X-Session"},
+			request: func(session string) *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "http://foo.bar", nil)
+				req.Header.Set("X-Session", session)
+				return req
+			},
+		},
+		{
+			desc:       "query parameter",
+			stickiness: &types.Stickiness{Query: "session"},
+			request: func(session string) *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://foo.bar/?session="+session, nil)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &types.LoadBalancer{Method: "wrr", Stickiness: test.stickiness}
+			balancer := newTestBalancer(t, config, map[string]int{"a": 1, "b": 1, "c": 1})
+
+			serve := func(session string) string {
+				recorder := httptest.NewRecorder()
+				balancer.ServeHTTP(recorder, test.request(session))
+				assert.Empty(t, recorder.Header().Get("Set-Cookie"))
+				return recorder.Header().Get("server")
+			}
+
+			servers := make(map[string]string)
+			counts := make(map[string]int)
+			for i := 0; i < 300; i++ {
+				session := "session" + strconv.Itoa(i)
+				servers[session] = serve(session)
+				counts[servers[session]]++
+				assert.Equal(t, servers[session], serve(session))
+			}
+			for _, server := range []string{"a", "b", "c"} {
+				assert.InDelta(t, 100, counts[server], 40, server)
+			}
+
+			// only the keys of the removed server move
+			require.NoError(t, balancer.RemoveServer(testhelpers.MustParseURL("http://c")))
+			for session, server := range servers {
+				if server != "c" {
+					assert.Equal(t, server, serve(session))
+				} else {
+					assert.NotEqual(t, "c", serve(session))
+				}
+			}
+
+			// the keys of a server added back return to it, the others stay
+			require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://c")))
+			for session, server := range servers {
+				assert.Equal(t, server, serve(session))
+			}
+		})
+	}
+}
+
+func TestBalancerConsistentHashBoundedLoad(t *testing.T) {
+	config := &types.LoadBalancer{Method: "consistenthash", ConsistentHash: &types.ConsistentHash{Key: "request.header.X-User", LoadFactor: 1.5}}
+	balancer := newTestBalancer(t, config, map[string]int{"a": 1, "b": 1})
+
+	req := httptest.NewRequest(http.MethodGet, "http://foo.bar", nil)
+	req.Header.Set("X-User", "user")
+	acquired := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		server, _ := balancer.acquire(req)
+		acquired[server.url.Host]++
+	}
+	// each server takes at most 1.5 times its share of the requests in flight
+	for _, count := range acquired {
+		assert.True(t, count <= 8, "%v", acquired)
+	}
+	assert.Len(t, acquired, 2)
+}
+
+func TestBalancerWrr(t *testing.T) {
+	balancer := newTestBalancer(t, &types.LoadBalancer{Method: "wrr"}, map[string]int{"a": 3, "b": 1})
+
+	// each cycle of 4 requests is spread among the servers by weight
+	for cycle := 0; cycle < 3; cycle++ {
+		served := make(map[string]int)
+		for i := 0; i < 4; i++ {
+			recorder := httptest.NewRecorder()
+			balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+			served[recorder.Header().Get("server")]++
+		}
+		assert.Equal(t, map[string]int{"a": 3, "b": 1}, served)
+	}
+}
+
+func TestBalancerSlowStart(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		method   string
+		started  time.Duration
+		expected map[string]int
+	}{
+		{
+			desc:     "wrr, started",
+			method:   "wrr",
+			started:  -time.Hour,
+			expected: map[string]int{"a": 50, "b": 50},
+		},
+		{
+			desc:     "wrr, just started",
+			method:   "wrr",
+			started:  0,
+			expected: map[string]int{"a": 91, "b": 9},
+		},
+		{
+			desc:     "wrr, halfway",
+			method:   "wrr",
+			started:  -30 * time.Second,
+			expected: map[string]int{"a": 67, "b": 33},
+		},
+		{
+			desc:     "leastconn, halfway",
+			method:   "leastconn",
+			started:  -30 * time.Second,
+			expected: map[string]int{"a": 67, "b": 33},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &types.LoadBalancer{Method: test.method, SlowStart: flaeg.Duration(time.Minute)}
+			balancer := newTestBalancer(t, config, map[string]int{"a": 1, "b": 1})
+			balancer.SetStartTimes(map[string]time.Time{
+				"http://a": {},
+				"http://b": time.Now().Add(test.started),
+			})
+
+			// the requests stay in flight for the least connections
+			acquired := make(map[string]int)
+			for i := 0; i < 100; i++ {
+				server, _ := balancer.acquire(httptest.NewRequest(http.MethodGet, "http://foo.bar", nil))
+				acquired[server.url.Host]++
+			}
+			assert.InDelta(t, test.expected["a"], acquired["a"], 1)
+			assert.InDelta(t, test.expected["b"], acquired["b"], 1)
+		})
+	}
+}
+
+func TestBalancerSlowStartUpsert(t *testing.T) {
+	config := &types.LoadBalancer{Method: "wrr", SlowStart: flaeg.Duration(time.Minute)}
+	balancer := newTestBalancer(t, config, map[string]int{"a": 1})
+	balancer.SetStartTimes(map[string]time.Time{"http://a": {}})
+
+	// e.g. a server put back by its health check
+	require.NoError(t, balancer.UpsertServer(testhelpers.MustParseURL("http://b")))
+	assert.InDelta(t, 0.1, balancer.effectiveWeight(balancer.servers[1], time.Now()), 0.01)
+	assert.Equal(t, 1.0, balancer.effectiveWeight(balancer.servers[0], time.Now()))
+}
@@ -0,0 +1,254 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const defaultBodyRewriteMaxSize = 1 << 20
+
+var defaultBodyRewriteContentTypes = []string{"text/html", "application/json"}
+
+type bodyRewriteRule struct {
+	regex        *regexp.Regexp
+	replacement  []byte
+	contentTypes []string
+}
+
+// BodyRewrite is a middleware rewriting the request and response bodies of a frontend with regex replacements.
+// The rewrite isn't streamed: a body is buffered up to the maximum size before being rewritten,
+// and the larger bodies are passed untouched.
+type BodyRewrite struct {
+	requestRules  []bodyRewriteRule
+	responseRules []bodyRewriteRule
+	maxSize       int64
+}
+
+// NewBodyRewrite builds a new BodyRewrite from the rules of a frontend.
+func NewBodyRewrite(config *types.BodyRewrite) (*BodyRewrite, error) {
+	if config == nil || len(config.Rules) == 0 {
+		return nil, errors.New("no body rewrite rule")
+	}
+	if config.MaxSize < 0 {
+		return nil, fmt.Errorf("invalid maximum size %d", config.MaxSize)
+	}
+
+	b := &BodyRewrite{maxSize: config.MaxSize}
+	if b.maxSize == 0 {
+		b.maxSize = defaultBodyRewriteMaxSize
+	}
+
+	for _, rule := range config.Rules {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body rewrite regex %q: %v", rule.Regex, err)
+		}
+
+		contentTypes := defaultBodyRewriteContentTypes
+		if len(rule.ContentTypes) > 0 {
+			contentTypes = nil
+			for _, contentType := range rule.ContentTypes {
+				contentTypes = append(contentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+			}
+		}
+
+		compiled := bodyRewriteRule{regex: regex, replacement: []byte(rule.Replacement), contentTypes: contentTypes}
+		if rule.Request {
+			b.requestRules = append(b.requestRules, compiled)
+		} else {
+			b.responseRules = append(b.responseRules, compiled)
+		}
+	}
+
+	return b, nil
+}
+
+func (b *BodyRewrite) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if len(b.requestRules) > 0 && r.Body != nil {
+		b.rewriteRequest(r)
+	}
+
+	if len(b.responseRules) == 0 || r.Method == http.MethodHead {
+		next.ServeHTTP(rw, r)
+		return
+	}
+
+	// The response must not be compressed by the backend to be rewritten
+	r.Header.Del("Accept-Encoding")
+
+	brw := &bodyRewriteWriter{rw: rw, rewrite: b}
+	defer brw.close()
+	next.ServeHTTP(brw, r)
+}
+
+func (b *BodyRewrite) rewriteRequest(r *http.Request) {
+	rules := matchingRules(b.requestRules, r.Header.Get("Content-Type"))
+	if len(rules) == 0 || len(r.Header.Get("Content-Encoding")) > 0 {
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, b.maxSize+1))
+	if err != nil {
+		log.Debugf("Error reading the request body to rewrite: %v", err)
+		r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		return
+	}
+
+	if int64(len(body)) > b.maxSize {
+		log.Warnf("Request body larger than %d bytes, not rewritten", b.maxSize)
+		r.Body = &multiReadCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return
+	}
+	r.Body.Close()
+
+	body = applyRules(rules, body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// matchingRules returns the rules applying to a content type.
+func matchingRules(rules []bodyRewriteRule, contentType string) []bodyRewriteRule {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if len(mediaType) == 0 {
+		return nil
+	}
+
+	var matching []bodyRewriteRule
+	for _, rule := range rules {
+		if matchContentType(rule.contentTypes, mediaType) {
+			matching = append(matching, rule)
+		}
+	}
+	return matching
+}
+
+func applyRules(rules []bodyRewriteRule, body []byte) []byte {
+	for _, rule := range rules {
+		body = rule.regex.ReplaceAll(body, rule.replacement)
+	}
+	return body
+}
+
+// bodyRewriteWriter buffers a response to rewrite it once complete,
+// or passes it untouched as soon as it can't be rewritten.
+type bodyRewriteWriter struct {
+	rw         http.ResponseWriter
+	rewrite    *BodyRewrite
+	statusCode int
+	rules      []bodyRewriteRule
+	buf        []byte
+	decided    bool
+	passing    bool
+}
+
+func (w *bodyRewriteWriter) Header() http.Header {
+	return w.rw.Header()
+}
+
+func (w *bodyRewriteWriter) WriteHeader(code int) {
+	if w.decided {
+		return
+	}
+	w.statusCode = code
+	w.decide()
+}
+
+func (w *bodyRewriteWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.statusCode = http.StatusOK
+		w.decide()
+	}
+
+	if w.passing {
+		return w.rw.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if int64(len(w.buf)) > w.rewrite.maxSize {
+		log.Warnf("Response body larger than %d bytes, not rewritten", w.rewrite.maxSize)
+		w.pass()
+	}
+	return len(b), nil
+}
+
+// decide checks whether the response can be rewritten, once its headers are known.
+func (w *bodyRewriteWriter) decide() {
+	w.decided = true
+
+	switch {
+	case w.statusCode < http.StatusOK, w.statusCode == http.StatusNoContent, w.statusCode == http.StatusNotModified:
+	case len(w.Header().Get("Content-Encoding")) > 0:
+	default:
+		w.rules = matchingRules(w.rewrite.responseRules, w.Header().Get("Content-Type"))
+	}
+
+	if len(w.rules) == 0 {
+		w.passing = true
+		w.rw.WriteHeader(w.statusCode)
+	}
+}
+
+// pass writes the headers and the buffered body untouched, and passes the rest of the response.
+func (w *bodyRewriteWriter) pass() {
+	w.passing = true
+	w.rw.WriteHeader(w.statusCode)
+	w.rw.Write(w.buf)
+	w.buf = nil
+}
+
+// Flush is ignored while the response is buffered.
+func (w *bodyRewriteWriter) Flush() {
+	if !w.passing {
+		return
+	}
+	if flusher, ok := w.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (w *bodyRewriteWriter) CloseNotify() <-chan bool {
+	return w.rw.(http.CloseNotifier).CloseNotify()
+}
+
+func (w *bodyRewriteWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.rw.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.rw)
+}
+
+func (w *bodyRewriteWriter) close() {
+	if !w.decided || w.passing {
+		return
+	}
+
+	body := applyRules(w.rules, w.buf)
+	if !bytes.Equal(body, w.buf) {
+		// The validator of the backend doesn't match the rewritten body
+		w.Header().Del("ETag")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.rw.WriteHeader(w.statusCode)
+	w.rw.Write(body)
+}
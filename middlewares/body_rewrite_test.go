@@ -0,0 +1,272 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBodyRewrite(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.BodyRewrite
+		expectedError bool
+	}{
+		{
+			desc:   "valid rules",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{{Regex: "foo", Replacement: "bar"}}},
+		},
+		{
+			desc:          "nil configuration",
+			expectedError: true,
+		},
+		{
+			desc:          "no rule",
+			config:        &types.BodyRewrite{},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid regex",
+			config:        &types.BodyRewrite{Rules: []types.BodyRewriteRule{{Regex: "(foo"}}},
+			expectedError: true,
+		},
+		{
+			desc:          "negative maximum size",
+			config:        &types.BodyRewrite{Rules: []types.BodyRewriteRule{{Regex: "foo"}}, MaxSize: -1},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewBodyRewrite(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBodyRewriteResponse(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.BodyRewrite
+		contentType     string
+		contentEncoding string
+		statusCode      int
+		body            string
+		expectedBody    string
+		expectedETag    string
+	}{
+		{
+			desc: "rewrite HTML",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "http://backend.internal", Replacement: "https://foo.bar"},
+			}},
+			contentType:  "text/html; charset=utf-8",
+			body:         `<a href="http://backend.internal/page">page</a>`,
+			expectedBody: `<a href="https://foo.bar/page">page</a>`,
+		},
+		{
+			desc: "strip JSON field with capture groups",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: `,\s*"internal":"[^"]*"`, Replacement: ""},
+				{Regex: `"name":"(\w+)"`, Replacement: `"user":"$1"`},
+			}},
+			contentType:  "application/json",
+			body:         `{"name":"bob","internal":"secret"}`,
+			expectedBody: `{"user":"bob"}`,
+		},
+		{
+			desc: "content type wildcard",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar", ContentTypes: []string{"text/*"}},
+			}},
+			contentType:  "text/plain",
+			body:         "foo",
+			expectedBody: "bar",
+		},
+		{
+			desc: "content type not matching",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar"},
+			}},
+			contentType:  "image/png",
+			body:         "foo",
+			expectedBody: "foo",
+			expectedETag: `"etag"`,
+		},
+		{
+			desc: "larger than the maximum size",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar"},
+			}, MaxSize: 5},
+			contentType:  "text/html",
+			body:         "foofoo",
+			expectedBody: "foofoo",
+			expectedETag: `"etag"`,
+		},
+		{
+			desc: "encoded response",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar"},
+			}},
+			contentType:     "text/html",
+			contentEncoding: "gzip",
+			body:            "foo",
+			expectedBody:    "foo",
+			expectedETag:    `"etag"`,
+		},
+		{
+			desc: "request rules only",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar", Request: true},
+			}},
+			contentType:  "text/html",
+			body:         "foo",
+			expectedBody: "foo",
+			expectedETag: `"etag"`,
+		},
+		{
+			desc: "rewrite error page",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar"},
+			}},
+			contentType:  "text/html",
+			statusCode:   http.StatusNotFound,
+			body:         "foo",
+			expectedBody: "bar",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			bodyRewrite, err := NewBodyRewrite(test.config)
+			require.NoError(t, err)
+
+			var acceptEncoding string
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				rw.Header().Set("Content-Type", test.contentType)
+				rw.Header().Set("ETag", `"etag"`)
+				if len(test.contentEncoding) > 0 {
+					rw.Header().Set("Content-Encoding", test.contentEncoding)
+				}
+				if test.statusCode != 0 {
+					rw.WriteHeader(test.statusCode)
+				}
+				// Written in two parts to check the buffering
+				rw.Write([]byte(test.body[:len(test.body)/2]))
+				rw.Write([]byte(test.body[len(test.body)/2:]))
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			recorder := httptest.NewRecorder()
+			bodyRewrite.ServeHTTP(recorder, req, next)
+
+			expectedStatusCode := test.statusCode
+			if expectedStatusCode == 0 {
+				expectedStatusCode = http.StatusOK
+			}
+			assert.Equal(t, expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			assert.Equal(t, test.expectedETag, recorder.Header().Get("ETag"))
+			if len(bodyRewrite.responseRules) > 0 {
+				assert.Empty(t, acceptEncoding)
+			}
+			if test.body != test.expectedBody {
+				assert.Equal(t, strconv.Itoa(len(test.expectedBody)), recorder.Header().Get("Content-Length"))
+			}
+		})
+	}
+}
+
+func TestBodyRewriteRequest(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		config       *types.BodyRewrite
+		contentType  string
+		body         string
+		expectedBody string
+	}{
+		{
+			desc: "rewrite JSON",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "https://foo.bar", Replacement: "http://backend.internal", Request: true},
+			}},
+			contentType:  "application/json",
+			body:         `{"url":"https://foo.bar/page"}`,
+			expectedBody: `{"url":"http://backend.internal/page"}`,
+		},
+		{
+			desc: "content type not matching",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar", Request: true},
+			}},
+			contentType:  "application/octet-stream",
+			body:         "foo",
+			expectedBody: "foo",
+		},
+		{
+			desc: "larger than the maximum size",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar", Request: true},
+			}, MaxSize: 5},
+			contentType:  "application/json",
+			body:         "foofoo",
+			expectedBody: "foofoo",
+		},
+		{
+			desc: "response rules only",
+			config: &types.BodyRewrite{Rules: []types.BodyRewriteRule{
+				{Regex: "foo", Replacement: "bar"},
+			}},
+			contentType:  "application/json",
+			body:         "foo",
+			expectedBody: "foo",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			bodyRewrite, err := NewBodyRewrite(test.config)
+			require.NoError(t, err)
+
+			var body string
+			var contentLength int64
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				content, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				body = string(content)
+				contentLength = r.ContentLength
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodPost, "http://foo.bar/", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			bodyRewrite.ServeHTTP(httptest.NewRecorder(), req, next)
+
+			assert.Equal(t, test.expectedBody, body)
+			assert.EqualValues(t, len(test.expectedBody), contentLength)
+		})
+	}
+}
@@ -0,0 +1,581 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultCacheMaxSize      = 10 << 20
+	defaultCacheMaxEntrySize = 1 << 20
+	defaultCacheKey          = "{{.Method}} {{.Host}}{{.Path}}?{{.Query}}"
+	cacheStatusHeader        = "X-Cache"
+)
+
+// cacheableStatusCodes are the status codes cacheable by default, cf RFC 7231 section 6.1
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// ResponseCache is a middleware caching the responses to the GET requests of a frontend, honoring their Cache-Control
+// and Vary headers. The least recently used responses are evicted once the cache is full.
+type ResponseCache struct {
+	config       types.Cache
+	key          *template.Template
+	maxSize      int64
+	maxEntrySize int64
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	varies  map[string][]string
+	lru     *list.List
+	size    int64
+	hits    int64
+	misses  int64
+}
+
+// ResponseCacheStats holds the current state of a response cache.
+type ResponseCacheStats struct {
+	Entries int   `json:"entries"`
+	Size    int64 `json:"size"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+type cacheEntry struct {
+	key      string
+	path     string
+	status   int
+	header   http.Header
+	body     []byte
+	file     string
+	size     int64
+	storedAt time.Time
+	expires  time.Time
+	element  *list.Element
+}
+
+// cacheKeyData holds the variables of the cache key template.
+type cacheKeyData struct {
+	Method string
+	Host   string
+	Path   string
+	Query  string
+	req    *http.Request
+}
+
+// Header returns the value of a header of the request.
+func (d cacheKeyData) Header(name string) string {
+	return d.req.Header.Get(name)
+}
+
+// NewResponseCache builds a new ResponseCache from the configuration.
+func NewResponseCache(config *types.Cache) (*ResponseCache, error) {
+	c := &ResponseCache{
+		config:       *config,
+		maxSize:      config.MaxSize,
+		maxEntrySize: config.MaxEntrySize,
+		entries:      make(map[string]*cacheEntry),
+		varies:       make(map[string][]string),
+		lru:          list.New(),
+	}
+	if c.maxSize <= 0 {
+		c.maxSize = defaultCacheMaxSize
+	}
+	if c.maxEntrySize <= 0 || c.maxEntrySize > c.maxSize {
+		c.maxEntrySize = defaultCacheMaxEntrySize
+		if c.maxEntrySize > c.maxSize {
+			c.maxEntrySize = c.maxSize
+		}
+	}
+
+	keyTemplate := config.Key
+	if len(keyTemplate) == 0 {
+		keyTemplate = defaultCacheKey
+	}
+	var err error
+	c.key, err = template.New("cacheKey").Parse(keyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache key template: %v", err)
+	}
+
+	if len(config.Directory) > 0 {
+		if err := os.MkdirAll(config.Directory, 0700); err != nil {
+			return nil, fmt.Errorf("unable to create cache directory: %v", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *ResponseCache) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	if req.Method != http.MethodGet {
+		next(rw, req)
+		return
+	}
+
+	primaryKey, err := c.primaryKey(req)
+	if err != nil {
+		log.Errorf("Error building the cache key of %s: %v", req.URL, err)
+		next(rw, req)
+		return
+	}
+
+	reqCacheControl := parseCacheControl(req.Header)
+	if _, noCache := reqCacheControl["no-cache"]; !noCache {
+		if entry, body := c.lookup(primaryKey, req); entry != nil {
+			c.serve(rw, req, entry, body)
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.misses++
+	c.mu.Unlock()
+
+	if _, noStore := reqCacheControl["no-store"]; noStore {
+		next(rw, req)
+		return
+	}
+
+	rw.Header().Set(cacheStatusHeader, "MISS")
+	cw := &cacheWriter{ResponseWriter: rw, cache: c, req: req}
+	next(cw, req)
+
+	if cw.recording {
+		c.store(primaryKey, req, cw.status, cw.storedHeader, cw.body.Bytes(), cw.ttl)
+	}
+}
+
+func (c *ResponseCache) primaryKey(req *http.Request) (string, error) {
+	var key bytes.Buffer
+	err := c.key.Execute(&key, cacheKeyData{
+		Method: req.Method,
+		Host:   req.Host,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		req:    req,
+	})
+	return key.String(), err
+}
+
+// secondaryKey completes the primary key with the values of the request headers of the Vary header of the response.
+func secondaryKey(primaryKey string, varyHeaders []string, req *http.Request) string {
+	key := primaryKey
+	for _, name := range varyHeaders {
+		key += "\x00" + name + "=" + strings.Join(req.Header[name], ",")
+	}
+	return key
+}
+
+// lookup returns the fresh entry of the request, if any, with its body.
+func (c *ResponseCache) lookup(primaryKey string, req *http.Request) (*cacheEntry, []byte) {
+	c.mu.Lock()
+	varyHeaders, ok := c.varies[primaryKey]
+	if !ok {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	entry, ok := c.entries[secondaryKey(primaryKey, varyHeaders, req)]
+	if !ok {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	if time.Now().After(entry.expires) {
+		c.evict(entry)
+		c.mu.Unlock()
+		return nil, nil
+	}
+	c.lru.MoveToFront(entry.element)
+	c.mu.Unlock()
+
+	body := entry.body
+	if len(entry.file) > 0 {
+		var err error
+		body, err = ioutil.ReadFile(entry.file)
+		if err != nil {
+			// the entry may have been evicted meanwhile
+			log.Debugf("Unable to read cached response %s: %v", entry.file, err)
+			c.mu.Lock()
+			c.evict(entry)
+			c.mu.Unlock()
+			return nil, nil
+		}
+	}
+
+	c.mu.Lock()
+	c.hits++
+	c.mu.Unlock()
+	return entry, body
+}
+
+func (c *ResponseCache) serve(rw http.ResponseWriter, req *http.Request, entry *cacheEntry, body []byte) {
+	for name, values := range entry.header {
+		rw.Header()[name] = append([]string{}, values...)
+	}
+	rw.Header().Set("Age", strconv.Itoa(int(time.Since(entry.storedAt).Seconds())))
+	rw.Header().Set(cacheStatusHeader, "HIT")
+	rw.WriteHeader(entry.status)
+	rw.Write(body)
+}
+
+func (c *ResponseCache) store(primaryKey string, req *http.Request, status int, header http.Header, body []byte, ttl time.Duration) {
+	varyHeaders := varyHeaderNames(header)
+	entry := &cacheEntry{
+		key:      secondaryKey(primaryKey, varyHeaders, req),
+		path:     req.URL.Path,
+		status:   status,
+		header:   header,
+		storedAt: time.Now(),
+		expires:  time.Now().Add(ttl),
+	}
+	_, headerSize := headerCountAndSize(header)
+	entry.size = int64(len(body) + headerSize)
+	if entry.size > c.maxEntrySize {
+		return
+	}
+
+	if len(c.config.Directory) > 0 {
+		sum := sha256.Sum256([]byte(entry.key))
+		entry.file = filepath.Join(c.config.Directory, fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), entry.storedAt.UnixNano()))
+		if err := ioutil.WriteFile(entry.file, body, 0600); err != nil {
+			log.Errorf("Unable to write cached response %s: %v", entry.file, err)
+			return
+		}
+	} else {
+		entry.body = body
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if previous, ok := c.entries[entry.key]; ok {
+		c.evict(previous)
+	}
+	if previousVary, ok := c.varies[primaryKey]; ok && !reflect.DeepEqual(previousVary, varyHeaders) {
+		// the responses varying on other headers can't be found anymore
+		c.evictPrimaryKey(primaryKey)
+	}
+
+	c.varies[primaryKey] = varyHeaders
+	c.entries[entry.key] = entry
+	entry.element = c.lru.PushFront(entry)
+	c.size += entry.size
+
+	for c.size > c.maxSize {
+		c.evict(c.lru.Back().Value.(*cacheEntry))
+	}
+}
+
+// evict must be called with the lock held.
+func (c *ResponseCache) evict(entry *cacheEntry) {
+	if c.entries[entry.key] != entry {
+		return
+	}
+	delete(c.entries, entry.key)
+	c.lru.Remove(entry.element)
+	c.size -= entry.size
+	if len(entry.file) > 0 {
+		if err := os.Remove(entry.file); err != nil && !os.IsNotExist(err) {
+			log.Errorf("Unable to remove cached response %s: %v", entry.file, err)
+		}
+	}
+}
+
+// evictPrimaryKey must be called with the lock held.
+func (c *ResponseCache) evictPrimaryKey(primaryKey string) {
+	for key, entry := range c.entries {
+		if key == primaryKey || strings.HasPrefix(key, primaryKey+"\x00") {
+			c.evict(entry)
+		}
+	}
+	delete(c.varies, primaryKey)
+}
+
+// Purge removes the responses whose path starts with the prefix, and returns their number.
+func (c *ResponseCache) Purge(pathPrefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var purged int
+	for _, entry := range c.entries {
+		if strings.HasPrefix(entry.path, pathPrefix) {
+			c.evict(entry)
+			purged++
+		}
+	}
+	return purged
+}
+
+// Stats returns the current state of the cache.
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ResponseCacheStats{
+		Entries: len(c.entries),
+		Size:    c.size,
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+}
+
+// cacheTTL returns how long the response can be cached, or 0 if it isn't cacheable.
+func (c *ResponseCache) cacheTTL(req *http.Request, status int, header http.Header) time.Duration {
+	if !cacheableStatusCodes[status] || len(header["Set-Cookie"]) > 0 {
+		return 0
+	}
+	for _, name := range varyHeaderNames(header) {
+		if name == "*" {
+			return 0
+		}
+	}
+
+	cacheControl := parseCacheControl(header)
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := cacheControl[directive]; ok {
+			return 0
+		}
+	}
+	_, public := cacheControl["public"]
+	sMaxAge, shared := cacheControl["s-maxage"]
+	if len(req.Header.Get("Authorization")) > 0 && !public && !shared {
+		return 0
+	}
+
+	if c.config.TTL > 0 {
+		return time.Duration(c.config.TTL)
+	}
+	if shared {
+		return parseSeconds(sMaxAge)
+	}
+	if maxAge, ok := cacheControl["max-age"]; ok {
+		return parseSeconds(maxAge)
+	}
+	if expires := header.Get("Expires"); len(expires) > 0 {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		now := time.Now()
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+		return expiresAt.Sub(now)
+	}
+	return time.Duration(c.config.DefaultTTL)
+}
+
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// parseCacheControl returns the directives of the Cache-Control header, with their value if any.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if len(directive) == 0 {
+				continue
+			}
+			name := directive
+			var arg string
+			if i := strings.Index(directive, "="); i >= 0 {
+				name = directive[:i]
+				arg = strings.Trim(directive[i+1:], `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	return directives
+}
+
+// varyHeaderNames returns the sorted canonical names of the Vary header.
+func varyHeaderNames(header http.Header) []string {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) > 0 {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cacheWriter records the cacheable responses while writing them.
+type cacheWriter struct {
+	http.ResponseWriter
+	cache        *ResponseCache
+	req          *http.Request
+	wroteHeader  bool
+	recording    bool
+	status       int
+	ttl          time.Duration
+	storedHeader http.Header
+	body         bytes.Buffer
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+
+	header := w.ResponseWriter.Header()
+	w.ttl = w.cache.cacheTTL(w.req, code, header)
+	if w.ttl > 0 {
+		if contentLength, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err != nil || contentLength <= w.cache.maxEntrySize {
+			w.recording = true
+			w.storedHeader = make(http.Header, len(header))
+			for name, values := range header {
+				if name != cacheStatusHeader {
+					w.storedHeader[name] = append([]string{}, values...)
+				}
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.recording {
+		if int64(w.body.Len()+len(b)) > w.cache.maxEntrySize {
+			w.recording = false
+			w.body = bytes.Buffer{}
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (w *cacheWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (w *cacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.recording = false
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.ResponseWriter)
+}
+
+// ResponseCaches holds the response caches of the frontends, kept across the configuration reloads.
+type ResponseCaches struct {
+	mutex  sync.RWMutex
+	caches map[string]*ResponseCache
+}
+
+// NewResponseCaches returns an empty set of response caches.
+func NewResponseCaches() *ResponseCaches {
+	return &ResponseCaches{caches: make(map[string]*ResponseCache)}
+}
+
+// Get returns the response cache of a frontend, reusing the current cache if its configuration didn't change.
+func (r *ResponseCaches) Get(frontendName string, config *types.Cache) (*ResponseCache, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if cache, ok := r.caches[frontendName]; ok && reflect.DeepEqual(cache.config, *config) {
+		return cache, nil
+	}
+
+	cache, err := NewResponseCache(config)
+	if err != nil {
+		return nil, err
+	}
+	if previous, ok := r.caches[frontendName]; ok {
+		previous.Purge("")
+	}
+	r.caches[frontendName] = cache
+	return cache, nil
+}
+
+// Retain removes the caches of the frontends which aren't in the configuration anymore.
+func (r *ResponseCaches) Retain(frontendNames map[string]bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for frontendName, cache := range r.caches {
+		if !frontendNames[frontendName] {
+			cache.Purge("")
+			delete(r.caches, frontendName)
+		}
+	}
+}
+
+// Purge removes the responses of a frontend whose path starts with the prefix, and returns their number,
+// and whether the frontend has a cache.
+func (r *ResponseCaches) Purge(frontendName string, pathPrefix string) (int, bool) {
+	r.mutex.RLock()
+	cache, ok := r.caches[frontendName]
+	r.mutex.RUnlock()
+	if !ok {
+		return 0, false
+	}
+	return cache.Purge(pathPrefix), true
+}
+
+// Data returns the current state of the caches, by frontend.
+func (r *ResponseCaches) Data() map[string]ResponseCacheStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	data := make(map[string]ResponseCacheStats, len(r.caches))
+	for frontendName, cache := range r.caches {
+		data[frontendName] = cache.Stats()
+	}
+	return data
+}
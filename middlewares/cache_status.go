@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/metrics"
+)
+
+const (
+	// CacheStatusHit is reported when the response was served from an upstream cache.
+	CacheStatusHit = "hit"
+	// CacheStatusMiss is reported when the upstream cache had to go to its origin.
+	CacheStatusMiss = "miss"
+	// CacheStatusUnknown is reported when none of the configured headers is present.
+	CacheStatusUnknown = "unknown"
+)
+
+// CacheStatus is a Negroni compatible Handler which reads the cache status
+// headers set by an upstream cache (e.g. a CDN) on the response, and reports
+// it in the metrics.
+type CacheStatus struct {
+	headers     []string
+	registry    metrics.Registry
+	serviceName string
+}
+
+// NewCacheStatus returns a CacheStatus middleware for the given headers.
+func NewCacheStatus(headers []string, registry metrics.Registry, service string) *CacheStatus {
+	return &CacheStatus{
+		headers:     headers,
+		registry:    registry,
+		serviceName: service,
+	}
+}
+
+func (c *CacheStatus) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(rw, r)
+
+	status := GetCacheStatus(rw.Header(), c.headers)
+	c.registry.CacheRequestsCounter().With("service", c.serviceName, "status", status).Add(1)
+}
+
+// GetCacheStatus returns the cache status found in the first header present in the response.
+// When a cache chain appends several values (e.g. "MISS, HIT"), the last one, i.e. the one
+// of the cache closest to Traefik, wins.
+func GetCacheStatus(header http.Header, names []string) string {
+	for _, name := range names {
+		value := header.Get(name)
+		if len(value) == 0 {
+			continue
+		}
+
+		values := strings.Split(value, ",")
+		return parseCacheStatus(values[len(values)-1])
+	}
+	return CacheStatusUnknown
+}
+
+func parseCacheStatus(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	// e.g. "HIT", "TCP_HIT", "Hit from cloudfront", "STALE", "UPDATING", "REVALIDATED"
+	for _, hit := range []string{"HIT", "STALE", "UPDATING", "REVALIDATED"} {
+		if strings.Contains(value, hit) {
+			return CacheStatusHit
+		}
+	}
+	return CacheStatusMiss
+}
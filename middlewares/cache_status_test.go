@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCacheStatus(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		headers  map[string]string
+		expected string
+	}{
+		{
+			desc:     "no header",
+			expected: CacheStatusUnknown,
+		},
+		{
+			desc:     "cloudflare hit",
+			headers:  map[string]string{"CF-Cache-Status": "HIT"},
+			expected: CacheStatusHit,
+		},
+		{
+			desc:     "cloudflare expired",
+			headers:  map[string]string{"CF-Cache-Status": "EXPIRED"},
+			expected: CacheStatusMiss,
+		},
+		{
+			desc:     "cloudflare stale",
+			headers:  map[string]string{"CF-Cache-Status": "STALE"},
+			expected: CacheStatusHit,
+		},
+		{
+			desc:     "cloudfront miss",
+			headers:  map[string]string{"X-Cache": "Miss from cloudfront"},
+			expected: CacheStatusMiss,
+		},
+		{
+			desc:     "squid hit",
+			headers:  map[string]string{"X-Cache": "TCP_HIT"},
+			expected: CacheStatusHit,
+		},
+		{
+			desc:     "cache chain uses last value",
+			headers:  map[string]string{"X-Cache": "MISS, HIT"},
+			expected: CacheStatusHit,
+		},
+		{
+			desc:     "first configured header wins",
+			headers:  map[string]string{"CF-Cache-Status": "BYPASS", "X-Cache": "HIT"},
+			expected: CacheStatusMiss,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			for k, v := range test.headers {
+				header.Set(k, v)
+			}
+
+			assert.Equal(t, test.expected, GetCacheStatus(header, []string{"CF-Cache-Status", "X-Cache"}))
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResponseCache(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        types.Cache
+		expectedError bool
+	}{
+		{
+			desc: "defaults",
+		},
+		{
+			desc:   "key template",
+			config: types.Cache{Key: `{{.Host}}{{.Path}} {{.Header "Accept-Language"}}`},
+		},
+		{
+			desc:          "invalid key template",
+			config:        types.Cache{Key: "{{.Path"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewResponseCache(&test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         types.Cache
+		method         string
+		requestHeader  http.Header
+		responseStatus int
+		responseHeader http.Header
+		responseBody   string
+		expectedCached bool
+	}{
+		{
+			desc:           "max-age",
+			responseHeader: http.Header{"Cache-Control": {"public, max-age=60"}},
+			expectedCached: true,
+		},
+		{
+			desc:           "s-maxage",
+			responseHeader: http.Header{"Cache-Control": {"max-age=0, s-maxage=60"}},
+			expectedCached: true,
+		},
+		{
+			desc:           "expires",
+			responseHeader: http.Header{"Expires": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}},
+			expectedCached: true,
+		},
+		{
+			desc:           "expired",
+			responseHeader: http.Header{"Expires": {time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}},
+		},
+		{
+			desc: "no freshness information",
+		},
+		{
+			desc:           "default TTL",
+			config:         types.Cache{DefaultTTL: flaeg.Duration(time.Minute)},
+			expectedCached: true,
+		},
+		{
+			desc:           "TTL override",
+			config:         types.Cache{TTL: flaeg.Duration(time.Minute)},
+			responseHeader: http.Header{"Cache-Control": {"max-age=0"}},
+			expectedCached: true,
+		},
+		{
+			desc:           "TTL override of a private response",
+			config:         types.Cache{TTL: flaeg.Duration(time.Minute)},
+			responseHeader: http.Header{"Cache-Control": {"private"}},
+		},
+		{
+			desc:           "no-store response",
+			responseHeader: http.Header{"Cache-Control": {"no-store"}},
+		},
+		{
+			desc:           "no-store request",
+			requestHeader:  http.Header{"Cache-Control": {"no-store"}},
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			desc:           "POST request",
+			method:         http.MethodPost,
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			desc:           "uncacheable status",
+			responseStatus: http.StatusInternalServerError,
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			desc:           "cookie",
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}, "Set-Cookie": {"session=foo"}},
+		},
+		{
+			desc:           "authorization",
+			requestHeader:  http.Header{"Authorization": {"Bearer foo"}},
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			desc:           "public authorization",
+			requestHeader:  http.Header{"Authorization": {"Bearer foo"}},
+			responseHeader: http.Header{"Cache-Control": {"public, max-age=60"}},
+			expectedCached: true,
+		},
+		{
+			desc:           "vary all",
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+		},
+		{
+			desc:           "too large",
+			config:         types.Cache{MaxEntrySize: 32},
+			responseHeader: http.Header{"Cache-Control": {"max-age=60"}},
+			responseBody:   "a body larger than the maximum size of an entry",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cache, err := NewResponseCache(&test.config)
+			require.NoError(t, err)
+
+			var calls int
+			next := func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				for name, values := range test.responseHeader {
+					rw.Header()[name] = values
+				}
+				if test.responseStatus != 0 {
+					rw.WriteHeader(test.responseStatus)
+				}
+				body := test.responseBody
+				if len(body) == 0 {
+					body = "backend"
+				}
+				rw.Write([]byte(body))
+			}
+
+			method := test.method
+			if len(method) == 0 {
+				method = http.MethodGet
+			}
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(method, "http://foo.bar/data", nil)
+				for name, values := range test.requestHeader {
+					req.Header[name] = values
+				}
+				recorder := httptest.NewRecorder()
+				cache.ServeHTTP(recorder, req, next)
+			}
+
+			if test.expectedCached {
+				assert.Equal(t, 1, calls)
+			} else {
+				assert.Equal(t, 2, calls)
+			}
+		})
+	}
+}
+
+func TestResponseCacheHit(t *testing.T) {
+	cache, err := NewResponseCache(&types.Cache{})
+	require.NoError(t, err)
+
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte(`{"path":"` + req.URL.Path + `"}`))
+	}
+
+	recorder := httptest.NewRecorder()
+	cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar/data", nil), next)
+	assert.Equal(t, "MISS", recorder.Header().Get(cacheStatusHeader))
+
+	recorder = httptest.NewRecorder()
+	cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar/data", nil), next)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "HIT", recorder.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "0", recorder.Header().Get("Age"))
+	assert.Equal(t, `{"path":"/data"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://foo.bar/data", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	cache.ServeHTTP(recorder, req, next)
+	assert.Equal(t, "MISS", recorder.Header().Get(cacheStatusHeader))
+
+	assert.Equal(t, ResponseCacheStats{Entries: 1, Size: 75, Hits: 1, Misses: 2}, cache.Stats())
+}
+
+func TestResponseCacheVary(t *testing.T) {
+	cache, err := NewResponseCache(&types.Cache{})
+	require.NoError(t, err)
+
+	var calls int
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("Vary", "Accept-Language")
+		rw.Write([]byte(req.Header.Get("Accept-Language")))
+	}
+
+	for _, language := range []string{"en", "fr", "en", "fr"} {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://foo.bar/data", nil)
+		req.Header.Set("Accept-Language", language)
+		cache.ServeHTTP(recorder, req, next)
+		assert.Equal(t, language, recorder.Body.String())
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	cache, err := NewResponseCache(&types.Cache{Key: "{{.Path}}"})
+	require.NoError(t, err)
+
+	var calls int
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}
+
+	for _, url := range []string{"http://foo.bar/data?page=1", "http://foo.bar/data?page=2", "http://bar.foo/data"} {
+		cache.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil), next)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	// each response takes 34 bytes
+	cache, err := NewResponseCache(&types.Cache{MaxSize: 80})
+	require.NoError(t, err)
+
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}
+
+	for _, path := range []string{"/a", "/b", "/a", "/c"} {
+		cache.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://foo.bar"+path, nil), next)
+	}
+
+	assert.Equal(t, 2, cache.Stats().Entries)
+	assert.Equal(t, 1, cache.Purge("/a"))
+	assert.Equal(t, 0, cache.Purge("/b"))
+	assert.Equal(t, 1, cache.Purge("/c"))
+}
+
+func TestResponseCacheDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	cache, err := NewResponseCache(&types.Cache{Directory: directory})
+	require.NoError(t, err)
+
+	var calls int
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar/data", nil), next)
+		assert.Equal(t, "backend", recorder.Body.String())
+	}
+	assert.Equal(t, 1, calls)
+
+	files, err := ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	cache.Purge("")
+	files, err = ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
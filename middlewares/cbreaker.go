@@ -1,14 +1,23 @@
 package middlewares
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
 	"github.com/vulcand/oxy/cbreaker"
+	"github.com/vulcand/oxy/utils"
 )
 
+const defaultCircuitBreakerOpenDuration = 10 * time.Second
+
 // CircuitBreaker holds the oxy circuit breaker.
 type CircuitBreaker struct {
-	circuitBreaker *cbreaker.CircuitBreaker
+	circuitBreaker http.Handler
 }
 
 // NewCircuitBreaker returns a new CircuitBreaker.
@@ -20,6 +29,253 @@ func NewCircuitBreaker(next http.Handler, expression string, options ...cbreaker
 	return &CircuitBreaker{circuitBreaker}, nil
 }
 
+// NewCircuitBreakerFromConfig returns a new CircuitBreaker of a backend, probing the backend in the half-open state
+// if the configuration has half-open requests.
+func NewCircuitBreakerFromConfig(next http.Handler, backendName string, config *types.CircuitBreaker) (*CircuitBreaker, error) {
+	fallback, err := newCircuitBreakerFallback(config.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	circuitBreaker, err := newBreaker(next, "backend "+backendName, config, fallback)
+	if err != nil {
+		return nil, err
+	}
+	return &CircuitBreaker{circuitBreaker}, nil
+}
+
 func (cb *CircuitBreaker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	cb.circuitBreaker.ServeHTTP(rw, r)
 }
+
+// ServerCircuitBreakers holds a circuit breaker per server of a backend, called once the load balancer has chosen the server.
+// The requests to a server whose circuit is open are recorded as network errors, to be retried on another server.
+type ServerCircuitBreakers struct {
+	next        http.Handler
+	backendName string
+	config      *types.CircuitBreaker
+	fallback    http.Handler
+
+	mu       sync.Mutex
+	breakers map[string]http.Handler
+}
+
+// NewServerCircuitBreakers returns new ServerCircuitBreakers for the servers of a backend.
+func NewServerCircuitBreakers(next http.Handler, backendName string, config *types.CircuitBreaker) (*ServerCircuitBreakers, error) {
+	fallback, err := newCircuitBreakerFallback(config.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	// validates the expression
+	if _, err := cbreaker.New(next, config.Expression); err != nil {
+		return nil, err
+	}
+
+	return &ServerCircuitBreakers{
+		next:        next,
+		backendName: backendName,
+		config:      config,
+		fallback: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			DefaultNetErrorRecorder{}.Record(req.Context())
+			fallback.ServeHTTP(rw, req)
+		}),
+		breakers: make(map[string]http.Handler),
+	}, nil
+}
+
+func (s *ServerCircuitBreakers) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	breaker, err := s.getBreaker(req.URL.Host)
+	if err != nil {
+		log.Errorf("Error creating circuit breaker of server %s: %v", req.URL.Host, err)
+		s.next.ServeHTTP(rw, req)
+		return
+	}
+	breaker.ServeHTTP(rw, req)
+}
+
+func (s *ServerCircuitBreakers) getBreaker(server string) (http.Handler, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if breaker, ok := s.breakers[server]; ok {
+		return breaker, nil
+	}
+	breaker, err := newBreaker(s.next, fmt.Sprintf("server %s of backend %s", server, s.backendName), s.config, s.fallback)
+	if err != nil {
+		return nil, err
+	}
+	s.breakers[server] = breaker
+	return breaker, nil
+}
+
+func newBreaker(next http.Handler, name string, config *types.CircuitBreaker, fallback http.Handler) (http.Handler, error) {
+	openDuration := time.Duration(config.OpenDuration)
+	if openDuration <= 0 {
+		openDuration = defaultCircuitBreakerOpenDuration
+	}
+
+	if config.HalfOpenRequests <= 0 {
+		return cbreaker.New(next, config.Expression, cbreaker.FallbackDuration(openDuration), cbreaker.Fallback(fallback))
+	}
+	return newHalfOpenBreaker(next, name, config.Expression, openDuration, config.HalfOpenRequests, fallback)
+}
+
+func newCircuitBreakerFallback(config *types.CircuitBreakerFallback) (http.Handler, error) {
+	statusCode := http.StatusServiceUnavailable
+	contentType := "text/plain; charset=utf-8"
+	var body string
+	if config != nil {
+		if config.StatusCode != 0 {
+			if config.StatusCode < 200 || config.StatusCode > 599 {
+				return nil, fmt.Errorf("invalid circuit breaker fallback status code %d", config.StatusCode)
+			}
+			statusCode = config.StatusCode
+		}
+		if len(config.ContentType) > 0 {
+			contentType = config.ContentType
+		}
+		body = config.Body
+	}
+	if len(body) == 0 {
+		body = http.StatusText(statusCode)
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", contentType)
+		rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		rw.WriteHeader(statusCode)
+		if req.Method != http.MethodHead {
+			rw.Write([]byte(body))
+		}
+	}), nil
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// halfOpenBreaker opens its circuit when the oxy circuit breaker trips, and once the circuit has been open
+// for the open duration, lets a limited number of requests probe the backend: the circuit closes when they all succeed,
+// and opens again as soon as one fails.
+type halfOpenBreaker struct {
+	next         http.Handler
+	name         string
+	expression   string
+	openDuration time.Duration
+	probes       int
+	fallback     http.Handler
+
+	mu        sync.Mutex
+	state     breakerState
+	closed    *cbreaker.CircuitBreaker
+	openUntil time.Time
+	inFlight  int
+	successes int
+}
+
+func newHalfOpenBreaker(next http.Handler, name string, expression string, openDuration time.Duration, probes int, fallback http.Handler) (*halfOpenBreaker, error) {
+	b := &halfOpenBreaker{
+		next:         next,
+		name:         name,
+		expression:   expression,
+		openDuration: openDuration,
+		probes:       probes,
+		fallback:     fallback,
+	}
+
+	var err error
+	b.closed, err = b.newClosedBreaker()
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// newClosedBreaker returns an oxy circuit breaker with fresh metrics, calling tripped as fallback.
+func (b *halfOpenBreaker) newClosedBreaker() (*cbreaker.CircuitBreaker, error) {
+	return cbreaker.New(b.next, b.expression, cbreaker.Fallback(http.HandlerFunc(b.tripped)))
+}
+
+func (b *halfOpenBreaker) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	b.mu.Lock()
+	if b.state == breakerOpen && !time.Now().Before(b.openUntil) {
+		log.Infof("Circuit breaker of %s half-open", b.name)
+		b.state = breakerHalfOpen
+		b.inFlight = 0
+		b.successes = 0
+	}
+
+	switch b.state {
+	case breakerClosed:
+		closed := b.closed
+		b.mu.Unlock()
+		closed.ServeHTTP(rw, req)
+	case breakerHalfOpen:
+		if b.inFlight+b.successes >= b.probes {
+			b.mu.Unlock()
+			b.fallback.ServeHTTP(rw, req)
+			return
+		}
+		b.inFlight++
+		b.mu.Unlock()
+
+		pw := &utils.ProxyWriter{W: rw}
+		b.next.ServeHTTP(pw, req)
+		b.recordProbe(pw.StatusCode() < http.StatusInternalServerError)
+	default:
+		b.mu.Unlock()
+		b.fallback.ServeHTTP(rw, req)
+	}
+}
+
+// tripped is called by the oxy circuit breaker once its expression matches.
+func (b *halfOpenBreaker) tripped(rw http.ResponseWriter, req *http.Request) {
+	b.mu.Lock()
+	if b.state == breakerClosed {
+		log.Warnf("Circuit breaker of %s open", b.name)
+		b.open()
+	}
+	b.mu.Unlock()
+
+	b.fallback.ServeHTTP(rw, req)
+}
+
+func (b *halfOpenBreaker) recordProbe(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerHalfOpen {
+		return
+	}
+	b.inFlight--
+
+	if !success {
+		log.Warnf("Circuit breaker of %s open again after a failed probe", b.name)
+		b.open()
+		return
+	}
+
+	b.successes++
+	if b.successes < b.probes {
+		return
+	}
+
+	closed, err := b.newClosedBreaker()
+	if err != nil {
+		log.Errorf("Error closing circuit breaker of %s: %v", b.name, err)
+		b.open()
+		return
+	}
+	log.Infof("Circuit breaker of %s closed", b.name)
+	b.closed = closed
+	b.state = breakerClosed
+}
+
+// open must be called with the lock held.
+func (b *halfOpenBreaker) open() {
+	b.state = breakerOpen
+	b.openUntil = time.Now().Add(b.openDuration)
+}
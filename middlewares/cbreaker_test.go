@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testExpression = "NetworkErrorRatio() > 0.5"
+
+func TestCircuitBreakerFallback(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		config              *types.CircuitBreakerFallback
+		expectedStatusCode  int
+		expectedBody        string
+		expectedContentType string
+		expectedError       bool
+	}{
+		{
+			desc:                "default fallback",
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "Service Unavailable",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:                "custom fallback",
+			config:              &types.CircuitBreakerFallback{StatusCode: http.StatusOK, Body: `{"items":[]}`, ContentType: "application/json"},
+			expectedStatusCode:  http.StatusOK,
+			expectedBody:        `{"items":[]}`,
+			expectedContentType: "application/json",
+		},
+		{
+			desc:          "invalid status code",
+			config:        &types.CircuitBreakerFallback{StatusCode: 42},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fallback, err := newCircuitBreakerFallback(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			fallback.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestNewCircuitBreakerFromConfig(t *testing.T) {
+	_, err := NewCircuitBreakerFromConfig(http.NotFoundHandler(), "backend1", &types.CircuitBreaker{Expression: testExpression})
+	assert.NoError(t, err)
+
+	_, err = NewCircuitBreakerFromConfig(http.NotFoundHandler(), "backend1", &types.CircuitBreaker{Expression: testExpression, HalfOpenRequests: 2})
+	assert.NoError(t, err)
+
+	_, err = NewCircuitBreakerFromConfig(http.NotFoundHandler(), "backend1", &types.CircuitBreaker{Expression: "Foo() > 1", HalfOpenRequests: 2})
+	assert.Error(t, err)
+
+	_, err = NewServerCircuitBreakers(http.NotFoundHandler(), "backend1", &types.CircuitBreaker{Expression: "Foo() > 1"})
+	assert.Error(t, err)
+}
+
+func TestHalfOpenBreaker(t *testing.T) {
+	statusCode := http.StatusOK
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(statusCode)
+	})
+	fallback, err := newCircuitBreakerFallback(&types.CircuitBreakerFallback{StatusCode: http.StatusTeapot})
+	require.NoError(t, err)
+
+	breaker, err := newHalfOpenBreaker(next, "backend1", testExpression, time.Hour, 2, fallback)
+	require.NoError(t, err)
+
+	call := func() int {
+		recorder := httptest.NewRecorder()
+		breaker.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+		return recorder.Code
+	}
+	expireOpenDuration := func() {
+		breaker.mu.Lock()
+		breaker.openUntil = time.Now()
+		breaker.mu.Unlock()
+	}
+
+	assert.Equal(t, http.StatusOK, call())
+
+	// the oxy circuit breaker trips
+	breaker.tripped(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+	assert.Equal(t, http.StatusTeapot, call())
+
+	// a failed probe opens the circuit again
+	expireOpenDuration()
+	statusCode = http.StatusBadGateway
+	assert.Equal(t, http.StatusBadGateway, call())
+	assert.Equal(t, http.StatusTeapot, call())
+
+	// the circuit closes after the successful probes
+	expireOpenDuration()
+	statusCode = http.StatusOK
+	assert.Equal(t, http.StatusOK, call())
+	assert.Equal(t, breakerHalfOpen, breaker.state)
+	assert.Equal(t, http.StatusOK, call())
+	assert.Equal(t, breakerClosed, breaker.state)
+	assert.Equal(t, http.StatusOK, call())
+}
+
+func TestHalfOpenBreakerLimitsProbes(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	fallback, err := newCircuitBreakerFallback(nil)
+	require.NoError(t, err)
+
+	breaker, err := newHalfOpenBreaker(next, "backend1", testExpression, time.Hour, 1, fallback)
+	require.NoError(t, err)
+	breaker.tripped(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+	breaker.openUntil = time.Now()
+
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		breaker.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+		done <- recorder.Code
+	}()
+	<-started
+
+	// only one probe at a time
+	recorder := httptest.NewRecorder()
+	breaker.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, breakerClosed, breaker.state)
+}
+
+func TestServerCircuitBreakers(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	breakers, err := NewServerCircuitBreakers(next, "backend1", &types.CircuitBreaker{Expression: testExpression, HalfOpenRequests: 1})
+	require.NoError(t, err)
+
+	serve := func(server string) (int, bool) {
+		netErrorOccurred := false
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://"+server+"/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), defaultNetErrCtxKey, &netErrorOccurred))
+		recorder := httptest.NewRecorder()
+		breakers.ServeHTTP(recorder, req)
+		return recorder.Code, netErrorOccurred
+	}
+
+	statusCode, netError := serve("10.0.0.1:80")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.False(t, netError)
+
+	breaker, err := breakers.getBreaker("10.0.0.1:80")
+	require.NoError(t, err)
+	breaker.(*halfOpenBreaker).tripped(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil))
+
+	statusCode, netError = serve("10.0.0.1:80")
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.True(t, netError, "the request should be retried on another server")
+
+	statusCode, netError = serve("10.0.0.2:80")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.False(t, netError)
+}
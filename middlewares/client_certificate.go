@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+)
+
+// XForwardedTLSClientSubject is the header holding the subject of the verified client certificate
+const XForwardedTLSClientSubject = "X-Forwarded-Tls-Client-Subject"
+
+// ClientCertificate is a middleware verifying the client certificates with the CAs of a frontend,
+// and passing the subject of the verified certificate to the backend.
+type ClientCertificate struct {
+	pool     *x509.CertPool
+	optional bool
+}
+
+// NewClientCertificate builds a new ClientCertificate from the CAs (file paths or contents),
+// requiring a client certificate unless the mode is verify.
+func NewClientCertificate(cas []traefikTls.FileOrContent, mode string) (*ClientCertificate, error) {
+	if len(cas) == 0 {
+		return nil, errors.New("no CA provided")
+	}
+
+	var optional bool
+	switch strings.ToLower(mode) {
+	case "", traefikTls.ClientAuthRequire:
+	case traefikTls.ClientAuthVerify:
+		optional = true
+	default:
+		return nil, fmt.Errorf("unknown client authentication mode %q", mode)
+	}
+
+	pool := x509.NewCertPool()
+	for _, ca := range cas {
+		content, err := ca.Read()
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("invalid certificate(s) in %s", ca)
+		}
+	}
+
+	return &ClientCertificate{
+		pool:     pool,
+		optional: optional,
+	}, nil
+}
+
+func (c *ClientCertificate) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	r.Header.Del(XForwardedTLSClientSubject)
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		if c.optional {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		log.Debugf("No client certificate from %s - rejecting", r.RemoteAddr)
+		reject(rw)
+		return
+	}
+
+	certificate := r.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         c.pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		log.Debugf("Invalid client certificate from %s: %v - rejecting", r.RemoteAddr, err)
+		reject(rw)
+		return
+	}
+
+	r.Header.Set(XForwardedTLSClientSubject, formatDistinguishedName(certificate.Subject))
+	next.ServeHTTP(rw, r)
+}
+
+// formatDistinguishedName formats the name as a RFC 2253 distinguished name.
+func formatDistinguishedName(name pkix.Name) string {
+	var attributes []string
+	add := func(attribute string, values ...string) {
+		for _, value := range values {
+			if len(value) == 0 {
+				continue
+			}
+			attributes = append(attributes, attribute+"="+escapeDistinguishedNameValue(value))
+		}
+	}
+
+	// RFC 2253 starts with the last RDN of the sequence
+	add("CN", name.CommonName)
+	add("SERIALNUMBER", name.SerialNumber)
+	add("OU", name.OrganizationalUnit...)
+	add("O", name.Organization...)
+	add("STREET", name.StreetAddress...)
+	add("L", name.Locality...)
+	add("ST", name.Province...)
+	add("POSTALCODE", name.PostalCode...)
+	add("C", name.Country...)
+	return strings.Join(attributes, ",")
+}
+
+func escapeDistinguishedNameValue(value string) string {
+	var escaped []rune
+	for i, r := range value {
+		if strings.ContainsRune(",+\"\\<>;=", r) ||
+			(i == 0 && (r == ' ' || r == '#')) ||
+			(i == len(value)-1 && r == ' ') {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, r)
+	}
+	return string(escaped)
+}
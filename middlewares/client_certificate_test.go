@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, subject pkix.Name) *x509.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestClientCertificate(t *testing.T) {
+	ca := newTestCA(t, "Client CA")
+	otherCA := newTestCA(t, "Other CA")
+	subject := pkix.Name{CommonName: "client", Organization: []string{"Containous"}, Country: []string{"FR"}}
+	validCert := ca.issue(t, subject)
+	otherCert := otherCA.issue(t, subject)
+
+	testCases := []struct {
+		desc            string
+		mode            string
+		peerCerts       []*x509.Certificate
+		expectedStatus  int
+		expectedSubject string
+	}{
+		{
+			desc:           "required certificate missing",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "optional certificate missing",
+			mode:           "verify",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:            "valid certificate",
+			peerCerts:       []*x509.Certificate{validCert},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "CN=client,O=Containous,C=FR",
+		},
+		{
+			desc:            "valid optional certificate",
+			mode:            "verify",
+			peerCerts:       []*x509.Certificate{validCert},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "CN=client,O=Containous,C=FR",
+		},
+		{
+			desc:           "certificate of another CA",
+			peerCerts:      []*x509.Certificate{otherCert},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "optional certificate of another CA",
+			mode:           "verify",
+			peerCerts:      []*x509.Certificate{otherCert},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clientCertificate, err := NewClientCertificate([]traefikTls.FileOrContent{traefikTls.FileOrContent(ca.pem)}, test.mode)
+			require.NoError(t, err)
+
+			var subject string
+			next := func(rw http.ResponseWriter, req *http.Request) {
+				subject = req.Header.Get(XForwardedTLSClientSubject)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "https://localhost", nil)
+			req.Header.Set(XForwardedTLSClientSubject, "CN=spoofed")
+			if test.peerCerts != nil {
+				req.TLS = &tls.ConnectionState{PeerCertificates: test.peerCerts}
+			} else {
+				req.TLS = &tls.ConnectionState{}
+			}
+			recorder := httptest.NewRecorder()
+
+			clientCertificate.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedSubject, subject)
+		})
+	}
+}
+
+func TestNewClientCertificateErrors(t *testing.T) {
+	_, err := NewClientCertificate(nil, "")
+	assert.Error(t, err, "no CA")
+
+	_, err = NewClientCertificate([]traefikTls.FileOrContent{"not a certificate"}, "")
+	assert.Error(t, err, "invalid CA")
+
+	_, err = NewClientCertificate([]traefikTls.FileOrContent{traefikTls.FileOrContent(newTestCA(t, "CA").pem)}, "request")
+	assert.Error(t, err, "request mode is only for entry points")
+}
+
+func TestFormatDistinguishedName(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		name     pkix.Name
+		expected string
+	}{
+		{
+			desc:     "common name",
+			name:     pkix.Name{CommonName: "client"},
+			expected: "CN=client",
+		},
+		{
+			desc: "several attributes",
+			name: pkix.Name{
+				CommonName:         "client",
+				OrganizationalUnit: []string{"Dev", "Ops"},
+				Organization:       []string{"Containous"},
+				Locality:           []string{"Lyon"},
+				Country:            []string{"FR"},
+			},
+			expected: "CN=client,OU=Dev,OU=Ops,O=Containous,L=Lyon,C=FR",
+		},
+		{
+			desc:     "special characters",
+			name:     pkix.Name{CommonName: " Doe, John+Jane ", Organization: []string{"#1"}},
+			expected: `CN=\ Doe\, John\+Jane\ ,O=\#1`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, formatDistinguishedName(test.name))
+		})
+	}
+}
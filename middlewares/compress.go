@@ -1,33 +1,353 @@
 package middlewares
 
 import (
+	"bufio"
 	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
-	"github.com/NYTimes/gziphandler"
-	"github.com/containous/traefik/log"
+	"github.com/andybalholm/brotli"
+	"github.com/containous/traefik/types"
 )
 
-// Compress is a middleware that allows redirection
-type Compress struct{}
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+
+	defaultCompressMinSize = 512
+	defaultBrotliQuality   = 4
+)
+
+// Compress is a middleware compressing the responses with gzip or brotli, negotiated with the Accept-Encoding request header.
+// The zero value compresses with gzip the responses larger than 512 bytes.
+type Compress struct {
+	algorithms           []string
+	gzipLevel            int
+	brotliQuality        int
+	minSize              int
+	contentTypes         []string
+	excludedContentTypes []string
+
+	gzipPool   sync.Pool
+	brotliPool sync.Pool
+}
+
+// NewCompress builds a Compress middleware from the compression options of an entry point.
+func NewCompress(config *types.Compression) (*Compress, error) {
+	c := &Compress{}
+	if config == nil {
+		return c, nil
+	}
+
+	for _, algorithm := range config.Algorithms {
+		algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+		if algorithm != encodingGzip && algorithm != encodingBrotli {
+			return nil, fmt.Errorf("unsupported compression algorithm %q", algorithm)
+		}
+		c.algorithms = append(c.algorithms, algorithm)
+	}
+	if len(c.algorithms) == 0 {
+		c.algorithms = []string{encodingBrotli, encodingGzip}
+	}
+
+	if config.GzipLevel < 0 || config.GzipLevel > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid gzip level %d", config.GzipLevel)
+	}
+	c.gzipLevel = config.GzipLevel
+
+	if config.BrotliQuality < 0 || config.BrotliQuality > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid brotli quality %d", config.BrotliQuality)
+	}
+	c.brotliQuality = config.BrotliQuality
+
+	if config.MinSize < 0 {
+		return nil, fmt.Errorf("invalid minimum size %d", config.MinSize)
+	}
+	c.minSize = config.MinSize
+
+	for _, contentType := range config.ContentTypes {
+		c.contentTypes = append(c.contentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+	}
+	for _, contentType := range config.ExcludedContentTypes {
+		c.excludedContentTypes = append(c.excludedContentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+	}
+
+	return c, nil
+}
 
 // ServerHTTP is a function used by Negroni
 func (c *Compress) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	contentType := r.Header.Get("Content-Type")
-	if strings.HasPrefix(contentType, "application/grpc") {
+	if strings.HasPrefix(contentType, "application/grpc") || r.Method == http.MethodHead {
+		next.ServeHTTP(rw, r)
+		return
+	}
+
+	encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+	if len(encoding) == 0 {
 		next.ServeHTTP(rw, r)
-	} else {
-		gzipHandler(next).ServeHTTP(rw, r)
+		return
+	}
+
+	cw := &compressWriter{rw: rw, compress: c, encoding: encoding}
+	defer cw.close()
+	next.ServeHTTP(cw, r)
+}
+
+// negotiate returns the preferred encoding of the client among the algorithms of the middleware,
+// the order of the algorithms breaking the ties.
+func (c *Compress) negotiate(acceptEncoding string) string {
+	if len(acceptEncoding) == 0 {
+		return ""
+	}
+
+	qvalues := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, qvalue := parseCoding(part)
+		if len(coding) > 0 {
+			qvalues[coding] = qvalue
+		}
+	}
+
+	var best string
+	var bestQvalue float64
+	for _, algorithm := range c.getAlgorithms() {
+		qvalue, ok := qvalues[algorithm]
+		if !ok {
+			qvalue, ok = qvalues["*"]
+		}
+		if ok && qvalue > bestQvalue {
+			best = algorithm
+			bestQvalue = qvalue
+		}
+	}
+	return best
+}
+
+func parseCoding(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	qvalue := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			value, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				return "", 0
+			}
+			qvalue = value
+		}
 	}
+	return coding, qvalue
 }
 
-func gzipHandler(h http.Handler) http.Handler {
-	wrapper, err := gziphandler.GzipHandlerWithOpts(
-		gziphandler.CompressionLevel(gzip.DefaultCompression),
-		gziphandler.MinSize(gziphandler.DefaultMinSize))
+func (c *Compress) getAlgorithms() []string {
+	if len(c.algorithms) == 0 {
+		return []string{encodingGzip}
+	}
+	return c.algorithms
+}
+
+func (c *Compress) getMinSize() int {
+	if c.minSize == 0 {
+		return defaultCompressMinSize
+	}
+	return c.minSize
+}
+
+// compressible checks the media type of a response against the included and excluded content types.
+// A type ending with /* matches all the subtypes, e.g. text/*.
+func (c *Compress) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		log.Error(err)
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+
+	if len(c.contentTypes) > 0 && !matchContentType(c.contentTypes, mediaType) {
+		return false
+	}
+	return !matchContentType(c.excludedContentTypes, mediaType)
+}
+
+func matchContentType(patterns []string, mediaType string) bool {
+	for _, pattern := range patterns {
+		if pattern == mediaType || strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compress) newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case encodingBrotli:
+		if encoder, ok := c.brotliPool.Get().(*brotli.Writer); ok {
+			encoder.Reset(w)
+			return encoder
+		}
+		quality := c.brotliQuality
+		if quality == 0 {
+			quality = defaultBrotliQuality
+		}
+		return brotli.NewWriterLevel(w, quality)
+	default:
+		if encoder, ok := c.gzipPool.Get().(*gzip.Writer); ok {
+			encoder.Reset(w)
+			return encoder
+		}
+		level := c.gzipLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		encoder, _ := gzip.NewWriterLevel(w, level)
+		return encoder
+	}
+}
+
+func (c *Compress) releaseEncoder(encoder io.WriteCloser) {
+	switch encoder := encoder.(type) {
+	case *brotli.Writer:
+		c.brotliPool.Put(encoder)
+	case *gzip.Writer:
+		c.gzipPool.Put(encoder)
+	}
+}
+
+// compressWriter buffers the beginning of a response until it knows whether to compress it:
+// once the minimum size is reached, when the response is flushed, or when it ends.
+type compressWriter struct {
+	rw         http.ResponseWriter
+	compress   *Compress
+	encoding   string
+	statusCode int
+	buf        []byte
+	decided    bool
+	encoder    io.WriteCloser
+}
+
+func (w *compressWriter) Header() http.Header {
+	return w.rw.Header()
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.statusCode != 0 {
+		return
+	}
+	w.statusCode = code
+
+	// No body, or a partial one: nothing to compress
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusPartialContent {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.statusCode == 0 {
+			w.statusCode = http.StatusOK
+		}
+		if len(w.Header().Get("Content-Encoding")) > 0 {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, b...)
+			if len(w.buf) >= w.compress.getMinSize() {
+				w.decide(w.compressible())
+			}
+			return len(b), nil
+		}
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.rw.Write(b)
+}
+
+func (w *compressWriter) compressible() bool {
+	if len(w.Header().Get("Content-Encoding")) > 0 {
+		return false
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if len(contentType) == 0 {
+		if len(w.buf) == 0 {
+			return len(w.compress.contentTypes) == 0
+		}
+		contentType = http.DetectContentType(w.buf)
+		w.Header().Set("Content-Type", contentType)
+	}
+	return w.compress.compressible(contentType)
+}
+
+// decide writes the headers and the buffered beginning of the response, compressed or not.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	if compress {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Del("Content-Length")
+		w.encoder = w.compress.newEncoder(w.encoding, w.rw)
+	}
+	w.rw.WriteHeader(w.statusCode)
+
+	if len(w.buf) > 0 {
+		if w.encoder != nil {
+			w.encoder.Write(w.buf)
+		} else {
+			w.rw.Write(w.buf)
+		}
+	}
+	w.buf = nil
+}
+
+// Flush sends the buffered response, compressed when it can be, without waiting for the minimum size.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.statusCode == 0 && len(w.buf) == 0 {
+			return
+		}
+		w.decide(w.compressible())
+	}
+
+	if flusher, ok := w.encoder.(interface {
+		Flush() error
+	}); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.rw.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.rw)
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		// The response ended before the minimum size
+		if w.statusCode == 0 {
+			return
+		}
+		w.decide(false)
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.compress.releaseEncoder(w.encoder)
+		w.encoder = nil
 	}
-	return wrapper(h)
 }
@@ -4,10 +4,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/NYTimes/gziphandler"
+	"github.com/andybalholm/brotli"
 	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
@@ -245,3 +249,296 @@ func generateBytes(len int) []byte {
 	}
 	return value
 }
+
+func TestNewCompress(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.Compression
+		expectedError bool
+	}{
+		{
+			desc: "no configuration",
+		},
+		{
+			desc: "valid configuration",
+			config: &types.Compression{
+				Algorithms:           []string{"br", "GZIP"},
+				GzipLevel:            9,
+				BrotliQuality:        11,
+				MinSize:              1024,
+				ContentTypes:         []string{"text/*"},
+				ExcludedContentTypes: []string{"text/event-stream"},
+			},
+		},
+		{
+			desc:          "unknown algorithm",
+			config:        &types.Compression{Algorithms: []string{"deflate"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid gzip level",
+			config:        &types.Compression{GzipLevel: 10},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid brotli quality",
+			config:        &types.Compression{BrotliQuality: 12},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid minimum size",
+			config:        &types.Compression{MinSize: -1},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCompress(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		algorithms       []string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{
+			desc:             "brotli preferred",
+			acceptEncoding:   "gzip, deflate, br",
+			expectedEncoding: "br",
+		},
+		{
+			desc:             "gzip only",
+			acceptEncoding:   "gzip",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "client preference",
+			acceptEncoding:   "br;q=0.5, gzip;q=0.8",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "brotli refused",
+			acceptEncoding:   "br;q=0, *",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "wildcard",
+			acceptEncoding:   "*",
+			expectedEncoding: "br",
+		},
+		{
+			desc:             "order of the algorithms",
+			algorithms:       []string{"gzip", "br"},
+			acceptEncoding:   "br, gzip",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "algorithm not enabled",
+			algorithms:       []string{"gzip"},
+			acceptEncoding:   "br",
+			expectedEncoding: "",
+		},
+		{
+			desc:             "identity",
+			acceptEncoding:   "identity",
+			expectedEncoding: "",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			compress, err := NewCompress(&types.Compression{Algorithms: test.algorithms})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedEncoding, compress.negotiate(test.acceptEncoding))
+		})
+	}
+}
+
+func TestCompressBrotli(t *testing.T) {
+	compress, err := NewCompress(&types.Compression{BrotliQuality: 6})
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Add(acceptEncodingHeader, "gzip, br")
+
+	body := []byte(strings.Repeat("traefik ", 1000))
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		rw.Write(body[:100])
+		rw.Write(body[100:])
+	}
+
+	rw := httptest.NewRecorder()
+	compress.ServeHTTP(rw, req, next)
+
+	assert.Equal(t, "br", rw.Header().Get(contentEncodingHeader))
+	assert.Equal(t, acceptEncodingHeader, rw.Header().Get(varyHeader))
+	assert.Empty(t, rw.Header().Get("Content-Length"))
+	assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get(contentTypeHeader))
+	assert.True(t, rw.Body.Len() < len(body))
+
+	decompressed, err := ioutil.ReadAll(brotli.NewReader(rw.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, decompressed)
+}
+
+func TestCompressFilters(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.Compression
+		contentType     string
+		size            int
+		statusCode      int
+		expectedEncoded bool
+	}{
+		{
+			desc:            "any content type",
+			config:          &types.Compression{},
+			contentType:     "image/png",
+			size:            1024,
+			expectedEncoded: true,
+		},
+		{
+			desc:            "included content type",
+			config:          &types.Compression{ContentTypes: []string{"text/*", "application/json"}},
+			contentType:     "application/json; charset=utf-8",
+			size:            1024,
+			expectedEncoded: true,
+		},
+		{
+			desc:            "included content type with a wildcard",
+			config:          &types.Compression{ContentTypes: []string{"text/*", "application/json"}},
+			contentType:     "text/html",
+			size:            1024,
+			expectedEncoded: true,
+		},
+		{
+			desc:        "content type not included",
+			config:      &types.Compression{ContentTypes: []string{"text/*", "application/json"}},
+			contentType: "image/png",
+			size:        1024,
+		},
+		{
+			desc:        "excluded content type",
+			config:      &types.Compression{ExcludedContentTypes: []string{"image/*", "application/zip"}},
+			contentType: "Image/JPEG",
+			size:        1024,
+		},
+		{
+			desc:            "content type not excluded",
+			config:          &types.Compression{ExcludedContentTypes: []string{"image/*", "application/zip"}},
+			contentType:     "application/javascript",
+			size:            1024,
+			expectedEncoded: true,
+		},
+		{
+			desc:        "smaller than the default minimum size",
+			config:      &types.Compression{},
+			contentType: "text/html",
+			size:        511,
+		},
+		{
+			desc:        "smaller than the minimum size",
+			config:      &types.Compression{MinSize: 2048},
+			contentType: "text/html",
+			size:        1024,
+		},
+		{
+			desc:            "larger than the minimum size",
+			config:          &types.Compression{MinSize: 100},
+			contentType:     "text/html",
+			size:            100,
+			expectedEncoded: true,
+		},
+		{
+			desc:        "no content",
+			config:      &types.Compression{},
+			contentType: "text/html",
+			statusCode:  http.StatusNoContent,
+		},
+		{
+			desc:        "partial content",
+			config:      &types.Compression{},
+			contentType: "text/html",
+			size:        1024,
+			statusCode:  http.StatusPartialContent,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			compress, err := NewCompress(test.config)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Add(acceptEncodingHeader, gzipValue)
+
+			body := generateBytes(test.size)
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set(contentTypeHeader, test.contentType)
+				if test.statusCode != 0 {
+					rw.WriteHeader(test.statusCode)
+				}
+				rw.Write(body)
+			}
+
+			rw := httptest.NewRecorder()
+			compress.ServeHTTP(rw, req, next)
+
+			if test.expectedEncoded {
+				assert.Equal(t, gzipValue, rw.Header().Get(contentEncodingHeader))
+				assert.NotEqual(t, body, rw.Body.Bytes())
+			} else {
+				assert.Empty(t, rw.Header().Get(contentEncodingHeader))
+				assert.EqualValues(t, body, rw.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	compress, err := NewCompress(&types.Compression{})
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Add(acceptEncodingHeader, "br")
+
+	var flushed int
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(contentTypeHeader, "text/event-stream")
+		rw.Write([]byte("data: event\n\n"))
+		rw.(http.Flusher).Flush()
+		flushed = rw.(*compressWriter).rw.(*httptest.ResponseRecorder).Body.Len()
+		rw.Write([]byte("data: other event\n\n"))
+	}
+
+	rw := httptest.NewRecorder()
+	compress.ServeHTTP(rw, req, next)
+
+	assert.Equal(t, "br", rw.Header().Get(contentEncodingHeader))
+	assert.NotZero(t, flushed, "the event should be sent when flushed")
+
+	decompressed, err := ioutil.ReadAll(brotli.NewReader(rw.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "data: event\n\ndata: other event\n\n", string(decompressed))
+}
@@ -0,0 +1,208 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+func (w wildcardOrigin) match(origin string) bool {
+	return len(origin) > len(w.prefix)+len(w.suffix) && strings.HasPrefix(origin, w.prefix) && strings.HasSuffix(origin, w.suffix)
+}
+
+// CORS is a middleware handling the Cross-Origin Resource Sharing requests of a frontend:
+// it answers the preflight requests, and adds the CORS headers to the responses of the allowed origins.
+type CORS struct {
+	allowAllOrigins  bool
+	origins          map[string]bool
+	wildcardOrigins  []wildcardOrigin
+	regexOrigins     []*regexp.Regexp
+	methods          []string
+	allowAllHeaders  bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           int
+}
+
+// NewCORS builds a new CORS middleware from the policy of a frontend.
+// An allowed origin is either *, an exact origin, or an origin with a wildcard, e.g. https://*.example.com.
+func NewCORS(config *types.CORS) (*CORS, error) {
+	if config.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid max age %d", config.MaxAge)
+	}
+
+	c := &CORS{
+		origins:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowCredentials: config.AllowCredentials,
+		maxAge:           config.MaxAge,
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch strings.Count(origin, "*") {
+		case 0:
+			c.origins[origin] = true
+		case 1:
+			if origin == "*" {
+				c.allowAllOrigins = true
+				continue
+			}
+			parts := strings.SplitN(origin, "*", 2)
+			c.wildcardOrigins = append(c.wildcardOrigins, wildcardOrigin{prefix: parts[0], suffix: parts[1]})
+		default:
+			return nil, fmt.Errorf("invalid allowed origin %q: only one wildcard is supported", origin)
+		}
+	}
+
+	for _, expression := range config.AllowedOriginsRegex {
+		regex, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed origin regex %q: %v", expression, err)
+		}
+		c.regexOrigins = append(c.regexOrigins, regex)
+	}
+
+	for _, method := range config.AllowedMethods {
+		c.methods = append(c.methods, strings.ToUpper(strings.TrimSpace(method)))
+	}
+	if len(c.methods) == 0 {
+		c.methods = defaultCORSMethods
+	}
+
+	for _, header := range config.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == "*" {
+			c.allowAllHeaders = true
+			continue
+		}
+		c.headers[strings.ToLower(header)] = true
+	}
+
+	c.exposedHeaders = strings.Join(config.ExposedHeaders, ", ")
+
+	return c, nil
+}
+
+func (c *CORS) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	origin := r.Header.Get("Origin")
+
+	if r.Method == http.MethodOptions && len(origin) > 0 && len(r.Header.Get("Access-Control-Request-Method")) > 0 {
+		c.preflight(rw, r, origin)
+		return
+	}
+
+	// The response depends on the origin, unless all the origins get the same *
+	if !c.allowAllOrigins || c.allowCredentials {
+		rw.Header().Add("Vary", "Origin")
+	}
+
+	if len(origin) > 0 && c.allowedOrigin(origin) {
+		c.setAllowOrigin(rw, origin)
+		if len(c.exposedHeaders) > 0 {
+			rw.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+		}
+	}
+
+	next.ServeHTTP(rw, r)
+}
+
+func (c *CORS) preflight(rw http.ResponseWriter, r *http.Request, origin string) {
+	rw.Header().Add("Vary", "Origin")
+	rw.Header().Add("Vary", "Access-Control-Request-Method")
+	rw.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.allowedOrigin(origin) {
+		log.Debugf("CORS preflight request from the origin %s refused", origin)
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !c.allowedMethod(method) {
+		log.Debugf("CORS preflight request for the method %s refused", method)
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var headers []string
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if len(header) == 0 {
+			continue
+		}
+		if !c.allowAllHeaders && !c.headers[header] {
+			log.Debugf("CORS preflight request for the header %s refused", header)
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		headers = append(headers, header)
+	}
+
+	c.setAllowOrigin(rw, origin)
+	rw.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if len(headers) > 0 {
+		rw.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.maxAge > 0 {
+		rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.maxAge))
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// setAllowOrigin allows the origin of the request, or all the origins with * when the credentials aren't allowed.
+func (c *CORS) setAllowOrigin(rw http.ResponseWriter, origin string) {
+	if c.allowAllOrigins && !c.allowCredentials {
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	rw.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		rw.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *CORS) allowedOrigin(origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+	for _, wildcard := range c.wildcardOrigins {
+		if wildcard.match(origin) {
+			return true
+		}
+	}
+	for _, regex := range c.regexOrigins {
+		if regex.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) allowedMethod(method string) bool {
+	for _, allowed := range c.methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,316 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCORS(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.CORS
+		expectedError bool
+	}{
+		{
+			desc: "valid policy",
+			config: &types.CORS{
+				AllowedOrigins:      []string{"*", "https://foo.bar", "https://*.foo.bar"},
+				AllowedOriginsRegex: []string{`^https://foo[0-9]+\.bar$`},
+				MaxAge:              600,
+			},
+		},
+		{
+			desc:          "several wildcards",
+			config:        &types.CORS{AllowedOrigins: []string{"https://*.*.foo.bar"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid regex",
+			config:        &types.CORS{AllowedOriginsRegex: []string{"(foo"}},
+			expectedError: true,
+		},
+		{
+			desc:          "negative max age",
+			config:        &types.CORS{MaxAge: -1},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCORS(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.CORS
+		origin          string
+		expectedHeaders map[string]string
+		expectedVary    []string
+	}{
+		{
+			desc:   "exact origin",
+			config: &types.CORS{AllowedOrigins: []string{"https://foo.bar"}, ExposedHeaders: []string{"X-Foo", "X-Bar"}},
+			origin: "https://foo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://foo.bar",
+				"Access-Control-Expose-Headers": "X-Foo, X-Bar",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "origin not allowed",
+			config: &types.CORS{AllowedOrigins: []string{"https://foo.bar"}},
+			origin: "https://evil.com",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "wildcard subdomain",
+			config: &types.CORS{AllowedOrigins: []string{"https://*.foo.bar"}},
+			origin: "https://app.foo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.foo.bar",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "wildcard subdomain without subdomain",
+			config: &types.CORS{AllowedOrigins: []string{"https://*.foo.bar"}},
+			origin: "https://foo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "wildcard subdomain of another domain",
+			config: &types.CORS{AllowedOrigins: []string{"https://*.foo.bar"}},
+			origin: "https://app.evilfoo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "regex origin",
+			config: &types.CORS{AllowedOriginsRegex: []string{`^https://foo[0-9]+\.bar$`}},
+			origin: "https://foo42.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://foo42.bar",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "all origins",
+			config: &types.CORS{AllowedOrigins: []string{"*"}},
+			origin: "https://foo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+		},
+		{
+			desc:   "all origins with credentials",
+			config: &types.CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			origin: "https://foo.bar",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://foo.bar",
+				"Access-Control-Allow-Credentials": "true",
+			},
+			expectedVary: []string{"Origin"},
+		},
+		{
+			desc:   "no origin",
+			config: &types.CORS{AllowedOrigins: []string{"https://foo.bar"}},
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			expectedVary: []string{"Origin"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cors, err := NewCORS(test.config)
+			require.NoError(t, err)
+
+			var called bool
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				called = true
+				rw.WriteHeader(http.StatusOK)
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+			if len(test.origin) > 0 {
+				req.Header.Set("Origin", test.origin)
+			}
+			recorder := httptest.NewRecorder()
+			cors.ServeHTTP(recorder, req, next)
+
+			assert.True(t, called)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+			assert.Equal(t, test.expectedVary, recorder.Header()["Vary"])
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		config             *types.CORS
+		origin             string
+		method             string
+		headers            string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			desc: "allowed",
+			config: &types.CORS{
+				AllowedOrigins:   []string{"https://foo.bar"},
+				AllowedMethods:   []string{"GET", "put"},
+				AllowedHeaders:   []string{"Content-Type", "X-Api-Key"},
+				AllowCredentials: true,
+				MaxAge:           600,
+			},
+			origin:             "https://foo.bar",
+			method:             "PUT",
+			headers:            "content-type, x-api-key",
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://foo.bar",
+				"Access-Control-Allow-Methods":     "GET, PUT",
+				"Access-Control-Allow-Headers":     "content-type, x-api-key",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			desc:               "default methods",
+			config:             &types.CORS{AllowedOrigins: []string{"*"}},
+			origin:             "https://foo.bar",
+			method:             "POST",
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "GET, HEAD, POST",
+				"Access-Control-Allow-Headers": "",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+		{
+			desc:               "all headers",
+			config:             &types.CORS{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			origin:             "https://foo.bar",
+			method:             "GET",
+			headers:            "X-Foo",
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Headers": "x-foo",
+			},
+		},
+		{
+			desc:               "origin not allowed",
+			config:             &types.CORS{AllowedOrigins: []string{"https://foo.bar"}},
+			origin:             "https://evil.com",
+			method:             "GET",
+			expectedStatusCode: http.StatusForbidden,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			desc:               "method not allowed",
+			config:             &types.CORS{AllowedOrigins: []string{"https://foo.bar"}},
+			origin:             "https://foo.bar",
+			method:             "DELETE",
+			expectedStatusCode: http.StatusForbidden,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			desc:               "header not allowed",
+			config:             &types.CORS{AllowedOrigins: []string{"https://foo.bar"}, AllowedHeaders: []string{"Content-Type"}},
+			origin:             "https://foo.bar",
+			method:             "GET",
+			headers:            "Content-Type, X-Foo",
+			expectedStatusCode: http.StatusForbidden,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cors, err := NewCORS(test.config)
+			require.NoError(t, err)
+
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				t.Error("the preflight request must not be forwarded")
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodOptions, "http://foo.bar/", nil)
+			req.Header.Set("Origin", test.origin)
+			req.Header.Set("Access-Control-Request-Method", test.method)
+			if len(test.headers) > 0 {
+				req.Header.Set("Access-Control-Request-Headers", test.headers)
+			}
+			recorder := httptest.NewRecorder()
+			cors.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+			assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, recorder.Header()["Vary"])
+		})
+	}
+}
+
+func TestCORSOptionsWithoutPreflight(t *testing.T) {
+	cors, err := NewCORS(&types.CORS{AllowedOrigins: []string{"https://foo.bar"}})
+	require.NoError(t, err)
+
+	var called bool
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+	}
+
+	req := testhelpers.MustNewRequest(http.MethodOptions, "http://foo.bar/", nil)
+	req.Header.Set("Origin", "https://foo.bar")
+	recorder := httptest.NewRecorder()
+	cors.ServeHTTP(recorder, req, next)
+
+	assert.True(t, called)
+	assert.Equal(t, "https://foo.bar", recorder.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,169 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// ServerDrainer tracks the requests in flight to the servers of the backends, across configuration reloads.
+// The servers removed from the configuration no longer receive new requests, and their requests in flight
+// are given the drain timeout to finish before being cancelled.
+type ServerDrainer struct {
+	timeout time.Duration
+
+	mutex sync.Mutex
+	// backends holds the servers with requests in flight, by backend and server
+	backends map[string]map[string]*drainedServer
+	// active holds the servers of the current configuration, by backend; nil until the first configuration
+	active map[string]map[string]bool
+	nextID uint64
+}
+
+type drainedServer struct {
+	requests      map[uint64]context.CancelFunc
+	drainingSince time.Time
+}
+
+// NewServerDrainer returns a new ServerDrainer cancelling the requests in flight to the removed servers
+// after the given timeout.
+func NewServerDrainer(timeout time.Duration) *ServerDrainer {
+	return &ServerDrainer{
+		timeout:  timeout,
+		backends: make(map[string]map[string]*drainedServer),
+	}
+}
+
+// Handler returns a handler tracking the requests forwarded by next to the servers of the given backend.
+// The server is identified by the scheme and host of the request URL, as set by the load-balancer.
+func (d *ServerDrainer) Handler(next http.Handler, backendName string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		server := r.URL.Scheme + "://" + r.URL.Host
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		id := d.add(backendName, server, cancel)
+		defer d.done(backendName, server, id)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// InFlight returns the number of requests in flight to a backend server.
+func (d *ServerDrainer) InFlight(backendName, server string) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	drained, ok := d.backends[backendName][server]
+	if !ok {
+		return 0
+	}
+	return len(drained.requests)
+}
+
+// Retain starts draining the servers with requests in flight which are not part of the given backend servers,
+// and stops draining the ones added back.
+func (d *ServerDrainer) Retain(backendServers map[string][]string) {
+	now := time.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.active = make(map[string]map[string]bool)
+	for backendName, servers := range backendServers {
+		d.active[backendName] = make(map[string]bool)
+		for _, server := range servers {
+			d.active[backendName][server] = true
+		}
+	}
+
+	for backendName, servers := range d.backends {
+		for server, drained := range servers {
+			switch {
+			case d.active[backendName][server]:
+				if !drained.drainingSince.IsZero() {
+					log.Infof("Server %s of backend %s added back: draining stopped", server, backendName)
+					drained.drainingSince = time.Time{}
+				}
+			case drained.drainingSince.IsZero():
+				d.drain(backendName, server, drained, now)
+			}
+		}
+	}
+}
+
+// drain must be called with the lock held.
+func (d *ServerDrainer) drain(backendName, server string, drained *drainedServer, now time.Time) {
+	drained.drainingSince = now
+	log.Infof("Server %s of backend %s removed: draining %d requests in flight for at most %s", server, backendName, len(drained.requests), d.timeout)
+	time.AfterFunc(d.timeout, func() {
+		d.expire(backendName, server, now)
+	})
+}
+
+func (d *ServerDrainer) expire(backendName, server string, drainingSince time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	drained, ok := d.backends[backendName][server]
+	if !ok || !drained.drainingSince.Equal(drainingSince) {
+		// the server has been drained, or added back in the meantime
+		return
+	}
+
+	log.Warnf("Drain timeout of server %s of backend %s expired: cancelling %d requests in flight", server, backendName, len(drained.requests))
+	for _, cancel := range drained.requests {
+		cancel()
+	}
+}
+
+func (d *ServerDrainer) add(backendName, server string, cancel context.CancelFunc) uint64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	servers, ok := d.backends[backendName]
+	if !ok {
+		servers = make(map[string]*drainedServer)
+		d.backends[backendName] = servers
+	}
+	drained, ok := servers[server]
+	if !ok {
+		drained = &drainedServer{requests: make(map[uint64]context.CancelFunc)}
+		servers[server] = drained
+	}
+
+	d.nextID++
+	drained.requests[d.nextID] = cancel
+
+	// the handler of a previous configuration may still forward requests to a removed server
+	if !ok && d.active != nil && !d.active[backendName][server] {
+		d.drain(backendName, server, drained, time.Now())
+	}
+	return d.nextID
+}
+
+func (d *ServerDrainer) done(backendName, server string, id uint64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	servers := d.backends[backendName]
+	drained, ok := servers[server]
+	if !ok {
+		return
+	}
+
+	delete(drained.requests, id)
+	if len(drained.requests) > 0 {
+		return
+	}
+
+	if !drained.drainingSince.IsZero() {
+		log.Debugf("Server %s of backend %s drained", server, backendName)
+	}
+	delete(servers, server)
+	if len(servers) == 0 {
+		delete(d.backends, backendName)
+	}
+}
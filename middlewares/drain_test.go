@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerDrainer(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		backendServers   map[string][]string
+		expectedCanceled bool
+	}{
+		{
+			desc:           "active server",
+			backendServers: map[string][]string{"backend": {"http://a:80"}},
+		},
+		{
+			desc:             "removed server",
+			backendServers:   map[string][]string{"backend": {"http://b:80"}},
+			expectedCanceled: true,
+		},
+		{
+			desc:             "removed backend",
+			backendServers:   map[string][]string{},
+			expectedCanceled: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			drainer := NewServerDrainer(50 * time.Millisecond)
+			started := make(chan struct{})
+			canceled := make(chan bool, 1)
+			handler := drainer.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				close(started)
+				select {
+				case <-req.Context().Done():
+					canceled <- true
+				case <-time.After(200 * time.Millisecond):
+					canceled <- false
+				}
+			}), "backend")
+
+			go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+			<-started
+			assert.Equal(t, 1, drainer.InFlight("backend", "http://a:80"))
+
+			drainer.Retain(test.backendServers)
+			assert.Equal(t, test.expectedCanceled, <-canceled)
+
+			time.Sleep(10 * time.Millisecond)
+			assert.Equal(t, 0, drainer.InFlight("backend", "http://a:80"))
+		})
+	}
+}
+
+func TestServerDrainerAddedBack(t *testing.T) {
+	drainer := NewServerDrainer(50 * time.Millisecond)
+	started := make(chan struct{})
+	canceled := make(chan bool, 1)
+	handler := drainer.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		select {
+		case <-req.Context().Done():
+			canceled <- true
+		case <-time.After(200 * time.Millisecond):
+			canceled <- false
+		}
+	}), "backend")
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+	<-started
+
+	drainer.Retain(map[string][]string{})
+	drainer.Retain(map[string][]string{"backend": {"http://a:80"}})
+	assert.False(t, <-canceled)
+}
+
+func TestServerDrainerPreviousConfiguration(t *testing.T) {
+	drainer := NewServerDrainer(20 * time.Millisecond)
+	drainer.Retain(map[string][]string{"backend": {"http://b:80"}})
+
+	// a request forwarded by the handler of a previous configuration to a removed server is drained
+	var canceled bool
+	handler := drainer.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			canceled = true
+		case <-time.After(200 * time.Millisecond):
+		}
+	}), "backend")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+
+	assert.True(t, canceled)
+}
@@ -62,7 +62,7 @@ func (lb *healthCheckLoadBalancer) UpsertServer(u *url.URL, options ...roundrobi
 }
 
 func (lb *healthCheckLoadBalancer) Servers() []*url.URL {
-	servers := make([]*url.URL, lb.amountServer)
+	servers := make([]*url.URL, 0, lb.amountServer)
 	for i := 0; i < lb.amountServer; i++ {
 		servers = append(servers, testhelpers.MustParseURL("http://localhost"))
 	}
@@ -1,9 +1,17 @@
 package middlewares
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/types"
@@ -16,19 +24,15 @@ type ErrorPagesHandler struct {
 	HTTPCodeRanges     [][2]int
 	BackendURL         string
 	errorPageForwarder *forward.Forwarder
+	htmlPage           pageTemplate
+	jsonPage           pageTemplate
 }
 
-//NewErrorPagesHandler initializes the utils.ErrorHandler for the custom error pages
-func NewErrorPagesHandler(errorPage types.ErrorPage, backendURL string) (*ErrorPagesHandler, error) {
-	fwd, err := forward.New()
-	if err != nil {
-		return nil, err
-	}
-
-	//Break out the http status code ranges into a low int and high int
-	//for ease of use at runtime
+// ParseHTTPCodeRanges breaks out the http status code ranges into a low int and high int
+// for ease of use at runtime
+func ParseHTTPCodeRanges(ranges []string) ([][2]int, error) {
 	var blocks [][2]int
-	for _, block := range errorPage.Status {
+	for _, block := range ranges {
 		codes := strings.Split(block, "-")
 		//if only a single HTTP code was configured, assume the best and create the correct configuration on the user's behalf
 		if len(codes) == 1 {
@@ -44,11 +48,84 @@ func NewErrorPagesHandler(errorPage types.ErrorPage, backendURL string) (*ErrorP
 		}
 		blocks = append(blocks, [2]int{lowCode, highCode})
 	}
-	return &ErrorPagesHandler{
-			HTTPCodeRanges:     blocks,
-			BackendURL:         backendURL + errorPage.Query,
-			errorPageForwarder: fwd},
-		nil
+	return blocks, nil
+}
+
+// pageTemplate is a html or text template rendering an error page
+type pageTemplate interface {
+	Execute(wr io.Writer, data interface{}) error
+}
+
+// errorPageData holds the variables of the error page templates
+type errorPageData struct {
+	Status     int
+	StatusText string
+	RequestID  string
+	Host       string
+	Method     string
+	Path       string
+}
+
+//NewErrorPagesHandler initializes the utils.ErrorHandler for the custom error pages
+func NewErrorPagesHandler(errorPage types.ErrorPage, backendURL string) (*ErrorPagesHandler, error) {
+	fwd, err := forward.New()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := ParseHTTPCodeRanges(errorPage.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	ep := &ErrorPagesHandler{
+		HTTPCodeRanges:     blocks,
+		BackendURL:         backendURL + errorPage.Query,
+		errorPageForwarder: fwd,
+	}
+
+	htmlPage, err := readErrorPage(errorPage.File, errorPage.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(htmlPage) > 0 {
+		ep.htmlPage, err = htmltemplate.New("errorPage").Parse(htmlPage)
+		if err != nil {
+			return nil, fmt.Errorf("parsing error page template: %v", err)
+		}
+	}
+
+	jsonPage, err := readErrorPage(errorPage.JSONFile, errorPage.JSONBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(jsonPage) > 0 {
+		ep.jsonPage, err = template.New("errorPage").Funcs(template.FuncMap{"json": toJSON}).Parse(jsonPage)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JSON error page template: %v", err)
+		}
+	}
+
+	if len(backendURL) == 0 && ep.htmlPage == nil && ep.jsonPage == nil {
+		return nil, fmt.Errorf("no backend nor page for the error page of status %s", errorPage.Status)
+	}
+	return ep, nil
+}
+
+func readErrorPage(file string, body string) (string, error) {
+	if len(file) == 0 {
+		return body, nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the error page: %v", err)
+	}
+	return string(content), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
 }
 
 func (ep *ErrorPagesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
@@ -56,11 +133,16 @@ func (ep *ErrorPagesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request,
 	recorder.responseWriter = w
 	next.ServeHTTP(recorder, req)
 
-	w.WriteHeader(recorder.Code)
 	//check the recorder code against the configured http status code ranges
 	for _, block := range ep.HTTPCodeRanges {
 		if recorder.Code >= block[0] && recorder.Code <= block[1] {
 			log.Errorf("Caught HTTP Status Code %d, returning error page", recorder.Code)
+			if page, contentType := ep.negotiatePage(req); page != nil {
+				ep.renderPage(w, req, recorder.Code, page, contentType)
+				return
+			}
+
+			w.WriteHeader(recorder.Code)
 			finalURL := strings.Replace(ep.BackendURL, "{status}", strconv.Itoa(recorder.Code), -1)
 			if newReq, err := http.NewRequest(http.MethodGet, finalURL, nil); err != nil {
 				w.Write([]byte(http.StatusText(recorder.Code)))
@@ -72,6 +154,73 @@ func (ep *ErrorPagesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request,
 	}
 
 	//did not catch a configured status code so proceed with the request
+	w.WriteHeader(recorder.Code)
 	utils.CopyHeaders(w.Header(), recorder.Header())
 	w.Write(recorder.Body.Bytes())
 }
+
+// negotiatePage returns the page matching the Accept header of the request, the HTML page being the default.
+func (ep *ErrorPagesHandler) negotiatePage(req *http.Request) (pageTemplate, string) {
+	switch {
+	case ep.jsonPage == nil:
+		return ep.htmlPage, "text/html; charset=utf-8"
+	case ep.htmlPage == nil || prefersJSON(req.Header.Get("Accept")):
+		return ep.jsonPage, "application/json"
+	default:
+		return ep.htmlPage, "text/html; charset=utf-8"
+	}
+}
+
+// prefersJSON returns true if the Accept header gives a higher quality to JSON than to HTML.
+func prefersJSON(accept string) bool {
+	htmlQuality, jsonQuality := 0.0, 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case mediaType == "text/html":
+			htmlQuality = quality
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			if quality > jsonQuality {
+				jsonQuality = quality
+			}
+		}
+	}
+	return jsonQuality > htmlQuality
+}
+
+func (ep *ErrorPagesHandler) renderPage(w http.ResponseWriter, req *http.Request, code int, page pageTemplate, contentType string) {
+	data := errorPageData{
+		Status:     code,
+		StatusText: http.StatusText(code),
+		RequestID:  GetRequestID(req),
+		Host:       req.Host,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+	}
+
+	var body bytes.Buffer
+	if err := page.Execute(&body, data); err != nil {
+		log.Errorf("Error rendering the error page of status %d: %v", code, err)
+		w.WriteHeader(code)
+		w.Write([]byte(http.StatusText(code)))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(code)
+	if req.Method != http.MethodHead {
+		w.Write(body.Bytes())
+	}
+}
@@ -2,8 +2,11 @@ package middlewares
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -150,3 +153,162 @@ func TestErrorPageSingleCode(t *testing.T) {
 	assert.Contains(t, recorder.Body.String(), "503 Test Server")
 	assert.NotContains(t, recorder.Body.String(), "oops", "Should not return the oops page")
 }
+
+func TestNewErrorPagesHandlerPage(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		errorPage     types.ErrorPage
+		expectedError bool
+	}{
+		{
+			desc:      "body",
+			errorPage: types.ErrorPage{Status: []string{"404"}, Body: "<h1>{{.Status}}</h1>"},
+		},
+		{
+			desc:          "no backend nor page",
+			errorPage:     types.ErrorPage{Status: []string{"404"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid template",
+			errorPage:     types.ErrorPage{Status: []string{"404"}, Body: "{{.Status"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid JSON template",
+			errorPage:     types.ErrorPage{Status: []string{"404"}, JSONBody: "{{unknown .Status}}"},
+			expectedError: true,
+		},
+		{
+			desc:          "missing file",
+			errorPage:     types.ErrorPage{Status: []string{"404"}, File: "/nonexistent/404.html"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewErrorPagesHandler(test.errorPage, "")
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestErrorPagesHandlerPage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "errorpages")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "404.html")
+	err = ioutil.WriteFile(file, []byte("<h1>{{.Status}} {{.StatusText}}</h1><p>{{.Path}}</p>"), 0644)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                string
+		errorPage           types.ErrorPage
+		accept              string
+		path                string
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			desc:                "HTML file",
+			errorPage:           types.ErrorPage{Status: []string{"404"}, File: file},
+			path:                "/<script>",
+			expectedBody:        "<h1>404 Not Found</h1><p>/&lt;script&gt;</p>",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:                "JSON body",
+			errorPage:           types.ErrorPage{Status: []string{"400-499"}, JSONBody: `{"status":{{.Status}},"host":{{json .Host}},"path":{{json .Path}}}`},
+			path:                `/"quoted"`,
+			expectedBody:        `{"status":404,"host":"foo.bar","path":"/\"quoted\""}`,
+			expectedContentType: "application/json",
+		},
+		{
+			desc:                "HTML preferred by default",
+			errorPage:           types.ErrorPage{Status: []string{"404"}, Body: "html", JSONBody: "json"},
+			accept:              "*/*",
+			expectedBody:        "html",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:                "JSON preferred",
+			errorPage:           types.ErrorPage{Status: []string{"404"}, Body: "html", JSONBody: "json"},
+			accept:              "text/html;q=0.5, application/json",
+			expectedBody:        "json",
+			expectedContentType: "application/json",
+		},
+		{
+			desc:                "JSON suffix",
+			errorPage:           types.ErrorPage{Status: []string{"404"}, Body: "html", JSONBody: "json"},
+			accept:              "application/problem+json",
+			expectedBody:        "json",
+			expectedContentType: "application/json",
+		},
+		{
+			desc:                "HTML preferred",
+			errorPage:           types.ErrorPage{Status: []string{"404"}, Body: "html", JSONBody: "json"},
+			accept:              "text/html, application/json;q=0.9",
+			expectedBody:        "html",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			testHandler, err := NewErrorPagesHandler(test.errorPage, "")
+			require.NoError(t, err)
+
+			n := negroni.New()
+			n.Use(testHandler)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintln(w, "oops")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://foo.bar/", nil)
+			req.URL.Path = test.path
+			if len(test.accept) > 0 {
+				req.Header.Set("Accept", test.accept)
+			}
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestErrorPagesHandlerPageRequestID(t *testing.T) {
+	testHandler, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"500"}, Body: "{{.RequestID}}"}, "")
+	require.NoError(t, err)
+
+	requestID, err := NewRequestID(&types.RequestID{})
+	require.NoError(t, err)
+
+	n := negroni.New()
+	n.Use(requestID)
+	n.Use(testHandler)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://foo.bar/", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "abc-123", recorder.Body.String())
+}
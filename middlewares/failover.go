@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// Failover sends the requests of a backend to its failover backend while too few of its servers are healthy,
+// the servers removed from the load balancer by the health checks or the outlier detection being unhealthy.
+type Failover struct {
+	next              http.Handler
+	failover          http.Handler
+	lb                healthcheck.LoadBalancer
+	backendName       string
+	failoverName      string
+	serverCount       int
+	minHealthyPercent int
+	failingOver       int32
+}
+
+// NewFailover creates a Failover sending the requests to next while enough of the serverCount servers
+// of the backend are in the load balancer, and to the handler of the failover backend otherwise.
+func NewFailover(next http.Handler, lb healthcheck.LoadBalancer, backendName string, serverCount int, config *types.Failover, failover http.Handler) (*Failover, error) {
+	if config.MinHealthyPercent < 0 || config.MinHealthyPercent > 100 {
+		return nil, fmt.Errorf("invalid minimum healthy percentage %d", config.MinHealthyPercent)
+	}
+	return &Failover{
+		next:              next,
+		failover:          failover,
+		lb:                lb,
+		backendName:       backendName,
+		failoverName:      config.Backend,
+		serverCount:       serverCount,
+		minHealthyPercent: config.MinHealthyPercent,
+	}, nil
+}
+
+func (f *Failover) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if f.isFailingOver() {
+		f.failover.ServeHTTP(rw, r)
+	} else {
+		f.next.ServeHTTP(rw, r)
+	}
+}
+
+// isFailingOver tells whether the requests go to the failover backend, logging the transitions.
+func (f *Failover) isFailingOver() bool {
+	healthy := len(f.lb.Servers())
+	failingOver := healthy == 0 || healthy*100 < f.minHealthyPercent*f.serverCount
+
+	if failingOver {
+		if atomic.CompareAndSwapInt32(&f.failingOver, 0, 1) {
+			log.Warnf("%d of the %d servers of backend %s are healthy: failing over to backend %s", healthy, f.serverCount, f.backendName, f.failoverName)
+		}
+	} else if atomic.CompareAndSwapInt32(&f.failingOver, 1, 0) {
+		log.Infof("%d of the %d servers of backend %s are healthy: failing back from backend %s", healthy, f.serverCount, f.backendName, f.failoverName)
+	}
+	return failingOver
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFailover(t *testing.T) {
+	testCases := []struct {
+		desc              string
+		minHealthyPercent int
+		expectedError     bool
+	}{
+		{
+			desc: "no minimum healthy percentage",
+		},
+		{
+			desc:              "minimum healthy percentage",
+			minHealthyPercent: 50,
+		},
+		{
+			desc:              "negative minimum healthy percentage",
+			minHealthyPercent: -10,
+			expectedError:     true,
+		},
+		{
+			desc:              "minimum healthy percentage above 100",
+			minHealthyPercent: 110,
+			expectedError:     true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &types.Failover{Backend: "dr", MinHealthyPercent: test.minHealthyPercent}
+			_, err := NewFailover(http.NotFoundHandler(), &healthCheckLoadBalancer{}, "primary", 4, config, http.NotFoundHandler())
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFailover(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		healthyServers     int
+		minHealthyPercent  int
+		expectedStatusCode int
+	}{
+		{
+			desc:               "healthy servers",
+			healthyServers:     1,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "no healthy server",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			desc:               "healthy servers at the minimum percentage",
+			healthyServers:     2,
+			minHealthyPercent:  50,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "healthy servers below the minimum percentage",
+			healthyServers:     1,
+			minHealthyPercent:  50,
+			expectedStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			primary := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+			secondary := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusNoContent)
+			})
+
+			config := &types.Failover{Backend: "dr", MinHealthyPercent: test.minHealthyPercent}
+			failover, err := NewFailover(primary, &healthCheckLoadBalancer{test.healthyServers}, "primary", 4, config, secondary)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			failover.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+		})
+	}
+}
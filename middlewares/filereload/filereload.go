@@ -0,0 +1,97 @@
+package filereload
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// DefaultCheckInterval is the default minimum duration between two checks of the modification time of a file.
+const DefaultCheckInterval = 5 * time.Second
+
+// File loads a file used by a middleware, and reloads it when its modification time changes.
+// The modification time is checked at most once per interval, by the request calling ReloadIfChanged,
+// so that the other requests don't access the file system.
+type File struct {
+	filename      string
+	description   string
+	checkInterval time.Duration
+	load          func() error
+
+	mu        sync.Mutex
+	modTime   time.Time
+	nextCheck time.Time
+	checking  bool
+}
+
+// New creates a File loaded by the given function, the description naming the file in the logs, e.g. "users file".
+func New(filename, description string, load func() error) *File {
+	return &File{
+		filename:      filename,
+		description:   description,
+		checkInterval: DefaultCheckInterval,
+		load:          load,
+	}
+}
+
+// Load loads the file, and records its modification time.
+func (f *File) Load() error {
+	info, err := os.Stat(f.filename)
+	if err != nil {
+		return err
+	}
+
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modTime = info.ModTime()
+	f.nextCheck = time.Now().Add(f.checkInterval)
+	return nil
+}
+
+// ReloadIfChanged reloads the file if its modification time changed, once the check interval elapsed.
+// The previous content is kept when the file can't be reloaded.
+func (f *File) ReloadIfChanged() {
+	f.mu.Lock()
+	if f.checking || time.Now().Before(f.nextCheck) {
+		f.mu.Unlock()
+		return
+	}
+	f.checking = true
+	f.nextCheck = time.Now().Add(f.checkInterval)
+	modTime := f.modTime
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.checking = false
+		f.mu.Unlock()
+	}()
+
+	info, err := os.Stat(f.filename)
+	if err != nil {
+		log.Errorf("Unable to check %s %s: %s", f.description, f.filename, err)
+		return
+	}
+	if info.ModTime().Equal(modTime) {
+		return
+	}
+
+	if err := f.Load(); err != nil {
+		log.Errorf("Unable to reload %s %s, keeping the previous version: %s", f.description, f.filename, err)
+		return
+	}
+	log.Debugf("Reloaded %s %s", f.description, f.filename)
+}
+
+// Expire makes the next call to ReloadIfChanged check the file, without waiting for the check interval.
+func (f *File) Expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextCheck = time.Time{}
+}
@@ -0,0 +1,78 @@
+package filereload
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReloadIfChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filereload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "file.txt")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("v1"), 0644))
+
+	var content string
+	file := New(filename, "test file", func() error {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if string(data) == "invalid" {
+			return errors.New("invalid content")
+		}
+		content = string(data)
+		return nil
+	})
+	require.NoError(t, file.Load())
+	assert.Equal(t, "v1", content)
+
+	changeFile := func(data string, modTime time.Time) {
+		require.NoError(t, ioutil.WriteFile(filename, []byte(data), 0644))
+		require.NoError(t, os.Chtimes(filename, modTime, modTime))
+	}
+
+	modTime := time.Now().Add(time.Minute)
+	changeFile("v2", modTime)
+
+	// not reloaded before the check interval elapsed
+	file.ReloadIfChanged()
+	assert.Equal(t, "v1", content)
+
+	file.Expire()
+	file.ReloadIfChanged()
+	assert.Equal(t, "v2", content)
+
+	// not reloaded when the modification time is unchanged
+	changeFile("v3", modTime)
+	file.Expire()
+	file.ReloadIfChanged()
+	assert.Equal(t, "v2", content)
+
+	// an invalid file keeps the previous content
+	changeFile("invalid", modTime.Add(time.Minute))
+	file.Expire()
+	file.ReloadIfChanged()
+	assert.Equal(t, "v2", content)
+
+	// a removed file keeps the previous content
+	require.NoError(t, os.Remove(filename))
+	file.Expire()
+	file.ReloadIfChanged()
+	assert.Equal(t, "v2", content)
+}
+
+func TestFileLoad(t *testing.T) {
+	file := New("/missing/file.txt", "test file", func() error {
+		return nil
+	})
+	assert.Error(t, file.Load())
+}
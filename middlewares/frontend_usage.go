@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultFrontendUnusedWindow = 24 * time.Hour
+
+// FrontendUsageRecorder records the last time each frontend matched a request,
+// to report the frontends which have not been used for a while.
+// The usage is kept across configuration reloads, as long as the frontend is part of the configuration.
+// The requests are recorded without lock, in the counters of their frontend.
+type FrontendUsageRecorder struct {
+	mutex        sync.RWMutex
+	unusedWindow time.Duration
+	frontends    map[string]*frontendUsage
+	now          func() time.Time
+}
+
+// NewFrontendUsageRecorder returns a new FrontendUsageRecorder reporting as unused
+// the frontends without request during the given window.
+func NewFrontendUsageRecorder(unusedWindow time.Duration) *FrontendUsageRecorder {
+	if unusedWindow <= 0 {
+		unusedWindow = defaultFrontendUnusedWindow
+	}
+	return &FrontendUsageRecorder{
+		unusedWindow: unusedWindow,
+		frontends:    make(map[string]*frontendUsage),
+		now:          time.Now,
+	}
+}
+
+// FrontendUsage holds the usage of a frontend since it is tracked.
+type FrontendUsage struct {
+	Provider     string     `json:"provider,omitempty"`
+	Requests     int64      `json:"requests"`
+	LastRequest  *time.Time `json:"last_request,omitempty"`
+	TrackedSince time.Time  `json:"tracked_since"`
+	Unused       bool       `json:"unused"`
+}
+
+type frontendUsage struct {
+	// requests and lastRequest, in nanoseconds since the epoch, are accessed atomically
+	requests     int64
+	lastRequest  int64
+	provider     string
+	trackedSince time.Time
+}
+
+// Handler returns a handler recording the requests matched by the given frontend before calling next.
+func (r *FrontendUsageRecorder) Handler(next http.Handler, frontendName string) http.Handler {
+	usage := r.track(frontendName)
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&usage.requests, 1)
+		atomic.StoreInt64(&usage.lastRequest, r.now().UnixNano())
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func (r *FrontendUsageRecorder) track(frontendName string) *frontendUsage {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	usage, ok := r.frontends[frontendName]
+	if !ok {
+		usage = &frontendUsage{trackedSince: r.now()}
+		r.frontends[frontendName] = usage
+	}
+	return usage
+}
+
+// Retain tracks the given frontends, by name with the name of their provider,
+// and forgets the usage of the frontends which are no longer part of the configuration.
+func (r *FrontendUsageRecorder) Retain(frontendProviders map[string]string) {
+	now := r.now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for frontendName := range r.frontends {
+		if _, ok := frontendProviders[frontendName]; !ok {
+			delete(r.frontends, frontendName)
+		}
+	}
+	for frontendName, providerName := range frontendProviders {
+		usage, ok := r.frontends[frontendName]
+		if !ok {
+			usage = &frontendUsage{trackedSince: now}
+			r.frontends[frontendName] = usage
+		}
+		usage.provider = providerName
+	}
+}
+
+// Data returns the usage of all the frontends, by frontend.
+func (r *FrontendUsageRecorder) Data() map[string]FrontendUsage {
+	now := r.now()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	data := make(map[string]FrontendUsage)
+	for frontendName, usage := range r.frontends {
+		data[frontendName] = r.aggregate(usage, now)
+	}
+	return data
+}
+
+// aggregate returns the usage of a frontend, which is unused when it has not matched a request during the window,
+// the frontends tracked for less than the window are never unused.
+func (r *FrontendUsageRecorder) aggregate(usage *frontendUsage, now time.Time) FrontendUsage {
+	result := FrontendUsage{
+		Provider:     usage.provider,
+		Requests:     atomic.LoadInt64(&usage.requests),
+		TrackedSince: usage.trackedSince,
+	}
+
+	lastUse := usage.trackedSince
+	if lastRequestNano := atomic.LoadInt64(&usage.lastRequest); lastRequestNano != 0 {
+		lastRequest := time.Unix(0, lastRequestNano).In(usage.trackedSince.Location())
+		result.LastRequest = &lastRequest
+		lastUse = lastRequest
+	}
+	result.Unused = now.Sub(lastUse) >= r.unusedWindow
+	return result
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontendUsageRecorder(t *testing.T) {
+	start := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	recorder := NewFrontendUsageRecorder(time.Hour)
+	recorder.now = func() time.Time { return now }
+
+	handler := recorder.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}), "frontend1")
+	recorder.Retain(map[string]string{"frontend1": "file", "frontend2": "docker"})
+
+	now = now.Add(10 * time.Minute)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	lastRequest := now
+
+	data := recorder.Data()
+	require.Len(t, data, 2)
+	assert.Equal(t, FrontendUsage{Provider: "file", Requests: 1, LastRequest: &lastRequest, TrackedSince: start}, data["frontend1"])
+	assert.Equal(t, FrontendUsage{Provider: "docker", TrackedSince: start}, data["frontend2"])
+
+	// the frontend without request is unused once it has been tracked for the whole window
+	now = start.Add(time.Hour)
+	data = recorder.Data()
+	assert.False(t, data["frontend1"].Unused)
+	assert.True(t, data["frontend2"].Unused)
+
+	now = lastRequest.Add(time.Hour)
+	assert.True(t, recorder.Data()["frontend1"].Unused)
+}
+
+func TestFrontendUsageRecorderRetain(t *testing.T) {
+	start := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	recorder := NewFrontendUsageRecorder(time.Hour)
+	recorder.now = func() time.Time { return now }
+
+	handler := recorder.Handler(http.NotFoundHandler(), "frontend1")
+	recorder.Retain(map[string]string{"frontend1": "file"})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	// the usage is kept across reloads
+	now = now.Add(time.Minute)
+	recorder.Handler(http.NotFoundHandler(), "frontend1")
+	recorder.Retain(map[string]string{"frontend1": "file", "frontend2": "file"})
+
+	data := recorder.Data()
+	assert.Equal(t, int64(1), data["frontend1"].Requests)
+	assert.Equal(t, start, data["frontend1"].TrackedSince)
+	assert.Equal(t, now, data["frontend2"].TrackedSince)
+
+	recorder.Retain(map[string]string{"frontend2": "file"})
+
+	data = recorder.Data()
+	assert.Len(t, data, 1)
+	assert.Contains(t, data, "frontend2")
+}
+
+func TestFrontendUsageRecorderConcurrentRequests(t *testing.T) {
+	recorder := NewFrontendUsageRecorder(time.Hour)
+	handler := recorder.Handler(http.NotFoundHandler(), "frontend1")
+	recorder.Retain(map[string]string{"frontend1": "file"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+				recorder.Data()
+			}
+		}()
+	}
+	wg.Wait()
+
+	data := recorder.Data()
+	assert.Equal(t, int64(1000), data["frontend1"].Requests)
+	assert.NotNil(t, data["frontend1"].LastRequest)
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNamedRouter(name string, started chan<- struct{}, wait <-chan struct{}) *mux.Router {
+	router := mux.NewRouter()
+	router.PathPrefix("/").HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if started != nil {
+			close(started)
+			<-wait
+		}
+		rw.Write([]byte(name))
+	})
+	return router
+}
+
+func TestHandlerSwitcherUpdateDuringRequest(t *testing.T) {
+	started := make(chan struct{})
+	wait := make(chan struct{})
+	switcher := NewHandlerSwitcher(newNamedRouter("old", started, wait))
+
+	inFlight := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		switcher.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+
+	<-started
+	switcher.UpdateHandler(newNamedRouter("new", nil, nil))
+
+	recorder := httptest.NewRecorder()
+	switcher.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "new", recorder.Body.String())
+
+	// the request in flight completes on the router it started on
+	close(wait)
+	<-done
+	assert.Equal(t, "old", inFlight.Body.String())
+}
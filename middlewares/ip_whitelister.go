@@ -1,20 +1,49 @@
 package middlewares
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/whitelist"
 	"github.com/pkg/errors"
 	"github.com/urfave/negroni"
 )
 
+const (
+	defaultWhitelistRefreshInterval = 5 * time.Minute
+	whitelistSourceTimeout          = 10 * time.Second
+	maxWhitelistSourceSize          = 10 << 20
+	forwardedForHeader              = "X-Forwarded-For"
+)
+
 // IPWhiteLister is a middleware that provides Checks of the Requesting IP against a set of Whitelists
 type IPWhiteLister struct {
 	handler     negroni.Handler
 	whiteLister *whitelist.IP
+
+	// The source ranges loaded from files and URLs, refreshed on an interval
+	sourceRange     []string
+	files           []string
+	urls            []string
+	refreshInterval time.Duration
+	client          *http.Client
+	mutex           sync.RWMutex
+	lastRefresh     time.Time
+	refreshing      bool
+
+	// The strategy extracting the client IP from X-Forwarded-For
+	depth          int
+	trustedProxies *whitelist.IP
 }
 
 // NewIPWhitelister builds a new IPWhiteLister given a list of CIDR-Strings to whitelist
@@ -38,15 +67,206 @@ func NewIPWhitelister(whitelistStrings []string) (*IPWhiteLister, error) {
 	return &whiteLister, nil
 }
 
-func (wl *IPWhiteLister) handle(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+// NewIPWhitelisterFromConfig builds a new IPWhiteLister from the whitelist of a frontend:
+// the source ranges of the files and URLs are loaded at once, then refreshed on an interval.
+func NewIPWhitelisterFromConfig(config *types.IPWhitelist) (*IPWhiteLister, error) {
+	if config.Depth < 0 {
+		return nil, fmt.Errorf("invalid X-Forwarded-For depth %d", config.Depth)
+	}
+	if config.Depth > 0 && len(config.TrustedProxies) > 0 {
+		return nil, errors.New("the X-Forwarded-For depth and the trusted proxies are exclusive")
+	}
+
+	wl := &IPWhiteLister{
+		sourceRange:     config.SourceRange,
+		files:           config.Files,
+		urls:            config.URLs,
+		refreshInterval: time.Duration(config.RefreshInterval),
+		client:          &http.Client{Timeout: whitelistSourceTimeout},
+		depth:           config.Depth,
+	}
+	if wl.refreshInterval <= 0 {
+		wl.refreshInterval = defaultWhitelistRefreshInterval
+	}
+
+	if len(config.TrustedProxies) > 0 {
+		trustedProxies, err := whitelist.NewIP(config.TrustedProxies, false)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted proxies %s: %v", config.TrustedProxies, err)
+		}
+		wl.trustedProxies = trustedProxies
+	}
+
+	whiteLister, err := wl.load()
+	if err != nil {
+		return nil, err
+	}
+	wl.whiteLister = whiteLister
+	wl.lastRefresh = time.Now()
+
+	wl.handler = negroni.HandlerFunc(wl.handle)
+	return wl, nil
+}
+
+// load builds the whitelist from the static source ranges and the ones of the files and URLs.
+func (wl *IPWhiteLister) load() (*whitelist.IP, error) {
+	ranges := append([]string{}, wl.sourceRange...)
+
+	for _, file := range wl.files {
+		fileRanges, err := readWhitelistFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading whitelist file %s: %v", file, err)
+		}
+		ranges = append(ranges, fileRanges...)
+	}
+
+	for _, url := range wl.urls {
+		urlRanges, err := wl.readWhitelistURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("reading whitelist URL %s: %v", url, err)
+		}
+		ranges = append(ranges, urlRanges...)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errors.New("no whitelists provided")
+	}
+
+	ip, err := whitelist.NewIP(ranges, false)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CIDR whitelist: %v", err)
+	}
+	log.Debugf("Loaded %d IP whitelists", len(ranges))
+	return ip, nil
+}
+
+func readWhitelistFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseWhitelist(file)
+}
+
+func (wl *IPWhiteLister) readWhitelistURL(url string) ([]string, error) {
+	resp, err := wl.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return parseWhitelist(io.LimitReader(resp.Body, maxWhitelistSourceSize))
+}
+
+// parseWhitelist reads one IP or CIDR per line, ignoring the empty lines and the comments starting with #.
+func parseWhitelist(reader io.Reader) ([]string, error) {
+	var ranges []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			ranges = append(ranges, fields[0])
+		}
+	}
+	return ranges, scanner.Err()
+}
+
+// refreshIfDue reloads the files and URLs in the background once the refresh interval is elapsed,
+// the requests being checked against the previous whitelist in the meantime.
+func (wl *IPWhiteLister) refreshIfDue() {
+	if len(wl.files) == 0 && len(wl.urls) == 0 {
+		return
+	}
+
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+	if wl.refreshing || time.Since(wl.lastRefresh) < wl.refreshInterval {
+		return
+	}
+	wl.refreshing = true
+
+	safe.Go(func() {
+		whiteLister, err := wl.load()
+
+		wl.mutex.Lock()
+		defer wl.mutex.Unlock()
+		wl.refreshing = false
+		wl.lastRefresh = time.Now()
+		if err != nil {
+			log.Errorf("Unable to refresh the IP whitelist, keeping the previous one: %v", err)
+			return
+		}
+		wl.whiteLister = whiteLister
+	})
+}
+
+func (wl *IPWhiteLister) getWhiteLister() *whitelist.IP {
+	wl.mutex.RLock()
+	defer wl.mutex.RUnlock()
+	return wl.whiteLister
+}
+
+// clientIP returns the IP of the client, from the remote address or X-Forwarded-For.
+func (wl *IPWhiteLister) clientIP(r *http.Request) (string, error) {
 	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		log.Warnf("unable to parse remote-address from header: %s - rejecting", r.RemoteAddr)
+		return "", fmt.Errorf("unable to parse remote-address %s", r.RemoteAddr)
+	}
+
+	if wl.depth == 0 && wl.trustedProxies == nil {
+		return ipAddress, nil
+	}
+
+	var forwardedFor []string
+	for _, value := range r.Header[forwardedForHeader] {
+		for _, ip := range strings.Split(value, ",") {
+			forwardedFor = append(forwardedFor, strings.TrimSpace(ip))
+		}
+	}
+
+	if wl.depth > 0 {
+		// The last IP is added by the closest proxy
+		if len(forwardedFor) < wl.depth {
+			return "", fmt.Errorf("less than %d IPs in %s", wl.depth, forwardedForHeader)
+		}
+		return forwardedFor[len(forwardedFor)-wl.depth], nil
+	}
+
+	// The client IP is the first one, from the right, which isn't a trusted proxy
+	if trusted, _, err := wl.trustedProxies.Contains(ipAddress); err != nil || !trusted {
+		return ipAddress, nil
+	}
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		if trusted, _, err := wl.trustedProxies.Contains(forwardedFor[i]); err != nil || !trusted {
+			return forwardedFor[i], nil
+		}
+	}
+	if len(forwardedFor) > 0 {
+		return forwardedFor[0], nil
+	}
+	return ipAddress, nil
+}
+
+func (wl *IPWhiteLister) handle(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	wl.refreshIfDue()
+
+	ipAddress, err := wl.clientIP(r)
+	if err != nil {
+		log.Warnf("unable to get the client IP: %v - rejecting", err)
 		reject(w)
 		return
 	}
 
-	allowed, ip, err := wl.whiteLister.Contains(ipAddress)
+	whiteLister := wl.getWhiteLister()
+	allowed, ip, err := whiteLister.Contains(ipAddress)
 	if err != nil {
 		log.Debugf("source-IP %s matched none of the whitelists - rejecting", ipAddress)
 		reject(w)
@@ -54,7 +274,7 @@ func (wl *IPWhiteLister) handle(w http.ResponseWriter, r *http.Request, next htt
 	}
 
 	if allowed {
-		log.Debugf("source-IP %s matched whitelist %s - passing", ipAddress, wl.whiteLister)
+		log.Debugf("source-IP %s matched whitelist %v - passing", ipAddress, whiteLister)
 		next.ServeHTTP(w, r)
 		return
 	}
@@ -0,0 +1,260 @@
+package middlewares
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIPWhitelisterFromConfig(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.IPWhitelist
+		expectedError bool
+	}{
+		{
+			desc:   "source range",
+			config: &types.IPWhitelist{SourceRange: []string{"10.0.0.0/8"}},
+		},
+		{
+			desc:          "empty whitelist",
+			config:        &types.IPWhitelist{},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid source range",
+			config:        &types.IPWhitelist{SourceRange: []string{"foo"}},
+			expectedError: true,
+		},
+		{
+			desc:          "missing file",
+			config:        &types.IPWhitelist{Files: []string{"/nonexistent/whitelist.txt"}},
+			expectedError: true,
+		},
+		{
+			desc:          "depth and trusted proxies",
+			config:        &types.IPWhitelist{SourceRange: []string{"10.0.0.0/8"}, Depth: 1, TrustedProxies: []string{"10.0.0.0/8"}},
+			expectedError: true,
+		},
+		{
+			desc:          "negative depth",
+			config:        &types.IPWhitelist{SourceRange: []string{"10.0.0.0/8"}, Depth: -1},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid trusted proxies",
+			config:        &types.IPWhitelist{SourceRange: []string{"10.0.0.0/8"}, TrustedProxies: []string{"foo"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewIPWhitelisterFromConfig(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIPWhitelisterSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "whitelist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "whitelist.txt")
+	err = ioutil.WriteFile(file, []byte("# partners\n10.1.0.0/16 partner1\n\n192.168.1.1 # partner2\n"), 0644)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(rw, "172.16.0.0/12")
+	}))
+	defer server.Close()
+
+	wl, err := NewIPWhitelisterFromConfig(&types.IPWhitelist{
+		SourceRange: []string{"10.2.0.0/16"},
+		Files:       []string{file},
+		URLs:        []string{server.URL},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		remoteAddr         string
+		expectedStatusCode int
+	}{
+		{remoteAddr: "10.2.3.4:1234", expectedStatusCode: http.StatusOK},
+		{remoteAddr: "10.1.3.4:1234", expectedStatusCode: http.StatusOK},
+		{remoteAddr: "192.168.1.1:1234", expectedStatusCode: http.StatusOK},
+		{remoteAddr: "172.17.0.1:1234", expectedStatusCode: http.StatusOK},
+		{remoteAddr: "192.168.1.2:1234", expectedStatusCode: http.StatusForbidden},
+		{remoteAddr: "10.3.0.1:1234", expectedStatusCode: http.StatusForbidden},
+	}
+
+	for _, test := range testCases {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+		req.RemoteAddr = test.remoteAddr
+		recorder := httptest.NewRecorder()
+		wl.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {})
+
+		assert.Equal(t, test.expectedStatusCode, recorder.Code, test.remoteAddr)
+	}
+}
+
+func TestIPWhitelisterRefresh(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			fmt.Fprintln(rw, "10.0.0.1")
+		case 2:
+			fmt.Fprintln(rw, "10.0.0.2")
+		default:
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	wl, err := NewIPWhitelisterFromConfig(&types.IPWhitelist{
+		URLs:            []string{server.URL},
+		RefreshInterval: flaeg.Duration(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	status := func(remoteAddr string) int {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		wl.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {})
+		return recorder.Code
+	}
+
+	// The refresh starts with the request, which is checked against the previous whitelist
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, status("10.0.0.1:1234"))
+
+	waitForRefresh := func(expectedCalls int32) {
+		for i := 0; i < 100; i++ {
+			wl.mutex.RLock()
+			done := !wl.refreshing && atomic.LoadInt32(&calls) >= expectedCalls
+			wl.mutex.RUnlock()
+			if done {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("the whitelist hasn't been refreshed")
+	}
+
+	waitForRefresh(2)
+	assert.Equal(t, http.StatusForbidden, status("10.0.0.1:1234"))
+
+	// The failed refresh keeps the previous whitelist
+	waitForRefresh(3)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, status("10.0.0.2:1234"))
+}
+
+func TestIPWhitelisterClientIP(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		config             *types.IPWhitelist
+		remoteAddr         string
+		forwardedFor       []string
+		expectedStatusCode int
+	}{
+		{
+			desc:               "remote address",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.1"}},
+			remoteAddr:         "10.0.0.1:1234",
+			forwardedFor:       []string{"192.168.1.1"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "X-Forwarded-For ignored without strategy",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.1"}},
+			remoteAddr:         "192.168.1.1:1234",
+			forwardedFor:       []string{"10.0.0.1"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "depth",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.1"}, Depth: 2},
+			remoteAddr:         "192.168.1.1:1234",
+			forwardedFor:       []string{"1.2.3.4, 10.0.0.1", "172.16.0.1"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "depth with a spoofed IP",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.1"}, Depth: 1},
+			remoteAddr:         "192.168.1.1:1234",
+			forwardedFor:       []string{"10.0.0.1, 1.2.3.4"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "depth with too few IPs",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.1"}, Depth: 3},
+			remoteAddr:         "10.0.0.1:1234",
+			forwardedFor:       []string{"10.0.0.1"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "trusted proxies",
+			config:             &types.IPWhitelist{SourceRange: []string{"1.2.3.4"}, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr:         "10.0.0.1:1234",
+			forwardedFor:       []string{"5.6.7.8, 1.2.3.4, 10.0.0.2"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "untrusted remote address",
+			config:             &types.IPWhitelist{SourceRange: []string{"1.2.3.4"}, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr:         "192.168.1.1:1234",
+			forwardedFor:       []string{"1.2.3.4"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "only trusted proxies",
+			config:             &types.IPWhitelist{SourceRange: []string{"10.0.0.3"}, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr:         "10.0.0.1:1234",
+			forwardedFor:       []string{"10.0.0.3, 10.0.0.2"},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			wl, err := NewIPWhitelisterFromConfig(test.config)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+			req.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwardedFor {
+				req.Header.Add(forwardedForHeader, value)
+			}
+			recorder := httptest.NewRecorder()
+			wl.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {})
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package middlewares
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// Limits is a middleware rejecting the requests whose URL, headers or body exceed the limits of a frontend.
+// The requests with a known body size are rejected before reading the body, the other ones as soon as
+// the body read by the backend exceeds the limit.
+type Limits struct {
+	maxBodySize    int64
+	maxHeaderCount int
+	maxHeaderSize  int
+	maxURLLength   int
+}
+
+// NewLimits builds a new Limits middleware from the configuration.
+func NewLimits(config *types.Limits) (*Limits, error) {
+	if config.MaxBodySize < 0 || config.MaxHeaderCount < 0 || config.MaxHeaderSize < 0 || config.MaxURLLength < 0 {
+		return nil, fmt.Errorf("invalid negative limit in %+v", *config)
+	}
+	return &Limits{
+		maxBodySize:    config.MaxBodySize,
+		maxHeaderCount: config.MaxHeaderCount,
+		maxHeaderSize:  config.MaxHeaderSize,
+		maxURLLength:   config.MaxURLLength,
+	}, nil
+}
+
+func (l *Limits) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	if l.maxURLLength > 0 && len(req.RequestURI) > l.maxURLLength {
+		l.reject(rw, req, http.StatusRequestURITooLong, "URL of %d bytes", len(req.RequestURI))
+		return
+	}
+
+	if l.maxHeaderCount > 0 || l.maxHeaderSize > 0 {
+		count, size := headerCountAndSize(req.Header)
+		if l.maxHeaderCount > 0 && count > l.maxHeaderCount {
+			l.reject(rw, req, http.StatusRequestHeaderFieldsTooLarge, "%d headers", count)
+			return
+		}
+		if l.maxHeaderSize > 0 && size > l.maxHeaderSize {
+			l.reject(rw, req, http.StatusRequestHeaderFieldsTooLarge, "headers of %d bytes", size)
+			return
+		}
+	}
+
+	if l.maxBodySize <= 0 || req.Body == nil || req.Body == http.NoBody {
+		next(rw, req)
+		return
+	}
+
+	if req.ContentLength > l.maxBodySize {
+		l.reject(rw, req, http.StatusRequestEntityTooLarge, "body of %d bytes", req.ContentLength)
+		return
+	}
+	if req.ContentLength >= 0 {
+		next(rw, req)
+		return
+	}
+
+	// the size of a chunked body is only known while reading it
+	body := &limitedBody{ReadCloser: req.Body, remaining: l.maxBodySize}
+	req.Body = body
+	next(&limitsWriter{ResponseWriter: rw, body: body}, req)
+}
+
+func (l *Limits) reject(rw http.ResponseWriter, req *http.Request, statusCode int, format string, args ...interface{}) {
+	log.Debugf("Rejecting the request to %s with a %s", req.URL.Path, fmt.Sprintf(format, args...))
+	http.Error(rw, http.StatusText(statusCode), statusCode)
+}
+
+// headerCountAndSize returns the number of header fields, and their size as sent on the wire.
+func headerCountAndSize(header http.Header) (int, int) {
+	var count, size int
+	for name, values := range header {
+		for _, value := range values {
+			count++
+			size += len(name) + len(value) + len(": \r\n")
+		}
+	}
+	return count, size
+}
+
+// limitedBody fails the reads once more than the remaining bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  int32
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		atomic.StoreInt32(&b.exceeded, 1)
+		return 0, errBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) isExceeded() bool {
+	return atomic.LoadInt32(&b.exceeded) == 1
+}
+
+// limitsWriter replaces the response of the backend with a 413 when the body of the request was too large.
+type limitsWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *limitsWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.body.isExceeded() {
+		w.rejected = true
+		log.Debug("Rejecting a request with a too large body")
+		for name := range w.ResponseWriter.Header() {
+			delete(w.ResponseWriter.Header(), name)
+		}
+		http.Error(w.ResponseWriter, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *limitsWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *limitsWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (w *limitsWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (w *limitsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.ResponseWriter)
+}
@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimits(t *testing.T) {
+	_, err := NewLimits(&types.Limits{MaxBodySize: 10, MaxURLLength: 100})
+	assert.NoError(t, err)
+
+	_, err = NewLimits(&types.Limits{MaxHeaderCount: -1})
+	assert.Error(t, err)
+}
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		config             types.Limits
+		url                string
+		headers            map[string]string
+		body               string
+		chunked            bool
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			desc:               "no limits",
+			url:                "http://foo.bar/" + strings.Repeat("a", 100),
+			body:               strings.Repeat("a", 100),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:               "URL too long",
+			config:             types.Limits{MaxURLLength: 10},
+			url:                "http://foo.bar/path?query=long",
+			expectedStatusCode: http.StatusRequestURITooLong,
+			expectedBody:       "Request URI Too Long\n",
+		},
+		{
+			desc:               "too many headers",
+			config:             types.Limits{MaxHeaderCount: 2},
+			headers:            map[string]string{"X-A": "a", "X-B": "b", "X-C": "c"},
+			expectedStatusCode: http.StatusRequestHeaderFieldsTooLarge,
+			expectedBody:       "Request Header Fields Too Large\n",
+		},
+		{
+			desc:               "headers too large",
+			config:             types.Limits{MaxHeaderSize: 20},
+			headers:            map[string]string{"X-Large": strings.Repeat("a", 20)},
+			expectedStatusCode: http.StatusRequestHeaderFieldsTooLarge,
+			expectedBody:       "Request Header Fields Too Large\n",
+		},
+		{
+			desc:               "headers within the limits",
+			config:             types.Limits{MaxHeaderCount: 2, MaxHeaderSize: 20},
+			headers:            map[string]string{"X-A": "a"},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:               "body too large",
+			config:             types.Limits{MaxBodySize: 10},
+			body:               strings.Repeat("a", 11),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody:       "Request Entity Too Large\n",
+		},
+		{
+			desc:               "body within the limit",
+			config:             types.Limits{MaxBodySize: 10},
+			body:               strings.Repeat("a", 10),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:               "chunked body too large",
+			config:             types.Limits{MaxBodySize: 10},
+			body:               strings.Repeat("a", 11),
+			chunked:            true,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody:       "Request Entity Too Large\n",
+		},
+		{
+			desc:               "chunked body within the limit",
+			config:             types.Limits{MaxBodySize: 10},
+			body:               strings.Repeat("a", 10),
+			chunked:            true,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			limits, err := NewLimits(&test.config)
+			require.NoError(t, err)
+
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				if _, err := ioutil.ReadAll(r.Body); err != nil {
+					http.Error(rw, err.Error(), http.StatusBadGateway)
+					return
+				}
+				rw.Header().Set("Content-Length", "7")
+				rw.Write([]byte("backend"))
+			}
+
+			url := test.url
+			if url == "" {
+				url = "http://foo.bar/"
+			}
+			req := testhelpers.MustNewRequest(http.MethodPost, url, strings.NewReader(test.body))
+			req.RequestURI = req.URL.RequestURI()
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			limits.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
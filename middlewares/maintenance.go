@@ -0,0 +1,188 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/containous/traefik/whitelist"
+)
+
+const defaultMaintenanceBody = "Service in maintenance"
+
+// Maintenance is a middleware answering the requests of a frontend in maintenance with a static response,
+// except the requests with the bypass header or from the bypass source ranges.
+// The maintenance mode of the configuration can be overridden at runtime through the MaintenanceModes.
+type Maintenance struct {
+	frontendName string
+	enabled      bool
+	modes        *MaintenanceModes
+	statusCode   int
+	body         []byte
+	contentType  string
+	bypassHeader string
+	bypassValue  string
+	whitelister  *whitelist.IP
+}
+
+// NewMaintenance builds a new Maintenance middleware for a frontend, the configuration being optional.
+func NewMaintenance(frontendName string, config *types.Maintenance, modes *MaintenanceModes) (*Maintenance, error) {
+	m := &Maintenance{
+		frontendName: frontendName,
+		modes:        modes,
+		statusCode:   http.StatusServiceUnavailable,
+		body:         []byte(defaultMaintenanceBody),
+		contentType:  "text/plain; charset=utf-8",
+	}
+	if config == nil {
+		return m, nil
+	}
+
+	m.enabled = config.Enabled
+
+	if config.StatusCode != 0 {
+		if config.StatusCode < 200 || config.StatusCode > 599 {
+			return nil, fmt.Errorf("invalid maintenance status code %d", config.StatusCode)
+		}
+		m.statusCode = config.StatusCode
+	}
+
+	switch {
+	case len(config.File) > 0:
+		body, err := ioutil.ReadFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the maintenance page: %v", err)
+		}
+		m.body = body
+		if contentType := mime.TypeByExtension(filepath.Ext(config.File)); len(contentType) > 0 {
+			m.contentType = contentType
+		} else {
+			m.contentType = http.DetectContentType(body)
+		}
+	case len(config.Body) > 0:
+		m.body = []byte(config.Body)
+		m.contentType = http.DetectContentType(m.body)
+	}
+	if len(config.ContentType) > 0 {
+		m.contentType = config.ContentType
+	}
+
+	if len(config.BypassHeader) > 0 {
+		if len(config.BypassValue) == 0 {
+			return nil, fmt.Errorf("missing value of the maintenance bypass header %s", config.BypassHeader)
+		}
+		m.bypassHeader = config.BypassHeader
+		m.bypassValue = config.BypassValue
+	}
+
+	if len(config.BypassSourceRange) > 0 {
+		whitelister, err := whitelist.NewIP(config.BypassSourceRange, false)
+		if err != nil {
+			return nil, fmt.Errorf("parsing maintenance bypass source range %s: %v", config.BypassSourceRange, err)
+		}
+		m.whitelister = whitelister
+	}
+
+	return m, nil
+}
+
+func (m *Maintenance) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !m.isEnabled() || m.bypass(r) {
+		next(rw, r)
+		return
+	}
+
+	rw.Header().Set("Content-Type", m.contentType)
+	rw.Header().Set("Content-Length", strconv.Itoa(len(m.body)))
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(m.statusCode)
+	if r.Method != http.MethodHead {
+		rw.Write(m.body)
+	}
+}
+
+func (m *Maintenance) isEnabled() bool {
+	if m.modes != nil {
+		if enabled, ok := m.modes.Get(m.frontendName); ok {
+			return enabled
+		}
+	}
+	return m.enabled
+}
+
+func (m *Maintenance) bypass(r *http.Request) bool {
+	if len(m.bypassHeader) > 0 && subtle.ConstantTimeCompare([]byte(r.Header.Get(m.bypassHeader)), []byte(m.bypassValue)) == 1 {
+		return true
+	}
+
+	if m.whitelister != nil {
+		ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return false
+		}
+		allowed, _, err := m.whitelister.Contains(ipAddress)
+		if err != nil {
+			log.Debugf("Unable to check the source IP %s against the maintenance bypass source range: %v", ipAddress, err)
+			return false
+		}
+		return allowed
+	}
+
+	return false
+}
+
+// MaintenanceModes holds the maintenance modes set at runtime, overriding the configuration of the frontends.
+type MaintenanceModes struct {
+	mutex sync.RWMutex
+	modes map[string]bool
+}
+
+// NewMaintenanceModes returns an empty set of maintenance modes.
+func NewMaintenanceModes() *MaintenanceModes {
+	return &MaintenanceModes{modes: make(map[string]bool)}
+}
+
+// Set enables or disables the maintenance of a frontend, whatever its configuration.
+func (m *MaintenanceModes) Set(frontendName string, enabled bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.modes[frontendName] = enabled
+}
+
+// Reset restores the maintenance mode of the configuration of a frontend.
+func (m *MaintenanceModes) Reset(frontendName string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.modes, frontendName)
+}
+
+// Get returns the maintenance mode set at runtime for a frontend, if any.
+func (m *MaintenanceModes) Get(frontendName string) (bool, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	enabled, ok := m.modes[frontendName]
+	return enabled, ok
+}
+
+// Data returns the maintenance modes set at runtime, by frontend.
+func (m *MaintenanceModes) Data() map[string]bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	data := make(map[string]bool, len(m.modes))
+	for frontendName, enabled := range m.modes {
+		data[frontendName] = enabled
+	}
+	return data
+}
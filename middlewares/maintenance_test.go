@@ -0,0 +1,221 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaintenance(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.Maintenance
+		expectedError bool
+	}{
+		{
+			desc: "no configuration",
+		},
+		{
+			desc:   "valid configuration",
+			config: &types.Maintenance{Enabled: true, StatusCode: 503, BypassHeader: "X-Bypass", BypassValue: "secret", BypassSourceRange: []string{"10.0.0.0/8"}},
+		},
+		{
+			desc:          "invalid status code",
+			config:        &types.Maintenance{StatusCode: 100},
+			expectedError: true,
+		},
+		{
+			desc:          "missing file",
+			config:        &types.Maintenance{File: "/nonexistent/maintenance.html"},
+			expectedError: true,
+		},
+		{
+			desc:          "bypass header without value",
+			config:        &types.Maintenance{BypassHeader: "X-Bypass"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid bypass source range",
+			config:        &types.Maintenance{BypassSourceRange: []string{"foo"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewMaintenance("frontend1", test.config, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "maintenance.html")
+	err = ioutil.WriteFile(file, []byte("<html>maintenance</html>"), 0644)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                string
+		config              *types.Maintenance
+		override            *bool
+		headers             map[string]string
+		remoteAddr          string
+		expectedStatusCode  int
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			desc:               "disabled",
+			config:             &types.Maintenance{},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:                "enabled with the default response",
+			config:              &types.Maintenance{Enabled: true},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "Service in maintenance",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:                "enabled with a body",
+			config:              &types.Maintenance{Enabled: true, StatusCode: http.StatusOK, Body: "<html><body>Back soon</body></html>"},
+			expectedStatusCode:  http.StatusOK,
+			expectedBody:        "<html><body>Back soon</body></html>",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:                "enabled with a file",
+			config:              &types.Maintenance{Enabled: true, File: file},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "<html>maintenance</html>",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:                "enabled with a content type",
+			config:              &types.Maintenance{Enabled: true, Body: `{"status":"maintenance"}`, ContentType: "application/json"},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        `{"status":"maintenance"}`,
+			expectedContentType: "application/json",
+		},
+		{
+			desc:               "bypass header",
+			config:             &types.Maintenance{Enabled: true, BypassHeader: "X-Bypass", BypassValue: "secret"},
+			headers:            map[string]string{"X-Bypass": "secret"},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:                "wrong bypass header value",
+			config:              &types.Maintenance{Enabled: true, BypassHeader: "X-Bypass", BypassValue: "secret"},
+			headers:             map[string]string{"X-Bypass": "guess"},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "Service in maintenance",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:               "bypass source range",
+			config:             &types.Maintenance{Enabled: true, BypassSourceRange: []string{"10.0.0.0/8"}},
+			remoteAddr:         "10.1.2.3:1234",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc:                "source out of the bypass range",
+			config:              &types.Maintenance{Enabled: true, BypassSourceRange: []string{"10.0.0.0/8"}},
+			remoteAddr:          "192.168.1.1:1234",
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "Service in maintenance",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:                "enabled at runtime",
+			override:            boolPtr(true),
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedBody:        "Service in maintenance",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:               "disabled at runtime",
+			config:             &types.Maintenance{Enabled: true},
+			override:           boolPtr(false),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			modes := NewMaintenanceModes()
+			if test.override != nil {
+				modes.Set("frontend1", *test.override)
+			}
+
+			maintenance, err := NewMaintenance("frontend1", test.config, modes)
+			require.NoError(t, err)
+
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				rw.Write([]byte("backend"))
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			if len(test.remoteAddr) > 0 {
+				req.RemoteAddr = test.remoteAddr
+			}
+			recorder := httptest.NewRecorder()
+			maintenance.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			if len(test.expectedContentType) > 0 {
+				assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestMaintenanceModes(t *testing.T) {
+	modes := NewMaintenanceModes()
+
+	_, ok := modes.Get("frontend1")
+	assert.False(t, ok)
+
+	modes.Set("frontend1", true)
+	modes.Set("frontend2", false)
+	enabled, ok := modes.Get("frontend1")
+	assert.True(t, ok)
+	assert.True(t, enabled)
+	assert.Equal(t, map[string]bool{"frontend1": true, "frontend2": false}, modes.Data())
+
+	modes.Reset("frontend1")
+	_, ok = modes.Get("frontend1")
+	assert.False(t, ok)
+	assert.Equal(t, map[string]bool{"frontend2": false}, modes.Data())
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
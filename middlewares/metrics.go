@@ -1,8 +1,12 @@
 package middlewares
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -14,8 +18,9 @@ import (
 // MetricsWrapper is a Negroni compatible Handler which relies on a
 // given Metrics implementation to expose and monitor Traefik Metrics.
 type MetricsWrapper struct {
-	registry    metrics.Registry
-	serviceName string
+	registry     metrics.Registry
+	serviceName  string
+	countBackend bool
 }
 
 // NewMetricsWrapper return a MetricsWrapper struct with
@@ -29,16 +34,146 @@ func NewMetricsWrapper(registry metrics.Registry, service string) *MetricsWrappe
 	return &metricsWrapper
 }
 
+// NewBackendMetricsWrapper returns a MetricsWrapper of a backend,
+// reporting the requests being forwarded to the backend as its open connections too.
+func NewBackendMetricsWrapper(registry metrics.Registry, backendName string) *MetricsWrapper {
+	return &MetricsWrapper{
+		registry:     registry,
+		serviceName:  backendName,
+		countBackend: true,
+	}
+}
+
+// ServeHTTP records the metrics of the request, labelled with its targets.
+// The wrapper of the entry point, the outermost one, creates the target of the request, shared with the wrappers of the backends.
 func (m *MetricsWrapper) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	start := time.Now()
-	prw := &responseRecorder{rw, http.StatusOK}
+	prw := &sizeRecorder{responseRecorder: &responseRecorder{rw, http.StatusOK}}
+	target, ok := r.Context().Value(metricsTargetKey{}).(*metricsTarget)
+	if !ok {
+		target = &metricsTarget{entryPoint: m.serviceName}
+		r = r.WithContext(context.WithValue(r.Context(), metricsTargetKey{}, target))
+	}
+
+	body := &countingReadCloser{}
+	if r.Body != nil && r.Body != http.NoBody {
+		body.ReadCloser = r.Body
+		r = r.WithContext(r.Context())
+		r.Body = body
+	}
+
+	if m.countBackend {
+		gauge := m.registry.BackendOpenConnsGauge().With("backend", m.serviceName)
+		backendConnections.add(gauge, m.serviceName, 1)
+		defer backendConnections.add(gauge, m.serviceName, -1)
+	}
+
 	next(prw, r)
 
-	reqLabels := []string{"service", m.serviceName, "code", strconv.Itoa(prw.statusCode), "method", getMethod(r)}
+	entryPoint, frontend, backend := target.get()
+	reqLabels := []string{"service", m.serviceName, "entrypoint", entryPoint, "frontend", frontend, "backend", backend, "code", strconv.Itoa(prw.statusCode), "method", getMethod(r)}
 	m.registry.ReqsCounter().With(reqLabels...).Add(1)
 
-	reqDurationLabels := []string{"service", m.serviceName, "code", strconv.Itoa(prw.statusCode)}
+	reqDurationLabels := []string{"service", m.serviceName, "entrypoint", entryPoint, "frontend", frontend, "backend", backend, "code", strconv.Itoa(prw.statusCode)}
 	m.registry.ReqDurationHistogram().With(reqDurationLabels...).Observe(time.Since(start).Seconds())
+	m.registry.ReqSizeHistogram().With(reqDurationLabels...).Observe(float64(body.size()))
+	m.registry.RespSizeHistogram().With(reqDurationLabels...).Observe(float64(prw.size))
+}
+
+// sizeRecorder is a responseRecorder counting the bytes of the response body.
+type sizeRecorder struct {
+	*responseRecorder
+	size int64
+}
+
+func (r *sizeRecorder) Write(b []byte) (int, error) {
+	n, err := r.responseRecorder.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// countingReadCloser counts the bytes read from the request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	read int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(&c.read, int64(n))
+	return n, err
+}
+
+func (c *countingReadCloser) size() int64 {
+	return atomic.LoadInt64(&c.read)
+}
+
+// backendConnections counts the open connections of the backends, across the reloads of the configuration.
+var backendConnections = &openConnections{counts: make(map[string]int)}
+
+// openConnections counts the open connections by name, reporting them to gauges.
+type openConnections struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (c *openConnections) add(gauge gokitmetrics.Gauge, name string, delta int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.counts[name] += delta
+	gauge.Set(float64(c.counts[name]))
+	if c.counts[name] == 0 {
+		delete(c.counts, name)
+	}
+}
+
+type metricsTargetKey struct{}
+
+// metricsTarget holds the entry point, the frontend and the backend of a request, labelling its metrics.
+type metricsTarget struct {
+	mutex      sync.Mutex
+	entryPoint string
+	frontend   string
+	backend    string
+}
+
+func (t *metricsTarget) get() (string, string, string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.entryPoint, t.frontend, t.backend
+}
+
+// MetricsFrontendLabel returns a handler labelling the metrics of the requests with the frontend.
+func MetricsFrontendLabel(next http.Handler, frontendName string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if target, ok := r.Context().Value(metricsTargetKey{}).(*metricsTarget); ok {
+			target.mutex.Lock()
+			target.frontend = frontendName
+			target.mutex.Unlock()
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MetricsBackendLabel is a Negroni compatible Handler labelling the metrics of the requests with the backend.
+// The last backend reached labels the metrics, e.g. the failover backend of a backend.
+type MetricsBackendLabel struct {
+	backendName string
+}
+
+// NewMetricsBackendLabel creates a MetricsBackendLabel labelling the metrics with the backend.
+func NewMetricsBackendLabel(backendName string) *MetricsBackendLabel {
+	return &MetricsBackendLabel{backendName: backendName}
+}
+
+func (m *MetricsBackendLabel) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if target, ok := r.Context().Value(metricsTargetKey{}).(*metricsTarget); ok {
+		target.mutex.Lock()
+		target.backend = m.backendName
+		target.mutex.Unlock()
+	}
+	next(rw, r)
 }
 
 type retryMetrics interface {
@@ -65,7 +200,12 @@ type MetricsRetryListener struct {
 	backendName  string
 }
 
-// Retried tracks the retry in the RequestMetrics implementation.
+// Retried tracks the retry in the RequestMetrics implementation,
+// labelled with the entry point and the frontend of the request if known.
 func (m *MetricsRetryListener) Retried(req *http.Request, attempt int) {
-	m.retryMetrics.RetriesCounter().With("backend", m.backendName).Add(1)
+	var entryPoint, frontend string
+	if target, ok := req.Context().Value(metricsTargetKey{}).(*metricsTarget); ok {
+		entryPoint, frontend, _ = target.get()
+	}
+	m.retryMetrics.RetriesCounter().With("entrypoint", entryPoint, "frontend", frontend, "backend", m.backendName).Add(1)
 }
@@ -1,12 +1,17 @@
 package middlewares
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
-	"github.com/go-kit/kit/metrics"
+	"github.com/containous/traefik/metrics"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/urfave/negroni"
 )
 
 func TestMetricsRetryListener(t *testing.T) {
@@ -21,12 +26,47 @@ func TestMetricsRetryListener(t *testing.T) {
 		t.Errorf("got counter value of %d, want %d", retryMetrics.retryCounter.counterValue, wantCounterValue)
 	}
 
-	wantLabelValues := []string{"backend", "backendName"}
+	wantLabelValues := []string{"entrypoint", "", "frontend", "", "backend", "backendName"}
 	if !reflect.DeepEqual(retryMetrics.retryCounter.lastLabelValues, wantLabelValues) {
 		t.Errorf("wrong label values %v used, want %v", retryMetrics.retryCounter.lastLabelValues, wantLabelValues)
 	}
 }
 
+func TestMetricsRetryListenerTarget(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	target := &metricsTarget{entryPoint: "http", frontend: "frontendName"}
+	req = req.WithContext(context.WithValue(req.Context(), metricsTargetKey{}, target))
+
+	retryMetrics := newCollectingRetryMetrics()
+	NewMetricsRetryListener(retryMetrics, "backendName").Retried(req, 1)
+
+	wantLabelValues := []string{"entrypoint", "http", "frontend", "frontendName", "backend", "backendName"}
+	if !reflect.DeepEqual(retryMetrics.retryCounter.lastLabelValues, wantLabelValues) {
+		t.Errorf("wrong label values %v used, want %v", retryMetrics.retryCounter.lastLabelValues, wantLabelValues)
+	}
+}
+
+func TestMetricsWrapperTargets(t *testing.T) {
+	registry := &collectingRegistry{Registry: metrics.NewVoidRegistry(), reqsCounter: &collectingCounter{}}
+
+	backendHandler := negroni.New(NewMetricsBackendLabel("backendName"), NewMetricsWrapper(registry, "backendName"))
+	backendHandler.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	handler := negroni.New(NewMetricsWrapper(registry, "http"))
+	handler.UseHandler(MetricsFrontendLabel(backendHandler, "frontendName"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	wantLabelValues := [][]string{
+		{"service", "backendName", "entrypoint", "http", "frontend", "frontendName", "backend", "backendName", "code", "404", "method", http.MethodGet},
+		{"service", "http", "entrypoint", "http", "frontend", "frontendName", "backend", "backendName", "code", "404", "method", http.MethodGet},
+	}
+	if !reflect.DeepEqual(registry.reqsCounter.labelValues, wantLabelValues) {
+		t.Errorf("wrong label values %v used, want %v", registry.reqsCounter.labelValues, wantLabelValues)
+	}
+}
+
 // collectingRetryMetrics is an implementation of the retryMetrics interface that can be used inside tests to collect the times Add() was called.
 type collectingRetryMetrics struct {
 	retryCounter *collectingCounter
@@ -36,17 +76,108 @@ func newCollectingRetryMetrics() collectingRetryMetrics {
 	return collectingRetryMetrics{retryCounter: &collectingCounter{}}
 }
 
-func (metrics collectingRetryMetrics) RetriesCounter() metrics.Counter {
+func (metrics collectingRetryMetrics) RetriesCounter() gokitmetrics.Counter {
 	return metrics.retryCounter
 }
 
+func TestMetricsWrapperSizes(t *testing.T) {
+	registry := &collectingRegistry{
+		Registry:          metrics.NewVoidRegistry(),
+		reqsCounter:       &collectingCounter{},
+		reqSizeHistogram:  &collectingHistogram{},
+		respSizeHistogram: &collectingHistogram{},
+		openConnsGauge:    &collectingGauge{},
+	}
+
+	handler := negroni.New(NewBackendMetricsWrapper(registry, "backendName"))
+	handler.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if registry.openConnsGauge.value != 1 {
+			t.Errorf("got %f open connections while forwarding the request, want 1", registry.openConnsGauge.value)
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		rw.Write(append(body, body...))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+
+	if registry.reqSizeHistogram.value != 7 {
+		t.Errorf("got a request size of %f, want 7", registry.reqSizeHistogram.value)
+	}
+	if registry.respSizeHistogram.value != 14 {
+		t.Errorf("got a response size of %f, want 14", registry.respSizeHistogram.value)
+	}
+	if registry.openConnsGauge.value != 0 {
+		t.Errorf("got %f open connections after the request, want 0", registry.openConnsGauge.value)
+	}
+	wantLabelValues := []string{"backend", "backendName"}
+	if !reflect.DeepEqual(registry.openConnsGauge.lastLabelValues, wantLabelValues) {
+		t.Errorf("wrong label values %v used, want %v", registry.openConnsGauge.lastLabelValues, wantLabelValues)
+	}
+}
+
+// collectingRegistry is a metrics.Registry collecting the label values of the requests counter.
+type collectingRegistry struct {
+	metrics.Registry
+	reqsCounter       *collectingCounter
+	reqSizeHistogram  *collectingHistogram
+	respSizeHistogram *collectingHistogram
+	openConnsGauge    *collectingGauge
+}
+
+func (r *collectingRegistry) ReqsCounter() gokitmetrics.Counter {
+	return r.reqsCounter
+}
+
+func (r *collectingRegistry) ReqSizeHistogram() gokitmetrics.Histogram {
+	if r.reqSizeHistogram == nil {
+		return r.Registry.ReqSizeHistogram()
+	}
+	return r.reqSizeHistogram
+}
+
+func (r *collectingRegistry) RespSizeHistogram() gokitmetrics.Histogram {
+	if r.respSizeHistogram == nil {
+		return r.Registry.RespSizeHistogram()
+	}
+	return r.respSizeHistogram
+}
+
+func (r *collectingRegistry) BackendOpenConnsGauge() gokitmetrics.Gauge {
+	if r.openConnsGauge == nil {
+		return r.Registry.BackendOpenConnsGauge()
+	}
+	return r.openConnsGauge
+}
+
+type collectingHistogram struct {
+	value float64
+}
+
+func (h *collectingHistogram) With(labelValues ...string) gokitmetrics.Histogram { return h }
+
+func (h *collectingHistogram) Observe(value float64) { h.value = value }
+
+type collectingGauge struct {
+	value           float64
+	lastLabelValues []string
+}
+
+func (g *collectingGauge) With(labelValues ...string) gokitmetrics.Gauge {
+	g.lastLabelValues = labelValues
+	return g
+}
+
+func (g *collectingGauge) Set(value float64) { g.value = value }
+
 type collectingCounter struct {
 	counterValue    float64
 	lastLabelValues []string
+	labelValues     [][]string
 }
 
-func (c *collectingCounter) With(labelValues ...string) metrics.Counter {
+func (c *collectingCounter) With(labelValues ...string) gokitmetrics.Counter {
 	c.lastLabelValues = labelValues
+	c.labelValues = append(c.labelValues, labelValues)
 	return c
 }
 
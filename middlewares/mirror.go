@@ -0,0 +1,175 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/forward"
+	"github.com/vulcand/oxy/utils"
+)
+
+const (
+	defaultMirrorMaxBodySize  = 1 << 20
+	maxMirrorRequestsInFlight = 100
+)
+
+// Mirror is a middleware duplicating a percentage of the requests to the servers of a secondary backend.
+// The mirrored requests are sent asynchronously, and their responses are ignored.
+type Mirror struct {
+	servers     []*url.URL
+	transport   http.RoundTripper
+	percent     int
+	maxBodySize int64
+	inFlight    chan struct{}
+	random      *rand.Rand
+	randomMutex sync.Mutex
+}
+
+// NewMirror builds a new Mirror middleware sending the requests to the servers with the transport.
+func NewMirror(config *types.Mirror, servers []string, transport http.RoundTripper) (*Mirror, error) {
+	if len(servers) == 0 {
+		return nil, errors.New("no servers to mirror the requests to")
+	}
+	percent := 100
+	if config.Percent != nil {
+		percent = *config.Percent
+	}
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("invalid mirroring percentage %d", percent)
+	}
+	if config.MaxBodySize < 0 {
+		return nil, fmt.Errorf("invalid mirroring max body size %d", config.MaxBodySize)
+	}
+
+	m := &Mirror{
+		transport:   transport,
+		percent:     percent,
+		maxBodySize: config.MaxBodySize,
+		inFlight:    make(chan struct{}, maxMirrorRequestsInFlight),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if m.maxBodySize == 0 {
+		m.maxBodySize = defaultMirrorMaxBodySize
+	}
+
+	for _, server := range servers {
+		serverURL, err := url.Parse(server)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirroring server URL %s: %v", server, err)
+		}
+		m.servers = append(m.servers, serverURL)
+	}
+
+	return m, nil
+}
+
+func (m *Mirror) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	if m.shouldMirror() {
+		if mirrorReq := m.newMirrorRequest(req); mirrorReq != nil {
+			m.send(mirrorReq)
+		}
+	}
+
+	next(rw, req)
+}
+
+// shouldMirror draws the requests to mirror, according to the percentage.
+func (m *Mirror) shouldMirror() bool {
+	if m.percent == 100 {
+		return true
+	}
+	if m.percent == 0 {
+		return false
+	}
+
+	m.randomMutex.Lock()
+	defer m.randomMutex.Unlock()
+	return m.random.Intn(100) < m.percent
+}
+
+func (m *Mirror) pickServer() *url.URL {
+	m.randomMutex.Lock()
+	defer m.randomMutex.Unlock()
+	return m.servers[m.random.Intn(len(m.servers))]
+}
+
+// newMirrorRequest copies the request for a server of the mirror backend,
+// or returns nil if its body is larger than the max body size.
+// The body of the original request is restored to be forwarded to its backend.
+func (m *Mirror) newMirrorRequest(req *http.Request) *http.Request {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > m.maxBodySize {
+			log.Debugf("Not mirroring the request to %s: body larger than %d bytes", req.URL.Path, m.maxBodySize)
+			return nil
+		}
+
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(req.Body, m.maxBodySize+1))
+		req.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+		if err != nil {
+			log.Debugf("Not mirroring the request to %s: unable to read the body: %v", req.URL.Path, err)
+			return nil
+		}
+		if int64(len(body)) > m.maxBodySize {
+			log.Debugf("Not mirroring the request to %s: body larger than %d bytes", req.URL.Path, m.maxBodySize)
+			return nil
+		}
+	}
+
+	server := m.pickServer()
+	mirrorURL := *server
+	mirrorURL.Path = req.URL.Path
+	mirrorURL.RawPath = req.URL.RawPath
+	mirrorURL.RawQuery = req.URL.RawQuery
+
+	mirrorReq, err := http.NewRequest(req.Method, mirrorURL.String(), bytes.NewReader(body))
+	if err != nil {
+		log.Debugf("Not mirroring the request to %s: %v", req.URL.Path, err)
+		return nil
+	}
+	mirrorReq.Host = req.Host
+	mirrorReq.ContentLength = int64(len(body))
+	utils.CopyHeaders(mirrorReq.Header, req.Header)
+	utils.RemoveHeaders(mirrorReq.Header, forward.HopHeaders...)
+
+	return mirrorReq
+}
+
+// send sends the mirrored request in the background, unless too many mirrored requests are in flight.
+func (m *Mirror) send(mirrorReq *http.Request) {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		log.Debugf("Not mirroring the request to %s: too many mirrored requests in flight", mirrorReq.URL.Path)
+		return
+	}
+
+	safe.Go(func() {
+		defer func() { <-m.inFlight }()
+
+		resp, err := m.transport.RoundTrip(mirrorReq)
+		if err != nil {
+			log.Debugf("Error mirroring the request to %s: %v", mirrorReq.URL, err)
+			return
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMirror(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.Mirror
+		servers       []string
+		expectedError bool
+	}{
+		{
+			desc:    "valid configuration",
+			config:  &types.Mirror{Backend: "shadow", Percent: intPtr(10), MaxBodySize: 1024},
+			servers: []string{"http://10.0.0.1:80"},
+		},
+		{
+			desc:          "no servers",
+			config:        &types.Mirror{Backend: "shadow"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid percentage",
+			config:        &types.Mirror{Backend: "shadow", Percent: intPtr(101)},
+			servers:       []string{"http://10.0.0.1:80"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid max body size",
+			config:        &types.Mirror{Backend: "shadow", MaxBodySize: -1},
+			servers:       []string{"http://10.0.0.1:80"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid server URL",
+			config:        &types.Mirror{Backend: "shadow"},
+			servers:       []string{"http://[::1"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewMirror(test.config, test.servers, http.DefaultTransport)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type mirroredRequest struct {
+	method string
+	uri    string
+	host   string
+	header string
+	body   string
+}
+
+func TestMirror(t *testing.T) {
+	mirrored := make(chan mirroredRequest, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mirrored <- mirroredRequest{method: r.Method, uri: r.URL.RequestURI(), host: r.Host, header: r.Header.Get("X-Foo"), body: string(body)}
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+
+	mirror, err := NewMirror(&types.Mirror{MaxBodySize: 10}, []string{shadow.URL}, http.DefaultTransport)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc             string
+		method           string
+		body             string
+		expectedMirrored bool
+	}{
+		{
+			desc:             "GET request",
+			method:           http.MethodGet,
+			expectedMirrored: true,
+		},
+		{
+			desc:             "POST request",
+			method:           http.MethodPost,
+			body:             "0123456789",
+			expectedMirrored: true,
+		},
+		{
+			desc:   "body too large",
+			method: http.MethodPost,
+			body:   "0123456789a",
+		},
+	}
+
+	for _, test := range testCases {
+		var backendBody string
+		next := func(rw http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			backendBody = string(body)
+			rw.Write([]byte("backend"))
+		}
+
+		req := httptest.NewRequest(test.method, "http://foo.bar/path?query=1", strings.NewReader(test.body))
+		req.Header.Set("X-Foo", "bar")
+		req.Header.Set("Connection", "close")
+		recorder := httptest.NewRecorder()
+		mirror.ServeHTTP(recorder, req, next)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, test.desc)
+		assert.Equal(t, "backend", recorder.Body.String(), test.desc)
+		assert.Equal(t, test.body, backendBody, test.desc)
+
+		if !test.expectedMirrored {
+			select {
+			case <-mirrored:
+				t.Errorf("%s: unexpected mirrored request", test.desc)
+			case <-time.After(50 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case r := <-mirrored:
+			assert.Equal(t, mirroredRequest{method: test.method, uri: "/path?query=1", host: "foo.bar", header: "bar", body: test.body}, r, test.desc)
+		case <-time.After(time.Second):
+			t.Errorf("%s: the request hasn't been mirrored", test.desc)
+		}
+	}
+}
+
+func TestMirrorPercent(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		percent  *int
+		expected int
+		delta    float64
+	}{
+		{
+			desc:     "omitted",
+			expected: 1000,
+		},
+		{
+			desc:     "half",
+			percent:  intPtr(50),
+			expected: 500,
+			delta:    100,
+		},
+		{
+			desc:     "disabled",
+			percent:  intPtr(0),
+			expected: 0,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mirror, err := NewMirror(&types.Mirror{Percent: test.percent}, []string{"http://10.0.0.1:80"}, http.DefaultTransport)
+			require.NoError(t, err)
+
+			var drawn int
+			for i := 0; i < 1000; i++ {
+				if mirror.shouldMirror() {
+					drawn++
+				}
+			}
+			assert.InDelta(t, test.expected, drawn, test.delta)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+const (
+	defaultOutlierConsecutiveErrors  = 5
+	defaultOutlierWindow             = 30 * time.Second
+	defaultOutlierBaseEjectionTime   = 30 * time.Second
+	defaultOutlierMaxEjectionTime    = 300 * time.Second
+	defaultOutlierMaxEjectionPercent = 50
+)
+
+// OutlierDetector ejects from the load balancer the servers of a backend answering consecutive 5XX responses
+// (including the 502 and 504 of the connection errors and timeouts) within a window.
+// An ejected server is put back after the ejection time, which doubles with each new ejection.
+// It must be called by the load balancer, once the server is chosen.
+type OutlierDetector struct {
+	next               http.Handler
+	backendName        string
+	consecutiveErrors  int
+	window             time.Duration
+	baseEjectionTime   time.Duration
+	maxEjectionTime    time.Duration
+	maxEjectionPercent int
+
+	mu      sync.Mutex
+	lb      healthcheck.LoadBalancer
+	servers map[string]*outlierServer
+}
+
+type outlierServer struct {
+	url          *url.URL
+	weight       int
+	errors       int
+	firstErrorAt time.Time
+	ejected      bool
+	// ejections is the number of consecutive ejections, reset once the server stays up for the max ejection time.
+	ejections    int
+	reinstatedAt time.Time
+}
+
+// NewOutlierDetector creates an outlier detector of the servers of a backend.
+func NewOutlierDetector(next http.Handler, backendName string, config *types.OutlierDetection) (*OutlierDetector, error) {
+	d := &OutlierDetector{
+		next:               next,
+		backendName:        backendName,
+		consecutiveErrors:  config.ConsecutiveErrors,
+		window:             time.Duration(config.Window),
+		baseEjectionTime:   time.Duration(config.BaseEjectionTime),
+		maxEjectionTime:    time.Duration(config.MaxEjectionTime),
+		maxEjectionPercent: config.MaxEjectionPercent,
+		servers:            make(map[string]*outlierServer),
+	}
+	if d.consecutiveErrors <= 0 {
+		d.consecutiveErrors = defaultOutlierConsecutiveErrors
+	}
+	if d.window <= 0 {
+		d.window = defaultOutlierWindow
+	}
+	if d.baseEjectionTime <= 0 {
+		d.baseEjectionTime = defaultOutlierBaseEjectionTime
+	}
+	if d.maxEjectionTime <= 0 {
+		d.maxEjectionTime = defaultOutlierMaxEjectionTime
+	}
+	if d.maxEjectionTime < d.baseEjectionTime {
+		return nil, fmt.Errorf("the max ejection time %s is shorter than the base ejection time %s", d.maxEjectionTime, d.baseEjectionTime)
+	}
+	if d.maxEjectionPercent == 0 {
+		d.maxEjectionPercent = defaultOutlierMaxEjectionPercent
+	}
+	if d.maxEjectionPercent < 0 || d.maxEjectionPercent > 100 {
+		return nil, fmt.Errorf("invalid max ejection percent %d", d.maxEjectionPercent)
+	}
+	return d, nil
+}
+
+// SetLoadBalancer sets the load balancer the servers are ejected from, and the servers of the backend.
+func (d *OutlierDetector) SetLoadBalancer(lb healthcheck.LoadBalancer, servers map[string]types.Server) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lb = lb
+	for _, server := range servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			return err
+		}
+		d.servers[outlierServerKey(u)] = &outlierServer{url: u, weight: server.Weight}
+	}
+	return nil
+}
+
+func outlierServerKey(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+func (d *OutlierDetector) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	recorder := &responseRecorder{rw, http.StatusOK}
+	d.next.ServeHTTP(recorder, req)
+	d.record(outlierServerKey(req.URL), recorder.statusCode >= http.StatusInternalServerError)
+}
+
+func (d *OutlierDetector) record(key string, failed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	server, ok := d.servers[key]
+	if !ok || server.ejected {
+		return
+	}
+
+	now := time.Now()
+	if !failed {
+		server.errors = 0
+		if server.ejections > 0 && now.Sub(server.reinstatedAt) > d.maxEjectionTime {
+			server.ejections = 0
+		}
+		return
+	}
+
+	if server.errors == 0 || now.Sub(server.firstErrorAt) > d.window {
+		server.errors = 0
+		server.firstErrorAt = now
+	}
+	server.errors++
+	if server.errors >= d.consecutiveErrors {
+		d.eject(server)
+	}
+}
+
+// eject must be called with the lock held.
+func (d *OutlierDetector) eject(server *outlierServer) {
+	if d.lb == nil {
+		return
+	}
+
+	var ejected int
+	for _, s := range d.servers {
+		if s.ejected {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > d.maxEjectionPercent*len(d.servers) {
+		log.Warnf("Server %s of backend %s failed %d consecutive requests, but at most %d%% of the servers can be ejected", server.url, d.backendName, server.errors, d.maxEjectionPercent)
+		server.errors = 0
+		return
+	}
+
+	if err := d.lb.RemoveServer(server.url); err != nil {
+		// the server is already out of the load balancer, e.g. because of its active health check
+		log.Debugf("Unable to eject server %s of backend %s: %v", server.url, d.backendName, err)
+		server.errors = 0
+		return
+	}
+
+	ejectionTime := d.baseEjectionTime << uint(server.ejections)
+	if ejectionTime > d.maxEjectionTime || ejectionTime <= 0 {
+		ejectionTime = d.maxEjectionTime
+	}
+	server.ejected = true
+	server.ejections++
+	server.errors = 0
+	log.Warnf("Server %s of backend %s failed %d consecutive requests: ejected for %s", server.url, d.backendName, d.consecutiveErrors, ejectionTime)
+
+	time.AfterFunc(ejectionTime, func() {
+		d.reinstate(server)
+	})
+}
+
+func (d *OutlierDetector) reinstate(server *outlierServer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	server.ejected = false
+	server.reinstatedAt = time.Now()
+	if err := d.lb.UpsertServer(server.url, roundrobin.Weight(server.weight)); err != nil {
+		log.Errorf("Unable to reinstate server %s of backend %s: %v", server.url, d.backendName, err)
+		return
+	}
+	log.Infof("Server %s of backend %s reinstated", server.url, d.backendName)
+}
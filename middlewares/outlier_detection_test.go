@@ -0,0 +1,156 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func TestNewOutlierDetector(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        types.OutlierDetection
+		expectedError bool
+	}{
+		{
+			desc: "defaults",
+		},
+		{
+			desc:          "max ejection time shorter than the base ejection time",
+			config:        types.OutlierDetection{BaseEjectionTime: flaeg.Duration(time.Minute), MaxEjectionTime: flaeg.Duration(time.Second)},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid max ejection percent",
+			config:        types.OutlierDetection{MaxEjectionPercent: 200},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewOutlierDetector(http.NotFoundHandler(), "backend", &test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOutlierDetector(t *testing.T) {
+	failing := map[string]bool{"a:80": true}
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if failing[req.URL.Host] {
+			rw.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	detector, err := NewOutlierDetector(next, "backend", &types.OutlierDetection{
+		ConsecutiveErrors: 3,
+		BaseEjectionTime:  flaeg.Duration(50 * time.Millisecond),
+		MaxEjectionTime:   flaeg.Duration(time.Second),
+	})
+	require.NoError(t, err)
+
+	rr, err := roundrobin.New(detector)
+	require.NoError(t, err)
+	servers := map[string]types.Server{
+		"a": {URL: "http://a:80", Weight: 2},
+		"b": {URL: "http://b:80", Weight: 1},
+	}
+	for _, server := range servers {
+		require.NoError(t, rr.UpsertServer(testhelpers.MustParseURL(server.URL), roundrobin.Weight(server.Weight)))
+	}
+	require.NoError(t, detector.SetLoadBalancer(rr, servers))
+
+	serve := func(host string) {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		detector.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// a success resets the consecutive errors
+	serve("a:80")
+	serve("a:80")
+	failing["a:80"] = false
+	serve("a:80")
+	failing["a:80"] = true
+	serve("a:80")
+	serve("a:80")
+	assert.Len(t, rr.Servers(), 2)
+
+	serve("a:80")
+	assert.Equal(t, []string{"b:80"}, hosts(rr.Servers()))
+
+	// at most half of the servers are ejected
+	failing["b:80"] = true
+	for i := 0; i < 5; i++ {
+		serve("b:80")
+	}
+	assert.Equal(t, []string{"b:80"}, hosts(rr.Servers()))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, rr.Servers(), 2)
+	weight, _ := rr.ServerWeight(testhelpers.MustParseURL("http://a:80"))
+	assert.Equal(t, 2, weight)
+
+	// the second ejection is twice longer
+	for i := 0; i < 3; i++ {
+		serve("a:80")
+	}
+	assert.Equal(t, []string{"b:80"}, hosts(rr.Servers()))
+	time.Sleep(75 * time.Millisecond)
+	assert.Equal(t, []string{"b:80"}, hosts(rr.Servers()))
+	time.Sleep(75 * time.Millisecond)
+	assert.Len(t, rr.Servers(), 2)
+}
+
+func TestOutlierDetectorWindow(t *testing.T) {
+	detector, err := NewOutlierDetector(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}), "backend", &types.OutlierDetection{
+		ConsecutiveErrors: 2,
+		Window:            flaeg.Duration(20 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	rr, err := roundrobin.New(detector)
+	require.NoError(t, err)
+	servers := map[string]types.Server{
+		"a": {URL: "http://a:80"},
+		"b": {URL: "http://b:80"},
+	}
+	for _, server := range servers {
+		require.NoError(t, rr.UpsertServer(testhelpers.MustParseURL(server.URL)))
+	}
+	require.NoError(t, detector.SetLoadBalancer(rr, servers))
+
+	detector.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+	time.Sleep(40 * time.Millisecond)
+	detector.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+	assert.Len(t, rr.Servers(), 2)
+
+	detector.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a:80/", nil))
+	assert.Equal(t, []string{"b:80"}, hosts(rr.Servers()))
+}
+
+func hosts(urls []*url.URL) []string {
+	var hosts []string
+	for _, u := range urls {
+		hosts = append(hosts, u.Host)
+	}
+	return hosts
+}
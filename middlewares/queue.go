@@ -0,0 +1,255 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/metrics"
+	"github.com/containous/traefik/types"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+)
+
+const defaultQueueTimeout = 10 * time.Second
+
+// BackendQueue limits the number of requests forwarded concurrently to a backend, to each of its servers, or by a frontend.
+// The requests above the limit wait in a bounded queue, and get a 503 (or 429) response with a Retry-After header
+// when the queue is full or when they waited longer than the timeout.
+// The handlers of a queue share its limits, e.g. the handlers of the frontends of its backend.
+type BackendQueue struct {
+	description   string
+	slots         chan struct{}
+	maxConcurrent int64
+	perServer     bool
+	serversLock   sync.Mutex
+	serversSlots  map[string]chan struct{}
+	maxSize       int64
+	timeout       time.Duration
+	statusCode    int
+	queued        int64
+	rejected      int64
+	depthGauge    gokitmetrics.Gauge
+	waitHistogram gokitmetrics.Histogram
+}
+
+// BackendQueueStats holds the current state of a backend queue.
+type BackendQueueStats struct {
+	MaxConcurrent int64 `json:"max_concurrent"`
+	InFlight      int64 `json:"in_flight"`
+	MaxSize       int64 `json:"max_size"`
+	Queued        int64 `json:"queued"`
+	Rejected      int64 `json:"rejected"`
+	// Servers holds the requests in flight by server, when the requests are limited per server.
+	Servers map[string]int64 `json:"servers,omitempty"`
+}
+
+// NewBackendQueue creates the queue of the backend, put in front of the handlers forwarding the requests to the backend.
+// When the queue is per server, its handlers must be called by the load balancer, once the server is chosen.
+func NewBackendQueue(backendName string, config *types.Queue, registry metrics.Registry) (*BackendQueue, error) {
+	queue, err := newQueue("backend "+backendName, config)
+	if err != nil {
+		return nil, err
+	}
+	queue.perServer = config.PerServer
+	queue.depthGauge = registry.QueueDepthGauge().With("backend", backendName)
+	queue.waitHistogram = registry.QueueWaitHistogram().With("backend", backendName)
+	return queue, nil
+}
+
+// NewFrontendQueue creates a queue limiting the requests handled concurrently by a frontend.
+// The frontend queues are not reported in the metrics of the backend queues.
+func NewFrontendQueue(next http.Handler, frontendName string, config *types.Queue) (http.Handler, error) {
+	if config.PerServer {
+		return nil, errors.New("a frontend queue can't be per server")
+	}
+	queue, err := newQueue("frontend "+frontendName, config)
+	if err != nil {
+		return nil, err
+	}
+	registry := metrics.NewVoidRegistry()
+	queue.depthGauge = registry.QueueDepthGauge()
+	queue.waitHistogram = registry.QueueWaitHistogram()
+	return queue.Handler(next), nil
+}
+
+func newQueue(description string, config *types.Queue) (*BackendQueue, error) {
+	if config.MaxConcurrent <= 0 {
+		return nil, fmt.Errorf("invalid maximum of concurrent requests %d", config.MaxConcurrent)
+	}
+	if config.MaxSize < 0 {
+		return nil, fmt.Errorf("invalid queue size %d", config.MaxSize)
+	}
+
+	timeout := defaultQueueTimeout
+	if len(config.Timeout) > 0 {
+		var err error
+		timeout, err = time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queue timeout %q: %v", config.Timeout, err)
+		}
+	}
+
+	statusCode := config.StatusCode
+	switch statusCode {
+	case 0:
+		statusCode = http.StatusServiceUnavailable
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+	default:
+		return nil, fmt.Errorf("invalid queue status code %d, must be 503 or 429", config.StatusCode)
+	}
+
+	return &BackendQueue{
+		description:   description,
+		slots:         make(chan struct{}, config.MaxConcurrent),
+		maxConcurrent: config.MaxConcurrent,
+		serversSlots:  make(map[string]chan struct{}),
+		maxSize:       config.MaxSize,
+		timeout:       timeout,
+		statusCode:    statusCode,
+	}, nil
+}
+
+// Handler returns a handler forwarding the requests to next within the limits of the queue.
+func (q *BackendQueue) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		q.serveHTTP(next, rw, r)
+	})
+}
+
+func (q *BackendQueue) serveHTTP(next http.Handler, rw http.ResponseWriter, r *http.Request) {
+	slots := q.getSlots(r)
+
+	select {
+	case slots <- struct{}{}:
+		q.serve(next, rw, r, slots)
+		return
+	default:
+	}
+
+	if queued := atomic.AddInt64(&q.queued, 1); queued > q.maxSize {
+		q.dequeue()
+		log.Debugf("Queue of the %s is full, rejecting the request", q.description)
+		q.reject(rw)
+		return
+	}
+	q.depthGauge.Set(float64(atomic.LoadInt64(&q.queued)))
+
+	start := time.Now()
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		q.dequeue()
+		q.waitHistogram.Observe(time.Since(start).Seconds())
+		q.serve(next, rw, r, slots)
+	case <-timer.C:
+		q.dequeue()
+		q.waitHistogram.Observe(time.Since(start).Seconds())
+		log.Debugf("Request waited more than %s in the queue of the %s, rejecting the request", q.timeout, q.description)
+		q.reject(rw)
+	case <-r.Context().Done():
+		q.dequeue()
+	}
+}
+
+// getSlots returns the slots of the server chosen by the load balancer when the queue is per server,
+// or the slots shared by all the requests otherwise.
+func (q *BackendQueue) getSlots(r *http.Request) chan struct{} {
+	if !q.perServer {
+		return q.slots
+	}
+
+	q.serversLock.Lock()
+	defer q.serversLock.Unlock()
+
+	slots, ok := q.serversSlots[r.URL.Host]
+	if !ok {
+		slots = make(chan struct{}, q.maxConcurrent)
+		q.serversSlots[r.URL.Host] = slots
+	}
+	return slots
+}
+
+func (q *BackendQueue) serve(next http.Handler, rw http.ResponseWriter, r *http.Request, slots chan struct{}) {
+	defer func() { <-slots }()
+	next.ServeHTTP(rw, r)
+}
+
+func (q *BackendQueue) dequeue() {
+	q.depthGauge.Set(float64(atomic.AddInt64(&q.queued, -1)))
+}
+
+func (q *BackendQueue) reject(rw http.ResponseWriter) {
+	atomic.AddInt64(&q.rejected, 1)
+	retryAfter := int64(math.Ceil(q.timeout.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	rw.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	http.Error(rw, http.StatusText(q.statusCode), q.statusCode)
+}
+
+// Stats returns the current state of the queue.
+func (q *BackendQueue) Stats() BackendQueueStats {
+	queued := atomic.LoadInt64(&q.queued)
+	if queued < 0 {
+		queued = 0
+	}
+	stats := BackendQueueStats{
+		MaxConcurrent: q.maxConcurrent,
+		InFlight:      int64(len(q.slots)),
+		MaxSize:       q.maxSize,
+		Queued:        queued,
+		Rejected:      atomic.LoadInt64(&q.rejected),
+	}
+
+	if q.perServer {
+		q.serversLock.Lock()
+		defer q.serversLock.Unlock()
+
+		stats.Servers = make(map[string]int64)
+		for server, slots := range q.serversSlots {
+			stats.Servers[server] = int64(len(slots))
+			stats.InFlight += int64(len(slots))
+		}
+	}
+	return stats
+}
+
+// BackendQueues holds the queues of the backends, by backend.
+type BackendQueues struct {
+	mutex  sync.RWMutex
+	queues map[string]*BackendQueue
+}
+
+// NewBackendQueues returns an empty set of backend queues.
+func NewBackendQueues() *BackendQueues {
+	return &BackendQueues{queues: make(map[string]*BackendQueue)}
+}
+
+// Replace replaces all the queues, on configuration reload.
+func (b *BackendQueues) Replace(queues map[string]*BackendQueue) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.queues = queues
+}
+
+// Data returns the current state of the queues, by backend.
+func (b *BackendQueues) Data() map[string]BackendQueueStats {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	data := make(map[string]BackendQueueStats)
+	for backendName, queue := range b.queues {
+		data[backendName] = queue.Stats()
+	}
+	return data
+}
@@ -0,0 +1,234 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/metrics"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBackendQueue(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.Queue
+		expectedError bool
+	}{
+		{
+			desc:   "valid configuration",
+			config: &types.Queue{MaxConcurrent: 10, MaxSize: 100, Timeout: "5s"},
+		},
+		{
+			desc:   "default timeout",
+			config: &types.Queue{MaxConcurrent: 10},
+		},
+		{
+			desc:          "no concurrent requests",
+			config:        &types.Queue{MaxSize: 100},
+			expectedError: true,
+		},
+		{
+			desc:          "negative size",
+			config:        &types.Queue{MaxConcurrent: 10, MaxSize: -1},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid timeout",
+			config:        &types.Queue{MaxConcurrent: 10, Timeout: "foo"},
+			expectedError: true,
+		},
+		{
+			desc:   "too many requests status code",
+			config: &types.Queue{MaxConcurrent: 10, StatusCode: http.StatusTooManyRequests},
+		},
+		{
+			desc:          "invalid status code",
+			config:        &types.Queue{MaxConcurrent: 10, StatusCode: http.StatusOK},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewBackendQueue("backend1", test.config, metrics.NewVoidRegistry())
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBackendQueue(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	queue, err := NewBackendQueue("backend1", &types.Queue{MaxConcurrent: 1, MaxSize: 1, Timeout: "10s"}, metrics.NewVoidRegistry())
+	require.NoError(t, err)
+	handler := queue.Handler(next)
+
+	serve := func() chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+			done <- recorder
+		}()
+		return done
+	}
+
+	// the first request is forwarded
+	first := serve()
+	<-started
+
+	// the second request waits in the queue
+	second := serve()
+	waitForQueue(t, queue, 1)
+	assert.Equal(t, BackendQueueStats{MaxConcurrent: 1, InFlight: 1, MaxSize: 1, Queued: 1}, queue.Stats())
+
+	// the third request is rejected as the queue is full
+	recorder := <-serve()
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("Retry-After"))
+
+	// the second request is forwarded once the first one is done
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	<-started
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+
+	assert.Equal(t, BackendQueueStats{MaxConcurrent: 1, MaxSize: 1, Rejected: 1}, queue.Stats())
+}
+
+func TestBackendQueueTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	queue, err := NewBackendQueue("backend1", &types.Queue{MaxConcurrent: 1, MaxSize: 10, Timeout: "10ms"}, metrics.NewVoidRegistry())
+	require.NoError(t, err)
+	handler := queue.Handler(next)
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	<-started
+	defer close(release)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, int64(0), queue.Stats().Queued)
+}
+
+func TestBackendQueuePerServer(t *testing.T) {
+	started := make(chan string)
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		started <- r.URL.Host
+		<-release
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	queue, err := NewBackendQueue("backend1", &types.Queue{MaxConcurrent: 1, Timeout: "10s", PerServer: true, StatusCode: http.StatusTooManyRequests}, metrics.NewVoidRegistry())
+	require.NoError(t, err)
+	handler := queue.Handler(next)
+
+	serve := func(server string) chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://"+server, nil))
+			done <- recorder
+		}()
+		return done
+	}
+
+	// each server has its own slots
+	first := serve("10.0.0.1:80")
+	assert.Equal(t, "10.0.0.1:80", <-started)
+	second := serve("10.0.0.2:80")
+	assert.Equal(t, "10.0.0.2:80", <-started)
+
+	expected := BackendQueueStats{
+		MaxConcurrent: 1,
+		InFlight:      2,
+		Servers:       map[string]int64{"10.0.0.1:80": 1, "10.0.0.2:80": 1},
+	}
+	assert.Equal(t, expected, queue.Stats())
+
+	// the queue is full for the busy server
+	recorder := <-serve("10.0.0.1:80")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("Retry-After"))
+
+	release <- struct{}{}
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+	assert.Equal(t, int64(0), queue.Stats().InFlight)
+}
+
+func TestBackendQueueHandlers(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	queue, err := NewBackendQueue("backend1", &types.Queue{MaxConcurrent: 1, Timeout: "10s"}, metrics.NewVoidRegistry())
+	require.NoError(t, err)
+
+	// the handlers of the frontends of the backend share the limits of its queue
+	go queue.Handler(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	<-started
+	defer close(release)
+
+	recorder := httptest.NewRecorder()
+	queue.Handler(http.NotFoundHandler()).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, BackendQueueStats{MaxConcurrent: 1, InFlight: 1, Rejected: 1}, queue.Stats())
+}
+
+func TestNewFrontendQueue(t *testing.T) {
+	queue, err := NewFrontendQueue(http.NotFoundHandler(), "frontend1", &types.Queue{MaxConcurrent: 1})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	queue.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	_, err = NewFrontendQueue(http.NotFoundHandler(), "frontend1", &types.Queue{MaxConcurrent: 1, PerServer: true})
+	assert.Error(t, err)
+}
+
+func waitForQueue(t *testing.T, queue *BackendQueue, queued int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for queue.Stats().Queued != queued {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %d queued requests", queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
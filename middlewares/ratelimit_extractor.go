@@ -0,0 +1,126 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vulcand/oxy/utils"
+)
+
+const (
+	sourceRequestCookie     = "request.cookie."
+	sourceRequestJWT        = "request.jwt."
+	sourceRequestPathPrefix = "request.path.prefix."
+)
+
+// NewRateLimitExtractor builds the extractor of the rate limiting key from a comma separated list of sources.
+// Besides the sources of oxy (client.ip, request.host and request.header.<name>),
+// the key can be built from request.cookie.<name>, request.jwt.<claim> and request.path.prefix.<segments>.
+func NewRateLimitExtractor(expression string) (utils.SourceExtractor, error) {
+	var extractors []utils.SourceExtractor
+	for _, source := range strings.Split(expression, ",") {
+		extractor, err := newSourceExtractor(strings.TrimSpace(source))
+		if err != nil {
+			return nil, err
+		}
+		extractors = append(extractors, extractor)
+	}
+
+	if len(extractors) == 1 {
+		return extractors[0], nil
+	}
+
+	return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+		tokens := make([]string, len(extractors))
+		for i, extractor := range extractors {
+			token, _, err := extractor.Extract(req)
+			if err != nil {
+				return "", 0, err
+			}
+			tokens[i] = token
+		}
+		return strings.Join(tokens, "\x00"), 1, nil
+	}), nil
+}
+
+func newSourceExtractor(source string) (utils.SourceExtractor, error) {
+	switch {
+	case strings.HasPrefix(source, sourceRequestCookie):
+		name := strings.TrimPrefix(source, sourceRequestCookie)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("missing cookie name in %q", source)
+		}
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			cookie, err := req.Cookie(name)
+			if err != nil {
+				return "", 1, nil
+			}
+			return cookie.Value, 1, nil
+		}), nil
+
+	case strings.HasPrefix(source, sourceRequestJWT):
+		claim := strings.TrimPrefix(source, sourceRequestJWT)
+		if len(claim) == 0 {
+			return nil, fmt.Errorf("missing JWT claim in %q", source)
+		}
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			return bearerClaim(req, claim), 1, nil
+		}), nil
+
+	case strings.HasPrefix(source, sourceRequestPathPrefix):
+		segments, err := strconv.Atoi(strings.TrimPrefix(source, sourceRequestPathPrefix))
+		if err != nil || segments < 1 {
+			return nil, fmt.Errorf("invalid number of path segments in %q", source)
+		}
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			return pathPrefix(req.URL.Path, segments), 1, nil
+		}), nil
+
+	default:
+		return utils.NewExtractor(source)
+	}
+}
+
+// bearerClaim returns a claim of the JWT of the Authorization header, without verifying the token.
+func bearerClaim(req *http.Request, claim string) string {
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimSpace(authorization[7:]), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	switch value := claims[claim].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// pathPrefix returns the first segments of a path, e.g. /api/v1 for the 2 first segments of /api/v1/users.
+func pathPrefix(path string, segments int) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", segments+1)
+	if len(parts) > segments {
+		parts = parts[:segments]
+	}
+	return "/" + strings.Join(parts, "/")
+}
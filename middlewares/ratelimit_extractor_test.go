@@ -0,0 +1,154 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimitExtractor(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"tenant1","plan":"gold","level":3}`)) + ".c2lnbmF0dXJl"
+
+	testCases := []struct {
+		desc          string
+		expression    string
+		path          string
+		headers       map[string]string
+		cookies       map[string]string
+		expectedToken string
+		expectedError bool
+	}{
+		{
+			desc:          "client ip",
+			expression:    "client.ip",
+			expectedToken: "10.0.0.1",
+		},
+		{
+			desc:          "request host",
+			expression:    "request.host",
+			expectedToken: "foo.bar",
+		},
+		{
+			desc:          "request header",
+			expression:    "request.header.X-Api-Key",
+			headers:       map[string]string{"X-Api-Key": "key1"},
+			expectedToken: "key1",
+		},
+		{
+			desc:          "request cookie",
+			expression:    "request.cookie.session",
+			cookies:       map[string]string{"session": "session1"},
+			expectedToken: "session1",
+		},
+		{
+			desc:          "missing cookie",
+			expression:    "request.cookie.session",
+			expectedToken: "",
+		},
+		{
+			desc:          "JWT subject",
+			expression:    "request.jwt.sub",
+			headers:       map[string]string{"Authorization": "Bearer " + token},
+			expectedToken: "tenant1",
+		},
+		{
+			desc:          "JWT numeric claim",
+			expression:    "request.jwt.level",
+			headers:       map[string]string{"Authorization": "bearer " + token},
+			expectedToken: "3",
+		},
+		{
+			desc:          "basic authorization",
+			expression:    "request.jwt.sub",
+			headers:       map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+			expectedToken: "",
+		},
+		{
+			desc:          "malformed JWT",
+			expression:    "request.jwt.sub",
+			headers:       map[string]string{"Authorization": "Bearer a.b!.c"},
+			expectedToken: "",
+		},
+		{
+			desc:          "path prefix",
+			expression:    "request.path.prefix.2",
+			path:          "/api/v1/users/1",
+			expectedToken: "/api/v1",
+		},
+		{
+			desc:          "path shorter than the prefix",
+			expression:    "request.path.prefix.3",
+			path:          "/api",
+			expectedToken: "/api",
+		},
+		{
+			desc:          "combination",
+			expression:    "request.jwt.sub, request.path.prefix.1,client.ip",
+			path:          "/api/v1/users",
+			headers:       map[string]string{"Authorization": "Bearer " + token},
+			expectedToken: "tenant1\x00/api\x0010.0.0.1",
+		},
+		{
+			desc:          "unknown source",
+			expression:    "request.body",
+			expectedError: true,
+		},
+		{
+			desc:          "unknown source in a combination",
+			expression:    "client.ip,request.body",
+			expectedError: true,
+		},
+		{
+			desc:          "missing cookie name",
+			expression:    "request.cookie.",
+			expectedError: true,
+		},
+		{
+			desc:          "missing JWT claim",
+			expression:    "request.jwt.",
+			expectedError: true,
+		},
+		{
+			desc:          "invalid path segments",
+			expression:    "request.path.prefix.0",
+			expectedError: true,
+		},
+		{
+			desc:          "empty expression",
+			expression:    "",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			extractor, err := NewRateLimitExtractor(test.expression)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar"+test.path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			for name, value := range test.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+
+			token, amount, err := extractor.Extract(req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedToken, token)
+			assert.EqualValues(t, 1, amount)
+		})
+	}
+}
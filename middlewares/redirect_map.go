@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/filereload"
+)
+
+// RedirectMap is a middleware redirecting requests according to a map of
+// source paths to target URLs loaded from a CSV (or TSV) file.
+// Each line holds a source path, a target URL and optionally a status code.
+// A source path ending with "*" is a prefix: the rest of the request path is
+// appended to the target.
+// The file is reloaded when it changes.
+type RedirectMap struct {
+	filename   string
+	statusCode int
+	file       *filereload.File
+
+	mu       sync.RWMutex
+	exact    map[string]redirectTarget
+	prefixes map[string]redirectTarget
+}
+
+type redirectTarget struct {
+	url        string
+	statusCode int
+}
+
+// NewRedirectMap creates a RedirectMap middleware from the given file.
+// The status code is used for the entries which do not define their own.
+func NewRedirectMap(filename string, statusCode int) (*RedirectMap, error) {
+	if statusCode == 0 {
+		statusCode = http.StatusMovedPermanently
+	}
+	if !isRedirectStatusCode(statusCode) {
+		return nil, fmt.Errorf("invalid redirect status code %d", statusCode)
+	}
+
+	redirectMap := &RedirectMap{
+		filename:   filename,
+		statusCode: statusCode,
+	}
+	redirectMap.file = filereload.New(filename, "redirect map file", redirectMap.load)
+	if err := redirectMap.file.Load(); err != nil {
+		return nil, err
+	}
+	return redirectMap, nil
+}
+
+func (m *RedirectMap) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	m.file.ReloadIfChanged()
+
+	if target, ok := m.lookup(r.URL.Path); ok {
+		// the query of the request is kept, unless the target has its own
+		location := target.url
+		if len(r.URL.RawQuery) > 0 && !strings.Contains(location, "?") {
+			location += "?" + r.URL.RawQuery
+		}
+		http.Redirect(rw, r, location, target.statusCode)
+		return
+	}
+	next(rw, r)
+}
+
+func (m *RedirectMap) lookup(path string) (redirectTarget, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if target, ok := m.exact[path]; ok {
+		return target, true
+	}
+
+	// longest prefix first
+	for i := len(path); i > 0; i-- {
+		if target, ok := m.prefixes[path[:i]]; ok {
+			return redirectTarget{url: target.url + path[i:], statusCode: target.statusCode}, true
+		}
+	}
+	return redirectTarget{}, false
+}
+
+func (m *RedirectMap) load() error {
+	file, err := os.Open(m.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if strings.EqualFold(filepath.Ext(m.filename), ".tsv") {
+		reader.Comma = '\t'
+	}
+
+	exact := make(map[string]redirectTarget)
+	prefixes := make(map[string]redirectTarget)
+	for entry := 1; ; entry++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(record) < 2 || len(record) > 3 {
+			return fmt.Errorf("entry %d: expected 'source,target[,status]', got %d fields", entry, len(record))
+		}
+
+		target := redirectTarget{url: strings.TrimSpace(record[1]), statusCode: m.statusCode}
+		if len(record) == 3 {
+			target.statusCode, err = strconv.Atoi(strings.TrimSpace(record[2]))
+			if err != nil || !isRedirectStatusCode(target.statusCode) {
+				return fmt.Errorf("entry %d: invalid redirect status code %q", entry, record[2])
+			}
+		}
+
+		source := strings.TrimSpace(record[0])
+		if strings.HasSuffix(source, "*") {
+			prefixes[strings.TrimSuffix(source, "*")] = target
+		} else {
+			exact[source] = target
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exact = exact
+	m.prefixes = prefixes
+	log.Debugf("Loaded %d exact and %d prefix redirects from %s", len(exact), len(prefixes), m.filename)
+	return nil
+}
+
+func isRedirectStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectMap(t *testing.T) {
+	content := `# legacy URLs
+/old-page,https://example.com/new-page
+/promo,/summer-sale,302
+/blog/*,https://blog.example.com/
+/blog/archive/*,https://archive.example.com/
+/search,/find?engine=new
+`
+	redirectMap := newTestRedirectMap(t, "redirects.csv", content, 0)
+
+	testCases := []struct {
+		desc             string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			desc:             "exact match with default status code",
+			path:             "/old-page",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com/new-page",
+		},
+		{
+			desc:             "exact match with custom status code",
+			path:             "/promo",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "/summer-sale",
+		},
+		{
+			desc:             "prefix match",
+			path:             "/blog/2017/hello",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://blog.example.com/2017/hello",
+		},
+		{
+			desc:             "longest prefix wins",
+			path:             "/blog/archive/2010/hello",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://archive.example.com/2010/hello",
+		},
+		{
+			desc:             "query kept",
+			path:             "/blog/2017/hello?page=2",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://blog.example.com/2017/hello?page=2",
+		},
+		{
+			desc:             "query of the target",
+			path:             "/search?q=foo",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/find?engine=new",
+		},
+		{
+			desc:           "no match",
+			path:           "/old-page/other",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://localhost"+test.path, nil)
+			redirectMap.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectMapTSV(t *testing.T) {
+	redirectMap := newTestRedirectMap(t, "redirects.tsv", "/a\t/b\t307\n", 0)
+
+	target, ok := redirectMap.lookup("/a")
+	require.True(t, ok)
+	assert.Equal(t, redirectTarget{url: "/b", statusCode: http.StatusTemporaryRedirect}, target)
+}
+
+func TestRedirectMapInvalid(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		content    string
+		statusCode int
+	}{
+		{
+			desc:    "missing target",
+			content: "/a\n",
+		},
+		{
+			desc:    "invalid entry status code",
+			content: "/a,/b,200\n",
+		},
+		{
+			desc:       "invalid default status code",
+			content:    "/a,/b\n",
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "redirectmap")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			filename := filepath.Join(dir, "redirects.csv")
+			require.NoError(t, ioutil.WriteFile(filename, []byte(test.content), 0644))
+
+			_, err = NewRedirectMap(filename, test.statusCode)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRedirectMapReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redirectmap")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "redirects.csv")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("/a,/b\n"), 0644))
+
+	redirectMap, err := NewRedirectMap(filename, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("/a,/c\n"), 0644))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filename, modTime, modTime))
+
+	redirectMap.file.Expire()
+	redirectMap.file.ReloadIfChanged()
+	target, _ := redirectMap.lookup("/a")
+	assert.Equal(t, "/c", target.url)
+}
+
+func newTestRedirectMap(t *testing.T, name, content string, statusCode int) *RedirectMap {
+	dir, err := ioutil.TempDir("", "redirectmap")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+
+	redirectMap, err := NewRedirectMap(filename, statusCode)
+	require.NoError(t, err)
+	return redirectMap
+}
@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+type redirectRule struct {
+	regex       *regexp.Regexp
+	replacement string
+	permanent   bool
+	rewrite     bool
+}
+
+// RedirectRules is a middleware applying the first of its regex rules changing the URL of a request:
+// the client is redirected to the new URL, or the new path and query are forwarded to the backend in rewrite mode.
+// The regexes match the full URL of the request, e.g. https://foo.bar/path?query, and the replacements can refer to their groups.
+type RedirectRules struct {
+	rules []redirectRule
+}
+
+// NewRedirectRules builds a new RedirectRules middleware from the rules of a frontend.
+func NewRedirectRules(rules []types.RedirectRule) (*RedirectRules, error) {
+	r := &RedirectRules{}
+	for _, rule := range rules {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect regex %q: %v", rule.Regex, err)
+		}
+		r.rules = append(r.rules, redirectRule{
+			regex:       regex,
+			replacement: rule.Replacement,
+			permanent:   rule.Permanent,
+			rewrite:     rule.Rewrite,
+		})
+	}
+	return r, nil
+}
+
+func (r *RedirectRules) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	oldURL := requestURL(req)
+
+	for _, rule := range r.rules {
+		if !rule.regex.MatchString(oldURL) {
+			continue
+		}
+		newURL := rule.regex.ReplaceAllString(oldURL, rule.replacement)
+		if newURL == oldURL {
+			continue
+		}
+
+		if !rule.rewrite {
+			statusCode := http.StatusFound
+			if rule.permanent {
+				statusCode = http.StatusMovedPermanently
+			}
+			http.Redirect(rw, req, newURL, statusCode)
+			return
+		}
+
+		parsedURL, err := url.Parse(newURL)
+		if err != nil {
+			log.Errorf("Error rewriting %s to %s: %v", oldURL, newURL, err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		req.URL.Path = parsedURL.Path
+		req.URL.RawPath = parsedURL.RawPath
+		req.URL.RawQuery = parsedURL.RawQuery
+		req.RequestURI = req.URL.RequestURI()
+		break
+	}
+
+	next(rw, req)
+}
+
+// requestURL returns the full URL of a request, as seen by the client.
+func requestURL(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host + req.URL.RequestURI()
+}
@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedirectRules(t *testing.T) {
+	_, err := NewRedirectRules([]types.RedirectRule{{Regex: "^http://foo.bar/(.*)", Replacement: "https://foo.bar/$1"}})
+	assert.NoError(t, err)
+
+	_, err = NewRedirectRules([]types.RedirectRule{{Regex: "(foo"}})
+	assert.Error(t, err)
+}
+
+func TestRedirectRules(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		rules              []types.RedirectRule
+		url                string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedLocation   string
+		expectedURI        string
+	}{
+		{
+			desc: "temporary redirect with capture groups",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/users/(\d+)/profile$`, Replacement: "http://foo.bar/profiles/$1"},
+			},
+			url:                "http://foo.bar/users/42/profile",
+			expectedStatusCode: http.StatusFound,
+			expectedLocation:   "http://foo.bar/profiles/42",
+		},
+		{
+			desc: "permanent redirect",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/(.*)`, Replacement: "https://foo.bar/$1", Permanent: true},
+			},
+			url:                "http://foo.bar/path?query=1",
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "https://foo.bar/path?query=1",
+		},
+		{
+			desc: "forwarded HTTPS request",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/(.*)`, Replacement: "https://foo.bar/$1", Permanent: true},
+			},
+			url:                "http://foo.bar/path",
+			headers:            map[string]string{"X-Forwarded-Proto": "https"},
+			expectedStatusCode: http.StatusOK,
+			expectedURI:        "/path",
+		},
+		{
+			desc: "first matching rule",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/old/(.*)`, Replacement: "http://foo.bar/new/$1"},
+				{Regex: `^http://foo\.bar/(.*)`, Replacement: "http://foo.bar/other/$1", Permanent: true},
+			},
+			url:                "http://foo.bar/old/page",
+			expectedStatusCode: http.StatusFound,
+			expectedLocation:   "http://foo.bar/new/page",
+		},
+		{
+			desc: "rule not changing the URL",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/(.*)`, Replacement: "http://foo.bar/$1"},
+				{Regex: `^http://foo\.bar/page`, Replacement: "http://foo.bar/other", Permanent: true},
+			},
+			url:                "http://foo.bar/page",
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "http://foo.bar/other",
+		},
+		{
+			desc: "no matching rule",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/old/(.*)`, Replacement: "http://foo.bar/new/$1"},
+			},
+			url:                "http://foo.bar/page",
+			expectedStatusCode: http.StatusOK,
+			expectedURI:        "/page",
+		},
+		{
+			desc: "rewrite",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/api/v1/(\w+)/(\d+)`, Replacement: "/v1/$1?id=$2", Rewrite: true},
+			},
+			url:                "http://foo.bar/api/v1/users/42",
+			expectedStatusCode: http.StatusOK,
+			expectedURI:        "/v1/users?id=42",
+		},
+		{
+			desc: "rewrite before a redirect",
+			rules: []types.RedirectRule{
+				{Regex: `^http://foo\.bar/legacy/(.*)`, Replacement: "http://foo.bar/$1", Rewrite: true},
+				{Regex: `^http://foo\.bar/(.*)`, Replacement: "https://foo.bar/$1"},
+			},
+			url:                "http://foo.bar/legacy/page",
+			expectedStatusCode: http.StatusOK,
+			expectedURI:        "/page",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			redirectRules, err := NewRedirectRules(test.rules)
+			require.NoError(t, err)
+
+			var uri string
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				uri = r.URL.RequestURI()
+				rw.WriteHeader(http.StatusOK)
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, test.url, nil)
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			redirectRules.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+			assert.Equal(t, test.expectedURI, uri)
+		})
+	}
+}
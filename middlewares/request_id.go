@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/types"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	maxRequestIDLength     = 128
+)
+
+type requestIDKey struct{}
+
+// RequestID is a middleware giving a unique ID to each request, or keeping the valid ID of an inbound request.
+// The ID is passed to the backend and returned to the client in the same header,
+// and stored in the request context for the access logs.
+type RequestID struct {
+	header   string
+	generate func() string
+}
+
+// NewRequestID builds a new RequestID middleware.
+func NewRequestID(config *types.RequestID) (*RequestID, error) {
+	r := &RequestID{header: http.CanonicalHeaderKey(strings.TrimSpace(config.Header))}
+	if len(r.header) == 0 {
+		r.header = defaultRequestIDHeader
+	}
+
+	switch strings.ToLower(config.Format) {
+	case "", "uuid":
+		r.generate = func() string {
+			return uuid.NewV4().String()
+		}
+	case "hex":
+		r.generate = randomHexID
+	default:
+		return nil, fmt.Errorf("unsupported request ID format %q", config.Format)
+	}
+
+	return r, nil
+}
+
+func (r *RequestID) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	id := req.Header.Get(r.header)
+	if !validRequestID(id) {
+		id = r.generate()
+		req.Header.Set(r.header, id)
+	}
+
+	// Set before the backend response, in case the handler doesn't write anything
+	rw.Header().Set(r.header, id)
+	ridw := &requestIDWriter{ResponseWriter: rw, header: r.header, id: id}
+	next(ridw, req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+}
+
+// GetRequestID returns the ID given to a request by the RequestID middleware, or an empty string.
+func GetRequestID(req *http.Request) string {
+	if id, ok := req.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// validRequestID checks that an inbound ID can be passed on: not empty, not too long, and made of visible ASCII characters.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHexID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return uuid.NewV4().String()
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDWriter sets the request ID in the response headers, replacing the one returned by the backend if any.
+type requestIDWriter struct {
+	http.ResponseWriter
+	header      string
+	id          string
+	wroteHeader bool
+}
+
+func (w *requestIDWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.Header().Set(w.header, w.id)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *requestIDWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (w *requestIDWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (w *requestIDWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.ResponseWriter)
+}
@@ -0,0 +1,144 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestID(t *testing.T) {
+	_, err := NewRequestID(&types.RequestID{Format: "hex"})
+	assert.NoError(t, err)
+
+	_, err = NewRequestID(&types.RequestID{Format: "ulid"})
+	assert.Error(t, err)
+}
+
+func TestRequestID(t *testing.T) {
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	hexRegex := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	testCases := []struct {
+		desc           string
+		config         *types.RequestID
+		inboundHeader  string
+		inboundID      string
+		backendID      string
+		expectedHeader string
+		expectedID     string
+		expectedRegex  *regexp.Regexp
+	}{
+		{
+			desc:           "generated UUID",
+			config:         &types.RequestID{},
+			expectedHeader: "X-Request-Id",
+			expectedRegex:  uuidRegex,
+		},
+		{
+			desc:           "generated hex ID in a custom header",
+			config:         &types.RequestID{Header: "x-correlation-id", Format: "hex"},
+			expectedHeader: "X-Correlation-Id",
+			expectedRegex:  hexRegex,
+		},
+		{
+			desc:           "inbound ID",
+			config:         &types.RequestID{},
+			inboundHeader:  "X-Request-Id",
+			inboundID:      "abc-123",
+			expectedHeader: "X-Request-Id",
+			expectedID:     "abc-123",
+		},
+		{
+			desc:           "inbound ID too long",
+			config:         &types.RequestID{},
+			inboundHeader:  "X-Request-Id",
+			inboundID:      strings.Repeat("a", maxRequestIDLength+1),
+			expectedHeader: "X-Request-Id",
+			expectedRegex:  uuidRegex,
+		},
+		{
+			desc:           "inbound ID with spaces",
+			config:         &types.RequestID{},
+			inboundHeader:  "X-Request-Id",
+			inboundID:      "abc 123",
+			expectedHeader: "X-Request-Id",
+			expectedRegex:  uuidRegex,
+		},
+		{
+			desc:           "inbound ID in another header",
+			config:         &types.RequestID{Header: "X-Correlation-Id"},
+			inboundHeader:  "X-Request-Id",
+			inboundID:      "abc-123",
+			expectedHeader: "X-Correlation-Id",
+			expectedRegex:  uuidRegex,
+		},
+		{
+			desc:           "ID returned by the backend",
+			config:         &types.RequestID{},
+			inboundHeader:  "X-Request-Id",
+			inboundID:      "abc-123",
+			backendID:      "backend-id",
+			expectedHeader: "X-Request-Id",
+			expectedID:     "abc-123",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			requestID, err := NewRequestID(test.config)
+			require.NoError(t, err)
+
+			var upstreamID, contextID string
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				upstreamID = r.Header.Get(test.expectedHeader)
+				contextID = GetRequestID(r)
+				if len(test.backendID) > 0 {
+					rw.Header().Add(test.expectedHeader, test.backendID)
+				}
+				rw.WriteHeader(http.StatusOK)
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+			if len(test.inboundHeader) > 0 {
+				req.Header.Set(test.inboundHeader, test.inboundID)
+			}
+			recorder := httptest.NewRecorder()
+			requestID.ServeHTTP(recorder, req, next)
+
+			if len(test.expectedID) > 0 {
+				assert.Equal(t, test.expectedID, upstreamID)
+			} else {
+				assert.Regexp(t, test.expectedRegex, upstreamID)
+			}
+			assert.Equal(t, upstreamID, contextID)
+			assert.Equal(t, []string{upstreamID}, recorder.Header()[test.expectedHeader])
+		})
+	}
+}
+
+func TestRequestIDUnique(t *testing.T) {
+	requestID, err := NewRequestID(&types.RequestID{})
+	require.NoError(t, err)
+
+	ids := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		recorder := httptest.NewRecorder()
+		requestID.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil), func(rw http.ResponseWriter, r *http.Request) {})
+		ids[recorder.Header().Get("X-Request-Id")] = true
+	}
+	assert.Len(t, ids, 100)
+}
+
+func TestGetRequestIDWithoutMiddleware(t *testing.T) {
+	assert.Empty(t, GetRequestID(testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)))
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+type deadlineHeaderKey struct{}
+
+// ResponseTimeout bounds the time given to answer a request, retries included:
+// the request is cancelled at the deadline, and answered with a 504 Gateway Timeout unless already answered.
+type ResponseTimeout struct {
+	next           http.Handler
+	timeout        time.Duration
+	deadlineHeader string
+}
+
+// NewResponseTimeout creates a ResponseTimeout from its configuration.
+func NewResponseTimeout(next http.Handler, config *types.ResponseTimeout) (*ResponseTimeout, error) {
+	if config.Timeout <= 0 {
+		return nil, errors.New("the response timeout must be positive")
+	}
+	return &ResponseTimeout{
+		next:           next,
+		timeout:        time.Duration(config.Timeout),
+		deadlineHeader: config.DeadlineHeader,
+	}, nil
+}
+
+func (t *ResponseTimeout) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), t.timeout)
+	defer cancel()
+	if len(t.deadlineHeader) > 0 {
+		ctx = context.WithValue(ctx, deadlineHeaderKey{}, t.deadlineHeader)
+	}
+	t.next.ServeHTTP(rw, r.WithContext(ctx))
+}
+
+// PropagateDeadline returns a handler setting the deadline header of the requests, if any,
+// to the milliseconds remaining before their deadline.
+// It is called by the load balancer once the server is chosen, so that each retry gets the up-to-date value.
+func PropagateDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if header, ok := r.Context().Value(deadlineHeaderKey{}).(string); ok {
+			if deadline, ok := r.Context().Deadline(); ok {
+				remaining := time.Until(deadline) / time.Millisecond
+				if remaining < 0 {
+					remaining = 0
+				}
+				r.Header.Set(header, strconv.FormatInt(int64(remaining), 10))
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResponseTimeout(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.ResponseTimeout
+		expectedError bool
+	}{
+		{
+			desc:   "timeout",
+			config: &types.ResponseTimeout{Timeout: flaeg.Duration(5 * time.Second)},
+		},
+		{
+			desc:   "timeout and deadline header",
+			config: &types.ResponseTimeout{Timeout: flaeg.Duration(5 * time.Second), DeadlineHeader: "X-Request-Deadline"},
+		},
+		{
+			desc:          "deadline header without timeout",
+			config:        &types.ResponseTimeout{DeadlineHeader: "X-Request-Deadline"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewResponseTimeout(http.NotFoundHandler(), test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResponseTimeout(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		deadlineHeader string
+		expectedHeader bool
+	}{
+		{
+			desc: "without deadline header",
+		},
+		{
+			desc:           "with deadline header",
+			deadlineHeader: "X-Request-Deadline",
+			expectedHeader: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var header string
+			server := PropagateDeadline(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				header = req.Header.Get("X-Request-Deadline")
+				select {
+				case <-req.Context().Done():
+					rw.WriteHeader(http.StatusGatewayTimeout)
+				case <-time.After(time.Second):
+					rw.WriteHeader(http.StatusOK)
+				}
+			}))
+
+			handler, err := NewResponseTimeout(server, &types.ResponseTimeout{
+				Timeout:        flaeg.Duration(100 * time.Millisecond),
+				DeadlineHeader: test.deadlineHeader,
+			})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+			assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+			if !test.expectedHeader {
+				assert.Empty(t, header)
+				return
+			}
+			remaining, err := strconv.Atoi(header)
+			require.NoError(t, err)
+			assert.True(t, remaining > 0 && remaining <= 100, "remaining milliseconds %d", remaining)
+		})
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/containous/traefik/log"
 	"github.com/vulcand/oxy/utils"
@@ -17,11 +22,36 @@ var (
 	_ Stateful = &retryResponseRecorder{}
 )
 
+const (
+	retryBudgetWindow     = 10 * time.Second
+	retryBudgetMinRetries = 3
+	defaultRetryMaxWait   = 10 * time.Second
+)
+
+// RetryPolicy restricts the retries and spaces them out
+type RetryPolicy struct {
+	// InitialInterval is the wait before the first retry, doubled at each attempt, with a random jitter
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts
+	MaxInterval time.Duration
+	// Budget is the max percentage of the requests retried
+	Budget int
+	// StatusCodes are the response status code ranges retried, in addition to the network errors
+	StatusCodes []string
+	// IdempotentOnly restricts the retries to the idempotent methods
+	IdempotentOnly bool
+	// MaxBodySize restricts the retries to the requests whose body is known and not larger
+	MaxBodySize int64
+}
+
 // Retry is a middleware that retries requests
 type Retry struct {
-	attempts int
-	next     http.Handler
-	listener RetryListener
+	attempts    int
+	next        http.Handler
+	listener    RetryListener
+	policy      RetryPolicy
+	statusCodes [][2]int
+	budget      *retryBudget
 }
 
 // NewRetry returns a new Retry instance
@@ -33,10 +63,50 @@ func NewRetry(attempts int, next http.Handler, listener RetryListener) *Retry {
 	}
 }
 
+// NewRetryWithPolicy returns a new Retry instance applying a retry policy
+func NewRetryWithPolicy(attempts int, policy RetryPolicy, next http.Handler, listener RetryListener) (*Retry, error) {
+	if policy.Budget < 0 || policy.Budget > 100 {
+		return nil, fmt.Errorf("invalid retry budget %d%%", policy.Budget)
+	}
+	statusCodes, err := ParseHTTPCodeRanges(policy.StatusCodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry status codes %v: %v", policy.StatusCodes, err)
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = defaultRetryMaxWait
+	}
+
+	retry := NewRetry(attempts, next, listener)
+	retry.policy = policy
+	retry.statusCodes = statusCodes
+	if policy.Budget > 0 {
+		retry.budget = &retryBudget{percent: policy.Budget}
+	}
+	return retry, nil
+}
+
 func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	// if we might make multiple attempts, swap the body for an ioutil.NopCloser
-	// cf https://github.com/containous/traefik/issues/1008
-	if retry.attempts > 1 {
+	maxAttempts := retry.maxAttempts(r)
+	if retry.budget != nil {
+		retry.budget.request()
+	}
+
+	if maxAttempts > 1 && len(retry.statusCodes) > 0 && r.Body != nil && r.Body != http.NoBody {
+		// the body is sent again after a response of the backend
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			log.Debugf("Error reading the body of the request %v: %v", r.URL, err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.Body, _ = r.GetBody()
+	} else if maxAttempts > 1 {
+		// if we might make multiple attempts, swap the body for an ioutil.NopCloser
+		// cf https://github.com/containous/traefik/issues/1008
 		body := r.Body
 		defer body.Close()
 		r.Body = ioutil.NopCloser(body)
@@ -59,18 +129,116 @@ func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		if !netErrorOccurred || attempts >= retry.attempts {
+		if !(netErrorOccurred || retry.isRetriedStatus(recorder.Code)) || attempts >= maxAttempts ||
+			(retry.budget != nil && !retry.budget.retry()) || !retry.wait(r, attempts) {
 			utils.CopyHeaders(rw.Header(), recorder.Header())
 			rw.WriteHeader(recorder.Code)
 			rw.Write(recorder.Body.Bytes())
 			break
 		}
 		attempts++
+		if r.GetBody != nil {
+			r.Body, _ = r.GetBody()
+		}
 		log.Debugf("New attempt %d for request: %v", attempts, r.URL)
 		retry.listener.Retried(r, attempts)
 	}
 }
 
+// maxAttempts returns the number of attempts allowed by the policy for a request.
+func (retry *Retry) maxAttempts(r *http.Request) int {
+	if retry.policy.IdempotentOnly && !isIdempotent(r.Method) {
+		return 1
+	}
+	if retry.policy.MaxBodySize > 0 && (r.ContentLength < 0 || r.ContentLength > retry.policy.MaxBodySize) {
+		return 1
+	}
+	return retry.attempts
+}
+
+func (retry *Retry) isRetriedStatus(code int) bool {
+	for _, block := range retry.statusCodes {
+		if code >= block[0] && code <= block[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// wait waits before the next attempt, an exponential backoff with jitter.
+// It returns false if the request is canceled in the meantime.
+func (retry *Retry) wait(r *http.Request, attempts int) bool {
+	if retry.policy.InitialInterval <= 0 {
+		return true
+	}
+
+	interval := retry.policy.InitialInterval
+	for i := 1; i < attempts && interval < retry.policy.MaxInterval; i++ {
+		interval *= 2
+	}
+	if interval > retry.policy.MaxInterval {
+		interval = retry.policy.MaxInterval
+	}
+	// wait between half and all of the interval
+	interval = interval/2 + time.Duration(rand.Int63n(int64(interval/2)+1))
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryBudget limits the retries to a percentage of the requests, over windows of 10 seconds.
+type retryBudget struct {
+	percent int
+
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.slide()
+	b.requests++
+}
+
+// retry returns whether a retry is allowed, and counts it if so.
+func (b *retryBudget) retry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.slide()
+	if b.retries >= retryBudgetMinRetries && b.retries*100 >= b.requests*b.percent {
+		log.Debugf("Retry budget of %d%% exhausted", b.percent)
+		return false
+	}
+	b.retries++
+	return true
+}
+
+func (b *retryBudget) slide() {
+	if time.Since(b.windowStart) < retryBudgetWindow {
+		return
+	}
+	b.windowStart = time.Now()
+	b.requests = 0
+	b.retries = 0
+}
+
 // netErrorCtxKey is a custom type that is used as key for the context.
 type netErrorCtxKey string
 
@@ -6,7 +6,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetry(t *testing.T) {
@@ -134,3 +139,138 @@ type countingRetryListener struct {
 func (l *countingRetryListener) Retried(req *http.Request, attempt int) {
 	l.timesCalled++
 }
+
+func TestRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		policy             RetryPolicy
+		method             string
+		body               string
+		chunked            bool
+		statusCodes        []int
+		expectedStatusCode int
+		expectedCalls      int
+	}{
+		{
+			desc:               "status codes not retried by default",
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCalls:      1,
+		},
+		{
+			desc:               "retried status codes",
+			policy:             RetryPolicy{StatusCodes: []string{"502-504"}},
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedStatusCode: http.StatusOK,
+			expectedCalls:      3,
+		},
+		{
+			desc:               "retried status codes with a body",
+			policy:             RetryPolicy{StatusCodes: []string{"503"}},
+			method:             http.MethodPut,
+			body:               "payload",
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusOK,
+			expectedCalls:      2,
+		},
+		{
+			desc:               "non idempotent method",
+			policy:             RetryPolicy{StatusCodes: []string{"503"}, IdempotentOnly: true},
+			method:             http.MethodPost,
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCalls:      1,
+		},
+		{
+			desc:               "body too large",
+			policy:             RetryPolicy{StatusCodes: []string{"503"}, MaxBodySize: 4},
+			method:             http.MethodPut,
+			body:               "payload",
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCalls:      1,
+		},
+		{
+			desc:               "unknown body size",
+			policy:             RetryPolicy{StatusCodes: []string{"503"}, MaxBodySize: 100},
+			method:             http.MethodPut,
+			body:               "payload",
+			chunked:            true,
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCalls:      1,
+		},
+		{
+			desc:               "backoff",
+			policy:             RetryPolicy{StatusCodes: []string{"503"}, InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond},
+			statusCodes:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatusCode: http.StatusOK,
+			expectedCalls:      3,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, test.body, string(body))
+
+				rw.WriteHeader(test.statusCodes[calls])
+				calls++
+			})
+
+			retry, err := NewRetryWithPolicy(3, test.policy, next, &countingRetryListener{})
+			require.NoError(t, err)
+
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "http://localhost/", strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			retry.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := &retryBudget{percent: 20}
+	for i := 0; i < 20; i++ {
+		budget.request()
+	}
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if budget.retry() {
+			allowed++
+		}
+	}
+	assert.Equal(t, 4, allowed)
+
+	// the minimum number of retries is allowed with little traffic
+	budget = &retryBudget{percent: 20}
+	budget.request()
+	assert.True(t, budget.retry())
+	assert.True(t, budget.retry())
+	assert.True(t, budget.retry())
+	assert.False(t, budget.retry())
+}
+
+func TestNewRetryWithPolicy(t *testing.T) {
+	_, err := NewRetryWithPolicy(3, RetryPolicy{Budget: 150}, http.NotFoundHandler(), RetryListeners{})
+	assert.Error(t, err)
+
+	_, err = NewRetryWithPolicy(3, RetryPolicy{StatusCodes: []string{"5xx"}}, http.NotFoundHandler(), RetryListeners{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,357 @@
+package middlewares
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/filereload"
+	"github.com/containous/traefik/types"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const defaultScriptTimeout = 100 * time.Millisecond
+
+type scriptMetadataKey struct{}
+
+// Script is a middleware running the functions of a Lua script on the requests and responses:
+// on_request(req) can modify the request, answer it without reaching the backend, or set metadata,
+// on_response(res) can modify the status and the headers of the response.
+// The script is reloaded when its file changes.
+type Script struct {
+	filename string
+	timeout  time.Duration
+	file     *filereload.File
+
+	mu      sync.RWMutex
+	program *scriptProgram
+}
+
+// scriptProgram holds the Lua states running a version of the script, a state running one call at a time.
+type scriptProgram struct {
+	source string
+	states sync.Pool
+}
+
+// NewScript builds a new Script middleware from the file of the configuration.
+func NewScript(config *types.Script) (*Script, error) {
+	if len(config.File) == 0 {
+		return nil, errors.New("missing script file")
+	}
+
+	s := &Script{
+		filename: config.File,
+		timeout:  time.Duration(config.Timeout),
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultScriptTimeout
+	}
+
+	s.file = filereload.New(s.filename, "script file", s.load)
+	if err := s.file.Load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetScriptMetadata returns the metadata set on the request by the scripts.
+func GetScriptMetadata(req *http.Request) map[string]string {
+	if metadata, ok := req.Context().Value(scriptMetadataKey{}).(map[string]string); ok {
+		return metadata
+	}
+	return nil
+}
+
+func (s *Script) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	s.file.ReloadIfChanged()
+
+	s.mu.RLock()
+	program := s.program
+	s.mu.RUnlock()
+
+	metadata := GetScriptMetadata(req)
+	if metadata == nil {
+		metadata = make(map[string]string)
+		req = req.WithContext(context.WithValue(req.Context(), scriptMetadataKey{}, metadata))
+	}
+
+	call := &scriptCall{req: req, metadata: metadata}
+	if err := s.run(program, "on_request", call.requestTable); err != nil {
+		log.Errorf("Error running on_request of script %s: %v", s.filename, err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if call.response != nil {
+		for name, value := range call.response.headers {
+			rw.Header().Set(name, value)
+		}
+		rw.Header().Set("Content-Length", strconv.Itoa(len(call.response.body)))
+		rw.WriteHeader(call.response.status)
+		if req.Method != http.MethodHead {
+			rw.Write([]byte(call.response.body))
+		}
+		return
+	}
+
+	next(&scriptWriter{ResponseWriter: rw, script: s, program: program, call: call}, req)
+}
+
+// run calls a function of the script, if defined, with the table built for the call.
+func (s *Script) run(program *scriptProgram, function string, table func(L *lua.LState) *lua.LTable) error {
+	L, ok := program.states.Get().(*lua.LState)
+	if !ok {
+		return errors.New("unable to load the script")
+	}
+
+	fn := L.GetGlobal(function)
+	if fn.Type() != lua.LTFunction {
+		program.states.Put(L)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, table(L))
+	L.RemoveContext()
+	if err != nil {
+		// the state may be left in the middle of the call
+		L.Close()
+		return err
+	}
+
+	program.states.Put(L)
+	return nil
+}
+
+func (s *Script) load() error {
+	source, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	program := &scriptProgram{source: string(source)}
+	L, err := program.newState()
+	if err != nil {
+		return fmt.Errorf("invalid script %s: %v", s.filename, err)
+	}
+	program.states.Put(L)
+	program.states.New = func() interface{} {
+		L, err := program.newState()
+		if err != nil {
+			log.Errorf("Unable to load script %s: %v", s.filename, err)
+			return nil
+		}
+		return L
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.program = program
+	log.Debugf("Loaded script %s", s.filename)
+	return nil
+}
+
+// newState returns a Lua state running the script, with the base, string, table and math libraries,
+// without the functions reaching the file system.
+func (p *scriptProgram) newState() (*lua.LState, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "module", "require", "print"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	L.SetGlobal("log", L.NewFunction(func(L *lua.LState) int {
+		log.Debugf("Script: %s", L.CheckString(1))
+		return 0
+	}))
+
+	if err := L.DoString(p.source); err != nil {
+		L.Close()
+		return nil, err
+	}
+	return L, nil
+}
+
+// scriptCall holds the request handled by the script, and the response set by on_request if any.
+type scriptCall struct {
+	req      *http.Request
+	metadata map[string]string
+	response *scriptResponse
+}
+
+type scriptResponse struct {
+	status  int
+	body    string
+	headers map[string]string
+}
+
+func (c *scriptCall) requestTable(L *lua.LState) *lua.LTable {
+	req := c.req
+	table := L.NewTable()
+	L.SetField(table, "method", lua.LString(req.Method))
+	L.SetField(table, "host", lua.LString(req.Host))
+	L.SetField(table, "path", lua.LString(req.URL.Path))
+	L.SetField(table, "query", lua.LString(req.URL.RawQuery))
+	L.SetField(table, "remote_addr", lua.LString(req.RemoteAddr))
+
+	c.setHeaderFunctions(L, table, req.Header)
+	c.setMetadataFunctions(L, table)
+
+	L.SetField(table, "set_path", L.NewFunction(func(L *lua.LState) int {
+		req.URL.Path = L.CheckString(1)
+		req.URL.RawPath = ""
+		req.RequestURI = req.URL.RequestURI()
+		return 0
+	}))
+	L.SetField(table, "set_query", L.NewFunction(func(L *lua.LState) int {
+		req.URL.RawQuery = L.CheckString(1)
+		req.RequestURI = req.URL.RequestURI()
+		return 0
+	}))
+	L.SetField(table, "respond", L.NewFunction(func(L *lua.LState) int {
+		status := L.CheckInt(1)
+		if status < 200 || status > 599 {
+			L.ArgError(1, fmt.Sprintf("invalid status code %d", status))
+		}
+		response := &scriptResponse{status: status, body: L.OptString(2, ""), headers: make(map[string]string)}
+		if headers := L.OptTable(3, nil); headers != nil {
+			headers.ForEach(func(name lua.LValue, value lua.LValue) {
+				response.headers[name.String()] = value.String()
+			})
+		}
+		c.response = response
+		return 0
+	}))
+	return table
+}
+
+func (c *scriptCall) responseTable(rw http.ResponseWriter, status *int) func(L *lua.LState) *lua.LTable {
+	return func(L *lua.LState) *lua.LTable {
+		table := L.NewTable()
+		L.SetField(table, "status", lua.LNumber(*status))
+
+		c.setHeaderFunctions(L, table, rw.Header())
+		c.setMetadataFunctions(L, table)
+
+		L.SetField(table, "set_status", L.NewFunction(func(L *lua.LState) int {
+			code := L.CheckInt(1)
+			if code < 200 || code > 599 {
+				L.ArgError(1, fmt.Sprintf("invalid status code %d", code))
+			}
+			*status = code
+			return 0
+		}))
+		return table
+	}
+}
+
+func (c *scriptCall) setHeaderFunctions(L *lua.LState, table *lua.LTable, header http.Header) {
+	L.SetField(table, "get_header", L.NewFunction(func(L *lua.LState) int {
+		if values, ok := header[http.CanonicalHeaderKey(L.CheckString(1))]; ok && len(values) > 0 {
+			L.Push(lua.LString(values[0]))
+		} else {
+			L.Push(lua.LNil)
+		}
+		return 1
+	}))
+	L.SetField(table, "set_header", L.NewFunction(func(L *lua.LState) int {
+		header.Set(L.CheckString(1), L.CheckString(2))
+		return 0
+	}))
+	L.SetField(table, "add_header", L.NewFunction(func(L *lua.LState) int {
+		header.Add(L.CheckString(1), L.CheckString(2))
+		return 0
+	}))
+	L.SetField(table, "del_header", L.NewFunction(func(L *lua.LState) int {
+		header.Del(L.CheckString(1))
+		return 0
+	}))
+}
+
+func (c *scriptCall) setMetadataFunctions(L *lua.LState, table *lua.LTable) {
+	L.SetField(table, "get_metadata", L.NewFunction(func(L *lua.LState) int {
+		if value, ok := c.metadata[L.CheckString(1)]; ok {
+			L.Push(lua.LString(value))
+		} else {
+			L.Push(lua.LNil)
+		}
+		return 1
+	}))
+	L.SetField(table, "set_metadata", L.NewFunction(func(L *lua.LState) int {
+		c.metadata[L.CheckString(1)] = L.CheckString(2)
+		return 0
+	}))
+}
+
+// scriptWriter runs on_response before writing the status and the headers of the response.
+type scriptWriter struct {
+	http.ResponseWriter
+	script      *Script
+	program     *scriptProgram
+	call        *scriptCall
+	wroteHeader bool
+}
+
+func (w *scriptWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if err := w.script.run(w.program, "on_response", w.call.responseTable(w.ResponseWriter, &code)); err != nil {
+		log.Errorf("Error running on_response of script %s: %v", w.script.filename, err)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *scriptWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *scriptWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (w *scriptWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (w *scriptWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer %T doesn't support hijacking", w.ResponseWriter)
+}
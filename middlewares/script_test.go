@@ -0,0 +1,201 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, dir string, source string) string {
+	file := filepath.Join(dir, "script.lua")
+	err := ioutil.WriteFile(file, []byte(source), 0644)
+	require.NoError(t, err)
+	return file
+}
+
+func TestNewScript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "script")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewScript(&types.Script{})
+	assert.Error(t, err)
+
+	_, err = NewScript(&types.Script{File: filepath.Join(dir, "missing.lua")})
+	assert.Error(t, err)
+
+	_, err = NewScript(&types.Script{File: writeScript(t, dir, "function on_request(req")})
+	assert.Error(t, err)
+
+	_, err = NewScript(&types.Script{File: writeScript(t, dir, "function on_request(req) end")})
+	assert.NoError(t, err)
+}
+
+func TestScript(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		source             string
+		expectedStatusCode int
+		expectedBody       string
+		expectedHeaders    map[string]string
+		expectedBackend    map[string]string
+	}{
+		{
+			desc:               "no functions",
+			source:             "local x = 1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+		},
+		{
+			desc: "request headers and path",
+			source: `
+function on_request(req)
+  req.set_header("X-Method", req.method .. " " .. req.host)
+  req.set_header("X-Foo", string.upper(req.get_header("X-Foo")))
+  req.del_header("X-Secret")
+  req.set_path("/v2" .. req.path)
+  req.set_query("page=2")
+end`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "backend",
+			expectedBackend:    map[string]string{"X-Method": "GET foo.bar", "X-Foo": "BAR", "X-Secret": "", "URI": "/v2/path?page=2"},
+		},
+		{
+			desc: "short-circuited response",
+			source: `
+function on_request(req)
+  if req.get_header("X-Secret") ~= nil then
+    req.respond(403, "forbidden", {["Content-Type"] = "text/plain"})
+  end
+end`,
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       "forbidden",
+			expectedHeaders:    map[string]string{"Content-Type": "text/plain"},
+		},
+		{
+			desc: "response headers and status",
+			source: `
+function on_request(req)
+  req.set_metadata("version", "canary")
+end
+function on_response(res)
+  if res.status == 200 then
+    res.set_status(202)
+  end
+  res.set_header("X-Version", res.get_metadata("version"))
+  res.del_header("X-Backend")
+end`,
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       "backend",
+			expectedHeaders:    map[string]string{"X-Version": "canary", "X-Backend": ""},
+			expectedBackend:    map[string]string{"Metadata": "canary"},
+		},
+		{
+			desc:               "runtime error",
+			source:             `function on_request(req) error("boom") end`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "Internal Server Error\n",
+		},
+		{
+			desc:               "invalid response status",
+			source:             `function on_request(req) req.respond(42) end`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "Internal Server Error\n",
+		},
+		{
+			desc:               "timeout",
+			source:             `function on_request(req) while true do end end`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "Internal Server Error\n",
+		},
+		{
+			desc:               "file system functions removed",
+			source:             `function on_request(req) dofile("/etc/passwd") end`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "Internal Server Error\n",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "script")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for i, test := range testCases {
+		test := test
+		file := filepath.Join(dir, strconv.Itoa(i)+".lua")
+		err := ioutil.WriteFile(file, []byte(test.source), 0644)
+		require.NoError(t, err)
+
+		t.Run(test.desc, func(t *testing.T) {
+			script, err := NewScript(&types.Script{File: file, Timeout: flaeg.Duration(50 * time.Millisecond)})
+			require.NoError(t, err)
+
+			backend := make(map[string]string)
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				for name := range test.expectedBackend {
+					backend[name] = r.Header.Get(name)
+				}
+				backend["URI"] = r.URL.RequestURI()
+				backend["Metadata"] = GetScriptMetadata(r)["version"]
+				rw.Header().Set("X-Backend", "1")
+				rw.Write([]byte("backend"))
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/path", nil)
+			req.Header.Set("X-Foo", "bar")
+			req.Header.Set("X-Secret", "1")
+			recorder := httptest.NewRecorder()
+			script.ServeHTTP(recorder, req, next)
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+			for name, value := range test.expectedBackend {
+				assert.Equal(t, value, backend[name], name)
+			}
+		})
+	}
+}
+
+func TestScriptReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "script")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := writeScript(t, dir, `function on_request(req) req.respond(200, "v1") end`)
+	script, err := NewScript(&types.Script{File: file})
+	require.NoError(t, err)
+
+	body := func() string {
+		recorder := httptest.NewRecorder()
+		script.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil), func(rw http.ResponseWriter, r *http.Request) {})
+		return recorder.Body.String()
+	}
+	assert.Equal(t, "v1", body())
+
+	writeScript(t, dir, `function on_request(req) req.respond(200, "v2") end`)
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+	script.file.Expire()
+	assert.Equal(t, "v2", body())
+
+	// an invalid script keeps the previous version
+	writeScript(t, dir, `function on_request(req`)
+	modTime = modTime.Add(time.Second)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+	script.file.Expire()
+	assert.Equal(t, "v2", body())
+}
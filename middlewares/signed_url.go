@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultSignatureParam = "signature"
+	defaultExpiresParam   = "expires"
+)
+
+// SignedURL is a middleware rejecting the requests without a valid HMAC signature, or whose expiry timestamp is past.
+// The signature covers the path and the query of the request (without the signature), the parameters being sorted by key,
+// followed by a newline and the expiry timestamp when the timestamp is carried in a header.
+type SignedURL struct {
+	secrets         [][]byte
+	newHash         func() hash.Hash
+	decode          func(string) ([]byte, error)
+	signatureParam  string
+	expiresParam    string
+	signatureHeader string
+	expiresHeader   string
+}
+
+// NewSignedURL builds a new SignedURL middleware from the configuration.
+func NewSignedURL(config *types.SignedURL) (*SignedURL, error) {
+	if len(config.Secrets) == 0 {
+		return nil, errors.New("missing signed URL secrets")
+	}
+
+	s := &SignedURL{
+		signatureParam:  config.SignatureParam,
+		expiresParam:    config.ExpiresParam,
+		signatureHeader: config.SignatureHeader,
+		expiresHeader:   config.ExpiresHeader,
+	}
+	for _, secret := range config.Secrets {
+		if len(secret) == 0 {
+			return nil, errors.New("empty signed URL secret")
+		}
+		s.secrets = append(s.secrets, []byte(secret))
+	}
+	if len(s.signatureParam) == 0 {
+		s.signatureParam = defaultSignatureParam
+	}
+	if len(s.expiresParam) == 0 {
+		s.expiresParam = defaultExpiresParam
+	}
+
+	switch strings.ToLower(config.Algorithm) {
+	case "", "sha256":
+		s.newHash = sha256.New
+	case "sha1":
+		s.newHash = sha1.New
+	case "sha512":
+		s.newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unknown signed URL algorithm %q", config.Algorithm)
+	}
+
+	switch strings.ToLower(config.Encoding) {
+	case "", "hex":
+		s.decode = hex.DecodeString
+	case "base64":
+		s.decode = func(signature string) ([]byte, error) {
+			return base64.RawURLEncoding.DecodeString(strings.TrimRight(signature, "="))
+		}
+	default:
+		return nil, fmt.Errorf("unknown signed URL encoding %q", config.Encoding)
+	}
+
+	return s, nil
+}
+
+func (s *SignedURL) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	if err := s.validate(req, time.Now()); err != nil {
+		log.Debugf("Rejecting the request to %s: %v", req.URL.Path, err)
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	next(rw, req)
+}
+
+func (s *SignedURL) validate(req *http.Request, now time.Time) error {
+	query := req.URL.Query()
+
+	signature := query.Get(s.signatureParam)
+	if len(signature) == 0 && len(s.signatureHeader) > 0 {
+		signature = req.Header.Get(s.signatureHeader)
+	}
+	if len(signature) == 0 {
+		return errors.New("missing signature")
+	}
+	query.Del(s.signatureParam)
+
+	message := req.URL.Path
+	if canonicalQuery := query.Encode(); len(canonicalQuery) > 0 {
+		message += "?" + canonicalQuery
+	}
+
+	expires := query.Get(s.expiresParam)
+	if len(expires) == 0 && len(s.expiresHeader) > 0 {
+		expires = req.Header.Get(s.expiresHeader)
+		message += "\n" + expires
+	}
+	if len(expires) == 0 {
+		return errors.New("missing expiry timestamp")
+	}
+
+	mac, err := s.decode(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+	if !s.isSigned(message, mac) {
+		return errors.New("invalid signature")
+	}
+
+	timestamp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiry timestamp %q", expires)
+	}
+	if now.Unix() > timestamp {
+		return fmt.Errorf("expired at %s", time.Unix(timestamp, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// isSigned returns whether the MAC is the signature of the message with one of the secrets.
+func (s *SignedURL) isSigned(message string, mac []byte) bool {
+	for _, secret := range s.secrets {
+		h := hmac.New(s.newHash, secret)
+		h.Write([]byte(message))
+		if hmac.Equal(h.Sum(nil), mac) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(newHash func() hash.Hash, secret string, message string) []byte {
+	h := hmac.New(newHash, []byte(secret))
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+func TestNewSignedURL(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        types.SignedURL
+		expectedError bool
+	}{
+		{
+			desc:   "defaults",
+			config: types.SignedURL{Secrets: []string{"secret"}},
+		},
+		{
+			desc:          "missing secrets",
+			expectedError: true,
+		},
+		{
+			desc:          "empty secret",
+			config:        types.SignedURL{Secrets: []string{""}},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown algorithm",
+			config:        types.SignedURL{Secrets: []string{"secret"}, Algorithm: "md5"},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown encoding",
+			config:        types.SignedURL{Secrets: []string{"secret"}, Encoding: "base32"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSignedURL(&test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSignedURL(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	testCases := []struct {
+		desc               string
+		config             types.SignedURL
+		url                string
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		{
+			desc:               "valid signature",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?expires=" + future + "&quality=hd&signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?expires="+future+"&quality=hd")),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "parameters in another order",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?expires="+future+"&quality=hd")) + "&quality=hd&expires=" + future,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "rotated secret",
+			config:             types.SignedURL{Secrets: []string{"new", "old"}},
+			url:                "/media/video.mp4?expires=" + future + "&signature=" + hex.EncodeToString(sign(sha256.New, "old", "/media/video.mp4?expires="+future)),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:               "tampered query",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?expires=" + future + "&quality=4k&signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?expires="+future+"&quality=hd")),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "tampered path",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/other.mp4?expires=" + future + "&signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?expires="+future)),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "expired",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?expires=" + past + "&signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?expires="+past)),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "missing expiry timestamp",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?signature=" + hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4")),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "missing signature",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?expires=" + future,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:               "invalid signature encoding",
+			config:             types.SignedURL{Secrets: []string{"secret"}},
+			url:                "/media/video.mp4?expires=" + future + "&signature=zz",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:   "custom parameters, sha512 and base64",
+			config: types.SignedURL{Secrets: []string{"secret"}, Algorithm: "sha512", Encoding: "base64", SignatureParam: "sig", ExpiresParam: "exp"},
+			url: "/media/video.mp4?exp=" + future + "&sig=" +
+				url.QueryEscape(base64.URLEncoding.EncodeToString(sign(sha512.New, "secret", "/media/video.mp4?exp="+future))),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:   "signature and expiry timestamp in headers",
+			config: types.SignedURL{Secrets: []string{"secret"}, SignatureHeader: "X-Signature", ExpiresHeader: "X-Expires"},
+			url:    "/media/video.mp4?quality=hd",
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4?quality=hd\n"+future)),
+				"X-Expires":   future,
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			desc:   "tampered expiry timestamp in headers",
+			config: types.SignedURL{Secrets: []string{"secret"}, SignatureHeader: "X-Signature", ExpiresHeader: "X-Expires"},
+			url:    "/media/video.mp4",
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", "/media/video.mp4\n"+past)),
+				"X-Expires":   future,
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			signedURL, err := NewSignedURL(&test.config)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar"+test.url, nil)
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			signedURL.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {})
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+		})
+	}
+}
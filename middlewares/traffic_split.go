@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrafficSplit splits the requests of a frontend between several backends, each one receiving a percentage of them.
+// The requests which are not drawn for one of these backends go to the backend of the frontend.
+type TrafficSplit struct {
+	next        http.Handler
+	backends    []splitBackend
+	random      *rand.Rand
+	randomMutex sync.Mutex
+}
+
+type splitBackend struct {
+	name    string
+	percent int
+	handler http.Handler
+}
+
+// NewTrafficSplit creates a TrafficSplit sending the percentages of the requests to the handlers of the backends,
+// and the remaining requests to next.
+func NewTrafficSplit(next http.Handler, percents map[string]int, handlers map[string]http.Handler) (*TrafficSplit, error) {
+	names := make([]string, 0, len(percents))
+	for name := range percents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := &TrafficSplit{
+		next:   next,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	var total int
+	for _, name := range names {
+		percent := percents[name]
+		if percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid percentage %d of backend %s", percent, name)
+		}
+		handler, ok := handlers[name]
+		if !ok {
+			return nil, fmt.Errorf("no handler for backend %s", name)
+		}
+		total += percent
+		t.backends = append(t.backends, splitBackend{name: name, percent: percent, handler: handler})
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("the percentages of the backends add up to %d", total)
+	}
+	return t, nil
+}
+
+func (t *TrafficSplit) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	t.pick().ServeHTTP(rw, r)
+}
+
+// pick draws the handler of the request, according to the percentages of the backends.
+func (t *TrafficSplit) pick() http.Handler {
+	t.randomMutex.Lock()
+	draw := t.random.Intn(100)
+	t.randomMutex.Unlock()
+
+	for _, backend := range t.backends {
+		if draw < backend.percent {
+			return backend.handler
+		}
+		draw -= backend.percent
+	}
+	return t.next
+}
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrafficSplit(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"v2": http.NotFoundHandler(),
+		"v3": http.NotFoundHandler(),
+	}
+
+	testCases := []struct {
+		desc          string
+		percents      map[string]int
+		expectedError bool
+	}{
+		{
+			desc:     "valid percentages",
+			percents: map[string]int{"v2": 10, "v3": 90},
+		},
+		{
+			desc:          "negative percentage",
+			percents:      map[string]int{"v2": -10},
+			expectedError: true,
+		},
+		{
+			desc:          "percentages above 100",
+			percents:      map[string]int{"v2": 60, "v3": 50},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown backend",
+			percents:      map[string]int{"v4": 10},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewTrafficSplit(http.NotFoundHandler(), test.percents, handlers)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTrafficSplit(t *testing.T) {
+	counts := make(map[string]int)
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			counts[name]++
+		})
+	}
+
+	split, err := NewTrafficSplit(handler("v1"), map[string]int{"v2": 10, "v3": 0}, map[string]http.Handler{
+		"v2": handler("v2"),
+		"v3": handler("v3"),
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10000; i++ {
+		split.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	}
+
+	assert.InDelta(t, 9000, counts["v1"], 300)
+	assert.InDelta(t, 1000, counts["v2"], 300)
+	assert.Zero(t, counts["v3"])
+}
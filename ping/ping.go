@@ -1,15 +1,18 @@
 package ping
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/containous/mux"
+	"github.com/containous/traefik/log"
 )
 
 //Handler expose ping routes
 type Handler struct {
 	EntryPoint string `description:"Ping entryPoint" export:"true"`
+	Statuses   *ProviderStatuses
 }
 
 // AddRoutes add ping routes on a router
@@ -18,4 +21,34 @@ func (g Handler) AddRoutes(router *mux.Router) {
 		HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 			fmt.Fprint(response, "OK")
 		})
+
+	router.Methods(http.MethodGet, http.MethodHead).Path("/healthz").
+		HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			status := g.status()
+			writeStatus(response, status, status.Healthy)
+		})
+	router.Methods(http.MethodGet, http.MethodHead).Path("/readyz").
+		HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			status := g.status()
+			writeStatus(response, status, status.Ready)
+		})
+}
+
+func (g Handler) status() Status {
+	if g.Statuses == nil {
+		return Status{Healthy: true, Providers: map[string]ProviderStatus{}}
+	}
+	return g.Statuses.Status()
+}
+
+func writeStatus(response http.ResponseWriter, status Status, ok bool) {
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+	}
+	response.Header().Set("Content-Type", "application/json; charset=utf-8")
+	response.WriteHeader(code)
+	if err := json.NewEncoder(response).Encode(status); err != nil {
+		log.Error(err)
+	}
 }
@@ -0,0 +1,106 @@
+package ping
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderStatuses(t *testing.T) {
+	now := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	statuses := NewProviderStatuses()
+	statuses.now = func() time.Time { return now }
+
+	status := statuses.Status()
+	assert.False(t, status.Ready)
+	assert.True(t, status.Healthy)
+
+	statuses.Refreshed("file")
+	statuses.Applied("file")
+	now = now.Add(time.Minute)
+	statuses.Refreshed("docker")
+	statuses.Failed("docker", errors.New("invalid configuration"))
+	now = now.Add(30 * time.Second)
+
+	status = statuses.Status()
+	assert.True(t, status.Ready)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "1m30s", status.Providers["file"].ConfigAge)
+	assert.True(t, status.Providers["file"].Healthy)
+	assert.Equal(t, "invalid configuration", status.Providers["docker"].LastError)
+	assert.Nil(t, status.Providers["docker"].LastApplied)
+	assert.False(t, status.Providers["docker"].Healthy)
+
+	statuses.Applied("docker")
+	status = statuses.Status()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, "invalid configuration", status.Providers["docker"].LastError, "the last error is kept")
+}
+
+func TestHandler(t *testing.T) {
+	statuses := NewProviderStatuses()
+	router := mux.NewRouter()
+	Handler{Statuses: statuses}.AddRoutes(router)
+
+	testCases := []struct {
+		desc           string
+		update         func()
+		path           string
+		expectedStatus int
+		expectedReady  bool
+	}{
+		{
+			desc:           "ping",
+			path:           "/ping",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "not ready",
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:           "healthy without providers",
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "ready",
+			update:         func() { statuses.Applied("file") },
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedReady:  true,
+		},
+		{
+			desc:           "unhealthy",
+			update:         func() { statuses.Failed("docker", errors.New("connection refused")) },
+			path:           "/healthz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReady:  true,
+		},
+	}
+
+	// The cases depend on the previous ones
+	for _, test := range testCases {
+		if test.update != nil {
+			test.update()
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		assert.Equal(t, test.expectedStatus, recorder.Code, test.desc)
+
+		if test.path != "/ping" {
+			var status Status
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status), test.desc)
+			assert.Equal(t, test.expectedReady, status.Ready, test.desc)
+		}
+	}
+}
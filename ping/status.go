@@ -0,0 +1,111 @@
+package ping
+
+import (
+	"sync"
+	"time"
+)
+
+// ProviderStatus is the state of a provider.
+type ProviderStatus struct {
+	LastRefresh   *time.Time `json:"lastRefresh,omitempty"`
+	LastApplied   *time.Time `json:"lastApplied,omitempty"`
+	ConfigAge     string     `json:"configAge,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	Healthy       bool       `json:"healthy"`
+}
+
+// Status is the state of the providers, and the overall readiness.
+type Status struct {
+	Ready     bool                      `json:"ready"`
+	Healthy   bool                      `json:"healthy"`
+	Providers map[string]ProviderStatus `json:"providers"`
+}
+
+type providerState struct {
+	lastRefresh   time.Time
+	lastApplied   time.Time
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// ProviderStatuses tracks, for each provider, its last configuration received and applied, and its last error.
+type ProviderStatuses struct {
+	mutex     sync.RWMutex
+	providers map[string]*providerState
+	now       func() time.Time
+}
+
+// NewProviderStatuses creates a ProviderStatuses.
+func NewProviderStatuses() *ProviderStatuses {
+	return &ProviderStatuses{
+		providers: make(map[string]*providerState),
+		now:       time.Now,
+	}
+}
+
+// Refreshed records that a configuration was received from the provider.
+func (s *ProviderStatuses) Refreshed(providerName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.state(providerName).lastRefresh = s.now()
+}
+
+// Applied records that the configuration of the provider was applied.
+func (s *ProviderStatuses) Applied(providerName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.state(providerName).lastApplied = s.now()
+}
+
+// Failed records an error of the provider, while starting it or applying its configuration.
+func (s *ProviderStatuses) Failed(providerName string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state := s.state(providerName)
+	state.lastError = err.Error()
+	state.lastErrorTime = s.now()
+}
+
+func (s *ProviderStatuses) state(providerName string) *providerState {
+	state, ok := s.providers[providerName]
+	if !ok {
+		state = &providerState{}
+		s.providers[providerName] = state
+	}
+	return state
+}
+
+// Status returns the state of the providers.
+// Traefik is ready once a configuration has been applied, and a provider is healthy
+// while it has not failed since its last configuration was applied.
+func (s *ProviderStatuses) Status() Status {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	status := Status{Healthy: true, Providers: make(map[string]ProviderStatus, len(s.providers))}
+	for name, state := range s.providers {
+		providerStatus := ProviderStatus{
+			LastRefresh:   timeOrNil(state.lastRefresh),
+			LastApplied:   timeOrNil(state.lastApplied),
+			LastError:     state.lastError,
+			LastErrorTime: timeOrNil(state.lastErrorTime),
+			Healthy:       state.lastErrorTime.IsZero() || state.lastApplied.After(state.lastErrorTime),
+		}
+		if !state.lastApplied.IsZero() {
+			providerStatus.ConfigAge = now.Sub(state.lastApplied).Truncate(time.Second).String()
+			status.Ready = true
+		}
+		status.Healthy = status.Healthy && providerStatus.Healthy
+		status.Providers[name] = providerStatus
+	}
+	return status
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
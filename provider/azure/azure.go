@@ -0,0 +1,273 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	autorestazure "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/BurntSushi/ty/fun"
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	resourceKindContainerGroup = "containerGroup"
+	resourceKindWebApp         = "webApp"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	provider.BaseProvider `mapstructure:",squash" export:"true"`
+
+	Domain           string `description:"Default domain used"`
+	ExposedByDefault bool   `description:"Expose resources by default" export:"true"`
+	RefreshSeconds   int    `description:"Polling interval (in seconds)" export:"true"`
+
+	// Provider lookup parameters
+	ResourceGroups  []string          `description:"Resource groups names (default: all the resource groups of the subscription)"`
+	Tags            map[string]string `description:"Tags the resources must have to be discovered"`
+	ContainerGroups bool              `description:"Discover the Container Instances container groups" export:"true"`
+	WebApps         bool              `description:"Discover the App Service web apps" export:"true"`
+	Environment     string            `description:"The Azure cloud environment name (default: AzurePublicCloud)" export:"true"`
+	SubscriptionID  string            `description:"The Azure subscription ID" export:"true"`
+	TenantID        string            `description:"The Azure Active Directory tenant ID of the service principal"`
+	ClientID        string            `description:"The client ID of the service principal"`
+	ClientSecret    string            `description:"The client secret of the service principal"`
+}
+
+// azureResource is a container group or a web app, with the address it is reachable on.
+type azureResource struct {
+	ID   string
+	Name string
+	Kind string
+	Host string
+	Port string
+	Tags map[string]string
+}
+
+func (p *Provider) createClient() (resourceManagerAPI, error) {
+	environment := autorestazure.PublicCloud
+	if len(p.Environment) > 0 {
+		var err error
+		environment, err = autorestazure.EnvironmentFromName(p.Environment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subscriptionID := getValueOrEnv(p.SubscriptionID, "AZURE_SUBSCRIPTION_ID")
+	if len(subscriptionID) == 0 {
+		return nil, errors.New("no Azure subscription ID provided")
+	}
+
+	token, err := p.newServicePrincipalToken(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResourceManagerClient(environment.ResourceManagerEndpoint, subscriptionID, autorest.NewBearerAuthorizer(token)), nil
+}
+
+// newServicePrincipalToken authenticates with the service principal credentials when provided,
+// with the managed service identity of the virtual machine otherwise.
+func (p *Provider) newServicePrincipalToken(environment autorestazure.Environment) (*adal.ServicePrincipalToken, error) {
+	clientID := getValueOrEnv(p.ClientID, "AZURE_CLIENT_ID")
+	clientSecret := getValueOrEnv(p.ClientSecret, "AZURE_CLIENT_SECRET")
+	if len(clientID) > 0 && len(clientSecret) > 0 {
+		oauthConfig, err := adal.NewOAuthConfig(environment.ActiveDirectoryEndpoint, getValueOrEnv(p.TenantID, "AZURE_TENANT_ID"))
+		if err != nil {
+			return nil, err
+		}
+		return adal.NewServicePrincipalToken(*oauthConfig, clientID, clientSecret, environment.ResourceManagerEndpoint)
+	}
+
+	log.Infoln("No Azure service principal provided, using the managed service identity...")
+	msiEndpoint, err := adal.GetMSIVMEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return adal.NewServicePrincipalTokenFromMSI(msiEndpoint, environment.ResourceManagerEndpoint)
+}
+
+func getValueOrEnv(value, envName string) string {
+	if len(value) > 0 {
+		return value
+	}
+	return os.Getenv(envName)
+}
+
+// Provide allows the azure provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+
+	p.Constraints = append(p.Constraints, constraints...)
+
+	handleCanceled := func(ctx context.Context, err error) error {
+		if ctx.Err() == context.Canceled || err == context.Canceled {
+			return nil
+		}
+		return err
+	}
+
+	pool.Go(func(stop chan bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		safe.Go(func() {
+			select {
+			case <-stop:
+				cancel()
+			}
+		})
+
+		operation := func() error {
+			client, err := p.createClient()
+			if err != nil {
+				return err
+			}
+
+			configuration, err := p.loadAzureConfig(ctx, client)
+			if err != nil {
+				return handleCanceled(ctx, err)
+			}
+
+			configurationChan <- types.ConfigMessage{
+				ProviderName:  "azure",
+				Configuration: configuration,
+			}
+
+			if p.Watch {
+				reload := time.NewTicker(time.Second * time.Duration(p.RefreshSeconds))
+				defer reload.Stop()
+				for {
+					select {
+					case <-reload.C:
+						configuration, err := p.loadAzureConfig(ctx, client)
+						if err != nil {
+							return handleCanceled(ctx, err)
+						}
+
+						configurationChan <- types.ConfigMessage{
+							ProviderName:  "azure",
+							Configuration: configuration,
+						}
+					case <-ctx.Done():
+						return handleCanceled(ctx, ctx.Err())
+					}
+				}
+			}
+
+			return nil
+		}
+
+		notify := func(err error, time time.Duration) {
+			log.Errorf("Provider connection error %+v, retrying in %s", err, time)
+		}
+		err := backoff.RetryNotify(safe.OperationWithRecover(operation), job.NewBackOff(backoff.NewExponentialBackOff()), notify)
+		if err != nil {
+			log.Errorf("Cannot connect to Provider api %+v", err)
+		}
+	})
+
+	return nil
+}
+
+func (p *Provider) loadAzureConfig(ctx context.Context, client resourceManagerAPI) (*types.Configuration, error) {
+	resources, err := p.listResources(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	resources = fun.Filter(p.filterResource, resources).([]azureResource)
+
+	services := make(map[string][]azureResource)
+	for _, resource := range resources {
+		name := getServiceName(resource)
+		services[name] = append(services[name], resource)
+	}
+	return p.buildConfiguration(services)
+}
+
+// listResources lists the running container groups and web apps of the resource groups, or of the whole subscription.
+func (p *Provider) listResources(ctx context.Context, client resourceManagerAPI) ([]azureResource, error) {
+	resourceGroups := p.ResourceGroups
+	if len(resourceGroups) == 0 {
+		resourceGroups = []string{""}
+	}
+
+	var resources []azureResource
+	for _, resourceGroup := range resourceGroups {
+		if p.ContainerGroups {
+			groups, err := client.ListContainerGroups(ctx, resourceGroup)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, group := range groups {
+				if group.Properties.ProvisioningState != provisioningStateSucceeded {
+					log.Debugf("Filtering Azure container group %s in state %s", group.Name, group.Properties.ProvisioningState)
+					continue
+				}
+				resources = append(resources, newContainerGroupResource(group))
+			}
+		}
+
+		if p.WebApps {
+			apps, err := client.ListWebApps(ctx, resourceGroup)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, app := range apps {
+				if !app.Properties.Enabled || app.Properties.State != webAppStateRunning {
+					log.Debugf("Filtering Azure web app %s in state %s", app.Name, app.Properties.State)
+					continue
+				}
+				resources = append(resources, newWebAppResource(app))
+			}
+		}
+	}
+	return resources, nil
+}
+
+// newContainerGroupResource uses the IP address of the container group and its first TCP port.
+func newContainerGroupResource(group containerGroup) azureResource {
+	resource := azureResource{
+		ID:   group.ID,
+		Name: group.Name,
+		Kind: resourceKindContainerGroup,
+		Tags: group.Tags,
+	}
+	if group.Properties.IPAddress != nil {
+		resource.Host = group.Properties.IPAddress.IP
+		for _, port := range group.Properties.IPAddress.Ports {
+			if len(port.Protocol) == 0 || strings.EqualFold(port.Protocol, "TCP") {
+				resource.Port = strconv.Itoa(port.Port)
+				break
+			}
+		}
+	}
+	return resource
+}
+
+// newWebAppResource uses the default HTTPS host name of the web app.
+func newWebAppResource(app webApp) azureResource {
+	return azureResource{
+		ID:   app.ID,
+		Name: app.Name,
+		Kind: resourceKindWebApp,
+		Host: app.Properties.DefaultHostName,
+		Port: "443",
+		Tags: app.Tags,
+	}
+}
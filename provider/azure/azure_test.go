@@ -0,0 +1,163 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	containerGroups map[string][]containerGroup
+	webApps         map[string][]webApp
+	err             error
+}
+
+func (c *fakeClient) ListContainerGroups(ctx context.Context, resourceGroup string) ([]containerGroup, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.containerGroups[resourceGroup], nil
+}
+
+func (c *fakeClient) ListWebApps(ctx context.Context, resourceGroup string) ([]webApp, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.webApps[resourceGroup], nil
+}
+
+func newFakeClient() *fakeClient {
+	whoami := containerGroup{
+		ID:   "/subscriptions/sub/resourceGroups/production/providers/Microsoft.ContainerInstance/containerGroups/whoami",
+		Name: "whoami",
+		Tags: map[string]string{"environment": "production"},
+		Properties: containerGroupProperties{
+			ProvisioningState: provisioningStateSucceeded,
+			IPAddress: &ipAddress{
+				IP:    "10.0.0.1",
+				Ports: []port{{Protocol: "UDP", Port: 53}, {Protocol: "TCP", Port: 80}},
+			},
+		},
+	}
+	pending := containerGroup{
+		ID:         "/subscriptions/sub/resourceGroups/production/providers/Microsoft.ContainerInstance/containerGroups/pending",
+		Name:       "pending",
+		Properties: containerGroupProperties{ProvisioningState: "Pending"},
+	}
+	api := webApp{
+		ID:   "/subscriptions/sub/resourceGroups/production/providers/Microsoft.Web/sites/api",
+		Name: "api",
+		Properties: webAppProperties{
+			State:           webAppStateRunning,
+			Enabled:         true,
+			DefaultHostName: "api.azurewebsites.net",
+		},
+	}
+	stopped := webApp{
+		ID:         "/subscriptions/sub/resourceGroups/staging/providers/Microsoft.Web/sites/stopped",
+		Name:       "stopped",
+		Properties: webAppProperties{State: "Stopped", Enabled: true, DefaultHostName: "stopped.azurewebsites.net"},
+	}
+
+	return &fakeClient{
+		containerGroups: map[string][]containerGroup{
+			"":           {whoami, pending},
+			"production": {whoami, pending},
+		},
+		webApps: map[string][]webApp{
+			"":           {api, stopped},
+			"production": {api},
+			"staging":    {stopped},
+		},
+	}
+}
+
+func TestListResources(t *testing.T) {
+	whoami := azureResource{
+		ID:   "/subscriptions/sub/resourceGroups/production/providers/Microsoft.ContainerInstance/containerGroups/whoami",
+		Name: "whoami",
+		Kind: resourceKindContainerGroup,
+		Host: "10.0.0.1",
+		Port: "80",
+		Tags: map[string]string{"environment": "production"},
+	}
+	api := azureResource{
+		ID:   "/subscriptions/sub/resourceGroups/production/providers/Microsoft.Web/sites/api",
+		Name: "api",
+		Kind: resourceKindWebApp,
+		Host: "api.azurewebsites.net",
+		Port: "443",
+	}
+
+	testCases := []struct {
+		desc     string
+		provider *Provider
+		expected []azureResource
+	}{
+		{
+			desc:     "container groups of the subscription",
+			provider: &Provider{ContainerGroups: true},
+			expected: []azureResource{whoami},
+		},
+		{
+			desc:     "container groups and web apps of the subscription",
+			provider: &Provider{ContainerGroups: true, WebApps: true},
+			expected: []azureResource{whoami, api},
+		},
+		{
+			desc:     "web apps of a resource group",
+			provider: &Provider{WebApps: true, ResourceGroups: []string{"staging"}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			resources, err := test.provider.listResources(context.Background(), newFakeClient())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, resources)
+		})
+	}
+}
+
+func TestListResourcesError(t *testing.T) {
+	client := newFakeClient()
+	client.err = errors.New("authorization failed")
+
+	p := &Provider{ContainerGroups: true}
+
+	_, err := p.listResources(context.Background(), client)
+	assert.EqualError(t, err, "authorization failed")
+}
+
+func TestLoadAzureConfig(t *testing.T) {
+	p := &Provider{
+		Domain:           "azure.localhost",
+		ExposedByDefault: true,
+		ContainerGroups:  true,
+		WebApps:          true,
+	}
+
+	configuration, err := p.loadAzureConfig(context.Background(), newFakeClient())
+	require.NoError(t, err)
+
+	require.Len(t, configuration.Backends, 2)
+	require.Contains(t, configuration.Backends, "backend-whoami")
+	require.Contains(t, configuration.Backends, "backend-api")
+
+	assert.Equal(t, "http://10.0.0.1:80", configuration.Backends["backend-whoami"].Servers["server-whoami"].URL)
+	assert.Equal(t, "https://api.azurewebsites.net:443", configuration.Backends["backend-api"].Servers["server-api"].URL)
+
+	require.Contains(t, configuration.Frontends, "frontend-whoami")
+	assert.Equal(t, "Host:whoami.azure.localhost", configuration.Frontends["frontend-whoami"].Routes["route-frontend-whoami"].Rule)
+	assert.True(t, configuration.Frontends["frontend-whoami"].PassHostHeader)
+
+	require.Contains(t, configuration.Frontends, "frontend-api")
+	assert.False(t, configuration.Frontends["frontend-api"].PassHostHeader)
+}
@@ -0,0 +1,142 @@
+package azure
+
+import (
+	"text/template"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
+	"github.com/containous/traefik/types"
+)
+
+// buildConfiguration fills the config template with the given resources
+func (p *Provider) buildConfiguration(services map[string][]azureResource) (*types.Configuration, error) {
+	var azureFuncMap = template.FuncMap{
+		"getFrontendRule":         p.getFrontendRule,
+		"getServerName":           getServerName,
+		"getHost":                 getHost,
+		"getPort":                 getPort,
+		"getProtocol":             getProtocol,
+		"getPassHostHeader":       getPassHostHeader,
+		"getWeight":               getFuncStringValue(label.TraefikWeight, label.DefaultWeight),
+		"getPriority":             getFuncStringValue(label.TraefikFrontendPriority, label.DefaultFrontendPriority),
+		"getEntryPoints":          getFuncSliceString(label.TraefikFrontendEntryPoints),
+		"getBasicAuth":            getFuncSliceString(label.TraefikFrontendAuthBasic),
+		"getLoadBalancerMethod":   getFuncFirstStringValue(label.TraefikBackendLoadBalancerMethod, label.DefaultBackendLoadBalancerMethod),
+		"hasStickinessLabel":      getFuncFirstBoolValue(label.TraefikBackendLoadBalancerStickiness, false),
+		"getStickinessCookieName": getFuncFirstStringValue(label.TraefikBackendLoadBalancerStickinessCookieName, label.DefaultBackendLoadbalancerStickinessCookieName),
+		"hasHealthCheckLabels":    hasFuncFirst(label.TraefikBackendHealthCheckPath),
+		"getHealthCheckPath":      getFuncFirstStringValue(label.TraefikBackendHealthCheckPath, ""),
+		"getHealthCheckInterval":  getFuncFirstStringValue(label.TraefikBackendHealthCheckInterval, ""),
+	}
+	return p.GetConfiguration("templates/azure.tmpl", azureFuncMap, struct {
+		Services map[string][]azureResource
+	}{
+		services,
+	})
+}
+
+func (p *Provider) filterResource(r azureResource) bool {
+	if getHost(r) == "" {
+		log.Debugf("Filtering Azure %s without address %s", r.Kind, r.Name)
+		return false
+	}
+
+	if getPort(r) == "" {
+		log.Debugf("Filtering Azure %s without port %s", r.Kind, r.Name)
+		return false
+	}
+
+	for key, value := range p.Tags {
+		if r.Tags[key] != value {
+			log.Debugf("Filtering Azure %s %s without the tag %s=%s", r.Kind, r.Name, key, value)
+			return false
+		}
+	}
+
+	if !label.IsEnabled(r.Tags, p.ExposedByDefault) {
+		log.Debugf("Filtering disabled Azure %s %s", r.Kind, r.Name)
+		return false
+	}
+
+	return true
+}
+
+func (p *Provider) getFrontendRule(r azureResource) string {
+	defaultRule := "Host:" + provider.Normalize(r.Name) + "." + p.Domain
+	return label.GetStringValue(r.Tags, label.TraefikFrontendRule, defaultRule)
+}
+
+// getServiceName returns the name of the backend and frontend of the resource,
+// resources are grouped in the same backend with the backend tag.
+func getServiceName(r azureResource) string {
+	return label.GetStringValue(r.Tags, label.TraefikBackend, provider.Normalize(r.Name))
+}
+
+func getServerName(r azureResource) string {
+	return provider.Normalize(r.Name)
+}
+
+func getHost(r azureResource) string {
+	return r.Host
+}
+
+func getPort(r azureResource) string {
+	return label.GetStringValue(r.Tags, label.TraefikPort, r.Port)
+}
+
+// getProtocol defaults to https for the web apps, only served over HTTPS on their default host name.
+func getProtocol(r azureResource) string {
+	defaultProtocol := label.DefaultProtocol
+	if r.Kind == resourceKindWebApp {
+		defaultProtocol = "https"
+	}
+	return label.GetStringValue(r.Tags, label.TraefikProtocol, defaultProtocol)
+}
+
+// getPassHostHeader defaults to false for the web apps, App Service routes the requests on their host name.
+func getPassHostHeader(r azureResource) string {
+	defaultPassHostHeader := label.DefaultPassHostHeader
+	if r.Kind == resourceKindWebApp {
+		defaultPassHostHeader = "false"
+	}
+	return label.GetStringValue(r.Tags, label.TraefikFrontendPassHostHeader, defaultPassHostHeader)
+}
+
+// Label functions
+
+func getFuncStringValue(labelName string, defaultValue string) func(r azureResource) string {
+	return func(r azureResource) string {
+		return label.GetStringValue(r.Tags, labelName, defaultValue)
+	}
+}
+
+func getFuncSliceString(labelName string) func(r azureResource) []string {
+	return func(r azureResource) []string {
+		return label.GetSliceStringValue(r.Tags, labelName)
+	}
+}
+
+func hasFuncFirst(labelName string) func(resources []azureResource) bool {
+	return func(resources []azureResource) bool {
+		return len(resources) > 0 && label.Has(resources[0].Tags, labelName)
+	}
+}
+
+func getFuncFirstStringValue(labelName string, defaultValue string) func(resources []azureResource) string {
+	return func(resources []azureResource) string {
+		if len(resources) == 0 {
+			return defaultValue
+		}
+		return label.GetStringValue(resources[0].Tags, labelName, defaultValue)
+	}
+}
+
+func getFuncFirstBoolValue(labelName string, defaultValue bool) func(resources []azureResource) bool {
+	return func(resources []azureResource) bool {
+		if len(resources) == 0 {
+			return defaultValue
+		}
+		return label.GetBoolValue(resources[0].Tags, labelName, defaultValue)
+	}
+}
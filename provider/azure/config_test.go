@@ -0,0 +1,124 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/provider/label"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterResource(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		resource         azureResource
+		tags             map[string]string
+		exposedByDefault bool
+		expected         bool
+	}{
+		{
+			desc:             "container group with address and port",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Port: "80"},
+			exposedByDefault: true,
+			expected:         true,
+		},
+		{
+			desc:             "without address",
+			resource:         azureResource{Name: "whoami", Port: "80"},
+			exposedByDefault: true,
+			expected:         false,
+		},
+		{
+			desc:             "port from the tag",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Tags: map[string]string{label.TraefikPort: "8080"}},
+			exposedByDefault: true,
+			expected:         true,
+		},
+		{
+			desc:             "without port",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1"},
+			exposedByDefault: true,
+			expected:         false,
+		},
+		{
+			desc:             "not exposed by default",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Port: "80"},
+			exposedByDefault: false,
+			expected:         false,
+		},
+		{
+			desc:             "enabled with the tag",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Port: "80", Tags: map[string]string{label.TraefikEnable: "true"}},
+			exposedByDefault: false,
+			expected:         true,
+		},
+		{
+			desc:             "matching the tags filter",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Port: "80", Tags: map[string]string{"environment": "production", "team": "a"}},
+			tags:             map[string]string{"environment": "production"},
+			exposedByDefault: true,
+			expected:         true,
+		},
+		{
+			desc:             "not matching the tags filter",
+			resource:         azureResource{Name: "whoami", Host: "10.0.0.1", Port: "80", Tags: map[string]string{"environment": "staging"}},
+			tags:             map[string]string{"environment": "production"},
+			exposedByDefault: true,
+			expected:         false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{ExposedByDefault: test.exposedByDefault, Tags: test.tags}
+
+			assert.Equal(t, test.expected, p.filterResource(test.resource))
+		})
+	}
+}
+
+func TestGetProtocolAndPassHostHeader(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		resource               azureResource
+		expectedProtocol       string
+		expectedPassHostHeader string
+	}{
+		{
+			desc:                   "container group",
+			resource:               azureResource{Kind: resourceKindContainerGroup},
+			expectedProtocol:       "http",
+			expectedPassHostHeader: "true",
+		},
+		{
+			desc:                   "web app",
+			resource:               azureResource{Kind: resourceKindWebApp},
+			expectedProtocol:       "https",
+			expectedPassHostHeader: "false",
+		},
+		{
+			desc: "web app with tags",
+			resource: azureResource{
+				Kind: resourceKindWebApp,
+				Tags: map[string]string{
+					label.TraefikProtocol:               "http",
+					label.TraefikFrontendPassHostHeader: "true",
+				},
+			},
+			expectedProtocol:       "http",
+			expectedPassHostHeader: "true",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedProtocol, getProtocol(test.resource))
+			assert.Equal(t, test.expectedPassHostHeader, getPassHostHeader(test.resource))
+		})
+	}
+}
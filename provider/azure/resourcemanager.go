@@ -0,0 +1,161 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	autorestazure "github.com/Azure/go-autorest/autorest/azure"
+)
+
+// The vendored Azure SDK does not ship the Container Instances and App Service clients,
+// this is a minimal implementation of the Resource Manager operations used to discover the resources.
+// See https://docs.microsoft.com/en-us/rest/api/container-instances/containergroups/list
+// and https://docs.microsoft.com/en-us/rest/api/appservice/webapps/list
+
+const (
+	containerGroupsType       = "Microsoft.ContainerInstance/containerGroups"
+	containerGroupsAPIVersion = "2018-10-01"
+	webAppsType               = "Microsoft.Web/sites"
+	webAppsAPIVersion         = "2016-08-01"
+
+	provisioningStateSucceeded = "Succeeded"
+	webAppStateRunning         = "Running"
+)
+
+// resourceManagerAPI is the subset of the Resource Manager API used by the provider.
+type resourceManagerAPI interface {
+	ListContainerGroups(ctx context.Context, resourceGroup string) ([]containerGroup, error)
+	ListWebApps(ctx context.Context, resourceGroup string) ([]webApp, error)
+}
+
+type resourceManagerClient struct {
+	autorest.Client
+	baseURI        string
+	subscriptionID string
+}
+
+func newResourceManagerClient(baseURI, subscriptionID string, authorizer autorest.Authorizer) *resourceManagerClient {
+	client := &resourceManagerClient{
+		Client:         autorest.NewClientWithUserAgent("traefik"),
+		baseURI:        baseURI,
+		subscriptionID: subscriptionID,
+	}
+	client.Authorizer = authorizer
+	return client
+}
+
+// ListContainerGroups returns the container groups of the resource group, or of the subscription when the resource group is empty.
+func (c *resourceManagerClient) ListContainerGroups(ctx context.Context, resourceGroup string) ([]containerGroup, error) {
+	var groups []containerGroup
+	err := c.list(ctx, resourceGroup, containerGroupsType, containerGroupsAPIVersion, func(resp *http.Response) (string, error) {
+		var page struct {
+			Value    []containerGroup `json:"value"`
+			NextLink string           `json:"nextLink"`
+		}
+		err := c.respond(resp, &page)
+		groups = append(groups, page.Value...)
+		return page.NextLink, err
+	})
+	return groups, err
+}
+
+// ListWebApps returns the web apps of the resource group, or of the subscription when the resource group is empty.
+func (c *resourceManagerClient) ListWebApps(ctx context.Context, resourceGroup string) ([]webApp, error) {
+	var apps []webApp
+	err := c.list(ctx, resourceGroup, webAppsType, webAppsAPIVersion, func(resp *http.Response) (string, error) {
+		var page struct {
+			Value    []webApp `json:"value"`
+			NextLink string   `json:"nextLink"`
+		}
+		err := c.respond(resp, &page)
+		apps = append(apps, page.Value...)
+		return page.NextLink, err
+	})
+	return apps, err
+}
+
+// list requests the pages of the resources until the last one, readPage returns the link to the next page.
+func (c *resourceManagerClient) list(ctx context.Context, resourceGroup, resourceType, apiVersion string, readPage func(*http.Response) (string, error)) error {
+	path := "/subscriptions/{subscriptionId}/providers/" + resourceType
+	pathParameters := map[string]interface{}{
+		"subscriptionId": autorest.Encode("path", c.subscriptionID),
+	}
+	if len(resourceGroup) > 0 {
+		path = "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/" + resourceType
+		pathParameters["resourceGroupName"] = autorest.Encode("path", resourceGroup)
+	}
+
+	req, err := autorest.Prepare(&http.Request{},
+		autorest.AsGet(),
+		autorest.WithBaseURL(c.baseURI),
+		autorest.WithPathParameters(path, pathParameters),
+		autorest.WithQueryParameters(map[string]interface{}{"api-version": apiVersion}))
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := autorest.SendWithSender(c, req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		nextLink, err := readPage(resp)
+		if err != nil || len(nextLink) == 0 {
+			return err
+		}
+
+		req, err = autorest.Prepare(&http.Request{},
+			autorest.AsGet(),
+			autorest.WithBaseURL(nextLink))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *resourceManagerClient) respond(resp *http.Response, page interface{}) error {
+	return autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		autorestazure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(page),
+		autorest.ByClosing())
+}
+
+type containerGroup struct {
+	ID         string                   `json:"id"`
+	Name       string                   `json:"name"`
+	Tags       map[string]string        `json:"tags"`
+	Properties containerGroupProperties `json:"properties"`
+}
+
+type containerGroupProperties struct {
+	ProvisioningState string     `json:"provisioningState"`
+	IPAddress         *ipAddress `json:"ipAddress"`
+}
+
+type ipAddress struct {
+	IP    string `json:"ip"`
+	Fqdn  string `json:"fqdn"`
+	Ports []port `json:"ports"`
+}
+
+type port struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+type webApp struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Tags       map[string]string `json:"tags"`
+	Properties webAppProperties  `json:"properties"`
+}
+
+type webAppProperties struct {
+	State           string `json:"state"`
+	Enabled         bool   `json:"enabled"`
+	DefaultHostName string `json:"defaultHostName"`
+}
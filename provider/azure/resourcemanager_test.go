@@ -0,0 +1,66 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceManagerClientListContainerGroups(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/subscriptions/sub/resourceGroups/production/providers/Microsoft.ContainerInstance/containerGroups":
+			assert.Equal(t, containerGroupsAPIVersion, req.URL.Query().Get("api-version"))
+			fmt.Fprintf(rw, `{"value": [{"name": "whoami", "properties": {"provisioningState": "Succeeded", "ipAddress": {"ip": "10.0.0.1", "ports": [{"protocol": "TCP", "port": 80}]}}}], "nextLink": "%s/page2"}`, server.URL)
+		case "/page2":
+			fmt.Fprint(rw, `{"value": [{"name": "api", "tags": {"traefik.port": "8080"}}]}`)
+		default:
+			http.NotFound(rw, req)
+		}
+	}))
+	defer server.Close()
+
+	client := newResourceManagerClient(server.URL, "sub", autorest.NullAuthorizer{})
+
+	groups, err := client.ListContainerGroups(context.Background(), "production")
+	require.NoError(t, err)
+
+	expected := []containerGroup{
+		{
+			Name: "whoami",
+			Properties: containerGroupProperties{
+				ProvisioningState: provisioningStateSucceeded,
+				IPAddress: &ipAddress{
+					IP:    "10.0.0.1",
+					Ports: []port{{Protocol: "TCP", Port: 80}},
+				},
+			},
+		},
+		{
+			Name: "api",
+			Tags: map[string]string{"traefik.port": "8080"},
+		},
+	}
+	assert.Equal(t, expected, groups)
+}
+
+func TestResourceManagerClientListWebAppsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/subscriptions/sub/providers/Microsoft.Web/sites", req.URL.Path)
+		rw.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(rw, `{"error": {"code": "AuthorizationFailed", "message": "not allowed"}}`)
+	}))
+	defer server.Close()
+
+	client := newResourceManagerClient(server.URL, "sub", autorest.NullAuthorizer{})
+
+	_, err := client.ListWebApps(context.Background(), "")
+	assert.Error(t, err)
+}
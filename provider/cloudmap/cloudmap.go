@@ -0,0 +1,264 @@
+package cloudmap
+
+import (
+	"context"
+	"time"
+
+	"github.com/BurntSushi/ty/fun"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	provider.BaseProvider `mapstructure:",squash" export:"true"`
+
+	Domain           string `description:"Default domain used, the namespace name when empty"`
+	ExposedByDefault bool   `description:"Expose services by default" export:"true"`
+	RefreshSeconds   int    `description:"Polling interval (in seconds)" export:"true"`
+
+	// Provider lookup parameters
+	Namespaces      []string `description:"Cloud Map namespaces names (default: all namespaces)"`
+	Region          string   `description:"The AWS region to use for requests" export:"true"`
+	AccessKeyID     string   `description:"The AWS credentials access key to use for making requests"`
+	SecretAccessKey string   `description:"The AWS credentials access key to use for making requests"`
+}
+
+type cloudMapInstance struct {
+	ID         string
+	Namespace  string
+	Service    string
+	Attributes map[string]string
+}
+
+func (p *Provider) createClient() (serviceDiscoveryAPI, error) {
+	sess := session.New()
+	ec2meta := ec2metadata.New(sess)
+	if p.Region == "" {
+		log.Infoln("No EC2 region provided, querying instance metadata endpoint...")
+		identity, err := ec2meta.GetInstanceIdentityDocument()
+		if err != nil {
+			return nil, err
+		}
+		p.Region = identity.Region
+	}
+
+	cfg := &aws.Config{
+		Region: &p.Region,
+		Credentials: credentials.NewChainCredentials(
+			[]credentials.Provider{
+				&credentials.StaticProvider{
+					Value: credentials.Value{
+						AccessKeyID:     p.AccessKeyID,
+						SecretAccessKey: p.SecretAccessKey,
+					},
+				},
+				&credentials.EnvProvider{},
+				&credentials.SharedCredentialsProvider{},
+				defaults.RemoteCredProvider(*(defaults.Config()), defaults.Handlers()),
+			}),
+	}
+
+	if p.Trace {
+		cfg.WithLogger(aws.LoggerFunc(func(args ...interface{}) {
+			log.Debug(args...)
+		}))
+	}
+
+	return newServiceDiscoveryClient(sess, cfg), nil
+}
+
+// Provide allows the cloudmap provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+
+	p.Constraints = append(p.Constraints, constraints...)
+
+	handleCanceled := func(ctx context.Context, err error) error {
+		if ctx.Err() == context.Canceled || err == context.Canceled {
+			return nil
+		}
+		return err
+	}
+
+	pool.Go(func(stop chan bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		safe.Go(func() {
+			select {
+			case <-stop:
+				cancel()
+			}
+		})
+
+		operation := func() error {
+			client, err := p.createClient()
+			if err != nil {
+				return err
+			}
+
+			configuration, err := p.loadCloudMapConfig(ctx, client)
+			if err != nil {
+				return handleCanceled(ctx, err)
+			}
+
+			configurationChan <- types.ConfigMessage{
+				ProviderName:  "cloudmap",
+				Configuration: configuration,
+			}
+
+			if p.Watch {
+				reload := time.NewTicker(time.Second * time.Duration(p.RefreshSeconds))
+				defer reload.Stop()
+				for {
+					select {
+					case <-reload.C:
+						configuration, err := p.loadCloudMapConfig(ctx, client)
+						if err != nil {
+							return handleCanceled(ctx, err)
+						}
+
+						configurationChan <- types.ConfigMessage{
+							ProviderName:  "cloudmap",
+							Configuration: configuration,
+						}
+					case <-ctx.Done():
+						return handleCanceled(ctx, ctx.Err())
+					}
+				}
+			}
+
+			return nil
+		}
+
+		notify := func(err error, time time.Duration) {
+			log.Errorf("Provider connection error %+v, retrying in %s", err, time)
+		}
+		err := backoff.RetryNotify(safe.OperationWithRecover(operation), job.NewBackOff(backoff.NewExponentialBackOff()), notify)
+		if err != nil {
+			log.Errorf("Cannot connect to Provider api %+v", err)
+		}
+	})
+
+	return nil
+}
+
+func (p *Provider) loadCloudMapConfig(ctx context.Context, client serviceDiscoveryAPI) (*types.Configuration, error) {
+	instances, err := p.listInstances(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	instances = fun.Filter(p.filterInstance, instances).([]cloudMapInstance)
+
+	services := make(map[string][]cloudMapInstance)
+	for _, instance := range instances {
+		name := getServiceName(instance)
+		services[name] = append(services[name], instance)
+	}
+	return p.buildConfiguration(services)
+}
+
+// listInstances lists the healthy instances of all the services of the namespaces.
+func (p *Provider) listInstances(ctx context.Context, client serviceDiscoveryAPI) ([]cloudMapInstance, error) {
+	namespaces, err := p.listNamespaces(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []cloudMapInstance
+	for _, namespace := range namespaces {
+		services, err := listServices(ctx, client, aws.StringValue(namespace.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, service := range services {
+			output, err := client.DiscoverInstances(ctx, &discoverInstancesInput{
+				NamespaceName: namespace.Name,
+				ServiceName:   service.Name,
+				HealthStatus:  aws.String(healthStatusHealthy),
+				MaxResults:    aws.Int64(maxDiscoveredInstances),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(output.Instances) >= maxDiscoveredInstances {
+				log.Warnf("Service %s of namespace %s reached the limit of %d discovered instances, its other healthy instances are ignored",
+					aws.StringValue(service.Name), aws.StringValue(namespace.Name), maxDiscoveredInstances)
+			}
+
+			for _, instance := range output.Instances {
+				attributes := make(map[string]string)
+				for key, value := range instance.Attributes {
+					attributes[key] = aws.StringValue(value)
+				}
+
+				instances = append(instances, cloudMapInstance{
+					ID:         aws.StringValue(instance.InstanceID),
+					Namespace:  aws.StringValue(namespace.Name),
+					Service:    aws.StringValue(service.Name),
+					Attributes: attributes,
+				})
+			}
+		}
+	}
+	return instances, nil
+}
+
+// listNamespaces returns the namespaces matching the configured names, or all the namespaces.
+func (p *Provider) listNamespaces(ctx context.Context, client serviceDiscoveryAPI) ([]*namespaceSummary, error) {
+	var namespaces []*namespaceSummary
+	input := &listNamespacesInput{}
+	for {
+		output, err := client.ListNamespaces(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, namespace := range output.Namespaces {
+			if len(p.Namespaces) == 0 || fun.In(aws.StringValue(namespace.Name), p.Namespaces) {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+
+		if output.NextToken == nil {
+			return namespaces, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func listServices(ctx context.Context, client serviceDiscoveryAPI, namespaceID string) ([]*serviceSummary, error) {
+	var services []*serviceSummary
+	input := &listServicesInput{
+		Filters: []*serviceFilter{{
+			Name:      aws.String(filterNamespaceID),
+			Condition: aws.String(filterConditionEQ),
+			Values:    []*string{aws.String(namespaceID)},
+		}},
+	}
+	for {
+		output, err := client.ListServices(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, output.Services...)
+
+		if output.NextToken == nil {
+			return services, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
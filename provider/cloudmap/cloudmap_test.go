@@ -0,0 +1,252 @@
+package cloudmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient serves one namespace or service per page.
+type fakeClient struct {
+	namespaces []*namespaceSummary
+	services   map[string][]*serviceSummary
+	instances  map[string][]*httpInstanceSummary
+	err        error
+}
+
+func (c *fakeClient) ListNamespaces(ctx context.Context, input *listNamespacesInput) (*listNamespacesOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	page, next := paginate(len(c.namespaces), input.NextToken)
+	output := &listNamespacesOutput{NextToken: next}
+	if page >= 0 {
+		output.Namespaces = c.namespaces[page : page+1]
+	}
+	return output, nil
+}
+
+func (c *fakeClient) ListServices(ctx context.Context, input *listServicesInput) (*listServicesOutput, error) {
+	services := c.services[aws.StringValue(input.Filters[0].Values[0])]
+	page, next := paginate(len(services), input.NextToken)
+	output := &listServicesOutput{NextToken: next}
+	if page >= 0 {
+		output.Services = services[page : page+1]
+	}
+	return output, nil
+}
+
+func (c *fakeClient) DiscoverInstances(ctx context.Context, input *discoverInstancesInput) (*discoverInstancesOutput, error) {
+	key := aws.StringValue(input.ServiceName) + "." + aws.StringValue(input.NamespaceName)
+	instances := c.instances[key]
+
+	// the API returns 100 instances by default
+	maxResults := 100
+	if input.MaxResults != nil {
+		maxResults = int(aws.Int64Value(input.MaxResults))
+	}
+	if len(instances) > maxResults {
+		instances = instances[:maxResults]
+	}
+	return &discoverInstancesOutput{Instances: instances}, nil
+}
+
+// paginate returns the index of the requested page and the token of the next page.
+func paginate(size int, token *string) (int, *string) {
+	if size == 0 {
+		return -1, nil
+	}
+	page := len(aws.StringValue(token))
+	if page+1 < size {
+		return page, aws.String(aws.StringValue(token) + "x")
+	}
+	return page, nil
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		namespaces: []*namespaceSummary{
+			{ID: aws.String("ns-1"), Name: aws.String("example.local")},
+			{ID: aws.String("ns-2"), Name: aws.String("other.local")},
+		},
+		services: map[string][]*serviceSummary{
+			"ns-1": {
+				{ID: aws.String("srv-1"), Name: aws.String("whoami")},
+				{ID: aws.String("srv-2"), Name: aws.String("api")},
+			},
+			"ns-2": {
+				{ID: aws.String("srv-3"), Name: aws.String("web")},
+			},
+		},
+		instances: map[string][]*httpInstanceSummary{
+			"whoami.example.local": {
+				{
+					InstanceID: aws.String("i-1"),
+					Attributes: map[string]*string{
+						attributeInstanceIPv4: aws.String("10.0.0.1"),
+						attributeInstancePort: aws.String("80"),
+					},
+				},
+			},
+			"api.example.local": {
+				{
+					InstanceID: aws.String("i-2"),
+					Attributes: map[string]*string{
+						attributeInstanceIPv4: aws.String("10.0.0.2"),
+					},
+				},
+			},
+			"web.other.local": {
+				{
+					InstanceID: aws.String("i-3"),
+					Attributes: map[string]*string{
+						attributeInstanceCNAME: aws.String("web.example.com"),
+						attributeInstancePort:  aws.String("8080"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestListInstances(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		namespaces []string
+		expected   []cloudMapInstance
+	}{
+		{
+			desc: "all namespaces",
+			expected: []cloudMapInstance{
+				{
+					ID:         "i-1",
+					Namespace:  "example.local",
+					Service:    "whoami",
+					Attributes: map[string]string{attributeInstanceIPv4: "10.0.0.1", attributeInstancePort: "80"},
+				},
+				{
+					ID:         "i-2",
+					Namespace:  "example.local",
+					Service:    "api",
+					Attributes: map[string]string{attributeInstanceIPv4: "10.0.0.2"},
+				},
+				{
+					ID:         "i-3",
+					Namespace:  "other.local",
+					Service:    "web",
+					Attributes: map[string]string{attributeInstanceCNAME: "web.example.com", attributeInstancePort: "8080"},
+				},
+			},
+		},
+		{
+			desc:       "filtered namespaces",
+			namespaces: []string{"other.local"},
+			expected: []cloudMapInstance{
+				{
+					ID:         "i-3",
+					Namespace:  "other.local",
+					Service:    "web",
+					Attributes: map[string]string{attributeInstanceCNAME: "web.example.com", attributeInstancePort: "8080"},
+				},
+			},
+		},
+		{
+			desc:       "unknown namespace",
+			namespaces: []string{"unknown.local"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{Namespaces: test.namespaces}
+
+			instances, err := p.listInstances(context.Background(), newFakeClient())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, instances)
+		})
+	}
+}
+
+func TestListInstancesLimit(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		count    int
+		expected int
+	}{
+		{
+			desc:     "more instances than the default of the API",
+			count:    150,
+			expected: 150,
+		},
+		{
+			desc:     "more instances than the limit",
+			count:    maxDiscoveredInstances + 1,
+			expected: maxDiscoveredInstances,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeClient()
+			client.instances["whoami.example.local"] = nil
+			for i := 0; i < test.count; i++ {
+				client.instances["whoami.example.local"] = append(client.instances["whoami.example.local"], &httpInstanceSummary{
+					InstanceID: aws.String(fmt.Sprintf("i-whoami-%d", i)),
+				})
+			}
+
+			p := &Provider{Namespaces: []string{"example.local"}}
+
+			instances, err := p.listInstances(context.Background(), client)
+			require.NoError(t, err)
+
+			var count int
+			for _, instance := range instances {
+				if instance.Service == "whoami" {
+					count++
+				}
+			}
+			assert.Equal(t, test.expected, count)
+		})
+	}
+}
+
+func TestListInstancesError(t *testing.T) {
+	client := newFakeClient()
+	client.err = errors.New("access denied")
+
+	p := &Provider{}
+
+	_, err := p.listInstances(context.Background(), client)
+	assert.EqualError(t, err, "access denied")
+}
+
+func TestLoadCloudMapConfig(t *testing.T) {
+	p := &Provider{ExposedByDefault: true}
+
+	configuration, err := p.loadCloudMapConfig(context.Background(), newFakeClient())
+	require.NoError(t, err)
+
+	// the api instance has no port
+	require.Len(t, configuration.Backends, 2)
+	require.Contains(t, configuration.Backends, "backend-whoami-example-local")
+	require.Contains(t, configuration.Backends, "backend-web-other-local")
+
+	assert.Equal(t, "http://10.0.0.1:80", configuration.Backends["backend-whoami-example-local"].Servers["server-i-1"].URL)
+	assert.Equal(t, "http://web.example.com:8080", configuration.Backends["backend-web-other-local"].Servers["server-i-3"].URL)
+
+	require.Contains(t, configuration.Frontends, "frontend-web-other-local")
+	assert.Equal(t, "Host:web.other.local", configuration.Frontends["frontend-web-other-local"].Routes["route-frontend-web-other-local"].Rule)
+}
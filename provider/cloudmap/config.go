@@ -0,0 +1,132 @@
+package cloudmap
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
+	"github.com/containous/traefik/types"
+)
+
+// Attributes set by Cloud Map and by the ECS service discovery on the registered instances
+const (
+	attributeInstanceIPv4  = "AWS_INSTANCE_IPV4"
+	attributeInstanceCNAME = "AWS_INSTANCE_CNAME"
+	attributeInstancePort  = "AWS_INSTANCE_PORT"
+)
+
+// buildConfiguration fills the config template with the given instances
+func (p *Provider) buildConfiguration(services map[string][]cloudMapInstance) (*types.Configuration, error) {
+	var cloudMapFuncMap = template.FuncMap{
+		"getFrontendRule":         p.getFrontendRule,
+		"getServerName":           getServerName,
+		"getHost":                 getHost,
+		"getPort":                 getPort,
+		"getProtocol":             getFuncStringValue(label.TraefikProtocol, label.DefaultProtocol),
+		"getWeight":               getFuncStringValue(label.TraefikWeight, label.DefaultWeight),
+		"getPassHostHeader":       getFuncStringValue(label.TraefikFrontendPassHostHeader, label.DefaultPassHostHeader),
+		"getPriority":             getFuncStringValue(label.TraefikFrontendPriority, label.DefaultFrontendPriority),
+		"getEntryPoints":          getFuncSliceString(label.TraefikFrontendEntryPoints),
+		"getBasicAuth":            getFuncSliceString(label.TraefikFrontendAuthBasic),
+		"getLoadBalancerMethod":   getFuncFirstStringValue(label.TraefikBackendLoadBalancerMethod, label.DefaultBackendLoadBalancerMethod),
+		"hasStickinessLabel":      getFuncFirstBoolValue(label.TraefikBackendLoadBalancerStickiness, false),
+		"getStickinessCookieName": getFuncFirstStringValue(label.TraefikBackendLoadBalancerStickinessCookieName, label.DefaultBackendLoadbalancerStickinessCookieName),
+		"hasHealthCheckLabels":    hasFuncFirst(label.TraefikBackendHealthCheckPath),
+		"getHealthCheckPath":      getFuncFirstStringValue(label.TraefikBackendHealthCheckPath, ""),
+		"getHealthCheckInterval":  getFuncFirstStringValue(label.TraefikBackendHealthCheckInterval, ""),
+	}
+	return p.GetConfiguration("templates/cloudmap.tmpl", cloudMapFuncMap, struct {
+		Services map[string][]cloudMapInstance
+	}{
+		services,
+	})
+}
+
+func (p *Provider) filterInstance(i cloudMapInstance) bool {
+	if getHost(i) == "" {
+		log.Debugf("Filtering Cloud Map instance without address %s (%s.%s)", i.ID, i.Service, i.Namespace)
+		return false
+	}
+
+	if getPort(i) == "" {
+		log.Debugf("Filtering Cloud Map instance without port %s (%s.%s)", i.ID, i.Service, i.Namespace)
+		return false
+	}
+
+	if !label.IsEnabled(i.Attributes, p.ExposedByDefault) {
+		log.Debugf("Filtering disabled Cloud Map instance %s (%s.%s)", i.ID, i.Service, i.Namespace)
+		return false
+	}
+
+	return true
+}
+
+func (p *Provider) getFrontendRule(i cloudMapInstance) string {
+	domain := p.Domain
+	if len(domain) == 0 {
+		domain = i.Namespace
+	}
+	defaultRule := "Host:" + strings.ToLower(strings.Replace(i.Service, "_", "-", -1)) + "." + domain
+	return label.GetStringValue(i.Attributes, label.TraefikFrontendRule, defaultRule)
+}
+
+// getServiceName returns the name of the backend and frontend of the instance,
+// the instances of a Cloud Map service share the same name unless overridden with the backend label.
+func getServiceName(i cloudMapInstance) string {
+	return label.GetStringValue(i.Attributes, label.TraefikBackend, provider.Normalize(i.Service+"-"+i.Namespace))
+}
+
+func getServerName(i cloudMapInstance) string {
+	return provider.Normalize(i.ID)
+}
+
+func getHost(i cloudMapInstance) string {
+	if host := i.Attributes[attributeInstanceIPv4]; len(host) > 0 {
+		return host
+	}
+	return i.Attributes[attributeInstanceCNAME]
+}
+
+func getPort(i cloudMapInstance) string {
+	return label.GetStringValue(i.Attributes, label.TraefikPort, i.Attributes[attributeInstancePort])
+}
+
+// Label functions
+
+func getFuncStringValue(labelName string, defaultValue string) func(i cloudMapInstance) string {
+	return func(i cloudMapInstance) string {
+		return label.GetStringValue(i.Attributes, labelName, defaultValue)
+	}
+}
+
+func getFuncSliceString(labelName string) func(i cloudMapInstance) []string {
+	return func(i cloudMapInstance) []string {
+		return label.GetSliceStringValue(i.Attributes, labelName)
+	}
+}
+
+func hasFuncFirst(labelName string) func(instances []cloudMapInstance) bool {
+	return func(instances []cloudMapInstance) bool {
+		return len(instances) > 0 && label.Has(instances[0].Attributes, labelName)
+	}
+}
+
+func getFuncFirstStringValue(labelName string, defaultValue string) func(instances []cloudMapInstance) string {
+	return func(instances []cloudMapInstance) string {
+		if len(instances) == 0 {
+			return defaultValue
+		}
+		return label.GetStringValue(instances[0].Attributes, labelName, defaultValue)
+	}
+}
+
+func getFuncFirstBoolValue(labelName string, defaultValue bool) func(instances []cloudMapInstance) bool {
+	return func(instances []cloudMapInstance) bool {
+		if len(instances) == 0 {
+			return defaultValue
+		}
+		return label.GetBoolValue(instances[0].Attributes, labelName, defaultValue)
+	}
+}
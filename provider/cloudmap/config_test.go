@@ -0,0 +1,321 @@
+package cloudmap
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/provider/label"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		services map[string][]cloudMapInstance
+		expected *types.Configuration
+	}{
+		{
+			desc:     "no service",
+			services: map[string][]cloudMapInstance{},
+			expected: &types.Configuration{
+				Backends:  map[string]*types.Backend{},
+				Frontends: map[string]*types.Frontend{},
+			},
+		},
+		{
+			desc: "one service with two instances",
+			services: map[string][]cloudMapInstance{
+				"whoami-example-local": {
+					{
+						ID:        "i-1",
+						Namespace: "example.local",
+						Service:   "whoami",
+						Attributes: map[string]string{
+							attributeInstanceIPv4: "10.0.0.1",
+							attributeInstancePort: "80",
+						},
+					},
+					{
+						ID:        "i-2",
+						Namespace: "example.local",
+						Service:   "whoami",
+						Attributes: map[string]string{
+							attributeInstanceIPv4: "10.0.0.2",
+							attributeInstancePort: "80",
+						},
+					},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-whoami-example-local": {
+						Servers: map[string]types.Server{
+							"server-i-1": {
+								URL: "http://10.0.0.1:80",
+							},
+							"server-i-2": {
+								URL: "http://10.0.0.2:80",
+							},
+						},
+						LoadBalancer: &types.LoadBalancer{
+							Method: "wrr",
+						},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-whoami-example-local": {
+						EntryPoints: []string{},
+						Backend:     "backend-whoami-example-local",
+						Routes: map[string]types.Route{
+							"route-frontend-whoami-example-local": {
+								Rule: "Host:whoami.example.local",
+							},
+						},
+						PassHostHeader: true,
+						BasicAuth:      []string{},
+					},
+				},
+			},
+		},
+		{
+			desc: "service with attributes",
+			services: map[string][]cloudMapInstance{
+				"whoami-example-local": {
+					{
+						ID:        "i-1",
+						Namespace: "example.local",
+						Service:   "whoami",
+						Attributes: map[string]string{
+							attributeInstanceCNAME:                     "whoami.example.com",
+							attributeInstancePort:                      "80",
+							label.TraefikPort:                          "443",
+							label.TraefikProtocol:                      "https",
+							label.TraefikWeight:                        "10",
+							label.TraefikBackendLoadBalancerMethod:     "drr",
+							label.TraefikBackendHealthCheckPath:        "/health",
+							label.TraefikBackendHealthCheckInterval:    "1s",
+							label.TraefikFrontendRule:                  "Host:whoami.traefik.io",
+							label.TraefikFrontendPassHostHeader:        "false",
+							label.TraefikFrontendPriority:              "10",
+							label.TraefikFrontendEntryPoints:           "http,https",
+							label.TraefikFrontendAuthBasic:             "test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/",
+							label.TraefikBackendLoadBalancerStickiness: "true",
+						},
+					},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-whoami-example-local": {
+						Servers: map[string]types.Server{
+							"server-i-1": {
+								URL:    "https://whoami.example.com:443",
+								Weight: 10,
+							},
+						},
+						LoadBalancer: &types.LoadBalancer{
+							Method:     "drr",
+							Stickiness: &types.Stickiness{},
+						},
+						HealthCheck: &types.HealthCheck{
+							Path:     "/health",
+							Interval: "1s",
+						},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-whoami-example-local": {
+						EntryPoints: []string{"http", "https"},
+						Backend:     "backend-whoami-example-local",
+						Routes: map[string]types.Route{
+							"route-frontend-whoami-example-local": {
+								Rule: "Host:whoami.traefik.io",
+							},
+						},
+						Priority:  10,
+						BasicAuth: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{}
+
+			actual, err := p.buildConfiguration(test.services)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFilterInstance(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		attributes       map[string]string
+		exposedByDefault bool
+		expected         bool
+	}{
+		{
+			desc: "instance with IPv4 and port",
+			attributes: map[string]string{
+				attributeInstanceIPv4: "10.0.0.1",
+				attributeInstancePort: "80",
+			},
+			exposedByDefault: true,
+			expected:         true,
+		},
+		{
+			desc: "instance with CNAME and port label",
+			attributes: map[string]string{
+				attributeInstanceCNAME: "whoami.example.com",
+				label.TraefikPort:      "80",
+			},
+			exposedByDefault: true,
+			expected:         true,
+		},
+		{
+			desc: "instance without address",
+			attributes: map[string]string{
+				attributeInstancePort: "80",
+			},
+			exposedByDefault: true,
+			expected:         false,
+		},
+		{
+			desc: "instance without port",
+			attributes: map[string]string{
+				attributeInstanceIPv4: "10.0.0.1",
+			},
+			exposedByDefault: true,
+			expected:         false,
+		},
+		{
+			desc: "disabled instance",
+			attributes: map[string]string{
+				attributeInstanceIPv4: "10.0.0.1",
+				attributeInstancePort: "80",
+				label.TraefikEnable:   "false",
+			},
+			exposedByDefault: true,
+			expected:         false,
+		},
+		{
+			desc: "instance not exposed by default",
+			attributes: map[string]string{
+				attributeInstanceIPv4: "10.0.0.1",
+				attributeInstancePort: "80",
+			},
+			exposedByDefault: false,
+			expected:         false,
+		},
+		{
+			desc: "instance enabled and not exposed by default",
+			attributes: map[string]string{
+				attributeInstanceIPv4: "10.0.0.1",
+				attributeInstancePort: "80",
+				label.TraefikEnable:   "true",
+			},
+			exposedByDefault: false,
+			expected:         true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{ExposedByDefault: test.exposedByDefault}
+			instance := cloudMapInstance{
+				ID:         "i-1",
+				Namespace:  "example.local",
+				Service:    "whoami",
+				Attributes: test.attributes,
+			}
+
+			assert.Equal(t, test.expected, p.filterInstance(instance))
+		})
+	}
+}
+
+func TestGetFrontendRule(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		domain   string
+		instance cloudMapInstance
+		expected string
+	}{
+		{
+			desc:     "namespace as domain",
+			instance: cloudMapInstance{Namespace: "example.local", Service: "who_ami"},
+			expected: "Host:who-ami.example.local",
+		},
+		{
+			desc:     "configured domain",
+			domain:   "traefik.io",
+			instance: cloudMapInstance{Namespace: "example.local", Service: "whoami"},
+			expected: "Host:whoami.traefik.io",
+		},
+		{
+			desc:   "frontend rule label",
+			domain: "traefik.io",
+			instance: cloudMapInstance{
+				Namespace:  "example.local",
+				Service:    "whoami",
+				Attributes: map[string]string{label.TraefikFrontendRule: "PathPrefix:/whoami"},
+			},
+			expected: "PathPrefix:/whoami",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{Domain: test.domain}
+
+			assert.Equal(t, test.expected, p.getFrontendRule(test.instance))
+		})
+	}
+}
+
+func TestGetServiceName(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		instance cloudMapInstance
+		expected string
+	}{
+		{
+			desc:     "service and namespace",
+			instance: cloudMapInstance{Namespace: "example.local", Service: "whoami"},
+			expected: "whoami-example-local",
+		},
+		{
+			desc: "backend label",
+			instance: cloudMapInstance{
+				Namespace:  "example.local",
+				Service:    "whoami",
+				Attributes: map[string]string{label.TraefikBackend: "foo"},
+			},
+			expected: "foo",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getServiceName(test.instance))
+		})
+	}
+}
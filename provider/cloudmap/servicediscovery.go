@@ -0,0 +1,190 @@
+package cloudmap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+// The vendored AWS SDK does not ship the Cloud Map (Service Discovery) client,
+// this is a minimal implementation of the operations used to discover the instances.
+// See https://docs.aws.amazon.com/cloud-map/latest/api/API_Operations.html
+
+const (
+	serviceDiscoveryName = "servicediscovery"
+
+	// DiscoverInstances is served by a dedicated data plane endpoint
+	serviceDiscoveryDataName = "data-servicediscovery"
+
+	healthStatusHealthy = "HEALTHY"
+
+	filterNamespaceID = "NAMESPACE_ID"
+	filterConditionEQ = "EQ"
+
+	// maxDiscoveredInstances is the maximum of instances returned by DiscoverInstances, which returns 100 instances by default
+	maxDiscoveredInstances = 1000
+)
+
+// serviceDiscoveryAPI is the subset of the Cloud Map API used by the provider.
+type serviceDiscoveryAPI interface {
+	ListNamespaces(context.Context, *listNamespacesInput) (*listNamespacesOutput, error)
+	ListServices(context.Context, *listServicesInput) (*listServicesOutput, error)
+	DiscoverInstances(context.Context, *discoverInstancesInput) (*discoverInstancesOutput, error)
+}
+
+type serviceDiscoveryClient struct {
+	control *client.Client
+	data    *client.Client
+}
+
+func newServiceDiscoveryClient(p client.ConfigProvider, cfg *aws.Config) *serviceDiscoveryClient {
+	region := aws.StringValue(cfg.Region)
+	return &serviceDiscoveryClient{
+		control: newJSONClient(p, cfg, serviceDiscoveryName, region),
+		data:    newJSONClient(p, cfg, serviceDiscoveryDataName, region),
+	}
+}
+
+func newJSONClient(p client.ConfigProvider, cfg *aws.Config, endpointName, region string) *client.Client {
+	// the vendored endpoints model does not know the service, the endpoint is built from the region
+	dnsSuffix := "amazonaws.com"
+	if strings.HasPrefix(region, "cn-") {
+		dnsSuffix = "amazonaws.com.cn"
+	}
+	endpoint := fmt.Sprintf("https://%s.%s.%s", endpointName, region, dnsSuffix)
+
+	c := p.ClientConfig(serviceDiscoveryName, cfg, &aws.Config{Endpoint: aws.String(endpoint)})
+
+	svc := client.New(
+		*c.Config,
+		metadata.ClientInfo{
+			ServiceName:   serviceDiscoveryName,
+			SigningName:   serviceDiscoveryName,
+			SigningRegion: c.SigningRegion,
+			Endpoint:      c.Endpoint,
+			APIVersion:    "2017-03-14",
+			JSONVersion:   "1.1",
+			TargetPrefix:  "Route53AutoNaming_v20170314",
+		},
+		c.Handlers,
+	)
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return svc
+}
+
+func send(ctx context.Context, c *client.Client, name string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	req := c.NewRequest(op, input, output)
+	req.HTTPRequest = req.HTTPRequest.WithContext(ctx)
+	return req.Send()
+}
+
+// ListNamespaces returns a page of the namespaces of the account.
+func (c *serviceDiscoveryClient) ListNamespaces(ctx context.Context, input *listNamespacesInput) (*listNamespacesOutput, error) {
+	output := &listNamespacesOutput{}
+	return output, send(ctx, c.control, "ListNamespaces", input, output)
+}
+
+// ListServices returns a page of the services matching the filters.
+func (c *serviceDiscoveryClient) ListServices(ctx context.Context, input *listServicesInput) (*listServicesOutput, error) {
+	output := &listServicesOutput{}
+	return output, send(ctx, c.control, "ListServices", input, output)
+}
+
+// DiscoverInstances returns the registered instances of a service.
+func (c *serviceDiscoveryClient) DiscoverInstances(ctx context.Context, input *discoverInstancesInput) (*discoverInstancesOutput, error) {
+	output := &discoverInstancesOutput{}
+	return output, send(ctx, c.data, "DiscoverInstances", input, output)
+}
+
+type listNamespacesInput struct {
+	_ struct{} `type:"structure"`
+
+	NextToken *string `type:"string"`
+}
+
+type listNamespacesOutput struct {
+	_ struct{} `type:"structure"`
+
+	Namespaces []*namespaceSummary `type:"list"`
+	NextToken  *string             `type:"string"`
+}
+
+type namespaceSummary struct {
+	_ struct{} `type:"structure"`
+
+	ID   *string `locationName:"Id" type:"string"`
+	Name *string `type:"string"`
+	Type *string `type:"string"`
+}
+
+type listServicesInput struct {
+	_ struct{} `type:"structure"`
+
+	Filters   []*serviceFilter `type:"list"`
+	NextToken *string          `type:"string"`
+}
+
+type serviceFilter struct {
+	_ struct{} `type:"structure"`
+
+	Condition *string   `type:"string"`
+	Name      *string   `type:"string" required:"true"`
+	Values    []*string `type:"list" required:"true"`
+}
+
+type listServicesOutput struct {
+	_ struct{} `type:"structure"`
+
+	NextToken *string           `type:"string"`
+	Services  []*serviceSummary `type:"list"`
+}
+
+type serviceSummary struct {
+	_ struct{} `type:"structure"`
+
+	ID   *string `locationName:"Id" type:"string"`
+	Name *string `type:"string"`
+}
+
+type discoverInstancesInput struct {
+	_ struct{} `type:"structure"`
+
+	HealthStatus  *string `type:"string"`
+	MaxResults    *int64  `type:"integer"`
+	NamespaceName *string `type:"string" required:"true"`
+	ServiceName   *string `type:"string" required:"true"`
+}
+
+type discoverInstancesOutput struct {
+	_ struct{} `type:"structure"`
+
+	Instances []*httpInstanceSummary `type:"list"`
+}
+
+type httpInstanceSummary struct {
+	_ struct{} `type:"structure"`
+
+	Attributes    map[string]*string `type:"map"`
+	HealthStatus  *string            `type:"string"`
+	InstanceID    *string            `locationName:"InstanceId" type:"string"`
+	NamespaceName *string            `type:"string"`
+	ServiceName   *string            `type:"string"`
+}
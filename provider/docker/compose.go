@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"gopkg.in/fsnotify.v1"
+)
+
+var _ provider.Provider = (*ComposeProvider)(nil)
+
+// ComposeProvider holds configurations of the Docker Compose provider.
+// It reads the services and their labels from Compose or stack files, without a Docker daemon,
+// and builds the configuration the Docker provider would build for the containers of these services.
+type ComposeProvider struct {
+	provider.BaseProvider `mapstructure:",squash" export:"true"`
+	Files                 []string `description:"Compose or stack files, the services of a file override the ones of the previous files"`
+	ProjectName           string   `description:"Compose project name, the name of the directory of the first file by default" export:"true"`
+	Domain                string   `description:"Default domain used"`
+	ExposedByDefault      bool     `description:"Expose services by default" export:"true"`
+}
+
+// Provide allows the compose provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *ComposeProvider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+	if len(p.Files) == 0 {
+		return errors.New("no file defined for the compose provider")
+	}
+	p.Constraints = append(p.Constraints, constraints...)
+
+	configuration, err := p.buildConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if p.Watch {
+		if err := p.addWatcher(pool, configurationChan); err != nil {
+			return err
+		}
+	}
+
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  "compose",
+		Configuration: configuration,
+	}
+	return nil
+}
+
+// buildConfiguration reads the files and builds the configuration with the Docker provider.
+func (p *ComposeProvider) buildConfiguration() (*types.Configuration, error) {
+	services, err := readComposeFiles(p.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerDataList, err := parseComposeServices(composeProjectName(p.ProjectName, p.Files), services)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerProvider := &Provider{
+		BaseProvider:     p.BaseProvider,
+		Domain:           p.Domain,
+		ExposedByDefault: p.ExposedByDefault,
+	}
+	return dockerProvider.buildConfiguration(dockerDataList), nil
+}
+
+// addWatcher builds the configuration again when one of the files changes.
+func (p *ComposeProvider) addWatcher(pool *safe.Pool, configurationChan chan<- types.ConfigMessage) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %s", err)
+	}
+
+	files := make(map[string]bool)
+	directories := make(map[string]bool)
+	for _, file := range p.Files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		files[path] = true
+		directories[filepath.Dir(path)] = true
+	}
+	for directory := range directories {
+		// the directories are watched as the editors replace the files
+		if err := watcher.Add(directory); err != nil {
+			watcher.Close()
+			return fmt.Errorf("error adding file watcher: %s", err)
+		}
+	}
+
+	pool.Go(func(stop chan bool) {
+		defer watcher.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case evt := <-watcher.Events:
+				if !files[evt.Name] {
+					continue
+				}
+				configuration, err := p.buildConfiguration()
+				if err != nil {
+					log.Errorf("Error reading the Compose files: %v", err)
+					continue
+				}
+				configurationChan <- types.ConfigMessage{
+					ProviderName:  "compose",
+					Configuration: configuration,
+				}
+			case err := <-watcher.Errors:
+				log.Errorf("Watcher event error: %s", err)
+			}
+		}
+	})
+	return nil
+}
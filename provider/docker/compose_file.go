@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	dockercontainertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v2"
+)
+
+var composeProjectNameRegexp = regexp.MustCompile("[^a-z0-9]")
+
+// composeFile holds the parts of a Compose or stack file used by the provider.
+type composeFile struct {
+	Services map[string]*composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Labels      composeLabels `yaml:"labels"`
+	Ports       []composePort `yaml:"ports"`
+	Expose      []string      `yaml:"expose"`
+	NetworkMode string        `yaml:"network_mode"`
+	Deploy      struct {
+		Labels   composeLabels `yaml:"labels"`
+		Replicas *int          `yaml:"replicas"`
+	} `yaml:"deploy"`
+}
+
+// composeLabels holds labels defined either as a map or as a list of key=value.
+type composeLabels map[string]string
+
+// UnmarshalYAML decodes labels defined either as a map or as a list of key=value.
+func (l *composeLabels) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	labels := make(map[string]string)
+	if err := unmarshal(&labels); err == nil {
+		*l = labels
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return fmt.Errorf("labels must be a map or a list of key=value: %v", err)
+	}
+	for _, item := range list {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) == 2 {
+			labels[parts[0]] = parts[1]
+		} else {
+			labels[parts[0]] = ""
+		}
+	}
+	*l = labels
+	return nil
+}
+
+// composePort holds a port defined with the short syntax ([ip:][published:]target[/protocol]) or the long syntax.
+type composePort string
+
+// UnmarshalYAML decodes a port defined with the short syntax or the long syntax.
+func (p *composePort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		*p = composePort(short)
+		return nil
+	}
+
+	var long struct {
+		Target    int    `yaml:"target"`
+		Published int    `yaml:"published"`
+		Protocol  string `yaml:"protocol"`
+	}
+	if err := unmarshal(&long); err != nil {
+		return fmt.Errorf("port must use the short or the long syntax: %v", err)
+	}
+	port := strconv.Itoa(long.Target)
+	if long.Published > 0 {
+		port = strconv.Itoa(long.Published) + ":" + port
+	}
+	if len(long.Protocol) > 0 {
+		port += "/" + long.Protocol
+	}
+	*p = composePort(port)
+	return nil
+}
+
+// readComposeFiles reads the services of the Compose files, the services of a file overriding the ones of the previous files:
+// the labels are merged, the ports and the exposed ports are added.
+// The variables of the files are substituted with the values of the environment.
+func readComposeFiles(files []string) (map[string]*composeService, error) {
+	services := make(map[string]*composeService)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		compose := &composeFile{}
+		if err := yaml.Unmarshal([]byte(interpolate(string(content))), compose); err != nil {
+			return nil, fmt.Errorf("unable to parse the Compose file %s: %v", file, err)
+		}
+
+		for name, service := range compose.Services {
+			if service == nil {
+				service = &composeService{}
+			}
+			existing, ok := services[name]
+			if !ok {
+				services[name] = service
+				continue
+			}
+			existing.Labels = mergeLabels(existing.Labels, service.Labels)
+			existing.Deploy.Labels = mergeLabels(existing.Deploy.Labels, service.Deploy.Labels)
+			existing.Ports = append(existing.Ports, service.Ports...)
+			existing.Expose = append(existing.Expose, service.Expose...)
+			if len(service.NetworkMode) > 0 {
+				existing.NetworkMode = service.NetworkMode
+			}
+			if service.Deploy.Replicas != nil {
+				existing.Deploy.Replicas = service.Deploy.Replicas
+			}
+		}
+	}
+	return services, nil
+}
+
+func mergeLabels(labels, overrides composeLabels) composeLabels {
+	merged := make(composeLabels)
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// interpolate substitutes the ${VARIABLE}, ${VARIABLE:-default} and ${VARIABLE-default} variables with the values of the environment,
+// $$ being a literal $.
+func interpolate(content string) string {
+	return os.Expand(content, func(variable string) string {
+		if variable == "$" {
+			return "$"
+		}
+		if i := strings.Index(variable, ":-"); i >= 0 {
+			if value := os.Getenv(variable[:i]); len(value) > 0 {
+				return value
+			}
+			return variable[i+2:]
+		}
+		if i := strings.Index(variable, "-"); i >= 0 {
+			if value, ok := os.LookupEnv(variable[:i]); ok {
+				return value
+			}
+			return variable[i+1:]
+		}
+		return os.Getenv(variable)
+	})
+}
+
+// composeProjectName returns the project name as Compose does, from the name of the directory of the first file by default.
+func composeProjectName(name string, files []string) string {
+	if len(name) == 0 {
+		name = os.Getenv("COMPOSE_PROJECT_NAME")
+	}
+	if len(name) == 0 && len(files) > 0 {
+		if dir, err := filepath.Abs(filepath.Dir(files[0])); err == nil {
+			name = filepath.Base(dir)
+		}
+	}
+	return composeProjectNameRegexp.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// parseComposeServices builds the data of the services as if they were containers of the project,
+// reached on the default network of the project by the name of their service, or on the host with the host network mode.
+func parseComposeServices(project string, services map[string]*composeService) ([]dockerData, error) {
+	var names []string
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var dockerDataList []dockerData
+	for _, name := range names {
+		service := services[name]
+		if service.Deploy.Replicas != nil && *service.Deploy.Replicas == 0 {
+			continue
+		}
+
+		labels := mergeLabels(service.Labels, service.Deploy.Labels)
+		labels[labelDockerComposeProject] = project
+		labels[labelDockerComposeService] = name
+
+		var specs []string
+		for _, port := range service.Ports {
+			specs = append(specs, string(port))
+		}
+		specs = append(specs, service.Expose...)
+		exposedPorts, portBindings, err := nat.ParsePortSpecs(specs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ports of the service %s: %v", name, err)
+		}
+		ports := nat.PortMap{}
+		for port := range exposedPorts {
+			ports[port] = portBindings[port]
+		}
+
+		settings := networkSettings{Ports: ports}
+		if service.NetworkMode == "host" {
+			settings.NetworkMode = dockercontainertypes.NetworkMode(service.NetworkMode)
+		} else {
+			networkName := project + "_default"
+			settings.Networks = map[string]*networkData{
+				networkName: {Name: networkName, Addr: name},
+			}
+		}
+
+		dockerDataList = append(dockerDataList, dockerData{
+			ServiceName:     name,
+			Name:            project + "_" + name,
+			Labels:          labels,
+			NetworkSettings: settings,
+		})
+	}
+	return dockerDataList, nil
+}
@@ -0,0 +1,207 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeComposeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+	return path
+}
+
+func TestComposeBuildConfiguration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traefik-compose")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("TRAEFIK_COMPOSE_TEST_HOST", "api.example.com")
+	defer os.Unsetenv("TRAEFIK_COMPOSE_TEST_HOST")
+
+	base := writeComposeFile(t, dir, "docker-compose.yml", `
+version: "3"
+services:
+  whoami:
+    image: emilevauge/whoami
+    ports:
+      - "8080:80"
+    labels:
+      traefik.frontend.entryPoints: http
+  api:
+    image: example/api
+    expose:
+      - 9000
+    deploy:
+      labels:
+        - traefik.port=9000
+        - traefik.frontend.rule=Host:${TRAEFIK_COMPOSE_TEST_HOST}
+  db:
+    image: postgres
+    labels:
+      - traefik.enable=false
+  worker:
+    image: example/worker
+    expose:
+      - 8000
+    deploy:
+      replicas: 0
+`)
+	override := writeComposeFile(t, dir, "docker-compose.override.yml", `
+version: "3"
+services:
+  whoami:
+    labels:
+      traefik.frontend.entryPoints: http,https
+`)
+
+	p := &ComposeProvider{
+		Files:            []string{base, override},
+		ProjectName:      "Preview-42",
+		Domain:           "docker.localhost",
+		ExposedByDefault: true,
+	}
+
+	configuration, err := p.buildConfiguration()
+	require.NoError(t, err)
+
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend-api-preview42": {
+				Servers: map[string]types.Server{
+					"server-preview42_api": {URL: "http://api:9000"},
+				},
+			},
+			"backend-whoami-preview42": {
+				Servers: map[string]types.Server{
+					"server-preview42_whoami": {URL: "http://whoami:80"},
+				},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"frontend-Host-api-example-com-0": {
+				Backend:        "backend-api-preview42",
+				PassHostHeader: true,
+				EntryPoints:    []string{},
+				BasicAuth:      []string{},
+				Routes: map[string]types.Route{
+					"route-frontend-Host-api-example-com-0": {Rule: "Host:api.example.com"},
+				},
+			},
+			"frontend-Host-whoami-preview42-docker-localhost-1": {
+				Backend:        "backend-whoami-preview42",
+				PassHostHeader: true,
+				EntryPoints:    []string{"http", "https"},
+				BasicAuth:      []string{},
+				Routes: map[string]types.Route{
+					"route-frontend-Host-whoami-preview42-docker-localhost-1": {Rule: "Host:whoami.preview42.docker.localhost"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, configuration)
+}
+
+func TestParseComposeServices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traefik-compose")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := writeComposeFile(t, dir, "stack.yml", `
+version: "3.4"
+services:
+  web:
+    ports:
+      - target: 80
+        published: 8080
+        protocol: tcp
+      - "127.0.0.1:8443:443"
+  host:
+    network_mode: host
+    expose:
+      - "3000"
+`)
+
+	services, err := readComposeFiles([]string{file})
+	require.NoError(t, err)
+
+	dockerDataList, err := parseComposeServices("stack", services)
+	require.NoError(t, err)
+	require.Len(t, dockerDataList, 2)
+
+	assert.Equal(t, "host", dockerDataList[0].ServiceName)
+	assert.True(t, dockerDataList[0].NetworkSettings.NetworkMode.IsHost())
+	assert.Equal(t, nat.PortMap{"3000/tcp": {{}}}, dockerDataList[0].NetworkSettings.Ports)
+
+	assert.Equal(t, "web", dockerDataList[1].ServiceName)
+	assert.Equal(t, map[string]string{
+		labelDockerComposeProject: "stack",
+		labelDockerComposeService: "web",
+	}, dockerDataList[1].Labels)
+	assert.Equal(t, nat.PortMap{
+		"80/tcp":  {{HostPort: "8080"}},
+		"443/tcp": {{HostIP: "127.0.0.1", HostPort: "8443"}},
+	}, dockerDataList[1].NetworkSettings.Ports)
+	assert.Equal(t, map[string]*networkData{
+		"stack_default": {Name: "stack_default", Addr: "web"},
+	}, dockerDataList[1].NetworkSettings.Networks)
+}
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("TRAEFIK_COMPOSE_TEST_SET", "value")
+	defer os.Unsetenv("TRAEFIK_COMPOSE_TEST_SET")
+	os.Setenv("TRAEFIK_COMPOSE_TEST_EMPTY", "")
+	defer os.Unsetenv("TRAEFIK_COMPOSE_TEST_EMPTY")
+
+	testCases := []struct {
+		desc     string
+		content  string
+		expected string
+	}{
+		{
+			desc:     "set variable",
+			content:  "host: ${TRAEFIK_COMPOSE_TEST_SET}",
+			expected: "host: value",
+		},
+		{
+			desc:     "unset variable",
+			content:  "host: $TRAEFIK_COMPOSE_TEST_UNSET",
+			expected: "host: ",
+		},
+		{
+			desc:     "default when unset or empty",
+			content:  "${TRAEFIK_COMPOSE_TEST_EMPTY:-default} ${TRAEFIK_COMPOSE_TEST_UNSET:-default}",
+			expected: "default default",
+		},
+		{
+			desc:     "default when unset",
+			content:  "${TRAEFIK_COMPOSE_TEST_EMPTY-default} ${TRAEFIK_COMPOSE_TEST_UNSET-default}",
+			expected: " default",
+		},
+		{
+			desc:     "escaped dollar",
+			content:  "password: $$apr1$$",
+			expected: "password: $apr1$",
+		},
+	}
+
+	// not parallel, the variables are unset when the test returns
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, interpolate(test.content))
+		})
+	}
+}
+
+func TestComposeProjectName(t *testing.T) {
+	assert.Equal(t, "myproject", composeProjectName("My_Project", nil))
+	assert.Equal(t, "preview", composeProjectName("", []string{"/srv/preview/docker-compose.yml"}))
+}
@@ -35,11 +35,47 @@ func (p *Provider) buildConfiguration(containersInspected []dockerData) *types.C
 		"getSticky":                   getSticky,
 		"hasStickinessLabel":          hasFunc(label.TraefikBackendLoadBalancerStickiness),
 		"getStickinessCookieName":     getFuncStringLabel(label.TraefikBackendLoadBalancerStickinessCookieName, label.DefaultBackendLoadbalancerStickinessCookieName),
+		"getStickinessHeader":         getFuncStringLabel(label.TraefikBackendLoadBalancerStickinessHeader, ""),
+		"getStickinessQuery":          getFuncStringLabel(label.TraefikBackendLoadBalancerStickinessQuery, ""),
+		"hasBackendTLSLabels":         hasBackendTLSLabels,
+		"getBackendTLSCert":           getFuncStringLabel(label.TraefikBackendTLSCert, ""),
+		"getBackendTLSKey":            getFuncStringLabel(label.TraefikBackendTLSKey, ""),
+		"getBackendTLSCA":             getFuncStringLabel(label.TraefikBackendTLSCA, ""),
+		"getBackendTLSInsecure":       getFuncBoolLabel(label.TraefikBackendTLSInsecureSkipVerify, false),
 		"isBackendLBSwarm":            isBackendLBSwarm, // FIXME DEAD ?
 		"getServiceBackend":           getServiceBackend,
 		"getServiceRedirect":          getFuncServiceStringLabel(label.SuffixFrontendRedirect, label.DefaultFrontendRedirect),
 		"getWhitelistSourceRange":     getFuncSliceStringLabel(label.TraefikFrontendWhitelistSourceRange),
 
+		"hasBackendTransportLabels":           hasBackendTransportLabels,
+		"getBackendTransportMaxIdleConns":     getFuncInt64Label(label.TraefikBackendTransportMaxIdleConnsPerHost, 0),
+		"getBackendTransportIdleConnTimeout":  getFuncStringLabel(label.TraefikBackendTransportIdleConnTimeout, "0"),
+		"getBackendTransportTLSHandshake":     getFuncStringLabel(label.TraefikBackendTransportTLSHandshakeTimeout, "0"),
+		"getBackendTransportResponseHeader":   getFuncStringLabel(label.TraefikBackendTransportResponseHeaderTimeout, "0"),
+		"getBackendTransportDisableKeepAlive": getFuncBoolLabel(label.TraefikBackendTransportDisableKeepAlives, false),
+
+		"hasBackendResponseTimeoutLabels":  hasBackendResponseTimeoutLabels,
+		"getBackendResponseTimeout":        getFuncStringLabel(label.TraefikBackendResponseTimeout, "0"),
+		"getBackendDeadlineHeader":         getFuncStringLabel(label.TraefikBackendResponseTimeoutDeadlineHeader, ""),
+		"hasFrontendResponseTimeoutLabels": hasFrontendResponseTimeoutLabels,
+		"getFrontendResponseTimeout":       getFuncStringLabel(label.TraefikFrontendResponseTimeout, "0"),
+		"getFrontendDeadlineHeader":        getFuncStringLabel(label.TraefikFrontendResponseTimeoutDeadlineHeader, ""),
+		"hasTrafficSplit":                  hasFunc(label.TraefikFrontendTrafficSplit),
+		"getTrafficSplit":                  getTrafficSplit,
+		"hasFailoverLabel":                 hasFunc(label.TraefikBackendFailover),
+		"getFailoverBackend":               getFailoverBackend,
+		"getFailoverMinHealthyPercent":     getFuncInt64Label(label.TraefikBackendFailoverMinHealthyPercent, 0),
+
+		"hasMaintenanceLabels":            hasMaintenanceLabels,
+		"getMaintenanceEnabled":           getFuncBoolLabel(label.TraefikFrontendMaintenanceEnabled, false),
+		"getMaintenanceStatusCode":        getFuncInt64Label(label.TraefikFrontendMaintenanceStatusCode, 0),
+		"getMaintenanceBody":              getFuncStringLabel(label.TraefikFrontendMaintenanceBody, ""),
+		"getMaintenanceFile":              getFuncStringLabel(label.TraefikFrontendMaintenanceFile, ""),
+		"getMaintenanceContentType":       getFuncStringLabel(label.TraefikFrontendMaintenanceContentType, ""),
+		"getMaintenanceBypassHeader":      getFuncStringLabel(label.TraefikFrontendMaintenanceBypassHeader, ""),
+		"getMaintenanceBypassValue":       getFuncStringLabel(label.TraefikFrontendMaintenanceBypassValue, ""),
+		"getMaintenanceBypassSourceRange": getFuncSliceStringLabel(label.TraefikFrontendMaintenanceBypassSourceRange),
+
 		"hasRequestHeaders":                 hasFunc(label.TraefikFrontendRequestHeaders),
 		"getRequestHeaders":                 getFuncMapLabel(label.TraefikFrontendRequestHeaders),
 		"hasResponseHeaders":                hasFunc(label.TraefikFrontendResponseHeaders),
@@ -112,6 +112,89 @@ func hasMaxConnLabels(container dockerData) bool {
 	return mca && mcef
 }
 
+func hasBackendTLSLabels(container dockerData) bool {
+	cert := label.Has(container.Labels, label.TraefikBackendTLSCert)
+	key := label.Has(container.Labels, label.TraefikBackendTLSKey)
+	ca := label.Has(container.Labels, label.TraefikBackendTLSCA)
+	insecure := label.Has(container.Labels, label.TraefikBackendTLSInsecureSkipVerify)
+	return cert || key || ca || insecure
+}
+
+func hasBackendTransportLabels(container dockerData) bool {
+	for _, labelName := range []string{
+		label.TraefikBackendTransportMaxIdleConnsPerHost,
+		label.TraefikBackendTransportIdleConnTimeout,
+		label.TraefikBackendTransportTLSHandshakeTimeout,
+		label.TraefikBackendTransportResponseHeaderTimeout,
+		label.TraefikBackendTransportDisableKeepAlives,
+	} {
+		if label.Has(container.Labels, labelName) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBackendResponseTimeoutLabels(container dockerData) bool {
+	timeout := label.Has(container.Labels, label.TraefikBackendResponseTimeout)
+	deadlineHeader := label.Has(container.Labels, label.TraefikBackendResponseTimeoutDeadlineHeader)
+	return timeout || deadlineHeader
+}
+
+func hasFrontendResponseTimeoutLabels(container dockerData) bool {
+	timeout := label.Has(container.Labels, label.TraefikFrontendResponseTimeout)
+	deadlineHeader := label.Has(container.Labels, label.TraefikFrontendResponseTimeoutDeadlineHeader)
+	return timeout || deadlineHeader
+}
+
+// getTrafficSplit returns the percentages of the traffic of the frontend sent to other backends,
+// from a label like "v2:10,v3:5".
+func getTrafficSplit(container dockerData) map[string]int {
+	values := label.GetSliceStringValue(container.Labels, label.TraefikFrontendTrafficSplit)
+	if len(values) == 0 {
+		return nil
+	}
+
+	trafficSplit := make(map[string]int)
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			log.Errorf("Invalid traffic split %q of container %s, skipping...", value, container.Name)
+			continue
+		}
+		percent, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			log.Errorf("Invalid percentage of the traffic split %q of container %s, skipping...", value, container.Name)
+			continue
+		}
+		trafficSplit[provider.Normalize(strings.TrimSpace(parts[0]))] = percent
+	}
+	return trafficSplit
+}
+
+// getFailoverBackend returns the backend receiving the requests of the backend while too few of its servers are healthy.
+func getFailoverBackend(container dockerData) string {
+	return provider.Normalize(label.GetStringValue(container.Labels, label.TraefikBackendFailover, ""))
+}
+
+func hasMaintenanceLabels(container dockerData) bool {
+	for _, labelName := range []string{
+		label.TraefikFrontendMaintenanceEnabled,
+		label.TraefikFrontendMaintenanceStatusCode,
+		label.TraefikFrontendMaintenanceBody,
+		label.TraefikFrontendMaintenanceFile,
+		label.TraefikFrontendMaintenanceContentType,
+		label.TraefikFrontendMaintenanceBypassHeader,
+		label.TraefikFrontendMaintenanceBypassValue,
+		label.TraefikFrontendMaintenanceBypassSourceRange,
+	} {
+		if label.Has(container.Labels, labelName) {
+			return true
+		}
+	}
+	return false
+}
+
 func getBackend(container dockerData) string {
 	if value := label.GetStringValue(container.Labels, label.TraefikBackend, ""); len(value) != 0 {
 		return provider.Normalize(value)
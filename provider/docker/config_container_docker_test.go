@@ -4,7 +4,9 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/types"
 	docker "github.com/docker/docker/api/types"
@@ -180,6 +182,326 @@ func TestDockerLoadDockerConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikBackendTLSCert:               "/certs/client.crt",
+						label.TraefikBackendTLSKey:                "/certs/client.key",
+						label.TraefikBackendTLSCA:                 "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+						label.TraefikBackendTLSInsecureSkipVerify: "true",
+					}),
+					ports(nat.PortMap{
+						"443/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:443",
+							Weight: 0,
+						},
+					},
+					TLS: &types.BackendTLS{
+						Cert:               "/certs/client.crt",
+						Key:                "/certs/client.key",
+						CA:                 "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+						InsecureSkipVerify: true,
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikBackendTransportMaxIdleConnsPerHost:   "50",
+						label.TraefikBackendTransportResponseHeaderTimeout: "120s",
+						label.TraefikBackendTransportDisableKeepAlives:     "true",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+					Transport: &types.BackendTransport{
+						MaxIdleConnsPerHost:   50,
+						ResponseHeaderTimeout: flaeg.Duration(120 * time.Second),
+						DisableKeepAlives:     true,
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikBackendResponseTimeout:               "5s",
+						label.TraefikBackendResponseTimeoutDeadlineHeader: "X-Request-Deadline",
+						label.TraefikFrontendResponseTimeout:              "120s",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+					ResponseTimeout: &types.ResponseTimeout{
+						Timeout: flaeg.Duration(120 * time.Second),
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+					ResponseTimeout: &types.ResponseTimeout{
+						Timeout:        flaeg.Duration(5 * time.Second),
+						DeadlineHeader: "X-Request-Deadline",
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikFrontendTrafficSplit: "test-canary:10, test.v3:5",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+					TrafficSplit: map[string]int{
+						"backend-test-canary": 10,
+						"backend-test-v3":     5,
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikBackendFailover:                  "test.dr",
+						label.TraefikBackendFailoverMinHealthyPercent: "50",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+					Failover: &types.Failover{
+						Backend:           "backend-test-dr",
+						MinHealthyPercent: 50,
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikBackendLoadBalancerStickiness:       "true",
+						label.TraefikBackendLoadBalancerStickinessHeader: "X-Session",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+					LoadBalancer: &types.LoadBalancer{
+						Method: "wrr",
+						Stickiness: &types.Stickiness{
+							Header: "X-Session",
+						},
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test"),
+					labels(map[string]string{
+						label.TraefikFrontendMaintenanceEnabled:           "true",
+						label.TraefikFrontendMaintenanceStatusCode:        "503",
+						label.TraefikFrontendMaintenanceBody:              "<h1>Back soon</h1>",
+						label.TraefikFrontendMaintenanceBypassHeader:      "X-Maintenance-Bypass",
+						label.TraefikFrontendMaintenanceBypassValue:       "secret",
+						label.TraefikFrontendMaintenanceBypassSourceRange: "10.0.0.0/8,192.168.1.1",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test-docker-localhost-0": {
+					Backend:        "backend-test",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Redirect:       "",
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test-docker-localhost-0": {
+							Rule: "Host:test.docker.localhost",
+						},
+					},
+					Maintenance: &types.Maintenance{
+						Enabled:           true,
+						StatusCode:        503,
+						Body:              "<h1>Back soon</h1>",
+						BypassHeader:      "X-Maintenance-Bypass",
+						BypassValue:       "secret",
+						BypassSourceRange: []string{"10.0.0.0/8", "192.168.1.1"},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-test": {
+					Servers: map[string]types.Server{
+						"server-test": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for caseID, test := range testCases {
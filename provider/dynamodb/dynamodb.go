@@ -31,10 +31,13 @@ type Provider struct {
 	SecretAccessKey       string `description:"The AWS credentials secret key to use for making requests"`
 	TableName             string `description:"The AWS dynamodb table that stores configuration for traefik" export:"true"`
 	Endpoint              string `description:"The endpoint of a dynamodb. Used for testing with a local dynamodb"`
+	Streams               bool   `description:"Watch the changes through the DynamoDB Stream of the table instead of scanning it on each refresh" export:"true"`
+	ReconcileSeconds      int    `description:"Interval (in seconds) between two full scans of the table when using the stream" export:"true"`
 }
 
 type dynamoClient struct {
-	db dynamodbiface.DynamoDBAPI
+	db      dynamodbiface.DynamoDBAPI
+	streams streamsAPI
 }
 
 // createClient configures aws credentials and creates a dynamoClient
@@ -71,7 +74,8 @@ func (p *Provider) createClient() (*dynamoClient, error) {
 	}
 
 	return &dynamoClient{
-		dynamodb.New(sess, cfg),
+		db:      dynamodb.New(sess, cfg),
+		streams: newStreamsClient(sess, cfg),
 	}, nil
 }
 
@@ -101,6 +105,11 @@ func (p *Provider) buildConfiguration(client *dynamoClient) (*types.Configuratio
 	if err != nil {
 		return nil, err
 	}
+	return p.buildConfigurationFromItems(items), nil
+}
+
+// buildConfigurationFromItems converts the given items into Backends and Frontends in a Configuration
+func (p *Provider) buildConfigurationFromItems(items []map[string]*dynamodb.AttributeValue) *types.Configuration {
 	log.Debugf("Number of Items retrieved from Provider: %d", len(items))
 	backends := make(map[string]*types.Backend)
 	frontends := make(map[string]*types.Frontend)
@@ -112,7 +121,7 @@ func (p *Provider) buildConfiguration(client *dynamoClient) (*types.Configuratio
 		if backend, exists := item["backend"]; exists {
 			log.Debug("Unmarshaling backend from Provider...")
 			tmpBackend := &types.Backend{}
-			err = dynamodbattribute.Unmarshal(backend, tmpBackend)
+			err := dynamodbattribute.Unmarshal(backend, tmpBackend)
 			if err != nil {
 				log.Errorf(err.Error())
 			} else {
@@ -122,7 +131,7 @@ func (p *Provider) buildConfiguration(client *dynamoClient) (*types.Configuratio
 		} else if frontend, exists := item["frontend"]; exists {
 			log.Debugf("Unmarshaling frontend from Provider...")
 			tmpFrontend := &types.Frontend{}
-			err = dynamodbattribute.Unmarshal(frontend, tmpFrontend)
+			err := dynamodbattribute.Unmarshal(frontend, tmpFrontend)
 			if err != nil {
 				log.Errorf(err.Error())
 			} else {
@@ -137,7 +146,7 @@ func (p *Provider) buildConfiguration(client *dynamoClient) (*types.Configuratio
 	return &types.Configuration{
 		Backends:  backends,
 		Frontends: frontends,
-	}, nil
+	}
 }
 
 // Provide provides the configuration to traefik via the configuration channel
@@ -167,6 +176,10 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 				return handleCanceled(ctx, err)
 			}
 
+			if p.Watch && p.Streams {
+				return handleCanceled(ctx, p.watchStream(ctx, awsClient, configurationChan))
+			}
+
 			configuration, err := p.buildConfiguration(awsClient)
 			if err != nil {
 				return handleCanceled(ctx, err)
@@ -0,0 +1,167 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// The vendored AWS SDK does not ship the DynamoDB Streams client,
+// this is a minimal implementation of the operations used to follow the stream of a table.
+// See https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_Operations_Amazon_DynamoDB_Streams.html
+
+const (
+	streamsEndpointsID = "streams.dynamodb"
+
+	shardIteratorTypeLatest      = "LATEST"
+	shardIteratorTypeTrimHorizon = "TRIM_HORIZON"
+
+	eventNameInsert = "INSERT"
+	eventNameModify = "MODIFY"
+	eventNameRemove = "REMOVE"
+)
+
+// streamsAPI is the subset of the DynamoDB Streams API used by the provider.
+type streamsAPI interface {
+	DescribeStream(*describeStreamInput) (*describeStreamOutput, error)
+	GetShardIterator(*getShardIteratorInput) (*getShardIteratorOutput, error)
+	GetRecords(*getRecordsInput) (*getRecordsOutput, error)
+}
+
+type streamsClient struct {
+	*client.Client
+}
+
+func newStreamsClient(p client.ConfigProvider, cfgs ...*aws.Config) *streamsClient {
+	c := p.ClientConfig(streamsEndpointsID, cfgs...)
+
+	signingName := c.SigningName
+	if signingName == "" {
+		signingName = dynamodb.ServiceName
+	}
+
+	svc := &streamsClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   streamsEndpointsID,
+				SigningName:   signingName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2012-08-10",
+				JSONVersion:   "1.0",
+				TargetPrefix:  "DynamoDBStreams_20120810",
+			},
+			c.Handlers,
+		),
+	}
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return svc
+}
+
+func (c *streamsClient) send(name string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, input, output).Send()
+}
+
+// DescribeStream returns information about a stream, including its shards.
+func (c *streamsClient) DescribeStream(input *describeStreamInput) (*describeStreamOutput, error) {
+	output := &describeStreamOutput{}
+	return output, c.send("DescribeStream", input, output)
+}
+
+// GetShardIterator returns a shard iterator, used to read the records of a shard.
+func (c *streamsClient) GetShardIterator(input *getShardIteratorInput) (*getShardIteratorOutput, error) {
+	output := &getShardIteratorOutput{}
+	return output, c.send("GetShardIterator", input, output)
+}
+
+// GetRecords returns the records of a shard from the given shard iterator.
+func (c *streamsClient) GetRecords(input *getRecordsInput) (*getRecordsOutput, error) {
+	output := &getRecordsOutput{}
+	return output, c.send("GetRecords", input, output)
+}
+
+type describeStreamInput struct {
+	_ struct{} `type:"structure"`
+
+	ExclusiveStartShardID *string `locationName:"ExclusiveStartShardId" type:"string"`
+	StreamArn             *string `type:"string" required:"true"`
+}
+
+type describeStreamOutput struct {
+	_ struct{} `type:"structure"`
+
+	StreamDescription *streamDescription `type:"structure"`
+}
+
+type streamDescription struct {
+	_ struct{} `type:"structure"`
+
+	LastEvaluatedShardID *string  `locationName:"LastEvaluatedShardId" type:"string"`
+	Shards               []*shard `type:"list"`
+	StreamArn            *string  `type:"string"`
+	StreamStatus         *string  `type:"string"`
+}
+
+type shard struct {
+	_ struct{} `type:"structure"`
+
+	ParentShardID *string `locationName:"ParentShardId" type:"string"`
+	ShardID       *string `locationName:"ShardId" type:"string"`
+}
+
+type getShardIteratorInput struct {
+	_ struct{} `type:"structure"`
+
+	ShardID           *string `locationName:"ShardId" type:"string" required:"true"`
+	ShardIteratorType *string `type:"string" required:"true"`
+	StreamArn         *string `type:"string" required:"true"`
+}
+
+type getShardIteratorOutput struct {
+	_ struct{} `type:"structure"`
+
+	ShardIterator *string `type:"string"`
+}
+
+type getRecordsInput struct {
+	_ struct{} `type:"structure"`
+
+	ShardIterator *string `type:"string" required:"true"`
+}
+
+type getRecordsOutput struct {
+	_ struct{} `type:"structure"`
+
+	NextShardIterator *string   `type:"string"`
+	Records           []*record `type:"list"`
+}
+
+type record struct {
+	_ struct{} `type:"structure"`
+
+	EventName *string       `locationName:"eventName" type:"string"`
+	Dynamodb  *streamRecord `locationName:"dynamodb" type:"structure"`
+}
+
+type streamRecord struct {
+	_ struct{} `type:"structure"`
+
+	Keys     map[string]*dynamodb.AttributeValue `type:"map"`
+	NewImage map[string]*dynamodb.AttributeValue `type:"map"`
+}
@@ -0,0 +1,236 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// tableStream keeps a copy of the table items up to date with the records of the table stream.
+type tableStream struct {
+	client    streamsAPI
+	streamArn string
+	keyNames  []string
+	// shard ID -> next shard iterator, nil when the shard is closed and fully read
+	iterators map[string]*string
+	items     map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (p *Provider) newTableStream(client *dynamoClient) (*tableStream, error) {
+	output, err := client.db.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(p.TableName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if output.Table == nil || output.Table.LatestStreamArn == nil {
+		return nil, fmt.Errorf("no stream enabled on Provider table %s", p.TableName)
+	}
+
+	stream := &tableStream{
+		client:    client.streams,
+		streamArn: aws.StringValue(output.Table.LatestStreamArn),
+		iterators: make(map[string]*string),
+		items:     make(map[string]map[string]*dynamodb.AttributeValue),
+	}
+	for _, key := range output.Table.KeySchema {
+		stream.keyNames = append(stream.keyNames, aws.StringValue(key.AttributeName))
+	}
+	sort.Strings(stream.keyNames)
+
+	// the shards which already exist are read from their end: their content is retrieved by the table scan
+	if err := stream.refreshShards(shardIteratorTypeLatest); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// refreshShards starts reading the shards unknown until now, from the given position, and forgets the expired ones.
+func (s *tableStream) refreshShards(iteratorType string) error {
+	described := make(map[string]bool)
+
+	input := &describeStreamInput{StreamArn: aws.String(s.streamArn)}
+	for {
+		output, err := s.client.DescribeStream(input)
+		if err != nil {
+			return err
+		}
+		if output.StreamDescription == nil {
+			break
+		}
+
+		for _, shard := range output.StreamDescription.Shards {
+			shardID := aws.StringValue(shard.ShardID)
+			described[shardID] = true
+			if _, exists := s.iterators[shardID]; exists {
+				continue
+			}
+
+			iterator, err := s.client.GetShardIterator(&getShardIteratorInput{
+				ShardID:           shard.ShardID,
+				ShardIteratorType: aws.String(iteratorType),
+				StreamArn:         aws.String(s.streamArn),
+			})
+			if err != nil {
+				return err
+			}
+			s.iterators[shardID] = iterator.ShardIterator
+		}
+
+		if output.StreamDescription.LastEvaluatedShardID == nil {
+			break
+		}
+		input.ExclusiveStartShardID = output.StreamDescription.LastEvaluatedShardID
+	}
+
+	for shardID := range s.iterators {
+		if !described[shardID] {
+			delete(s.iterators, shardID)
+		}
+	}
+	return nil
+}
+
+// poll applies the new records of the stream to the items and returns true if the items changed.
+func (s *tableStream) poll() (bool, error) {
+	var changed bool
+	for shardID, iterator := range s.iterators {
+		if iterator == nil {
+			continue
+		}
+
+		output, err := s.client.GetRecords(&getRecordsInput{ShardIterator: iterator})
+		if err != nil {
+			return changed, err
+		}
+
+		for _, record := range output.Records {
+			if s.apply(record) {
+				changed = true
+			}
+		}
+		s.iterators[shardID] = output.NextShardIterator
+	}
+
+	// the new shards are read from their beginning
+	return changed, s.refreshShards(shardIteratorTypeTrimHorizon)
+}
+
+func (s *tableStream) apply(record *record) bool {
+	if record.Dynamodb == nil {
+		return false
+	}
+
+	switch aws.StringValue(record.EventName) {
+	case eventNameInsert, eventNameModify:
+		if record.Dynamodb.NewImage == nil {
+			log.Warn("No new image in DynamoDB stream record, the stream view type must include new images")
+			return false
+		}
+		s.items[s.itemKey(record.Dynamodb.Keys)] = record.Dynamodb.NewImage
+	case eventNameRemove:
+		delete(s.items, s.itemKey(record.Dynamodb.Keys))
+	default:
+		log.Warnf("Unknown DynamoDB stream event: %s", aws.StringValue(record.EventName))
+		return false
+	}
+	return true
+}
+
+// reset replaces the items by the result of a full table scan.
+func (s *tableStream) reset(items []map[string]*dynamodb.AttributeValue) {
+	s.items = make(map[string]map[string]*dynamodb.AttributeValue)
+	for _, item := range items {
+		s.items[s.itemKey(item)] = item
+	}
+}
+
+func (s *tableStream) itemList() []map[string]*dynamodb.AttributeValue {
+	var items []map[string]*dynamodb.AttributeValue
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	return items
+}
+
+// itemKey builds a unique key for an item from its primary key attributes.
+func (s *tableStream) itemKey(item map[string]*dynamodb.AttributeValue) string {
+	var parts []string
+	for _, name := range s.keyNames {
+		value := item[name]
+		if value == nil {
+			parts = append(parts, "")
+			continue
+		}
+		parts = append(parts, aws.StringValue(value.S)+aws.StringValue(value.N)+string(value.B))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// watchStream provides the configuration each time the stream of the table holds new records,
+// and after each full scan of the table used to reconcile the items with the table.
+func (p *Provider) watchStream(ctx context.Context, client *dynamoClient, configurationChan chan<- types.ConfigMessage) error {
+	stream, err := p.newTableStream(client)
+	if err != nil {
+		return err
+	}
+
+	reconcile := func() error {
+		items, err := p.scanTable(client)
+		if err != nil {
+			return err
+		}
+		stream.reset(items)
+
+		configurationChan <- types.ConfigMessage{
+			ProviderName:  "dynamodb",
+			Configuration: p.buildConfigurationFromItems(stream.itemList()),
+		}
+		return nil
+	}
+
+	if err := reconcile(); err != nil {
+		return err
+	}
+
+	poll := time.NewTicker(time.Second * time.Duration(p.RefreshSeconds))
+	defer poll.Stop()
+	// the reconciliation is disabled when no interval is set
+	var reconciliation <-chan time.Time
+	if p.ReconcileSeconds > 0 {
+		reconcileTicker := time.NewTicker(time.Second * time.Duration(p.ReconcileSeconds))
+		defer reconcileTicker.Stop()
+		reconciliation = reconcileTicker.C
+	}
+
+	for {
+		log.Debug("Watching Provider stream...")
+		select {
+		case <-poll.C:
+			changed, err := stream.poll()
+			if err != nil {
+				return err
+			}
+			if changed {
+				configurationChan <- types.ConfigMessage{
+					ProviderName:  "dynamodb",
+					Configuration: p.buildConfigurationFromItems(stream.itemList()),
+				}
+			}
+		case <-reconciliation:
+			if err := reconcile(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
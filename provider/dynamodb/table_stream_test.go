@@ -0,0 +1,163 @@
+package dynamodb
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStreamsClient struct {
+	shards    []string
+	records   map[string][]*record
+	iterators map[string]string
+}
+
+func (m *mockStreamsClient) DescribeStream(input *describeStreamInput) (*describeStreamOutput, error) {
+	description := &streamDescription{StreamArn: input.StreamArn}
+	for _, shardID := range m.shards {
+		description.Shards = append(description.Shards, &shard{ShardID: aws.String(shardID)})
+	}
+	return &describeStreamOutput{StreamDescription: description}, nil
+}
+
+func (m *mockStreamsClient) GetShardIterator(input *getShardIteratorInput) (*getShardIteratorOutput, error) {
+	shardID := aws.StringValue(input.ShardID)
+	m.iterators[shardID] = aws.StringValue(input.ShardIteratorType)
+	return &getShardIteratorOutput{ShardIterator: aws.String(shardID)}, nil
+}
+
+func (m *mockStreamsClient) GetRecords(input *getRecordsInput) (*getRecordsOutput, error) {
+	shardID := aws.StringValue(input.ShardIterator)
+	records := m.records[shardID]
+	delete(m.records, shardID)
+	return &getRecordsOutput{NextShardIterator: input.ShardIterator, Records: records}, nil
+}
+
+func TestTableStreamPoll(t *testing.T) {
+	client := &mockStreamsClient{
+		shards:    []string{"shard1"},
+		records:   make(map[string][]*record),
+		iterators: make(map[string]string),
+	}
+	stream := &tableStream{
+		client:    client,
+		streamArn: "arn",
+		keyNames:  []string{"id"},
+		iterators: make(map[string]*string),
+	}
+	require.NoError(t, stream.refreshShards(shardIteratorTypeLatest))
+	assert.Equal(t, map[string]string{"shard1": shardIteratorTypeLatest}, client.iterators)
+
+	stream.reset([]map[string]*dynamodb.AttributeValue{
+		buildItem("backend1", "backend"),
+		buildItem("frontend1", "frontend"),
+	})
+
+	changed, err := stream.poll()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	client.shards = append(client.shards, "shard2")
+	client.records["shard1"] = []*record{
+		buildRecord(eventNameRemove, buildItem("frontend1", "frontend")),
+		buildRecord(eventNameModify, buildItem("backend1", "backend")),
+	}
+
+	changed, err = stream.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, shardIteratorTypeTrimHorizon, client.iterators["shard2"])
+
+	client.shards = []string{"shard2"}
+	client.records["shard2"] = []*record{
+		buildRecord(eventNameInsert, buildItem("frontend2", "frontend")),
+	}
+
+	changed, err = stream.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, stream.iterators, "shard1")
+
+	var ids []string
+	for _, item := range stream.itemList() {
+		ids = append(ids, aws.StringValue(item["id"].S))
+	}
+	sort.Strings(ids)
+	assert.Equal(t, []string{"backend1", "frontend2"}, ids)
+}
+
+func TestTableStreamApply(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		record          *record
+		expectedChanged bool
+		expectedItems   int
+	}{
+		{
+			desc:            "insert",
+			record:          buildRecord(eventNameInsert, buildItem("frontend2", "frontend")),
+			expectedChanged: true,
+			expectedItems:   2,
+		},
+		{
+			desc:            "modify",
+			record:          buildRecord(eventNameModify, buildItem("frontend1", "frontend")),
+			expectedChanged: true,
+			expectedItems:   1,
+		},
+		{
+			desc:            "remove",
+			record:          buildRecord(eventNameRemove, buildItem("frontend1", "frontend")),
+			expectedChanged: true,
+			expectedItems:   0,
+		},
+		{
+			desc: "missing new image",
+			record: &record{
+				EventName: aws.String(eventNameInsert),
+				Dynamodb:  &streamRecord{Keys: map[string]*dynamodb.AttributeValue{"id": {S: aws.String("frontend2")}}},
+			},
+			expectedItems: 1,
+		},
+		{
+			desc:          "unknown event",
+			record:        buildRecord("UNKNOWN", buildItem("frontend2", "frontend")),
+			expectedItems: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stream := &tableStream{keyNames: []string{"id"}}
+			stream.reset([]map[string]*dynamodb.AttributeValue{buildItem("frontend1", "frontend")})
+
+			assert.Equal(t, test.expectedChanged, stream.apply(test.record))
+			assert.Len(t, stream.items, test.expectedItems)
+		})
+	}
+}
+
+func buildItem(id, kind string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id":   {S: aws.String(id)},
+		"name": {S: aws.String(id)},
+		kind:   {M: map[string]*dynamodb.AttributeValue{}},
+	}
+}
+
+func buildRecord(eventName string, item map[string]*dynamodb.AttributeValue) *record {
+	return &record{
+		EventName: aws.String(eventName),
+		Dynamodb: &streamRecord{
+			Keys:     map[string]*dynamodb.AttributeValue{"id": item["id"]},
+			NewImage: item,
+		},
+	}
+}
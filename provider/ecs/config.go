@@ -25,6 +25,7 @@ func (p *Provider) buildConfiguration(services map[string][]ecsInstance) (*types
 		"getHost":                 getHost,
 		"getPort":                 getPort,
 		"getWeight":               getFuncStringValue(label.TraefikWeight, label.DefaultWeight),
+		"getMetadata":             getMetadata,
 		"getPassHostHeader":       getFuncStringValue(label.TraefikFrontendPassHostHeader, label.DefaultPassHostHeader),
 		"getPriority":             getFuncStringValue(label.TraefikFrontendPriority, label.DefaultFrontendPriority),
 		"getEntryPoints":          getFuncSliceString(label.TraefikFrontendEntryPoints),
@@ -57,6 +58,35 @@ func getHost(i ecsInstance) string {
 	return *i.machine.PrivateIpAddress
 }
 
+// getMetadata returns the AWS metadata of the task of the instance,
+// they are added to the access log of the requests forwarded to the instance.
+func getMetadata(i ecsInstance) map[string]string {
+	if i.task == nil {
+		return nil
+	}
+
+	metadata := make(map[string]string)
+	if i.task.TaskArn != nil {
+		metadata["ecsTaskArn"] = *i.task.TaskArn
+	}
+	if i.task.Group != nil && strings.HasPrefix(*i.task.Group, "service:") {
+		metadata["ecsServiceName"] = strings.TrimPrefix(*i.task.Group, "service:")
+	}
+	if i.task.ClusterArn != nil {
+		metadata["ecsCluster"] = arnResourceID(*i.task.ClusterArn)
+	}
+	if i.task.ContainerInstanceArn != nil {
+		metadata["ecsContainerInstanceId"] = arnResourceID(*i.task.ContainerInstanceArn)
+	}
+	return metadata
+}
+
+// arnResourceID returns the last part of the resource of an ARN,
+// e.g. "default" for "arn:aws:ecs:us-east-1:123456789012:cluster/default".
+func arnResourceID(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
 func getPort(i ecsInstance) string {
 	if value := getStringValue(i, label.TraefikPort, ""); len(value) > 0 {
 		return value
@@ -11,6 +11,57 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestGetMetadata(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		instance ecsInstance
+		expected map[string]string
+	}{
+		{
+			desc:     "without task",
+			instance: ecsInstance{},
+		},
+		{
+			desc: "task of a service",
+			instance: ecsInstance{
+				task: &ecs.Task{
+					TaskArn:              aws.String("arn:aws:ecs:us-east-1:123456789012:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a"),
+					Group:                aws.String("service:whoami"),
+					ClusterArn:           aws.String("arn:aws:ecs:us-east-1:123456789012:cluster/default"),
+					ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:123456789012:container-instance/f9cc75bb-0c94-46b9-bf6d-49d320bc1551"),
+				},
+			},
+			expected: map[string]string{
+				"ecsTaskArn":             "arn:aws:ecs:us-east-1:123456789012:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a",
+				"ecsServiceName":         "whoami",
+				"ecsCluster":             "default",
+				"ecsContainerInstanceId": "f9cc75bb-0c94-46b9-bf6d-49d320bc1551",
+			},
+		},
+		{
+			desc: "standalone task",
+			instance: ecsInstance{
+				task: &ecs.Task{
+					TaskArn: aws.String("arn:aws:ecs:us-east-1:123456789012:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a"),
+					Group:   aws.String("family:whoami"),
+				},
+			},
+			expected: map[string]string{
+				"ecsTaskArn": "arn:aws:ecs:us-east-1:123456789012:task/0b69d5c0-d655-4695-98cd-5d2d526d9d5a",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getMetadata(test.instance))
+		})
+	}
+}
+
 func TestBuildConfiguration(t *testing.T) {
 	provider := &Provider{}
 	tests := []struct {
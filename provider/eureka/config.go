@@ -21,13 +21,7 @@ func (p *Provider) buildConfiguration() (*types.Configuration, error) {
 		"getInstanceID": getInstanceID,
 	}
 
-	eureka.GetLogger().SetOutput(ioutil.Discard)
-
-	client := eureka.NewClient([]string{
-		p.Endpoint,
-	})
-
-	applications, err := client.GetApplications()
+	applications, err := p.fetchApplications()
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +29,7 @@ func (p *Provider) buildConfiguration() (*types.Configuration, error) {
 	templateObjects := struct {
 		Applications []eureka.Application
 	}{
-		applications.Applications,
+		applications,
 	}
 
 	configuration, err := p.GetConfiguration("templates/eureka.tmpl", EurekaFuncMap, templateObjects)
@@ -45,6 +39,47 @@ func (p *Provider) buildConfiguration() (*types.Configuration, error) {
 	return configuration, nil
 }
 
+// fetchApplications updates the local registry, using a delta fetch when possible,
+// and returns the applications with their instances matching the configured statuses.
+func (p *Provider) fetchApplications() ([]eureka.Application, error) {
+	if p.client == nil {
+		eureka.GetLogger().SetOutput(ioutil.Discard)
+		p.client = eureka.NewClient([]string{
+			p.Endpoint,
+		})
+	}
+
+	if p.registry != nil && !p.DisableDelta {
+		delta, err := getDelta(p.client)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.registry.applyDelta(delta); err != nil {
+			log.Warnf("Unable to apply Eureka registry delta, fetching the full registry: %v", err)
+		} else if p.registry.hashCode() != delta.AppsHashcode {
+			log.Debug("Eureka registry out of sync, fetching the full registry")
+		} else {
+			return p.registry.filter(p.getStatuses()), nil
+		}
+	}
+
+	applications, err := p.client.GetApplications()
+	if err != nil {
+		return nil, err
+	}
+	p.registry = newRegistry(applications)
+
+	return p.registry.filter(p.getStatuses()), nil
+}
+
+func (p *Provider) getStatuses() []string {
+	if len(p.Statuses) == 0 {
+		return []string{"UP"}
+	}
+	return p.Statuses
+}
+
 func getInstanceID(instance eureka.InstanceInfo) string {
 	defaultID := provider.Normalize(instance.IpAddr) + "-" + getPort(instance)
 	return label.GetStringValue(instance.Metadata.Map, label.TraefikBackendID, defaultID)
@@ -3,6 +3,7 @@ package eureka
 import (
 	"time"
 
+	"github.com/ArthurHlt/go-eureka-client/eureka"
 	"github.com/cenk/backoff"
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
@@ -14,8 +15,12 @@ import (
 // Provider holds configuration of the Provider provider.
 type Provider struct {
 	provider.BaseProvider `mapstructure:",squash" export:"true"`
-	Endpoint              string `description:"Eureka server endpoint"`
-	Delay                 string `description:"Override default configuration time between refresh" export:"true"`
+	Endpoint              string   `description:"Eureka server endpoint"`
+	Delay                 string   `description:"Override default configuration time between refresh" export:"true"`
+	Statuses              []string `description:"Route only to the instances with one of these statuses (default: UP)" export:"true"`
+	DisableDelta          bool     `description:"Always fetch the full registry instead of the changes since the last refresh" export:"true"`
+	client                *eureka.Client
+	registry              *registry
 }
 
 // Provide allows the eureka provider to provide configurations to traefik
@@ -0,0 +1,147 @@
+package eureka
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ArthurHlt/go-eureka-client/eureka"
+)
+
+// Delta action types, see https://github.com/Netflix/eureka/wiki/Eureka-REST-operations
+const (
+	actionAdded    = "ADDED"
+	actionModified = "MODIFIED"
+	actionDeleted  = "DELETED"
+)
+
+// registry is a local copy of the Eureka registry, kept up to date with delta fetches.
+type registry struct {
+	applications map[string]map[string]eureka.InstanceInfo
+}
+
+func newRegistry(applications *eureka.Applications) *registry {
+	r := &registry{applications: make(map[string]map[string]eureka.InstanceInfo)}
+	for _, app := range applications.Applications {
+		for _, instance := range app.Instances {
+			r.put(app.Name, instance)
+		}
+	}
+	return r
+}
+
+func (r *registry) put(appName string, instance eureka.InstanceInfo) {
+	instances, ok := r.applications[appName]
+	if !ok {
+		instances = make(map[string]eureka.InstanceInfo)
+		r.applications[appName] = instances
+	}
+	instances[instanceKey(instance)] = instance
+}
+
+func (r *registry) remove(appName string, instance eureka.InstanceInfo) {
+	instances, ok := r.applications[appName]
+	if !ok {
+		return
+	}
+	delete(instances, instanceKey(instance))
+	if len(instances) == 0 {
+		delete(r.applications, appName)
+	}
+}
+
+// applyDelta updates the registry with the changes returned by the delta endpoint.
+func (r *registry) applyDelta(delta *eureka.Applications) error {
+	for _, app := range delta.Applications {
+		for _, instance := range app.Instances {
+			switch instance.ActionType {
+			case actionAdded, actionModified:
+				r.put(app.Name, instance)
+			case actionDeleted:
+				r.remove(app.Name, instance)
+			default:
+				return fmt.Errorf("unknown action type %q for instance %s of application %s", instance.ActionType, instanceKey(instance), app.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// hashCode computes the registry hash code the same way the Eureka server does
+// (i.e. "DOWN_1_UP_3_"), it is used to detect a drift from the server registry.
+func (r *registry) hashCode() string {
+	counts := make(map[string]int)
+	for _, instances := range r.applications {
+		for _, instance := range instances {
+			counts[instance.Status]++
+		}
+	}
+
+	var statuses []string
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var hashCode string
+	for _, status := range statuses {
+		hashCode += fmt.Sprintf("%s_%d_", status, counts[status])
+	}
+	return hashCode
+}
+
+// filter returns the applications and their instances matching one of the statuses, sorted by name.
+func (r *registry) filter(statuses []string) []eureka.Application {
+	var names []string
+	for name := range r.applications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var applications []eureka.Application
+	for _, name := range names {
+		instances := r.applications[name]
+
+		var keys []string
+		for key, instance := range instances {
+			if matchStatus(instance.Status, statuses) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+
+		app := eureka.Application{Name: name}
+		for _, key := range keys {
+			app.Instances = append(app.Instances, instances[key])
+		}
+		applications = append(applications, app)
+	}
+	return applications
+}
+
+func matchStatus(status string, statuses []string) bool {
+	for _, s := range statuses {
+		if strings.EqualFold(s, status) {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceKey(instance eureka.InstanceInfo) string {
+	return instance.HostName + "-" + instance.IpAddr + "-" + getPort(instance)
+}
+
+func getDelta(client *eureka.Client) (*eureka.Applications, error) {
+	response, err := client.Get("apps/delta")
+	if err != nil {
+		return nil, err
+	}
+	delta := new(eureka.Applications)
+	err = xml.Unmarshal(response.Body, delta)
+	return delta, err
+}
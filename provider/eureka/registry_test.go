@@ -0,0 +1,142 @@
+package eureka
+
+import (
+	"testing"
+
+	"github.com/ArthurHlt/go-eureka-client/eureka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryApplyDelta(t *testing.T) {
+	registry := newRegistry(&eureka.Applications{
+		Applications: []eureka.Application{
+			{
+				Name: "FOO",
+				Instances: []eureka.InstanceInfo{
+					buildInstance("foo-1", "10.0.0.1", "UP"),
+					buildInstance("foo-2", "10.0.0.2", "UP"),
+				},
+			},
+			{
+				Name: "BAR",
+				Instances: []eureka.InstanceInfo{
+					buildInstance("bar-1", "10.0.0.3", "UP"),
+				},
+			},
+		},
+	})
+	assert.Equal(t, "UP_3_", registry.hashCode())
+
+	modified := buildInstance("foo-2", "10.0.0.2", "OUT_OF_SERVICE")
+	modified.ActionType = actionModified
+	deleted := buildInstance("bar-1", "10.0.0.3", "UP")
+	deleted.ActionType = actionDeleted
+	added := buildInstance("baz-1", "10.0.0.4", "DOWN")
+	added.ActionType = actionAdded
+
+	err := registry.applyDelta(&eureka.Applications{
+		Applications: []eureka.Application{
+			{Name: "FOO", Instances: []eureka.InstanceInfo{modified}},
+			{Name: "BAR", Instances: []eureka.InstanceInfo{deleted}},
+			{Name: "BAZ", Instances: []eureka.InstanceInfo{added}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "DOWN_1_OUT_OF_SERVICE_1_UP_1_", registry.hashCode())
+	assert.NotContains(t, registry.applications, "BAR")
+	assert.Len(t, registry.applications["FOO"], 2)
+	assert.Len(t, registry.applications["BAZ"], 1)
+}
+
+func TestRegistryApplyDeltaUnknownAction(t *testing.T) {
+	registry := newRegistry(&eureka.Applications{})
+
+	instance := buildInstance("foo-1", "10.0.0.1", "UP")
+	instance.ActionType = "UNKNOWN"
+
+	err := registry.applyDelta(&eureka.Applications{
+		Applications: []eureka.Application{
+			{Name: "FOO", Instances: []eureka.InstanceInfo{instance}},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestRegistryFilter(t *testing.T) {
+	registry := newRegistry(&eureka.Applications{
+		Applications: []eureka.Application{
+			{
+				Name: "FOO",
+				Instances: []eureka.InstanceInfo{
+					buildInstance("foo-2", "10.0.0.2", "OUT_OF_SERVICE"),
+					buildInstance("foo-1", "10.0.0.1", "UP"),
+				},
+			},
+			{
+				Name: "BAR",
+				Instances: []eureka.InstanceInfo{
+					buildInstance("bar-1", "10.0.0.3", "STARTING"),
+				},
+			},
+		},
+	})
+
+	testCases := []struct {
+		desc     string
+		statuses []string
+		expected map[string][]string
+	}{
+		{
+			desc:     "UP only",
+			statuses: []string{"UP"},
+			expected: map[string][]string{"FOO": {"foo-1"}},
+		},
+		{
+			desc:     "case insensitive statuses",
+			statuses: []string{"up", "starting"},
+			expected: map[string][]string{"BAR": {"bar-1"}, "FOO": {"foo-1"}},
+		},
+		{
+			desc:     "all statuses",
+			statuses: []string{"UP", "STARTING", "OUT_OF_SERVICE"},
+			expected: map[string][]string{"BAR": {"bar-1"}, "FOO": {"foo-1", "foo-2"}},
+		},
+		{
+			desc:     "no match",
+			statuses: []string{"DOWN"},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			actual := make(map[string][]string)
+			for _, app := range registry.filter(test.statuses) {
+				for _, instance := range app.Instances {
+					actual[app.Name] = append(actual[app.Name], instance.HostName)
+				}
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func buildInstance(hostName, ip, status string) eureka.InstanceInfo {
+	return eureka.InstanceInfo{
+		HostName: hostName,
+		IpAddr:   ip,
+		Status:   status,
+		Port: &eureka.Port{
+			Port: 80, Enabled: true,
+		},
+		SecurePort: &eureka.Port{
+			Port: 443, Enabled: false,
+		},
+	}
+}
@@ -2,7 +2,6 @@ package file
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -23,8 +22,13 @@ var _ provider.Provider = (*Provider)(nil)
 type Provider struct {
 	provider.BaseProvider `mapstructure:",squash" export:"true"`
 	Directory             string `description:"Load configuration from one or more .toml files in a directory" export:"true"`
+	Include               string `description:"Glob pattern of the files to load from the directory, relative to it ('**' matches any number of sub-directories)" export:"true"`
+	Templating            bool   `description:"Render the configuration files as Go templates" export:"true"`
 }
 
+// defaultInclude matches all the .toml files of the directory and its sub-directories.
+const defaultInclude = "**/*.toml"
+
 // Provide allows the file provider to provide configurations to traefik
 // using the given configuration channel.
 func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
@@ -56,9 +60,9 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 // and returns a 'Configuration' object
 func (p *Provider) BuildConfiguration() (*types.Configuration, error) {
 	if p.Directory != "" {
-		return loadFileConfigFromDirectory(p.Directory, nil)
+		return loadFileConfigFromDirectory(p.Directory, p.Include, p.Templating)
 	}
-	return loadFileConfig(p.Filename)
+	return loadFileConfig(p.Filename, p.Templating)
 }
 
 func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationChan chan<- types.ConfigMessage, callback func(chan<- types.ConfigMessage, fsnotify.Event)) error {
@@ -75,6 +79,13 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 			case <-stop:
 				return
 			case evt := <-watcher.Events:
+				if p.Directory != "" && evt.Op&fsnotify.Create == fsnotify.Create {
+					// watch the new sub-directories
+					if err := addWatchedDirectories(watcher, evt.Name); err != nil {
+						log.Errorf("Unable to watch %s: %v", evt.Name, err)
+					}
+				}
+
 				if p.Directory == "" {
 					_, evtFileName := filepath.Split(evt.Name)
 					_, confFileName := filepath.Split(p.Filename)
@@ -89,7 +100,11 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 			}
 		}
 	})
-	err = watcher.Add(directory)
+	if p.Directory != "" {
+		err = addWatchedDirectories(watcher, directory)
+	} else {
+		err = watcher.Add(directory)
+	}
 	if err != nil {
 		return fmt.Errorf("error adding file watcher: %s", err)
 	}
@@ -97,6 +112,23 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 	return nil
 }
 
+// addWatchedDirectories adds the directory, and all its sub-directories, to the watcher.
+func addWatchedDirectories(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.Walk(directory, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the file may have been removed in the meantime
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(name)
+	})
+}
+
 func (p *Provider) watcherCallback(configurationChan chan<- types.ConfigMessage, event fsnotify.Event) {
 	watchItem := p.Filename
 	if p.Directory != "" {
@@ -125,45 +157,40 @@ func sendConfigToChannel(configurationChan chan<- types.ConfigMessage, configura
 	}
 }
 
-func loadFileConfig(filename string) (*types.Configuration, error) {
+func loadFileConfig(filename string, templating bool) (*types.Configuration, error) {
 	configuration := new(types.Configuration)
-	if _, err := toml.DecodeFile(filename, configuration); err != nil {
-		return nil, fmt.Errorf("error reading configuration file: %s", err)
+	if !templating {
+		if _, err := toml.DecodeFile(filename, configuration); err != nil {
+			return nil, fmt.Errorf("error reading configuration file: %s", err)
+		}
+		return configuration, nil
+	}
+
+	content, err := renderTemplate(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering configuration file %s: %s", filename, err)
+	}
+	if _, err := toml.Decode(content, configuration); err != nil {
+		return nil, fmt.Errorf("error reading configuration file %s: %s", filename, err)
 	}
 	return configuration, nil
 }
 
-func loadFileConfigFromDirectory(directory string, configuration *types.Configuration) (*types.Configuration, error) {
-	fileList, err := ioutil.ReadDir(directory)
-
+func loadFileConfigFromDirectory(directory, include string, templating bool) (*types.Configuration, error) {
+	fileList, err := listConfigFiles(directory, include)
 	if err != nil {
-		return configuration, fmt.Errorf("unable to read directory %s: %v", directory, err)
+		return nil, err
 	}
 
-	if configuration == nil {
-		configuration = &types.Configuration{
-			Frontends:        make(map[string]*types.Frontend),
-			Backends:         make(map[string]*types.Backend),
-			TLSConfiguration: make([]*tls.Configuration, 0),
-		}
+	configuration := &types.Configuration{
+		Frontends:        make(map[string]*types.Frontend),
+		Backends:         make(map[string]*types.Backend),
+		TLSConfiguration: make([]*tls.Configuration, 0),
 	}
 
-	configTLSMaps := make(map[*tls.Configuration]struct{})
-	for _, item := range fileList {
-
-		if item.IsDir() {
-			configuration, err = loadFileConfigFromDirectory(filepath.Join(directory, item.Name()), configuration)
-			if err != nil {
-				return configuration, fmt.Errorf("unable to load content configuration from subdirectory %s: %v", item, err)
-			}
-			continue
-		} else if !strings.HasSuffix(item.Name(), ".toml") {
-			continue
-		}
-
-		var c *types.Configuration
-		c, err = loadFileConfig(path.Join(directory, item.Name()))
-
+	// the files are merged in path order: the first definition of a frontend or a backend wins
+	for _, filename := range fileList {
+		c, err := loadFileConfig(filename, templating)
 		if err != nil {
 			return configuration, err
 		}
@@ -184,17 +211,65 @@ func loadFileConfigFromDirectory(directory string, configuration *types.Configur
 			}
 		}
 
-		for _, conf := range c.TLSConfiguration {
-			if _, exists := configTLSMaps[conf]; exists {
-				log.Warnf("TLS Configuration %v already configured, skipping", conf)
-			} else {
-				configTLSMaps[conf] = struct{}{}
-			}
+		configuration.TLSConfiguration = append(configuration.TLSConfiguration, c.TLSConfiguration...)
+	}
+	return configuration, nil
+}
+
+// listConfigFiles returns the files of the directory, and its sub-directories, matching the include pattern, in path order.
+func listConfigFiles(directory, include string) ([]string, error) {
+	if include == "" {
+		include = defaultInclude
+	}
+
+	var fileList []string
+	err := filepath.Walk(directory, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %v", filename, err)
+		}
+		if info.IsDir() {
+			return nil
 		}
 
+		rel, err := filepath.Rel(directory, filename)
+		if err != nil {
+			return err
+		}
+		if matchGlob(include, filepath.ToSlash(rel)) {
+			fileList = append(fileList, filename)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory %s: %v", directory, err)
 	}
-	for conf := range configTLSMaps {
-		configuration.TLSConfiguration = append(configuration.TLSConfiguration, conf)
+	return fileList, nil
+}
+
+// matchGlob reports whether the slash separated name matches the pattern.
+// In addition to the path.Match syntax, a "**" element matches zero or more directories.
+func matchGlob(pattern, name string) bool {
+	return matchGlobElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobElements(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchGlobElements(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+		if matched, err := path.Match(patterns[0], names[0]); err != nil || !matched {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
 	}
-	return configuration, nil
+	return len(names) == 0
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProvideSingleFileAndWatch(t *testing.T) {
@@ -167,6 +168,130 @@ func TestProvideDirectoryAndNotWatch(t *testing.T) {
 
 }
 
+func TestProvideDirectoryAndWatchSubDirectory(t *testing.T) {
+	tempDir := createTempDir(t, "testdir")
+	tempSubDir := createSubDir(t, tempDir, "sub")
+	defer os.RemoveAll(tempDir)
+
+	expectedNumFrontends := 2
+	expectedNumBackends := 0
+	expectedNumTLSConf := 0
+
+	createRandomFile(t, tempSubDir, createFrontendConfiguration(expectedNumFrontends))
+
+	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+
+	provide(configurationChan, watch, withDirectory(tempDir))
+
+	// Wait for initial config message to be tested
+	err := waitForSignal(signal, 2*time.Second, "initial config")
+	assert.NoError(t, err)
+
+	// Now add a backends file in a new sub-directory
+	tempNewSubDir := createSubDir(t, tempSubDir, "new")
+	err = waitForSignal(signal, 2*time.Second, "create a sub-directory")
+	assert.NoError(t, err)
+
+	expectedNumBackends = 2
+	createFile(t, tempNewSubDir, "backends.toml", createBackendConfiguration(expectedNumBackends))
+	err = waitForSignal(signal, 2*time.Second, "add a backends file in the new sub-directory")
+	assert.NoError(t, err)
+}
+
+func TestLoadFileConfigFromDirectoryInclude(t *testing.T) {
+	tempDir := createTempDir(t, "testdir")
+	defer os.RemoveAll(tempDir)
+
+	confDir := createSubDir(t, tempDir, "conf.d")
+	nestedDir := createSubDir(t, confDir, "nested")
+
+	createFile(t, tempDir, "root.toml", createFrontendConfiguration(3))
+	createFile(t, confDir, "a.toml", `[backends]
+  [backends.backend1]
+    [backends.backend1.servers.server1]
+    url = "http://first:80"
+`)
+	createFile(t, nestedDir, "b.toml", `[backends]
+  [backends.backend1]
+    [backends.backend1.servers.server1]
+    url = "http://second:80"
+  [backends.backend2]
+    [backends.backend2.servers.server1]
+    url = "http://second:80"
+`)
+	createFile(t, nestedDir, "c.txt", createFrontendConfiguration(1))
+
+	testCases := []struct {
+		desc              string
+		include           string
+		expectedFrontends int
+		expectedBackends  int
+	}{
+		{
+			desc:              "default include",
+			expectedFrontends: 3,
+			expectedBackends:  2,
+		},
+		{
+			desc:             "recursive include",
+			include:          "conf.d/**/*.toml",
+			expectedBackends: 2,
+		},
+		{
+			desc:             "single level include",
+			include:          "conf.d/*.toml",
+			expectedBackends: 1,
+		},
+		{
+			desc:              "top level include",
+			include:           "*.toml",
+			expectedFrontends: 3,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			configuration, err := loadFileConfigFromDirectory(tempDir, test.include, false)
+			require.NoError(t, err)
+
+			assert.Len(t, configuration.Frontends, test.expectedFrontends)
+			assert.Len(t, configuration.Backends, test.expectedBackends)
+			if test.expectedBackends > 0 {
+				// the first file in path order wins
+				assert.Equal(t, "http://first:80", configuration.Backends["backend1"].Servers["server1"].URL)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "**/*.toml", name: "a.toml", expected: true},
+		{pattern: "**/*.toml", name: "a/b/c.toml", expected: true},
+		{pattern: "**/*.toml", name: "a/b/c.txt", expected: false},
+		{pattern: "conf.d/**/*.toml", name: "conf.d/a.toml", expected: true},
+		{pattern: "conf.d/**/*.toml", name: "conf.d/a/b.toml", expected: true},
+		{pattern: "conf.d/**/*.toml", name: "other/a.toml", expected: false},
+		{pattern: "conf.d/*.toml", name: "conf.d/a/b.toml", expected: false},
+		{pattern: "*/rules.toml", name: "a/rules.toml", expected: true},
+		{pattern: "**", name: "a/b/c", expected: true},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, matchGlob(test.pattern, test.name))
+		})
+	}
+}
+
 func createConfigurationRoutine(t *testing.T, expectedNumFrontends *int, expectedNumBackends *int, expectedNumTLSConfigurations *int) (chan types.ConfigMessage, chan interface{}) {
 	configurationChan := make(chan types.ConfigMessage)
 	signal := make(chan interface{})
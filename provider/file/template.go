@@ -0,0 +1,53 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"github.com/containous/traefik/provider"
+)
+
+// maxIncludeDepth limits the nesting of the included files, to detect include cycles.
+const maxIncludeDepth = 10
+
+// renderTemplate renders the given file as a Go template.
+// In addition to the sprig functions (env, default, ...), the include function renders
+// another file, whose path is relative to the directory of the including file.
+func renderTemplate(filename string) (string, error) {
+	return renderTemplateFile(filename, 0)
+}
+
+func renderTemplateFile(filename string, depth int) (string, error) {
+	if depth > maxIncludeDepth {
+		return "", fmt.Errorf("too many nested includes (maximum %d), including %s", maxIncludeDepth, filename)
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	funcMap := sprig.TxtFuncMap()
+	funcMap["normalize"] = provider.Normalize
+	funcMap["include"] = func(name string) (string, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(filename), name)
+		}
+		return renderTemplateFile(name, depth+1)
+	}
+
+	tmpl, err := template.New(filename).Funcs(funcMap).Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, nil); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,64 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileConfigTemplating(t *testing.T) {
+	tempDir := createTempDir(t, "testtemplate")
+	defer os.RemoveAll(tempDir)
+
+	snippetsDir := createSubDir(t, tempDir, "snippets")
+
+	// the included file is rendered without data
+	createFile(t, snippetsDir, "servers.tmpl", `
+    [backends.BACKEND.servers.server1]
+    url = "http://{{ env "TRAEFIK_TEST_TEMPLATE_HOST" | default "localhost" }}:80"
+`)
+	createFile(t, tempDir, "rules.toml", `[backends]
+{{ range $name := list "backend1" "backend2" }}
+  [backends.{{ $name }}]
+  {{ include "snippets/servers.tmpl" | replace "BACKEND" $name }}
+{{ end }}
+`)
+
+	os.Setenv("TRAEFIK_TEST_TEMPLATE_HOST", "10.0.0.1")
+	defer os.Unsetenv("TRAEFIK_TEST_TEMPLATE_HOST")
+
+	configuration, err := loadFileConfig(filepath.Join(tempDir, "rules.toml"), true)
+	require.NoError(t, err)
+
+	require.Len(t, configuration.Backends, 2)
+	assert.Equal(t, "http://10.0.0.1:80", configuration.Backends["backend1"].Servers["server1"].URL)
+	assert.Equal(t, "http://10.0.0.1:80", configuration.Backends["backend2"].Servers["server1"].URL)
+}
+
+func TestLoadFileConfigTemplatingDisabled(t *testing.T) {
+	tempDir := createTempDir(t, "testtemplate")
+	defer os.RemoveAll(tempDir)
+
+	createFile(t, tempDir, "rules.toml", `[frontends]
+  [frontends.frontend1]
+  backend = "{{ backend }}"
+`)
+
+	configuration, err := loadFileConfig(filepath.Join(tempDir, "rules.toml"), false)
+	require.NoError(t, err)
+	assert.Equal(t, "{{ backend }}", configuration.Frontends["frontend1"].Backend)
+}
+
+func TestLoadFileConfigTemplatingIncludeCycle(t *testing.T) {
+	tempDir := createTempDir(t, "testtemplate")
+	defer os.RemoveAll(tempDir)
+
+	createFile(t, tempDir, "a.toml", `{{ include "b.toml" }}`)
+	createFile(t, tempDir, "b.toml", `{{ include "a.toml" }}`)
+
+	_, err := loadFileConfig(filepath.Join(tempDir, "a.toml"), true)
+	assert.Error(t, err)
+}
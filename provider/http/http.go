@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider is a provider.Provider implementation that polls a configuration document from an HTTP endpoint
+type Provider struct {
+	Endpoint     string            `description:"URL of the configuration document" export:"true"`
+	PollInterval flaeg.Duration    `description:"Polling interval of the endpoint" export:"true"`
+	PollTimeout  flaeg.Duration    `description:"Timeout of the requests to the endpoint" export:"true"`
+	Headers      map[string]string `description:"Headers added to the requests to the endpoint, e.g. for authentication"`
+	TLS          *types.ClientTLS  `description:"Enable TLS support" export:"true"`
+	client       *http.Client
+	etag         string
+}
+
+// errNotModified is returned when the configuration document has not changed since the last poll
+var errNotModified = errors.New("configuration not modified")
+
+// Provide allows the provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	if len(p.Endpoint) == 0 {
+		return errors.New("no endpoint defined for the http provider")
+	}
+	if _, err := url.Parse(p.Endpoint); err != nil {
+		return fmt.Errorf("invalid endpoint %q for the http provider: %v", p.Endpoint, err)
+	}
+	if p.PollInterval <= 0 {
+		return fmt.Errorf("invalid poll interval %s for the http provider", time.Duration(p.PollInterval))
+	}
+
+	client, err := p.createClient()
+	if err != nil {
+		return err
+	}
+	p.client = client
+
+	pool.Go(func(stop chan bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		safe.Go(func() {
+			<-stop
+			cancel()
+		})
+
+		p.poll(ctx, configurationChan)
+
+		ticker := time.NewTicker(time.Duration(p.PollInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.poll(ctx, configurationChan)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (p *Provider) createClient() (*http.Client, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if p.TLS != nil {
+		tlsConfig, err := p.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the TLS configuration of the http provider: %v", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(p.PollTimeout),
+	}, nil
+}
+
+// poll fetches the configuration document and sends it to traefik when it has changed and is valid,
+// the current configuration is kept otherwise.
+func (p *Provider) poll(ctx context.Context, configurationChan chan<- types.ConfigMessage) {
+	configuration, err := p.fetchConfiguration(ctx)
+	if err == errNotModified {
+		log.Debugf("Configuration of %s not modified", p.Endpoint)
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Errorf("Error fetching the configuration from %s: %v", p.Endpoint, err)
+		}
+		return
+	}
+
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  "http",
+		Configuration: configuration,
+	}
+}
+
+// fetchConfiguration fetches and validates the configuration document,
+// the ETag of the last document is sent to avoid fetching an unchanged document.
+func (p *Provider) fetchConfiguration(ctx context.Context) (*types.Configuration, error) {
+	req, err := http.NewRequest(http.MethodGet, p.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(p.etag) > 0 {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration, err := decodeConfiguration(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConfiguration(configuration); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	// the ETag is only kept once the document is applied, an invalid document is fetched again
+	p.etag = resp.Header.Get("ETag")
+
+	return configuration, nil
+}
+
+// decodeConfiguration decodes a JSON document, or a TOML document for any other content type
+func decodeConfiguration(contentType string, body []byte) (*types.Configuration, error) {
+	configuration := new(types.Configuration)
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(body, configuration); err != nil {
+			return nil, fmt.Errorf("error decoding JSON configuration: %v", err)
+		}
+		return configuration, nil
+	}
+
+	if _, err := toml.Decode(string(body), configuration); err != nil {
+		return nil, fmt.Errorf("error decoding TOML configuration: %v", err)
+	}
+	return configuration, nil
+}
+
+// validateConfiguration checks that the frontends have routes and reference existing backends,
+// and that the URLs of the servers are valid.
+func validateConfiguration(configuration *types.Configuration) error {
+	for name, backend := range configuration.Backends {
+		if backend == nil {
+			return fmt.Errorf("backend %s is empty", name)
+		}
+		for serverName, server := range backend.Servers {
+			u, err := url.Parse(server.URL)
+			if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return fmt.Errorf("invalid URL %q for the server %s of the backend %s", server.URL, serverName, name)
+			}
+		}
+	}
+
+	for name, frontend := range configuration.Frontends {
+		if frontend == nil {
+			return fmt.Errorf("frontend %s is empty", name)
+		}
+		if _, ok := configuration.Backends[frontend.Backend]; !ok {
+			return fmt.Errorf("frontend %s references an undefined backend %q", name, frontend.Backend)
+		}
+		if len(frontend.Routes) == 0 {
+			return fmt.Errorf("frontend %s has no route", name)
+		}
+		for routeName, route := range frontend.Routes {
+			if len(route.Rule) == 0 {
+				return fmt.Errorf("route %s of the frontend %s has no rule", routeName, name)
+			}
+		}
+	}
+
+	return nil
+}
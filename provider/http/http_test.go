@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tomlConfiguration = `
+[backends]
+  [backends.backend1]
+    [backends.backend1.servers.server1]
+    url = "http://127.0.0.1:80"
+
+[frontends]
+  [frontends.frontend1]
+  backend = "backend1"
+    [frontends.frontend1.routes.route1]
+    rule = "Host:foo.bar"
+`
+
+const jsonConfiguration = `{
+  "backends": {"backend1": {"servers": {"server1": {"url": "http://127.0.0.1:80"}}}},
+  "frontends": {"frontend1": {"backend": "backend1", "routes": {"route1": {"rule": "Host:foo.bar"}}}}
+}`
+
+func TestFetchConfiguration(t *testing.T) {
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend1": {
+				Servers: map[string]types.Server{
+					"server1": {URL: "http://127.0.0.1:80"},
+				},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"frontend1": {
+				Backend: "backend1",
+				Routes: map[string]types.Route{
+					"route1": {Rule: "Host:foo.bar"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		contentType   string
+		body          string
+		statusCode    int
+		expected      *types.Configuration
+		expectedError bool
+	}{
+		{
+			desc:        "TOML document",
+			contentType: "text/plain",
+			body:        tomlConfiguration,
+			statusCode:  http.StatusOK,
+			expected:    expected,
+		},
+		{
+			desc:        "JSON document",
+			contentType: "application/json; charset=utf-8",
+			body:        jsonConfiguration,
+			statusCode:  http.StatusOK,
+			expected:    expected,
+		},
+		{
+			desc:          "malformed document",
+			contentType:   "application/json",
+			body:          "{",
+			statusCode:    http.StatusOK,
+			expectedError: true,
+		},
+		{
+			desc:          "invalid configuration",
+			contentType:   "application/json",
+			body:          `{"frontends": {"frontend1": {"backend": "backend1", "routes": {"route1": {"rule": "Host:foo.bar"}}}}}`,
+			statusCode:    http.StatusOK,
+			expectedError: true,
+		},
+		{
+			desc:          "error status code",
+			statusCode:    http.StatusInternalServerError,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", test.contentType)
+				rw.WriteHeader(test.statusCode)
+				fmt.Fprint(rw, test.body)
+			}))
+			defer server.Close()
+
+			p := &Provider{Endpoint: server.URL, client: http.DefaultClient}
+
+			configuration, err := p.fetchConfiguration(context.Background())
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, configuration)
+			}
+		})
+	}
+}
+
+func TestFetchConfigurationETag(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req)
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rw.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(rw, tomlConfiguration)
+	}))
+	defer server.Close()
+
+	p := &Provider{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		client:   http.DefaultClient,
+	}
+
+	_, err := p.fetchConfiguration(context.Background())
+	require.NoError(t, err)
+
+	_, err = p.fetchConfiguration(context.Background())
+	assert.Equal(t, errNotModified, err)
+
+	require.Len(t, requests, 2)
+	assert.Empty(t, requests[0].Header.Get("If-None-Match"))
+	assert.Equal(t, `"v1"`, requests[1].Header.Get("If-None-Match"))
+	for _, req := range requests {
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		configuration *types.Configuration
+		expectedError bool
+	}{
+		{
+			desc:          "empty configuration",
+			configuration: &types.Configuration{},
+		},
+		{
+			desc: "frontend without backend",
+			configuration: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {
+						Backend: "backend1",
+						Routes:  map[string]types.Route{"route1": {Rule: "Host:foo.bar"}},
+					},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "frontend without route",
+			configuration: &types.Configuration{
+				Backends: map[string]*types.Backend{"backend1": {}},
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {Backend: "backend1"},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "route without rule",
+			configuration: &types.Configuration{
+				Backends: map[string]*types.Backend{"backend1": {}},
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {
+						Backend: "backend1",
+						Routes:  map[string]types.Route{"route1": {}},
+					},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "server with invalid URL",
+			configuration: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend1": {
+						Servers: map[string]types.Server{"server1": {URL: "127.0.0.1:80"}},
+					},
+				},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateConfiguration(test.configuration)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
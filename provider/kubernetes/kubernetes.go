@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/cenk/backoff"
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
@@ -57,6 +58,7 @@ const (
 	annotationKubernetesPublicKey               = "ingress.kubernetes.io/public-key"
 	annotationKubernetesReferrerPolicy          = "ingress.kubernetes.io/referrer-policy"
 	annotationKubernetesIsDevelopment           = "ingress.kubernetes.io/is-development"
+	annotationKubernetesBackendTLSSecret        = "traefik.backend.tls.secret"
 )
 
 const traefikDefaultRealm = "traefik"
@@ -214,6 +216,12 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 
 					priority := label.GetIntValue(i.Annotations, label.TraefikFrontendPriority, 0)
 
+					responseTimeout, err := loadResponseTimeout(i.Annotations, label.TraefikFrontendResponseTimeout, label.TraefikFrontendResponseTimeoutDeadlineHeader)
+					if err != nil {
+						log.Errorf("Failed to load the response timeout of ingress %s/%s: %v", i.ObjectMeta.Namespace, i.ObjectMeta.Name, err)
+						continue
+					}
+
 					headers := types.Headers{
 						CustomRequestHeaders:    label.GetMapValue(i.Annotations, annotationKubernetesCustomRequestHeaders),
 						CustomResponseHeaders:   label.GetMapValue(i.Annotations, annotationKubernetesCustomResponseHeaders),
@@ -248,6 +256,7 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 						Redirect:             entryPointRedirect,
 						EntryPoints:          entryPoints,
 						Headers:              headers,
+						ResponseTimeout:      responseTimeout,
 					}
 				}
 				if len(r.Host) > 0 {
@@ -289,8 +298,8 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 					}
 				}
 
-				if service.Annotations[label.TraefikBackendLoadBalancerMethod] == "drr" {
-					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Method = "drr"
+				if method := service.Annotations[label.TraefikBackendLoadBalancerMethod]; len(method) > 0 {
+					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Method = method
 				}
 
 				if sticky := service.Annotations[label.TraefikBackendLoadBalancerSticky]; len(sticky) > 0 {
@@ -303,8 +312,34 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 					if cookieName := service.Annotations[label.TraefikBackendLoadBalancerStickinessCookieName]; len(cookieName) > 0 {
 						templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Stickiness.CookieName = cookieName
 					}
+					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Stickiness.Header = service.Annotations[label.TraefikBackendLoadBalancerStickinessHeader]
+					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Stickiness.Query = service.Annotations[label.TraefikBackendLoadBalancerStickinessQuery]
 				}
 
+				backendTLS, err := loadBackendTLS(service, k8sClient)
+				if err != nil {
+					log.Errorf("Failed to load the backend TLS configuration of service %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
+					delete(templateObjects.Frontends, r.Host+pa.Path)
+					continue
+				}
+				templateObjects.Backends[r.Host+pa.Path].TLS = backendTLS
+
+				backendTransport, err := loadBackendTransport(service)
+				if err != nil {
+					log.Errorf("Failed to load the backend transport configuration of service %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
+					delete(templateObjects.Frontends, r.Host+pa.Path)
+					continue
+				}
+				templateObjects.Backends[r.Host+pa.Path].Transport = backendTransport
+
+				backendResponseTimeout, err := loadResponseTimeout(service.Annotations, label.TraefikBackendResponseTimeout, label.TraefikBackendResponseTimeoutDeadlineHeader)
+				if err != nil {
+					log.Errorf("Failed to load the response timeout of service %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
+					delete(templateObjects.Frontends, r.Host+pa.Path)
+					continue
+				}
+				templateObjects.Backends[r.Host+pa.Path].ResponseTimeout = backendResponseTimeout
+
 				protocol := label.DefaultProtocol
 				for _, port := range service.Spec.Ports {
 					if equalPorts(port, pa.Backend.ServicePort) {
@@ -443,6 +478,91 @@ func loadAuthCredentials(namespace, secretName string, k8sClient Client) ([]stri
 	return creds, nil
 }
 
+// loadBackendTLS returns the TLS configuration used to reach the endpoints of a service,
+// its client certificate and CA being taken from the tls.crt, tls.key and ca.crt keys of a secret.
+func loadBackendTLS(service *v1.Service, k8sClient Client) (*types.BackendTLS, error) {
+	secretName := service.Annotations[annotationKubernetesBackendTLSSecret]
+	insecureSkipVerify := label.GetBoolValue(service.Annotations, label.TraefikBackendTLSInsecureSkipVerify, false)
+	if len(secretName) == 0 && !insecureSkipVerify {
+		return nil, nil
+	}
+
+	backendTLS := &types.BackendTLS{InsecureSkipVerify: insecureSkipVerify}
+	if len(secretName) == 0 {
+		return backendTLS, nil
+	}
+
+	namespace := service.ObjectMeta.Namespace
+	secret, ok, err := k8sClient.GetSecret(namespace, secretName)
+	switch { // keep order of case conditions
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch secret %q/%q: %s", namespace, secretName, err)
+	case !ok:
+		return nil, fmt.Errorf("secret %q/%q not found", namespace, secretName)
+	case secret == nil:
+		return nil, fmt.Errorf("data for secret %q/%q must not be nil", namespace, secretName)
+	default:
+	}
+
+	backendTLS.Cert = string(secret.Data[v1.TLSCertKey])
+	backendTLS.Key = string(secret.Data[v1.TLSPrivateKeyKey])
+	backendTLS.CA = string(secret.Data["ca.crt"])
+	if len(backendTLS.Cert) == 0 && len(backendTLS.Key) == 0 && len(backendTLS.CA) == 0 {
+		return nil, fmt.Errorf("secret %q/%q contains none of %s, %s and ca.crt", namespace, secretName, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+	if (len(backendTLS.Cert) == 0) != (len(backendTLS.Key) == 0) {
+		return nil, fmt.Errorf("secret %q/%q must contain both %s and %s", namespace, secretName, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+	return backendTLS, nil
+}
+
+// loadBackendTransport returns the settings of the connections to the endpoints of a service.
+func loadBackendTransport(service *v1.Service) (*types.BackendTransport, error) {
+	backendTransport := &types.BackendTransport{
+		MaxIdleConnsPerHost: label.GetIntValue(service.Annotations, label.TraefikBackendTransportMaxIdleConnsPerHost, 0),
+		DisableKeepAlives:   label.GetBoolValue(service.Annotations, label.TraefikBackendTransportDisableKeepAlives, false),
+	}
+	found := label.Has(service.Annotations, label.TraefikBackendTransportMaxIdleConnsPerHost) ||
+		label.Has(service.Annotations, label.TraefikBackendTransportDisableKeepAlives)
+
+	for annotation, duration := range map[string]*flaeg.Duration{
+		label.TraefikBackendTransportIdleConnTimeout:       &backendTransport.IdleConnTimeout,
+		label.TraefikBackendTransportTLSHandshakeTimeout:   &backendTransport.TLSHandshakeTimeout,
+		label.TraefikBackendTransportResponseHeaderTimeout: &backendTransport.ResponseHeaderTimeout,
+	} {
+		value, ok := service.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		if err := duration.Set(value); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation %q: %v", annotation, value, err)
+		}
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return backendTransport, nil
+}
+
+// loadResponseTimeout loads the response timeout of an ingress or a service from its annotations.
+func loadResponseTimeout(annotations map[string]string, timeoutAnnotation, deadlineHeaderAnnotation string) (*types.ResponseTimeout, error) {
+	if !label.Has(annotations, timeoutAnnotation) && !label.Has(annotations, deadlineHeaderAnnotation) {
+		return nil, nil
+	}
+
+	responseTimeout := &types.ResponseTimeout{
+		DeadlineHeader: annotations[deadlineHeaderAnnotation],
+	}
+	if value, ok := annotations[timeoutAnnotation]; ok {
+		if err := responseTimeout.Timeout.Set(value); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation %q: %v", timeoutAnnotation, value, err)
+		}
+	}
+	return responseTimeout, nil
+}
+
 func endpointPortNumber(servicePort v1.ServicePort, endpointPorts []v1.EndpointPort) int {
 	if len(endpointPorts) > 0 {
 		//name is optional if there is only one port
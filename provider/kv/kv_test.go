@@ -291,6 +291,14 @@ func TestKVLoadConfig(t *testing.T) {
 					Key:   "traefik/frontends/frontend.with.dot/backend",
 					Value: []byte("backend.with.dot.too"),
 				},
+				{
+					Key:   "traefik/frontends/frontend.with.dot/trafficsplit",
+					Value: []byte(""),
+				},
+				{
+					Key:   "traefik/frontends/frontend.with.dot/trafficsplit/backend.canary",
+					Value: []byte("10"),
+				},
 				{
 					Key:   "traefik/frontends/frontend.with.dot/routes",
 					Value: []byte(""),
@@ -307,6 +315,14 @@ func TestKVLoadConfig(t *testing.T) {
 					Key:   "traefik/backends/backend.with.dot.too",
 					Value: []byte(""),
 				},
+				{
+					Key:   "traefik/backends/backend.with.dot.too/failover/backend",
+					Value: []byte("backend.dr"),
+				},
+				{
+					Key:   "traefik/backends/backend.with.dot.too/failover/minhealthypercent",
+					Value: []byte("50"),
+				},
 				{
 					Key:   "traefik/backends/backend.with.dot.too/servers",
 					Value: []byte(""),
@@ -346,6 +362,10 @@ func TestKVLoadConfig(t *testing.T) {
 				},
 				CircuitBreaker: nil,
 				LoadBalancer:   nil,
+				Failover: &types.Failover{
+					Backend:           "backend.dr",
+					MinHealthyPercent: 50,
+				},
 			},
 		},
 		Frontends: map[string]*types.Frontend{
@@ -358,6 +378,9 @@ func TestKVLoadConfig(t *testing.T) {
 						Rule: "Host:test.localhost",
 					},
 				},
+				TrafficSplit: map[string]int{
+					"backend.canary": 10,
+				},
 			},
 		},
 	}
@@ -14,14 +14,29 @@ const (
 	SuffixBackendID                                = "backend.id"
 	SuffixBackendCircuitBreaker                    = "backend.circuitbreaker"
 	SuffixBackendCircuitBreakerExpression          = "backend.circuitbreaker.expression"
+	SuffixBackendFailover                          = "backend.failover"
+	SuffixBackendFailoverMinHealthyPercent         = "backend.failover.minHealthyPercent"
 	SuffixBackendHealthCheckPath                   = "backend.healthcheck.path"
 	SuffixBackendHealthCheckInterval               = "backend.healthcheck.interval"
 	SuffixBackendLoadBalancerMethod                = "backend.loadbalancer.method"
 	SuffixBackendLoadBalancerSticky                = "backend.loadbalancer.sticky"
 	SuffixBackendLoadBalancerStickiness            = "backend.loadbalancer.stickiness"
 	SuffixBackendLoadBalancerStickinessCookieName  = "backend.loadbalancer.stickiness.cookieName"
+	SuffixBackendLoadBalancerStickinessHeader      = "backend.loadbalancer.stickiness.header"
+	SuffixBackendLoadBalancerStickinessQuery       = "backend.loadbalancer.stickiness.query"
 	SuffixBackendMaxConnAmount                     = "backend.maxconn.amount"
 	SuffixBackendMaxConnExtractorFunc              = "backend.maxconn.extractorfunc"
+	SuffixBackendResponseTimeout                   = "backend.responseTimeout"
+	SuffixBackendResponseTimeoutDeadlineHeader     = "backend.responseTimeout.deadlineHeader"
+	SuffixBackendTLSCert                           = "backend.tls.cert"
+	SuffixBackendTLSKey                            = "backend.tls.key"
+	SuffixBackendTLSCA                             = "backend.tls.ca"
+	SuffixBackendTLSInsecureSkipVerify             = "backend.tls.insecureSkipVerify"
+	SuffixBackendTransportMaxIdleConnsPerHost      = "backend.transport.maxIdleConnsPerHost"
+	SuffixBackendTransportIdleConnTimeout          = "backend.transport.idleConnTimeout"
+	SuffixBackendTransportTLSHandshakeTimeout      = "backend.transport.tlsHandshakeTimeout"
+	SuffixBackendTransportResponseHeaderTimeout    = "backend.transport.responseHeaderTimeout"
+	SuffixBackendTransportDisableKeepAlives        = "backend.transport.disableKeepAlives"
 	SuffixFrontendAuthBasic                        = "frontend.auth.basic"
 	SuffixFrontendBackend                          = "frontend.backend"
 	SuffixFrontendEntryPoints                      = "frontend.entryPoints"
@@ -45,12 +60,23 @@ const (
 	SuffixFrontendHeadersPublicKey                 = "frontend.headers.publicKey"
 	SuffixFrontendHeadersReferrerPolicy            = "frontend.headers.referrerPolicy"
 	SuffixFrontendHeadersIsDevelopment             = "frontend.headers.isDevelopment"
+	SuffixFrontendMaintenanceEnabled               = "frontend.maintenance.enabled"
+	SuffixFrontendMaintenanceStatusCode            = "frontend.maintenance.statusCode"
+	SuffixFrontendMaintenanceBody                  = "frontend.maintenance.body"
+	SuffixFrontendMaintenanceFile                  = "frontend.maintenance.file"
+	SuffixFrontendMaintenanceContentType           = "frontend.maintenance.contentType"
+	SuffixFrontendMaintenanceBypassHeader          = "frontend.maintenance.bypassHeader"
+	SuffixFrontendMaintenanceBypassValue           = "frontend.maintenance.bypassValue"
+	SuffixFrontendMaintenanceBypassSourceRange     = "frontend.maintenance.bypassSourceRange"
 	SuffixFrontendPassHostHeader                   = "frontend.passHostHeader"
 	SuffixFrontendPassTLSCert                      = "frontend.passTLSCert"
 	SuffixFrontendPriority                         = "frontend.priority"
 	SuffixFrontendRedirect                         = "frontend.redirect"
+	SuffixFrontendResponseTimeout                  = "frontend.responseTimeout"
+	SuffixFrontendResponseTimeoutDeadlineHeader    = "frontend.responseTimeout.deadlineHeader"
 	SuffixFrontendRule                             = "frontend.rule"
 	SuffixFrontendRuleType                         = "frontend.rule.type"
+	SuffixFrontendTrafficSplit                     = "frontend.trafficSplit"
 	SuffixFrontendWhitelistSourceRange             = "frontend.whitelistSourceRange"
 	SuffixFrontendValue                            = "frontend.value"
 	TraefikDomain                                  = Prefix + SuffixDomain
@@ -64,22 +90,48 @@ const (
 	TraefikBackendID                               = Prefix + SuffixBackendID
 	TraefikBackendCircuitBreaker                   = Prefix + SuffixBackendCircuitBreaker
 	TraefikBackendCircuitBreakerExpression         = Prefix + SuffixBackendCircuitBreakerExpression
+	TraefikBackendFailover                         = Prefix + SuffixBackendFailover
+	TraefikBackendFailoverMinHealthyPercent        = Prefix + SuffixBackendFailoverMinHealthyPercent
 	TraefikBackendHealthCheckPath                  = Prefix + SuffixBackendHealthCheckPath
 	TraefikBackendHealthCheckInterval              = Prefix + SuffixBackendHealthCheckInterval
 	TraefikBackendLoadBalancerMethod               = Prefix + SuffixBackendLoadBalancerMethod
 	TraefikBackendLoadBalancerSticky               = Prefix + SuffixBackendLoadBalancerSticky
 	TraefikBackendLoadBalancerStickiness           = Prefix + SuffixBackendLoadBalancerStickiness
 	TraefikBackendLoadBalancerStickinessCookieName = Prefix + SuffixBackendLoadBalancerStickinessCookieName
+	TraefikBackendLoadBalancerStickinessHeader     = Prefix + SuffixBackendLoadBalancerStickinessHeader
+	TraefikBackendLoadBalancerStickinessQuery      = Prefix + SuffixBackendLoadBalancerStickinessQuery
 	TraefikBackendMaxConnAmount                    = Prefix + SuffixBackendMaxConnAmount
 	TraefikBackendMaxConnExtractorFunc             = Prefix + SuffixBackendMaxConnExtractorFunc
+	TraefikBackendResponseTimeout                  = Prefix + SuffixBackendResponseTimeout
+	TraefikBackendResponseTimeoutDeadlineHeader    = Prefix + SuffixBackendResponseTimeoutDeadlineHeader
+	TraefikBackendTLSCert                          = Prefix + SuffixBackendTLSCert
+	TraefikBackendTLSKey                           = Prefix + SuffixBackendTLSKey
+	TraefikBackendTLSCA                            = Prefix + SuffixBackendTLSCA
+	TraefikBackendTLSInsecureSkipVerify            = Prefix + SuffixBackendTLSInsecureSkipVerify
+	TraefikBackendTransportMaxIdleConnsPerHost     = Prefix + SuffixBackendTransportMaxIdleConnsPerHost
+	TraefikBackendTransportIdleConnTimeout         = Prefix + SuffixBackendTransportIdleConnTimeout
+	TraefikBackendTransportTLSHandshakeTimeout     = Prefix + SuffixBackendTransportTLSHandshakeTimeout
+	TraefikBackendTransportResponseHeaderTimeout   = Prefix + SuffixBackendTransportResponseHeaderTimeout
+	TraefikBackendTransportDisableKeepAlives       = Prefix + SuffixBackendTransportDisableKeepAlives
 	TraefikFrontendAuthBasic                       = Prefix + SuffixFrontendAuthBasic
 	TraefikFrontendEntryPoints                     = Prefix + SuffixFrontendEntryPoints
 	TraefikFrontendPassHostHeader                  = Prefix + SuffixFrontendPassHostHeader
+	TraefikFrontendMaintenanceEnabled              = Prefix + SuffixFrontendMaintenanceEnabled
+	TraefikFrontendMaintenanceStatusCode           = Prefix + SuffixFrontendMaintenanceStatusCode
+	TraefikFrontendMaintenanceBody                 = Prefix + SuffixFrontendMaintenanceBody
+	TraefikFrontendMaintenanceFile                 = Prefix + SuffixFrontendMaintenanceFile
+	TraefikFrontendMaintenanceContentType          = Prefix + SuffixFrontendMaintenanceContentType
+	TraefikFrontendMaintenanceBypassHeader         = Prefix + SuffixFrontendMaintenanceBypassHeader
+	TraefikFrontendMaintenanceBypassValue          = Prefix + SuffixFrontendMaintenanceBypassValue
+	TraefikFrontendMaintenanceBypassSourceRange    = Prefix + SuffixFrontendMaintenanceBypassSourceRange
 	TraefikFrontendPassTLSCert                     = Prefix + SuffixFrontendPassTLSCert
 	TraefikFrontendPriority                        = Prefix + SuffixFrontendPriority
 	TraefikFrontendRule                            = Prefix + SuffixFrontendRule
 	TraefikFrontendRuleType                        = Prefix + SuffixFrontendRuleType
+	TraefikFrontendTrafficSplit                    = Prefix + SuffixFrontendTrafficSplit
 	TraefikFrontendRedirect                        = Prefix + SuffixFrontendRedirect
+	TraefikFrontendResponseTimeout                 = Prefix + SuffixFrontendResponseTimeout
+	TraefikFrontendResponseTimeoutDeadlineHeader   = Prefix + SuffixFrontendResponseTimeoutDeadlineHeader
 	TraefikFrontendValue                           = Prefix + SuffixFrontendValue
 	TraefikFrontendWhitelistSourceRange            = Prefix + SuffixFrontendWhitelistSourceRange
 	TraefikFrontendRequestHeaders                  = Prefix + SuffixFrontendRequestHeaders
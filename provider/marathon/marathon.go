@@ -59,6 +59,7 @@ type Provider struct {
 	ForceTaskHostname         bool             `description:"Force to use the task's hostname." export:"true"`
 	Basic                     *Basic           `description:"Enable basic authentication" export:"true"`
 	RespectReadinessChecks    bool             `description:"Filter out tasks with non-successful readiness checks during deployments" export:"true"`
+	ReadinessCheckGracePeriod flaeg.Duration   `description:"Time added to the readiness check timeout before a task without readiness check result is considered ready" export:"true"`
 	readyChecker              *readinessChecker
 	marathonClient            marathon.Marathon
 }
@@ -88,6 +89,9 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 		if p.RespectReadinessChecks {
 			log.Debug("Enabling Marathon readiness checker")
 			rc = defaultReadinessChecker(p.Trace)
+			if p.ReadinessCheckGracePeriod > 0 {
+				rc.checkSafetyMargin = time.Duration(p.ReadinessCheckGracePeriod)
+			}
 		}
 		p.readyChecker = rc
 
@@ -91,6 +91,30 @@ func TestEnabledReadinessChecker(t *testing.T) {
 			},
 			expectedReady: false,
 		},
+		{
+			desc: "no readiness check result within grace period",
+			task: task(startedAtFromNow(40 * time.Second)),
+			app: application(
+				deployments("deploymentId"),
+				readinessCheck(30*time.Second),
+			),
+			rc: readinessChecker{
+				checkSafetyMargin: 1 * time.Minute,
+			},
+			expectedReady: false,
+		},
+		{
+			desc: "no readiness check result after grace period",
+			task: task(startedAtFromNow(2 * time.Minute)),
+			app: application(
+				deployments("deploymentId"),
+				readinessCheck(30*time.Second),
+			),
+			rc: readinessChecker{
+				checkSafetyMargin: 1 * time.Minute,
+			},
+			expectedReady: true,
+		},
 		{
 			desc: "invalid task start time",
 			task: task(startedAt("invalid")),
@@ -125,7 +149,7 @@ func TestEnabledReadinessChecker(t *testing.T) {
 			if test.rc.checkSafetyMargin > 0 {
 				rc.checkSafetyMargin = test.rc.checkSafetyMargin
 			}
-			actualReady := test.rc.Do(test.task, test.app)
+			actualReady := rc.Do(test.task, test.app)
 			if actualReady != test.expectedReady {
 				t.Errorf("actual ready = %t, expected ready = %t", actualReady, test.expectedReady)
 			}
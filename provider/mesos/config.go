@@ -44,7 +44,7 @@ func (p *Provider) buildConfiguration() *types.Configuration {
 
 	// filter tasks
 	filteredTasks := fun.Filter(func(task state.Task) bool {
-		return taskFilter(task, p.ExposedByDefault)
+		return p.taskFilter(task)
 	}, tasks).([]state.Task)
 
 	uniqueApps := make(map[string]state.Task)
@@ -95,12 +95,12 @@ func taskRecords(st state.State) []state.Task {
 	return tasks
 }
 
-func taskFilter(task state.Task, exposedByDefaultFlag bool) bool {
+func (p *Provider) taskFilter(task state.Task) bool {
 	if len(task.DiscoveryInfo.Ports.DiscoveryPorts) == 0 {
 		log.Debugf("Filtering Mesos task without port %s", task.Name)
 		return false
 	}
-	if !isEnabled(task, exposedByDefaultFlag) {
+	if !isEnabled(task, p.ExposedByDefault) {
 		log.Debugf("Filtering disabled Mesos task %s", task.DiscoveryInfo.Name)
 		return false
 	}
@@ -141,14 +141,25 @@ func taskFilter(task state.Task, exposedByDefaultFlag bool) bool {
 	}
 
 	//filter healthChecks
-	if task.Statuses != nil && len(task.Statuses) > 0 && task.Statuses[0].Healthy != nil && !*task.Statuses[0].Healthy {
+	if !p.DisableHealthCheckFilter && !isTaskHealthy(task) {
 		log.Debugf("Filtering Mesos task %s with bad healthCheck", task.DiscoveryInfo.Name)
 		return false
-
 	}
 	return true
 }
 
+// isTaskHealthy checks the health reported by the latest status of the task.
+// Tasks without health check are considered healthy.
+func isTaskHealthy(task state.Task) bool {
+	var latest *state.Status
+	for i := range task.Statuses {
+		if latest == nil || task.Statuses[i].Timestamp >= latest.Timestamp {
+			latest = &task.Statuses[i]
+		}
+	}
+	return latest == nil || latest.Healthy == nil || *latest.Healthy
+}
+
 func getID(task state.Task) string {
 	return provider.Normalize(task.ID)
 }
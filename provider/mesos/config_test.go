@@ -48,9 +48,10 @@ func TestBuildConfiguration(t *testing.T) {
 
 func TestTaskFilter(t *testing.T) {
 	testCases := []struct {
-		mesosTask        state.Task
-		expected         bool
-		exposedByDefault bool
+		mesosTask                state.Task
+		expected                 bool
+		exposedByDefault         bool
+		disableHealthCheckFilter bool
 	}{
 		{
 			mesosTask:        state.Task{},
@@ -232,6 +233,53 @@ func TestTaskFilter(t *testing.T) {
 			),
 			expected:         false, // HealthCheck at false
 			exposedByDefault: true,
+		}, {
+			mesosTask: task(
+				statuses(
+					status(
+						setState("TASK_RUNNING"),
+						setHealthy(false))),
+				setLabels(label.TraefikEnable, "true",
+					label.TraefikPort, "80"),
+				discovery(setDiscoveryPort("TCP", 80, "WEB")),
+			),
+			expected:                 true, // HealthCheck at false but filter disabled
+			exposedByDefault:         true,
+			disableHealthCheckFilter: true,
+		}, {
+			mesosTask: task(
+				statuses(
+					status(
+						setState("TASK_RUNNING"),
+						setTimestamp(1),
+						setHealthy(true)),
+					status(
+						setState("TASK_RUNNING"),
+						setTimestamp(2),
+						setHealthy(false))),
+				setLabels(label.TraefikEnable, "true",
+					label.TraefikPort, "80"),
+				discovery(setDiscoveryPort("TCP", 80, "WEB")),
+			),
+			expected:         false, // Latest healthCheck at false
+			exposedByDefault: true,
+		}, {
+			mesosTask: task(
+				statuses(
+					status(
+						setState("TASK_RUNNING"),
+						setTimestamp(1),
+						setHealthy(false)),
+					status(
+						setState("TASK_RUNNING"),
+						setTimestamp(2),
+						setHealthy(true))),
+				setLabels(label.TraefikEnable, "true",
+					label.TraefikPort, "80"),
+				discovery(setDiscoveryPort("TCP", 80, "WEB")),
+			),
+			expected:         true, // Latest healthCheck at true
+			exposedByDefault: true,
 		},
 	}
 
@@ -239,7 +287,11 @@ func TestTaskFilter(t *testing.T) {
 		t.Run(strconv.Itoa(index), func(t *testing.T) {
 			t.Parallel()
 
-			actual := taskFilter(test.mesosTask, test.exposedByDefault)
+			provider := &Provider{
+				ExposedByDefault:         test.exposedByDefault,
+				DisableHealthCheckFilter: test.disableHealthCheckFilter,
+			}
+			actual := provider.taskFilter(test.mesosTask)
 			if actual != test.expected {
 				t.Logf("Statuses : %v", test.mesosTask.Statuses)
 				t.Logf("Label : %v", test.mesosTask.Labels)
@@ -24,15 +24,16 @@ var _ provider.Provider = (*Provider)(nil)
 //Provider holds configuration of the provider.
 type Provider struct {
 	provider.BaseProvider
-	Endpoint           string `description:"Mesos server endpoint. You can also specify multiple endpoint for Mesos"`
-	Domain             string `description:"Default domain used"`
-	ExposedByDefault   bool   `description:"Expose Mesos apps by default" export:"true"`
-	GroupsAsSubDomains bool   `description:"Convert Mesos groups to subdomains" export:"true"`
-	ZkDetectionTimeout int    `description:"Zookeeper timeout (in seconds)" export:"true"`
-	RefreshSeconds     int    `description:"Polling interval (in seconds)" export:"true"`
-	IPSources          string `description:"IPSources (e.g. host, docker, mesos, rkt)" export:"true"`
-	StateTimeoutSecond int    `description:"HTTP Timeout (in seconds)" export:"true"`
-	Masters            []string
+	Endpoint                 string `description:"Mesos server endpoint. You can also specify multiple endpoint for Mesos"`
+	Domain                   string `description:"Default domain used"`
+	ExposedByDefault         bool   `description:"Expose Mesos apps by default" export:"true"`
+	GroupsAsSubDomains       bool   `description:"Convert Mesos groups to subdomains" export:"true"`
+	ZkDetectionTimeout       int    `description:"Zookeeper timeout (in seconds)" export:"true"`
+	RefreshSeconds           int    `description:"Polling interval (in seconds)" export:"true"`
+	IPSources                string `description:"IPSources (e.g. host, docker, mesos, rkt)" export:"true"`
+	StateTimeoutSecond       int    `description:"HTTP Timeout (in seconds)" export:"true"`
+	DisableHealthCheckFilter bool   `description:"Keep tasks failing their health checks in rotation" export:"true"`
+	Masters                  []string
 }
 
 // Provide allows the mesos provider to provide configurations to traefik
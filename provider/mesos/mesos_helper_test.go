@@ -106,6 +106,12 @@ func setState(st string) statusOpt {
 	}
 }
 
+func setTimestamp(timestamp float64) statusOpt {
+	return func(s *state.Status) {
+		s.Timestamp = timestamp
+	}
+}
+
 func setHealthy(b bool) statusOpt {
 	return func(s *state.Status) {
 		s.Healthy = &b
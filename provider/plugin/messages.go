@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The messages of the traefik.provider.v1 protocol, see plugin.proto.
+
+const (
+	serviceName   = "traefik.provider.v1.Provider"
+	provideStream = "Provide"
+	provideMethod = "/" + serviceName + "/" + provideStream
+)
+
+type provideRequest struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name"`
+	Constraints []string `protobuf:"bytes,2,rep,name=constraints"`
+}
+
+func (m *provideRequest) Reset()         { *m = provideRequest{} }
+func (m *provideRequest) String() string { return proto.CompactTextString(m) }
+func (*provideRequest) ProtoMessage()    {}
+
+type configurationUpdate struct {
+	Configuration []byte `protobuf:"bytes,1,opt,name=configuration"`
+}
+
+func (m *configurationUpdate) Reset()         { *m = configurationUpdate{} }
+func (m *configurationUpdate) String() string { return proto.CompactTextString(m) }
+func (*configurationUpdate) ProtoMessage()    {}
@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const unixPrefix = "unix://"
+
+var provideStreamDesc = &grpc.StreamDesc{
+	StreamName:    provideStream,
+	ServerStreams: true,
+}
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+// It receives the configurations of an out-of-tree provider, running as a sidecar process and speaking the protocol of plugin.proto.
+type Provider struct {
+	Name        string            `description:"Name of the plugin, sent to the plugin and used to name the provider" export:"true"`
+	Endpoint    string            `description:"Address of the plugin, host:port or unix:///path/to/socket" export:"true"`
+	Constraints types.Constraints `description:"Filter services by constraint, matching with Traefik tags." export:"true"`
+	TLS         *types.ClientTLS  `description:"Enable TLS support" export:"true"`
+}
+
+// Provide allows the plugin provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+	if len(p.Endpoint) == 0 {
+		return errors.New("no endpoint defined for the plugin provider")
+	}
+	p.Constraints = append(p.Constraints, constraints...)
+
+	dialOptions, err := p.dialOptions()
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(strings.TrimPrefix(p.Endpoint, unixPrefix), dialOptions...)
+	if err != nil {
+		return fmt.Errorf("unable to create the connection to the plugin %s: %v", p.Endpoint, err)
+	}
+
+	pool.Go(func(stop chan bool) {
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		safe.Go(func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		})
+
+		operation := func() error {
+			err := p.streamConfigurations(ctx, conn, configurationChan)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		notify := func(err error, time time.Duration) {
+			log.Errorf("Plugin %s stream error: %v; retrying in %s", p.Endpoint, err, time)
+		}
+		err := backoff.RetryNotify(safe.OperationWithRecover(operation), job.NewBackOff(backoff.NewExponentialBackOff()), notify)
+		if err != nil {
+			log.Errorf("Cannot stream from plugin %s: %v", p.Endpoint, err)
+		}
+	})
+
+	return nil
+}
+
+func (p *Provider) dialOptions() ([]grpc.DialOption, error) {
+	var dialOptions []grpc.DialOption
+	if strings.HasPrefix(p.Endpoint, unixPrefix) {
+		dialOptions = append(dialOptions, grpc.WithDialer(func(address string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", address, timeout)
+		}))
+	}
+
+	if p.TLS == nil {
+		return append(dialOptions, grpc.WithInsecure()), nil
+	}
+	tlsConfig, err := p.TLS.CreateTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))), nil
+}
+
+// providerName returns the name of the provider of the configurations, distinct from the names of the built-in providers.
+func (p *Provider) providerName() string {
+	if len(p.Name) == 0 {
+		return "plugin"
+	}
+	return "plugin-" + p.Name
+}
+
+// streamConfigurations sends a configuration for each update of the plugin, until the stream fails.
+// The updates which can't be decoded are ignored, the previous configuration is kept.
+func (p *Provider) streamConfigurations(ctx context.Context, conn *grpc.ClientConn, configurationChan chan<- types.ConfigMessage) error {
+	stream, err := grpc.NewClientStream(ctx, provideStreamDesc, conn, provideMethod)
+	if err != nil {
+		return err
+	}
+
+	request := &provideRequest{Name: p.Name}
+	for _, constraint := range p.Constraints {
+		request.Constraints = append(request.Constraints, constraint.String())
+	}
+	if err := stream.SendMsg(request); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		update := &configurationUpdate{}
+		if err := stream.RecvMsg(update); err != nil {
+			return err
+		}
+
+		configuration, err := decodeConfiguration(update.Configuration)
+		if err != nil {
+			log.Errorf("Ignoring the configuration of the plugin %s: %v", p.Endpoint, err)
+			continue
+		}
+
+		select {
+		case configurationChan <- types.ConfigMessage{
+			ProviderName:  p.providerName(),
+			Configuration: configuration,
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func decodeConfiguration(content []byte) (*types.Configuration, error) {
+	configuration := &types.Configuration{}
+	if err := json.Unmarshal(content, configuration); err != nil {
+		return nil, fmt.Errorf("invalid JSON configuration: %v", err)
+	}
+	for name, frontend := range configuration.Frontends {
+		if frontend == nil {
+			return nil, fmt.Errorf("empty frontend %s", name)
+		}
+		if _, ok := configuration.Backends[frontend.Backend]; !ok {
+			return nil, fmt.Errorf("undefined backend %q for the frontend %s", frontend.Backend, name)
+		}
+	}
+	for name, backend := range configuration.Backends {
+		if backend == nil {
+			return nil, fmt.Errorf("empty backend %s", name)
+		}
+	}
+	return configuration, nil
+}
@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakePlugin records the request and streams its updates.
+type fakePlugin struct {
+	updates  []string
+	requests chan *provideRequest
+}
+
+func (s *fakePlugin) provide(_ interface{}, stream grpc.ServerStream) error {
+	request := &provideRequest{}
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	s.requests <- request
+
+	for _, update := range s.updates {
+		if err := stream.SendMsg(&configurationUpdate{Configuration: []byte(update)}); err != nil {
+			return err
+		}
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func startPlugin(t *testing.T, plugin *fakePlugin) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    provideStream,
+				Handler:       plugin.provide,
+				ServerStreams: true,
+			},
+		},
+	}, plugin)
+	go server.Serve(listener)
+
+	return listener.Addr().String(), server.Stop
+}
+
+func TestProvide(t *testing.T) {
+	plugin := &fakePlugin{
+		updates: []string{
+			`{"frontends": {`,
+			`{
+				"frontends": {"frontend1": {"backend": "backend1", "routes": {"route1": {"rule": "Host:test.localhost"}}}},
+				"backends": {"backend1": {"servers": {"server1": {"url": "http://10.0.0.1:80"}}}}
+			}`,
+		},
+		requests: make(chan *provideRequest, 1),
+	}
+	endpoint, stopPlugin := startPlugin(t, plugin)
+	defer stopPlugin()
+
+	constraint, err := types.NewConstraint("tag==api")
+	require.NoError(t, err)
+
+	p := &Provider{
+		Name:     "inventory",
+		Endpoint: endpoint,
+	}
+	configurationChan := make(chan types.ConfigMessage, 10)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	err = p.Provide(configurationChan, pool, types.Constraints{constraint})
+	require.NoError(t, err)
+
+	select {
+	case request := <-plugin.requests:
+		assert.Equal(t, &provideRequest{Name: "inventory", Constraints: []string{"tag==api"}}, request)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the plugin has not been requested")
+	}
+
+	select {
+	case configMsg := <-configurationChan:
+		assert.Equal(t, "plugin-inventory", configMsg.ProviderName)
+		assert.Equal(t, &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {
+					Backend: "backend1",
+					Routes:  map[string]types.Route{"route1": {Rule: "Host:test.localhost"}},
+				},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.1:80"}}},
+			},
+		}, configMsg.Configuration)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no configuration received from the plugin")
+	}
+}
+
+func TestDecodeConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		content       string
+		expectedError bool
+	}{
+		{
+			desc:    "empty configuration",
+			content: `{}`,
+		},
+		{
+			desc:    "frontend and backend",
+			content: `{"frontends": {"frontend1": {"backend": "backend1"}}, "backends": {"backend1": {}}}`,
+		},
+		{
+			desc:          "invalid JSON",
+			content:       `{"frontends"`,
+			expectedError: true,
+		},
+		{
+			desc:          "undefined backend",
+			content:       `{"frontends": {"frontend1": {"backend": "backend1"}}}`,
+			expectedError: true,
+		},
+		{
+			desc:          "empty frontend",
+			content:       `{"frontends": {"frontend1": null}}`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := decodeConfiguration([]byte(test.content))
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	assert.Equal(t, "plugin", (&Provider{}).providerName())
+	assert.Equal(t, "plugin-inventory", (&Provider{Name: "inventory"}).providerName())
+}
@@ -29,6 +29,7 @@ type BaseProvider struct {
 	Constraints               types.Constraints `description:"Filter services by constraint, matching with Traefik tags." export:"true"`
 	Trace                     bool              `description:"Display additional provider logs (if available)." export:"true"`
 	DebugLogGeneratedTemplate bool              `description:"Enable debug logging of generated configuration template." export:"true"`
+	BackendTLS                *types.BackendTLS `description:"Default TLS configuration used to reach the backends of the provider."`
 }
 
 // MatchConstraints must match with EVERY single constraint
@@ -88,9 +89,23 @@ func (p *BaseProvider) GetConfiguration(defaultTemplateFile string, funcMap temp
 	if _, err := toml.Decode(renderedTemplate, configuration); err != nil {
 		return nil, err
 	}
+	p.setDefaultBackendTLS(configuration)
 	return configuration, nil
 }
 
+// setDefaultBackendTLS sets the default TLS configuration of the provider to the backends without their own.
+func (p *BaseProvider) setDefaultBackendTLS(configuration *types.Configuration) {
+	if p.BackendTLS == nil {
+		return
+	}
+	for _, backend := range configuration.Backends {
+		if backend != nil && backend.TLS == nil {
+			backendTLS := *p.BackendTLS
+			backend.TLS = &backendTLS
+		}
+	}
+}
+
 func (p *BaseProvider) getTemplateContent(defaultTemplateFile string) (string, error) {
 	if len(p.Filename) > 0 {
 		buf, err := ioutil.ReadFile(p.Filename)
@@ -186,6 +186,46 @@ func TestGetConfigurationReturnsCorrectMaxConnConfiguration(t *testing.T) {
 	}
 }
 
+func TestGetConfigurationSetsDefaultBackendTLS(t *testing.T) {
+	templateFile, err := ioutil.TempFile("", "provider-configuration")
+	require.NoError(t, err)
+	defer os.RemoveAll(templateFile.Name())
+
+	data := []byte(`[backends]
+  [backends.backend1]
+    [backends.backend1.tls]
+      cert = '''
+-----BEGIN CERTIFICATE-----
+MIIB
+-----END CERTIFICATE-----'''
+      key = "/etc/traefik/backend1.key"
+      ca = ''''''
+  [backends.backend2]
+    [backends.backend2.servers.server1]
+      url = "https://10.0.0.2"`)
+	require.NoError(t, ioutil.WriteFile(templateFile.Name(), data, 0700))
+
+	provider := &myProvider{
+		BaseProvider{
+			Filename:   templateFile.Name(),
+			BackendTLS: &types.BackendTLS{CA: "/etc/traefik/ca.pem"},
+		},
+		nil,
+	}
+	configuration, err := provider.GetConfiguration(templateFile.Name(), nil, nil)
+	require.NoError(t, err)
+
+	expected := &types.BackendTLS{
+		Cert: "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+		Key:  "/etc/traefik/backend1.key",
+	}
+	assert.Equal(t, expected, configuration.Backends["backend1"].TLS)
+	assert.Equal(t, &types.BackendTLS{CA: "/etc/traefik/ca.pem"}, configuration.Backends["backend2"].TLS)
+
+	configuration.Backends["backend2"].TLS.CA = "changed"
+	assert.Equal(t, "/etc/traefik/ca.pem", provider.BackendTLS.CA, "the default should be copied to the backends")
+}
+
 func TestNilClientTLS(t *testing.T) {
 	provider := &myProvider{
 		BaseProvider{
@@ -13,13 +13,15 @@ import (
 	"github.com/containous/traefik/types"
 
 	rancher "github.com/rancher/go-rancher-metadata/metadata"
+	"github.com/ryanuber/go-glob"
 )
 
 // MetadataConfiguration contains configuration properties specific to
 // the Rancher metadata service provider.
 type MetadataConfiguration struct {
-	IntervalPoll bool   `description:"Poll the Rancher metadata service every 'rancher.refreshseconds' (less accurate)"`
-	Prefix       string `description:"Prefix used for accessing the Rancher metadata service"`
+	IntervalPoll bool     `description:"Poll the Rancher metadata service every 'rancher.refreshseconds' (less accurate)"`
+	Prefix       string   `description:"Prefix used for accessing the Rancher metadata service"`
+	Stacks       []string `description:"Only consider the services of the given stacks (glob patterns are supported)" export:"true"`
 }
 
 func (p *Provider) metadataProvide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
@@ -31,7 +33,7 @@ func (p *Provider) metadataProvide(configurationChan chan<- types.ConfigMessage,
 		operation := func() error {
 			client, err := rancher.NewClientAndWait(metadataServiceURL)
 			if err != nil {
-				log.Errorln("Failed to create Rancher metadata service client: %s", err)
+				log.Errorf("Failed to create Rancher metadata service client: %s", err)
 				return err
 			}
 
@@ -44,7 +46,7 @@ func (p *Provider) metadataProvide(configurationChan chan<- types.ConfigMessage,
 					return
 				}
 
-				rancherData := parseMetadataSourcedRancherData(stacks)
+				rancherData := parseMetadataSourcedRancherData(filterStacks(stacks, p.Metadata.Stacks))
 				configuration := p.buildConfiguration(rancherData)
 				configurationChan <- types.ConfigMessage{
 					ProviderName:  "rancher",
@@ -118,6 +120,33 @@ func (p *Provider) longPoll(client rancher.Client, updateConfiguration func(stri
 	<-stop
 }
 
+// filterStacks keeps the stacks whose name matches one of the given patterns.
+// All stacks are kept when no pattern is given.
+func filterStacks(stacks []rancher.Stack, patterns []string) []rancher.Stack {
+	if len(patterns) == 0 {
+		return stacks
+	}
+
+	var filtered []rancher.Stack
+	for _, stack := range stacks {
+		if stackMatches(stack.Name, patterns) {
+			filtered = append(filtered, stack)
+		} else {
+			log.Debugf("Filtering Rancher stack %s not matching %v", stack.Name, patterns)
+		}
+	}
+	return filtered
+}
+
+func stackMatches(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if glob.Glob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
 func parseMetadataSourcedRancherData(stacks []rancher.Stack) (rancherDataList []rancherData) {
 	for _, stack := range stacks {
 		for _, service := range stack.Services {
@@ -0,0 +1,61 @@
+package rancher
+
+import (
+	"testing"
+
+	rancher "github.com/rancher/go-rancher-metadata/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterStacks(t *testing.T) {
+	stacks := []rancher.Stack{
+		{Name: "frontend"},
+		{Name: "api-users"},
+		{Name: "api-orders"},
+		{Name: "database"},
+	}
+
+	testCases := []struct {
+		desc     string
+		patterns []string
+		expected []string
+	}{
+		{
+			desc:     "no pattern keeps all stacks",
+			expected: []string{"frontend", "api-users", "api-orders", "database"},
+		},
+		{
+			desc:     "exact name",
+			patterns: []string{"frontend"},
+			expected: []string{"frontend"},
+		},
+		{
+			desc:     "glob pattern",
+			patterns: []string{"api-*"},
+			expected: []string{"api-users", "api-orders"},
+		},
+		{
+			desc:     "several patterns",
+			patterns: []string{"frontend", "*base"},
+			expected: []string{"frontend", "database"},
+		},
+		{
+			desc:     "no match",
+			patterns: []string{"unknown"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var names []string
+			for _, stack := range filterStacks(stacks, test.patterns) {
+				names = append(names, stack.Name)
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// The frontends, the backends and the servers of the configuration of the rest provider are read, created, replaced and removed one by one.
+// Their ETag is given with them, and the changes are conditioned on it with the If-Match and If-None-Match headers.
+
+func (p *Provider) getFrontendHandler(response http.ResponseWriter, request *http.Request) {
+	p.mutex.Lock()
+	frontend, ok := frontendOf(p.configuration, mux.Vars(request)["frontend"])
+	p.mutex.Unlock()
+
+	writeElement(response, request, ok, http.StatusOK, frontend)
+}
+
+func (p *Provider) putFrontendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["frontend"]
+	frontend := new(types.Frontend)
+	if err := json.NewDecoder(request.Body).Decode(frontend); err != nil {
+		http.Error(response, fmt.Sprintf("invalid frontend: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, exists := frontendOf(p.configuration, name)
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+	if err := validateFrontend(frontend, p.configuration); err != nil {
+		http.Error(response, fmt.Sprintf("invalid frontend %s: %v", name, err), http.StatusBadRequest)
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Frontends: map[string]*types.Frontend{name: frontend}}))
+	log.Infof("Frontend %s of the rest provider set through the API", name)
+	writeElement(response, request, true, createdStatus(exists), frontend)
+}
+
+func (p *Provider) deleteFrontendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["frontend"]
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, exists := frontendOf(p.configuration, name)
+	if !exists {
+		http.NotFound(response, request)
+		return
+	}
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Frontends: map[string]*types.Frontend{name: nil}}))
+	log.Infof("Frontend %s of the rest provider removed through the API", name)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Provider) getBackendHandler(response http.ResponseWriter, request *http.Request) {
+	p.mutex.Lock()
+	backend, ok := backendOf(p.configuration, mux.Vars(request)["backend"])
+	p.mutex.Unlock()
+
+	writeElement(response, request, ok, http.StatusOK, backend)
+}
+
+func (p *Provider) putBackendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["backend"]
+	backend := new(types.Backend)
+	if err := json.NewDecoder(request.Body).Decode(backend); err != nil {
+		http.Error(response, fmt.Sprintf("invalid backend: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, exists := backendOf(p.configuration, name)
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+	if err := validateBackend(backend); err != nil {
+		http.Error(response, fmt.Sprintf("invalid backend %s: %v", name, err), http.StatusBadRequest)
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Backends: map[string]*types.Backend{name: backend}}))
+	log.Infof("Backend %s of the rest provider set through the API", name)
+	writeElement(response, request, true, createdStatus(exists), backend)
+}
+
+func (p *Provider) deleteBackendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["backend"]
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, exists := backendOf(p.configuration, name)
+	if !exists {
+		http.NotFound(response, request)
+		return
+	}
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+	if frontends := frontendsOf(p.configuration, name); len(frontends) > 0 {
+		http.Error(response, fmt.Sprintf("the backend %s is used by the frontends %s", name, strings.Join(frontends, ", ")), http.StatusConflict)
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Backends: map[string]*types.Backend{name: nil}}))
+	log.Infof("Backend %s of the rest provider removed through the API", name)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Provider) getServerHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+
+	p.mutex.Lock()
+	server, ok := serverOf(p.configuration, vars["backend"], vars["server"])
+	p.mutex.Unlock()
+
+	writeElement(response, request, ok, http.StatusOK, server)
+}
+
+func (p *Provider) putServerHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	backendName, name := vars["backend"], vars["server"]
+	server := types.Server{}
+	if err := json.NewDecoder(request.Body).Decode(&server); err != nil {
+		http.Error(response, fmt.Sprintf("invalid server: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	backend, ok := backendOf(p.configuration, backendName)
+	if !ok {
+		http.NotFound(response, request)
+		return
+	}
+	current, exists := backend.Servers[name]
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+	if err := validateServer(server); err != nil {
+		http.Error(response, fmt.Sprintf("invalid server %s: %v", name, err), http.StatusBadRequest)
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Backends: map[string]*types.Backend{backendName: withServer(backend, name, &server)}}))
+	log.Infof("Server %s of the backend %s of the rest provider set through the API", name, backendName)
+	writeElement(response, request, true, createdStatus(exists), server)
+}
+
+func (p *Provider) deleteServerHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	backendName, name := vars["backend"], vars["server"]
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	backend, ok := backendOf(p.configuration, backendName)
+	if !ok {
+		http.NotFound(response, request)
+		return
+	}
+	current, exists := backend.Servers[name]
+	if !exists {
+		http.NotFound(response, request)
+		return
+	}
+	if !checkPreconditions(response, request, exists, current) {
+		return
+	}
+
+	p.apply(mergeConfiguration(p.configuration, &types.Configuration{Backends: map[string]*types.Backend{backendName: withServer(backend, name, nil)}}))
+	log.Infof("Server %s of the backend %s of the rest provider removed through the API", name, backendName)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func frontendOf(configuration *types.Configuration, name string) (*types.Frontend, bool) {
+	if configuration == nil {
+		return nil, false
+	}
+	frontend, ok := configuration.Frontends[name]
+	return frontend, ok && frontend != nil
+}
+
+func backendOf(configuration *types.Configuration, name string) (*types.Backend, bool) {
+	if configuration == nil {
+		return nil, false
+	}
+	backend, ok := configuration.Backends[name]
+	return backend, ok && backend != nil
+}
+
+func serverOf(configuration *types.Configuration, backendName, name string) (types.Server, bool) {
+	backend, ok := backendOf(configuration, backendName)
+	if !ok {
+		return types.Server{}, false
+	}
+	server, ok := backend.Servers[name]
+	return server, ok
+}
+
+// frontendsOf returns the names of the frontends using the backend.
+func frontendsOf(configuration *types.Configuration, backendName string) []string {
+	var names []string
+	for name, frontend := range configuration.Frontends {
+		if frontend != nil && frontend.Backend == backendName {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// withServer returns a copy of the backend with the server set, or removed if nil.
+func withServer(backend *types.Backend, name string, server *types.Server) *types.Backend {
+	copied := *backend
+	copied.Servers = make(map[string]types.Server, len(backend.Servers)+1)
+	for serverName, s := range backend.Servers {
+		copied.Servers[serverName] = s
+	}
+	if server == nil {
+		delete(copied.Servers, name)
+	} else {
+		copied.Servers[name] = *server
+	}
+	return &copied
+}
+
+func validateFrontend(frontend *types.Frontend, configuration *types.Configuration) error {
+	if len(frontend.Backend) == 0 {
+		return errors.New("the backend is required")
+	}
+	if _, ok := backendOf(configuration, frontend.Backend); !ok {
+		return fmt.Errorf("the backend %s doesn't exist", frontend.Backend)
+	}
+	if len(frontend.Routes) == 0 {
+		return errors.New("at least one route is required")
+	}
+	for name, route := range frontend.Routes {
+		if len(strings.TrimSpace(route.Rule)) == 0 {
+			return fmt.Errorf("the rule of the route %s is empty", name)
+		}
+	}
+	return nil
+}
+
+func validateBackend(backend *types.Backend) error {
+	for name, server := range backend.Servers {
+		if err := validateServer(server); err != nil {
+			return fmt.Errorf("server %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func validateServer(server types.Server) error {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", server.URL, err)
+	}
+	if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return fmt.Errorf("invalid URL %q, expected an absolute URL", server.URL)
+	}
+	if server.Weight < 0 {
+		return fmt.Errorf("negative weight %d", server.Weight)
+	}
+	return nil
+}
+
+// etag returns the ETag of the JSON representation of the element.
+func etag(element interface{}) string {
+	data, err := json.Marshal(element)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// checkPreconditions checks the If-Match and If-None-Match headers against the current element, if it exists,
+// and answers with a 412 status when they fail.
+func checkPreconditions(response http.ResponseWriter, request *http.Request, exists bool, current interface{}) bool {
+	var currentETag string
+	if exists {
+		currentETag = etag(current)
+	}
+
+	if ifMatch := request.Header.Get("If-Match"); len(ifMatch) > 0 {
+		if !exists || (strings.TrimSpace(ifMatch) != "*" && !matchETag(ifMatch, currentETag)) {
+			http.Error(response, "the element was changed, or doesn't exist", http.StatusPreconditionFailed)
+			return false
+		}
+	}
+	if ifNoneMatch := request.Header.Get("If-None-Match"); len(ifNoneMatch) > 0 && exists {
+		if strings.TrimSpace(ifNoneMatch) == "*" || matchETag(ifNoneMatch, currentETag) {
+			http.Error(response, "the element already exists", http.StatusPreconditionFailed)
+			return false
+		}
+	}
+	return true
+}
+
+// matchETag returns whether the ETag is in the comma-separated list of the header.
+func matchETag(header, value string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == value {
+			return true
+		}
+	}
+	return false
+}
+
+func createdStatus(existed bool) int {
+	if existed {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+func writeElement(response http.ResponseWriter, request *http.Request, exists bool, status int, element interface{}) {
+	if !exists {
+		http.NotFound(response, request)
+		return
+	}
+
+	response.Header().Set("ETag", etag(element))
+	err := templatesRenderer.JSON(response, status, element)
+	if err != nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,247 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderElements(t *testing.T) {
+	currentConfigurations := &safe.Safe{}
+	currentConfigurations.Set(types.Configurations{})
+
+	configurationChan := make(chan types.ConfigMessage, 100)
+	provider := &Provider{CurrentConfigurations: currentConfigurations}
+	require.NoError(t, provider.Provide(configurationChan, nil, nil))
+
+	router := mux.NewRouter()
+	provider.AddRoutes(router)
+
+	const path = "/api/providers/rest/configuration"
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		body           string
+		ifMatch        string
+		ifNoneMatch    string
+		ifMatchLast    bool
+		expectedStatus int
+	}{
+		{
+			desc:           "frontend of a missing backend",
+			method:         http.MethodPut,
+			path:           path + "/frontends/frontend1",
+			body:           `{"backend": "backend1", "routes": {"route1": {"rule": "Host:example.com"}}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "create a backend",
+			method:         http.MethodPut,
+			path:           path + "/backends/backend1",
+			body:           `{"servers": {"server1": {"url": "http://10.0.0.1:80", "weight": 1}}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			desc:           "backend with an invalid server",
+			method:         http.MethodPut,
+			path:           path + "/backends/backend2",
+			body:           `{"servers": {"server1": {"url": "10.0.0.2"}}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "frontend without routes",
+			method:         http.MethodPut,
+			path:           path + "/frontends/frontend1",
+			body:           `{"backend": "backend1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "create a frontend",
+			method:         http.MethodPut,
+			path:           path + "/frontends/frontend1",
+			body:           `{"backend": "backend1", "routes": {"route1": {"rule": "Host:example.com"}}}`,
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			desc:           "create an existing frontend",
+			method:         http.MethodPut,
+			path:           path + "/frontends/frontend1",
+			body:           `{"backend": "backend1", "routes": {"route1": {"rule": "Host:example.org"}}}`,
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			desc:           "replace a changed frontend",
+			method:         http.MethodPut,
+			path:           path + "/frontends/frontend1",
+			body:           `{"backend": "backend1", "routes": {"route1": {"rule": "Host:example.org"}}}`,
+			ifMatch:        `"0123456789"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			desc:           "add a server",
+			method:         http.MethodPut,
+			path:           path + "/backends/backend1/servers/server2",
+			body:           `{"url": "http://10.0.0.2:80", "weight": 1}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			desc:           "add a server to a missing backend",
+			method:         http.MethodPut,
+			path:           path + "/backends/backend3/servers/server1",
+			body:           `{"url": "http://10.0.0.3:80"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "remove a used backend",
+			method:         http.MethodDelete,
+			path:           path + "/backends/backend1",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			desc:           "get a server",
+			method:         http.MethodGet,
+			path:           path + "/backends/backend1/servers/server1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "remove an unchanged server",
+			method:         http.MethodDelete,
+			path:           path + "/backends/backend1/servers/server1",
+			ifMatchLast:    true,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			desc:           "get a removed server",
+			method:         http.MethodGet,
+			path:           path + "/backends/backend1/servers/server1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "remove a frontend",
+			method:         http.MethodDelete,
+			path:           path + "/frontends/frontend1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			desc:           "remove an unused backend",
+			method:         http.MethodDelete,
+			path:           path + "/backends/backend1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	// The cases depend on the previous ones
+	var lastETag string
+	for _, test := range testCases {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if len(test.ifMatch) > 0 {
+			req.Header.Set("If-Match", test.ifMatch)
+		}
+		if test.ifMatchLast {
+			req.Header.Set("If-Match", lastETag)
+		}
+		if len(test.ifNoneMatch) > 0 {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.expectedStatus, recorder.Code, test.desc)
+		lastETag = recorder.Header().Get("ETag")
+	}
+
+	// The configuration is sent on each change: the created backend and frontend, the added and removed servers, the removed frontend and backend
+	require.Len(t, configurationChan, 6)
+	var message types.ConfigMessage
+	for len(configurationChan) > 0 {
+		message = <-configurationChan
+	}
+	assert.Equal(t, "web", message.ProviderName)
+	assert.Empty(t, message.Configuration.Frontends)
+	assert.Empty(t, message.Configuration.Backends)
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	element := types.Server{URL: "http://10.0.0.1:80", Weight: 1}
+	currentETag := etag(element)
+
+	testCases := []struct {
+		desc        string
+		exists      bool
+		ifMatch     string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{
+			desc:     "no precondition",
+			exists:   true,
+			expected: true,
+		},
+		{
+			desc:     "matching ETag",
+			exists:   true,
+			ifMatch:  `"other", ` + currentETag,
+			expected: true,
+		},
+		{
+			desc:     "weak matching ETag",
+			exists:   true,
+			ifMatch:  "W/" + currentETag,
+			expected: true,
+		},
+		{
+			desc:     "changed ETag",
+			exists:   true,
+			ifMatch:  `"other"`,
+			expected: false,
+		},
+		{
+			desc:     "any ETag of a missing element",
+			ifMatch:  "*",
+			expected: false,
+		},
+		{
+			desc:        "creation of a missing element",
+			ifNoneMatch: "*",
+			expected:    true,
+		},
+		{
+			desc:        "creation of an existing element",
+			exists:      true,
+			ifNoneMatch: "*",
+			expected:    false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if len(test.ifMatch) > 0 {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			if len(test.ifNoneMatch) > 0 {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+
+			assert.Equal(t, test.expected, checkPreconditions(recorder, req, test.exists, element))
+			if !test.expected {
+				assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			}
+		})
+	}
+}
@@ -1,56 +1,72 @@
 package rest
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/containous/mux"
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/auth"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/unrolled/render"
+	"github.com/urfave/negroni"
 )
 
 // Provider is a provider.Provider implementation that provides a Rest API
 type Provider struct {
 	configurationChan     chan<- types.ConfigMessage
-	EntryPoint            string `description:"EntryPoint" export:"true"`
+	EntryPoint            string      `description:"EntryPoint" export:"true"`
+	Auth                  *types.Auth `description:"Authentication required to read and update the configuration" export:"true"`
+	Token                 string      `description:"Bearer token required to read and update the configuration"`
 	CurrentConfigurations *safe.Safe
+	mutex                 sync.Mutex
+	configuration         *types.Configuration
 }
 
 var templatesRenderer = render.New(render.Options{Directory: "nowhere"})
 
 // AddRoutes add rest provider routes on a router
 func (p *Provider) AddRoutes(systemRouter *mux.Router) {
+	authHandler, err := p.authHandler()
+	if err != nil {
+		log.Errorf("Error creating the rest provider authentication, the rest provider routes are disabled: %v", err)
+		return
+	}
+
 	systemRouter.
 		Methods(http.MethodPut).
 		Path("/api/providers/{provider}").
-		HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		Handler(withAuth(authHandler, p.putConfigHandler))
 
-			vars := mux.Vars(request)
-			// TODO: Deprecated configuration - Need to be removed in the future
-			if vars["provider"] != "web" && vars["provider"] != "rest" {
-				response.WriteHeader(http.StatusBadRequest)
-				fmt.Fprint(response, "Only 'rest' provider can be updated through the REST API")
-				return
-			} else if vars["provider"] == "web" {
-				log.Warn("The provider web is deprecated. Please use /rest instead")
-			}
+	systemRouter.
+		Methods(http.MethodPatch).
+		Path("/api/providers/{provider}").
+		Handler(withAuth(authHandler, p.patchConfigHandler))
 
-			configuration := new(types.Configuration)
-			body, _ := ioutil.ReadAll(request.Body)
-			err := json.Unmarshal(body, configuration)
-			if err == nil {
-				// TODO: Deprecated configuration - Change to `rest` in the future
-				p.configurationChan <- types.ConfigMessage{ProviderName: "web", Configuration: configuration}
-				p.getConfigHandler(response, request)
-			} else {
-				log.Errorf("Error parsing configuration %+v", err)
-				http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
-			}
-		})
+	systemRouter.
+		Methods(http.MethodGet).
+		Path("/api/providers/{provider:web|rest}/configuration").
+		Handler(withAuth(authHandler, p.getProviderConfigHandler))
+
+	configurationPath := "/api/providers/{provider:web|rest}/configuration"
+	for _, route := range []struct {
+		path                 string
+		get, put, deleteFunc http.HandlerFunc
+	}{
+		{path: "/frontends/{frontend}", get: p.getFrontendHandler, put: p.putFrontendHandler, deleteFunc: p.deleteFrontendHandler},
+		{path: "/backends/{backend}", get: p.getBackendHandler, put: p.putBackendHandler, deleteFunc: p.deleteBackendHandler},
+		{path: "/backends/{backend}/servers/{server}", get: p.getServerHandler, put: p.putServerHandler, deleteFunc: p.deleteServerHandler},
+	} {
+		systemRouter.Methods(http.MethodGet).Path(configurationPath + route.path).Handler(withAuth(authHandler, route.get))
+		systemRouter.Methods(http.MethodPut).Path(configurationPath + route.path).Handler(withAuth(authHandler, route.put))
+		systemRouter.Methods(http.MethodDelete).Path(configurationPath + route.path).Handler(withAuth(authHandler, route.deleteFunc))
+	}
 }
 
 // Provide allows the provider to provide configurations to traefik
@@ -60,6 +76,68 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 	return nil
 }
 
+func (p *Provider) putConfigHandler(response http.ResponseWriter, request *http.Request) {
+	if !checkProvider(response, request) {
+		return
+	}
+
+	configuration, err := readConfiguration(request)
+	if err != nil {
+		log.Errorf("Error parsing configuration %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	if !checkPreconditions(response, request, true, p.currentConfiguration()) {
+		p.mutex.Unlock()
+		return
+	}
+	p.apply(configuration)
+	p.mutex.Unlock()
+
+	p.getConfigHandler(response, request)
+}
+
+// patchConfigHandler merges a partial configuration into the configuration of the provider:
+// the given frontends and backends replace the existing ones, the ones set to null are removed,
+// and the TLS configurations replace the existing ones when they are given.
+func (p *Provider) patchConfigHandler(response http.ResponseWriter, request *http.Request) {
+	if !checkProvider(response, request) {
+		return
+	}
+
+	patch, err := readConfiguration(request)
+	if err != nil {
+		log.Errorf("Error parsing configuration %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	if !checkPreconditions(response, request, true, p.currentConfiguration()) {
+		p.mutex.Unlock()
+		return
+	}
+	p.apply(mergeConfiguration(p.configuration, patch))
+	p.mutex.Unlock()
+
+	p.getConfigHandler(response, request)
+}
+
+// getProviderConfigHandler returns the last configuration applied through the rest provider.
+func (p *Provider) getProviderConfigHandler(response http.ResponseWriter, request *http.Request) {
+	p.mutex.Lock()
+	configuration := p.currentConfiguration()
+	p.mutex.Unlock()
+
+	response.Header().Set("ETag", etag(configuration))
+	err := templatesRenderer.JSON(response, http.StatusOK, configuration)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
 func (p *Provider) getConfigHandler(response http.ResponseWriter, request *http.Request) {
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	err := templatesRenderer.JSON(response, http.StatusOK, currentConfigurations)
@@ -67,3 +145,121 @@ func (p *Provider) getConfigHandler(response http.ResponseWriter, request *http.
 		log.Error(err)
 	}
 }
+
+// currentConfiguration returns the last configuration applied, empty if none, the mutex must be held by the caller.
+func (p *Provider) currentConfiguration() *types.Configuration {
+	if p.configuration == nil {
+		return &types.Configuration{}
+	}
+	return p.configuration
+}
+
+// apply sends the configuration to traefik, the mutex must be held by the caller.
+func (p *Provider) apply(configuration *types.Configuration) {
+	p.configuration = configuration
+	// TODO: Deprecated configuration - Change to `rest` in the future
+	p.configurationChan <- types.ConfigMessage{ProviderName: "web", Configuration: configuration}
+}
+
+func (p *Provider) authHandler() (negroni.Handler, error) {
+	var authenticator *auth.Authenticator
+	if p.Auth != nil {
+		var err error
+		authenticator, err = auth.NewAuthenticator(p.Auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.Token) == 0 {
+		if authenticator == nil {
+			return nil, nil
+		}
+		return authenticator, nil
+	}
+
+	token := []byte(p.Token)
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		header := r.Header.Get("Authorization")
+		if strings.HasPrefix(header, "Bearer ") {
+			if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, "Bearer ")), token) == 1 {
+				next(rw, r)
+				return
+			}
+			log.Debug("Rest provider bearer token authentication failed...")
+		} else if authenticator != nil {
+			authenticator.ServeHTTP(rw, r, next)
+			return
+		}
+
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="traefik"`)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	}), nil
+}
+
+func withAuth(authHandler negroni.Handler, handler http.HandlerFunc) http.Handler {
+	if authHandler == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		authHandler.ServeHTTP(rw, r, handler)
+	})
+}
+
+func checkProvider(response http.ResponseWriter, request *http.Request) bool {
+	vars := mux.Vars(request)
+	// TODO: Deprecated configuration - Need to be removed in the future
+	if vars["provider"] != "web" && vars["provider"] != "rest" {
+		response.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(response, "Only 'rest' provider can be updated through the REST API")
+		return false
+	} else if vars["provider"] == "web" {
+		log.Warn("The provider web is deprecated. Please use /rest instead")
+	}
+	return true
+}
+
+func readConfiguration(request *http.Request) (*types.Configuration, error) {
+	configuration := new(types.Configuration)
+	body, _ := ioutil.ReadAll(request.Body)
+	err := json.Unmarshal(body, configuration)
+	return configuration, err
+}
+
+// mergeConfiguration returns a new configuration made of the current configuration and the patch.
+func mergeConfiguration(current, patch *types.Configuration) *types.Configuration {
+	merged := &types.Configuration{
+		Frontends: make(map[string]*types.Frontend),
+		Backends:  make(map[string]*types.Backend),
+	}
+
+	if current != nil {
+		for name, frontend := range current.Frontends {
+			merged.Frontends[name] = frontend
+		}
+		for name, backend := range current.Backends {
+			merged.Backends[name] = backend
+		}
+		merged.TLSConfiguration = current.TLSConfiguration
+	}
+
+	for name, frontend := range patch.Frontends {
+		if frontend == nil {
+			delete(merged.Frontends, name)
+		} else {
+			merged.Frontends[name] = frontend
+		}
+	}
+	for name, backend := range patch.Backends {
+		if backend == nil {
+			delete(merged.Backends, name)
+		} else {
+			merged.Backends[name] = backend
+		}
+	}
+	if patch.TLSConfiguration != nil {
+		merged.TLSConfiguration = patch.TLSConfiguration
+	}
+
+	return merged
+}
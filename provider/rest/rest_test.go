@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfiguration(t *testing.T) {
+	current := &types.Configuration{
+		Frontends: map[string]*types.Frontend{
+			"frontend1": {Backend: "backend1"},
+			"frontend2": {Backend: "backend2"},
+		},
+		Backends: map[string]*types.Backend{
+			"backend1": {},
+			"backend2": {},
+		},
+	}
+
+	testCases := []struct {
+		desc              string
+		current           *types.Configuration
+		patch             string
+		expectedFrontends map[string]string
+		expectedBackends  []string
+	}{
+		{
+			desc:              "no current configuration",
+			patch:             `{"frontends": {"frontend3": {"backend": "backend3"}}}`,
+			expectedFrontends: map[string]string{"frontend3": "backend3"},
+		},
+		{
+			desc:              "replace and add",
+			current:           current,
+			patch:             `{"frontends": {"frontend1": {"backend": "backend2"}, "frontend3": {"backend": "backend3"}}, "backends": {"backend3": {}}}`,
+			expectedFrontends: map[string]string{"frontend1": "backend2", "frontend2": "backend2", "frontend3": "backend3"},
+			expectedBackends:  []string{"backend1", "backend2", "backend3"},
+		},
+		{
+			desc:              "remove",
+			current:           current,
+			patch:             `{"frontends": {"frontend2": null}, "backends": {"backend2": null}}`,
+			expectedFrontends: map[string]string{"frontend1": "backend1"},
+			expectedBackends:  []string{"backend1"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			patch := &types.Configuration{}
+			require.NoError(t, json.Unmarshal([]byte(test.patch), patch))
+
+			merged := mergeConfiguration(test.current, patch)
+
+			frontends := make(map[string]string)
+			for name, frontend := range merged.Frontends {
+				frontends[name] = frontend.Backend
+			}
+			assert.Equal(t, test.expectedFrontends, frontends)
+
+			assert.Len(t, merged.Backends, len(test.expectedBackends))
+			for _, name := range test.expectedBackends {
+				assert.Contains(t, merged.Backends, name)
+			}
+		})
+	}
+
+	// the current configuration is not modified
+	assert.Len(t, current.Frontends, 2)
+	assert.Len(t, current.Backends, 2)
+}
+
+func TestProviderAuth(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		provider       *Provider
+		authorization  func(req *http.Request)
+		expectedStatus int
+	}{
+		{
+			desc:           "no authentication",
+			provider:       &Provider{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "valid token",
+			provider:       &Provider{Token: "secret"},
+			authorization:  func(req *http.Request) { req.Header.Set("Authorization", "Bearer secret") },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "invalid token",
+			provider:       &Provider{Token: "secret"},
+			authorization:  func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "missing token",
+			provider:       &Provider{Token: "secret"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc: "valid basic auth",
+			provider: &Provider{
+				Token: "secret",
+				Auth:  &types.Auth{Basic: &types.Basic{Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}}},
+			},
+			authorization:  func(req *http.Request) { req.SetBasicAuth("test", "test") },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "invalid basic auth",
+			provider: &Provider{
+				Auth: &types.Auth{Basic: &types.Basic{Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}}},
+			},
+			authorization:  func(req *http.Request) { req.SetBasicAuth("test", "wrong") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			router := mux.NewRouter()
+			test.provider.AddRoutes(router)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/providers/rest/configuration", nil)
+			if test.authorization != nil {
+				test.authorization(req)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestProviderPatch(t *testing.T) {
+	currentConfigurations := &safe.Safe{}
+	currentConfigurations.Set(types.Configurations{})
+
+	configurationChan := make(chan types.ConfigMessage, 10)
+	provider := &Provider{CurrentConfigurations: currentConfigurations}
+	require.NoError(t, provider.Provide(configurationChan, nil, nil))
+
+	router := mux.NewRouter()
+	provider.AddRoutes(router)
+
+	requests := []struct {
+		method string
+		body   string
+	}{
+		{method: http.MethodPut, body: `{"frontends": {"frontend1": {"backend": "backend1"}}, "backends": {"backend1": {}}}`},
+		{method: http.MethodPatch, body: `{"frontends": {"frontend2": {"backend": "backend1"}}}`},
+	}
+	for _, r := range requests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(r.method, "/api/providers/rest", strings.NewReader(r.body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	require.Len(t, configurationChan, 2)
+	<-configurationChan
+	message := <-configurationChan
+	assert.Equal(t, "web", message.ProviderName)
+	assert.Len(t, message.Configuration.Frontends, 2)
+	assert.Len(t, message.Configuration.Backends, 1)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/providers/rest/configuration", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	configuration := &types.Configuration{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), configuration))
+	assert.Len(t, configuration.Frontends, 2)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPatch, "/api/providers/docker", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,72 @@
+package srv
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+)
+
+// buildConfiguration builds a backend and a frontend for each service from the targets of its SRV record.
+// Only the targets with the lowest priority are used, the other ones are backups.
+func (p *Provider) buildConfiguration(targets map[string][]srvTarget) *types.Configuration {
+	configuration := &types.Configuration{
+		Backends:  make(map[string]*types.Backend),
+		Frontends: make(map[string]*types.Frontend),
+	}
+
+	for name, service := range p.Services {
+		serviceTargets := lowestPriority(targets[name])
+		if len(serviceTargets) == 0 {
+			continue
+		}
+
+		backendName := "backend-" + provider.Normalize(name)
+		backend := &types.Backend{
+			Servers:     make(map[string]types.Server),
+			HealthCheck: service.HealthCheck,
+		}
+		protocol := service.Protocol
+		if len(protocol) == 0 {
+			protocol = "http"
+		}
+		for _, target := range serviceTargets {
+			address := net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port)))
+			backend.Servers["server-"+provider.Normalize(address)] = types.Server{
+				URL:    protocol + "://" + address,
+				Weight: int(target.Weight),
+			}
+		}
+		configuration.Backends[backendName] = backend
+
+		rule := service.Rule
+		if len(rule) == 0 {
+			rule = "Host:" + name + "." + p.Domain
+		}
+		frontendName := "frontend-" + provider.Normalize(name)
+		configuration.Frontends[frontendName] = &types.Frontend{
+			Backend:        backendName,
+			EntryPoints:    service.EntryPoints,
+			PassHostHeader: true,
+			Routes: map[string]types.Route{
+				"route-" + provider.Normalize(name): {Rule: rule},
+			},
+		}
+	}
+
+	return configuration
+}
+
+func lowestPriority(targets []srvTarget) []srvTarget {
+	var result []srvTarget
+	for _, target := range targets {
+		switch {
+		case len(result) == 0 || target.Priority == result[0].Priority:
+			result = append(result, target)
+		case target.Priority < result[0].Priority:
+			result = []srvTarget{target}
+		}
+	}
+	return result
+}
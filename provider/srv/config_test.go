@@ -0,0 +1,157 @@
+package srv
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		services map[string]*Service
+		targets  map[string][]srvTarget
+		expected *types.Configuration
+	}{
+		{
+			desc: "no target",
+			services: map[string]*Service{
+				"whoami": {Record: "_http._tcp.whoami.example.com"},
+			},
+			targets: map[string][]srvTarget{},
+			expected: &types.Configuration{
+				Backends:  map[string]*types.Backend{},
+				Frontends: map[string]*types.Frontend{},
+			},
+		},
+		{
+			desc: "one service with default rule and protocol",
+			services: map[string]*Service{
+				"whoami": {
+					Record:      "_http._tcp.whoami.example.com",
+					EntryPoints: []string{"http"},
+					HealthCheck: &types.HealthCheck{Path: "/health", Interval: "10s"},
+				},
+			},
+			targets: map[string][]srvTarget{
+				"whoami": {
+					{Host: "10.0.0.1", Port: 8080, Weight: 10},
+					{Host: "10.0.0.2", Port: 8080, Weight: 20},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-whoami": {
+						Servers: map[string]types.Server{
+							"server-10-0-0-1-8080": {URL: "http://10.0.0.1:8080", Weight: 10},
+							"server-10-0-0-2-8080": {URL: "http://10.0.0.2:8080", Weight: 20},
+						},
+						HealthCheck: &types.HealthCheck{Path: "/health", Interval: "10s"},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-whoami": {
+						Backend:        "backend-whoami",
+						EntryPoints:    []string{"http"},
+						PassHostHeader: true,
+						Routes: map[string]types.Route{
+							"route-whoami": {Rule: "Host:whoami.example.com"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "custom rule and protocol, backup targets ignored",
+			services: map[string]*Service{
+				"api": {
+					Record:   "_https._tcp.api.example.com",
+					Rule:     "PathPrefix:/api",
+					Protocol: "https",
+				},
+			},
+			targets: map[string][]srvTarget{
+				"api": {
+					{Host: "backup.example.com", Port: 443, Priority: 20},
+					{Host: "api1.example.com", Port: 443, Priority: 10},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-api": {
+						Servers: map[string]types.Server{
+							"server-api1-example-com-443": {URL: "https://api1.example.com:443"},
+						},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-api": {
+						Backend:        "backend-api",
+						PassHostHeader: true,
+						Routes: map[string]types.Route{
+							"route-api": {Rule: "PathPrefix:/api"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{Domain: "example.com", Services: test.services}
+
+			assert.Equal(t, test.expected, p.buildConfiguration(test.targets))
+		})
+	}
+}
+
+func TestLowestPriority(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		targets  []srvTarget
+		expected []srvTarget
+	}{
+		{
+			desc: "no target",
+		},
+		{
+			desc: "same priority",
+			targets: []srvTarget{
+				{Host: "a", Priority: 10},
+				{Host: "b", Priority: 10},
+			},
+			expected: []srvTarget{
+				{Host: "a", Priority: 10},
+				{Host: "b", Priority: 10},
+			},
+		},
+		{
+			desc: "lower priority after higher ones",
+			targets: []srvTarget{
+				{Host: "a", Priority: 20},
+				{Host: "b", Priority: 20},
+				{Host: "c", Priority: 10},
+				{Host: "d", Priority: 30},
+				{Host: "e", Priority: 10},
+			},
+			expected: []srvTarget{
+				{Host: "c", Priority: 10},
+				{Host: "e", Priority: 10},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, lowestPriority(test.targets))
+		})
+	}
+}
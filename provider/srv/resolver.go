@@ -0,0 +1,123 @@
+package srv
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const resolvConf = "/etc/resolv.conf"
+
+// srvTarget is a host/port pair returned by a SRV record.
+type srvTarget struct {
+	Host     string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+}
+
+// resolver resolves the targets of SRV records, with the TTL of the answer.
+type resolver interface {
+	LookupSRV(record string) ([]srvTarget, time.Duration, error)
+}
+
+type dnsResolver struct {
+	client      *dns.Client
+	nameservers []string
+}
+
+func newDNSResolver(nameservers []string, timeout time.Duration) (*dnsResolver, error) {
+	if len(nameservers) == 0 {
+		config, err := dns.ClientConfigFromFile(resolvConf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the nameservers from %s: %v", resolvConf, err)
+		}
+		for _, server := range config.Servers {
+			nameservers = append(nameservers, net.JoinHostPort(server, config.Port))
+		}
+	}
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf("no nameserver")
+	}
+
+	return &dnsResolver{
+		client:      &dns.Client{Timeout: timeout},
+		nameservers: nameservers,
+	}, nil
+}
+
+// LookupSRV queries the nameservers in order until one answers,
+// the addresses of the targets given in the additional section of the answer are used instead of their names.
+func (r *dnsResolver) LookupSRV(record string) ([]srvTarget, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(record), dns.TypeSRV)
+
+	var lastErr error
+	for _, nameserver := range r.nameservers {
+		answer, _, err := r.client.Exchange(msg, nameserver)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if answer.Rcode == dns.RcodeNameError {
+			// the record does not exist (anymore), the service has no target
+			return nil, 0, nil
+		}
+		if answer.Rcode != dns.RcodeSuccess {
+			lastErr = fmt.Errorf("%s answered %s for %s", nameserver, dns.RcodeToString[answer.Rcode], record)
+			continue
+		}
+		targets, ttl := parseSRVAnswer(answer)
+		return targets, ttl, nil
+	}
+	return nil, 0, lastErr
+}
+
+// parseSRVAnswer returns the targets of the answer and the lowest TTL of the records.
+func parseSRVAnswer(answer *dns.Msg) ([]srvTarget, time.Duration) {
+	addresses := make(map[string]string)
+	var ttl uint32
+	updateTTL := func(header *dns.RR_Header) {
+		if ttl == 0 || header.Ttl < ttl {
+			ttl = header.Ttl
+		}
+	}
+
+	for _, rr := range answer.Extra {
+		switch extra := rr.(type) {
+		case *dns.A:
+			addresses[extra.Hdr.Name] = extra.A.String()
+			updateTTL(&extra.Hdr)
+		case *dns.AAAA:
+			if _, ok := addresses[extra.Hdr.Name]; !ok {
+				addresses[extra.Hdr.Name] = extra.AAAA.String()
+				updateTTL(&extra.Hdr)
+			}
+		}
+	}
+
+	var targets []srvTarget
+	for _, rr := range answer.Answer {
+		record, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+		updateTTL(&record.Hdr)
+
+		host, ok := addresses[record.Target]
+		if !ok {
+			host = strings.TrimSuffix(record.Target, ".")
+		}
+		targets = append(targets, srvTarget{
+			Host:     host,
+			Port:     record.Port,
+			Priority: record.Priority,
+			Weight:   record.Weight,
+		})
+	}
+
+	return targets, time.Duration(ttl) * time.Second
+}
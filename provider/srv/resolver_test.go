@@ -0,0 +1,93 @@
+package srv
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSRVAnswer(t *testing.T) {
+	answer := new(dns.Msg)
+	answer.Answer = []dns.RR{
+		&dns.SRV{
+			Hdr:      dns.RR_Header{Name: "_http._tcp.whoami.example.com.", Rrtype: dns.TypeSRV, Ttl: 60},
+			Priority: 10,
+			Weight:   5,
+			Port:     8080,
+			Target:   "node1.example.com.",
+		},
+		&dns.SRV{
+			Hdr:      dns.RR_Header{Name: "_http._tcp.whoami.example.com.", Rrtype: dns.TypeSRV, Ttl: 30},
+			Priority: 20,
+			Weight:   1,
+			Port:     8081,
+			Target:   "node2.example.com.",
+		},
+	}
+	answer.Extra = []dns.RR{
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "node1.example.com.", Rrtype: dns.TypeA, Ttl: 120},
+			A:   net.ParseIP("10.0.0.1"),
+		},
+	}
+
+	targets, ttl := parseSRVAnswer(answer)
+
+	expected := []srvTarget{
+		{Host: "10.0.0.1", Port: 8080, Priority: 10, Weight: 5},
+		{Host: "node2.example.com", Port: 8081, Priority: 20, Weight: 1},
+	}
+	assert.Equal(t, expected, targets)
+	assert.Equal(t, 30*time.Second, ttl)
+}
+
+func TestDNSResolverLookupSRV(t *testing.T) {
+	mux := dns.NewServeMux()
+	mux.HandleFunc("whoami.example.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Answer = []dns.RR{
+			&dns.SRV{
+				Hdr:    dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 10},
+				Port:   8080,
+				Target: "node1.example.com.",
+			},
+		}
+		w.WriteMsg(m)
+	})
+	mux.HandleFunc("missing.example.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeNameError)
+		w.WriteMsg(m)
+	})
+	mux.HandleFunc("broken.example.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		w.WriteMsg(m)
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &dns.Server{PacketConn: conn, Handler: mux}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	r, err := newDNSResolver([]string{conn.LocalAddr().String()}, time.Second)
+	require.NoError(t, err)
+
+	targets, ttl, err := r.LookupSRV("whoami.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []srvTarget{{Host: "node1.example.com", Port: 8080}}, targets)
+	assert.Equal(t, 10*time.Second, ttl)
+
+	targets, _, err = r.LookupSRV("missing.example.com")
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	_, _, err = r.LookupSRV("broken.example.com")
+	assert.Error(t, err)
+}
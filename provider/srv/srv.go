@@ -0,0 +1,126 @@
+package srv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+const dnsTimeout = 5 * time.Second
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	Domain            string              `description:"Default domain used" export:"true"`
+	Nameservers       []string            `description:"Nameservers (host:port) resolving the SRV records, the ones of /etc/resolv.conf by default" export:"true"`
+	MinRefreshSeconds int                 `description:"Minimum interval between two resolutions of a record (in seconds)" export:"true"`
+	MaxRefreshSeconds int                 `description:"Maximum interval between two resolutions of a record (in seconds)" export:"true"`
+	Services          map[string]*Service `description:"Services resolved from SRV records" export:"true"`
+	resolver          resolver
+}
+
+// Service holds the SRV record of a service and the configuration of its frontend and backend.
+type Service struct {
+	Record      string             `description:"SRV record of the service" export:"true"`
+	Rule        string             `description:"Frontend rule, Host:{service}.{domain} by default" export:"true"`
+	Protocol    string             `description:"Protocol used to reach the targets, http by default" export:"true"`
+	EntryPoints []string           `description:"Entry points of the frontend" export:"true"`
+	HealthCheck *types.HealthCheck `description:"Health check of the targets" export:"true"`
+}
+
+type serviceTargets struct {
+	name    string
+	targets []srvTarget
+}
+
+// Provide allows the srv provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	if len(p.Services) == 0 {
+		return errors.New("no service defined for the srv provider")
+	}
+	for name, service := range p.Services {
+		if service == nil || len(service.Record) == 0 {
+			return fmt.Errorf("no SRV record defined for the service %s", name)
+		}
+	}
+
+	if p.resolver == nil {
+		dnsResolver, err := newDNSResolver(p.Nameservers, dnsTimeout)
+		if err != nil {
+			return err
+		}
+		p.resolver = dnsResolver
+	}
+
+	updates := make(chan serviceTargets)
+	for name, service := range p.Services {
+		name, record := name, service.Record
+		pool.Go(func(stop chan bool) {
+			p.watchService(stop, name, record, updates)
+		})
+	}
+
+	pool.Go(func(stop chan bool) {
+		targets := make(map[string][]srvTarget)
+		for {
+			select {
+			case <-stop:
+				return
+			case update := <-updates:
+				targets[update.name] = update.targets
+				configurationChan <- types.ConfigMessage{
+					ProviderName:  "srv",
+					Configuration: p.buildConfiguration(targets),
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// watchService resolves the SRV record of a service again once the TTL of the previous answer has expired.
+// The targets of the previous answer are kept when the resolution fails.
+func (p *Provider) watchService(stop chan bool, name, record string, updates chan<- serviceTargets) {
+	for {
+		targets, ttl, err := p.resolver.LookupSRV(record)
+		if err != nil {
+			log.Errorf("Error resolving the SRV record %s of the service %s: %v", record, name, err)
+		} else {
+			log.Debugf("SRV record %s of the service %s resolved to %d targets, TTL %s", record, name, len(targets), ttl)
+			select {
+			case updates <- serviceTargets{name: name, targets: targets}:
+			case <-stop:
+				return
+			}
+		}
+
+		timer := time.NewTimer(p.refreshInterval(ttl))
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// refreshInterval returns the TTL bounded by the minimum and maximum refresh intervals.
+func (p *Provider) refreshInterval(ttl time.Duration) time.Duration {
+	minRefresh := time.Duration(p.MinRefreshSeconds) * time.Second
+	maxRefresh := time.Duration(p.MaxRefreshSeconds) * time.Second
+	if ttl < minRefresh {
+		return minRefresh
+	}
+	if maxRefresh > 0 && ttl > maxRefresh {
+		return maxRefresh
+	}
+	return ttl
+}
@@ -0,0 +1,123 @@
+package srv
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	mutex   sync.Mutex
+	answers []fakeAnswer
+}
+
+type fakeAnswer struct {
+	targets []srvTarget
+	err     error
+}
+
+func (r *fakeResolver) LookupSRV(record string) ([]srvTarget, time.Duration, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	answer := r.answers[0]
+	if len(r.answers) > 1 {
+		r.answers = r.answers[1:]
+	}
+	return answer.targets, 0, answer.err
+}
+
+func TestRefreshInterval(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		ttl      time.Duration
+		expected time.Duration
+	}{
+		{
+			desc:     "TTL below the minimum",
+			ttl:      time.Second,
+			expected: 5 * time.Second,
+		},
+		{
+			desc:     "TTL between the bounds",
+			ttl:      time.Minute,
+			expected: time.Minute,
+		},
+		{
+			desc:     "TTL above the maximum",
+			ttl:      time.Hour,
+			expected: 5 * time.Minute,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{MinRefreshSeconds: 5, MaxRefreshSeconds: 300}
+
+			assert.Equal(t, test.expected, p.refreshInterval(test.ttl))
+		})
+	}
+}
+
+func TestProvideKeepsTargetsOnError(t *testing.T) {
+	p := &Provider{
+		Domain: "example.com",
+		Services: map[string]*Service{
+			"whoami": {Record: "_http._tcp.whoami.example.com"},
+		},
+		resolver: &fakeResolver{
+			answers: []fakeAnswer{
+				{targets: []srvTarget{{Host: "10.0.0.1", Port: 80}}},
+				{err: errors.New("timeout")},
+				{targets: []srvTarget{{Host: "10.0.0.2", Port: 80}}},
+			},
+		},
+	}
+
+	configurationChan := make(chan types.ConfigMessage)
+	pool := safe.NewPool(context.Background())
+
+	require.NoError(t, p.Provide(configurationChan, pool, nil))
+
+	for _, expected := range []string{"server-10-0-0-1-80", "server-10-0-0-2-80"} {
+		select {
+		case msg := <-configurationChan:
+			assert.Equal(t, "srv", msg.ProviderName)
+			require.Contains(t, msg.Configuration.Backends, "backend-whoami")
+			assert.Len(t, msg.Configuration.Backends["backend-whoami"].Servers, 1)
+			assert.Contains(t, msg.Configuration.Backends["backend-whoami"].Servers, expected)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no configuration received")
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-configurationChan:
+			case <-done:
+				return
+			}
+		}
+	}()
+	pool.Stop()
+	close(done)
+}
+
+func TestProvideWithoutService(t *testing.T) {
+	p := &Provider{}
+
+	err := p.Provide(make(chan types.ConfigMessage), safe.NewPool(context.Background()), nil)
+	assert.Error(t, err)
+}
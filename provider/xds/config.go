@@ -0,0 +1,250 @@
+package xds
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+)
+
+type serverEndpoint struct {
+	host   string
+	port   uint32
+	weight int
+}
+
+// buildConfiguration builds a backend for each cluster, and a frontend for each route of the virtual hosts
+// of the route configurations forwarding to a known cluster.
+func (p *Provider) buildConfiguration(clusters map[string]*cluster, assignments map[string]*clusterLoadAssignment, routeConfigurations map[string]*routeConfiguration) *types.Configuration {
+	configuration := &types.Configuration{
+		Backends:  make(map[string]*types.Backend),
+		Frontends: make(map[string]*types.Frontend),
+	}
+
+	for name, c := range clusters {
+		configuration.Backends[backendName(name)] = buildBackend(c, assignments)
+	}
+
+	for _, routeConfiguration := range routeConfigurations {
+		for _, vhost := range routeConfiguration.VirtualHosts {
+			hostRule, ok := buildHostRule(vhost.Domains)
+			if !ok {
+				log.Warnf("Skipping the virtual host %s of the route configuration %s: unsupported domains %v", vhost.Name, routeConfiguration.Name, vhost.Domains)
+				continue
+			}
+
+			for i, r := range vhost.Routes {
+				rule, err := buildRouteRule(r)
+				if err != nil {
+					log.Warnf("Skipping the route %d of the virtual host %s of the route configuration %s: %v", i, vhost.Name, routeConfiguration.Name, err)
+					continue
+				}
+				if _, ok := clusters[r.Route.Cluster]; !ok {
+					log.Debugf("Skipping the route %d of the virtual host %s of the route configuration %s: unknown cluster %s", i, vhost.Name, routeConfiguration.Name, r.Route.Cluster)
+					continue
+				}
+
+				if len(hostRule) > 0 {
+					rule = hostRule + ";" + rule
+				}
+				frontendName := "frontend-" + provider.Normalize(routeConfiguration.Name+"-"+vhost.Name+"-"+strconv.Itoa(i))
+				configuration.Frontends[frontendName] = &types.Frontend{
+					Backend:        backendName(r.Route.Cluster),
+					EntryPoints:    p.EntryPoints,
+					PassHostHeader: true,
+					// Envoy uses the first matching route of a virtual host
+					Priority: len(vhost.Routes) - i,
+					Routes: map[string]types.Route{
+						"route-" + provider.Normalize(strconv.Itoa(i)): {Rule: rule},
+					},
+				}
+			}
+		}
+	}
+
+	return configuration
+}
+
+func backendName(clusterName string) string {
+	return "backend-" + provider.Normalize(clusterName)
+}
+
+// buildBackend builds a backend from the endpoints of the cluster, reached over HTTPS when it has a TLS context.
+func buildBackend(c *cluster, assignments map[string]*clusterLoadAssignment) *types.Backend {
+	protocol := "http"
+	if c.TLSContext != nil {
+		protocol = "https"
+	}
+	method := "wrr"
+	if c.LbPolicy == lbPolicyLeastRequest {
+		method = "leastconn"
+	}
+
+	backend := &types.Backend{
+		Servers:      make(map[string]types.Server),
+		LoadBalancer: &types.LoadBalancer{Method: method},
+	}
+	for _, endpoint := range clusterEndpoints(c, assignments) {
+		address := net.JoinHostPort(endpoint.host, strconv.Itoa(int(endpoint.port)))
+		backend.Servers["server-"+provider.Normalize(address)] = types.Server{
+			URL:    protocol + "://" + address,
+			Weight: endpoint.weight,
+		}
+	}
+	return backend
+}
+
+// clusterEndpoints returns the endpoints of the cluster, from its EDS load assignment for an EDS cluster,
+// from its own load assignment or its hosts otherwise.
+func clusterEndpoints(c *cluster, assignments map[string]*clusterLoadAssignment) []serverEndpoint {
+	if c.Type == clusterTypeEDS {
+		return loadAssignmentEndpoints(assignments[edsServiceName(c)])
+	}
+	if c.LoadAssignment != nil {
+		return loadAssignmentEndpoints(c.LoadAssignment)
+	}
+
+	var endpoints []serverEndpoint
+	for _, host := range c.Hosts {
+		if host == nil || host.SocketAddress == nil {
+			continue
+		}
+		endpoints = append(endpoints, serverEndpoint{host: host.SocketAddress.Address, port: host.SocketAddress.PortValue, weight: 1})
+	}
+	return endpoints
+}
+
+// loadAssignmentEndpoints returns the healthy endpoints of the localities with the lowest priority having some,
+// the localities with a higher priority are only used when the lower ones have none.
+func loadAssignmentEndpoints(assignment *clusterLoadAssignment) []serverEndpoint {
+	if assignment == nil {
+		return nil
+	}
+
+	var endpoints []serverEndpoint
+	var priority uint32
+	for _, locality := range assignment.Endpoints {
+		if locality == nil || (len(endpoints) > 0 && locality.Priority > priority) {
+			continue
+		}
+
+		var localityEndpoints []serverEndpoint
+		for _, lbEndpoint := range locality.LbEndpoints {
+			if endpoint, ok := healthyEndpoint(lbEndpoint); ok {
+				localityEndpoints = append(localityEndpoints, endpoint)
+			}
+		}
+		if len(localityEndpoints) == 0 {
+			continue
+		}
+
+		if len(endpoints) > 0 && locality.Priority == priority {
+			endpoints = append(endpoints, localityEndpoints...)
+		} else {
+			endpoints = localityEndpoints
+			priority = locality.Priority
+		}
+	}
+	return endpoints
+}
+
+func healthyEndpoint(lbEndpoint *lbEndpoint) (serverEndpoint, bool) {
+	if lbEndpoint == nil || lbEndpoint.Endpoint == nil || lbEndpoint.Endpoint.Address == nil || lbEndpoint.Endpoint.Address.SocketAddress == nil {
+		return serverEndpoint{}, false
+	}
+	switch lbEndpoint.HealthStatus {
+	case healthStatusUnhealthy, healthStatusDraining, healthStatusTimeout:
+		return serverEndpoint{}, false
+	}
+
+	weight := 1
+	if lbEndpoint.LoadBalancingWeight != nil && lbEndpoint.LoadBalancingWeight.Value > 0 {
+		weight = int(lbEndpoint.LoadBalancingWeight.Value)
+	}
+	socketAddress := lbEndpoint.Endpoint.Address.SocketAddress
+	return serverEndpoint{host: socketAddress.Address, port: socketAddress.PortValue, weight: weight}, true
+}
+
+// buildHostRule builds the rule matching the domains of a virtual host, none for the "*" domain.
+// The domains with a wildcard prefix are matched with a HostRegexp rule.
+func buildHostRule(domains []string) (string, bool) {
+	var hosts []string
+	var wildcard bool
+	for _, domain := range domains {
+		switch {
+		case domain == "*":
+			return "", true
+		case strings.HasPrefix(domain, "*.") && !strings.Contains(domain[2:], "*"):
+			wildcard = true
+			hosts = append(hosts, "{subdomain:[a-zA-Z0-9-.]+}."+domain[2:])
+		case strings.Contains(domain, "*"):
+			return "", false
+		default:
+			hosts = append(hosts, domain)
+		}
+	}
+	if len(hosts) == 0 {
+		return "", false
+	}
+	if wildcard {
+		return "HostRegexp:" + strings.Join(hosts, ","), true
+	}
+	return "Host:" + strings.Join(hosts, ","), true
+}
+
+// buildRouteRule builds the rule matching the path and the headers of a route forwarding to a single cluster.
+func buildRouteRule(r *route) (string, error) {
+	if r == nil || r.Match == nil || r.Route == nil || len(r.Route.Cluster) == 0 {
+		return "", errors.New("route not forwarding to a single cluster")
+	}
+	if len(r.Match.Regex) > 0 {
+		return "", errors.New("unsupported regex path match")
+	}
+
+	var rules []string
+	switch {
+	case len(r.Match.Path) > 0:
+		if len(r.Route.PrefixRewrite) > 0 {
+			return "", errors.New("unsupported prefix rewrite of a path match")
+		}
+		rules = append(rules, "Path:"+r.Match.Path)
+	case len(r.Route.PrefixRewrite) == 0:
+		rules = append(rules, "PathPrefix:"+prefixOrRoot(r.Match.Prefix))
+	case r.Route.PrefixRewrite == "/":
+		rules = append(rules, "PathPrefixStrip:"+prefixOrRoot(r.Match.Prefix))
+	default:
+		return "", fmt.Errorf("unsupported prefix rewrite to %s", r.Route.PrefixRewrite)
+	}
+
+	for _, header := range r.Match.Headers {
+		if header == nil || strings.HasPrefix(header.Name, ":") {
+			return "", errors.New("unsupported pseudo header match")
+		}
+		switch {
+		case len(header.RegexMatch) > 0:
+			rules = append(rules, "HeadersRegexp:"+header.Name+","+header.RegexMatch)
+		case len(header.ExactMatch) > 0:
+			rules = append(rules, "Headers:"+header.Name+","+header.ExactMatch)
+		case len(header.Value) > 0 && header.Regex != nil && header.Regex.Value:
+			rules = append(rules, "HeadersRegexp:"+header.Name+","+header.Value)
+		case len(header.Value) > 0:
+			rules = append(rules, "Headers:"+header.Name+","+header.Value)
+		default:
+			return "", fmt.Errorf("unsupported presence match of the header %s", header.Name)
+		}
+	}
+
+	return strings.Join(rules, ";"), nil
+}
+
+func prefixOrRoot(prefix string) string {
+	if len(prefix) == 0 {
+		return "/"
+	}
+	return prefix
+}
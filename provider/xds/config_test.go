@@ -0,0 +1,374 @@
+package xds
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func socket(host string, port uint32) *address {
+	return &address{SocketAddress: &socketAddress{Address: host, PortValue: port}}
+}
+
+func TestBuildConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		entryPoints         []string
+		clusters            map[string]*cluster
+		assignments         map[string]*clusterLoadAssignment
+		routeConfigurations map[string]*routeConfiguration
+		expected            *types.Configuration
+	}{
+		{
+			desc: "no cluster",
+			expected: &types.Configuration{
+				Backends:  map[string]*types.Backend{},
+				Frontends: map[string]*types.Frontend{},
+			},
+		},
+		{
+			desc: "EDS cluster routed by host and prefix",
+			clusters: map[string]*cluster{
+				"whoami": {
+					Name:             "whoami",
+					Type:             clusterTypeEDS,
+					EdsClusterConfig: &edsClusterConfig{ServiceName: "whoami-eds"},
+					LbPolicy:         lbPolicyLeastRequest,
+				},
+			},
+			assignments: map[string]*clusterLoadAssignment{
+				"whoami-eds": {
+					ClusterName: "whoami-eds",
+					Endpoints: []*localityLbEndpoints{
+						{
+							LbEndpoints: []*lbEndpoint{
+								{Endpoint: &endpoint{Address: socket("10.0.0.1", 8080)}, LoadBalancingWeight: &uint32Value{Value: 10}},
+								{Endpoint: &endpoint{Address: socket("10.0.0.2", 8080)}},
+							},
+						},
+					},
+				},
+			},
+			routeConfigurations: map[string]*routeConfiguration{
+				"local": {
+					Name: "local",
+					VirtualHosts: []*virtualHost{
+						{
+							Name:    "whoami",
+							Domains: []string{"whoami.example.com", "www.whoami.example.com"},
+							Routes: []*route{
+								{
+									Match: &routeMatch{Prefix: "/api"},
+									Route: &routeAction{Cluster: "whoami"},
+								},
+								{
+									Match: &routeMatch{Prefix: "/"},
+									Route: &routeAction{Cluster: "whoami"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-whoami": {
+						Servers: map[string]types.Server{
+							"server-10-0-0-1-8080": {URL: "http://10.0.0.1:8080", Weight: 10},
+							"server-10-0-0-2-8080": {URL: "http://10.0.0.2:8080", Weight: 1},
+						},
+						LoadBalancer: &types.LoadBalancer{Method: "leastconn"},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-local-whoami-0": {
+						Backend:        "backend-whoami",
+						PassHostHeader: true,
+						Priority:       2,
+						Routes: map[string]types.Route{
+							"route-0": {Rule: "Host:whoami.example.com,www.whoami.example.com;PathPrefix:/api"},
+						},
+					},
+					"frontend-local-whoami-1": {
+						Backend:        "backend-whoami",
+						PassHostHeader: true,
+						Priority:       1,
+						Routes: map[string]types.Route{
+							"route-1": {Rule: "Host:whoami.example.com,www.whoami.example.com;PathPrefix:/"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:        "static TLS cluster on any domain, unknown cluster skipped",
+			entryPoints: []string{"https"},
+			clusters: map[string]*cluster{
+				"api": {
+					Name:       "api",
+					Type:       clusterTypeStatic,
+					Hosts:      []*address{socket("10.0.1.1", 443)},
+					TLSContext: &upstreamTLSContext{},
+				},
+			},
+			routeConfigurations: map[string]*routeConfiguration{
+				"edge": {
+					Name: "edge",
+					VirtualHosts: []*virtualHost{
+						{
+							Name:    "all",
+							Domains: []string{"*"},
+							Routes: []*route{
+								{
+									Match: &routeMatch{Path: "/missing"},
+									Route: &routeAction{Cluster: "missing"},
+								},
+								{
+									Match: &routeMatch{
+										Prefix:  "/api",
+										Headers: []*headerMatcher{{Name: "X-Version", ExactMatch: "2"}},
+									},
+									Route: &routeAction{Cluster: "api", PrefixRewrite: "/"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-api": {
+						Servers: map[string]types.Server{
+							"server-10-0-1-1-443": {URL: "https://10.0.1.1:443", Weight: 1},
+						},
+						LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+					},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-edge-all-1": {
+						Backend:        "backend-api",
+						EntryPoints:    []string{"https"},
+						PassHostHeader: true,
+						Priority:       1,
+						Routes: map[string]types.Route{
+							"route-1": {Rule: "PathPrefixStrip:/api;Headers:X-Version,2"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Provider{EntryPoints: test.entryPoints}
+
+			configuration := p.buildConfiguration(test.clusters, test.assignments, test.routeConfigurations)
+
+			assert.Equal(t, test.expected, configuration)
+		})
+	}
+}
+
+func TestLoadAssignmentEndpoints(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		assignment *clusterLoadAssignment
+		expected   []serverEndpoint
+	}{
+		{
+			desc: "no assignment",
+		},
+		{
+			desc: "unhealthy endpoints skipped",
+			assignment: &clusterLoadAssignment{
+				Endpoints: []*localityLbEndpoints{
+					{
+						LbEndpoints: []*lbEndpoint{
+							{Endpoint: &endpoint{Address: socket("10.0.0.1", 80)}, HealthStatus: healthStatusUnhealthy},
+							{Endpoint: &endpoint{Address: socket("10.0.0.2", 80)}, HealthStatus: healthStatusDraining},
+							{Endpoint: &endpoint{Address: socket("10.0.0.3", 80)}},
+						},
+					},
+				},
+			},
+			expected: []serverEndpoint{{host: "10.0.0.3", port: 80, weight: 1}},
+		},
+		{
+			desc: "lowest priority only",
+			assignment: &clusterLoadAssignment{
+				Endpoints: []*localityLbEndpoints{
+					{
+						Priority:    1,
+						LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.1.1", 80)}}},
+					},
+					{
+						LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.0.1", 80)}}},
+					},
+					{
+						LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.0.2", 80)}}},
+					},
+				},
+			},
+			expected: []serverEndpoint{
+				{host: "10.0.0.1", port: 80, weight: 1},
+				{host: "10.0.0.2", port: 80, weight: 1},
+			},
+		},
+		{
+			desc: "failover to a higher priority",
+			assignment: &clusterLoadAssignment{
+				Endpoints: []*localityLbEndpoints{
+					{
+						LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.0.1", 80)}, HealthStatus: healthStatusTimeout}},
+					},
+					{
+						Priority:    1,
+						LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.1.1", 80)}}},
+					},
+				},
+			},
+			expected: []serverEndpoint{{host: "10.0.1.1", port: 80, weight: 1}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, loadAssignmentEndpoints(test.assignment))
+		})
+	}
+}
+
+func TestBuildHostRule(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		domains    []string
+		expected   string
+		expectedOk bool
+	}{
+		{
+			desc:       "hosts",
+			domains:    []string{"example.com", "www.example.com"},
+			expected:   "Host:example.com,www.example.com",
+			expectedOk: true,
+		},
+		{
+			desc:       "any host",
+			domains:    []string{"example.com", "*"},
+			expectedOk: true,
+		},
+		{
+			desc:       "wildcard prefix",
+			domains:    []string{"example.com", "*.example.com"},
+			expected:   "HostRegexp:example.com,{subdomain:[a-zA-Z0-9-.]+}.example.com",
+			expectedOk: true,
+		},
+		{
+			desc:    "wildcard suffix",
+			domains: []string{"example.*"},
+		},
+		{
+			desc: "no domain",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rule, ok := buildHostRule(test.domains)
+
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expected, rule)
+		})
+	}
+}
+
+func TestBuildRouteRule(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		route         *route
+		expected      string
+		expectedError bool
+	}{
+		{
+			desc:     "empty prefix",
+			route:    &route{Match: &routeMatch{}, Route: &routeAction{Cluster: "whoami"}},
+			expected: "PathPrefix:/",
+		},
+		{
+			desc:     "path",
+			route:    &route{Match: &routeMatch{Path: "/health"}, Route: &routeAction{Cluster: "whoami"}},
+			expected: "Path:/health",
+		},
+		{
+			desc: "headers",
+			route: &route{
+				Match: &routeMatch{
+					Prefix: "/",
+					Headers: []*headerMatcher{
+						{Name: "X-Exact", ExactMatch: "a"},
+						{Name: "X-Regex", RegexMatch: "b.*"},
+						{Name: "X-Value", Value: "c"},
+						{Name: "X-Value-Regex", Value: "d.*", Regex: &boolValue{Value: true}},
+					},
+				},
+				Route: &routeAction{Cluster: "whoami"},
+			},
+			expected: "PathPrefix:/;Headers:X-Exact,a;HeadersRegexp:X-Regex,b.*;Headers:X-Value,c;HeadersRegexp:X-Value-Regex,d.*",
+		},
+		{
+			desc:          "regex path",
+			route:         &route{Match: &routeMatch{Regex: "/v[0-9]+/.*"}, Route: &routeAction{Cluster: "whoami"}},
+			expectedError: true,
+		},
+		{
+			desc:          "prefix rewrite",
+			route:         &route{Match: &routeMatch{Prefix: "/api"}, Route: &routeAction{Cluster: "whoami", PrefixRewrite: "/v2"}},
+			expectedError: true,
+		},
+		{
+			desc:          "weighted clusters",
+			route:         &route{Match: &routeMatch{Prefix: "/"}, Route: &routeAction{WeightedClusters: &weightedClusters{}}},
+			expectedError: true,
+		},
+		{
+			desc:          "redirect",
+			route:         &route{Match: &routeMatch{Prefix: "/"}},
+			expectedError: true,
+		},
+		{
+			desc: "pseudo header",
+			route: &route{
+				Match: &routeMatch{Prefix: "/", Headers: []*headerMatcher{{Name: ":method", ExactMatch: "GET"}}},
+				Route: &routeAction{Cluster: "whoami"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := buildRouteRule(test.route)
+
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, rule)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package xds
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// The Envoy data plane API is not vendored, these are the subsets of the envoy.api.v2 messages used by the provider,
+// with the field numbers of the Envoy protos. The fields which are not declared are skipped when decoding.
+// See https://github.com/envoyproxy/data-plane-api/tree/master/envoy/api/v2
+
+const (
+	typeURLPrefix                = "type.googleapis.com/envoy.api.v2."
+	typeURLCluster               = typeURLPrefix + "Cluster"
+	typeURLClusterLoadAssignment = typeURLPrefix + "ClusterLoadAssignment"
+	typeURLRouteConfiguration    = typeURLPrefix + "RouteConfiguration"
+
+	// Cluster.DiscoveryType
+	clusterTypeStatic     = 0
+	clusterTypeStrictDNS  = 1
+	clusterTypeLogicalDNS = 2
+	clusterTypeEDS        = 3
+
+	// Cluster.LbPolicy
+	lbPolicyLeastRequest = 1
+
+	// core.HealthStatus
+	healthStatusUnhealthy = 2
+	healthStatusDraining  = 3
+	healthStatusTimeout   = 4
+)
+
+type node struct {
+	ID      string `protobuf:"bytes,1,opt,name=id"`
+	Cluster string `protobuf:"bytes,2,opt,name=cluster"`
+}
+
+func (m *node) Reset()         { *m = node{} }
+func (m *node) String() string { return proto.CompactTextString(m) }
+func (*node) ProtoMessage()    {}
+
+type discoveryRequest struct {
+	VersionInfo   string   `protobuf:"bytes,1,opt,name=version_info,json=versionInfo"`
+	Node          *node    `protobuf:"bytes,2,opt,name=node"`
+	ResourceNames []string `protobuf:"bytes,3,rep,name=resource_names,json=resourceNames"`
+	TypeURL       string   `protobuf:"bytes,4,opt,name=type_url,json=typeUrl"`
+	ResponseNonce string   `protobuf:"bytes,5,opt,name=response_nonce,json=responseNonce"`
+}
+
+func (m *discoveryRequest) Reset()         { *m = discoveryRequest{} }
+func (m *discoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*discoveryRequest) ProtoMessage()    {}
+
+type discoveryResponse struct {
+	VersionInfo string     `protobuf:"bytes,1,opt,name=version_info,json=versionInfo"`
+	Resources   []*any.Any `protobuf:"bytes,2,rep,name=resources"`
+	TypeURL     string     `protobuf:"bytes,4,opt,name=type_url,json=typeUrl"`
+	Nonce       string     `protobuf:"bytes,5,opt,name=nonce"`
+}
+
+func (m *discoveryResponse) Reset()         { *m = discoveryResponse{} }
+func (m *discoveryResponse) String() string { return proto.CompactTextString(m) }
+func (*discoveryResponse) ProtoMessage()    {}
+
+type cluster struct {
+	Name             string                 `protobuf:"bytes,1,opt,name=name"`
+	Type             int32                  `protobuf:"varint,2,opt,name=type"`
+	EdsClusterConfig *edsClusterConfig      `protobuf:"bytes,3,opt,name=eds_cluster_config,json=edsClusterConfig"`
+	LbPolicy         int32                  `protobuf:"varint,6,opt,name=lb_policy,json=lbPolicy"`
+	Hosts            []*address             `protobuf:"bytes,7,rep,name=hosts"`
+	TLSContext       *upstreamTLSContext    `protobuf:"bytes,11,opt,name=tls_context,json=tlsContext"`
+	LoadAssignment   *clusterLoadAssignment `protobuf:"bytes,33,opt,name=load_assignment,json=loadAssignment"`
+}
+
+func (m *cluster) Reset()         { *m = cluster{} }
+func (m *cluster) String() string { return proto.CompactTextString(m) }
+func (*cluster) ProtoMessage()    {}
+
+type edsClusterConfig struct {
+	ServiceName string `protobuf:"bytes,2,opt,name=service_name,json=serviceName"`
+}
+
+func (m *edsClusterConfig) Reset()         { *m = edsClusterConfig{} }
+func (m *edsClusterConfig) String() string { return proto.CompactTextString(m) }
+func (*edsClusterConfig) ProtoMessage()    {}
+
+// upstreamTLSContext is only used to know whether the cluster is reached over TLS.
+type upstreamTLSContext struct{}
+
+func (m *upstreamTLSContext) Reset()         { *m = upstreamTLSContext{} }
+func (m *upstreamTLSContext) String() string { return proto.CompactTextString(m) }
+func (*upstreamTLSContext) ProtoMessage()    {}
+
+type clusterLoadAssignment struct {
+	ClusterName string                 `protobuf:"bytes,1,opt,name=cluster_name,json=clusterName"`
+	Endpoints   []*localityLbEndpoints `protobuf:"bytes,2,rep,name=endpoints"`
+}
+
+func (m *clusterLoadAssignment) Reset()         { *m = clusterLoadAssignment{} }
+func (m *clusterLoadAssignment) String() string { return proto.CompactTextString(m) }
+func (*clusterLoadAssignment) ProtoMessage()    {}
+
+type localityLbEndpoints struct {
+	LbEndpoints []*lbEndpoint `protobuf:"bytes,2,rep,name=lb_endpoints,json=lbEndpoints"`
+	Priority    uint32        `protobuf:"varint,5,opt,name=priority"`
+}
+
+func (m *localityLbEndpoints) Reset()         { *m = localityLbEndpoints{} }
+func (m *localityLbEndpoints) String() string { return proto.CompactTextString(m) }
+func (*localityLbEndpoints) ProtoMessage()    {}
+
+type lbEndpoint struct {
+	Endpoint            *endpoint    `protobuf:"bytes,1,opt,name=endpoint"`
+	HealthStatus        int32        `protobuf:"varint,2,opt,name=health_status,json=healthStatus"`
+	LoadBalancingWeight *uint32Value `protobuf:"bytes,4,opt,name=load_balancing_weight,json=loadBalancingWeight"`
+}
+
+func (m *lbEndpoint) Reset()         { *m = lbEndpoint{} }
+func (m *lbEndpoint) String() string { return proto.CompactTextString(m) }
+func (*lbEndpoint) ProtoMessage()    {}
+
+type endpoint struct {
+	Address *address `protobuf:"bytes,1,opt,name=address"`
+}
+
+func (m *endpoint) Reset()         { *m = endpoint{} }
+func (m *endpoint) String() string { return proto.CompactTextString(m) }
+func (*endpoint) ProtoMessage()    {}
+
+type address struct {
+	SocketAddress *socketAddress `protobuf:"bytes,1,opt,name=socket_address,json=socketAddress"`
+}
+
+func (m *address) Reset()         { *m = address{} }
+func (m *address) String() string { return proto.CompactTextString(m) }
+func (*address) ProtoMessage()    {}
+
+type socketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value,json=portValue"`
+}
+
+func (m *socketAddress) Reset()         { *m = socketAddress{} }
+func (m *socketAddress) String() string { return proto.CompactTextString(m) }
+func (*socketAddress) ProtoMessage()    {}
+
+type uint32Value struct {
+	Value uint32 `protobuf:"varint,1,opt,name=value"`
+}
+
+func (m *uint32Value) Reset()         { *m = uint32Value{} }
+func (m *uint32Value) String() string { return proto.CompactTextString(m) }
+func (*uint32Value) ProtoMessage()    {}
+
+type routeConfiguration struct {
+	Name         string         `protobuf:"bytes,1,opt,name=name"`
+	VirtualHosts []*virtualHost `protobuf:"bytes,2,rep,name=virtual_hosts,json=virtualHosts"`
+}
+
+func (m *routeConfiguration) Reset()         { *m = routeConfiguration{} }
+func (m *routeConfiguration) String() string { return proto.CompactTextString(m) }
+func (*routeConfiguration) ProtoMessage()    {}
+
+type virtualHost struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name"`
+	Domains []string `protobuf:"bytes,2,rep,name=domains"`
+	Routes  []*route `protobuf:"bytes,3,rep,name=routes"`
+}
+
+func (m *virtualHost) Reset()         { *m = virtualHost{} }
+func (m *virtualHost) String() string { return proto.CompactTextString(m) }
+func (*virtualHost) ProtoMessage()    {}
+
+type route struct {
+	Match *routeMatch  `protobuf:"bytes,1,opt,name=match"`
+	Route *routeAction `protobuf:"bytes,2,opt,name=route"`
+}
+
+func (m *route) Reset()         { *m = route{} }
+func (m *route) String() string { return proto.CompactTextString(m) }
+func (*route) ProtoMessage()    {}
+
+type routeMatch struct {
+	Prefix  string           `protobuf:"bytes,1,opt,name=prefix"`
+	Path    string           `protobuf:"bytes,2,opt,name=path"`
+	Regex   string           `protobuf:"bytes,3,opt,name=regex"`
+	Headers []*headerMatcher `protobuf:"bytes,6,rep,name=headers"`
+}
+
+func (m *routeMatch) Reset()         { *m = routeMatch{} }
+func (m *routeMatch) String() string { return proto.CompactTextString(m) }
+func (*routeMatch) ProtoMessage()    {}
+
+type headerMatcher struct {
+	Name       string     `protobuf:"bytes,1,opt,name=name"`
+	Value      string     `protobuf:"bytes,2,opt,name=value"`
+	Regex      *boolValue `protobuf:"bytes,3,opt,name=regex"`
+	ExactMatch string     `protobuf:"bytes,4,opt,name=exact_match,json=exactMatch"`
+	RegexMatch string     `protobuf:"bytes,5,opt,name=regex_match,json=regexMatch"`
+}
+
+func (m *headerMatcher) Reset()         { *m = headerMatcher{} }
+func (m *headerMatcher) String() string { return proto.CompactTextString(m) }
+func (*headerMatcher) ProtoMessage()    {}
+
+type boolValue struct {
+	Value bool `protobuf:"varint,1,opt,name=value"`
+}
+
+func (m *boolValue) Reset()         { *m = boolValue{} }
+func (m *boolValue) String() string { return proto.CompactTextString(m) }
+func (*boolValue) ProtoMessage()    {}
+
+type routeAction struct {
+	Cluster          string            `protobuf:"bytes,1,opt,name=cluster"`
+	WeightedClusters *weightedClusters `protobuf:"bytes,3,opt,name=weighted_clusters,json=weightedClusters"`
+	PrefixRewrite    string            `protobuf:"bytes,5,opt,name=prefix_rewrite,json=prefixRewrite"`
+}
+
+func (m *routeAction) Reset()         { *m = routeAction{} }
+func (m *routeAction) String() string { return proto.CompactTextString(m) }
+func (*routeAction) ProtoMessage()    {}
+
+// weightedClusters is only used to report the routes splitting the traffic, which are not supported.
+type weightedClusters struct{}
+
+func (m *weightedClusters) Reset()         { *m = weightedClusters{} }
+func (m *weightedClusters) String() string { return proto.CompactTextString(m) }
+func (*weightedClusters) ProtoMessage()    {}
@@ -0,0 +1,292 @@
+package xds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const adsMethod = "/envoy.service.discovery.v2.AggregatedDiscoveryService/StreamAggregatedResources"
+
+var adsStreamDesc = &grpc.StreamDesc{
+	StreamName:    "StreamAggregatedResources",
+	ClientStreams: true,
+	ServerStreams: true,
+}
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	Endpoint            string           `description:"Address (host:port) of the xDS management server" export:"true"`
+	NodeID              string           `description:"Node identifier sent to the management server" export:"true"`
+	NodeCluster         string           `description:"Node cluster sent to the management server" export:"true"`
+	RouteConfigurations []string         `description:"Names of the route configurations to subscribe to" export:"true"`
+	EntryPoints         []string         `description:"Entry points of the frontends" export:"true"`
+	TLS                 *types.ClientTLS `description:"Enable TLS support" export:"true"`
+}
+
+// Provide allows the xds provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	if len(p.Endpoint) == 0 {
+		return errors.New("no endpoint defined for the xds provider")
+	}
+	if len(p.RouteConfigurations) == 0 {
+		return errors.New("no route configuration defined for the xds provider")
+	}
+
+	dialOption := grpc.WithInsecure()
+	if p.TLS != nil {
+		tlsConfig, err := p.TLS.CreateTLSConfig()
+		if err != nil {
+			return err
+		}
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	conn, err := grpc.Dial(p.Endpoint, dialOption)
+	if err != nil {
+		return fmt.Errorf("unable to create the connection to the xDS management server %s: %v", p.Endpoint, err)
+	}
+
+	pool.Go(func(stop chan bool) {
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		safe.Go(func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		})
+
+		operation := func() error {
+			err := p.streamResources(ctx, conn, configurationChan)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		notify := func(err error, time time.Duration) {
+			log.Errorf("xDS management server %s stream error: %v; retrying in %s", p.Endpoint, err, time)
+		}
+		err := backoff.RetryNotify(safe.OperationWithRecover(operation), job.NewBackOff(backoff.NewExponentialBackOff()), notify)
+		if err != nil {
+			log.Errorf("Cannot stream from xDS management server %s: %v", p.Endpoint, err)
+		}
+	})
+
+	return nil
+}
+
+// subscription holds the resources requested for a type and the last accepted response.
+type subscription struct {
+	names   []string
+	version string
+	nonce   string
+}
+
+// adsSession holds the resources received on an ADS stream, they are all requested again on a new stream.
+type adsSession struct {
+	provider      *Provider
+	stream        grpc.ClientStream
+	node          *node
+	subscriptions map[string]*subscription
+
+	clustersReceived    bool
+	clusters            map[string]*cluster
+	assignments         map[string]*clusterLoadAssignment
+	routeConfigurations map[string]*routeConfiguration
+}
+
+// streamResources subscribes to the clusters and route configurations over an ADS stream,
+// and to the endpoints of the EDS clusters, until the stream fails.
+// A configuration is sent after each accepted response once the clusters are known.
+func (p *Provider) streamResources(ctx context.Context, conn *grpc.ClientConn, configurationChan chan<- types.ConfigMessage) error {
+	stream, err := grpc.NewClientStream(ctx, adsStreamDesc, conn, adsMethod)
+	if err != nil {
+		return err
+	}
+
+	s := &adsSession{
+		provider: p,
+		stream:   stream,
+		node:     &node{ID: p.NodeID, Cluster: p.NodeCluster},
+		subscriptions: map[string]*subscription{
+			typeURLCluster:               {},
+			typeURLClusterLoadAssignment: {},
+			typeURLRouteConfiguration:    {names: p.RouteConfigurations},
+		},
+	}
+
+	if err := s.send(typeURLCluster); err != nil {
+		return err
+	}
+	if err := s.send(typeURLRouteConfiguration); err != nil {
+		return err
+	}
+
+	for {
+		response := &discoveryResponse{}
+		if err := stream.RecvMsg(response); err != nil {
+			return err
+		}
+
+		sub, ok := s.subscriptions[response.TypeURL]
+		if !ok {
+			log.Debugf("Ignoring xDS response of unexpected type %s", response.TypeURL)
+			continue
+		}
+
+		if err := s.update(response); err != nil {
+			log.Errorf("Rejecting xDS response %s version %s: %v", response.TypeURL, response.VersionInfo, err)
+			// the previous version is requested again to reject the response
+			sub.nonce = response.Nonce
+			if err := s.send(response.TypeURL); err != nil {
+				return err
+			}
+			continue
+		}
+
+		log.Debugf("Accepting xDS response %s version %s with %d resources", response.TypeURL, response.VersionInfo, len(response.Resources))
+		sub.version = response.VersionInfo
+		sub.nonce = response.Nonce
+		if err := s.send(response.TypeURL); err != nil {
+			return err
+		}
+
+		if response.TypeURL == typeURLCluster {
+			if err := s.subscribeEndpoints(); err != nil {
+				return err
+			}
+		}
+
+		if s.clustersReceived {
+			select {
+			case configurationChan <- types.ConfigMessage{
+				ProviderName:  "xds",
+				Configuration: p.buildConfiguration(s.clusters, s.assignments, s.routeConfigurations),
+			}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// send requests the resources of the subscription of the type,
+// acknowledging the last response with its version and nonce.
+func (s *adsSession) send(typeURL string) error {
+	sub := s.subscriptions[typeURL]
+	return s.stream.SendMsg(&discoveryRequest{
+		VersionInfo:   sub.version,
+		Node:          s.node,
+		ResourceNames: sub.names,
+		TypeURL:       typeURL,
+		ResponseNonce: sub.nonce,
+	})
+}
+
+// subscribeEndpoints requests the endpoints of the EDS clusters when they changed.
+// No request is sent without EDS cluster, as an empty list of names would subscribe to all the endpoints.
+func (s *adsSession) subscribeEndpoints() error {
+	var names []string
+	for _, c := range s.clusters {
+		if c.Type == clusterTypeEDS {
+			names = append(names, edsServiceName(c))
+		}
+	}
+	sort.Strings(names)
+
+	sub := s.subscriptions[typeURLClusterLoadAssignment]
+	if len(names) == 0 || reflect.DeepEqual(names, sub.names) {
+		return nil
+	}
+	sub.names = names
+	return s.send(typeURLClusterLoadAssignment)
+}
+
+// update replaces the resources of the type of the response, the response is rejected as a whole
+// if one of its resources cannot be decoded.
+func (s *adsSession) update(response *discoveryResponse) error {
+	switch response.TypeURL {
+	case typeURLCluster:
+		clusters := make(map[string]*cluster)
+		err := decodeResources(response, func() proto.Message {
+			return &cluster{}
+		}, func(message proto.Message) {
+			c := message.(*cluster)
+			clusters[c.Name] = c
+		})
+		if err != nil {
+			return err
+		}
+		s.clusters = clusters
+		s.clustersReceived = true
+
+	case typeURLClusterLoadAssignment:
+		assignments := make(map[string]*clusterLoadAssignment)
+		err := decodeResources(response, func() proto.Message {
+			return &clusterLoadAssignment{}
+		}, func(message proto.Message) {
+			assignment := message.(*clusterLoadAssignment)
+			assignments[assignment.ClusterName] = assignment
+		})
+		if err != nil {
+			return err
+		}
+		s.assignments = assignments
+
+	case typeURLRouteConfiguration:
+		routeConfigurations := make(map[string]*routeConfiguration)
+		err := decodeResources(response, func() proto.Message {
+			return &routeConfiguration{}
+		}, func(message proto.Message) {
+			routeConfiguration := message.(*routeConfiguration)
+			routeConfigurations[routeConfiguration.Name] = routeConfiguration
+		})
+		if err != nil {
+			return err
+		}
+		s.routeConfigurations = routeConfigurations
+	}
+	return nil
+}
+
+func decodeResources(response *discoveryResponse, newMessage func() proto.Message, add func(proto.Message)) error {
+	for _, resource := range response.Resources {
+		if resource.TypeUrl != response.TypeURL {
+			return fmt.Errorf("resource of type %s in a response of type %s", resource.TypeUrl, response.TypeURL)
+		}
+		message := newMessage()
+		if err := proto.Unmarshal(resource.Value, message); err != nil {
+			return fmt.Errorf("unable to decode a resource of type %s: %v", resource.TypeUrl, err)
+		}
+		add(message)
+	}
+	return nil
+}
+
+// edsServiceName returns the name of the load assignment of an EDS cluster.
+func edsServiceName(c *cluster) string {
+	if c.EdsClusterConfig != nil && len(c.EdsClusterConfig.ServiceName) > 0 {
+		return c.EdsClusterConfig.ServiceName
+	}
+	return c.Name
+}
@@ -0,0 +1,216 @@
+package xds
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeManagementServer answers the new subscriptions with its resources, in version 1.
+type fakeManagementServer struct {
+	resources map[string][]proto.Message
+
+	mutex    sync.Mutex
+	requests []*discoveryRequest
+}
+
+func (s *fakeManagementServer) streamAggregatedResources(_ interface{}, stream grpc.ServerStream) error {
+	for {
+		request := &discoveryRequest{}
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+
+		s.mutex.Lock()
+		s.requests = append(s.requests, request)
+		s.mutex.Unlock()
+
+		if len(request.ResponseNonce) > 0 {
+			continue
+		}
+
+		response := &discoveryResponse{VersionInfo: "1", TypeURL: request.TypeURL, Nonce: "nonce-" + request.TypeURL}
+		for _, resource := range s.resources[request.TypeURL] {
+			value, err := proto.Marshal(resource)
+			if err != nil {
+				return err
+			}
+			response.Resources = append(response.Resources, &any.Any{TypeUrl: request.TypeURL, Value: value})
+		}
+		if err := stream.SendMsg(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *fakeManagementServer) getRequests() []*discoveryRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*discoveryRequest{}, s.requests...)
+}
+
+func startManagementServer(t *testing.T, managementServer *fakeManagementServer) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "envoy.service.discovery.v2.AggregatedDiscoveryService",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "StreamAggregatedResources",
+				Handler:       managementServer.streamAggregatedResources,
+				ServerStreams: true,
+				ClientStreams: true,
+			},
+		},
+	}, managementServer)
+	go server.Serve(listener)
+
+	return listener.Addr().String(), server.Stop
+}
+
+func TestProvide(t *testing.T) {
+	managementServer := &fakeManagementServer{
+		resources: map[string][]proto.Message{
+			typeURLCluster: {
+				&cluster{Name: "whoami", Type: clusterTypeEDS},
+			},
+			typeURLClusterLoadAssignment: {
+				&clusterLoadAssignment{
+					ClusterName: "whoami",
+					Endpoints: []*localityLbEndpoints{
+						{LbEndpoints: []*lbEndpoint{{Endpoint: &endpoint{Address: socket("10.0.0.1", 8080)}}}},
+					},
+				},
+			},
+			typeURLRouteConfiguration: {
+				&routeConfiguration{
+					Name: "local",
+					VirtualHosts: []*virtualHost{
+						{
+							Name:    "whoami",
+							Domains: []string{"whoami.example.com"},
+							Routes: []*route{
+								{Match: &routeMatch{Prefix: "/"}, Route: &routeAction{Cluster: "whoami"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	endpoint, stopServer := startManagementServer(t, managementServer)
+	defer stopServer()
+
+	p := &Provider{
+		Endpoint:            endpoint,
+		NodeID:              "traefik",
+		NodeCluster:         "edge",
+		RouteConfigurations: []string{"local"},
+	}
+
+	configurationChan := make(chan types.ConfigMessage)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	err := p.Provide(configurationChan, pool, nil)
+	require.NoError(t, err)
+
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend-whoami": {
+				Servers: map[string]types.Server{
+					"server-10-0-0-1-8080": {URL: "http://10.0.0.1:8080", Weight: 1},
+				},
+				LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"frontend-local-whoami-0": {
+				Backend:        "backend-whoami",
+				PassHostHeader: true,
+				Priority:       1,
+				Routes: map[string]types.Route{
+					"route-0": {Rule: "Host:whoami.example.com;PathPrefix:/"},
+				},
+			},
+		},
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		var configuration *types.Configuration
+		select {
+		case message := <-configurationChan:
+			assert.Equal(t, "xds", message.ProviderName)
+			configuration = message.Configuration
+		case <-timeout:
+			t.Fatal("Configuration not received")
+		}
+		if assert.ObjectsAreEqual(expected, configuration) {
+			break
+		}
+	}
+
+	var acknowledged map[string]*discoveryRequest
+	for i := 0; i < 50 && len(acknowledged) < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		acknowledged = make(map[string]*discoveryRequest)
+		for _, request := range managementServer.getRequests() {
+			assert.Equal(t, &node{ID: "traefik", Cluster: "edge"}, request.Node)
+			if len(request.ResponseNonce) > 0 {
+				acknowledged[request.TypeURL] = request
+			}
+		}
+	}
+	require.Len(t, acknowledged, 3)
+	assert.Equal(t, "1", acknowledged[typeURLCluster].VersionInfo)
+	assert.Equal(t, "nonce-"+typeURLCluster, acknowledged[typeURLCluster].ResponseNonce)
+	assert.Equal(t, []string{"whoami"}, acknowledged[typeURLClusterLoadAssignment].ResourceNames)
+	assert.Equal(t, []string{"local"}, acknowledged[typeURLRouteConfiguration].ResourceNames)
+}
+
+func TestUpdateRejectsResponse(t *testing.T) {
+	s := &adsSession{
+		clusters: map[string]*cluster{"whoami": {Name: "whoami"}},
+	}
+
+	err := s.update(&discoveryResponse{
+		TypeURL: typeURLCluster,
+		Resources: []*any.Any{
+			{TypeUrl: typeURLCluster, Value: []byte{0x0a, 0x02, 'a'}},
+		},
+	})
+	require.Error(t, err)
+
+	err = s.update(&discoveryResponse{
+		TypeURL: typeURLCluster,
+		Resources: []*any.Any{
+			{TypeUrl: typeURLRouteConfiguration},
+		},
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, map[string]*cluster{"whoami": {Name: "whoami"}}, s.clusters)
+}
+
+func TestProvideWithoutRouteConfiguration(t *testing.T) {
+	p := &Provider{Endpoint: "127.0.0.1:18000"}
+
+	err := p.Provide(make(chan types.ConfigMessage), safe.NewPool(context.Background()), nil)
+
+	assert.Error(t, err)
+}
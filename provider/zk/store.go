@@ -0,0 +1,481 @@
+package zk
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The store of the provider is the zookeeper store of libkv, connecting with TLS and authenticating
+// with the digest scheme, which the store of libkv doesn't.
+
+const (
+	// soh is the value of the znodes written non-atomically by the old versions of libkv
+	soh = "\x01"
+
+	defaultTimeout = 10 * time.Second
+
+	syncRetryLimit = 5
+)
+
+// zkClient is the part of the zookeeper connection used by the store, implemented by *zk.Conn.
+type zkClient interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Delete(path string, version int32) error
+	Exists(path string) (bool, *zk.Stat, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Sync(path string) (string, error)
+	Multi(ops ...interface{}) ([]zk.MultiResponse, error)
+	AddAuth(scheme string, auth []byte) error
+	Close()
+}
+
+// zkLocker is a distributed lock on a znode, implemented by *zk.Lock.
+type zkLocker interface {
+	Lock() error
+	Unlock() error
+}
+
+// zkStore implements the libkv store on a zookeeper connection.
+type zkStore struct {
+	client  zkClient
+	newLock func(path string) zkLocker
+}
+
+type zkLock struct {
+	client zkClient
+	lock   zkLocker
+	key    string
+	value  []byte
+}
+
+func registerStore() {
+	libkv.AddStore(store.ZK, newStore)
+}
+
+// newStore connects to the zookeeper endpoints, with TLS if configured,
+// and authenticates with the digest scheme if a username is configured.
+func newStore(endpoints []string, options *store.Config) (store.Store, error) {
+	timeout := defaultTimeout
+	var dialer zk.Dialer = net.DialTimeout
+	if options != nil {
+		if options.ConnectionTimeout != 0 {
+			timeout = options.ConnectionTimeout
+		}
+		if options.TLS != nil {
+			dialer = tlsDialer(options.TLS)
+		}
+	}
+
+	conn, _, err := zk.Connect(endpoints, timeout, zk.WithDialer(dialer))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authenticate(conn, options); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &zkStore{
+		client: conn,
+		newLock: func(path string) zkLocker {
+			return zk.NewLock(conn, path, zk.WorldACL(zk.PermAll))
+		},
+	}, nil
+}
+
+// authenticate submits the credentials of the options with the digest scheme, if a username is configured.
+// The credentials are submitted again by the client when it reconnects.
+func authenticate(client zkClient, options *store.Config) error {
+	if options == nil || len(options.Username) == 0 {
+		return nil
+	}
+	return client.AddAuth("digest", []byte(options.Username+":"+options.Password))
+}
+
+// tlsDialer returns a dialer establishing the TLS connections to zookeeper.
+func tlsDialer(config *tls.Config) zk.Dialer {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, address, config)
+	}
+}
+
+// Get returns the value of the key, and its version used by the atomic operations.
+func (s *zkStore) Get(key string, opts *store.ReadOptions) (*store.KVPair, error) {
+	resp, meta, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return &store.KVPair{Key: key, Value: resp, LastIndex: uint64(meta.Version)}, nil
+}
+
+// createFullPath creates the missing znodes of the path, setting the value of the last one.
+func (s *zkStore) createFullPath(path []string, data []byte, ephemeral bool) error {
+	for i := 1; i <= len(path); i++ {
+		newPath := "/" + strings.Join(path[:i], "/")
+
+		if i == len(path) {
+			var flag int32
+			if ephemeral {
+				flag = zk.FlagEphemeral
+			}
+			_, err := s.client.Create(newPath, data, flag, zk.WorldACL(zk.PermAll))
+			return err
+		}
+
+		if _, err := s.client.Create(newPath, []byte{}, 0, zk.WorldACL(zk.PermAll)); err != nil && err != zk.ErrNodeExists {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put sets the value of the key, creating its znode if needed.
+func (s *zkStore) Put(key string, value []byte, opts *store.WriteOptions) error {
+	exists, err := s.Exists(key, nil)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err = s.client.Set(s.normalize(key), value, -1)
+		return err
+	}
+	return s.createFullPath(store.SplitKey(strings.TrimSuffix(key, "/")), value, opts != nil && opts.TTL > 0)
+}
+
+// Delete removes the key.
+func (s *zkStore) Delete(key string) error {
+	err := s.client.Delete(s.normalize(key), -1)
+	if err == zk.ErrNoNode {
+		return store.ErrKeyNotFound
+	}
+	return err
+}
+
+// Exists checks whether the key exists.
+func (s *zkStore) Exists(key string, opts *store.ReadOptions) (bool, error) {
+	exists, _, err := s.client.Exists(s.normalize(key))
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Watch sends the value of the key, then its changes, until the stop channel is closed.
+func (s *zkStore) Watch(key string, stopCh <-chan struct{}, opts *store.ReadOptions) (<-chan *store.KVPair, error) {
+	watchCh := make(chan *store.KVPair)
+	go func() {
+		defer close(watchCh)
+
+		fireEvent := true
+		for {
+			resp, meta, eventCh, err := s.getW(key)
+			if err != nil {
+				return
+			}
+			if fireEvent {
+				watchCh <- &store.KVPair{Key: key, Value: resp, LastIndex: uint64(meta.Version)}
+			}
+			select {
+			case event := <-eventCh:
+				// the other events, e.g. of the session, only reset the watch
+				fireEvent = event.Type == zk.EventNodeDataChanged
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return watchCh, nil
+}
+
+// WatchTree sends the values of the children of the directory, then their changes, until the stop channel is closed.
+func (s *zkStore) WatchTree(directory string, stopCh <-chan struct{}, opts *store.ReadOptions) (<-chan []*store.KVPair, error) {
+	watchCh := make(chan []*store.KVPair)
+	go func() {
+		defer close(watchCh)
+
+		fireEvent := true
+		for {
+			keys, _, eventCh, err := s.client.ChildrenW(s.normalize(directory))
+			if err != nil {
+				return
+			}
+			if fireEvent {
+				kvs, err := s.getListWithPath(directory, keys)
+				if err != nil {
+					// a child changed in the meantime, the list is read again
+					continue
+				}
+				watchCh <- kvs
+			}
+			select {
+			case event := <-eventCh:
+				// the other events, e.g. of the session, only reset the watch
+				fireEvent = event.Type == zk.EventNodeChildrenChanged
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return watchCh, nil
+}
+
+func (s *zkStore) listChildren(directory string) ([]string, error) {
+	children, _, err := s.client.Children(s.normalize(directory))
+	if err == zk.ErrNoNode {
+		return nil, store.ErrKeyNotFound
+	}
+	return children, err
+}
+
+// listChildrenRecursive appends the descendants of the directory to the list.
+func (s *zkStore) listChildrenRecursive(list *[]string, directory string) error {
+	children, err := s.listChildren(directory)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		child = strings.TrimSuffix(directory, "/") + "/" + child
+		if err := s.listChildrenRecursive(list, child); err != nil && err != zk.ErrNoChildrenForEphemerals {
+			return err
+		}
+		*list = append(*list, child)
+	}
+	return nil
+}
+
+// List returns the descendants of the directory.
+func (s *zkStore) List(directory string, opts *store.ReadOptions) ([]*store.KVPair, error) {
+	var children []string
+	if err := s.listChildrenRecursive(&children, directory); err != nil {
+		return nil, err
+	}
+
+	kvs, err := s.getList(children)
+	if err == store.ErrKeyNotFound {
+		// a descendant was removed in the meantime, the list is read again
+		return s.List(directory, opts)
+	}
+	return kvs, err
+}
+
+// DeleteTree removes the children of the directory.
+func (s *zkStore) DeleteTree(directory string) error {
+	children, err := s.listChildren(directory)
+	if err != nil {
+		return err
+	}
+
+	var requests []interface{}
+	for _, child := range children {
+		requests = append(requests, &zk.DeleteRequest{Path: s.normalize(directory + "/" + child), Version: -1})
+	}
+	_, err = s.client.Multi(requests...)
+	return err
+}
+
+// AtomicPut sets the value of the key if it's still at the version of previous, or creates it if previous is nil.
+func (s *zkStore) AtomicPut(key string, value []byte, previous *store.KVPair, _ *store.WriteOptions) (bool, *store.KVPair, error) {
+	if previous != nil {
+		meta, err := s.client.Set(s.normalize(key), value, int32(previous.LastIndex))
+		if err == zk.ErrBadVersion {
+			return false, nil, store.ErrKeyModified
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		return true, &store.KVPair{Key: key, Value: value, LastIndex: uint64(meta.Version)}, nil
+	}
+
+	_, err := s.client.Create(s.normalize(key), value, 0, zk.WorldACL(zk.PermAll))
+	if err == zk.ErrNoNode {
+		// the directory of the key is created first
+		parts := store.SplitKey(strings.TrimSuffix(key, "/"))
+		if err = s.createFullPath(parts[:len(parts)-1], []byte{}, false); err != nil {
+			return false, nil, err
+		}
+		_, err = s.client.Create(s.normalize(key), value, 0, zk.WorldACL(zk.PermAll))
+	}
+	if err == zk.ErrNodeExists {
+		return false, nil, store.ErrKeyExists
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	// the created znodes are at the version 0
+	return true, &store.KVPair{Key: key, Value: value, LastIndex: 0}, nil
+}
+
+// AtomicDelete removes the key if it's still at the version of previous.
+func (s *zkStore) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	if previous == nil {
+		return false, store.ErrPreviousNotSpecified
+	}
+
+	err := s.client.Delete(s.normalize(key), int32(previous.LastIndex))
+	switch err {
+	case nil:
+		return true, nil
+	case zk.ErrNoNode:
+		return false, store.ErrKeyNotFound
+	case zk.ErrBadVersion:
+		return false, store.ErrKeyModified
+	default:
+		return false, err
+	}
+}
+
+// NewLock returns a lock on the key, setting its value once acquired.
+func (s *zkStore) NewLock(key string, options *store.LockOptions) (store.Locker, error) {
+	value := []byte("")
+	if options != nil && options.Value != nil {
+		value = options.Value
+	}
+
+	return &zkLock{
+		client: s.client,
+		key:    s.normalize(key),
+		value:  value,
+		lock:   s.newLock(s.normalize(key)),
+	}, nil
+}
+
+// Close closes the connection.
+func (s *zkStore) Close() {
+	s.client.Close()
+}
+
+func (s *zkStore) normalize(key string) string {
+	return strings.TrimSuffix(store.Normalize(key), "/")
+}
+
+// get returns the value of the key, synchronizing the znodes written non-atomically by the old versions of libkv.
+func (s *zkStore) get(key string) ([]byte, *zk.Stat, error) {
+	var resp []byte
+	var meta *zk.Stat
+	var err error
+	for i := 0; i <= syncRetryLimit; i++ {
+		resp, meta, err = s.client.Get(s.normalize(key))
+		if err == zk.ErrNoNode {
+			return nil, nil, store.ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if string(resp) != soh && string(resp) != "" {
+			break
+		}
+		if i < syncRetryLimit {
+			if _, err = s.client.Sync(s.normalize(key)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return resp, meta, nil
+}
+
+// getW returns the value of the key and watches it, like get.
+func (s *zkStore) getW(key string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	var resp []byte
+	var meta *zk.Stat
+	var eventCh <-chan zk.Event
+	var err error
+	for i := 0; i <= syncRetryLimit; i++ {
+		resp, meta, eventCh, err = s.client.GetW(s.normalize(key))
+		if err == zk.ErrNoNode {
+			return nil, nil, nil, store.ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if string(resp) != soh && string(resp) != "" {
+			break
+		}
+		if i < syncRetryLimit {
+			if _, err = s.client.Sync(s.normalize(key)); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	return resp, meta, eventCh, nil
+}
+
+// getListWithPath returns the pairs of the keys relative to the path, e.g. the children of a directory.
+func (s *zkStore) getListWithPath(path string, keys []string) ([]*store.KVPair, error) {
+	kvs := []*store.KVPair{}
+	for _, key := range keys {
+		pair, err := s.Get(strings.TrimSuffix(path, "/")+s.normalize(key), nil)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, &store.KVPair{Key: key, Value: pair.Value, LastIndex: pair.LastIndex})
+	}
+	return kvs, nil
+}
+
+// getList returns the pairs of the full keys.
+func (s *zkStore) getList(keys []string) ([]*store.KVPair, error) {
+	kvs := []*store.KVPair{}
+	for _, key := range keys {
+		pair, err := s.Get(strings.TrimSuffix(key, "/"), nil)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, &store.KVPair{Key: key, Value: pair.Value, LastIndex: pair.LastIndex})
+	}
+	return kvs, nil
+}
+
+// Lock acquires the lock, blocking meanwhile, and returns a channel closed when the lock is lost.
+func (l *zkLock) Lock(stopChan chan struct{}) (<-chan struct{}, error) {
+	err := l.lock.Lock()
+
+	lostCh := make(chan struct{})
+	if err == nil {
+		if _, err = l.client.Set(l.key, l.value, -1); err == nil {
+			go l.monitorLock(stopChan, lostCh)
+		}
+	}
+	return lostCh, err
+}
+
+// Unlock releases the lock.
+func (l *zkLock) Unlock() error {
+	return l.lock.Unlock()
+}
+
+// monitorLock closes the lost channel when the lock is lost, or released on the stop channel.
+func (l *zkLock) monitorLock(stopCh <-chan struct{}, lostCh chan struct{}) {
+	defer close(lostCh)
+
+	for {
+		_, _, eventCh, err := l.client.GetW(l.key)
+		if err != nil {
+			return
+		}
+		select {
+		case event := <-eventCh:
+			// the session closed or expired, or another client wrote the lock believing it holds it
+			if event.Type == zk.EventNotWatching ||
+				(event.Type == zk.EventSession && event.State == zk.StateExpired) ||
+				event.Type == zk.EventNodeDataChanged {
+				return
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,768 @@
+package zk
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/libkv/store"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSDialer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	testCases := []struct {
+		desc          string
+		config        *tls.Config
+		expectedError bool
+	}{
+		{
+			desc:   "trusted server",
+			config: server.Client().Transport.(*http.Transport).TLSClientConfig,
+		},
+		{
+			desc:          "untrusted server",
+			config:        &tls.Config{ServerName: "example.com"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			conn, err := tlsDialer(test.config)("tcp", server.Listener.Addr().String(), time.Second)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.IsType(t, &tls.Conn{}, conn)
+				conn.Close()
+			}
+		})
+	}
+}
+
+func newFakeStore(client *fakeClient, nodes map[string]string) *zkStore {
+	var paths []string
+	for path := range nodes {
+		paths = append(paths, path)
+	}
+	// the parents are created before their children
+	sort.Strings(paths)
+	for _, path := range paths {
+		client.nodes[path] = &fakeNode{data: []byte(nodes[path])}
+	}
+
+	return &zkStore{
+		client: client,
+		newLock: func(path string) zkLocker {
+			return &fakeLocker{client: client, path: path}
+		},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		options         *store.Config
+		expectedScheme  string
+		expectedPayload string
+	}{
+		{
+			desc:    "no options",
+			options: nil,
+		},
+		{
+			desc:    "no username",
+			options: &store.Config{},
+		},
+		{
+			desc:            "digest",
+			options:         &store.Config{Username: "user", Password: "pass"},
+			expectedScheme:  "digest",
+			expectedPayload: "user:pass",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeClient()
+			require.NoError(t, authenticate(client, test.options))
+
+			assert.Equal(t, test.expectedScheme, client.authScheme)
+			assert.Equal(t, test.expectedPayload, string(client.authPayload))
+		})
+	}
+}
+
+func TestStorePut(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		nodes           map[string]string
+		expectedVersion uint64
+	}{
+		{
+			desc:            "missing path",
+			expectedVersion: 0,
+		},
+		{
+			desc:            "missing key",
+			nodes:           map[string]string{"/traefik": ""},
+			expectedVersion: 0,
+		},
+		{
+			desc:            "existing key",
+			nodes:           map[string]string{"/traefik": "", "/traefik/foo": "old"},
+			expectedVersion: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			kv := newFakeStore(newFakeClient(), test.nodes)
+			require.NoError(t, kv.Put("traefik/foo", []byte("bar"), nil))
+
+			exists, err := kv.Exists("traefik/foo", nil)
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			pair, err := kv.Get("traefik/foo", nil)
+			require.NoError(t, err)
+			assert.Equal(t, &store.KVPair{Key: "traefik/foo", Value: []byte("bar"), LastIndex: test.expectedVersion}, pair)
+		})
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		nodes         map[string]string
+		syncedData    map[string][]byte
+		expectedValue string
+		expectedError error
+	}{
+		{
+			desc:          "value",
+			nodes:         map[string]string{"/foo": "bar"},
+			expectedValue: "bar",
+		},
+		{
+			desc:          "value written non-atomically",
+			nodes:         map[string]string{"/foo": soh},
+			syncedData:    map[string][]byte{"/foo": []byte("bar")},
+			expectedValue: "bar",
+		},
+		{
+			desc:          "empty value",
+			nodes:         map[string]string{"/foo": ""},
+			expectedValue: "",
+		},
+		{
+			desc:          "missing key",
+			expectedError: store.ErrKeyNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeClient()
+			for path, data := range test.syncedData {
+				client.syncedData[path] = data
+			}
+			kv := newFakeStore(client, test.nodes)
+
+			pair, err := kv.Get("foo", nil)
+			if test.expectedError != nil {
+				assert.Equal(t, test.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedValue, string(pair.Value))
+		})
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		nodes         map[string]string
+		expectedError error
+	}{
+		{
+			desc:  "existing key",
+			nodes: map[string]string{"/foo": "bar"},
+		},
+		{
+			desc:          "missing key",
+			expectedError: store.ErrKeyNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			kv := newFakeStore(newFakeClient(), test.nodes)
+
+			err := kv.Delete("foo")
+			assert.Equal(t, test.expectedError, err)
+
+			exists, err := kv.Exists("foo", nil)
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	kv := newFakeStore(newFakeClient(), map[string]string{
+		"/traefik":                  "",
+		"/traefik/backends":         "",
+		"/traefik/backends/foo":     "",
+		"/traefik/backends/foo/url": "http://127.0.0.1",
+		"/traefik/loglevel":         "DEBUG",
+		"/other":                    "",
+	})
+
+	kvs, err := kv.List("traefik", nil)
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, pair := range kvs {
+		values[pair.Key] = string(pair.Value)
+	}
+	assert.Equal(t, map[string]string{
+		"traefik/backends":         "",
+		"traefik/backends/foo":     "",
+		"traefik/backends/foo/url": "http://127.0.0.1",
+		"traefik/loglevel":         "DEBUG",
+	}, values)
+
+	_, err = kv.List("missing", nil)
+	assert.Equal(t, store.ErrKeyNotFound, err)
+}
+
+func TestStoreDeleteTree(t *testing.T) {
+	kv := newFakeStore(newFakeClient(), map[string]string{
+		"/traefik":     "",
+		"/traefik/foo": "1",
+		"/traefik/bar": "2",
+	})
+
+	require.NoError(t, kv.DeleteTree("traefik"))
+
+	kvs, err := kv.List("traefik", nil)
+	require.NoError(t, err)
+	assert.Empty(t, kvs)
+
+	assert.Equal(t, store.ErrKeyNotFound, kv.DeleteTree("missing"))
+}
+
+func TestStoreAtomicPut(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		nodes           map[string]string
+		previous        *store.KVPair
+		expectedVersion uint64
+		expectedError   error
+	}{
+		{
+			desc:            "create with missing path",
+			expectedVersion: 0,
+		},
+		{
+			desc:            "create",
+			nodes:           map[string]string{"/traefik": ""},
+			expectedVersion: 0,
+		},
+		{
+			desc:          "create existing key",
+			nodes:         map[string]string{"/traefik": "", "/traefik/foo": "old"},
+			expectedError: store.ErrKeyExists,
+		},
+		{
+			desc:            "update",
+			nodes:           map[string]string{"/traefik": "", "/traefik/foo": "old"},
+			previous:        &store.KVPair{Key: "traefik/foo", Value: []byte("old"), LastIndex: 0},
+			expectedVersion: 1,
+		},
+		{
+			desc:          "update modified key",
+			nodes:         map[string]string{"/traefik": "", "/traefik/foo": "old"},
+			previous:      &store.KVPair{Key: "traefik/foo", Value: []byte("older"), LastIndex: 3},
+			expectedError: store.ErrKeyModified,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			kv := newFakeStore(newFakeClient(), test.nodes)
+
+			ok, pair, err := kv.AtomicPut("traefik/foo", []byte("bar"), test.previous, nil)
+			if test.expectedError != nil {
+				assert.Equal(t, test.expectedError, err)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, &store.KVPair{Key: "traefik/foo", Value: []byte("bar"), LastIndex: test.expectedVersion}, pair)
+
+			stored, err := kv.Get("traefik/foo", nil)
+			require.NoError(t, err)
+			assert.Equal(t, pair, stored)
+		})
+	}
+}
+
+func TestStoreAtomicDelete(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		nodes         map[string]string
+		previous      *store.KVPair
+		expectedError error
+	}{
+		{
+			desc:     "delete",
+			nodes:    map[string]string{"/foo": "bar"},
+			previous: &store.KVPair{Key: "foo", Value: []byte("bar"), LastIndex: 0},
+		},
+		{
+			desc:          "no previous",
+			nodes:         map[string]string{"/foo": "bar"},
+			expectedError: store.ErrPreviousNotSpecified,
+		},
+		{
+			desc:          "missing key",
+			previous:      &store.KVPair{Key: "foo", Value: []byte("bar"), LastIndex: 0},
+			expectedError: store.ErrKeyNotFound,
+		},
+		{
+			desc:          "modified key",
+			nodes:         map[string]string{"/foo": "bar"},
+			previous:      &store.KVPair{Key: "foo", Value: []byte("old"), LastIndex: 2},
+			expectedError: store.ErrKeyModified,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			kv := newFakeStore(newFakeClient(), test.nodes)
+
+			ok, err := kv.AtomicDelete("foo", test.previous)
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedError == nil, ok)
+		})
+	}
+}
+
+func TestStoreWatch(t *testing.T) {
+	kv := newFakeStore(newFakeClient(), map[string]string{"/foo": "bar"})
+
+	stopCh := make(chan struct{})
+	watchCh, err := kv.Watch("foo", stopCh, nil)
+	require.NoError(t, err)
+
+	pair := receivePair(t, watchCh)
+	assert.Equal(t, &store.KVPair{Key: "foo", Value: []byte("bar"), LastIndex: 0}, pair)
+
+	require.NoError(t, kv.Put("foo", []byte("baz"), nil))
+	pair = receivePair(t, watchCh)
+	assert.Equal(t, &store.KVPair{Key: "foo", Value: []byte("baz"), LastIndex: 1}, pair)
+
+	close(stopCh)
+	select {
+	case _, ok := <-watchCh:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("the watch was not stopped")
+	}
+}
+
+func TestStoreWatchTree(t *testing.T) {
+	kv := newFakeStore(newFakeClient(), map[string]string{
+		"/traefik":     "",
+		"/traefik/foo": "1",
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	watchCh, err := kv.WatchTree("traefik", stopCh, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"foo": "1"}, receiveList(t, watchCh))
+
+	require.NoError(t, kv.Put("traefik/bar", []byte("2"), nil))
+	assert.Equal(t, map[string]string{"foo": "1", "bar": "2"}, receiveList(t, watchCh))
+
+	require.NoError(t, kv.Delete("traefik/foo"))
+	assert.Equal(t, map[string]string{"bar": "2"}, receiveList(t, watchCh))
+}
+
+func TestStoreLock(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		options       *store.LockOptions
+		expectedValue string
+	}{
+		{
+			desc:          "no value",
+			expectedValue: "",
+		},
+		{
+			desc:          "value",
+			options:       &store.LockOptions{Value: []byte("node1")},
+			expectedValue: "node1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeClient()
+			kv := newFakeStore(client, nil)
+
+			locker, err := kv.NewLock("lock", test.options)
+			require.NoError(t, err)
+
+			lostCh, err := locker.Lock(nil)
+			require.NoError(t, err)
+
+			lock := locker.(*zkLock).lock.(*fakeLocker)
+			assert.True(t, lock.locked)
+			value, _, err := client.Get("/lock")
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedValue, string(value))
+
+			// another client writing the lock believes it holds it
+			client.waitWatch(t, "/lock")
+			_, err = client.Set("/lock", []byte("node2"), -1)
+			require.NoError(t, err)
+			select {
+			case <-lostCh:
+			case <-time.After(time.Second):
+				t.Fatal("the lock was not lost")
+			}
+
+			require.NoError(t, locker.Unlock())
+			assert.False(t, lock.locked)
+		})
+	}
+}
+
+func TestStoreLockStop(t *testing.T) {
+	kv := newFakeStore(newFakeClient(), nil)
+
+	locker, err := kv.NewLock("lock", nil)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	lostCh, err := locker.Lock(stopCh)
+	require.NoError(t, err)
+
+	close(stopCh)
+	select {
+	case <-lostCh:
+	case <-time.After(time.Second):
+		t.Fatal("the lock was not released")
+	}
+}
+
+func receivePair(t *testing.T, watchCh <-chan *store.KVPair) *store.KVPair {
+	t.Helper()
+
+	select {
+	case pair := <-watchCh:
+		return pair
+	case <-time.After(time.Second):
+		t.Fatal("no value received")
+		return nil
+	}
+}
+
+func receiveList(t *testing.T, watchCh <-chan []*store.KVPair) map[string]string {
+	t.Helper()
+
+	select {
+	case kvs := <-watchCh:
+		values := make(map[string]string)
+		for _, pair := range kvs {
+			values[pair.Key] = string(pair.Value)
+		}
+		return values
+	case <-time.After(time.Second):
+		t.Fatal("no list received")
+		return nil
+	}
+}
+
+// fakeClient is an in-memory zookeeper client.
+type fakeClient struct {
+	mutex        sync.Mutex
+	nodes        map[string]*fakeNode
+	dataWatches  map[string][]chan zk.Event
+	childWatches map[string][]chan zk.Event
+	syncedData   map[string][]byte
+	authScheme   string
+	authPayload  []byte
+	closed       bool
+}
+
+type fakeNode struct {
+	data    []byte
+	version int32
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		nodes:        map[string]*fakeNode{"/": {}},
+		dataWatches:  make(map[string][]chan zk.Event),
+		childWatches: make(map[string][]chan zk.Event),
+		syncedData:   make(map[string][]byte),
+	}
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		return path[:i]
+	}
+	return "/"
+}
+
+// fire sends the event to the watches of the path, a watch being triggered once.
+func fire(watches map[string][]chan zk.Event, path string, eventType zk.EventType) {
+	for _, watch := range watches[path] {
+		watch <- zk.Event{Type: eventType, Path: path}
+	}
+	delete(watches, path)
+}
+
+func (c *fakeClient) watch(watches map[string][]chan zk.Event, path string) <-chan zk.Event {
+	watch := make(chan zk.Event, 1)
+	watches[path] = append(watches[path], watch)
+	return watch
+}
+
+// waitWatch waits for a watch of the data of the path.
+func (c *fakeClient) waitWatch(t *testing.T, path string) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.mutex.Lock()
+		watched := len(c.dataWatches[path]) > 0
+		c.mutex.Unlock()
+		if watched {
+			return
+		}
+	}
+	t.Fatalf("%s was not watched", path)
+}
+
+func (c *fakeClient) Get(path string) ([]byte, *zk.Stat, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, ok := c.nodes[path]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return node.data, &zk.Stat{Version: node.version}, nil
+}
+
+func (c *fakeClient) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, ok := c.nodes[path]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return node.data, &zk.Stat{Version: node.version}, c.watch(c.dataWatches, path), nil
+}
+
+func (c *fakeClient) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, ok := c.nodes[path]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	if version != -1 && version != node.version {
+		return nil, zk.ErrBadVersion
+	}
+	node.data = data
+	node.version++
+	fire(c.dataWatches, path, zk.EventNodeDataChanged)
+	return &zk.Stat{Version: node.version}, nil
+}
+
+func (c *fakeClient) Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.nodes[path]; ok {
+		return "", zk.ErrNodeExists
+	}
+	if _, ok := c.nodes[parentPath(path)]; !ok {
+		return "", zk.ErrNoNode
+	}
+	c.nodes[path] = &fakeNode{data: data}
+	fire(c.childWatches, parentPath(path), zk.EventNodeChildrenChanged)
+	return path, nil
+}
+
+func (c *fakeClient) Delete(path string, version int32) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.delete(path, version)
+}
+
+func (c *fakeClient) delete(path string, version int32) error {
+	node, ok := c.nodes[path]
+	if !ok {
+		return zk.ErrNoNode
+	}
+	if version != -1 && version != node.version {
+		return zk.ErrBadVersion
+	}
+	if len(c.children(path)) > 0 {
+		return zk.ErrNotEmpty
+	}
+	delete(c.nodes, path)
+	fire(c.dataWatches, path, zk.EventNodeDeleted)
+	fire(c.childWatches, parentPath(path), zk.EventNodeChildrenChanged)
+	return nil
+}
+
+func (c *fakeClient) Exists(path string) (bool, *zk.Stat, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, ok := c.nodes[path]
+	if !ok {
+		return false, nil, nil
+	}
+	return true, &zk.Stat{Version: node.version}, nil
+}
+
+func (c *fakeClient) children(path string) []string {
+	var children []string
+	for nodePath := range c.nodes {
+		if nodePath != "/" && parentPath(nodePath) == path {
+			children = append(children, nodePath[strings.LastIndex(nodePath, "/")+1:])
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+func (c *fakeClient) Children(path string) ([]string, *zk.Stat, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.nodes[path]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return c.children(path), &zk.Stat{}, nil
+}
+
+func (c *fakeClient) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.nodes[path]; !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return c.children(path), &zk.Stat{}, c.watch(c.childWatches, path), nil
+}
+
+// Sync applies the data set for the path with syncedData, as if it was written on another server.
+func (c *fakeClient) Sync(path string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if data, ok := c.syncedData[path]; ok {
+		c.nodes[path].data = data
+		delete(c.syncedData, path)
+	}
+	return path, nil
+}
+
+func (c *fakeClient) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	responses := make([]zk.MultiResponse, len(ops))
+	for i, op := range ops {
+		request, ok := op.(*zk.DeleteRequest)
+		if !ok {
+			return nil, fmt.Errorf("unsupported operation %T", op)
+		}
+		if err := c.delete(request.Path, request.Version); err != nil {
+			responses[i].Error = err
+			return responses, err
+		}
+	}
+	return responses, nil
+}
+
+func (c *fakeClient) AddAuth(scheme string, auth []byte) error {
+	c.authScheme = scheme
+	c.authPayload = auth
+	return nil
+}
+
+func (c *fakeClient) Close() {
+	c.closed = true
+}
+
+// fakeLocker is a lock always acquired at once, creating its znode like the lock of the client.
+type fakeLocker struct {
+	client *fakeClient
+	path   string
+	locked bool
+}
+
+func (l *fakeLocker) Lock() error {
+	if _, err := l.client.Create(l.path, []byte{}, 0, zk.WorldACL(zk.PermAll)); err != nil && err != zk.ErrNodeExists {
+		return err
+	}
+	l.locked = true
+	return nil
+}
+
+func (l *fakeLocker) Unlock() error {
+	l.locked = false
+	return nil
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/docker/libkv/store"
-	"github.com/docker/libkv/store/zookeeper"
 )
 
 var _ provider.Provider = (*Provider)(nil)
@@ -32,6 +31,6 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 // CreateStore creates the KV store
 func (p *Provider) CreateStore() (store.Store, error) {
 	p.SetStoreType(store.ZK)
-	zookeeper.Register()
+	registerStore()
 	return p.Provider.CreateStore()
 }
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+
+	"github.com/containous/traefik/acme"
+)
+
+// defaultACMEResolver is the name of the resolver configured by the acme section, used by the frontends selecting no resolver.
+const defaultACMEResolver = ""
+
+// acmeResolvers returns the ACME configurations, by resolver name.
+func (s *Server) acmeResolvers() map[string]*acme.ACME {
+	resolvers := make(map[string]*acme.ACME)
+	if s.globalConfiguration.ACME != nil {
+		resolvers[defaultACMEResolver] = s.globalConfiguration.ACME
+	}
+	for name, resolver := range s.globalConfiguration.ACMEResolvers {
+		if resolver != nil {
+			resolvers[name] = resolver
+		}
+	}
+	return resolvers
+}
+
+// sortedACMEResolverNames returns the names of the resolvers, the default resolver first.
+func sortedACMEResolverNames(resolvers map[string]*acme.ACME) []string {
+	var names []string
+	for name := range resolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// httpChallengeResolvers returns the resolvers answering their HTTP-01 challenges on the entry point.
+func (s *Server) httpChallengeResolvers(entryPointName string) []*acme.ACME {
+	resolvers := s.acmeResolvers()
+	var challengeResolvers []*acme.ACME
+	for _, name := range sortedACMEResolverNames(resolvers) {
+		resolver := resolvers[name]
+		if resolver.HTTPChallenge != nil && resolver.HTTPChallenge.EntryPoint == entryPointName {
+			challengeResolvers = append(challengeResolvers, resolver)
+		}
+	}
+	return challengeResolvers
+}
+
+// checkACMEResolvers checks that each resolver stores its account and certificates apart.
+func checkACMEResolvers(resolvers map[string]*acme.ACME) error {
+	storages := make(map[string]string)
+	for _, name := range sortedACMEResolverNames(resolvers) {
+		storage := resolvers[name].Storage
+		if len(storage) == 0 {
+			storage = resolvers[name].StorageFile
+		}
+		if len(storage) == 0 {
+			continue
+		}
+		if other, ok := storages[storage]; ok {
+			return fmt.Errorf("the ACME resolvers %q and %q use the same storage %s", other, name, storage)
+		}
+		storages[storage] = name
+	}
+	return nil
+}
+
+// firstCertificate returns the certificate of the first function returning one.
+func firstCertificate(getCertificates ...func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		for _, getCertificate := range getCertificates {
+			certificate, err := getCertificate(clientHello)
+			if certificate != nil || err != nil {
+				return certificate, err
+			}
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/containous/traefik/acme"
+	"github.com/containous/traefik/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACMEResolvers(t *testing.T) {
+	defaultResolver := &acme.ACME{Storage: "acme.json"}
+	staging := &acme.ACME{Storage: "acme-staging.json"}
+
+	server := &Server{globalConfiguration: configuration.GlobalConfiguration{
+		ACME: defaultResolver,
+		ACMEResolvers: map[string]*acme.ACME{
+			"staging": staging,
+			"empty":   nil,
+		},
+	}}
+
+	resolvers := server.acmeResolvers()
+	assert.Equal(t, map[string]*acme.ACME{defaultACMEResolver: defaultResolver, "staging": staging}, resolvers)
+	assert.Equal(t, []string{defaultACMEResolver, "staging"}, sortedACMEResolverNames(resolvers))
+}
+
+func TestCheckACMEResolvers(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		resolvers     map[string]*acme.ACME
+		expectedError bool
+	}{
+		{
+			desc: "distinct storages",
+			resolvers: map[string]*acme.ACME{
+				defaultACMEResolver: {Storage: "acme.json"},
+				"staging":           {Storage: "acme-staging.json"},
+			},
+		},
+		{
+			desc: "same storage",
+			resolvers: map[string]*acme.ACME{
+				defaultACMEResolver: {Storage: "acme.json"},
+				"staging":           {Storage: "acme.json"},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "same deprecated storage file",
+			resolvers: map[string]*acme.ACME{
+				"production": {StorageFile: "acme.json"},
+				"staging":    {Storage: "acme.json"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkACMEResolvers(test.resolvers)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFirstCertificate(t *testing.T) {
+	foo := &tls.Certificate{}
+	none := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }
+	found := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return foo, nil }
+	failed := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, errors.New("failed") }
+
+	certificate, err := firstCertificate(none, found, failed)(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.True(t, certificate == foo)
+
+	_, err = firstCertificate(none, failed, found)(&tls.ClientHelloInfo{})
+	assert.Error(t, err)
+
+	certificate, err = firstCertificate(none, none)(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Nil(t, certificate)
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+)
+
+// The kinds of the alerts.
+const (
+	alertErrorRatio        = "errorRatio"
+	alertUnhealthyServers  = "unhealthyServers"
+	alertCertificateExpiry = "certificateExpiry"
+)
+
+// The status of the alerts.
+const (
+	alertFiring   = "firing"
+	alertResolved = "resolved"
+)
+
+// The payload templates of the alerts.
+const (
+	alertTemplateJSON      = "json"
+	alertTemplateSlack     = "slack"
+	alertTemplatePagerDuty = "pagerduty"
+)
+
+// alertEvent is an alert condition starting or resolved.
+// The target is the backend, or the entry point and the domain of a certificate, the alert is about.
+type alertEvent struct {
+	Status    string    `json:"status"`
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (e alertEvent) key() string {
+	return e.Kind + "/" + e.Target
+}
+
+// alerter evaluates periodically the alert conditions, and posts an event to the webhook
+// when a condition starts and when it is resolved.
+type alerter struct {
+	config           *configuration.Alerts
+	interval         time.Duration
+	poster           *webhookPoster
+	recorder         *middlewares.BackendStatsRecorder
+	certificates     func() map[string][]*tls.Certificate
+	unhealthyServers func() map[string][]string
+	firing           map[string]alertEvent
+	now              func() time.Time
+}
+
+func newAlerter(config *configuration.Alerts, certificates func() map[string][]*tls.Certificate, unhealthyServers func() map[string][]string) (*alerter, error) {
+	switch config.Template {
+	case "", alertTemplateJSON, alertTemplateSlack:
+	case alertTemplatePagerDuty:
+		if len(config.RoutingKey) == 0 {
+			return nil, fmt.Errorf("the routing key is required by the %s template", alertTemplatePagerDuty)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alert template: %s", config.Template)
+	}
+
+	interval := time.Duration(config.Interval)
+	if interval <= 0 {
+		interval = configuration.DefaultAlertsInterval
+	}
+	window := time.Duration(config.ErrorWindow)
+	if window <= 0 {
+		window = configuration.DefaultAlertsErrorWindow
+	}
+
+	return &alerter{
+		config:           config,
+		interval:         interval,
+		poster:           newWebhookPoster(webhookAlertEvent, config.Secret, time.Duration(config.Timeout)),
+		recorder:         middlewares.NewBackendStatsRecorder(window, window),
+		certificates:     certificates,
+		unhealthyServers: unhealthyServers,
+		firing:           make(map[string]alertEvent),
+		now:              time.Now,
+	}, nil
+}
+
+// run evaluates the alert conditions and posts the events until the context is done.
+func (a *alerter) run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, event := range a.evaluate() {
+				if err := a.post(ctx, event); err != nil {
+					log.Errorf("Unable to post the %s alert of %s to the webhook: %v", event.Kind, event.Target, err)
+				}
+			}
+		}
+	}
+}
+
+// evaluate returns the events of the conditions which started or were resolved since the previous evaluation.
+func (a *alerter) evaluate() []alertEvent {
+	now := a.now().UTC()
+
+	current := make(map[string]alertEvent)
+	for _, event := range a.conditions(now) {
+		event.Timestamp = now
+		current[event.key()] = event
+	}
+
+	var events []alertEvent
+	for key, event := range current {
+		if _, ok := a.firing[key]; !ok {
+			event.Status = alertFiring
+			events = append(events, event)
+			a.firing[key] = event
+		}
+	}
+	for key, event := range a.firing {
+		if _, ok := current[key]; !ok {
+			event.Status = alertResolved
+			event.Message = "Resolved: " + event.Message
+			event.Timestamp = now
+			events = append(events, event)
+			delete(a.firing, key)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].key() < events[j].key()
+	})
+	return events
+}
+
+// conditions returns the alert conditions currently met.
+func (a *alerter) conditions(now time.Time) []alertEvent {
+	var events []alertEvent
+
+	if a.config.ErrorRatio > 0 {
+		for backendName, servers := range a.recorder.Data() {
+			var requests, failed int64
+			for _, stats := range servers {
+				requests += stats.Requests
+				failed += stats.Errors
+			}
+			if requests == 0 || requests < int64(a.config.MinRequests) {
+				continue
+			}
+			ratio := float64(failed) / float64(requests)
+			if ratio > a.config.ErrorRatio {
+				events = append(events, alertEvent{
+					Kind:      alertErrorRatio,
+					Target:    backendName,
+					Message:   fmt.Sprintf("The backend %s answered %.1f%% of %d requests with a 5xx status", backendName, ratio*100, requests),
+					Value:     ratio,
+					Threshold: a.config.ErrorRatio,
+				})
+			}
+		}
+	}
+
+	if a.config.UnhealthyServers > 0 && a.unhealthyServers != nil {
+		for backendName, servers := range a.unhealthyServers() {
+			if len(servers) >= a.config.UnhealthyServers {
+				events = append(events, alertEvent{
+					Kind:      alertUnhealthyServers,
+					Target:    backendName,
+					Message:   fmt.Sprintf("%d servers of the backend %s fail their health check: %v", len(servers), backendName, servers),
+					Value:     float64(len(servers)),
+					Threshold: float64(a.config.UnhealthyServers),
+				})
+			}
+		}
+	}
+
+	if a.config.CertificateExpiry > 0 && a.certificates != nil {
+		for entryPointName, certificates := range a.certificates() {
+			for domain, notAfter := range certificatesExpiration(entryPointName, certificates) {
+				remaining := notAfter.Sub(now)
+				if remaining < time.Duration(a.config.CertificateExpiry) {
+					events = append(events, alertEvent{
+						Kind:      alertCertificateExpiry,
+						Target:    entryPointName + "/" + domain,
+						Message:   fmt.Sprintf("The certificate of %s on the entry point %s expires on %s", domain, entryPointName, notAfter.UTC().Format(time.RFC3339)),
+						Value:     remaining.Seconds(),
+						Threshold: time.Duration(a.config.CertificateExpiry).Seconds(),
+					})
+				}
+			}
+		}
+	}
+
+	return events
+}
+
+func (a *alerter) post(ctx context.Context, event alertEvent) error {
+	payload, err := json.Marshal(a.payload(event))
+	if err != nil {
+		return err
+	}
+	return a.poster.post(ctx, a.config.URL, payload)
+}
+
+// payload formats the event for the template of the webhook:
+// the event as is, a Slack incoming webhook message or a PagerDuty Events API v2 event.
+func (a *alerter) payload(event alertEvent) interface{} {
+	switch a.config.Template {
+	case alertTemplateSlack:
+		prefix := ":rotating_light:"
+		if event.Status == alertResolved {
+			prefix = ":white_check_mark:"
+		}
+		return map[string]string{"text": prefix + " " + event.Message}
+
+	case alertTemplatePagerDuty:
+		action := "trigger"
+		if event.Status == alertResolved {
+			action = "resolve"
+		}
+		return map[string]interface{}{
+			"routing_key":  a.config.RoutingKey,
+			"event_action": action,
+			"dedup_key":    "traefik/" + event.key(),
+			"payload": map[string]interface{}{
+				"summary":   event.Message,
+				"source":    event.Target,
+				"severity":  "error",
+				"component": "traefik",
+				"class":     event.Kind,
+				"timestamp": event.Timestamp.Format(time.RFC3339),
+				"custom_details": map[string]float64{
+					"value":     event.Value,
+					"threshold": event.Threshold,
+				},
+			},
+		}
+
+	default:
+		return event
+	}
+}
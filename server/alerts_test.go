@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlerterEvaluate(t *testing.T) {
+	certificate, err := tls.X509KeyPair([]byte(localhostCert), []byte(localhostKey))
+	require.NoError(t, err)
+
+	unhealthyServers := map[string][]string{}
+	a, err := newAlerter(&configuration.Alerts{
+		URL:              "http://127.0.0.1/alerts",
+		ErrorRatio:       0.1,
+		MinRequests:      10,
+		UnhealthyServers: 2,
+	}, func() map[string][]*tls.Certificate {
+		return map[string][]*tls.Certificate{"https": {&certificate}}
+	}, func() map[string][]string {
+		return unhealthyServers
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 9; i++ {
+		a.recorder.Record("backend1", "http://10.0.0.1:80", time.Millisecond, i < 5)
+	}
+	assert.Empty(t, a.evaluate(), "too few requests")
+
+	a.recorder.Record("backend1", "http://10.0.0.1:80", time.Millisecond, false)
+	a.recorder.Record("backend2", "http://10.0.0.2:80", time.Millisecond, false)
+	unhealthyServers["backend2"] = []string{"http://10.0.0.2:80", "http://10.0.0.3:80"}
+	events := a.evaluate()
+	require.Len(t, events, 2)
+	assert.Equal(t, alertFiring, events[0].Status)
+	assert.Equal(t, alertErrorRatio, events[0].Kind)
+	assert.Equal(t, "backend1", events[0].Target)
+	assert.Equal(t, 0.5, events[0].Value)
+	assert.Equal(t, alertFiring, events[1].Status)
+	assert.Equal(t, alertUnhealthyServers, events[1].Kind)
+	assert.Equal(t, "backend2", events[1].Target)
+
+	assert.Empty(t, a.evaluate(), "the alerts already firing are not posted again")
+
+	unhealthyServers["backend2"] = []string{"http://10.0.0.2:80"}
+	events = a.evaluate()
+	require.Len(t, events, 1)
+	assert.Equal(t, alertResolved, events[0].Status)
+	assert.Equal(t, alertUnhealthyServers, events[0].Kind)
+
+	// the test certificate expires on Jan 29 2084 at 16:00
+	a.now = func() time.Time { return time.Date(2084, time.January, 29, 15, 0, 0, 0, time.UTC) }
+	a.config.CertificateExpiry = flaeg.Duration(2 * time.Hour)
+	events = a.evaluate()
+	require.Len(t, events, 1)
+	assert.Equal(t, alertCertificateExpiry, events[0].Kind)
+	assert.Equal(t, "https/example.com", events[0].Target)
+}
+
+func TestAlerterPayload(t *testing.T) {
+	event := alertEvent{
+		Status:    alertResolved,
+		Kind:      alertErrorRatio,
+		Target:    "backend1",
+		Message:   "Resolved: the backend backend1 answered 50.0% of 10 requests with a 5xx status",
+		Value:     0.5,
+		Threshold: 0.1,
+		Timestamp: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		desc     string
+		config   configuration.Alerts
+		expected string
+	}{
+		{
+			desc:     "json",
+			expected: `{"status":"resolved","kind":"errorRatio","target":"backend1","message":"Resolved: the backend backend1 answered 50.0% of 10 requests with a 5xx status","value":0.5,"threshold":0.1,"timestamp":"2018-01-01T00:00:00Z"}`,
+		},
+		{
+			desc:     "slack",
+			config:   configuration.Alerts{Template: alertTemplateSlack},
+			expected: `{"text":":white_check_mark: Resolved: the backend backend1 answered 50.0% of 10 requests with a 5xx status"}`,
+		},
+		{
+			desc:   "pagerduty",
+			config: configuration.Alerts{Template: alertTemplatePagerDuty, RoutingKey: "key"},
+			expected: `{
+				"routing_key": "key",
+				"event_action": "resolve",
+				"dedup_key": "traefik/errorRatio/backend1",
+				"payload": {
+					"summary": "Resolved: the backend backend1 answered 50.0% of 10 requests with a 5xx status",
+					"source": "backend1",
+					"severity": "error",
+					"component": "traefik",
+					"class": "errorRatio",
+					"timestamp": "2018-01-01T00:00:00Z",
+					"custom_details": {"value": 0.5, "threshold": 0.1}
+				}
+			}`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := newAlerter(&test.config, nil, nil)
+			require.NoError(t, err)
+
+			payload, err := json.Marshal(a.payload(event))
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(payload))
+		})
+	}
+}
+
+func TestAlerterPost(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "alert", req.Header.Get("X-Traefik-Event"))
+		assert.Equal(t, "sha256="+signPayload([]byte("secret"), body), req.Header.Get("X-Traefik-Signature"))
+		bodies <- body
+	}))
+	defer server.Close()
+
+	a, err := newAlerter(&configuration.Alerts{URL: server.URL, Template: alertTemplateSlack, Secret: "secret"}, nil, nil)
+	require.NoError(t, err)
+
+	err = a.post(context.Background(), alertEvent{Status: alertFiring, Message: "The backend backend1 is failing"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":":rotating_light: The backend backend1 is failing"}`, string(<-bodies))
+}
+
+func TestNewAlerter(t *testing.T) {
+	_, err := newAlerter(&configuration.Alerts{Template: alertTemplatePagerDuty}, nil, nil)
+	assert.Error(t, err, "the routing key is required")
+
+	_, err = newAlerter(&configuration.Alerts{Template: "opsgenie"}, nil, nil)
+	assert.Error(t, err)
+}
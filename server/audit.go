@@ -0,0 +1,59 @@
+package server
+
+import (
+	"time"
+
+	"github.com/containous/traefik/audit"
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/types"
+)
+
+func newAuditLog(config *configuration.Audit) (*audit.Log, error) {
+	return audit.NewLog(config.FilePath, config.URL, config.MaxEntries, time.Duration(config.MaxAge))
+}
+
+// recordAudit records in the audit log the changes between the configuration previously applied for the provider and the new one, if any.
+func (s *Server) recordAudit(providerName string, previous, next types.Configurations) {
+	if s.auditLog == nil {
+		return
+	}
+	changes := diffAuditConfigurations(providerName, previous, next)
+	if len(changes) == 0 {
+		return
+	}
+	s.auditLog.Record(audit.Entry{
+		Provider:  providerName,
+		Timestamp: time.Now().UTC(),
+		Changes:   changes,
+	})
+}
+
+// diffAuditConfigurations lists the frontends, backends and certificates of the provider added, removed or changed,
+// the changed frontends and backends with their changed fields.
+func diffAuditConfigurations(providerName string, previous, next types.Configurations) []audit.Change {
+	changes := audit.DiffConfigurations(previous[providerName], next[providerName])
+
+	// the certificates are identified by their fingerprint, their content is never recorded
+	previousCertificates := summarizeCertificates(types.Configurations{providerName: previous[providerName]})
+	nextCertificates := summarizeCertificates(types.Configurations{providerName: next[providerName]})
+	certificates := diffCertificates(previousCertificates, nextCertificates)
+	for _, summary := range certificates.Added {
+		changes = append(changes, audit.Change{Kind: audit.KindCertificate, Name: summary.Fingerprint, Action: audit.ActionAdded})
+	}
+	for _, summary := range certificates.Removed {
+		changes = append(changes, audit.Change{Kind: audit.KindCertificate, Name: summary.Fingerprint, Action: audit.ActionRemoved})
+	}
+	for _, summary := range certificates.Changed {
+		changes = append(changes, audit.Change{
+			Kind:   audit.KindCertificate,
+			Name:   summary.Fingerprint,
+			Action: audit.ActionChanged,
+			Fields: []audit.FieldChange{{
+				Path:     "entryPoints",
+				Previous: previousCertificates[summary.Fingerprint].EntryPoints,
+				Next:     summary.EntryPoints,
+			}},
+		})
+	}
+	return changes
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/audit"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAudit(t *testing.T) {
+	certificate := &traefikTls.Certificate{CertFile: localhostCert, KeyFile: localhostKey}
+
+	previous := types.Configurations{
+		"file": {
+			Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+			TLSConfiguration: []*traefikTls.Configuration{
+				{EntryPoints: []string{"https"}, Certificate: certificate},
+			},
+		},
+		"docker": {
+			Frontends: map[string]*types.Frontend{"frontend2": {Backend: "backend2"}},
+		},
+	}
+	next := types.Configurations{
+		"file": {
+			Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend2"}},
+			TLSConfiguration: []*traefikTls.Configuration{
+				{EntryPoints: []string{"https", "admin"}, Certificate: certificate},
+			},
+		},
+	}
+
+	auditLog, err := audit.NewLog("", "", 0, 0)
+	require.NoError(t, err)
+	defer auditLog.Close()
+	s := &Server{auditLog: auditLog}
+
+	s.recordAudit("file", previous, next)
+	s.recordAudit("file", next, next)
+
+	entries := auditLog.Entries(audit.Query{})
+	require.Len(t, entries, 1, "a configuration without changes is not recorded")
+	assert.Equal(t, "file", entries[0].Provider)
+
+	changes := entries[0].Changes
+	require.Len(t, changes, 2, "the changes of the other providers are not recorded")
+	assert.Equal(t, audit.Change{
+		Kind:   audit.KindFrontend,
+		Name:   "frontend1",
+		Action: audit.ActionChanged,
+		Fields: []audit.FieldChange{{Path: "backend", Previous: "backend1", Next: "backend2"}},
+	}, changes[0])
+	assert.Equal(t, audit.KindCertificate, changes[1].Kind)
+	assert.Len(t, changes[1].Name, 64)
+	assert.Equal(t, []audit.FieldChange{{Path: "entryPoints", Previous: []string{"https"}, Next: []string{"admin", "https"}}}, changes[1].Fields)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/metrics"
+	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
+)
+
+// certificateMetricsInterval is the interval between two reports of the certificate metrics.
+const certificateMetricsInterval = time.Minute
+
+// reportCertificateMetrics reports periodically the expiration dates of the certificates served by the entry points,
+// and the number of certificates of the ACME store.
+func (s *Server) reportCertificateMetrics(ctx context.Context) {
+	ticker := time.NewTicker(certificateMetricsInterval)
+	defer ticker.Stop()
+	for {
+		s.collectCertificateMetrics()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) collectCertificateMetrics() {
+	for entryPointName, certificates := range s.servedCertificates() {
+		reportCertificatesExpiration(s.metricsRegistry, entryPointName, certificates)
+	}
+
+	resolvers := s.acmeResolvers()
+	if len(resolvers) > 0 {
+		var count int
+		for _, resolver := range resolvers {
+			count += len(resolver.Certificates())
+		}
+		s.metricsRegistry.ACMECertificatesGauge().Set(float64(count))
+	}
+}
+
+// servedCertificates returns the certificates served by each entry point with TLS,
+// listed from the lowest to the highest priority, as they are served.
+func (s *Server) servedCertificates() map[string][]*tls.Certificate {
+	resolvers := s.acmeResolvers()
+	served := make(map[string][]*tls.Certificate)
+	for entryPointName, serverEntryPoint := range s.serverEntryPoints {
+		if serverEntryPoint.httpServer == nil || serverEntryPoint.httpServer.TLSConfig == nil {
+			continue
+		}
+
+		var certificates []*tls.Certificate
+		for i := range serverEntryPoint.httpServer.TLSConfig.Certificates {
+			certificates = append(certificates, &serverEntryPoint.httpServer.TLSConfig.Certificates[i])
+		}
+		for _, resolverName := range sortedACMEResolverNames(resolvers) {
+			if resolvers[resolverName].EntryPoint == entryPointName {
+				certificates = append(certificates, resolvers[resolverName].Certificates()...)
+			}
+		}
+		certificates = append(certificates, safeDomainsCertificates(&serverEntryPoint.certs)...)
+		if serverEntryPoint.certificatesDirectory != nil {
+			certificates = append(certificates, safeDomainsCertificates(&serverEntryPoint.certificatesDirectory.certs)...)
+		}
+		served[entryPointName] = certificates
+	}
+	return served
+}
+
+// reportCertificatesExpiration sets the expiration date of the certificate served for each domain of the entry point.
+func reportCertificatesExpiration(registry metrics.Registry, entryPointName string, certificates []*tls.Certificate) {
+	for domain, notAfter := range certificatesExpiration(entryPointName, certificates) {
+		registry.CertificateExpirationGauge().With("entrypoint", entryPointName, "domain", domain).Set(float64(notAfter.Unix()))
+	}
+}
+
+// certificatesExpiration returns the expiration date of the certificate served for each domain of the entry point,
+// a certificate overriding the previous ones for its domains.
+func certificatesExpiration(entryPointName string, certificates []*tls.Certificate) map[string]time.Time {
+	notAfters := make(map[string]time.Time)
+	for _, certificate := range certificates {
+		leaf, err := certificateLeaf(certificate)
+		if err != nil {
+			log.Debugf("Unable to parse a certificate of the entry point %s: %v", entryPointName, err)
+			continue
+		}
+		domains := leaf.DNSNames
+		if len(domains) == 0 && len(leaf.Subject.CommonName) > 0 {
+			domains = []string{leaf.Subject.CommonName}
+		}
+		for _, domain := range domains {
+			notAfters[domain] = leaf.NotAfter
+		}
+	}
+	return notAfters
+}
+
+func certificateLeaf(certificate *tls.Certificate) (*x509.Certificate, error) {
+	if certificate.Leaf != nil {
+		return certificate.Leaf, nil
+	}
+	if len(certificate.Certificate) == 0 {
+		return nil, errors.New("empty certificate")
+	}
+	return x509.ParseCertificate(certificate.Certificate[0])
+}
+
+func safeDomainsCertificates(certs *safe.Safe) []*tls.Certificate {
+	domainsCertificates, ok := certs.Get().(*traefikTls.DomainsCertificates)
+	if !ok || domainsCertificates == nil {
+		return nil
+	}
+	var certificates []*tls.Certificate
+	for _, certificate := range *domainsCertificates {
+		certificates = append(certificates, certificate)
+	}
+	return certificates
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/metrics"
+	"github.com/containous/traefik/tls/generate"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collectingGauge struct {
+	values          map[string]float64
+	lastLabelValues []string
+}
+
+func (g *collectingGauge) With(labelValues ...string) gokitmetrics.Gauge {
+	return &collectingGauge{values: g.values, lastLabelValues: labelValues}
+}
+
+func (g *collectingGauge) Set(value float64) {
+	g.values[strings.Join(g.lastLabelValues, ",")] = value
+}
+
+type certificateMetricsRegistry struct {
+	metrics.Registry
+	gauge *collectingGauge
+}
+
+func (r *certificateMetricsRegistry) CertificateExpirationGauge() gokitmetrics.Gauge {
+	return r.gauge
+}
+
+func newTestCertificate(t *testing.T, domain string, expiration time.Time) *tls.Certificate {
+	certPEM, keyPEM, err := generate.KeyPair(domain, expiration)
+	require.NoError(t, err)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return &certificate
+}
+
+func TestReportCertificatesExpiration(t *testing.T) {
+	now := time.Now()
+	foo := newTestCertificate(t, "foo.example.com", now.Add(24*time.Hour))
+	bar := newTestCertificate(t, "bar.example.com", now.Add(48*time.Hour))
+	renewedFoo := newTestCertificate(t, "foo.example.com", now.Add(72*time.Hour))
+
+	registry := &certificateMetricsRegistry{
+		Registry: metrics.NewVoidRegistry(),
+		gauge:    &collectingGauge{values: make(map[string]float64)},
+	}
+	reportCertificatesExpiration(registry, "https", []*tls.Certificate{foo, bar, {}, renewedFoo})
+
+	assert.Equal(t, map[string]float64{
+		"entrypoint,https,domain,foo.example.com": float64(now.Add(72 * time.Hour).Unix()),
+		"entrypoint,https,domain,bar.example.com": float64(now.Add(48 * time.Hour).Unix()),
+	}, registry.gauge.values)
+}
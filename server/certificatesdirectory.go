@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
+	"gopkg.in/fsnotify.v1"
+)
+
+const (
+	certificateFileExtension = ".crt"
+	keyFileExtension         = ".key"
+
+	// certificatesDirectoryReloadDelay is the delay without change in the directory before it is reloaded,
+	// the certificate and key files of a certificate being written one after the other.
+	certificatesDirectoryReloadDelay = time.Second
+)
+
+// certificatesDirectory serves the certificates of a directory, and reloads them when the directory changes:
+// each <name>.crt file holds a certificate, followed by its chain, and the <name>.key file its private key.
+type certificatesDirectory struct {
+	directory      string
+	entryPointName string
+	reloadDelay    time.Duration
+
+	certs safe.Safe
+	// files holds the certificate loaded from each certificate file, only used by load
+	files map[string]*directoryCertificate
+}
+
+type directoryCertificate struct {
+	domains     string
+	certificate *tls.Certificate
+	certContent []byte
+	keyContent  []byte
+}
+
+func newCertificatesDirectory(directory, entryPointName string) *certificatesDirectory {
+	return &certificatesDirectory{
+		directory:      directory,
+		entryPointName: entryPointName,
+		reloadDelay:    certificatesDirectoryReloadDelay,
+		files:          make(map[string]*directoryCertificate),
+	}
+}
+
+// wrapGetCertificate serves the certificates of the directory before the ones returned by getCertificate.
+func (c *certificatesDirectory) wrapGetCertificate(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if certificate := c.getCertificate(clientHello); certificate != nil {
+			return certificate, nil
+		}
+		if getCertificate != nil {
+			return getCertificate(clientHello)
+		}
+		return nil, nil
+	}
+}
+
+func (c *certificatesDirectory) getCertificate(clientHello *tls.ClientHelloInfo) *tls.Certificate {
+	certificates, ok := c.certs.Get().(*traefikTls.DomainsCertificates)
+	if !ok {
+		return nil
+	}
+	return matchDomainsCertificates(certificates, clientHello.ServerName)
+}
+
+func (c *certificatesDirectory) run(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("Unable to watch the certificates directory %s: %v", c.directory, err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(c.directory); err != nil {
+		log.Errorf("Unable to watch the certificates directory %s: %v", c.directory, err)
+		return
+	}
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-watcher.Events:
+			reload = time.After(c.reloadDelay)
+		case err := <-watcher.Errors:
+			log.Errorf("Certificates directory %s watcher error: %v", c.directory, err)
+		case <-reload:
+			reload = nil
+			if err := c.load(); err != nil {
+				log.Errorf("Unable to reload the certificates directory %s: %v", c.directory, err)
+			}
+		}
+	}
+}
+
+// load loads the certificates of the directory, and replaces the served ones.
+// A certificate which fails to load or to validate is refused, the certificate previously loaded from the same file is kept, if any.
+func (c *certificatesDirectory) load() error {
+	if _, err := os.Stat(c.directory); err != nil {
+		return err
+	}
+	certFiles, err := filepath.Glob(filepath.Join(c.directory, "*"+certificateFileExtension))
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]*directoryCertificate)
+	certificates := make(traefikTls.DomainsCertificates)
+	for _, certFile := range certFiles {
+		current := c.files[certFile]
+		loaded, err := loadDirectoryCertificate(certFile, current)
+		if err != nil {
+			if current == nil {
+				log.Errorf("Refusing the certificate %s on entry point %s: %v", certFile, c.entryPointName, err)
+				continue
+			}
+			log.Errorf("Refusing the new certificate %s on entry point %s, the current certificate is kept: %v", certFile, c.entryPointName, err)
+			loaded = current
+		}
+
+		if _, exists := certificates[loaded.domains]; exists {
+			log.Warnf("The certificate %s on entry point %s is for domains which already have a certificate (%s), it is ignored", certFile, c.entryPointName, loaded.domains)
+			continue
+		}
+		files[certFile] = loaded
+		certificates[loaded.domains] = loaded.certificate
+
+		if current == nil {
+			log.Infof("Certificate %s loaded for domains %s on entry point %s", certFile, loaded.domains, c.entryPointName)
+		} else if loaded != current {
+			log.Infof("Certificate %s replaced for domains %s on entry point %s", certFile, loaded.domains, c.entryPointName)
+		}
+	}
+	for certFile, current := range c.files {
+		if _, ok := files[certFile]; !ok {
+			log.Infof("Certificate %s removed for domains %s on entry point %s", certFile, current.domains, c.entryPointName)
+		}
+	}
+
+	c.files = files
+	c.certs.Set(&certificates)
+	return nil
+}
+
+// loadDirectoryCertificate loads the certificate file and its key file, current being returned when they are unchanged.
+func loadDirectoryCertificate(certFile string, current *directoryCertificate) (*directoryCertificate, error) {
+	certContent, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyContent, err := ioutil.ReadFile(strings.TrimSuffix(certFile, certificateFileExtension) + keyFileExtension)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && bytes.Equal(current.certContent, certContent) && bytes.Equal(current.keyContent, keyContent) {
+		return current, nil
+	}
+
+	certificate, err := tls.X509KeyPair(certContent, keyContent)
+	if err != nil {
+		return nil, err
+	}
+	if err := traefikTls.ValidateCertificate(&certificate); err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &directoryCertificate{
+		domains:     certificateDomains(leaf),
+		certificate: &certificate,
+		certContent: certContent,
+		keyContent:  keyContent,
+	}, nil
+}
+
+// certificateDomains returns the sorted common name and DNS names of the certificate, comma separated.
+func certificateDomains(leaf *x509.Certificate) string {
+	var domains []string
+	seen := make(map[string]bool)
+	for _, domain := range append([]string{leaf.Subject.CommonName}, leaf.DNSNames...) {
+		if len(domain) == 0 || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return strings.Join(domains, ",")
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/tls/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCertificate(t *testing.T, directory, name, domain string) {
+	certPEM, keyPEM, err := generate.KeyPair(domain, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, name+".key"), keyPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, name+".crt"), certPEM, 0600))
+}
+
+func TestCertificatesDirectoryLoad(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-certificates")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	writeTestCertificate(t, directory, "foo", "foo.example.com")
+	writeTestCertificate(t, directory, "bar", "bar.example.com")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "README"), []byte("not a certificate"), 0600))
+
+	certificates := newCertificatesDirectory(directory, "https")
+	require.NoError(t, certificates.load())
+
+	foo := certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"})
+	require.NotNil(t, foo)
+	assert.NotNil(t, certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "bar.example.com"}))
+	assert.Nil(t, certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "baz.example.com"}))
+
+	// an unchanged certificate is kept as is
+	require.NoError(t, certificates.load())
+	assert.True(t, foo == certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"}))
+
+	// a renewed certificate replaces the current one
+	writeTestCertificate(t, directory, "foo", "foo.example.com")
+	require.NoError(t, certificates.load())
+	renewed := certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"})
+	require.NotNil(t, renewed)
+	assert.NotEqual(t, foo.Certificate[0], renewed.Certificate[0])
+
+	// an invalid certificate is refused, the current one is kept
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "foo.key"), []byte("not a key"), 0600))
+	require.NoError(t, certificates.load())
+	assert.True(t, renewed == certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"}))
+
+	// a removed certificate is no longer served
+	require.NoError(t, os.Remove(filepath.Join(directory, "bar.crt")))
+	require.NoError(t, certificates.load())
+	assert.Nil(t, certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "bar.example.com"}))
+
+	require.NoError(t, os.RemoveAll(directory))
+	assert.Error(t, certificates.load())
+	assert.NotNil(t, certificates.getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"}), "the certificates are kept when the directory can't be read")
+}
+
+func TestCertificatesDirectoryWatch(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-certificates")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	certificates := newCertificatesDirectory(directory, "https")
+	certificates.reloadDelay = 10 * time.Millisecond
+	require.NoError(t, certificates.load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certificates.run(ctx)
+
+	getCertificate := certificates.wrapGetCertificate(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return &tls.Certificate{}, nil
+	})
+	served := func() *tls.Certificate {
+		certificate, err := getCertificate(&tls.ClientHelloInfo{ServerName: "foo.example.com"})
+		require.NoError(t, err)
+		return certificate
+	}
+	assert.Empty(t, served().Certificate, "the wrapped certificates should be served when the directory has none")
+
+	// let the watcher start before writing the certificate
+	time.Sleep(100 * time.Millisecond)
+	writeTestCertificate(t, directory, "foo", "foo.example.com")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(served().Certificate) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NotEmpty(t, served().Certificate, "the certificate written in the directory should be served")
+}
+
+func TestCertificateDomains(t *testing.T) {
+	leaf := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "www.example.com"},
+		DNSNames: []string{"www.example.com", "example.com", "*.example.org"},
+	}
+	assert.Equal(t, "*.example.org,example.com,www.example.com", certificateDomains(leaf))
+}
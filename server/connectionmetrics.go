@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/containous/traefik/metrics"
+)
+
+// trackOpenConnections returns the hook of the connection states of the server of an entry point,
+// reporting the number of its open client connections.
+// The hijacked connections, e.g. the WebSockets, are no longer tracked by the server, and are no longer counted.
+func trackOpenConnections(registry metrics.Registry, entryPointName string) func(net.Conn, http.ConnState) {
+	gauge := registry.EntryPointOpenConnsGauge().With("entrypoint", entryPointName)
+
+	var mutex sync.Mutex
+	var open int
+	return func(conn net.Conn, state http.ConnState) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch state {
+		case http.StateNew:
+			open++
+		case http.StateHijacked, http.StateClosed:
+			open--
+		default:
+			return
+		}
+		gauge.Set(float64(open))
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/containous/traefik/metrics"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type connectionMetricsRegistry struct {
+	metrics.Registry
+	gauge *collectingGauge
+}
+
+func (r *connectionMetricsRegistry) EntryPointOpenConnsGauge() gokitmetrics.Gauge {
+	return r.gauge
+}
+
+func TestTrackOpenConnections(t *testing.T) {
+	registry := &connectionMetricsRegistry{
+		Registry: metrics.NewVoidRegistry(),
+		gauge:    &collectingGauge{values: make(map[string]float64)},
+	}
+	hook := trackOpenConnections(registry, "http")
+
+	states := []http.ConnState{http.StateNew, http.StateNew, http.StateActive, http.StateNew, http.StateIdle, http.StateClosed, http.StateHijacked}
+	for _, state := range states {
+		hook(nil, state)
+	}
+
+	assert.Equal(t, map[string]float64{"entrypoint,http": 1}, registry.gauge.values)
+}
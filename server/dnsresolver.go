@@ -0,0 +1,365 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	"github.com/miekg/dns"
+)
+
+const resolvConf = "/etc/resolv.conf"
+
+var errNoSuchHost = errors.New("no such host")
+
+const (
+	// dnsRefreshInterval is the interval between the checks of the cache entries to refresh
+	dnsRefreshInterval = time.Second
+	// dnsUnusedExpiration is the duration after which a host name no longer dialed is no longer refreshed
+	dnsUnusedExpiration = 10 * time.Minute
+)
+
+// dnsResolver resolves the backend host names with its own DNS servers and cache,
+// independently of the resolver of the OS.
+type dnsResolver struct {
+	client              *dns.Client
+	nameservers         []string
+	searchDomains       []string
+	minTTL              time.Duration
+	maxTTL              time.Duration
+	negativeTTL         time.Duration
+	fallbackToLastKnown bool
+	refresh             bool
+
+	lock  sync.Mutex
+	cache map[string]*dnsCacheEntry
+	// transports holds the number of open connections of the transports, to close their idle connections
+	// when the addresses of a host change
+	transports map[*http.Transport]int
+	// nextAddress rotates the first address dialed, to spread the connections across the addresses
+	nextAddress uint64
+
+	// lookup resolves a host name, returning its addresses and their TTL
+	lookup func(host string) ([]string, time.Duration, error)
+}
+
+type dnsCacheEntry struct {
+	addresses  []string
+	err        error
+	expiration time.Time
+	lastUsed   time.Time
+}
+
+func newDNSResolver(config *configuration.DNSResolver) (*dnsResolver, error) {
+	nameservers := config.Nameservers
+	if len(nameservers) == 0 {
+		clientConfig, err := dns.ClientConfigFromFile(resolvConf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the nameservers from %s: %v", resolvConf, err)
+		}
+		for _, server := range clientConfig.Servers {
+			nameservers = append(nameservers, net.JoinHostPort(server, clientConfig.Port))
+		}
+	}
+	if len(nameservers) == 0 {
+		return nil, errors.New("no nameserver")
+	}
+
+	timeout := time.Duration(config.Timeout)
+	if timeout <= 0 {
+		timeout = configuration.DefaultDNSTimeout
+	}
+
+	r := &dnsResolver{
+		client:              &dns.Client{Timeout: timeout},
+		nameservers:         nameservers,
+		searchDomains:       config.SearchDomains,
+		minTTL:              time.Duration(config.MinTTL),
+		maxTTL:              time.Duration(config.MaxTTL),
+		negativeTTL:         time.Duration(config.NegativeTTL),
+		fallbackToLastKnown: config.FallbackToLastKnown,
+		refresh:             config.Refresh,
+		cache:               make(map[string]*dnsCacheEntry),
+		transports:          make(map[*http.Transport]int),
+	}
+	r.lookup = r.lookupHost
+	return r, nil
+}
+
+// DialContext wraps the dial function to connect to the addresses resolved for the host,
+// trying them in order until a connection succeeds.
+// With the refresh, the first address tried is rotated to spread the connections across the addresses.
+func (r *dnsResolver) DialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses, err := r.resolve(host)
+		if err != nil {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: err.Error(), Name: host}}
+		}
+
+		if r.refresh && len(addresses) > 1 {
+			first := int(atomic.AddUint64(&r.nextAddress, 1) % uint64(len(addresses)))
+			addresses = append(append([]string{}, addresses[first:]...), addresses[:first]...)
+		}
+
+		var lastErr error
+		for _, ip := range addresses {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, lastErr
+	}
+}
+
+// resolve returns the addresses of the host from the cache, or resolves them when the cache entry expired.
+func (r *dnsResolver) resolve(host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	now := time.Now()
+	r.lock.Lock()
+	entry, ok := r.cache[host]
+	if ok {
+		entry.lastUsed = now
+	}
+	r.lock.Unlock()
+	if ok && now.Before(entry.expiration) {
+		return entry.addresses, entry.err
+	}
+
+	return r.update(host, entry, now)
+}
+
+// update resolves the host and replaces its cache entry, the previous one being nil if none.
+func (r *dnsResolver) update(host string, entry *dnsCacheEntry, lastUsed time.Time) ([]string, error) {
+	addresses, ttl, err := r.lookup(host)
+	now := time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if entry != nil && entry.lastUsed.After(lastUsed) {
+		lastUsed = entry.lastUsed
+	}
+
+	if err != nil {
+		if r.fallbackToLastKnown && entry != nil && len(entry.addresses) > 0 {
+			log.Warnf("Unable to resolve the backend host %s, using its last known addresses %v: %v", host, entry.addresses, err)
+			// the last known addresses are kept until the next attempt, at least the minimum TTL later
+			r.cache[host] = &dnsCacheEntry{addresses: entry.addresses, expiration: now.Add(r.minTTL), lastUsed: lastUsed}
+			return entry.addresses, nil
+		}
+		if err == errNoSuchHost && r.negativeTTL > 0 {
+			r.cache[host] = &dnsCacheEntry{err: err, expiration: now.Add(r.negativeTTL), lastUsed: lastUsed}
+		}
+		return nil, err
+	}
+
+	if r.refresh && entry != nil && len(entry.addresses) > 0 && !sameAddresses(entry.addresses, addresses) {
+		log.Infof("Addresses of the backend host %s changed from %v to %v: closing the idle connections", host, entry.addresses, addresses)
+		for transport := range r.transports {
+			// the closed connections call back the resolver, once the lock is released
+			go transport.CloseIdleConnections()
+		}
+	}
+
+	r.cache[host] = &dnsCacheEntry{addresses: addresses, expiration: now.Add(r.boundTTL(ttl)), lastUsed: lastUsed}
+	return addresses, nil
+}
+
+// run re-resolves the host names in use when their cache entry expires, until the context is done.
+func (r *dnsResolver) run(ctx context.Context) {
+	ticker := time.NewTicker(dnsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refreshExpired()
+		}
+	}
+}
+
+// refreshExpired re-resolves the host names whose cache entry expired, and drops the ones no longer used.
+func (r *dnsResolver) refreshExpired() {
+	now := time.Now()
+	expired := make(map[string]*dnsCacheEntry)
+
+	r.lock.Lock()
+	for host, entry := range r.cache {
+		if now.Sub(entry.lastUsed) > dnsUnusedExpiration {
+			delete(r.cache, host)
+			continue
+		}
+		if !now.Before(entry.expiration) {
+			expired[host] = entry
+		}
+	}
+	r.lock.Unlock()
+
+	for host, entry := range expired {
+		if _, err := r.update(host, entry, time.Time{}); err != nil {
+			log.Debugf("Unable to refresh the addresses of the backend host %s: %v", host, err)
+		}
+	}
+}
+
+// trackConnections wraps the dial function of the transport to count its open connections,
+// the transports with open connections having their idle connections closed when the addresses of a host change.
+func (r *dnsResolver) trackConnections(transport *http.Transport, dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		r.lock.Lock()
+		r.transports[transport]++
+		r.lock.Unlock()
+		return &trackedConn{Conn: conn, onClose: func() {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			if r.transports[transport]--; r.transports[transport] <= 0 {
+				delete(r.transports, transport)
+			}
+		}}, nil
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
+
+func sameAddresses(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *dnsResolver) boundTTL(ttl time.Duration) time.Duration {
+	if ttl < r.minTTL {
+		return r.minTTL
+	}
+	if r.maxTTL > 0 && ttl > r.maxTTL {
+		return r.maxTTL
+	}
+	return ttl
+}
+
+// lookupHost resolves the host name, completed with the search domains when it has no dot.
+// The IPv4 addresses are used when the host has some, the IPv6 addresses otherwise,
+// or both with the refresh.
+func (r *dnsResolver) lookupHost(host string) ([]string, time.Duration, error) {
+	names := []string{host}
+	if !strings.Contains(strings.TrimSuffix(host, "."), ".") && len(r.searchDomains) > 0 {
+		names = nil
+		for _, domain := range r.searchDomains {
+			names = append(names, host+"."+strings.Trim(domain, "."))
+		}
+		names = append(names, host)
+	}
+
+	for _, name := range names {
+		var addresses []string
+		var ttl time.Duration
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			qAddresses, qTTL, err := r.query(name, qtype)
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(qAddresses) == 0 {
+				continue
+			}
+			if len(addresses) == 0 || qTTL < ttl {
+				ttl = qTTL
+			}
+			addresses = append(addresses, qAddresses...)
+			if !r.refresh {
+				break
+			}
+		}
+		if len(addresses) > 0 {
+			return addresses, ttl, nil
+		}
+	}
+	return nil, 0, errNoSuchHost
+}
+
+// query asks the nameservers in order until one answers, a name which does not exist gives no address.
+func (r *dnsResolver) query(name string, qtype uint16) ([]string, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	var lastErr error
+	for _, nameserver := range r.nameservers {
+		answer, _, err := r.client.Exchange(msg, nameserver)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if answer.Rcode == dns.RcodeNameError {
+			return nil, 0, nil
+		}
+		if answer.Rcode != dns.RcodeSuccess {
+			lastErr = fmt.Errorf("%s answered %s for %s", nameserver, dns.RcodeToString[answer.Rcode], name)
+			continue
+		}
+
+		var addresses []string
+		var ttl uint32
+		for _, rr := range answer.Answer {
+			switch record := rr.(type) {
+			case *dns.A:
+				addresses = append(addresses, record.A.String())
+			case *dns.AAAA:
+				addresses = append(addresses, record.AAAA.String())
+			default:
+				continue
+			}
+			if ttl == 0 || rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+			}
+		}
+		return addresses, time.Duration(ttl) * time.Second, nil
+	}
+	return nil, 0, lastErr
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lookupResult struct {
+	addresses []string
+	ttl       time.Duration
+	err       error
+}
+
+func TestDNSResolverResolve(t *testing.T) {
+	errTimeout := errors.New("i/o timeout")
+
+	testCases := []struct {
+		desc     string
+		config   configuration.DNSResolver
+		lookups  []lookupResult
+		expected []lookupResult
+		calls    int
+	}{
+		{
+			desc:   "cached during the TTL",
+			config: configuration.DNSResolver{},
+			lookups: []lookupResult{
+				{addresses: []string{"10.0.0.1"}, ttl: time.Hour},
+			},
+			expected: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.1"}},
+			},
+			calls: 1,
+		},
+		{
+			desc:   "TTL bounded by the maximum TTL",
+			config: configuration.DNSResolver{MaxTTL: flaeg.Duration(time.Nanosecond)},
+			lookups: []lookupResult{
+				{addresses: []string{"10.0.0.1"}, ttl: time.Hour},
+				{addresses: []string{"10.0.0.2"}, ttl: time.Hour},
+			},
+			expected: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.2"}},
+			},
+			calls: 2,
+		},
+		{
+			desc:   "TTL bounded by the minimum TTL",
+			config: configuration.DNSResolver{MinTTL: flaeg.Duration(time.Hour)},
+			lookups: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+			},
+			expected: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.1"}},
+			},
+			calls: 1,
+		},
+		{
+			desc:   "unknown host without negative cache",
+			config: configuration.DNSResolver{},
+			lookups: []lookupResult{
+				{err: errNoSuchHost},
+			},
+			expected: []lookupResult{
+				{err: errNoSuchHost},
+				{err: errNoSuchHost},
+			},
+			calls: 2,
+		},
+		{
+			desc:   "unknown host with negative cache",
+			config: configuration.DNSResolver{NegativeTTL: flaeg.Duration(time.Hour)},
+			lookups: []lookupResult{
+				{err: errNoSuchHost},
+			},
+			expected: []lookupResult{
+				{err: errNoSuchHost},
+				{err: errNoSuchHost},
+			},
+			calls: 1,
+		},
+		{
+			desc:   "failure without fallback",
+			config: configuration.DNSResolver{},
+			lookups: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{err: errTimeout},
+			},
+			expected: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{err: errTimeout},
+			},
+			calls: 2,
+		},
+		{
+			desc:   "failure with fallback to the last known addresses",
+			config: configuration.DNSResolver{FallbackToLastKnown: true},
+			lookups: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{err: errTimeout},
+				{err: errNoSuchHost},
+				{addresses: []string{"10.0.0.2"}},
+			},
+			expected: []lookupResult{
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.1"}},
+				{addresses: []string{"10.0.0.2"}},
+			},
+			calls: 4,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := test.config
+			config.Nameservers = []string{"127.0.0.1:53"}
+			r, err := newDNSResolver(&config)
+			require.NoError(t, err)
+
+			calls := 0
+			r.lookup = func(host string) ([]string, time.Duration, error) {
+				assert.Equal(t, "whoami.local", host)
+				result := test.lookups[calls%len(test.lookups)]
+				calls++
+				return result.addresses, result.ttl, result.err
+			}
+
+			for _, expected := range test.expected {
+				addresses, err := r.resolve("whoami.local")
+				assert.Equal(t, expected.addresses, addresses)
+				assert.Equal(t, expected.err, err)
+			}
+			assert.Equal(t, test.calls, calls)
+		})
+	}
+}
+
+func TestDNSResolverResolveIP(t *testing.T) {
+	r, err := newDNSResolver(&configuration.DNSResolver{Nameservers: []string{"127.0.0.1:53"}})
+	require.NoError(t, err)
+	r.lookup = func(host string) ([]string, time.Duration, error) {
+		t.Fatalf("unexpected lookup of %s", host)
+		return nil, 0, nil
+	}
+
+	addresses, err := r.resolve("10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addresses)
+}
+
+func TestDNSResolverLookupHost(t *testing.T) {
+	records := map[string][]dns.RR{
+		"whoami.internal.": {
+			&dns.A{Hdr: dns.RR_Header{Name: "whoami.internal.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30}, A: net.ParseIP("10.0.0.1")},
+			&dns.A{Hdr: dns.RR_Header{Name: "whoami.internal.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 10}, A: net.ParseIP("10.0.0.2")},
+		},
+		"api.example.com.": {
+			&dns.AAAA{Hdr: dns.RR_Header{Name: "api.example.com.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 60}, AAAA: net.ParseIP("fd00::1")},
+		},
+		"dual.example.com.": {
+			&dns.A{Hdr: dns.RR_Header{Name: "dual.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("10.0.0.3")},
+			&dns.AAAA{Hdr: dns.RR_Header{Name: "dual.example.com.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 20}, AAAA: net.ParseIP("fd00::3")},
+		},
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		question := req.Question[0]
+		rrs, ok := records[question.Name]
+		if !ok {
+			m.SetRcode(req, dns.RcodeNameError)
+			w.WriteMsg(m)
+			return
+		}
+		m.SetReply(req)
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == question.Qtype {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		w.WriteMsg(m)
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &dns.Server{PacketConn: conn, Handler: handler}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	config := configuration.DNSResolver{
+		Nameservers:   []string{conn.LocalAddr().String()},
+		SearchDomains: []string{"internal"},
+		Timeout:       flaeg.Duration(time.Second),
+	}
+	r, err := newDNSResolver(&config)
+	require.NoError(t, err)
+	config.Refresh = true
+	refreshResolver, err := newDNSResolver(&config)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc              string
+		host              string
+		refresh           bool
+		expectedAddresses []string
+		expectedTTL       time.Duration
+		expectedErr       error
+	}{
+		{
+			desc:              "completed with the search domain",
+			host:              "whoami",
+			expectedAddresses: []string{"10.0.0.1", "10.0.0.2"},
+			expectedTTL:       10 * time.Second,
+		},
+		{
+			desc:              "fully qualified",
+			host:              "whoami.internal",
+			expectedAddresses: []string{"10.0.0.1", "10.0.0.2"},
+			expectedTTL:       10 * time.Second,
+		},
+		{
+			desc:              "IPv6 only",
+			host:              "api.example.com",
+			expectedAddresses: []string{"fd00::1"},
+			expectedTTL:       time.Minute,
+		},
+		{
+			desc:              "IPv4 addresses preferred",
+			host:              "dual.example.com",
+			expectedAddresses: []string{"10.0.0.3"},
+			expectedTTL:       time.Minute,
+		},
+		{
+			desc:              "IPv4 and IPv6 addresses with the refresh",
+			host:              "dual.example.com",
+			refresh:           true,
+			expectedAddresses: []string{"10.0.0.3", "fd00::3"},
+			expectedTTL:       20 * time.Second,
+		},
+		{
+			desc:        "unknown host",
+			host:        "unknown",
+			expectedErr: errNoSuchHost,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			resolver := r
+			if test.refresh {
+				resolver = refreshResolver
+			}
+			addresses, ttl, err := resolver.lookupHost(test.host)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedAddresses, addresses)
+			assert.Equal(t, test.expectedTTL, ttl)
+		})
+	}
+}
+
+func TestDNSResolverTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(backendURL.Host)
+	require.NoError(t, err)
+
+	r, err := newDNSResolver(&configuration.DNSResolver{Nameservers: []string{"127.0.0.1:53"}})
+	require.NoError(t, err)
+	r.lookup = func(host string) ([]string, time.Duration, error) {
+		if host != "backend.internal" {
+			return nil, 0, errNoSuchHost
+		}
+		// the first address refuses the connections
+		return []string{"127.0.0.2", "127.0.0.1"}, time.Minute, nil
+	}
+
+	s := &Server{dnsResolver: r}
+	transport := s.withDNSResolver(createHTTPTransport(configuration.GlobalConfiguration{}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://backend.internal:"+port, nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req.WithContext(context.Background()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodGet, "http://unknown.internal:"+port, nil)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.Error(t, err)
+}
+
+func TestDNSResolverRefreshExpired(t *testing.T) {
+	r, err := newDNSResolver(&configuration.DNSResolver{Nameservers: []string{"127.0.0.1:53"}, Refresh: true})
+	require.NoError(t, err)
+
+	var lookups []string
+	r.lookup = func(host string) ([]string, time.Duration, error) {
+		lookups = append(lookups, host)
+		return []string{"10.0.0.2"}, time.Minute, nil
+	}
+
+	now := time.Now()
+	r.cache = map[string]*dnsCacheEntry{
+		"expired.internal": {addresses: []string{"10.0.0.1"}, expiration: now.Add(-time.Second), lastUsed: now},
+		"valid.internal":   {addresses: []string{"10.0.0.1"}, expiration: now.Add(time.Minute), lastUsed: now},
+		"unused.internal":  {addresses: []string{"10.0.0.1"}, expiration: now.Add(-time.Second), lastUsed: now.Add(-time.Hour)},
+	}
+
+	r.refreshExpired()
+
+	assert.Equal(t, []string{"expired.internal"}, lookups)
+	require.Contains(t, r.cache, "expired.internal")
+	assert.Equal(t, []string{"10.0.0.2"}, r.cache["expired.internal"].addresses)
+	assert.Equal(t, now, r.cache["expired.internal"].lastUsed)
+	assert.Equal(t, []string{"10.0.0.1"}, r.cache["valid.internal"].addresses)
+	assert.NotContains(t, r.cache, "unused.internal")
+}
+
+func TestDNSResolverRefreshClosesIdleConnections(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(backendURL.Host)
+	require.NoError(t, err)
+
+	r, err := newDNSResolver(&configuration.DNSResolver{Nameservers: []string{"127.0.0.1:53"}, Refresh: true})
+	require.NoError(t, err)
+	r.lookup = func(host string) ([]string, time.Duration, error) {
+		return []string{"127.0.0.1"}, time.Minute, nil
+	}
+
+	s := &Server{dnsResolver: r}
+	transport := s.withDNSResolver(createHTTPTransport(configuration.GlobalConfiguration{}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://backend.internal:"+port, nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	r.lock.Lock()
+	assert.Equal(t, 1, r.transports[transport])
+	entry := r.cache["backend.internal"]
+	r.lock.Unlock()
+
+	// the host has a new address: the idle connection to its previous address is closed
+	r.lookup = func(host string) ([]string, time.Duration, error) {
+		return []string{"127.0.0.1", "127.0.0.2"}, time.Minute, nil
+	}
+	_, err = r.update("backend.internal", entry, time.Now())
+	require.NoError(t, err)
+
+	var transports int
+	for i := 0; i < 100; i++ {
+		r.lock.Lock()
+		transports = len(r.transports)
+		r.lock.Unlock()
+		if transports == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, transports)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/containous/traefik/types"
+	"github.com/urfave/negroni"
+)
+
+// frontendMiddlewareNames holds the names of the middlewares which can be listed in the order of a frontend
+var frontendMiddlewareNames = func() map[string]bool {
+	names := map[string]bool{types.MiddlewareMaintenance: true}
+	for _, name := range types.FrontendMiddlewares {
+		names[name] = true
+	}
+	return names
+}()
+
+// middlewareChain holds the middlewares of a frontend by name.
+type middlewareChain struct {
+	handlers map[string][]negroni.Handler
+}
+
+func newMiddlewareChain() *middlewareChain {
+	return &middlewareChain{handlers: make(map[string][]negroni.Handler)}
+}
+
+func (c *middlewareChain) use(name string, handler negroni.Handler) {
+	c.handlers[name] = append(c.handlers[name], handler)
+}
+
+// apply adds the middlewares to n: first the ones of the order, then the others in their default order.
+func (c *middlewareChain) apply(n *negroni.Negroni, order []string) error {
+	if err := validateMiddlewareOrder(order); err != nil {
+		return err
+	}
+
+	for _, name := range types.OrderMiddlewares(order) {
+		for _, handler := range c.handlers[name] {
+			n.Use(handler)
+		}
+	}
+	return nil
+}
+
+func validateMiddlewareOrder(order []string) error {
+	seen := make(map[string]bool)
+	for _, name := range order {
+		if !frontendMiddlewareNames[name] {
+			return fmt.Errorf("unknown middleware %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("middleware %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+type nextHandlerKey struct{}
+
+// handlerWrapper adapts a handler wrapping the next handler at its creation, e.g. the rate limiter,
+// to a middleware of the chain.
+type handlerWrapper struct {
+	handler http.Handler
+}
+
+func newHandlerWrapper(wrap func(next http.Handler) (http.Handler, error)) (*handlerWrapper, error) {
+	handler, err := wrap(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		next := req.Context().Value(nextHandlerKey{}).(http.HandlerFunc)
+		next(rw, req)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &handlerWrapper{handler: handler}, nil
+}
+
+func (h *handlerWrapper) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	h.handler.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), nextHandlerKey{}, next)))
+}
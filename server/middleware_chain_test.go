@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func namedMiddleware(name string) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		rw.Header().Add("X-Chain", name)
+		next(rw, req)
+	})
+}
+
+func TestMiddlewareChainApply(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		order         []string
+		expectedChain string
+		expectedError bool
+	}{
+		{
+			desc:          "default order",
+			expectedChain: "metrics,ipWhitelist,basicAuth,basicAuth,headers",
+		},
+		{
+			desc:          "partial order",
+			order:         []string{"basicAuth", "ipWhitelist"},
+			expectedChain: "basicAuth,basicAuth,ipWhitelist,metrics,headers",
+		},
+		{
+			desc:          "middleware not configured",
+			order:         []string{"cors", "headers"},
+			expectedChain: "headers,metrics,ipWhitelist,basicAuth,basicAuth",
+		},
+		{
+			desc:          "unknown middleware",
+			order:         []string{"foo"},
+			expectedError: true,
+		},
+		{
+			desc:          "middleware listed twice",
+			order:         []string{"headers", "headers"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			chain := newMiddlewareChain()
+			chain.use(types.MiddlewareMetrics, namedMiddleware("metrics"))
+			chain.use(types.MiddlewareIPWhitelist, namedMiddleware("ipWhitelist"))
+			chain.use(types.MiddlewareBasicAuth, namedMiddleware("basicAuth"))
+			chain.use(types.MiddlewareBasicAuth, namedMiddleware("basicAuth"))
+			chain.use(types.MiddlewareHeaders, namedMiddleware("headers"))
+
+			n := negroni.New()
+			err := chain.apply(n, test.order)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar/", nil))
+
+			assert.Equal(t, test.expectedChain, strings.Join(recorder.Header()["X-Chain"], ","))
+		})
+	}
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	wrapper, err := newHandlerWrapper(func(next http.Handler) (http.Handler, error) {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Denied") != "" {
+				rw.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			rw.Header().Set("X-Wrapped", "true")
+			next.ServeHTTP(rw, req)
+		}), nil
+	})
+	require.NoError(t, err)
+
+	n := negroni.New(wrapper)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("backend"))
+	}))
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.bar/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("X-Wrapped"))
+	assert.Equal(t, "backend", recorder.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "http://foo.bar/", nil)
+	req.Header.Set("X-Denied", "1")
+	recorder = httptest.NewRecorder()
+	n.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// ocspRetryInterval is the delay before fetching again an OCSP response after a failure.
+	ocspRetryInterval = 5 * time.Minute
+	// ocspDefaultValidity is the validity assumed for the OCSP responses without next update.
+	ocspDefaultValidity = time.Hour
+	// ocspUnusedExpiration is the duration after which the OCSP response of a certificate no longer served is dropped.
+	ocspUnusedExpiration = 24 * time.Hour
+)
+
+// ocspStapler fetches the OCSP responses of the served certificates from their responders,
+// caches them, staples them in the TLS handshakes and refreshes them before they expire.
+type ocspStapler struct {
+	client        *http.Client
+	checkInterval time.Duration
+
+	lock    sync.Mutex
+	entries map[[sha256.Size]byte]*ocspEntry
+	fetches chan *ocspEntry
+}
+
+type ocspEntry struct {
+	leaf   *x509.Certificate
+	issuer *x509.Certificate
+
+	// response is the last valid OCSP response, nil if none
+	response    []byte
+	nextUpdate  time.Time
+	nextFetch   time.Time
+	fetching    bool
+	lastUsed    time.Time
+	unsupported bool
+}
+
+func newOCSPStapler(config *configuration.OCSPStapling) *ocspStapler {
+	timeout := time.Duration(config.Timeout)
+	if timeout <= 0 {
+		timeout = configuration.DefaultOCSPTimeout
+	}
+
+	return &ocspStapler{
+		client:        &http.Client{Timeout: timeout},
+		checkInterval: time.Minute,
+		entries:       make(map[[sha256.Size]byte]*ocspEntry),
+		fetches:       make(chan *ocspEntry, 100),
+	}
+}
+
+// wrapGetCertificate staples the OCSP responses of the certificates returned by getCertificate,
+// or by the fallback of the TLS configuration to its static certificates.
+func (o *ocspStapler) wrapGetCertificate(config *tls.Config, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		var certificate *tls.Certificate
+		if getCertificate != nil {
+			var err error
+			certificate, err = getCertificate(clientHello)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if certificate == nil {
+			certificate = staticCertificate(config, clientHello.ServerName)
+		}
+		return o.staple(certificate), nil
+	}
+}
+
+// prefetch fetches in the background the OCSP responses of the certificates, before they are served.
+func (o *ocspStapler) prefetch(certificates *traefikTls.DomainsCertificates) {
+	if certificates == nil {
+		return
+	}
+	for _, certificate := range *certificates {
+		o.staple(certificate)
+	}
+}
+
+// staticCertificate returns the certificate of the TLS configuration matching the server name as the TLS server would:
+// the certificate of the name, else of its wildcard, else the first certificate.
+func staticCertificate(config *tls.Config, serverName string) *tls.Certificate {
+	if len(config.Certificates) == 0 {
+		return nil
+	}
+	if certificate, ok := config.NameToCertificate[serverName]; ok {
+		return certificate
+	}
+	if index := strings.Index(serverName, "."); index > 0 {
+		if certificate, ok := config.NameToCertificate["*"+serverName[index:]]; ok {
+			return certificate
+		}
+	}
+	return &config.Certificates[0]
+}
+
+// staple returns a copy of the certificate with its OCSP response, when it is known.
+// The response of a new certificate is fetched in the background.
+func (o *ocspStapler) staple(certificate *tls.Certificate) *tls.Certificate {
+	if certificate == nil || len(certificate.Certificate) == 0 || certificate.OCSPStaple != nil {
+		return certificate
+	}
+
+	key := ocspKey(certificate)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	entry, ok := o.entries[key]
+	if !ok {
+		entry = newOCSPEntry(certificate)
+		o.entries[key] = entry
+	}
+	entry.lastUsed = time.Now()
+
+	if entry.response == nil {
+		o.scheduleFetch(entry)
+		return certificate
+	}
+
+	stapled := *certificate
+	stapled.OCSPStaple = entry.response
+	return &stapled
+}
+
+// ocspKey identifies the OCSP entry of the certificate by the hash of its leaf.
+func ocspKey(certificate *tls.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(certificate.Certificate[0])
+}
+
+func newOCSPEntry(certificate *tls.Certificate) *ocspEntry {
+	entry := &ocspEntry{unsupported: true}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil || len(leaf.OCSPServer) == 0 || len(certificate.Certificate) < 2 {
+		return entry
+	}
+	issuer, err := x509.ParseCertificate(certificate.Certificate[1])
+	if err != nil {
+		return entry
+	}
+
+	return &ocspEntry{leaf: leaf, issuer: issuer}
+}
+
+// scheduleFetch queues the fetch of the entry, when it's due and not already queued.
+// The caller must hold the lock.
+func (o *ocspStapler) scheduleFetch(entry *ocspEntry) {
+	if entry.unsupported || entry.fetching || time.Now().Before(entry.nextFetch) {
+		return
+	}
+
+	select {
+	case o.fetches <- entry:
+		entry.fetching = true
+	default:
+		log.Debugf("Too many pending OCSP requests, the response for %s will be fetched later", entry.leaf.Subject.CommonName)
+	}
+}
+
+func (o *ocspStapler) run(ctx context.Context) {
+	ticker := time.NewTicker(o.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-o.fetches:
+			o.fetch(ctx, entry)
+		case <-ticker.C:
+			o.refresh()
+		}
+	}
+}
+
+// refresh schedules the fetch of the responses due, drops the expired responses
+// and the entries of the certificates no longer served.
+func (o *ocspStapler) refresh() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	now := time.Now()
+	for key, entry := range o.entries {
+		if now.Sub(entry.lastUsed) > ocspUnusedExpiration || (entry.leaf != nil && now.After(entry.leaf.NotAfter)) {
+			delete(o.entries, key)
+			continue
+		}
+		if entry.response != nil && now.After(entry.nextUpdate) {
+			entry.response = nil
+		}
+		o.scheduleFetch(entry)
+	}
+}
+
+func (o *ocspStapler) fetch(ctx context.Context, entry *ocspEntry) {
+	response, parsedResponse, err := o.request(ctx, entry.leaf, entry.issuer)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	entry.fetching = false
+	now := time.Now()
+	if err != nil {
+		log.Warnf("Unable to fetch the OCSP response for %s from %s: %v", entry.leaf.Subject.CommonName, entry.leaf.OCSPServer[0], err)
+		entry.nextFetch = now.Add(ocspRetryInterval)
+		return
+	}
+
+	entry.response = response
+	entry.nextUpdate = parsedResponse.NextUpdate
+	if entry.nextUpdate.IsZero() {
+		entry.nextUpdate = now.Add(ocspDefaultValidity)
+	}
+	// refresh at the half of the validity, for the new response to be fetched while the current one is still valid
+	entry.nextFetch = now.Add(entry.nextUpdate.Sub(now) / 2)
+	log.Debugf("OCSP response for %s fetched, refreshed at %s", entry.leaf.Subject.CommonName, entry.nextFetch)
+}
+
+func (o *ocspStapler) request(ctx context.Context, leaf, issuer *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	ocspRequest, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, leaf.OCSPServer[0], bytes.NewReader(ocspRequest))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	resp, err := o.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	response, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsedResponse, err := ocsp.ParseResponse(response, issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if parsedResponse.Status == ocsp.ServerFailed {
+		return nil, nil, errors.New("the responder failed")
+	}
+	if parsedResponse.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		return nil, nil, errors.New("the response is not for the certificate")
+	}
+	if parsedResponse.Status == ocsp.Unknown {
+		return nil, nil, errors.New("the certificate is unknown to the responder")
+	}
+	if !parsedResponse.NextUpdate.IsZero() && time.Now().After(parsedResponse.NextUpdate) {
+		return nil, nil, errors.New("the response has expired")
+	}
+	return response, parsedResponse, nil
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+// The ASN.1 structures of the OCSP responses (RFC 6960), to build them in the tests.
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag `asn1:"tag:0,optional"`
+	ThisUpdate time.Time `asn1:"generalized"`
+	NextUpdate time.Time `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	KeyHash    []byte    `asn1:"explicit,tag:2"`
+	ProducedAt time.Time `asn1:"generalized"`
+	Responses  []ocspSingleResponse
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0"`
+}
+
+// createOCSPResponse returns a good OCSP response for the serial number, signed by the issuer.
+func createOCSPResponse(t *testing.T, issuerKey *rsa.PrivateKey, serialNumber *big.Int, thisUpdate, nextUpdate time.Time) []byte {
+	keyHash := sha256.Sum256(issuerKey.PublicKey.N.Bytes())
+	tbsResponseData, err := asn1.Marshal(ocspResponseData{
+		KeyHash:    keyHash[:20],
+		ProducedAt: time.Now().UTC().Truncate(time.Second),
+		Responses: []ocspSingleResponse{{
+			CertID: ocspCertID{
+				HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Parameters: asn1.NullRawValue},
+				NameHash:      make([]byte, 20),
+				IssuerKeyHash: make([]byte, 20),
+				SerialNumber:  serialNumber,
+			},
+			Good:       true,
+			ThisUpdate: thisUpdate.UTC().Truncate(time.Second),
+			NextUpdate: nextUpdate.UTC().Truncate(time.Second),
+		}},
+	})
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(tbsResponseData)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, issuerKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	basicResponse, err := asn1.Marshal(ocspBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbsResponseData},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, Parameters: asn1.NullRawValue},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	})
+	require.NoError(t, err)
+
+	response, err := asn1.Marshal(ocspResponse{
+		Response: ocspResponseBytes{
+			ResponseType: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1},
+			Response:     basicResponse,
+		},
+	})
+	require.NoError(t, err)
+	return response
+}
+
+// newOCSPTestCertificate returns a certificate and its issuer, the certificate having the OCSP responder.
+func newOCSPTestCertificate(t *testing.T, responder string) (*tls.Certificate, *x509.Certificate, *rsa.PrivateKey) {
+	issuerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "OCSP test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, &issuerKey.PublicKey, issuerKey)
+	require.NoError(t, err)
+	issuer, err := x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "ocsp.example.com"},
+		DNSNames:     []string{"ocsp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	if len(responder) > 0 {
+		template.OCSPServer = []string{responder}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	require.NoError(t, err)
+
+	return &tls.Certificate{Certificate: [][]byte{der, issuerDER}, PrivateKey: key}, issuer, issuerKey
+}
+
+func TestOCSPStapler(t *testing.T) {
+	var responder http.HandlerFunc
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		responder(rw, req)
+	}))
+	defer ts.Close()
+
+	certificate, issuer, issuerKey := newOCSPTestCertificate(t, ts.URL)
+
+	var requests int
+	responder = func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		assert.Equal(t, "application/ocsp-request", req.Header.Get("Content-Type"))
+		rw.Write(createOCSPResponse(t, issuerKey, big.NewInt(42), time.Now().Add(-time.Hour), time.Now().Add(4*time.Hour)))
+	}
+
+	stapler := newOCSPStapler(&configuration.OCSPStapling{Timeout: flaeg.Duration(time.Second)})
+
+	stapled := stapler.staple(certificate)
+	assert.Nil(t, stapled.OCSPStaple, "the response isn't fetched yet")
+	require.Len(t, stapler.fetches, 1)
+
+	stapler.staple(certificate)
+	assert.Len(t, stapler.fetches, 1, "the fetch of the response should be queued once")
+
+	stapler.fetch(context.Background(), <-stapler.fetches)
+	assert.Equal(t, 1, requests)
+
+	stapled = stapler.staple(certificate)
+	require.NotNil(t, stapled.OCSPStaple)
+	assert.Nil(t, certificate.OCSPStaple, "the served certificate should not be modified")
+	response, err := ocsp.ParseResponse(stapled.OCSPStaple, issuer)
+	require.NoError(t, err)
+	assert.Equal(t, ocsp.Good, response.Status)
+	assert.Len(t, stapler.fetches, 0, "the response should not be fetched before the half of its validity")
+
+	entry := stapler.entries[ocspKey(certificate)]
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), entry.nextFetch, time.Minute)
+
+	// the response is refreshed when due, and kept if the responder fails
+	responder = func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+	entry.nextFetch = time.Now().Add(-time.Second)
+	stapler.refresh()
+	require.Len(t, stapler.fetches, 1)
+	stapler.fetch(context.Background(), <-stapler.fetches)
+	assert.Equal(t, 2, requests)
+	assert.NotNil(t, stapler.staple(certificate).OCSPStaple)
+	assert.WithinDuration(t, time.Now().Add(ocspRetryInterval), entry.nextFetch, time.Minute)
+
+	// the response is dropped once expired
+	entry.nextUpdate = time.Now().Add(-time.Second)
+	stapler.refresh()
+	assert.Nil(t, stapler.staple(certificate).OCSPStaple)
+
+	// the entry is dropped when the certificate is no longer served
+	entry.lastUsed = time.Now().Add(-ocspUnusedExpiration - time.Minute)
+	stapler.refresh()
+	assert.Empty(t, stapler.entries)
+}
+
+func TestOCSPStaplerWithoutResponder(t *testing.T) {
+	certificate, _, _ := newOCSPTestCertificate(t, "")
+	stapler := newOCSPStapler(&configuration.OCSPStapling{})
+
+	stapled := stapler.staple(certificate)
+	assert.Nil(t, stapled.OCSPStaple)
+	assert.Len(t, stapler.fetches, 0)
+
+	stapler.refresh()
+	assert.Len(t, stapler.fetches, 0)
+}
+
+func TestStaticCertificate(t *testing.T) {
+	first := &tls.Certificate{Certificate: [][]byte{[]byte("first")}}
+	exact := &tls.Certificate{Certificate: [][]byte{[]byte("exact")}}
+	wildcard := &tls.Certificate{Certificate: [][]byte{[]byte("wildcard")}}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{*first},
+		NameToCertificate: map[string]*tls.Certificate{
+			"www.example.com": exact,
+			"*.example.com":   wildcard,
+		},
+	}
+
+	assert.Equal(t, exact, staticCertificate(config, "www.example.com"))
+	assert.Equal(t, wildcard, staticCertificate(config, "api.example.com"))
+	assert.Equal(t, first, staticCertificate(config, "example.org"))
+	assert.Nil(t, staticCertificate(&tls.Config{}, "example.org"))
+}
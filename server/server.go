@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -24,6 +25,8 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/armon/go-proxyproto"
 	"github.com/containous/mux"
+	"github.com/containous/traefik/acme"
+	"github.com/containous/traefik/audit"
 	"github.com/containous/traefik/cluster"
 	"github.com/containous/traefik/configuration"
 	"github.com/containous/traefik/healthcheck"
@@ -32,10 +35,12 @@ import (
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/middlewares/accesslog"
 	mauth "github.com/containous/traefik/middlewares/auth"
+	"github.com/containous/traefik/ping"
 	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/server/cookie"
 	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
 	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/whitelist"
 	"github.com/eapache/channels"
@@ -62,6 +67,20 @@ type Server struct {
 	stopChan                      chan bool
 	providers                     []provider.Provider
 	currentConfigurations         safe.Safe
+	shadowConfigurations          safe.Safe
+	shadowLock                    sync.Mutex
+	backendQueues                 *middlewares.BackendQueues
+	maintenanceModes              *middlewares.MaintenanceModes
+	responseCaches                *middlewares.ResponseCaches
+	serverStartTimes              serverStartTimes
+	serverDrainer                 *middlewares.ServerDrainer
+	dnsResolver                   *dnsResolver
+	webhookNotifier               *webhookNotifier
+	auditLog                      *audit.Log
+	providerStatuses              *ping.ProviderStatuses
+	alerter                       *alerter
+	ocspStapler                   *ocspStapler
+	vaultPKI                      *vaultPKI
 	globalConfiguration           configuration.GlobalConfiguration
 	accessLoggerMiddleware        *accesslog.LogHandler
 	routinesPool                  *safe.Pool
@@ -77,6 +96,8 @@ type serverEntryPoint struct {
 	listener   net.Listener
 	httpRouter *middlewares.HandlerSwitcher
 	certs      safe.Safe
+	// certificatesDirectory holds the certificates loaded from the directory of the entry point, if any
+	certificatesDirectory *certificatesDirectory
 }
 
 type serverRoute struct {
@@ -101,18 +122,86 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration) *Server {
 	server.configureSignals()
 	currentConfigurations := make(types.Configurations)
 	server.currentConfigurations.Set(currentConfigurations)
+	server.shadowConfigurations.Set(make(types.ShadowConfigurations))
+	server.backendQueues = middlewares.NewBackendQueues()
+	server.maintenanceModes = middlewares.NewMaintenanceModes()
+	server.responseCaches = middlewares.NewResponseCaches()
+	server.providerStatuses = ping.NewProviderStatuses()
+	if globalConfiguration.LifeCycle != nil && globalConfiguration.LifeCycle.DrainTimeout > 0 {
+		server.serverDrainer = middlewares.NewServerDrainer(time.Duration(globalConfiguration.LifeCycle.DrainTimeout))
+	}
 	server.globalConfiguration = globalConfiguration
+	if server.globalConfiguration.Ping != nil {
+		server.globalConfiguration.Ping.Statuses = server.providerStatuses
+	}
 	if server.globalConfiguration.API != nil {
 		server.globalConfiguration.API.CurrentConfigurations = &server.currentConfigurations
+		server.globalConfiguration.API.ShadowConfigurations = &server.shadowConfigurations
+		server.globalConfiguration.API.BackendQueues = server.backendQueues
+		server.globalConfiguration.API.MaintenanceModes = server.maintenanceModes
+		server.globalConfiguration.API.ResponseCaches = server.responseCaches
+		if statistics := server.globalConfiguration.API.Statistics; statistics != nil && server.globalConfiguration.API.BackendStatsRecorder == nil {
+			server.globalConfiguration.API.BackendStatsRecorder = middlewares.NewBackendStatsRecorder(time.Duration(statistics.BackendWindow), time.Duration(statistics.BackendRetention))
+		}
+		if statistics := server.globalConfiguration.API.Statistics; statistics != nil && server.globalConfiguration.API.FrontendUsageRecorder == nil {
+			server.globalConfiguration.API.FrontendUsageRecorder = middlewares.NewFrontendUsageRecorder(time.Duration(statistics.FrontendUnusedWindow))
+		}
 	}
 
 	server.routinesPool = safe.NewPool(context.Background())
-	server.defaultForwardingRoundTripper = createHTTPTransport(globalConfiguration)
+	if globalConfiguration.DNSResolver != nil {
+		resolver, err := newDNSResolver(globalConfiguration.DNSResolver)
+		if err != nil {
+			log.Errorf("Unable to create the DNS resolver, the backend host names are resolved by the OS: %v", err)
+		} else {
+			server.dnsResolver = resolver
+		}
+	}
+	server.defaultForwardingRoundTripper = server.withDNSResolver(createHTTPTransport(globalConfiguration))
+
+	if globalConfiguration.ConfigurationWebhooks != nil && len(globalConfiguration.ConfigurationWebhooks.URLs) > 0 {
+		server.webhookNotifier = newWebhookNotifier(globalConfiguration.ConfigurationWebhooks)
+	}
+	if globalConfiguration.Audit != nil {
+		auditLog, err := newAuditLog(globalConfiguration.Audit)
+		if err != nil {
+			log.Errorf("Unable to create the audit log: %v", err)
+		} else {
+			server.auditLog = auditLog
+			if server.globalConfiguration.API != nil {
+				server.globalConfiguration.API.AuditLog = auditLog
+			}
+		}
+	}
+	if globalConfiguration.Alerts != nil && len(globalConfiguration.Alerts.URL) > 0 {
+		alerter, err := newAlerter(globalConfiguration.Alerts, server.servedCertificates, healthcheck.GetHealthCheck().UnhealthyServers)
+		if err != nil {
+			log.Errorf("Unable to create the alerts: %v", err)
+		} else {
+			server.alerter = alerter
+		}
+	}
+	if globalConfiguration.OCSPStapling != nil {
+		server.ocspStapler = newOCSPStapler(globalConfiguration.OCSPStapling)
+	}
+	if globalConfiguration.VaultPKI != nil {
+		vaultPKI, err := newVaultPKI(globalConfiguration.VaultPKI)
+		if err != nil {
+			log.Errorf("Unable to request the certificates from Vault: %v", err)
+		} else if entryPoint, ok := globalConfiguration.EntryPoints[vaultPKI.entryPoint]; !ok || entryPoint.TLS == nil {
+			log.Errorf("Unable to request the certificates from Vault: the entry point %s is unknown or without TLS", vaultPKI.entryPoint)
+		} else {
+			server.vaultPKI = vaultPKI
+		}
+	}
 
 	server.metricsRegistry = metrics.NewVoidRegistry()
 	if globalConfiguration.Metrics != nil {
 		server.registerMetricClients(globalConfiguration.Metrics)
 	}
+	for _, resolver := range server.acmeResolvers() {
+		resolver.SetMetricsRegistry(server.metricsRegistry)
+	}
 
 	if globalConfiguration.Cluster != nil {
 		// leadership creation if cluster mode
@@ -139,6 +228,14 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration) *Server {
 // in Traefik at this point in time. Setting this value to the default of 100 could lead to confusing
 // behaviour and backwards compatibility issues.
 func createHTTPTransport(globalConfiguration configuration.GlobalConfiguration) *http.Transport {
+	return createHTTPTransportWithTLS(globalConfiguration, createBackendTLSConfig(globalConfiguration))
+}
+
+// createHTTPTransportWithTLS creates an http.Transport using the given TLS client configuration.
+// The TLS configuration is set before configuring HTTP/2 so that "h2" and "http/1.1" are offered
+// through ALPN to HTTPS backends: backends supporting HTTP/2 are then multiplexed over a single
+// connection, the others keep on using HTTP/1.1.
+func createHTTPTransportWithTLS(globalConfiguration configuration.GlobalConfiguration, tlsConfig *tls.Config) *http.Transport {
 	dialer := &net.Dialer{
 		Timeout:   configuration.DefaultDialTimeout,
 		KeepAlive: 30 * time.Second,
@@ -155,21 +252,63 @@ func createHTTPTransport(globalConfiguration configuration.GlobalConfiguration)
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
 	}
 	if globalConfiguration.ForwardingTimeouts != nil {
 		transport.ResponseHeaderTimeout = time.Duration(globalConfiguration.ForwardingTimeouts.ResponseHeaderTimeout)
 	}
-	if globalConfiguration.InsecureSkipVerify {
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	if err := http2.ConfigureTransport(transport); err != nil {
+		log.Errorf("Unable to enable HTTP/2 to backends: %s", err)
+	}
+
+	return transport
+}
+
+// createBackendTLSConfig creates the TLS client configuration used to reach the backends.
+func createBackendTLSConfig(globalConfiguration configuration.GlobalConfiguration) *tls.Config {
+	if !globalConfiguration.InsecureSkipVerify && len(globalConfiguration.RootCAs) == 0 {
+		return nil
 	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: globalConfiguration.InsecureSkipVerify}
 	if len(globalConfiguration.RootCAs) > 0 {
-		transport.TLSClientConfig = &tls.Config{
-			RootCAs: createRootCACertPool(globalConfiguration.RootCAs),
-		}
+		tlsConfig.RootCAs = createRootCACertPool(globalConfiguration.RootCAs)
 	}
-	http2.ConfigureTransport(transport)
+	return tlsConfig
+}
 
-	return transport
+// applyBackendTLS sets the client certificate and the CA of a backend to its TLS client configuration.
+func applyBackendTLS(tlsConfig *tls.Config, backendTLS *types.BackendTLS) error {
+	if backendTLS.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if len(backendTLS.CA) > 0 {
+		ca, err := traefikTls.FileOrContent(backendTLS.CA).Read()
+		if err != nil {
+			return fmt.Errorf("unable to read the backend CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return errors.New("invalid backend CA certificate(s)")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(backendTLS.Cert) > 0 || len(backendTLS.Key) > 0 {
+		cert, err := traefikTls.FileOrContent(backendTLS.Cert).Read()
+		if err != nil {
+			return fmt.Errorf("unable to read the backend client certificate: %v", err)
+		}
+		key, err := traefikTls.FileOrContent(backendTLS.Key).Read()
+		if err != nil {
+			return fmt.Errorf("unable to read the key of the backend client certificate: %v", err)
+		}
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return fmt.Errorf("invalid backend client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return nil
 }
 
 func createRootCACertPool(rootCAs traefikTls.RootCAs) *x509.CertPool {
@@ -197,6 +336,24 @@ func (s *Server) Start() {
 	s.routinesPool.Go(func(stop chan bool) {
 		s.listenConfigurations(stop)
 	})
+	if s.webhookNotifier != nil {
+		s.routinesPool.GoCtx(s.webhookNotifier.run)
+	}
+	if s.ocspStapler != nil {
+		s.routinesPool.GoCtx(s.ocspStapler.run)
+	}
+	if s.alerter != nil {
+		s.routinesPool.GoCtx(s.alerter.run)
+	}
+	if s.dnsResolver != nil && s.dnsResolver.refresh {
+		s.routinesPool.GoCtx(s.dnsResolver.run)
+	}
+	if s.vaultPKI != nil {
+		s.routinesPool.GoCtx(s.vaultPKI.run)
+	}
+	if s.metricsRegistry.IsEnabled() {
+		s.routinesPool.GoCtx(s.reportCertificateMetrics)
+	}
 	s.configureProviders()
 	s.startProviders()
 	go s.listenSignals()
@@ -255,6 +412,11 @@ func (s *Server) Close() {
 			log.Errorf("Error closing access log file: %s", err)
 		}
 	}
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			log.Errorf("Error closing audit log file: %s", err)
+		}
+	}
 	cancel()
 }
 
@@ -282,6 +444,13 @@ func (s *Server) startHTTPServers() {
 func (s *Server) setupServerEntryPoint(newServerEntryPointName string, newServerEntryPoint *serverEntryPoint) *serverEntryPoint {
 	serverMiddlewares := []negroni.Handler{middlewares.NegroniRecoverHandler()}
 	serverInternalMiddlewares := []negroni.Handler{middlewares.NegroniRecoverHandler()}
+	if s.globalConfiguration.RequestID != nil {
+		requestIDMiddleware, err := middlewares.NewRequestID(s.globalConfiguration.RequestID)
+		if err != nil {
+			log.Fatal("Error starting server: ", err)
+		}
+		serverMiddlewares = append(serverMiddlewares, requestIDMiddleware)
+	}
 	if s.accessLoggerMiddleware != nil {
 		serverMiddlewares = append(serverMiddlewares, s.accessLoggerMiddleware)
 	}
@@ -309,8 +478,12 @@ func (s *Server) setupServerEntryPoint(newServerEntryPointName string, newServer
 		serverMiddlewares = append(serverMiddlewares, authMiddleware)
 		serverInternalMiddlewares = append(serverInternalMiddlewares, authMiddleware)
 	}
-	if s.globalConfiguration.EntryPoints[newServerEntryPointName].Compress {
-		serverMiddlewares = append(serverMiddlewares, &middlewares.Compress{})
+	if s.globalConfiguration.EntryPoints[newServerEntryPointName].Compress || s.globalConfiguration.EntryPoints[newServerEntryPointName].Compression != nil {
+		compressMiddleware, err := middlewares.NewCompress(s.globalConfiguration.EntryPoints[newServerEntryPointName].Compression)
+		if err != nil {
+			log.Fatal("Error starting server: ", err)
+		}
+		serverMiddlewares = append(serverMiddlewares, compressMiddleware)
 	}
 	if len(s.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange) > 0 {
 		ipWhitelistMiddleware, err := middlewares.NewIPWhitelister(s.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange)
@@ -352,7 +525,11 @@ func (s *Server) preLoadConfiguration(configMsg types.ConfigMessage) {
 	currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
 	jsonConf, _ := json.Marshal(configMsg.Configuration)
 	log.Debugf("Configuration received from provider %s: %s", configMsg.ProviderName, string(jsonConf))
-	if configMsg.Configuration == nil || configMsg.Configuration.Backends == nil && configMsg.Configuration.Frontends == nil && configMsg.Configuration.TLSConfiguration == nil {
+	s.providerStatuses.Refreshed(configMsg.ProviderName)
+	if s.isShadowProvider(configMsg.ProviderName) {
+		log.Infof("Comparing the configuration of the shadow provider %s without applying it", configMsg.ProviderName)
+		s.loadShadowConfiguration(configMsg)
+	} else if configMsg.Configuration == nil || configMsg.Configuration.Backends == nil && configMsg.Configuration.Frontends == nil && configMsg.Configuration.TLSConfiguration == nil {
 		log.Infof("Skipping empty Configuration for provider %s", configMsg.ProviderName)
 	} else if reflect.DeepEqual(currentConfigurations[configMsg.ProviderName], configMsg.Configuration) {
 		log.Infof("Skipping same configuration for provider %s", configMsg.ProviderName)
@@ -440,10 +617,40 @@ func (s *Server) loadConfiguration(configMsg types.ConfigMessage) {
 			log.Infof("Server configuration reloaded on %s", s.serverEntryPoints[newServerEntryPointName].httpServer.Addr)
 		}
 		s.currentConfigurations.Set(newConfigurations)
+		if s.serverDrainer != nil {
+			s.serverDrainer.Retain(getBackendServers(newConfigurations))
+		}
+		s.updateShadowConfigurations()
+		s.notifyWebhooks(configMsg.ProviderName, currentConfigurations, newConfigurations)
+		s.recordAudit(configMsg.ProviderName, currentConfigurations, newConfigurations)
+		s.providerStatuses.Applied(configMsg.ProviderName)
 		s.postLoadConfiguration()
 	} else {
 		log.Error("Error loading new configuration, aborted ", err)
+		s.providerStatuses.Failed(configMsg.ProviderName, err)
+	}
+}
+
+// BuildHandlers builds, for each entry point of the global configuration, the HTTP handler routing
+// requests according to the given provider configurations.
+// No listener is opened and no provider is started: this allows other programs to embed the Traefik
+// routing engine (e.g. behind their own http.Server or in tests) and to feed it their own configurations.
+// Entry point level middlewares (access log, metrics, authentication, ...) are not part of the handlers.
+func (s *Server) BuildHandlers(configurations types.Configurations) (map[string]http.Handler, error) {
+	for _, config := range configurations {
+		s.defaultConfigurationValues(config)
 	}
+
+	serverEntryPoints, err := s.loadConfig(configurations, s.globalConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := make(map[string]http.Handler, len(serverEntryPoints))
+	for entryPointName, serverEntryPoint := range serverEntryPoints {
+		handlers[entryPointName] = serverEntryPoint.httpRouter
+	}
+	return handlers, nil
 }
 
 // loadHTTPSConfiguration add/delete HTTPS certificate managed dynamically
@@ -457,57 +664,108 @@ func (s *Server) loadHTTPSConfiguration(configurations types.Configurations) (ma
 			}
 		}
 	}
+	for entryPointName, certificates := range newEPCertificates {
+		s.validateCertificates(entryPointName, certificates)
+	}
 	return newEPCertificates, nil
 }
 
+// validateCertificates refuses the new or changed certificates of the entry point which fail the validation:
+// the certificate currently served for the same domains is kept, if any.
+func (s *Server) validateCertificates(entryPointName string, newCertificates *traefikTls.DomainsCertificates) {
+	var currentCertificates traefikTls.DomainsCertificates
+	if serverEntryPoint, ok := s.serverEntryPoints[entryPointName]; ok && serverEntryPoint.certs.Get() != nil {
+		currentCertificates = *serverEntryPoint.certs.Get().(*traefikTls.DomainsCertificates)
+	}
+
+	for domains, cert := range *newCertificates {
+		current := currentCertificates[domains]
+		if current != nil && len(current.Certificate) > 0 && bytes.Equal(current.Certificate[0], cert.Certificate[0]) {
+			continue
+		}
+
+		err := traefikTls.ValidateCertificate(cert)
+		if err == nil {
+			continue
+		}
+		if current != nil {
+			log.Errorf("Refusing the new certificate for domains %s on entry point %s, the current certificate is kept: %v", domains, entryPointName, err)
+			(*newCertificates)[domains] = current
+		} else {
+			log.Errorf("Refusing the certificate for domains %s on entry point %s: %v", domains, entryPointName, err)
+			delete(*newCertificates, domains)
+		}
+	}
+}
+
 // getCertificate allows to customize tlsConfig.Getcertificate behaviour to get the certificates inserted dynamically
 func (s *serverEntryPoint) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	if s.certs.Get() != nil {
-		domainToCheck := types.CanonicalDomain(clientHello.ServerName)
-		for domains, cert := range *s.certs.Get().(*traefikTls.DomainsCertificates) {
-			for _, domain := range strings.Split(domains, ",") {
-				selector := "^" + strings.Replace(domain, "*.", "[^\\.]*\\.?", -1) + "$"
-				domainCheck, _ := regexp.MatchString(selector, domainToCheck)
-				if domainCheck {
-					return cert, nil
-				}
+		return matchDomainsCertificates(s.certs.Get().(*traefikTls.DomainsCertificates), clientHello.ServerName), nil
+	}
+	return nil, nil
+}
+
+// matchDomainsCertificates returns the certificate whose domains match the server name, nil if none.
+func matchDomainsCertificates(certificates *traefikTls.DomainsCertificates, serverName string) *tls.Certificate {
+	domainToCheck := types.CanonicalDomain(serverName)
+	for domains, cert := range *certificates {
+		for _, domain := range strings.Split(domains, ",") {
+			selector := "^" + strings.Replace(domain, "*.", "[^\\.]*\\.?", -1) + "$"
+			domainCheck, _ := regexp.MatchString(selector, domainToCheck)
+			if domainCheck {
+				return cert
 			}
 		}
 	}
-	return nil, nil
+	return nil
 }
 
 func (s *Server) postLoadConfiguration() {
-	if s.globalConfiguration.ACME == nil {
+	if s.vaultPKI != nil && s.vaultPKI.onHostRule {
+		// each instance requests its own certificates from Vault, whether or not it's the leader
+		s.vaultPKI.setFrontendDomains(s.currentConfigurations.Get().(types.Configurations))
+	}
+
+	resolvers := s.acmeResolvers()
+	if len(resolvers) == 0 {
 		return
 	}
 	if s.leadership != nil && !s.leadership.IsLeader() {
 		return
 	}
-	if s.globalConfiguration.ACME.OnHostRule {
-		currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
-		for _, config := range currentConfigurations {
-			for _, frontend := range config.Frontends {
-
-				// check if one of the frontend entrypoints is configured with TLS
-				// and is configured with ACME
-				ACMEEnabled := false
-				for _, entryPoint := range frontend.EntryPoints {
-					if s.globalConfiguration.ACME.EntryPoint == entryPoint && s.globalConfiguration.EntryPoints[entryPoint].TLS != nil {
-						ACMEEnabled = true
-						break
-					}
+	currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
+	for _, config := range currentConfigurations {
+		for frontendName, frontend := range config.Frontends {
+			resolver, ok := resolvers[frontend.ACMEResolver]
+			if !ok {
+				if len(frontend.ACMEResolver) > 0 {
+					log.Errorf("Unknown ACME resolver %s for frontend %s", frontend.ACMEResolver, frontendName)
 				}
+				continue
+			}
+			if !resolver.OnHostRule {
+				continue
+			}
 
-				if ACMEEnabled {
-					for _, route := range frontend.Routes {
-						rules := Rules{}
-						domains, err := rules.ParseDomains(route.Rule)
-						if err != nil {
-							log.Errorf("Error parsing domains: %v", err)
-						} else {
-							s.globalConfiguration.ACME.LoadCertificateForDomains(domains)
-						}
+			// check if one of the frontend entrypoints is configured with TLS
+			// and is configured with ACME
+			ACMEEnabled := false
+			for _, entryPoint := range frontend.EntryPoints {
+				if resolver.EntryPoint == entryPoint && s.globalConfiguration.EntryPoints[entryPoint].TLS != nil {
+					ACMEEnabled = true
+					break
+				}
+			}
+
+			if ACMEEnabled {
+				for _, route := range frontend.Routes {
+					rules := Rules{}
+					domains, err := rules.ParseDomains(route.Rule)
+					if err != nil {
+						log.Errorf("Error parsing domains: %v", err)
+					} else {
+						resolver.LoadCertificateForDomains(domains)
 					}
 				}
 			}
@@ -520,6 +778,9 @@ func (s *Server) configureProviders() {
 	if s.globalConfiguration.Docker != nil {
 		s.providers = append(s.providers, s.globalConfiguration.Docker)
 	}
+	if s.globalConfiguration.Compose != nil {
+		s.providers = append(s.providers, s.globalConfiguration.Compose)
+	}
 	if s.globalConfiguration.Marathon != nil {
 		s.providers = append(s.providers, s.globalConfiguration.Marathon)
 	}
@@ -557,6 +818,12 @@ func (s *Server) configureProviders() {
 	if s.globalConfiguration.ECS != nil {
 		s.providers = append(s.providers, s.globalConfiguration.ECS)
 	}
+	if s.globalConfiguration.CloudMap != nil {
+		s.providers = append(s.providers, s.globalConfiguration.CloudMap)
+	}
+	if s.globalConfiguration.Azure != nil {
+		s.providers = append(s.providers, s.globalConfiguration.Azure)
+	}
 	if s.globalConfiguration.Rancher != nil {
 		s.providers = append(s.providers, s.globalConfiguration.Rancher)
 	}
@@ -566,6 +833,18 @@ func (s *Server) configureProviders() {
 	if s.globalConfiguration.ServiceFabric != nil {
 		s.providers = append(s.providers, s.globalConfiguration.ServiceFabric)
 	}
+	if s.globalConfiguration.HTTP != nil {
+		s.providers = append(s.providers, s.globalConfiguration.HTTP)
+	}
+	if s.globalConfiguration.SRV != nil {
+		s.providers = append(s.providers, s.globalConfiguration.SRV)
+	}
+	if s.globalConfiguration.XDS != nil {
+		s.providers = append(s.providers, s.globalConfiguration.XDS)
+	}
+	if s.globalConfiguration.Plugin != nil {
+		s.providers = append(s.providers, s.globalConfiguration.Plugin)
+	}
 }
 
 func (s *Server) startProviders() {
@@ -579,6 +858,8 @@ func (s *Server) startProviders() {
 			err := currentProvider.Provide(s.configurationChan, s.routinesPool, s.globalConfiguration.Constraints)
 			if err != nil {
 				log.Errorf("Error starting provider %v: %s", providerType, err)
+				// the provider is unknown by the name of its configurations, as it failed before sending any
+				s.providerStatuses.Failed(strings.TrimPrefix(providerType.String(), "*"), err)
 			}
 		})
 	}
@@ -654,66 +935,120 @@ func (s *Server) createTLSConfig(entryPointName string, tlsOption *traefikTls.TL
 			}
 		}
 		config.ClientCAs = pool
-		if tlsOption.ClientCA.Optional {
-			config.ClientAuth = tls.VerifyClientCertIfGiven
-		} else {
-			config.ClientAuth = tls.RequireAndVerifyClientCert
-		}
+	}
+	config.ClientAuth, err = tlsOption.ClientCA.ClientAuthType()
+	if err != nil {
+		return nil, err
 	}
 
-	if s.globalConfiguration.ACME != nil {
-		if _, ok := s.serverEntryPoints[s.globalConfiguration.ACME.EntryPoint]; ok {
-			if entryPointName == s.globalConfiguration.ACME.EntryPoint {
-				checkOnDemandDomain := func(domain string) bool {
-					routeMatch := &mux.RouteMatch{}
-					router := router.GetHandler()
-					match := router.Match(&http.Request{URL: &url.URL{}, Host: domain}, routeMatch)
-					if match && routeMatch.Route != nil {
-						return true
-					}
-					return false
-				}
-				if s.leadership == nil {
-					err := s.globalConfiguration.ACME.CreateLocalConfig(config, &s.serverEntryPoints[entryPointName].certs, checkOnDemandDomain)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					err := s.globalConfiguration.ACME.CreateClusterConfig(s.leadership, config, &s.serverEntryPoints[entryPointName].certs, checkOnDemandDomain)
-					if err != nil {
-						return nil, err
-					}
-				}
+	config.GetCertificate = s.serverEntryPoints[entryPointName].getCertificate
+	resolvers := s.acmeResolvers()
+	if err := checkACMEResolvers(resolvers); err != nil {
+		return nil, err
+	}
+	var acmeEntryPoint bool
+	var domainsResolvers []*acme.ACME
+	for _, resolverName := range sortedACMEResolverNames(resolvers) {
+		resolver := resolvers[resolverName]
+		if _, ok := s.serverEntryPoints[resolver.EntryPoint]; !ok {
+			return nil, errors.New("Unknown entrypoint " + resolver.EntryPoint + " for ACME configuration")
+		}
+		if resolver.HTTPChallenge != nil {
+			if _, ok := s.serverEntryPoints[resolver.HTTPChallenge.EntryPoint]; !ok {
+				return nil, errors.New("Unknown entrypoint " + resolver.HTTPChallenge.EntryPoint + " for the ACME HTTP challenge")
+			}
+		}
+		if entryPointName != resolver.EntryPoint {
+			// the ACME certificates of the domains configured with the entry point are served after the provided ones
+			domainsResolvers = append(domainsResolvers, resolver)
+			continue
+		}
+
+		checkOnDemandDomain := func(domain string) bool {
+			routeMatch := &mux.RouteMatch{}
+			router := router.GetHandler()
+			match := router.Match(&http.Request{URL: &url.URL{}, Host: domain}, routeMatch)
+			if match && routeMatch.Route != nil {
+				return true
+			}
+			return false
+		}
+		previousGetCertificate := config.GetCertificate
+		if s.leadership == nil {
+			err := resolver.CreateLocalConfig(config, &s.serverEntryPoints[entryPointName].certs, checkOnDemandDomain)
+			if err != nil {
+				return nil, err
 			}
 		} else {
-			return nil, errors.New("Unknown entrypoint " + s.globalConfiguration.ACME.EntryPoint + " for ACME configuration")
+			err := resolver.CreateClusterConfig(s.leadership, config, &s.serverEntryPoints[entryPointName].certs, checkOnDemandDomain)
+			if err != nil {
+				return nil, err
+			}
 		}
-	} else {
-		config.GetCertificate = s.serverEntryPoints[entryPointName].getCertificate
+		if acmeEntryPoint {
+			// the resolvers sharing the entry point are asked in turn
+			config.GetCertificate = firstCertificate(previousGetCertificate, config.GetCertificate)
+		}
+		acmeEntryPoint = true
+	}
+	if len(domainsResolvers) > 0 {
+		getCertificate := config.GetCertificate
+		config.GetCertificate = func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certificate, err := getCertificate(clientHello)
+			if certificate != nil || err != nil {
+				return certificate, err
+			}
+			for _, resolver := range domainsResolvers {
+				if certificate := resolver.GetCertificateForEntryPoint(entryPointName, clientHello); certificate != nil {
+					return certificate, nil
+				}
+			}
+			return nil, nil
+		}
+	}
+	if s.vaultPKI != nil && s.vaultPKI.entryPoint == entryPointName {
+		if len(config.Certificates) == 0 {
+			// the default certificate is served until the certificates are issued by Vault
+			certificate, err := generate.DefaultCertificate()
+			if err != nil {
+				return nil, err
+			}
+			config.Certificates = append(config.Certificates, *certificate)
+		}
+		config.GetCertificate = s.vaultPKI.wrapGetCertificate(config.GetCertificate)
+	}
+	if len(tlsOption.CertificatesDirectory) > 0 {
+		directory := newCertificatesDirectory(tlsOption.CertificatesDirectory, entryPointName)
+		if err := directory.load(); err != nil {
+			return nil, fmt.Errorf("unable to load the certificates directory %s: %v", tlsOption.CertificatesDirectory, err)
+		}
+		s.routinesPool.GoCtx(directory.run)
+		s.serverEntryPoints[entryPointName].certificatesDirectory = directory
+		config.GetCertificate = directory.wrapGetCertificate(config.GetCertificate)
 	}
 	if len(config.Certificates) == 0 {
 		return nil, errors.New("No certificates found for TLS entrypoint " + entryPointName)
 	}
+	if len(tlsOption.DefaultCertificate) > 0 {
+		if err := setDefaultCertificate(config, tlsOption.DefaultCertificate); err != nil {
+			return nil, fmt.Errorf("invalid default certificate for TLS entrypoint %s: %v", entryPointName, err)
+		}
+	}
+	if tlsOption.StrictSNI {
+		config.GetConfigForClient = rejectMissingSNI
+	}
 	// BuildNameToCertificate parses the CommonName and SubjectAlternateName fields
 	// in each certificate and populates the config.NameToCertificate map.
 	config.BuildNameToCertificate()
-	//Set the minimum TLS version if set in the config TOML
-	if minConst, exists := traefikTls.MinVersion[s.globalConfiguration.EntryPoints[entryPointName].TLS.MinVersion]; exists {
-		config.PreferServerCipherSuites = true
-		config.MinVersion = minConst
-	}
-	//Set the list of CipherSuites if set in the config TOML
-	if s.globalConfiguration.EntryPoints[entryPointName].TLS.CipherSuites != nil {
-		//if our list of CipherSuites is defined in the entrypoint config, we can re-initilize the suites list as empty
-		config.CipherSuites = make([]uint16, 0)
-		for _, cipher := range s.globalConfiguration.EntryPoints[entryPointName].TLS.CipherSuites {
-			if cipherConst, exists := traefikTls.CipherSuites[cipher]; exists {
-				config.CipherSuites = append(config.CipherSuites, cipherConst)
-			} else {
-				//CipherSuite listed in the toml does not exist in our listed
-				return nil, errors.New("Invalid CipherSuite: " + cipher)
-			}
+	if s.ocspStapler != nil {
+		for i := range config.Certificates {
+			s.ocspStapler.staple(&config.Certificates[i])
 		}
+		s.ocspStapler.prefetch(epDomainsCertificatesTmp)
+		config.GetCertificate = s.ocspStapler.wrapGetCertificate(config, config.GetCertificate)
+	}
+	if err := s.globalConfiguration.EntryPoints[entryPointName].TLS.ApplyPolicy(config); err != nil {
+		return nil, err
 	}
 	return config, nil
 }
@@ -800,6 +1135,11 @@ func (s *Server) prepareServer(entryPointName string, entryPoint *configuration.
 		}
 	}
 
+	var connState func(net.Conn, http.ConnState)
+	if s.metricsRegistry.IsEnabled() {
+		connState = trackOpenConnections(s.metricsRegistry, entryPointName)
+	}
+
 	return &http.Server{
 			Addr:         entryPoint.Address,
 			Handler:      internalMuxRouter,
@@ -808,6 +1148,7 @@ func (s *Server) prepareServer(entryPointName string, entryPoint *configuration.
 			WriteTimeout: writeTimeout,
 			IdleTimeout:  idleTimeout,
 			ErrorLog:     httpServerLogger,
+			ConnState:    connState,
 		},
 		listener,
 		nil
@@ -826,6 +1167,11 @@ func (s *Server) buildInternalRouter(entryPointName, path string, internalMiddle
 	internalMuxRouter.Walk(wrapRoute(internalMiddlewares))
 
 	s.addInternalPublicRoutes(entryPointName, internalMuxSubrouter)
+
+	// the HTTP-01 challenges are answered on the root path, before the frontends
+	if resolvers := s.httpChallengeResolvers(entryPointName); len(resolvers) > 0 {
+		acme.AddHTTPChallengeRoute(internalMuxRouter, resolvers)
+	}
 	return internalMuxRouter
 }
 
@@ -870,22 +1216,109 @@ func (s *Server) buildEntryPoints(globalConfiguration configuration.GlobalConfig
 	return serverEntryPoints
 }
 
+// requestsClientCertificates returns whether the TLS configuration of an entry point requests client certificates.
+func requestsClientCertificates(tlsOption *traefikTls.TLS) bool {
+	if tlsOption == nil {
+		return false
+	}
+	if len(tlsOption.ClientCAFiles) > 0 {
+		return true
+	}
+	clientAuth, err := tlsOption.ClientCA.ClientAuthType()
+	return err == nil && clientAuth != tls.NoClientCert
+}
+
 // getRoundTripper will either use server.defaultForwardingRoundTripper or create a new one
-// given a custom TLS configuration is passed and the passTLSCert option is set to true.
-func (s *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tls *traefikTls.TLS) (http.RoundTripper, error) {
+// given a custom TLS configuration is passed and the passTLSCert option is set to true,
+// or the backend has its own TLS configuration.
+func (s *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tlsOption *traefikTls.TLS, backend *types.Backend) (http.RoundTripper, error) {
+	var backendTLS *types.BackendTLS
+	var backendTransport *types.BackendTransport
+	if backend != nil {
+		backendTLS = backend.TLS
+		backendTransport = backend.Transport
+	}
+	if !passTLSCert && backendTLS == nil && backendTransport == nil {
+		return s.defaultForwardingRoundTripper, nil
+	}
+
+	tlsConfig := createBackendTLSConfig(globalConfiguration)
 	if passTLSCert {
-		tlsConfig, err := createClientTLSConfig(entryPointName, tls)
+		var err error
+		tlsConfig, err = createClientTLSConfig(entryPointName, tlsOption)
 		if err != nil {
 			log.Errorf("Failed to create TLSClientConfig: %s", err)
 			return nil, err
 		}
+	}
+	if backendTLS != nil {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if err := applyBackendTLS(tlsConfig, backendTLS); err != nil {
+			return nil, err
+		}
+	}
+
+	transport := createHTTPTransportWithTLS(globalConfiguration, tlsConfig)
+	if backendTransport != nil {
+		applyBackendTransport(transport, backendTransport)
+	}
+	return s.withDNSResolver(transport), nil
+}
+
+// applyBackendTransport overrides the settings of the transport with the non-zero ones of the backend.
+func applyBackendTransport(transport *http.Transport, backendTransport *types.BackendTransport) {
+	if backendTransport.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = backendTransport.MaxIdleConnsPerHost
+	}
+	if backendTransport.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = time.Duration(backendTransport.IdleConnTimeout)
+	}
+	if backendTransport.TLSHandshakeTimeout != 0 {
+		transport.TLSHandshakeTimeout = time.Duration(backendTransport.TLSHandshakeTimeout)
+	}
+	if backendTransport.ResponseHeaderTimeout != 0 {
+		transport.ResponseHeaderTimeout = time.Duration(backendTransport.ResponseHeaderTimeout)
+	}
+	transport.DisableKeepAlives = backendTransport.DisableKeepAlives
+}
+
+// getHealthCheckRoundTripper creates the transport of the health checks skipping the verification of the certificates
+// of the servers, or verifying them against another server name.
+// The gRPC health checks use an HTTP/2 transport, reaching the http servers over h2c.
+func (s *Server) getHealthCheckRoundTripper(globalConfiguration configuration.GlobalConfiguration, backendTLS *types.BackendTLS, hc *types.HealthCheck) (http.RoundTripper, error) {
+	tlsConfig := createBackendTLSConfig(globalConfiguration)
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if backendTLS != nil {
+		if err := applyBackendTLS(tlsConfig, backendTLS); err != nil {
+			return nil, err
+		}
+	}
+	if hc.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if len(hc.ServerName) > 0 {
+		tlsConfig.ServerName = hc.ServerName
+	}
 
-		transport := createHTTPTransport(globalConfiguration)
-		transport.TLSClientConfig = tlsConfig
-		return transport, nil
+	if strings.EqualFold(hc.Protocol, "grpc") {
+		return healthcheck.NewGRPCTransport(tlsConfig), nil
 	}
+	return s.withDNSResolver(createHTTPTransportWithTLS(globalConfiguration, tlsConfig)), nil
+}
 
-	return s.defaultForwardingRoundTripper, nil
+// withDNSResolver makes the transport resolve the backend host names with the DNS resolver, when configured.
+func (s *Server) withDNSResolver(transport *http.Transport) *http.Transport {
+	if s.dnsResolver != nil {
+		transport.DialContext = s.dnsResolver.DialContext(transport.DialContext)
+		if s.dnsResolver.refresh {
+			transport.DialContext = s.dnsResolver.trackConnections(transport, transport.DialContext)
+		}
+	}
+	return transport
 }
 
 // loadConfig returns a new gorilla.mux Route from the specified global configuration and the dynamic
@@ -895,15 +1328,21 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 	redirectHandlers := make(map[string]negroni.Handler)
 	backends := map[string]http.Handler{}
 	backendsHealthCheck := map[string]*healthcheck.BackendHealthCheck{}
+	backendQueues := map[string]*middlewares.BackendQueue{}
 	errorHandler := NewRecordingErrorHandler(middlewares.DefaultNetErrorRecorder{})
+	serverStartTimes := s.serverStartTimes.update(configurations, time.Now())
 
 	for _, config := range configurations {
 		frontendNames := sortedFrontendNamesForConfig(config)
 	frontend:
-		for _, frontendName := range frontendNames {
-			frontend := config.Frontends[frontendName]
+		for _, target := range getFrontendTargets(config, frontendNames) {
+			frontendName, frontend := target.name, target.frontend
 
-			log.Debugf("Creating frontend %s", frontendName)
+			if target.secondaryBackend {
+				log.Debugf("Creating backend %s of the traffic split or failover of frontend %s", frontend.Backend, frontendName)
+			} else {
+				log.Debugf("Creating frontend %s", frontendName)
+			}
 
 			if len(frontend.EntryPoints) == 0 {
 				log.Errorf("No entrypoint defined for frontend %s, defaultEntryPoints:%s", frontendName, globalConfiguration.DefaultEntryPoints)
@@ -923,15 +1362,19 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 					continue
 				}
 
-				newServerRoute := &serverRoute{route: serverEntryPoints[entryPointName].httpRouter.GetHandler().NewRoute().Name(frontendName)}
-				for routeName, route := range frontend.Routes {
-					err := getRoute(newServerRoute, &route)
-					if err != nil {
-						log.Errorf("Error creating route for frontend %s: %v", frontendName, err)
-						log.Errorf("Skipping frontend %s...", frontendName)
-						continue frontend
+				// the backends of a traffic split or failover are only reached through their frontend
+				var newServerRoute *serverRoute
+				if !target.secondaryBackend {
+					newServerRoute = &serverRoute{route: serverEntryPoints[entryPointName].httpRouter.GetHandler().NewRoute().Name(frontendName)}
+					for routeName, route := range frontend.Routes {
+						err := getRoute(newServerRoute, &route)
+						if err != nil {
+							log.Errorf("Error creating route for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						log.Debugf("Creating route %s %s", routeName, route.Rule)
 					}
-					log.Debugf("Creating route %s %s", routeName, route.Rule)
 				}
 
 				entryPoint := globalConfiguration.EntryPoints[entryPointName]
@@ -954,10 +1397,26 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						}
 					}
 				}
-				if backends[entryPointName+frontend.Backend] == nil {
+				// a backend failing over is built for each frontend, like its failover backends
+				perFrontend := target.secondaryBackend
+				if backend := config.Backends[frontend.Backend]; backend != nil && backend.Failover != nil {
+					perFrontend = true
+				}
+				backendKey := getBackendHandlerKey(entryPointName, frontendName, frontend.Backend, perFrontend)
+				var headerMiddleware *middlewares.HeaderStruct
+				if frontend.Headers.HasCustomHeadersDefined() {
+					headerMiddleware = middlewares.NewHeaderFromStruct(frontend.Headers)
+				}
+
+				if backends[backendKey] == nil {
 					log.Debugf("Creating backend %s", frontend.Backend)
+					backendChain := negroni.New()
 
-					roundTripper, err := s.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS)
+					var backendTLS *types.BackendTLS
+					if backend := config.Backends[frontend.Backend]; backend != nil {
+						backendTLS = backend.TLS
+					}
+					roundTripper, err := s.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS, config.Backends[frontend.Backend])
 					if err != nil {
 						log.Errorf("Failed to create RoundTripper for frontend %s: %v", frontendName, err)
 						log.Errorf("Skipping frontend %s...", frontendName)
@@ -971,10 +1430,8 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						continue frontend
 					}
 
-					var headerMiddleware *middlewares.HeaderStruct
 					var responseModifier func(res *http.Response) error
-					if frontend.Headers.HasCustomHeadersDefined() {
-						headerMiddleware = middlewares.NewHeaderFromStruct(frontend.Headers)
+					if headerMiddleware != nil {
 						responseModifier = headerMiddleware.ModifyResponseHeaders
 					}
 
@@ -993,14 +1450,63 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						continue frontend
 					}
 
+					// the remaining time of the response timeout is propagated to the chosen server
+					backendHandler := middlewares.PropagateDeadline(fwd)
+					if globalConfiguration.API != nil && globalConfiguration.API.BackendStatsRecorder != nil {
+						backendHandler = globalConfiguration.API.BackendStatsRecorder.Handler(backendHandler, frontend.Backend)
+					}
+					if s.alerter != nil {
+						backendHandler = s.alerter.recorder.Handler(backendHandler, frontend.Backend)
+					}
+					if s.serverDrainer != nil {
+						backendHandler = s.serverDrainer.Handler(backendHandler, frontend.Backend)
+					}
+
+					// the outlier detection is called by the load balancer, once the server is chosen
+					var outlierDetector *middlewares.OutlierDetector
+					if backend := config.Backends[frontend.Backend]; backend != nil && backend.OutlierDetection != nil {
+						log.Debugf("Creating outlier detection for the servers of backend %s", frontend.Backend)
+						outlierDetector, err = middlewares.NewOutlierDetector(backendHandler, frontend.Backend, backend.OutlierDetection)
+						if err != nil {
+							log.Errorf("Error creating outlier detection for backend %s: %v", frontend.Backend, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendHandler = outlierDetector
+					}
+
+					// the queues per server are called by the load balancer, once the server is chosen
+					if backend := config.Backends[frontend.Backend]; backend != nil && backend.Queue != nil && backend.Queue.PerServer {
+						log.Debugf("Creating queues for the servers of backend %s", frontend.Backend)
+						queue, err := s.getBackendQueue(backendQueues, frontend.Backend, backend.Queue)
+						if err != nil {
+							log.Errorf("Error creating queue for backend %s: %v", frontend.Backend, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendHandler = queue.Handler(backendHandler)
+					}
+
+					// the circuit breakers per server are called by the load balancer, once the server is chosen
+					if backend := config.Backends[frontend.Backend]; backend != nil && backend.CircuitBreaker != nil && backend.CircuitBreaker.PerServer {
+						log.Debugf("Creating circuit breakers %s for the servers of backend %s", backend.CircuitBreaker.Expression, frontend.Backend)
+						serverCircuitBreakers, err := middlewares.NewServerCircuitBreakers(backendHandler, frontend.Backend, backend.CircuitBreaker)
+						if err != nil {
+							log.Errorf("Error creating circuit breakers: %v", err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendHandler = serverCircuitBreakers
+					}
+
 					var rr *roundrobin.RoundRobin
 					var saveFrontend http.Handler
 					if s.accessLoggerMiddleware != nil {
-						saveBackend := accesslog.NewSaveBackend(fwd, frontend.Backend)
+						saveBackend := accesslog.NewSaveBackend(backendHandler, frontend.Backend, getServersMetadata(config.Backends[frontend.Backend]))
 						saveFrontend = accesslog.NewSaveFrontend(saveBackend, frontendName)
 						rr, _ = roundrobin.New(saveFrontend)
 					} else {
-						rr, _ = roundrobin.New(fwd)
+						rr, _ = roundrobin.New(backendHandler)
 					}
 
 					if config.Backends[frontend.Backend] == nil {
@@ -1018,15 +1524,40 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 
 					var sticky *roundrobin.StickySession
 					var cookieName string
+					var keyedAffinity bool
 					if stickiness := config.Backends[frontend.Backend].LoadBalancer.Stickiness; stickiness != nil {
-						cookieName = cookie.GetName(stickiness.CookieName, frontend.Backend)
-						sticky = roundrobin.NewStickySession(cookieName)
+						if stickiness.KeyedAffinity() {
+							keyedAffinity = true
+						} else {
+							cookieName = cookie.GetName(stickiness.CookieName, frontend.Backend)
+							sticky = roundrobin.NewStickySession(cookieName)
+						}
+					}
+
+					// the health checks reach the servers with the TLS configuration of the backend
+					healthCheckTransport := s.defaultForwardingRoundTripper
+					if backendTLS != nil {
+						healthCheckTransport = roundTripper
+					}
+					if hc := config.Backends[frontend.Backend].HealthCheck; hc != nil && (hc.InsecureSkipVerify || len(hc.ServerName) > 0 || strings.EqualFold(hc.Protocol, "grpc")) {
+						healthCheckTransport, err = s.getHealthCheckRoundTripper(globalConfiguration, backendTLS, hc)
+						if err != nil {
+							log.Errorf("Failed to create the health check RoundTripper for backend %s: %v", frontend.Backend, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
 					}
 
 					var lb http.Handler
-					switch lbMethod {
-					case types.Drr:
+					slowStart := config.Backends[frontend.Backend].LoadBalancer.SlowStart > 0
+
+					var lbServers healthcheck.LoadBalancer
+					switch {
+					case lbMethod == types.Drr && !keyedAffinity:
 						log.Debugf("Creating load-balancer drr")
+						if slowStart {
+							log.Warnf("The slow start of backend %s isn't supported by the drr load-balancer", frontend.Backend)
+						}
 						rebalancer, _ := roundrobin.NewRebalancer(rr)
 						if sticky != nil {
 							log.Debugf("Sticky session with cookie %v", cookieName)
@@ -1040,18 +1571,19 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						hcOpts := parseHealthCheckOptions(rebalancer, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
 						if hcOpts != nil {
 							log.Debugf("Setting up backend health check %s", *hcOpts)
-							hcOpts.Transport = s.defaultForwardingRoundTripper
-							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts)
+							hcOpts.Transport = healthCheckTransport
+							backendsHealthCheck[backendKey] = healthcheck.NewBackendHealthCheck(*hcOpts)
 						}
 						lb = middlewares.NewEmptyBackendHandler(rebalancer, lb)
-					case types.Wrr:
+						lbServers = rebalancer
+					case lbMethod == types.Wrr && !slowStart && !keyedAffinity:
 						log.Debugf("Creating load-balancer wrr")
 						if sticky != nil {
 							log.Debugf("Sticky session with cookie %v", cookieName)
 							if s.accessLoggerMiddleware != nil {
 								rr, _ = roundrobin.New(saveFrontend, roundrobin.EnableStickySession(sticky))
 							} else {
-								rr, _ = roundrobin.New(fwd, roundrobin.EnableStickySession(sticky))
+								rr, _ = roundrobin.New(backendHandler, roundrobin.EnableStickySession(sticky))
 							}
 						}
 						lb = rr
@@ -1062,36 +1594,56 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						hcOpts := parseHealthCheckOptions(rr, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
 						if hcOpts != nil {
 							log.Debugf("Setting up backend health check %s", *hcOpts)
-							hcOpts.Transport = s.defaultForwardingRoundTripper
-							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts)
+							hcOpts.Transport = healthCheckTransport
+							backendsHealthCheck[backendKey] = healthcheck.NewBackendHealthCheck(*hcOpts)
 						}
 						lb = middlewares.NewEmptyBackendHandler(rr, lb)
-					}
-
-					if len(frontend.Errors) > 0 {
-						for _, errorPage := range frontend.Errors {
-							if config.Backends[errorPage.Backend] != nil && config.Backends[errorPage.Backend].Servers["error"].URL != "" {
-								errorPageHandler, err := middlewares.NewErrorPagesHandler(errorPage, config.Backends[errorPage.Backend].Servers["error"].URL)
-								if err != nil {
-									log.Errorf("Error creating custom error page middleware, %v", err)
-								} else {
-									n.Use(errorPageHandler)
-								}
-							} else {
-								log.Errorf("Error Page is configured for Frontend %s, but either Backend %s is not set or Backend URL is missing", frontendName, errorPage.Backend)
-							}
+						lbServers = rr
+					default:
+						log.Debugf("Creating load-balancer %s", config.Backends[frontend.Backend].LoadBalancer.Method)
+						balancerHandler := backendHandler
+						if s.accessLoggerMiddleware != nil {
+							balancerHandler = saveFrontend
+						}
+						if sticky != nil {
+							log.Debugf("Sticky session with cookie %v", cookieName)
+						}
+						if keyedAffinity && lbMethod == types.Drr {
+							log.Warnf("The drr load-balancer of backend %s doesn't support the stickiness keyed on a header or a query parameter: using leastconn", frontend.Backend)
+						}
+						balancer, err := middlewares.NewBalancer(balancerHandler, config.Backends[frontend.Backend].LoadBalancer, sticky)
+						if err != nil {
+							log.Errorf("Error creating load-balancer for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						lb = balancer
+						if err := configureLBServers(balancer, config, frontend); err != nil {
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						balancer.SetStartTimes(serverStartTimes[frontend.Backend])
+						hcOpts := parseHealthCheckOptions(balancer, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
+						if hcOpts != nil {
+							log.Debugf("Setting up backend health check %s", *hcOpts)
+							hcOpts.Transport = healthCheckTransport
+							backendsHealthCheck[backendKey] = healthcheck.NewBackendHealthCheck(*hcOpts)
 						}
+						lb = middlewares.NewEmptyBackendHandler(balancer, lb)
+						lbServers = balancer
 					}
 
-					if frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0 {
-						lb, err = s.buildRateLimiter(lb, frontend.RateLimit)
-						if err != nil {
-							log.Errorf("Error creating rate limiter: %v", err)
+					if outlierDetector != nil {
+						if err := outlierDetector.SetLoadBalancer(lbServers, config.Backends[frontend.Backend].Servers); err != nil {
+							log.Errorf("Error creating outlier detection for backend %s: %v", frontend.Backend, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
 					}
 
+					if s.metricsRegistry.IsEnabled() {
+						backendChain.Use(middlewares.NewMetricsBackendLabel(frontend.Backend))
+					}
 					maxConns := config.Backends[frontend.Backend].MaxConn
 					if maxConns != nil && maxConns.Amount != 0 {
 						extractFunc, err := utils.NewExtractor(maxConns.ExtractorFunc)
@@ -1109,89 +1661,362 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						}
 					}
 
+					if queueConfig := config.Backends[frontend.Backend].Queue; queueConfig != nil && !queueConfig.PerServer {
+						log.Debugf("Creating queue for backend %s", frontend.Backend)
+						queue, err := s.getBackendQueue(backendQueues, frontend.Backend, queueConfig)
+						if err != nil {
+							log.Errorf("Error creating queue for backend %s: %v", frontend.Backend, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						lb = queue.Handler(lb)
+					}
+
 					if globalConfiguration.Retry != nil {
 						countServers := len(config.Backends[frontend.Backend].Servers)
-						lb = s.buildRetryMiddleware(lb, globalConfiguration, countServers, frontend.Backend)
+						lb, err = s.buildRetryMiddleware(lb, globalConfiguration, countServers, frontend.Backend)
+						if err != nil {
+							log.Errorf("Error creating retries for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
 					}
 
-					if s.metricsRegistry.IsEnabled() {
-						n.Use(middlewares.NewMetricsWrapper(s.metricsRegistry, frontend.Backend))
+					if cbConfig := config.Backends[frontend.Backend].CircuitBreaker; cbConfig != nil && !cbConfig.PerServer {
+						log.Debugf("Creating circuit breaker %s", cbConfig.Expression)
+						circuitBreaker, err := middlewares.NewCircuitBreakerFromConfig(lb, frontend.Backend, cbConfig)
+						if err != nil {
+							log.Errorf("Error creating circuit breaker: %v", err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendChain.Use(circuitBreaker)
+					} else {
+						backendChain.UseHandler(lb)
+					}
+					if failoverConfig := config.Backends[frontend.Backend].Failover; failoverConfig != nil {
+						failover, err := buildFailover(backendChain, lbServers, frontendName, frontend.Backend, config, backends, entryPointName)
+						if err != nil {
+							log.Errorf("Error creating failover for backend %s: %v", frontend.Backend, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						log.Debugf("Failing over backend %s to backend %s", frontend.Backend, failoverConfig.Backend)
+						backends[backendKey] = failover
+					} else {
+						backends[backendKey] = backendChain
+					}
+				} else {
+					log.Debugf("Reusing backend %s", frontend.Backend)
+				}
+				if target.secondaryBackend {
+					continue
+				}
+				if frontend.Priority > 0 {
+					newServerRoute.route.Priority(frontend.Priority)
+				}
+				frontendHandler := backends[backendKey]
+				if len(frontend.TrafficSplit) > 0 {
+					trafficSplit, err := buildTrafficSplit(frontendHandler, frontendName, frontend, backends, entryPointName)
+					if err != nil {
+						log.Errorf("Error creating traffic split for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					log.Debugf("Splitting the traffic of frontend %s between backend %s and %v", frontendName, frontend.Backend, frontend.TrafficSplit)
+					frontendHandler = trafficSplit
+				}
+				// The middlewares of the frontend are built for the frontend, around the handler of its backend
+				// shared with the other frontends.
+				chain := newMiddlewareChain()
+				if len(frontend.Errors) > 0 {
+					for _, errorPage := range frontend.Errors {
+						var backendURL string
+						if config.Backends[errorPage.Backend] != nil {
+							backendURL = config.Backends[errorPage.Backend].Servers["error"].URL
+						}
+						if backendURL == "" && !errorPage.HasPage() {
+							log.Errorf("Error Page is configured for Frontend %s, but either Backend %s is not set or Backend URL is missing", frontendName, errorPage.Backend)
+							continue
+						}
+
+						errorPageHandler, err := middlewares.NewErrorPagesHandler(errorPage, backendURL)
+						if err != nil {
+							log.Errorf("Error creating custom error page middleware, %v", err)
+						} else {
+							chain.use(types.MiddlewareErrorPages, errorPageHandler)
+						}
+					}
+				}
+
+				if frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0 {
+					rateLimiter, err := newHandlerWrapper(func(next http.Handler) (http.Handler, error) {
+						return s.buildRateLimiter(next, frontend.RateLimit)
+					})
+					if err != nil {
+						log.Errorf("Error creating rate limiter: %v", err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareRateLimit, rateLimiter)
+				}
+
+				if s.metricsRegistry.IsEnabled() {
+					chain.use(types.MiddlewareMetrics, middlewares.NewBackendMetricsWrapper(s.metricsRegistry, frontend.Backend))
+				}
+
+				if frontend.Limits != nil {
+					limits, err := middlewares.NewLimits(frontend.Limits)
+					if err != nil {
+						log.Errorf("Error creating limits for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareLimits, limits)
+				}
+
+				if globalConfiguration.CacheStatus != nil && len(globalConfiguration.CacheStatus.Headers) > 0 {
+					log.Debugf("Reporting cache status for backend %s from headers %v", frontend.Backend, globalConfiguration.CacheStatus.Headers)
+					chain.use(types.MiddlewareCacheStatus, middlewares.NewCacheStatus(globalConfiguration.CacheStatus.Headers, s.metricsRegistry, frontend.Backend))
+					if s.accessLoggerMiddleware != nil {
+						chain.use(types.MiddlewareCacheStatus, accesslog.NewSaveCacheStatus(globalConfiguration.CacheStatus.Headers))
+					}
+				}
+
+				if frontend.CORS != nil {
+					cors, err := middlewares.NewCORS(frontend.CORS)
+					if err != nil {
+						log.Errorf("Error creating CORS for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareCORS, cors)
+					log.Debugf("Configured CORS for frontend %s", frontendName)
+				}
+
+				if frontend.ClientCA != nil {
+					clientCertificate, err := middlewares.NewClientCertificate(frontend.ClientCA.Files, frontend.ClientCA.Mode)
+					if err != nil {
+						log.Errorf("Error creating client certificate authentication for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
 					}
+					if !requestsClientCertificates(entryPoint.TLS) {
+						log.Warnf("The entrypoint %s doesn't request client certificates, required by the frontend %s", entryPointName, frontendName)
+					}
+					chain.use(types.MiddlewareClientCA, clientCertificate)
+					log.Debugf("Configured client certificate authentication for frontend %s", frontendName)
+				}
+
+				if frontend.OIDC != nil {
+					oidcMiddleware, err := mauth.NewOIDC(frontend.OIDC)
+					if err != nil {
+						log.Errorf("Error creating OpenID Connect authentication for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareOIDC, oidcMiddleware)
+					log.Debugf("Configured OpenID Connect authentication with %s for frontend %s", frontend.OIDC.Issuer, frontendName)
+				}
 
+				if frontend.IPWhitelist != nil {
+					ipWhitelist := *frontend.IPWhitelist
+					ipWhitelist.SourceRange = append(append([]string{}, frontend.WhitelistSourceRange...), ipWhitelist.SourceRange...)
+					ipWhitelistMiddleware, err := middlewares.NewIPWhitelisterFromConfig(&ipWhitelist)
+					if err != nil {
+						log.Errorf("Error creating IP Whitelister for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareIPWhitelist, ipWhitelistMiddleware)
+					log.Infof("Configured IP Whitelists for frontend %s", frontendName)
+				} else {
 					ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(frontend.WhitelistSourceRange)
 					if err != nil {
 						log.Fatalf("Error creating IP Whitelister: %s", err)
 					} else if ipWhitelistMiddleware != nil {
-						n.Use(ipWhitelistMiddleware)
+						chain.use(types.MiddlewareIPWhitelist, ipWhitelistMiddleware)
 						log.Infof("Configured IP Whitelists: %s", frontend.WhitelistSourceRange)
 					}
+				}
 
-					if len(frontend.Redirect) > 0 {
-						proto := "http"
-						if s.globalConfiguration.EntryPoints[frontend.Redirect].TLS != nil {
-							proto = "https"
-						}
+				if frontend.SignedURL != nil {
+					signedURL, err := middlewares.NewSignedURL(frontend.SignedURL)
+					if err != nil {
+						log.Errorf("Error creating signed URL validation for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareSignedURL, signedURL)
+				}
 
-						regex, replacement, err := s.buildRedirect(proto, entryPoint)
-						if err != nil {
-							log.Fatalf("Error creating Frontend Redirect: %v", err)
-						}
-						rewrite, err := middlewares.NewRewrite(regex, replacement, true)
-						if err != nil {
-							log.Fatalf("Error creating Frontend Redirect: %v", err)
-						}
-						n.Use(rewrite)
-						log.Debugf("Creating frontend %s redirect to %s", frontendName, proto)
+				if len(frontend.Redirect) > 0 {
+					proto := "http"
+					if s.globalConfiguration.EntryPoints[frontend.Redirect].TLS != nil {
+						proto = "https"
 					}
 
-					if len(frontend.BasicAuth) > 0 {
-						users := types.Users{}
-						for _, user := range frontend.BasicAuth {
-							users = append(users, user)
-						}
+					regex, replacement, err := s.buildRedirect(proto, entryPoint)
+					if err != nil {
+						log.Fatalf("Error creating Frontend Redirect: %v", err)
+					}
+					rewrite, err := middlewares.NewRewrite(regex, replacement, true)
+					if err != nil {
+						log.Fatalf("Error creating Frontend Redirect: %v", err)
+					}
+					chain.use(types.MiddlewareRedirect, rewrite)
+					log.Debugf("Creating frontend %s redirect to %s", frontendName, proto)
+				}
 
-						auth := &types.Auth{}
-						auth.Basic = &types.Basic{
-							Users: users,
-						}
-						authMiddleware, err := mauth.NewAuthenticator(auth)
-						if err != nil {
-							log.Errorf("Error creating Auth: %s", err)
-						} else {
-							n.Use(authMiddleware)
-						}
+				if len(frontend.RedirectRules) > 0 {
+					redirectRules, err := middlewares.NewRedirectRules(frontend.RedirectRules)
+					if err != nil {
+						log.Errorf("Error creating redirect rules for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
 					}
+					chain.use(types.MiddlewareRedirectRules, redirectRules)
+					log.Debugf("Creating frontend %s with %d redirect rules", frontendName, len(frontend.RedirectRules))
+				}
 
-					if headerMiddleware != nil {
-						log.Debugf("Adding header middleware for frontend %s", frontendName)
-						n.Use(headerMiddleware)
+				if frontend.RedirectMap != nil && len(frontend.RedirectMap.File) > 0 {
+					redirectMap, err := middlewares.NewRedirectMap(frontend.RedirectMap.File, frontend.RedirectMap.StatusCode)
+					if err != nil {
+						log.Errorf("Error creating redirect map for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
 					}
-					if frontend.Headers.HasSecureHeadersDefined() {
-						secureMiddleware := middlewares.NewSecure(frontend.Headers)
-						log.Debugf("Adding secure middleware for frontend %s", frontendName)
-						n.UseFunc(secureMiddleware.HandlerFuncWithNext)
+					chain.use(types.MiddlewareRedirectMap, redirectMap)
+					log.Debugf("Creating frontend %s redirect map from %s", frontendName, frontend.RedirectMap.File)
+				}
+
+				if len(frontend.BasicAuth) > 0 || len(frontend.BasicAuthUsersFile) > 0 {
+					users := types.Users{}
+					for _, user := range frontend.BasicAuth {
+						users = append(users, user)
 					}
 
-					if config.Backends[frontend.Backend].CircuitBreaker != nil {
-						log.Debugf("Creating circuit breaker %s", config.Backends[frontend.Backend].CircuitBreaker.Expression)
-						circuitBreaker, err := middlewares.NewCircuitBreaker(lb, config.Backends[frontend.Backend].CircuitBreaker.Expression)
-						if err != nil {
-							log.Errorf("Error creating circuit breaker: %v", err)
-							log.Errorf("Skipping frontend %s...", frontendName)
-							continue frontend
-						}
-						n.Use(circuitBreaker)
+					auth := &types.Auth{HeaderField: frontend.BasicAuthHeaderField}
+					auth.Basic = &types.Basic{
+						Users:     users,
+						UsersFile: frontend.BasicAuthUsersFile,
+					}
+					authMiddleware, err := mauth.NewAuthenticator(auth)
+					if err != nil {
+						log.Errorf("Error creating Auth: %s", err)
 					} else {
-						n.UseHandler(lb)
+						chain.use(types.MiddlewareBasicAuth, authMiddleware)
 					}
-					backends[entryPointName+frontend.Backend] = n
-				} else {
-					log.Debugf("Reusing backend %s", frontend.Backend)
 				}
-				if frontend.Priority > 0 {
-					newServerRoute.route.Priority(frontend.Priority)
+
+				if frontend.Script != nil {
+					script, err := middlewares.NewScript(frontend.Script)
+					if err != nil {
+						log.Errorf("Error creating script for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareScript, script)
+					log.Debugf("Running script %s for frontend %s", frontend.Script.File, frontendName)
+				}
+
+				if frontend.BodyRewrite != nil {
+					bodyRewrite, err := middlewares.NewBodyRewrite(frontend.BodyRewrite)
+					if err != nil {
+						log.Errorf("Error creating body rewrite for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareBodyRewrite, bodyRewrite)
+					log.Debugf("Creating frontend %s body rewrite with %d rules", frontendName, len(frontend.BodyRewrite.Rules))
+				}
+
+				if headerMiddleware != nil {
+					log.Debugf("Adding header middleware for frontend %s", frontendName)
+					chain.use(types.MiddlewareHeaders, headerMiddleware)
+				}
+				if frontend.Headers.HasSecureHeadersDefined() {
+					secureMiddleware := middlewares.NewSecure(frontend.Headers)
+					log.Debugf("Adding secure middleware for frontend %s", frontendName)
+					chain.use(types.MiddlewareSecureHeaders, negroni.HandlerFunc(secureMiddleware.HandlerFuncWithNext))
+				}
+
+				if frontend.Cache != nil {
+					cache, err := s.responseCaches.Get(frontendName, frontend.Cache)
+					if err != nil {
+						log.Errorf("Error creating cache for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareCache, cache)
+				}
+
+				if frontend.Mirror != nil {
+					mirror, err := s.buildMirror(entryPointName, globalConfiguration, config, frontend.Mirror)
+					if err != nil {
+						log.Errorf("Error creating mirror for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					chain.use(types.MiddlewareMirror, mirror)
+					log.Debugf("Mirroring the requests of frontend %s to backend %s", frontendName, frontend.Mirror.Backend)
+				}
+
+				if err := chain.apply(n, frontend.Middlewares); err != nil {
+					log.Errorf("Error ordering the middlewares of frontend %s: %v", frontendName, err)
+					log.Errorf("Skipping frontend %s...", frontendName)
+					continue frontend
+				}
+				n.UseHandler(frontendHandler)
+				frontendHandler = n
+				if responseTimeout := getResponseTimeout(frontend, config.Backends[frontend.Backend]); responseTimeout != nil {
+					log.Debugf("Creating response timeout %s for frontend %s", time.Duration(responseTimeout.Timeout), frontendName)
+					timeout, err := middlewares.NewResponseTimeout(frontendHandler, responseTimeout)
+					if err != nil {
+						log.Errorf("Error creating response timeout for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					frontendHandler = timeout
+				}
+				if frontend.Queue != nil {
+					log.Debugf("Creating queue for frontend %s", frontendName)
+					queue, err := middlewares.NewFrontendQueue(frontendHandler, frontendName, frontend.Queue)
+					if err != nil {
+						log.Errorf("Error creating queue for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					frontendHandler = queue
+				}
+				// The maintenance of any frontend can be enabled through the API.
+				// It's applied to the frontend, and not to its backend shared with the other frontends.
+				if frontend.Maintenance != nil || globalConfiguration.API != nil {
+					maintenance, err := middlewares.NewMaintenance(frontendName, frontend.Maintenance, s.maintenanceModes)
+					if err != nil {
+						log.Errorf("Error creating maintenance for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					frontendHandler = negroni.New(maintenance, negroni.Wrap(frontendHandler))
+				}
+				if s.metricsRegistry.IsEnabled() {
+					frontendHandler = middlewares.MetricsFrontendLabel(frontendHandler, frontendName)
+				}
+				if globalConfiguration.API != nil && globalConfiguration.API.FrontendUsageRecorder != nil {
+					frontendHandler = globalConfiguration.API.FrontendUsageRecorder.Handler(frontendHandler, frontendName)
+				}
+				if s.accessLoggerMiddleware != nil && frontend.AccessLogSampling != nil {
+					log.Debugf("Sampling the access log of frontend %s", frontendName)
+					sampling, err := accesslog.NewSampling(frontendHandler, frontend.AccessLogSampling)
+					if err != nil {
+						log.Errorf("Error creating the access log sampling of frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					frontendHandler = sampling
 				}
-				s.wireFrontendBackend(newServerRoute, backends[entryPointName+frontend.Backend])
+				s.wireFrontendBackend(newServerRoute, frontendHandler)
 
 				err := newServerRoute.route.GetError()
 				if err != nil {
@@ -1201,6 +2026,21 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 		}
 	}
 	healthcheck.GetHealthCheck().SetBackendsConfiguration(s.routinesPool.Ctx(), backendsHealthCheck)
+	s.backendQueues.Replace(backendQueues)
+	if globalConfiguration.API != nil && globalConfiguration.API.BackendStatsRecorder != nil {
+		globalConfiguration.API.BackendStatsRecorder.Retain(getBackendServers(configurations))
+	}
+	if s.alerter != nil {
+		s.alerter.recorder.Retain(getBackendServers(configurations))
+	}
+	if globalConfiguration.API != nil && globalConfiguration.API.FrontendUsageRecorder != nil {
+		globalConfiguration.API.FrontendUsageRecorder.Retain(getFrontendProviders(configurations))
+	}
+	frontendNames := make(map[string]bool)
+	for frontendName := range getFrontendProviders(configurations) {
+		frontendNames[frontendName] = true
+	}
+	s.responseCaches.Retain(frontendNames)
 	// Get new certificates list sorted per entrypoints
 	// Update certificates
 	entryPointsCertificates, err := s.loadHTTPSConfiguration(configurations)
@@ -1210,6 +2050,9 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 		_, exists := entryPointsCertificates[serverEntryPointName]
 		if exists {
 			serverEntryPoint.certs.Set(entryPointsCertificates[serverEntryPointName])
+			if s.ocspStapler != nil {
+				s.ocspStapler.prefetch(entryPointsCertificates[serverEntryPointName])
+			}
 		}
 	}
 
@@ -1232,6 +2075,100 @@ func configureLBServers(lb healthcheck.LoadBalancer, config *types.Configuration
 	return nil
 }
 
+// getFrontendProviders returns the names of the frontends of all the configurations, with the name of their provider.
+func getFrontendProviders(configurations types.Configurations) map[string]string {
+	frontendProviders := make(map[string]string)
+	for providerName, config := range configurations {
+		for frontendName := range config.Frontends {
+			frontendProviders[frontendName] = providerName
+		}
+	}
+	return frontendProviders
+}
+
+// getResponseTimeout returns the response timeout of a frontend, its settings overriding the ones of its backend.
+func getResponseTimeout(frontend *types.Frontend, backend *types.Backend) *types.ResponseTimeout {
+	var responseTimeout *types.ResponseTimeout
+	if backend != nil && backend.ResponseTimeout != nil {
+		backendTimeout := *backend.ResponseTimeout
+		responseTimeout = &backendTimeout
+	}
+	if frontend.ResponseTimeout == nil {
+		return responseTimeout
+	}
+	if responseTimeout == nil {
+		responseTimeout = &types.ResponseTimeout{}
+	}
+	if frontend.ResponseTimeout.Timeout > 0 {
+		responseTimeout.Timeout = frontend.ResponseTimeout.Timeout
+	}
+	if len(frontend.ResponseTimeout.DeadlineHeader) > 0 {
+		responseTimeout.DeadlineHeader = frontend.ResponseTimeout.DeadlineHeader
+	}
+	return responseTimeout
+}
+
+// getBackendServers returns the servers (scheme and host) of the backends of all the configurations.
+func getBackendServers(configurations types.Configurations) map[string][]string {
+	backendServers := make(map[string][]string)
+	for _, config := range configurations {
+		for backendName, backend := range config.Backends {
+			if backend == nil {
+				continue
+			}
+			for _, server := range backend.Servers {
+				u, err := url.Parse(server.URL)
+				if err != nil {
+					continue
+				}
+				backendServers[backendName] = append(backendServers[backendName], u.Scheme+"://"+u.Host)
+			}
+		}
+	}
+	return backendServers
+}
+
+// getServersMetadata returns the metadata of the servers of the backend, indexed by the host of their URL.
+func getServersMetadata(backend *types.Backend) map[string]map[string]string {
+	if backend == nil {
+		return nil
+	}
+
+	serversMetadata := make(map[string]map[string]string)
+	for _, server := range backend.Servers {
+		if len(server.Metadata) == 0 {
+			continue
+		}
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+		serversMetadata[u.Host] = server.Metadata
+	}
+	return serversMetadata
+}
+
+// buildMirror creates the middleware mirroring the requests to the servers of the mirror backend,
+// with the TLS configuration of this backend.
+func (s *Server) buildMirror(entryPointName string, globalConfiguration configuration.GlobalConfiguration, config *types.Configuration, mirror *types.Mirror) (*middlewares.Mirror, error) {
+	backend := config.Backends[mirror.Backend]
+	if backend == nil {
+		return nil, fmt.Errorf("undefined mirror backend %s", mirror.Backend)
+	}
+
+	var servers []string
+	for _, server := range backend.Servers {
+		servers = append(servers, server.URL)
+	}
+
+	roundTripper, err := s.getRoundTripper(entryPointName, globalConfiguration, false, nil, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	return middlewares.NewMirror(mirror, servers, roundTripper)
+}
+
 func configureIPWhitelistMiddleware(whitelistSourceRanges []string) (negroni.Handler, error) {
 	if len(whitelistSourceRanges) > 0 {
 		ipSourceRanges := whitelistSourceRanges
@@ -1337,7 +2274,20 @@ func (s *Server) buildDefaultHTTPRouter() *mux.Router {
 }
 
 func parseHealthCheckOptions(lb healthcheck.LoadBalancer, backend string, hc *types.HealthCheck, hcConfig *configuration.HealthCheckConfig) *healthcheck.Options {
-	if hc == nil || hc.Path == "" || hcConfig == nil {
+	if hc == nil || hcConfig == nil {
+		return nil
+	}
+
+	var grpc bool
+	switch strings.ToLower(hc.Protocol) {
+	case "", "http":
+		if hc.Path == "" {
+			return nil
+		}
+	case "grpc":
+		grpc = true
+	default:
+		log.Errorf("Illegal healthcheck protocol for backend '%s': %s", backend, hc.Protocol)
 		return nil
 	}
 
@@ -1354,11 +2304,47 @@ func parseHealthCheckOptions(lb healthcheck.LoadBalancer, backend string, hc *ty
 		}
 	}
 
+	var timeout time.Duration
+	if hc.Timeout != "" {
+		timeoutOverride, err := time.ParseDuration(hc.Timeout)
+		switch {
+		case err != nil:
+			log.Errorf("Illegal healthcheck timeout for backend '%s': %s", backend, err)
+		case timeoutOverride <= 0:
+			log.Errorf("Healthcheck timeout smaller than zero for backend '%s'", backend)
+		default:
+			timeout = timeoutOverride
+		}
+	}
+
+	statusCodes, err := middlewares.ParseHTTPCodeRanges(hc.Status)
+	if err != nil {
+		log.Errorf("Illegal healthcheck status for backend '%s': %s", backend, err)
+		return nil
+	}
+
+	var body *regexp.Regexp
+	if hc.Body != "" {
+		body, err = regexp.Compile(hc.Body)
+		if err != nil {
+			log.Errorf("Illegal healthcheck body for backend '%s': %s", backend, err)
+			return nil
+		}
+	}
+
 	return &healthcheck.Options{
-		Path:     hc.Path,
-		Port:     hc.Port,
-		Interval: interval,
-		LB:       lb,
+		Path:        hc.Path,
+		Port:        hc.Port,
+		Interval:    interval,
+		Timeout:     timeout,
+		Method:      strings.ToUpper(hc.Method),
+		Headers:     hc.Headers,
+		StatusCodes: statusCodes,
+		Body:        body,
+		GRPC:        grpc,
+		GRPCService: hc.GRPCService,
+		Backend:     backend,
+		LB:          lb,
 	}
 }
 
@@ -1373,6 +2359,108 @@ func getRoute(serverRoute *serverRoute, route *types.Route) error {
 	return nil
 }
 
+// frontendTarget is a frontend to build, or a copy of a frontend building the handler of one of the backends
+// of its traffic split or of the failover backends.
+type frontendTarget struct {
+	name             string
+	frontend         *types.Frontend
+	secondaryBackend bool
+}
+
+// getFrontendTargets returns the frontends to build, each one preceded by the backends of its traffic split
+// and by the failover backends, so that their handlers are built with the settings of the frontend.
+func getFrontendTargets(config *types.Configuration, frontendNames []string) []frontendTarget {
+	var targets []frontendTarget
+	for _, frontendName := range frontendNames {
+		frontend := config.Frontends[frontendName]
+
+		backendNames := make([]string, 0, len(frontend.TrafficSplit))
+		for backendName := range frontend.TrafficSplit {
+			backendNames = append(backendNames, backendName)
+		}
+		sort.Strings(backendNames)
+		for _, backendName := range backendNames {
+			if backendName == frontend.Backend {
+				continue
+			}
+			targets = appendFailoverTargets(targets, config, frontendName, frontend, backendName)
+			targets = append(targets, newSecondaryTarget(frontendName, frontend, backendName))
+		}
+
+		targets = appendFailoverTargets(targets, config, frontendName, frontend, frontend.Backend)
+		targets = append(targets, frontendTarget{name: frontendName, frontend: frontend})
+	}
+	return targets
+}
+
+// appendFailoverTargets appends the targets of the failover backends of the backend, last failover first,
+// stopping at the first failover backend already seen.
+func appendFailoverTargets(targets []frontendTarget, config *types.Configuration, frontendName string, frontend *types.Frontend, backendName string) []frontendTarget {
+	seen := map[string]bool{backendName: true}
+	var failoverNames []string
+	for backend := config.Backends[backendName]; backend != nil && backend.Failover != nil && !seen[backend.Failover.Backend]; backend = config.Backends[backend.Failover.Backend] {
+		seen[backend.Failover.Backend] = true
+		failoverNames = append(failoverNames, backend.Failover.Backend)
+	}
+	for i := len(failoverNames) - 1; i >= 0; i-- {
+		targets = append(targets, newSecondaryTarget(frontendName, frontend, failoverNames[i]))
+	}
+	return targets
+}
+
+// newSecondaryTarget returns the copy of the frontend building the handler of the backend.
+func newSecondaryTarget(frontendName string, frontend *types.Frontend, backendName string) frontendTarget {
+	secondaryFrontend := *frontend
+	secondaryFrontend.Backend = backendName
+	secondaryFrontend.Routes = nil
+	secondaryFrontend.TrafficSplit = nil
+	return frontendTarget{name: frontendName, frontend: &secondaryFrontend, secondaryBackend: true}
+}
+
+// getBackendHandlerKey returns the key of the handler of the backend on the entry point.
+// The handler of a backend of a traffic split, failing over or of a failover is built for its frontend,
+// with the forwarding settings of the frontend, e.g. its custom headers.
+func getBackendHandlerKey(entryPointName, frontendName, backendName string, perFrontend bool) string {
+	if perFrontend {
+		return entryPointName + "/" + frontendName + "/" + backendName
+	}
+	return entryPointName + backendName
+}
+
+// buildTrafficSplit creates the handler splitting the traffic of the frontend between its backend and the backends
+// of its traffic split, built for the frontend on the entry point.
+func buildTrafficSplit(next http.Handler, frontendName string, frontend *types.Frontend, backends map[string]http.Handler, entryPointName string) (*middlewares.TrafficSplit, error) {
+	handlers := make(map[string]http.Handler)
+	for backendName := range frontend.TrafficSplit {
+		if backendName == frontend.Backend {
+			return nil, fmt.Errorf("the backend %s of the frontend can't be part of its traffic split", backendName)
+		}
+		handler, ok := backends[getBackendHandlerKey(entryPointName, frontendName, backendName, true)]
+		if !ok {
+			return nil, fmt.Errorf("undefined backend %s", backendName)
+		}
+		handlers[backendName] = handler
+	}
+	return middlewares.NewTrafficSplit(next, frontend.TrafficSplit, handlers)
+}
+
+// buildFailover creates the handler sending the requests of the backend to next while enough of its servers
+// are in the load balancer, and to the handler of its failover backend, built for the frontend on the entry point, otherwise.
+func buildFailover(next http.Handler, lb healthcheck.LoadBalancer, frontendName, backendName string, config *types.Configuration, backends map[string]http.Handler, entryPointName string) (*middlewares.Failover, error) {
+	failoverConfig := config.Backends[backendName].Failover
+	if failoverConfig.Backend == backendName {
+		return nil, fmt.Errorf("the backend %s can't fail over to itself", backendName)
+	}
+	if config.Backends[failoverConfig.Backend] == nil {
+		return nil, fmt.Errorf("undefined backend %s", failoverConfig.Backend)
+	}
+	failover, ok := backends[getBackendHandlerKey(entryPointName, frontendName, failoverConfig.Backend, true)]
+	if !ok {
+		return nil, fmt.Errorf("the failover backend %s isn't built, its failover backends may loop", failoverConfig.Backend)
+	}
+	return middlewares.NewFailover(next, lb, backendName, len(config.Backends[backendName].Servers), failoverConfig, failover)
+}
+
 func sortedFrontendNamesForConfig(configuration *types.Configuration) []string {
 	keys := []string{}
 	for key := range configuration.Frontends {
@@ -1432,21 +2520,25 @@ func (s *Server) registerMetricClients(metricsConfig *types.Metrics) {
 	registries := []metrics.Registry{}
 
 	if metricsConfig.Prometheus != nil {
-		registries = append(registries, metrics.RegisterPrometheus(metricsConfig.Prometheus))
+		registries = append(registries, metrics.RegisterPrometheus(metricsConfig.Prometheus, metricsConfig.Granularity))
 		log.Debug("Configured Prometheus metrics")
 	}
 	if metricsConfig.Datadog != nil {
-		registries = append(registries, metrics.RegisterDatadog(metricsConfig.Datadog))
+		registries = append(registries, metrics.RegisterDatadog(metricsConfig.Datadog, metricsConfig.Granularity))
 		log.Debugf("Configured DataDog metrics pushing to %s once every %s", metricsConfig.Datadog.Address, metricsConfig.Datadog.PushInterval)
 	}
 	if metricsConfig.StatsD != nil {
-		registries = append(registries, metrics.RegisterStatsd(metricsConfig.StatsD))
+		registries = append(registries, metrics.RegisterStatsd(metricsConfig.StatsD, metricsConfig.Granularity))
 		log.Debugf("Configured StatsD metrics pushing to %s once every %s", metricsConfig.StatsD.Address, metricsConfig.StatsD.PushInterval)
 	}
 	if metricsConfig.InfluxDB != nil {
-		registries = append(registries, metrics.RegisterInfluxDB(metricsConfig.InfluxDB))
+		registries = append(registries, metrics.RegisterInfluxDB(metricsConfig.InfluxDB, metricsConfig.Granularity))
 		log.Debugf("Configured InfluxDB metrics pushing to %s once every %s", metricsConfig.InfluxDB.Address, metricsConfig.InfluxDB.PushInterval)
 	}
+	if metricsConfig.InfluxDB2 != nil {
+		registries = append(registries, metrics.RegisterInfluxDB2(metricsConfig.InfluxDB2, metricsConfig.Granularity))
+		log.Debugf("Configured InfluxDB 2.x metrics pushing to the bucket %s of %s once every %s", metricsConfig.InfluxDB2.Bucket, metricsConfig.InfluxDB2.Address, metricsConfig.InfluxDB2.PushInterval)
+	}
 
 	if len(registries) > 0 {
 		s.metricsRegistry = metrics.NewMultiRegistry(registries)
@@ -1457,10 +2549,26 @@ func stopMetricsClients() {
 	metrics.StopDatadog()
 	metrics.StopStatsd()
 	metrics.StopInfluxDB()
+	metrics.StopInfluxDB2()
+}
+
+// getBackendQueue returns the queue of the backend, creating it on its first handler.
+// The handlers of the backend, built by frontend and entry point, share its queue and so its limits.
+func (s *Server) getBackendQueue(backendQueues map[string]*middlewares.BackendQueue, backendName string, config *types.Queue) (*middlewares.BackendQueue, error) {
+	if queue, ok := backendQueues[backendName]; ok {
+		return queue, nil
+	}
+
+	queue, err := middlewares.NewBackendQueue(backendName, config, s.metricsRegistry)
+	if err != nil {
+		return nil, err
+	}
+	backendQueues[backendName] = queue
+	return queue, nil
 }
 
 func (s *Server) buildRateLimiter(handler http.Handler, rlConfig *types.RateLimit) (http.Handler, error) {
-	extractFunc, err := utils.NewExtractor(rlConfig.ExtractorFunc)
+	extractFunc, err := middlewares.NewRateLimitExtractor(rlConfig.ExtractorFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -1474,7 +2582,7 @@ func (s *Server) buildRateLimiter(handler http.Handler, rlConfig *types.RateLimi
 	return ratelimit.New(handler, extractFunc, rateSet)
 }
 
-func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, countServers int, backendName string) http.Handler {
+func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, countServers int, backendName string) (http.Handler, error) {
 	retryListeners := middlewares.RetryListeners{}
 	if s.metricsRegistry.IsEnabled() {
 		retryListeners = append(retryListeners, middlewares.NewMetricsRetryListener(s.metricsRegistry, backendName))
@@ -1490,5 +2598,13 @@ func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configu
 
 	log.Debugf("Creating retries max attempts %d", retryAttempts)
 
-	return middlewares.NewRetry(retryAttempts, handler, retryListeners)
+	policy := middlewares.RetryPolicy{
+		InitialInterval: time.Duration(globalConfig.Retry.InitialInterval),
+		MaxInterval:     time.Duration(globalConfig.Retry.MaxInterval),
+		Budget:          globalConfig.Retry.Budget,
+		StatusCodes:     globalConfig.Retry.StatusCodes,
+		IdempotentOnly:  globalConfig.Retry.IdempotentOnly,
+		MaxBodySize:     globalConfig.Retry.MaxBodySize,
+	}
+	return middlewares.NewRetryWithPolicy(retryAttempts, policy, handler, retryListeners)
 }
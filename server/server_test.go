@@ -1,6 +1,8 @@
 package server
 
 import (
+	cryptotls "crypto/tls"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +19,7 @@ import (
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
 	"github.com/containous/traefik/types"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
@@ -523,6 +526,7 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 			wantOpts: &healthcheck.Options{
 				Path:     "/path",
 				Interval: globalInterval,
+				Backend:  "backend",
 				LB:       lb,
 			},
 		},
@@ -535,6 +539,7 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 			wantOpts: &healthcheck.Options{
 				Path:     "/path",
 				Interval: globalInterval,
+				Backend:  "backend",
 				LB:       lb,
 			},
 		},
@@ -547,9 +552,68 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 			wantOpts: &healthcheck.Options{
 				Path:     "/path",
 				Interval: 5 * time.Minute,
+				Backend:  "backend",
 				LB:       lb,
 			},
 		},
+		{
+			desc: "request and response",
+			hc: &types.HealthCheck{
+				Path:    "/path",
+				Timeout: "2s",
+				Method:  "head",
+				Headers: map[string]string{"Host": "health.local"},
+				Status:  []string{"200-299", "301"},
+			},
+			wantOpts: &healthcheck.Options{
+				Path:        "/path",
+				Interval:    globalInterval,
+				Timeout:     2 * time.Second,
+				Method:      http.MethodHead,
+				Headers:     map[string]string{"Host": "health.local"},
+				StatusCodes: [][2]int{{200, 299}, {301, 301}},
+				Backend:     "backend",
+				LB:          lb,
+			},
+		},
+		{
+			desc: "unparseable status",
+			hc: &types.HealthCheck{
+				Path:   "/path",
+				Status: []string{"2xx"},
+			},
+			wantOpts: nil,
+		},
+		{
+			desc: "unparseable body",
+			hc: &types.HealthCheck{
+				Path: "/path",
+				Body: "(",
+			},
+			wantOpts: nil,
+		},
+		{
+			desc: "gRPC protocol without path",
+			hc: &types.HealthCheck{
+				Protocol:    "grpc",
+				GRPCService: "helloworld.Greeter",
+			},
+			wantOpts: &healthcheck.Options{
+				Interval:    globalInterval,
+				GRPC:        true,
+				GRPCService: "helloworld.Greeter",
+				Backend:     "backend",
+				LB:          lb,
+			},
+		},
+		{
+			desc: "unknown protocol",
+			hc: &types.HealthCheck{
+				Path:     "/path",
+				Protocol: "tcp",
+			},
+			wantOpts: nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -903,6 +967,593 @@ func TestServerResponseEmptyBackend(t *testing.T) {
 	}
 }
 
+func TestServerTrafficSplit(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		trafficSplit   map[string]int
+		wantStatusCode int
+	}{
+		{
+			desc:           "all the traffic to the backend of the frontend",
+			trafficSplit:   map[string]int{"canary": 0},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc:           "all the traffic to the canary backend",
+			trafficSplit:   map[string]int{"canary": 100},
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			desc:           "undefined backend",
+			trafficSplit:   map[string]int{"unknown": 10},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			desc:           "backend of the frontend in the traffic split",
+			trafficSplit:   map[string]int{"backend": 10},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			desc:           "percentages above 100",
+			trafficSplit:   map[string]int{"canary": 110},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			stableServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer stableServer.Close()
+			canaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusAccepted)
+			}))
+			defer canaryServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+				withFrontend("frontend", buildFrontend(withRoute("/path", "Path:/path"), withTrafficSplit(test.trafficSplit))),
+				withBackend("backend", buildBackend(withServer("stable", stableServer.URL))),
+				withBackend("canary", buildBackend(withServer("canary", canaryServer.URL))),
+			)}
+
+			srv := NewServer(globalConfig)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			responseRecorder := &httptest.ResponseRecorder{}
+			request := httptest.NewRequest(http.MethodGet, stableServer.URL+"/path", nil)
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Result().StatusCode)
+		})
+	}
+}
+
+func TestServerTrafficSplitMiddlewares(t *testing.T) {
+	stableServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer stableServer.Close()
+	canaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer canaryServer.Close()
+
+	// the public frontend is built first, on the canary backend of the traffic split of the private frontend
+	publicFrontend := buildFrontend(withRoute("/public", "Path:/public"))
+	publicFrontend.Backend = "canary"
+	privateFrontend := buildFrontend(withRoute("/private", "Path:/private"), withTrafficSplit(map[string]int{"canary": 100}))
+	privateFrontend.BasicAuth = []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a-public", publicFrontend),
+		withFrontend("frontend-b-private", privateFrontend),
+		withBackend("backend", buildBackend(withServer("stable", stableServer.URL))),
+		withBackend("canary", buildBackend(withServer("canary", canaryServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		path           string
+		authenticated  bool
+		wantStatusCode int
+	}{
+		{
+			desc:           "split traffic of the private frontend without credentials",
+			path:           "/private",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			desc:           "split traffic of the private frontend with credentials",
+			path:           "/private",
+			authenticated:  true,
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			desc:           "traffic of the public frontend",
+			path:           "/public",
+			wantStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, stableServer.URL+test.path, nil)
+			if test.authenticated {
+				request.SetBasicAuth("test", "test")
+			}
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Code)
+		})
+	}
+}
+
+func TestServerMiddlewaresOfFrontendsSharingBackend(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	// the public frontend is built first, on the backend shared by all the frontends
+	publicFrontend := buildFrontend(withRoute("/public", "Path:/public"))
+	privateFrontend := buildFrontend(withRoute("/private", "Path:/private"))
+	privateFrontend.BasicAuth = []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}
+	whitelistedFrontend := buildFrontend(withRoute("/whitelisted", "Path:/whitelisted"))
+	whitelistedFrontend.BasicAuth = []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}
+	whitelistedFrontend.WhitelistSourceRange = []string{"10.0.0.0/8"}
+	orderedFrontend := buildFrontend(withRoute("/ordered", "Path:/ordered"))
+	orderedFrontend.BasicAuth = []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}
+	orderedFrontend.WhitelistSourceRange = []string{"10.0.0.0/8"}
+	orderedFrontend.Middlewares = []string{types.MiddlewareBasicAuth, types.MiddlewareIPWhitelist}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a-public", publicFrontend),
+		withFrontend("frontend-b-private", privateFrontend),
+		withFrontend("frontend-c-whitelisted", whitelistedFrontend),
+		withFrontend("frontend-d-ordered", orderedFrontend),
+		withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		path           string
+		authenticated  bool
+		wantStatusCode int
+	}{
+		{
+			desc:           "public frontend",
+			path:           "/public",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc:           "private frontend without credentials",
+			path:           "/private",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			desc:           "private frontend with credentials",
+			path:           "/private",
+			authenticated:  true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc:           "IP whitelist before the authentication by default",
+			path:           "/whitelisted",
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			desc:           "authentication before the IP whitelist in the order of the frontend",
+			path:           "/ordered",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, backendServer.URL+test.path, nil)
+			if test.authenticated {
+				request.SetBasicAuth("test", "test")
+			}
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Code)
+		})
+	}
+}
+
+func TestServerOIDCOfFrontendsSharingBackend(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	// the unprotected frontend is built first, on the backend shared with the protected frontend
+	publicFrontend := buildFrontend(withRoute("/public", "Path:/public"))
+	protectedFrontend := buildFrontend(withRoute("/protected", "Path:/protected"))
+	protectedFrontend.OIDC = &types.OIDC{
+		Issuer:        "https://accounts.example.com",
+		ClientID:      "traefik",
+		SessionSecret: "session secret",
+	}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a-public", publicFrontend),
+		withFrontend("frontend-b-protected", protectedFrontend),
+		withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	// the requests which can't be redirected to the provider are rejected without reaching it
+	responseRecorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, backendServer.URL+"/protected", nil))
+	assert.Equal(t, http.StatusUnauthorized, responseRecorder.Code)
+
+	responseRecorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodPost, backendServer.URL+"/public", nil))
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+}
+
+func TestServerQueueOfFrontendsSharingBackend(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		perServer bool
+	}{
+		{
+			desc: "queue of the backend",
+		},
+		{
+			desc:      "queues of the servers",
+			perServer: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				close(started)
+				<-release
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer backendServer.Close()
+
+			frontendA := buildFrontend(withRoute("/a", "Path:/a"))
+			frontendB := buildFrontend(withRoute("/b", "Path:/b"))
+			frontendB.EntryPoints = []string{"http", "other"}
+			backend := buildBackend(withServer("server", backendServer.URL))
+			backend.Queue = &types.Queue{MaxConcurrent: 1, PerServer: test.perServer}
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http":  &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+					"other": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+				withFrontend("frontend-a", frontendA),
+				withFrontend("frontend-b", frontendB),
+				withBackend("backend", backend),
+			)}
+
+			srv := NewServer(globalConfig)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			first := make(chan int, 1)
+			go func() {
+				recorder := httptest.NewRecorder()
+				entryPoints["http"].httpRouter.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://127.0.0.1/a", nil))
+				first <- recorder.Code
+			}()
+			<-started
+
+			// the frontends and entry points of the backend share the limit of its queue
+			for _, entryPointName := range []string{"http", "other"} {
+				recorder := httptest.NewRecorder()
+				entryPoints[entryPointName].httpRouter.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://127.0.0.1/b", nil))
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, entryPointName)
+			}
+			queues := srv.backendQueues.Data()
+			assert.Len(t, queues, 1)
+			assert.Equal(t, int64(2), queues["backend"].Rejected)
+
+			close(release)
+			assert.Equal(t, http.StatusOK, <-first)
+		})
+	}
+}
+
+func TestServerFailover(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		primaryServer  bool
+		failover       *types.Failover
+		drFailover     *types.Failover
+		wantStatusCode int
+	}{
+		{
+			desc:           "healthy primary backend",
+			primaryServer:  true,
+			failover:       &types.Failover{Backend: "dr"},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc:           "primary backend without server",
+			failover:       &types.Failover{Backend: "dr"},
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			desc:           "undefined failover backend",
+			primaryServer:  true,
+			failover:       &types.Failover{Backend: "unknown"},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			desc:           "failover to the backend itself",
+			primaryServer:  true,
+			failover:       &types.Failover{Backend: "backend"},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			desc:           "failover loop",
+			primaryServer:  true,
+			failover:       &types.Failover{Backend: "dr"},
+			drFailover:     &types.Failover{Backend: "backend"},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			primaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer primaryServer.Close()
+			drServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusAccepted)
+			}))
+			defer drServer.Close()
+
+			primaryBuilders := []func(*types.Backend){withFailover(test.failover)}
+			if test.primaryServer {
+				primaryBuilders = append(primaryBuilders, withServer("primary", primaryServer.URL))
+			}
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+				withFrontend("frontend", buildFrontend(withRoute("/path", "Path:/path"))),
+				withBackend("backend", buildBackend(primaryBuilders...)),
+				withBackend("dr", buildBackend(withServer("dr", drServer.URL), withFailover(test.drFailover))),
+			)}
+
+			srv := NewServer(globalConfig)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			responseRecorder := &httptest.ResponseRecorder{}
+			request := httptest.NewRequest(http.MethodGet, primaryServer.URL+"/path", nil)
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Result().StatusCode)
+		})
+	}
+}
+
+func TestServerFailoverMiddlewares(t *testing.T) {
+	drServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer drServer.Close()
+
+	// both frontends use the backend without server, failing over to the dr backend
+	privateFrontend := buildFrontend(withRoute("/private", "Path:/private"))
+	privateFrontend.BasicAuth = []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a-public", buildFrontend(withRoute("/public", "Path:/public"))),
+		withFrontend("frontend-b-private", privateFrontend),
+		withBackend("backend", buildBackend(withFailover(&types.Failover{Backend: "dr"}))),
+		withBackend("dr", buildBackend(withServer("dr", drServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		path           string
+		authenticated  bool
+		wantStatusCode int
+	}{
+		{
+			desc:           "failover of the private frontend without credentials",
+			path:           "/private",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			desc:           "failover of the private frontend with credentials",
+			path:           "/private",
+			authenticated:  true,
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			desc:           "failover of the public frontend",
+			path:           "/public",
+			wantStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, drServer.URL+test.path, nil)
+			if test.authenticated {
+				request.SetBasicAuth("test", "test")
+			}
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Code)
+		})
+	}
+}
+
+func TestServerMaintenanceOfFrontendsSharingBackend(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	frontendA := buildFrontend(withRoute("/a", "Path:/a"))
+	frontendA.Maintenance = &types.Maintenance{}
+	frontendB := buildFrontend(withRoute("/b", "Path:/b"))
+	frontendB.Maintenance = &types.Maintenance{}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a", frontendA),
+		withFrontend("frontend-b", frontendB),
+		withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	// the maintenance of the second frontend is enabled through the API
+	srv.maintenanceModes.Set("frontend-b", true)
+
+	testCases := []struct {
+		path           string
+		wantStatusCode int
+	}{
+		{
+			path:           "/a",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			path:           "/b",
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.path, func(t *testing.T) {
+			responseRecorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, backendServer.URL+test.path, nil)
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, test.wantStatusCode, responseRecorder.Code)
+		})
+	}
+}
+
+func TestServerCacheOfFrontendsSharingBackend(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	frontendA := buildFrontend(withRoute("/a", "Path:/a"))
+	frontendA.Cache = &types.Cache{}
+	frontendB := buildFrontend(withRoute("/b", "Path:/b"))
+	frontendB.Cache = &types.Cache{}
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+		withFrontend("frontend-a", frontendA),
+		withFrontend("frontend-b", frontendB),
+		withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+	)}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	// the cache needs a response writer notifying the closing of the connection
+	frontServer := httptest.NewServer(entryPoints["http"].httpRouter)
+	defer frontServer.Close()
+	resp, err := http.Get(frontServer.URL + "/b")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the response is cached, and purged, by the frontend which served it
+	assert.Equal(t, 1, srv.responseCaches.Data()["frontend-b"].Entries)
+	assert.Equal(t, 0, srv.responseCaches.Data()["frontend-a"].Entries)
+}
+
 func TestServerLoadConfigBuildRedirect(t *testing.T) {
 	testCases := []struct {
 		desc                 string
@@ -1010,6 +1661,12 @@ func withRoute(routeName, rule string) func(*types.Frontend) {
 	}
 }
 
+func withTrafficSplit(trafficSplit map[string]int) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.TrafficSplit = trafficSplit
+	}
+}
+
 func buildBackend(backendBuilders ...func(*types.Backend)) *types.Backend {
 	be := &types.Backend{
 		Servers:      make(map[string]types.Server),
@@ -1027,6 +1684,12 @@ func withServer(name, url string) func(backend *types.Backend) {
 	}
 }
 
+func withFailover(failover *types.Failover) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.Failover = failover
+	}
+}
+
 func withLoadBalancer(method string, sticky bool) func(*types.Backend) {
 	return func(be *types.Backend) {
 		if sticky {
@@ -1036,3 +1699,284 @@ func withLoadBalancer(method string, sticky bool) func(*types.Backend) {
 		}
 	}
 }
+
+func TestCreateHTTPTransportNegotiatesHTTP2(t *testing.T) {
+	testCases := []struct {
+		desc                       string
+		globalConfig               configuration.GlobalConfiguration
+		tlsConfig                  *cryptotls.Config
+		expectedInsecureSkipVerify bool
+		expectRootCAs              bool
+	}{
+		{
+			desc: "default configuration",
+		},
+		{
+			desc:                       "insecure skip verify and root CAs",
+			globalConfig:               configuration.GlobalConfiguration{InsecureSkipVerify: true, RootCAs: tls.RootCAs{localhostCert}},
+			expectedInsecureSkipVerify: true,
+			expectRootCAs:              true,
+		},
+		{
+			desc:      "custom TLS client configuration",
+			tlsConfig: &cryptotls.Config{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var transport *http.Transport
+			if test.tlsConfig != nil {
+				transport = createHTTPTransportWithTLS(test.globalConfig, test.tlsConfig)
+			} else {
+				transport = createHTTPTransport(test.globalConfig)
+			}
+
+			require.NotNil(t, transport.TLSClientConfig)
+			assert.Contains(t, transport.TLSClientConfig.NextProtos, "h2")
+			assert.Contains(t, transport.TLSClientConfig.NextProtos, "http/1.1")
+			assert.Equal(t, test.expectedInsecureSkipVerify, transport.TLSClientConfig.InsecureSkipVerify)
+			assert.Equal(t, test.expectRootCAs, transport.TLSClientConfig.RootCAs != nil)
+		})
+	}
+}
+
+func TestServerBackendTLS(t *testing.T) {
+	clientCert, clientKey, err := generate.KeyPair("client", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	clientBlock, _ := pem.Decode(clientCert)
+	require.NotNil(t, clientBlock)
+
+	serverCertificate, err := cryptotls.X509KeyPair([]byte(localhostCert), []byte(localhostKey))
+	require.NoError(t, err)
+	testServer := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) == 0 || !reflect.DeepEqual(req.TLS.PeerCertificates[0].Raw, clientBlock.Bytes) {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	testServer.TLS = &cryptotls.Config{
+		Certificates: []cryptotls.Certificate{serverCertificate},
+		ClientAuth:   cryptotls.RequestClientCert,
+	}
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	testCases := []struct {
+		desc           string
+		backendTLS     *types.BackendTLS
+		expectedStatus int
+	}{
+		{
+			desc:           "client certificate and CA",
+			backendTLS:     &types.BackendTLS{Cert: string(clientCert), Key: string(clientKey), CA: string(localhostCert)},
+			expectedStatus: http.StatusTeapot,
+		},
+		{
+			desc:           "CA without client certificate",
+			backendTLS:     &types.BackendTLS{CA: string(localhostCert)},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "client certificate without the CA of the server",
+			backendTLS:     &types.BackendTLS{Cert: string(clientCert), Key: string(clientKey)},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			desc:           "insecure client certificate",
+			backendTLS:     &types.BackendTLS{Cert: string(clientCert), Key: string(clientKey), InsecureSkipVerify: true},
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				DefaultEntryPoints: []string{"http"},
+			}
+
+			dynamicConfig := buildDynamicConfig(
+				withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+				withBackend("backend", buildBackend(withServer("server", testServer.URL))),
+			)
+			dynamicConfig.Frontends["frontend"].EntryPoints = nil
+			dynamicConfig.Backends["backend"].TLS = test.backendTLS
+
+			srv := NewServer(globalConfig)
+			handlers, err := srv.BuildHandlers(types.Configurations{"backendTLS": dynamicConfig})
+			require.NoError(t, err)
+			require.Contains(t, handlers, "http")
+
+			recorder := httptest.NewRecorder()
+			handlers["http"].ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://127.0.0.1/", nil))
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestApplyBackendTLSErrors(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		backendTLS *types.BackendTLS
+	}{
+		{
+			desc:       "invalid CA",
+			backendTLS: &types.BackendTLS{CA: "not a certificate"},
+		},
+		{
+			desc:       "certificate without key",
+			backendTLS: &types.BackendTLS{Cert: string(localhostCert)},
+		},
+		{
+			desc:       "key not matching the certificate",
+			backendTLS: &types.BackendTLS{Cert: string(localhostCert), Key: "not a key"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Error(t, applyBackendTLS(&cryptotls.Config{}, test.backendTLS))
+		})
+	}
+}
+
+func TestGetRoundTripperBackendTransport(t *testing.T) {
+	s := &Server{defaultForwardingRoundTripper: createHTTPTransport(configuration.GlobalConfiguration{})}
+
+	roundTripper, err := s.getRoundTripper("http", configuration.GlobalConfiguration{}, false, nil, &types.Backend{})
+	require.NoError(t, err)
+	assert.Equal(t, s.defaultForwardingRoundTripper, roundTripper)
+
+	roundTripper, err = s.getRoundTripper("http", configuration.GlobalConfiguration{MaxIdleConnsPerHost: 200}, false, nil, &types.Backend{
+		Transport: &types.BackendTransport{
+			ResponseHeaderTimeout: flaeg.Duration(120 * time.Second),
+			DisableKeepAlives:     true,
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, &http.Transport{}, roundTripper)
+
+	transport := roundTripper.(*http.Transport)
+	assert.Equal(t, 200, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, 120*time.Second, transport.ResponseHeaderTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+}
+
+func TestGetResponseTimeout(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		frontend *types.Frontend
+		backend  *types.Backend
+		expected *types.ResponseTimeout
+	}{
+		{
+			desc:     "no response timeout",
+			frontend: &types.Frontend{},
+			backend:  &types.Backend{},
+		},
+		{
+			desc:     "backend response timeout",
+			frontend: &types.Frontend{},
+			backend: &types.Backend{ResponseTimeout: &types.ResponseTimeout{
+				Timeout:        flaeg.Duration(5 * time.Second),
+				DeadlineHeader: "X-Request-Deadline",
+			}},
+			expected: &types.ResponseTimeout{Timeout: flaeg.Duration(5 * time.Second), DeadlineHeader: "X-Request-Deadline"},
+		},
+		{
+			desc:     "frontend response timeout without backend",
+			frontend: &types.Frontend{ResponseTimeout: &types.ResponseTimeout{Timeout: flaeg.Duration(120 * time.Second)}},
+			expected: &types.ResponseTimeout{Timeout: flaeg.Duration(120 * time.Second)},
+		},
+		{
+			desc:     "frontend overriding the timeout of the backend",
+			frontend: &types.Frontend{ResponseTimeout: &types.ResponseTimeout{Timeout: flaeg.Duration(120 * time.Second)}},
+			backend: &types.Backend{ResponseTimeout: &types.ResponseTimeout{
+				Timeout:        flaeg.Duration(5 * time.Second),
+				DeadlineHeader: "X-Request-Deadline",
+			}},
+			expected: &types.ResponseTimeout{Timeout: flaeg.Duration(120 * time.Second), DeadlineHeader: "X-Request-Deadline"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, getResponseTimeout(test.frontend, test.backend))
+		})
+	}
+}
+
+func TestServerBuildHandlers(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+	defer testServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+		DefaultEntryPoints: []string{"http"},
+	}
+
+	dynamicConfig := buildDynamicConfig(
+		withFrontend("frontend", buildFrontend(withRoute("route", "Path:/embedded"))),
+		withBackend("backend", buildBackend(withServer("server", testServer.URL))),
+	)
+	dynamicConfig.Frontends["frontend"].EntryPoints = nil
+
+	srv := NewServer(globalConfig)
+	handlers, err := srv.BuildHandlers(types.Configurations{"embedded": dynamicConfig})
+	require.NoError(t, err)
+	require.Contains(t, handlers, "http")
+
+	recorder := httptest.NewRecorder()
+	handlers["http"].ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testServer.URL+"/embedded", nil))
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handlers["http"].ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testServer.URL+"/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestValidateCertificates(t *testing.T) {
+	certContent, err := localhostCert.Read()
+	require.NoError(t, err)
+	keyContent, err := localhostKey.Read()
+	require.NoError(t, err)
+	currentCert, err := cryptotls.X509KeyPair(certContent, keyContent)
+	require.NoError(t, err)
+
+	otherCert, _, err := generate.KeyPair("example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	otherCertBlock, _ := pem.Decode(otherCert)
+	// the private key does not match the certificate
+	invalidCert := &cryptotls.Certificate{Certificate: [][]byte{otherCertBlock.Bytes}, PrivateKey: currentCert.PrivateKey}
+
+	currentCertificates := tls.DomainsCertificates{"example.com": &currentCert}
+	srv := &Server{serverEntryPoints: serverEntryPoints{"https": &serverEntryPoint{}}}
+	srv.serverEntryPoints["https"].certs.Set(&currentCertificates)
+
+	newCertificates := tls.DomainsCertificates{
+		"example.com": invalidCert,
+		"other.com":   invalidCert,
+	}
+	srv.validateCertificates("https", &newCertificates)
+
+	assert.Equal(t, tls.DomainsCertificates{"example.com": &currentCert}, newCertificates)
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// isShadowProvider checks whether the configuration of the provider is only compared to the active configuration.
+func (s *Server) isShadowProvider(providerName string) bool {
+	for _, name := range s.globalConfiguration.ShadowProviders {
+		if name == providerName {
+			return true
+		}
+	}
+	return false
+}
+
+// loadShadowConfiguration records the configuration of a shadow provider and its differences with the active configuration,
+// the configuration is not applied.
+func (s *Server) loadShadowConfiguration(configMsg types.ConfigMessage) {
+	s.shadowLock.Lock()
+	defer s.shadowLock.Unlock()
+
+	shadowConfigurations := s.shadowConfigurations.Get().(types.ShadowConfigurations)
+	newShadowConfigurations := make(types.ShadowConfigurations)
+	for name, shadow := range shadowConfigurations {
+		newShadowConfigurations[name] = shadow
+	}
+
+	active := mergeConfigurations(s.currentConfigurations.Get().(types.Configurations))
+	newShadowConfigurations[configMsg.ProviderName] = s.compareShadowConfiguration(configMsg.ProviderName, active, configMsg.Configuration)
+
+	s.shadowConfigurations.Set(newShadowConfigurations)
+}
+
+// updateShadowConfigurations compares the configurations of the shadow providers to the new active configuration.
+func (s *Server) updateShadowConfigurations() {
+	s.shadowLock.Lock()
+	defer s.shadowLock.Unlock()
+
+	shadowConfigurations := s.shadowConfigurations.Get().(types.ShadowConfigurations)
+	if len(shadowConfigurations) == 0 {
+		return
+	}
+
+	active := mergeConfigurations(s.currentConfigurations.Get().(types.Configurations))
+	newShadowConfigurations := make(types.ShadowConfigurations)
+	for name, shadow := range shadowConfigurations {
+		newShadowConfigurations[name] = s.compareShadowConfiguration(name, active, shadow.Configuration)
+	}
+
+	s.shadowConfigurations.Set(newShadowConfigurations)
+}
+
+func (s *Server) compareShadowConfiguration(providerName string, active, shadow *types.Configuration) *types.ShadowConfiguration {
+	diff := diffConfigurations(active, shadow)
+
+	if count := diff.Count(); count > 0 {
+		log.Infof("Configuration of the shadow provider %s differs from the active configuration on %d frontends and backends: %+v", providerName, count, diff)
+	} else {
+		log.Infof("Configuration of the shadow provider %s matches the active configuration", providerName)
+	}
+	s.metricsRegistry.ShadowDifferencesGauge().With("provider", providerName).Set(float64(diff.Count()))
+
+	return &types.ShadowConfiguration{
+		Configuration: shadow,
+		Diff:          diff,
+	}
+}
+
+// mergeConfigurations merges the frontends and backends of the configurations of all the providers.
+func mergeConfigurations(configurations types.Configurations) *types.Configuration {
+	merged := &types.Configuration{
+		Frontends: make(map[string]*types.Frontend),
+		Backends:  make(map[string]*types.Backend),
+	}
+	for _, configuration := range configurations {
+		for name, frontend := range configuration.Frontends {
+			merged.Frontends[name] = frontend
+		}
+		for name, backend := range configuration.Backends {
+			merged.Backends[name] = backend
+		}
+	}
+	return merged
+}
+
+// diffConfigurations lists the frontends and backends of the active configuration missing from the shadow configuration,
+// the ones only defined by the shadow configuration, and the ones defined differently.
+func diffConfigurations(active, shadow *types.Configuration) types.ConfigurationDiff {
+	diff := types.ConfigurationDiff{}
+
+	for name, frontend := range active.Frontends {
+		shadowFrontend, ok := shadow.Frontends[name]
+		if !ok {
+			diff.MissingFrontends = append(diff.MissingFrontends, name)
+		} else if !reflect.DeepEqual(frontend, shadowFrontend) {
+			diff.ChangedFrontends = append(diff.ChangedFrontends, name)
+		}
+	}
+	for name := range shadow.Frontends {
+		if _, ok := active.Frontends[name]; !ok {
+			diff.ExtraFrontends = append(diff.ExtraFrontends, name)
+		}
+	}
+
+	for name, backend := range active.Backends {
+		shadowBackend, ok := shadow.Backends[name]
+		if !ok {
+			diff.MissingBackends = append(diff.MissingBackends, name)
+		} else if !reflect.DeepEqual(backend, shadowBackend) {
+			diff.ChangedBackends = append(diff.ChangedBackends, name)
+		}
+	}
+	for name := range shadow.Backends {
+		if _, ok := active.Backends[name]; !ok {
+			diff.ExtraBackends = append(diff.ExtraBackends, name)
+		}
+	}
+
+	sort.Strings(diff.MissingFrontends)
+	sort.Strings(diff.ExtraFrontends)
+	sort.Strings(diff.ChangedFrontends)
+	sort.Strings(diff.MissingBackends)
+	sort.Strings(diff.ExtraBackends)
+	sort.Strings(diff.ChangedBackends)
+
+	return diff
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffConfigurations(t *testing.T) {
+	active := &types.Configuration{
+		Frontends: map[string]*types.Frontend{
+			"frontend1": {Backend: "backend1"},
+			"frontend2": {Backend: "backend2"},
+			"frontend3": {Backend: "backend1"},
+		},
+		Backends: map[string]*types.Backend{
+			"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.1:80"}}},
+			"backend2": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.2:80"}}},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		shadow   *types.Configuration
+		expected types.ConfigurationDiff
+	}{
+		{
+			desc:   "same configuration",
+			shadow: active,
+		},
+		{
+			desc:   "empty shadow configuration",
+			shadow: &types.Configuration{},
+			expected: types.ConfigurationDiff{
+				MissingFrontends: []string{"frontend1", "frontend2", "frontend3"},
+				MissingBackends:  []string{"backend1", "backend2"},
+			},
+		},
+		{
+			desc: "missing, extra and changed frontends and backends",
+			shadow: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {Backend: "backend1"},
+					"frontend2": {Backend: "backend3"},
+					"frontend4": {Backend: "backend3"},
+				},
+				Backends: map[string]*types.Backend{
+					"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.1:80"}}},
+					"backend3": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.3:80"}}},
+				},
+			},
+			expected: types.ConfigurationDiff{
+				MissingFrontends: []string{"frontend3"},
+				ExtraFrontends:   []string{"frontend4"},
+				ChangedFrontends: []string{"frontend2"},
+				MissingBackends:  []string{"backend2"},
+				ExtraBackends:    []string{"backend3"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, diffConfigurations(active, test.shadow))
+		})
+	}
+}
+
+func TestPreLoadShadowConfiguration(t *testing.T) {
+	srv := NewServer(configuration.GlobalConfiguration{
+		ShadowProviders: []string{"ecs"},
+	})
+
+	srv.currentConfigurations.Set(types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend-whoami": {Backend: "backend-whoami"},
+			},
+			Backends: map[string]*types.Backend{
+				"backend-whoami": {},
+			},
+		},
+	})
+
+	srv.preLoadConfiguration(types.ConfigMessage{
+		ProviderName: "ecs",
+		Configuration: &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend-whoami": {Backend: "backend-whoami"},
+			},
+			Backends: map[string]*types.Backend{},
+		},
+	})
+
+	// the configuration of the shadow provider is not applied
+	assert.Len(t, srv.configurationValidatedChan, 0)
+
+	shadowConfigurations := srv.shadowConfigurations.Get().(types.ShadowConfigurations)
+	require.Contains(t, shadowConfigurations, "ecs")
+	assert.Equal(t, types.ConfigurationDiff{MissingBackends: []string{"backend-whoami"}}, shadowConfigurations["ecs"].Diff)
+
+	// the differences are updated when the active configuration changes
+	srv.currentConfigurations.Set(types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend-whoami": {Backend: "backend-whoami"},
+			},
+			Backends: map[string]*types.Backend{},
+		},
+	})
+	srv.updateShadowConfigurations()
+
+	shadowConfigurations = srv.shadowConfigurations.Get().(types.ShadowConfigurations)
+	assert.Equal(t, 0, shadowConfigurations["ecs"].Diff.Count())
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+// serverStartTimes records when the servers were added to their backend, across the configuration reloads,
+// for the slow start of the new servers.
+type serverStartTimes struct {
+	mu sync.Mutex
+	// backends holds the start times of the servers, by backend and server URL
+	backends map[string]map[string]time.Time
+}
+
+// update records the servers of the configurations, and returns their start times.
+// The servers of a backend seen for the first time have the zero start time:
+// only the servers added afterwards to a known backend are new.
+func (t *serverStartTimes) update(configurations types.Configurations, now time.Time) map[string]map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	backends := make(map[string]map[string]time.Time)
+	for _, config := range configurations {
+		for backendName, backend := range config.Backends {
+			previous, known := t.backends[backendName]
+			startTimes := make(map[string]time.Time, len(backend.Servers))
+			for _, server := range backend.Servers {
+				u, err := url.Parse(server.URL)
+				if err != nil {
+					continue
+				}
+				serverURL := u.String()
+				switch startedAt, ok := previous[serverURL]; {
+				case ok:
+					startTimes[serverURL] = startedAt
+				case known:
+					startTimes[serverURL] = now
+				default:
+					startTimes[serverURL] = time.Time{}
+				}
+			}
+			backends[backendName] = startTimes
+		}
+	}
+	t.backends = backends
+	return backends
+}
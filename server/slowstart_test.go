@@ -0,0 +1,38 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerStartTimesUpdate(t *testing.T) {
+	configuration := func(servers ...string) types.Configurations {
+		backend := &types.Backend{Servers: make(map[string]types.Server)}
+		for _, server := range servers {
+			backend.Servers[server] = types.Server{URL: "http://" + server + ":80"}
+		}
+		return types.Configurations{
+			"file": &types.Configuration{Backends: map[string]*types.Backend{"backend1": backend}},
+		}
+	}
+
+	var startTimes serverStartTimes
+	start := time.Now()
+
+	// the servers of a new backend are already started
+	assert.Equal(t, map[string]map[string]time.Time{
+		"backend1": {"http://a:80": {}, "http://b:80": {}},
+	}, startTimes.update(configuration("a", "b"), start))
+
+	// a new server starts, the removed servers are forgotten
+	assert.Equal(t, map[string]map[string]time.Time{
+		"backend1": {"http://a:80": {}, "http://c:80": start.Add(time.Second)},
+	}, startTimes.update(configuration("a", "c"), start.Add(time.Second)))
+
+	assert.Equal(t, map[string]map[string]time.Time{
+		"backend1": {"http://a:80": {}, "http://b:80": start.Add(2 * time.Second), "http://c:80": start.Add(time.Second)},
+	}, startTimes.update(configuration("a", "b", "c"), start.Add(2*time.Second)))
+}
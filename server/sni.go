@@ -0,0 +1,39 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/containous/traefik/types"
+)
+
+// errMissingSNI is the error of the handshakes without server name on an entry point with strict SNI.
+var errMissingSNI = errors.New("strict SNI: the client sent no server name")
+
+// rejectMissingSNI rejects the handshakes without server name.
+// It is set as GetConfigForClient, the only callback of the TLS configuration called before a certificate is chosen:
+// GetCertificate isn't called without server name when the configuration has static certificates.
+func rejectMissingSNI(clientHello *tls.ClientHelloInfo) (*tls.Config, error) {
+	if len(clientHello.ServerName) == 0 {
+		return nil, errMissingSNI
+	}
+	return nil, nil
+}
+
+// setDefaultCertificate moves the certificate of the domain first among the certificates of the configuration,
+// the first one being served to the clients sending no server name or an unknown one.
+func setDefaultCertificate(config *tls.Config, domain string) error {
+	domain = types.CanonicalDomain(domain)
+	for i := range config.Certificates {
+		leaf, err := certificateLeaf(&config.Certificates[i])
+		if err != nil {
+			continue
+		}
+		if leaf.VerifyHostname(domain) == nil {
+			config.Certificates[0], config.Certificates[i] = config.Certificates[i], config.Certificates[0]
+			return nil
+		}
+	}
+	return fmt.Errorf("no certificate for the domain %s", domain)
+}
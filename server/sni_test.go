@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultCertificate(t *testing.T) {
+	expiration := time.Now().Add(time.Hour)
+	foo := newTestCertificate(t, "foo.example.com", expiration)
+	bar := newTestCertificate(t, "bar.example.com", expiration)
+	wildcard := newTestCertificate(t, "*.example.org", expiration)
+
+	testCases := []struct {
+		desc          string
+		domain        string
+		expected      *tls.Certificate
+		expectedError bool
+	}{
+		{
+			desc:     "first certificate",
+			domain:   "foo.example.com",
+			expected: foo,
+		},
+		{
+			desc:     "other certificate",
+			domain:   "Bar.Example.com",
+			expected: bar,
+		},
+		{
+			desc:     "wildcard certificate",
+			domain:   "www.example.org",
+			expected: wildcard,
+		},
+		{
+			desc:          "unknown domain",
+			domain:        "baz.example.com",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &tls.Config{Certificates: []tls.Certificate{*foo, *bar, *wildcard}}
+			err := setDefaultCertificate(config, test.domain)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Certificate, config.Certificates[0].Certificate)
+			assert.Len(t, config.Certificates, 3)
+		})
+	}
+}
+
+func TestStrictSNI(t *testing.T) {
+	expiration := time.Now().Add(time.Hour)
+	foo := newTestCertificate(t, "foo.example.com", expiration)
+	bar := newTestCertificate(t, "bar.example.com", expiration)
+
+	testCases := []struct {
+		desc          string
+		strictSNI     bool
+		serverName    string
+		expected      *tls.Certificate
+		expectedError bool
+	}{
+		{
+			desc:     "no server name",
+			expected: bar,
+		},
+		{
+			desc:          "no server name with strict SNI",
+			strictSNI:     true,
+			expectedError: true,
+		},
+		{
+			desc:       "server name with strict SNI",
+			strictSNI:  true,
+			serverName: "foo.example.com",
+			expected:   foo,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &tls.Config{Certificates: []tls.Certificate{*foo, *bar}}
+			require.NoError(t, setDefaultCertificate(config, "bar.example.com"))
+			config.BuildNameToCertificate()
+			if test.strictSNI {
+				config.GetConfigForClient = rejectMissingSNI
+			}
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go func() {
+				server := tls.Server(serverConn, config)
+				server.Handshake()
+				server.Close()
+			}()
+
+			client := tls.Client(clientConn, &tls.Config{ServerName: test.serverName, InsecureSkipVerify: true})
+			err := client.Handshake()
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Certificate[0], client.ConnectionState().PeerCertificates[0].Raw)
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig"
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultVaultPKIMount     = "pki"
+	defaultVaultAppRoleMount = "approle"
+	// vaultPKIRetryInterval is the delay before requesting again a certificate after a failure.
+	vaultPKIRetryInterval = time.Minute
+	// vaultPKITimeout is the amount of time to wait for the response of Vault.
+	vaultPKITimeout = 30 * time.Second
+)
+
+// vaultPKI requests the certificates of the domains of an entry point from the PKI secrets engine of Vault,
+// serves them, and renews them before they expire.
+// A renewed certificate replaces the previous one in the served certificates once it is issued,
+// the previous one being served until then.
+type vaultPKI struct {
+	entryPoint    string
+	onHostRule    bool
+	client        *http.Client
+	address       string
+	mount         string
+	role          *template.Template
+	commonName    *template.Template
+	ttl           time.Duration
+	renewBefore   time.Duration
+	auth          *configuration.VaultAuth
+	checkInterval time.Duration
+
+	certs safe.Safe
+	wake  chan struct{}
+
+	lock sync.Mutex
+	// static holds the domains of the configuration, frontends the ones of the Host rules of the frontends
+	static       map[string]bool
+	frontends    map[string]bool
+	certificates map[string]*vaultCertificate
+	token        string
+	tokenRenewAt time.Time
+}
+
+// vaultCertificate is the certificate of a domain, nil until it's issued.
+type vaultCertificate struct {
+	certificate *tls.Certificate
+	domains     string
+	notAfter    time.Time
+	renewAt     time.Time
+	nextAttempt time.Time
+}
+
+// vaultPKITemplateData holds the data of the role and common name templates.
+type vaultPKITemplateData struct {
+	Domain string
+}
+
+func newVaultPKI(config *configuration.VaultPKI) (*vaultPKI, error) {
+	address := config.Address
+	if len(address) == 0 {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if len(address) == 0 {
+		return nil, errors.New("the address of Vault is required")
+	}
+	if len(config.EntryPoint) == 0 {
+		return nil, errors.New("the entry point serving the certificates is required")
+	}
+	if len(config.Role) == 0 {
+		return nil, errors.New("the role issuing the certificates is required")
+	}
+
+	role, err := template.New("role").Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(config.Role)
+	if err != nil {
+		return nil, fmt.Errorf("invalid role template: %v", err)
+	}
+	commonName := config.CommonName
+	if len(commonName) == 0 {
+		commonName = "{{.Domain}}"
+	}
+	commonNameTemplate, err := template.New("commonName").Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(commonName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid common name template: %v", err)
+	}
+
+	auth := config.Auth
+	if auth == nil || (len(auth.Token) == 0 && auth.AppRole == nil) {
+		auth = &configuration.VaultAuth{Token: os.Getenv("VAULT_TOKEN")}
+	}
+	if auth.AppRole == nil && len(auth.Token) == 0 {
+		return nil, errors.New("a token or the AppRole credentials are required")
+	}
+	if auth.AppRole != nil && len(auth.AppRole.RoleID) == 0 {
+		return nil, errors.New("the role ID of the AppRole auth method is required")
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if len(config.CA) > 0 {
+		ca, err := config.CA.Read()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CA of Vault: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid CA certificate(s) of Vault")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	mount := strings.Trim(config.Mount, "/")
+	if len(mount) == 0 {
+		mount = defaultVaultPKIMount
+	}
+
+	v := &vaultPKI{
+		entryPoint:    config.EntryPoint,
+		onHostRule:    config.OnHostRule,
+		client:        &http.Client{Timeout: vaultPKITimeout, Transport: transport},
+		address:       strings.TrimSuffix(address, "/"),
+		mount:         mount,
+		role:          role,
+		commonName:    commonNameTemplate,
+		ttl:           time.Duration(config.TTL),
+		renewBefore:   time.Duration(config.RenewBefore),
+		auth:          auth,
+		checkInterval: time.Minute,
+		wake:          make(chan struct{}, 1),
+		static:        make(map[string]bool),
+		frontends:     make(map[string]bool),
+		certificates:  make(map[string]*vaultCertificate),
+	}
+	for _, domain := range config.Domains {
+		if domain = types.CanonicalDomain(domain); len(domain) > 0 {
+			v.static[domain] = true
+			v.certificates[domain] = &vaultCertificate{}
+		}
+	}
+	return v, nil
+}
+
+// wrapGetCertificate serves the certificates issued by Vault after the ones returned by getCertificate.
+func (v *vaultPKI) wrapGetCertificate(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if getCertificate != nil {
+			certificate, err := getCertificate(clientHello)
+			if certificate != nil || err != nil {
+				return certificate, err
+			}
+		}
+		return v.getCertificate(clientHello), nil
+	}
+}
+
+func (v *vaultPKI) getCertificate(clientHello *tls.ClientHelloInfo) *tls.Certificate {
+	certificates, ok := v.certs.Get().(*traefikTls.DomainsCertificates)
+	if !ok {
+		return nil
+	}
+	return matchDomainsCertificates(certificates, clientHello.ServerName)
+}
+
+// setFrontendDomains sets the domains of the Host rules of the frontends of the entry point.
+// The certificates of the new domains are requested, the ones of the domains no longer used are no longer served.
+func (v *vaultPKI) setFrontendDomains(configurations types.Configurations) {
+	frontends := make(map[string]bool)
+	for _, config := range configurations {
+		for _, frontend := range config.Frontends {
+			if !containsString(frontend.EntryPoints, v.entryPoint) {
+				continue
+			}
+			for _, route := range frontend.Routes {
+				rules := Rules{}
+				domains, err := rules.ParseDomains(route.Rule)
+				if err != nil {
+					log.Errorf("Error parsing domains: %v", err)
+					continue
+				}
+				for _, domain := range domains {
+					frontends[types.CanonicalDomain(domain)] = true
+				}
+			}
+		}
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.frontends = frontends
+	var added bool
+	for domain := range frontends {
+		if _, ok := v.certificates[domain]; !ok {
+			v.certificates[domain] = &vaultCertificate{}
+			added = true
+		}
+	}
+	var removed bool
+	for domain := range v.certificates {
+		if !v.static[domain] && !v.frontends[domain] {
+			delete(v.certificates, domain)
+			removed = true
+		}
+	}
+	if removed {
+		v.publish()
+	}
+	if added {
+		select {
+		case v.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// publish replaces the served certificates by the issued ones. The caller must hold the lock.
+func (v *vaultPKI) publish() {
+	certificates := make(traefikTls.DomainsCertificates)
+	for _, certificate := range v.certificates {
+		if certificate.certificate != nil {
+			certificates[certificate.domains] = certificate.certificate
+		}
+	}
+	v.certs.Set(&certificates)
+}
+
+func (v *vaultPKI) run(ctx context.Context) {
+	ticker := time.NewTicker(v.checkInterval)
+	defer ticker.Stop()
+
+	v.issueDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-v.wake:
+			v.issueDue(ctx)
+		case <-ticker.C:
+			v.issueDue(ctx)
+		}
+	}
+}
+
+// issueDue requests the certificates of the domains without certificate, or whose certificate must be renewed.
+func (v *vaultPKI) issueDue(ctx context.Context) {
+	now := time.Now()
+	v.lock.Lock()
+	var domains []string
+	for domain, certificate := range v.certificates {
+		if now.Before(certificate.nextAttempt) {
+			continue
+		}
+		if certificate.certificate == nil || !now.Before(certificate.renewAt) {
+			domains = append(domains, domain)
+		}
+	}
+	v.lock.Unlock()
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return
+		}
+		issued, err := v.issue(ctx, domain)
+
+		v.lock.Lock()
+		current, ok := v.certificates[domain]
+		switch {
+		case !ok:
+			// the domain is no longer used
+		case err != nil:
+			current.nextAttempt = time.Now().Add(vaultPKIRetryInterval)
+			if current.certificate == nil {
+				log.Errorf("Unable to request the certificate of %s from Vault: %v", domain, err)
+			} else {
+				log.Errorf("Unable to renew the certificate of %s from Vault, the current certificate expiring at %s is kept: %v", domain, current.notAfter, err)
+			}
+		default:
+			if current.certificate == nil {
+				log.Infof("Certificate of %s issued by Vault, renewed at %s", domain, issued.renewAt)
+			} else {
+				log.Infof("Certificate of %s renewed by Vault, renewed again at %s", domain, issued.renewAt)
+			}
+			v.certificates[domain] = issued
+			v.publish()
+		}
+		v.lock.Unlock()
+	}
+}
+
+// issue requests a new certificate of the domain.
+func (v *vaultPKI) issue(ctx context.Context, domain string) (*vaultCertificate, error) {
+	data := vaultPKITemplateData{Domain: domain}
+	role, err := executeVaultPKITemplate(v.role, data)
+	if err != nil {
+		return nil, err
+	}
+	commonName, err := executeVaultPKITemplate(v.commonName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]string{"common_name": commonName}
+	if commonName != domain {
+		request["alt_names"] = domain
+	}
+	if v.ttl > 0 {
+		request["ttl"] = v.ttl.String()
+	}
+	response := struct {
+		Certificate string
+		PrivateKey  string   `json:"private_key"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	}{}
+	if err := v.authenticatedRequest(ctx, fmt.Sprintf("%s/issue/%s", v.mount, role), request, &response); err != nil {
+		return nil, err
+	}
+
+	chain := response.CAChain
+	if len(chain) == 0 && len(response.IssuingCA) > 0 {
+		chain = []string{response.IssuingCA}
+	}
+	certContent := strings.Join(append([]string{response.Certificate}, chain...), "\n")
+	certificate, err := tls.X509KeyPair([]byte(certContent), []byte(response.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate issued by Vault: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate issued by Vault: %v", err)
+	}
+
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	renewBefore := v.renewBefore
+	if renewBefore <= 0 || renewBefore >= lifetime {
+		renewBefore = lifetime / 3
+	}
+	return &vaultCertificate{
+		certificate: &certificate,
+		domains:     certificateDomains(leaf),
+		notAfter:    leaf.NotAfter,
+		renewAt:     leaf.NotAfter.Add(-renewBefore),
+	}, nil
+}
+
+func executeVaultPKITemplate(tmpl *template.Template, data vaultPKITemplateData) (string, error) {
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("unable to execute the %s template: %v", tmpl.Name(), err)
+	}
+	value := strings.TrimSpace(buffer.String())
+	if len(value) == 0 {
+		return "", fmt.Errorf("the %s template is empty for %s", tmpl.Name(), data.Domain)
+	}
+	return value, nil
+}
+
+// authenticatedRequest sends a request with the Vault token, logging in again when the AppRole token is denied.
+func (v *vaultPKI) authenticatedRequest(ctx context.Context, path string, body interface{}, data interface{}) error {
+	token, err := v.getToken(ctx, false)
+	if err != nil {
+		return err
+	}
+	err = v.request(ctx, path, token, body, data, nil)
+	if err == errVaultPermissionDenied && v.auth.AppRole != nil {
+		if token, err = v.getToken(ctx, true); err != nil {
+			return err
+		}
+		err = v.request(ctx, path, token, body, data, nil)
+	}
+	return err
+}
+
+// getToken returns the Vault token, logging in with the AppRole auth method when needed.
+func (v *vaultPKI) getToken(ctx context.Context, renew bool) (string, error) {
+	if v.auth.AppRole == nil {
+		return v.auth.Token, nil
+	}
+
+	v.lock.Lock()
+	if !renew && len(v.token) > 0 && time.Now().Before(v.tokenRenewAt) {
+		token := v.token
+		v.lock.Unlock()
+		return token, nil
+	}
+	v.lock.Unlock()
+
+	secretID := v.auth.AppRole.SecretID
+	if len(secretID) == 0 {
+		secretID = os.Getenv("VAULT_SECRET_ID")
+	}
+	mount := strings.Trim(v.auth.AppRole.Mount, "/")
+	if len(mount) == 0 {
+		mount = defaultVaultAppRoleMount
+	}
+	auth := struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	}{}
+	login := map[string]string{"role_id": v.auth.AppRole.RoleID, "secret_id": secretID}
+	if err := v.request(ctx, fmt.Sprintf("auth/%s/login", mount), "", login, nil, &auth); err != nil {
+		return "", fmt.Errorf("unable to log in to Vault with the AppRole auth method: %v", err)
+	}
+	if len(auth.ClientToken) == 0 {
+		return "", errors.New("no token returned by the AppRole auth method of Vault")
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.token = auth.ClientToken
+	// log in again at two thirds of the lease of the token, for the token not to expire while it's used
+	v.tokenRenewAt = time.Now().Add(time.Duration(auth.LeaseDuration) * time.Second * 2 / 3)
+	return v.token, nil
+}
+
+var errVaultPermissionDenied = errors.New("permission denied")
+
+// request posts the body to the Vault API path, and decodes the data and the auth of the response.
+func (v *vaultPKI) request(ctx context.Context, path, token string, body interface{}, data interface{}, auth interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/%s", v.address, path), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	if len(token) > 0 {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errVaultPermissionDenied
+	}
+	response := struct {
+		Data   interface{}
+		Auth   interface{}
+		Errors []string
+	}{Data: data, Auth: auth}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && resp.StatusCode/100 == 2 {
+		return fmt.Errorf("invalid response of Vault: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Vault request failed with status %d: %s", resp.StatusCode, strings.Join(response.Errors, ", "))
+	}
+	return nil
+}
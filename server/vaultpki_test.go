@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVaultPKI issues the certificates of the PKI secrets engine with a generated CA.
+type fakeVaultPKI struct {
+	t      *testing.T
+	caKey  *rsa.PrivateKey
+	ca     *x509.Certificate
+	caPEM  string
+	serial int64
+
+	lock     sync.Mutex
+	tokens   map[string]bool
+	logins   int
+	requests []map[string]string
+	paths    []string
+	failing  bool
+}
+
+func newFakeVaultPKI(t *testing.T) *fakeVaultPKI {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake Vault CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &fakeVaultPKI{
+		t:      t,
+		caKey:  caKey,
+		ca:     ca,
+		caPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		serial: 1,
+		tokens: map[string]bool{"root": true},
+	}
+}
+
+func (f *fakeVaultPKI) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	input := map[string]string{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.URL.Path == "/v1/auth/approle/login" {
+		if input["role_id"] != "role" || input["secret_id"] != "secret" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		f.logins++
+		token := "approle-" + big.NewInt(int64(f.logins)).String()
+		f.tokens[token] = true
+		json.NewEncoder(rw).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600}})
+		return
+	}
+
+	if !f.tokens[req.Header.Get("X-Vault-Token")] {
+		rw.WriteHeader(http.StatusForbidden)
+		rw.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, "/v1/pki/issue/") {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	if f.failing {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(`{"errors":["internal error"]}`))
+		return
+	}
+	f.paths = append(f.paths, req.URL.Path)
+	f.requests = append(f.requests, input)
+
+	ttl := time.Hour
+	if len(input["ttl"]) > 0 {
+		var err error
+		ttl, err = time.ParseDuration(input["ttl"])
+		require.NoError(f.t, err)
+	}
+	var dnsNames []string
+	if len(input["alt_names"]) > 0 {
+		dnsNames = strings.Split(input["alt_names"], ",")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(f.t, err)
+	f.serial++
+	now := time.Now()
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(f.serial),
+		Subject:      pkix.Name{CommonName: input["common_name"]},
+		DNSNames:     dnsNames,
+		NotBefore:    now,
+		NotAfter:     now.Add(ttl),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, f.ca, &key.PublicKey, f.caKey)
+	require.NoError(f.t, err)
+
+	json.NewEncoder(rw).Encode(map[string]interface{}{"data": map[string]interface{}{
+		"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"issuing_ca":  f.caPEM,
+		"ca_chain":    []string{f.caPEM},
+	}})
+}
+
+func servedSerial(t *testing.T, v *vaultPKI, serverName string) int64 {
+	certificate := v.getCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	if certificate == nil {
+		return 0
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	return leaf.SerialNumber.Int64()
+}
+
+func TestNewVaultPKI(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *configuration.VaultPKI
+		expectedError bool
+	}{
+		{
+			desc:   "token",
+			config: &configuration.VaultPKI{Address: "http://vault:8200", EntryPoint: "https", Role: "traefik", Auth: &configuration.VaultAuth{Token: "root"}},
+		},
+		{
+			desc:   "AppRole",
+			config: &configuration.VaultPKI{Address: "http://vault:8200", EntryPoint: "https", Role: "traefik", Auth: &configuration.VaultAuth{AppRole: &configuration.VaultAppRole{RoleID: "role"}}},
+		},
+		{
+			desc:          "AppRole without role ID",
+			config:        &configuration.VaultPKI{Address: "http://vault:8200", EntryPoint: "https", Role: "traefik", Auth: &configuration.VaultAuth{AppRole: &configuration.VaultAppRole{}}},
+			expectedError: true,
+		},
+		{
+			desc:          "no address",
+			config:        &configuration.VaultPKI{EntryPoint: "https", Role: "traefik", Auth: &configuration.VaultAuth{Token: "root"}},
+			expectedError: true,
+		},
+		{
+			desc:          "no role",
+			config:        &configuration.VaultPKI{Address: "http://vault:8200", EntryPoint: "https", Auth: &configuration.VaultAuth{Token: "root"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid common name template",
+			config:        &configuration.VaultPKI{Address: "http://vault:8200", EntryPoint: "https", Role: "traefik", CommonName: "{{.Domain", Auth: &configuration.VaultAuth{Token: "root"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid CA",
+			config:        &configuration.VaultPKI{Address: "http://vault:8200", CA: "not a certificate", EntryPoint: "https", Role: "traefik", Auth: &configuration.VaultAuth{Token: "root"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newVaultPKI(test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVaultPKIIssueAndRenew(t *testing.T) {
+	vault := newFakeVaultPKI(t)
+	vaultServer := httptest.NewServer(vault)
+	defer vaultServer.Close()
+
+	v, err := newVaultPKI(&configuration.VaultPKI{
+		Address:    vaultServer.URL,
+		Auth:       &configuration.VaultAuth{Token: "root"},
+		Role:       `{{.Domain | replace "." "-"}}`,
+		CommonName: "{{.Domain}}.internal",
+		TTL:        flaeg.Duration(3 * time.Hour),
+		EntryPoint: "https",
+		Domains:    []string{"Static.Example.com"},
+	})
+	require.NoError(t, err)
+
+	v.issueDue(context.Background())
+	require.Equal(t, []string{"/v1/pki/issue/static-example-com"}, vault.paths)
+	assert.Equal(t, map[string]string{"common_name": "static.example.com.internal", "alt_names": "static.example.com", "ttl": "3h0m0s"}, vault.requests[0])
+
+	firstSerial := servedSerial(t, v, "static.example.com")
+	require.NotZero(t, firstSerial)
+	assert.Equal(t, firstSerial, servedSerial(t, v, "static.example.com.internal"))
+	assert.Zero(t, servedSerial(t, v, "other.example.com"))
+
+	// renewed a third of the lifetime before the expiration
+	certificate := v.certificates["static.example.com"]
+	assert.WithinDuration(t, certificate.notAfter.Add(-time.Hour), certificate.renewAt, time.Second)
+
+	// not renewed before its time
+	v.issueDue(context.Background())
+	assert.Len(t, vault.paths, 1)
+
+	// the current certificate is served while the renewal fails
+	v.certificates["static.example.com"].renewAt = time.Now().Add(-time.Second)
+	vault.failing = true
+	v.issueDue(context.Background())
+	assert.Equal(t, firstSerial, servedSerial(t, v, "static.example.com"))
+	assert.True(t, v.certificates["static.example.com"].nextAttempt.After(time.Now()))
+
+	// the renewed certificate replaces the current one
+	vault.failing = false
+	v.certificates["static.example.com"].nextAttempt = time.Time{}
+	v.issueDue(context.Background())
+	renewedSerial := servedSerial(t, v, "static.example.com")
+	assert.NotZero(t, renewedSerial)
+	assert.NotEqual(t, firstSerial, renewedSerial)
+}
+
+func TestVaultPKIFrontendDomains(t *testing.T) {
+	vault := newFakeVaultPKI(t)
+	vaultServer := httptest.NewServer(vault)
+	defer vaultServer.Close()
+
+	v, err := newVaultPKI(&configuration.VaultPKI{
+		Address:    vaultServer.URL,
+		Auth:       &configuration.VaultAuth{Token: "root"},
+		Role:       "traefik",
+		EntryPoint: "https",
+		OnHostRule: true,
+	})
+	require.NoError(t, err)
+
+	configurations := types.Configurations{
+		"provider": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {EntryPoints: []string{"http", "https"}, Routes: map[string]types.Route{"route": {Rule: "Host:a.example.com,b.example.com"}}},
+				"frontend2": {EntryPoints: []string{"http"}, Routes: map[string]types.Route{"route": {Rule: "Host:c.example.com"}}},
+			},
+		},
+	}
+	v.setFrontendDomains(configurations)
+	v.issueDue(context.Background())
+
+	assert.Len(t, vault.requests, 2)
+	assert.NotZero(t, servedSerial(t, v, "a.example.com"))
+	assert.NotZero(t, servedSerial(t, v, "b.example.com"))
+	assert.Zero(t, servedSerial(t, v, "c.example.com"))
+	assert.Equal(t, "a.example.com", vault.requests[0]["common_name"])
+	assert.NotContains(t, vault.requests[0], "alt_names")
+
+	// the certificates of the domains no longer used are no longer served
+	configurations["provider"].Frontends["frontend1"].Routes["route"] = types.Route{Rule: "Host:a.example.com"}
+	v.setFrontendDomains(configurations)
+	assert.NotZero(t, servedSerial(t, v, "a.example.com"))
+	assert.Zero(t, servedSerial(t, v, "b.example.com"))
+}
+
+func TestVaultPKIAppRole(t *testing.T) {
+	vault := newFakeVaultPKI(t)
+	vaultServer := httptest.NewServer(vault)
+	defer vaultServer.Close()
+
+	v, err := newVaultPKI(&configuration.VaultPKI{
+		Address:    vaultServer.URL,
+		Auth:       &configuration.VaultAuth{AppRole: &configuration.VaultAppRole{RoleID: "role", SecretID: "secret"}},
+		Role:       "traefik",
+		EntryPoint: "https",
+		Domains:    []string{"a.example.com", "b.example.com"},
+	})
+	require.NoError(t, err)
+
+	v.issueDue(context.Background())
+	assert.Equal(t, 1, vault.logins, "the token is reused")
+	assert.NotZero(t, servedSerial(t, v, "a.example.com"))
+	assert.NotZero(t, servedSerial(t, v, "b.example.com"))
+	assert.WithinDuration(t, time.Now().Add(40*time.Minute), v.tokenRenewAt, 5*time.Second)
+
+	// a revoked token is replaced by logging in again
+	vault.tokens = map[string]bool{}
+	v.certificates["a.example.com"].renewAt = time.Now().Add(-time.Second)
+	v.issueDue(context.Background())
+	assert.Equal(t, 2, vault.logins)
+	assert.Len(t, vault.requests, 3)
+
+	t.Run("invalid secret ID", func(t *testing.T) {
+		v.auth = &configuration.VaultAuth{AppRole: &configuration.VaultAppRole{RoleID: "role", SecretID: "invalid"}}
+		_, err := v.getToken(context.Background(), true)
+		assert.EqualError(t, err, "unable to log in to Vault with the AppRole auth method: Vault request failed with status 400: invalid role or secret ID")
+	})
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	webhookSignatureHeader = "X-Traefik-Signature"
+	webhookEventHeader     = "X-Traefik-Event"
+	webhookEvent           = "configuration"
+	webhookAlertEvent      = "alert"
+)
+
+// configurationChange is the payload posted to the webhooks when a new configuration is applied.
+type configurationChange struct {
+	Provider     string             `json:"provider"`
+	Timestamp    time.Time          `json:"timestamp"`
+	Frontends    nameChanges        `json:"frontends"`
+	Backends     nameChanges        `json:"backends"`
+	Certificates certificateChanges `json:"certificates"`
+}
+
+type nameChanges struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+type certificateChanges struct {
+	Added   []certificateSummary `json:"added,omitempty"`
+	Removed []certificateSummary `json:"removed,omitempty"`
+	Changed []certificateSummary `json:"changed,omitempty"`
+}
+
+// certificateSummary identifies a certificate by the SHA-256 fingerprint of its leaf, never by its content.
+type certificateSummary struct {
+	Fingerprint string   `json:"fingerprint"`
+	Domains     []string `json:"domains,omitempty"`
+	EntryPoints []string `json:"entryPoints,omitempty"`
+}
+
+func (c configurationChange) isEmpty() bool {
+	return len(c.Frontends.Added)+len(c.Frontends.Removed)+len(c.Frontends.Changed)+
+		len(c.Backends.Added)+len(c.Backends.Removed)+len(c.Backends.Changed)+
+		len(c.Certificates.Added)+len(c.Certificates.Removed)+len(c.Certificates.Changed) == 0
+}
+
+// diffAppliedConfigurations lists the frontends, backends and certificates added, removed or changed
+// between the previously applied configurations and the new ones.
+func diffAppliedConfigurations(providerName string, previous, next types.Configurations) configurationChange {
+	diff := diffConfigurations(mergeConfigurations(previous), mergeConfigurations(next))
+
+	return configurationChange{
+		Provider: providerName,
+		Frontends: nameChanges{
+			Added:   diff.ExtraFrontends,
+			Removed: diff.MissingFrontends,
+			Changed: diff.ChangedFrontends,
+		},
+		Backends: nameChanges{
+			Added:   diff.ExtraBackends,
+			Removed: diff.MissingBackends,
+			Changed: diff.ChangedBackends,
+		},
+		Certificates: diffCertificates(summarizeCertificates(previous), summarizeCertificates(next)),
+	}
+}
+
+// summarizeCertificates returns the certificates of the configurations by fingerprint,
+// the certificates which can't be parsed are ignored as they are rejected when the configuration is loaded.
+func summarizeCertificates(configurations types.Configurations) map[string]certificateSummary {
+	summaries := make(map[string]certificateSummary)
+	for _, config := range configurations {
+		if config == nil {
+			continue
+		}
+		for _, tlsConfiguration := range config.TLSConfiguration {
+			if tlsConfiguration == nil || tlsConfiguration.Certificate == nil {
+				continue
+			}
+			summary, err := summarizeCertificate(tlsConfiguration.Certificate.CertFile)
+			if err != nil {
+				log.Debugf("Unable to read the certificate to notify the webhooks: %v", err)
+				continue
+			}
+			summary.EntryPoints = append(summaries[summary.Fingerprint].EntryPoints, tlsConfiguration.EntryPoints...)
+			sort.Strings(summary.EntryPoints)
+			summaries[summary.Fingerprint] = summary
+		}
+	}
+	return summaries
+}
+
+func summarizeCertificate(certFile traefikTls.FileOrContent) (certificateSummary, error) {
+	content, err := certFile.Read()
+	if err != nil {
+		return certificateSummary{}, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return certificateSummary{}, errors.New("no PEM data")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return certificateSummary{}, err
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	summary := certificateSummary{Fingerprint: hex.EncodeToString(fingerprint[:])}
+	if len(cert.Subject.CommonName) > 0 {
+		summary.Domains = append(summary.Domains, cert.Subject.CommonName)
+	}
+	for _, domain := range cert.DNSNames {
+		if domain != cert.Subject.CommonName {
+			summary.Domains = append(summary.Domains, domain)
+		}
+	}
+	return summary, nil
+}
+
+// diffCertificates compares the certificates by fingerprint, a certificate being changed when its entry points are.
+func diffCertificates(previous, next map[string]certificateSummary) certificateChanges {
+	changes := certificateChanges{}
+	for _, fingerprint := range sortedFingerprints(previous) {
+		summary, ok := next[fingerprint]
+		if !ok {
+			changes.Removed = append(changes.Removed, previous[fingerprint])
+		} else if !reflect.DeepEqual(previous[fingerprint].EntryPoints, summary.EntryPoints) {
+			changes.Changed = append(changes.Changed, summary)
+		}
+	}
+	for _, fingerprint := range sortedFingerprints(next) {
+		if _, ok := previous[fingerprint]; !ok {
+			changes.Added = append(changes.Added, next[fingerprint])
+		}
+	}
+	return changes
+}
+
+func sortedFingerprints(summaries map[string]certificateSummary) []string {
+	var fingerprints []string
+	for fingerprint := range summaries {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
+
+// notifyWebhooks notifies the webhooks of the changes between the previously applied configurations and the new ones, if any.
+func (s *Server) notifyWebhooks(providerName string, previous, next types.Configurations) {
+	if s.webhookNotifier == nil {
+		return
+	}
+	change := diffAppliedConfigurations(providerName, previous, next)
+	if change.isEmpty() {
+		return
+	}
+	change.Timestamp = time.Now().UTC()
+	s.webhookNotifier.notify(change)
+}
+
+// webhookNotifier posts the configuration changes to the webhooks, one change at a time in the order they were applied.
+type webhookNotifier struct {
+	urls    []string
+	poster  *webhookPoster
+	changes chan configurationChange
+}
+
+func newWebhookNotifier(config *configuration.ConfigurationWebhooks) *webhookNotifier {
+	return &webhookNotifier{
+		urls:    config.URLs,
+		poster:  newWebhookPoster(webhookEvent, config.Secret, time.Duration(config.Timeout)),
+		changes: make(chan configurationChange, 100),
+	}
+}
+
+// notify queues the change, the change is dropped when the webhooks are too slow to keep up.
+func (n *webhookNotifier) notify(change configurationChange) {
+	select {
+	case n.changes <- change:
+	default:
+		log.Warnf("Too many configuration changes waiting for the webhooks, dropping the change of the provider %s", change.Provider)
+	}
+}
+
+// run posts the queued changes until the context is done.
+func (n *webhookNotifier) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-n.changes:
+			payload, err := json.Marshal(change)
+			if err != nil {
+				log.Errorf("Unable to encode the configuration change for the webhooks: %v", err)
+				continue
+			}
+			for _, url := range n.urls {
+				if err := n.poster.post(ctx, url, payload); err != nil {
+					log.Errorf("Unable to notify the webhook %s of the configuration change: %v", url, err)
+				}
+			}
+		}
+	}
+}
+
+// webhookPoster posts the JSON payloads of an event to webhooks, signed when a secret is configured.
+type webhookPoster struct {
+	event  string
+	secret []byte
+	client *http.Client
+}
+
+func newWebhookPoster(event, secret string, timeout time.Duration) *webhookPoster {
+	if timeout <= 0 {
+		timeout = configuration.DefaultWebhookTimeout
+	}
+	return &webhookPoster{
+		event:  event,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *webhookPoster) post(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, p.event)
+	if len(p.secret) > 0 {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signPayload(p.secret, payload))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of the payload.
+func signPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAppliedConfigurations(t *testing.T) {
+	certificate := &traefikTls.Certificate{CertFile: localhostCert, KeyFile: localhostKey}
+
+	previous := types.Configurations{
+		"file": {
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend1"},
+				"frontend2": {Backend: "backend1"},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {LoadBalancer: &types.LoadBalancer{Method: "wrr"}},
+			},
+			TLSConfiguration: []*traefikTls.Configuration{
+				{EntryPoints: []string{"https"}, Certificate: certificate},
+			},
+		},
+	}
+	next := types.Configurations{
+		"file": {
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend2"},
+				"frontend3": {Backend: "backend2"},
+			},
+			Backends: map[string]*types.Backend{
+				"backend2": {LoadBalancer: &types.LoadBalancer{Method: "wrr"}},
+			},
+			TLSConfiguration: []*traefikTls.Configuration{
+				{EntryPoints: []string{"https", "admin"}, Certificate: certificate},
+			},
+		},
+	}
+
+	change := diffAppliedConfigurations("file", previous, next)
+	assert.Equal(t, "file", change.Provider)
+	assert.Equal(t, nameChanges{Added: []string{"frontend3"}, Removed: []string{"frontend2"}, Changed: []string{"frontend1"}}, change.Frontends)
+	assert.Equal(t, nameChanges{Added: []string{"backend2"}, Removed: []string{"backend1"}}, change.Backends)
+
+	assert.Empty(t, change.Certificates.Added)
+	assert.Empty(t, change.Certificates.Removed)
+	require.Len(t, change.Certificates.Changed, 1)
+	assert.Len(t, change.Certificates.Changed[0].Fingerprint, 64)
+	assert.Equal(t, []string{"example.com"}, change.Certificates.Changed[0].Domains)
+	assert.Equal(t, []string{"admin", "https"}, change.Certificates.Changed[0].EntryPoints)
+
+	change = diffAppliedConfigurations("file", next, types.Configurations{})
+	assert.Len(t, change.Certificates.Removed, 1)
+	assert.False(t, change.isEmpty())
+
+	assert.True(t, diffAppliedConfigurations("file", next, next).isEmpty())
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		requests <- req
+		bodies <- body
+	}))
+	defer ts.Close()
+
+	notifier := newWebhookNotifier(&configuration.ConfigurationWebhooks{
+		URLs:    []string{ts.URL},
+		Secret:  "secret",
+		Timeout: flaeg.Duration(time.Second),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifier.run(ctx)
+
+	notifier.notify(configurationChange{
+		Provider:  "docker",
+		Frontends: nameChanges{Added: []string{"frontend1"}},
+	})
+
+	select {
+	case req := <-requests:
+		body := <-bodies
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "configuration", req.Header.Get("X-Traefik-Event"))
+		assert.Equal(t, "sha256="+signPayload([]byte("secret"), body), req.Header.Get("X-Traefik-Signature"))
+
+		change := configurationChange{}
+		require.NoError(t, json.Unmarshal(body, &change))
+		assert.Equal(t, "docker", change.Provider)
+		assert.Equal(t, []string{"frontend1"}, change.Frontends.Added)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook has not been notified")
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	// echo -n '{"provider":"file"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "6769dcc4818fa267362b7ca1f30ccd8c52bd3c4c470d8969ebfdec49f09bf850", signPayload([]byte("secret"), []byte(`{"provider":"file"}`)))
+}
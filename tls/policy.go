@@ -0,0 +1,162 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// Policy is a named preset of TLS versions, cipher suites and curves
+type Policy struct {
+	MinVersion       string
+	CipherSuites     []string
+	CurvePreferences []string
+}
+
+var (
+	// MaxVersion Map of allowed TLS maximum versions
+	MaxVersion = map[string]uint16{
+		`VersionTLS10`: tls.VersionTLS10,
+		`VersionTLS11`: tls.VersionTLS11,
+		`VersionTLS12`: tls.VersionTLS12,
+	}
+
+	// Curves Map of the elliptic curves from crypto/tls
+	Curves = map[string]tls.CurveID{
+		`CurveP256`: tls.CurveP256,
+		`CurveP384`: tls.CurveP384,
+		`CurveP521`: tls.CurveP521,
+		`X25519`:    tls.X25519,
+	}
+
+	// Policies Map of the TLS policy presets, following the Mozilla server side TLS guidelines
+	// https://wiki.mozilla.org/Security/Server_Side_TLS
+	Policies = map[string]Policy{
+		`modern`: {
+			MinVersion: `VersionTLS12`,
+			CipherSuites: []string{
+				`TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256`,
+			},
+			CurvePreferences: []string{`X25519`, `CurveP256`, `CurveP384`},
+		},
+		`intermediate`: {
+			MinVersion: `VersionTLS10`,
+			CipherSuites: []string{
+				`TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA`,
+				`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA`,
+				`TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA`,
+				`TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA`,
+				`TLS_RSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_RSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_RSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_RSA_WITH_AES_128_CBC_SHA`,
+				`TLS_RSA_WITH_AES_256_CBC_SHA`,
+			},
+			CurvePreferences: []string{`X25519`, `CurveP256`, `CurveP384`, `CurveP521`},
+		},
+		`old`: {
+			MinVersion: `VersionTLS10`,
+			CipherSuites: []string{
+				`TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA`,
+				`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA`,
+				`TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA`,
+				`TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA`,
+				`TLS_RSA_WITH_AES_128_GCM_SHA256`,
+				`TLS_RSA_WITH_AES_256_GCM_SHA384`,
+				`TLS_RSA_WITH_AES_128_CBC_SHA256`,
+				`TLS_RSA_WITH_AES_128_CBC_SHA`,
+				`TLS_RSA_WITH_AES_256_CBC_SHA`,
+				`TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA`,
+				`TLS_RSA_WITH_3DES_EDE_CBC_SHA`,
+			},
+			CurvePreferences: []string{`X25519`, `CurveP256`, `CurveP384`, `CurveP521`},
+		},
+	}
+)
+
+// ApplyPolicy sets the TLS versions, cipher suites and curves of the entry point in the configuration:
+// the settings of the policy preset, if any, are overridden by the ones set explicitly.
+func (t *TLS) ApplyPolicy(config *tls.Config) error {
+	minVersion, cipherSuites, curvePreferences := t.MinVersion, t.CipherSuites, t.CurvePreferences
+	if len(t.Policy) > 0 {
+		policy, ok := Policies[strings.ToLower(t.Policy)]
+		if !ok {
+			return fmt.Errorf("unknown TLS policy %q", t.Policy)
+		}
+		if len(minVersion) == 0 {
+			minVersion = policy.MinVersion
+		}
+		if cipherSuites == nil {
+			cipherSuites = policy.CipherSuites
+		}
+		if curvePreferences == nil {
+			curvePreferences = policy.CurvePreferences
+		}
+		config.PreferServerCipherSuites = true
+	}
+
+	//Set the minimum TLS version if set in the config TOML
+	if minConst, exists := MinVersion[minVersion]; exists {
+		config.PreferServerCipherSuites = true
+		config.MinVersion = minConst
+	}
+	if len(t.MaxVersion) > 0 {
+		maxConst, exists := MaxVersion[t.MaxVersion]
+		if !exists {
+			return fmt.Errorf("invalid MaxVersion: %s", t.MaxVersion)
+		}
+		if config.MinVersion > maxConst {
+			return fmt.Errorf("the MaxVersion %s is lower than the MinVersion %s", t.MaxVersion, minVersion)
+		}
+		config.MaxVersion = maxConst
+	}
+
+	//Set the list of CipherSuites if set in the config TOML
+	if cipherSuites != nil {
+		//if our list of CipherSuites is defined in the entrypoint config, we can re-initilize the suites list as empty
+		config.CipherSuites = make([]uint16, 0)
+		for _, cipher := range cipherSuites {
+			cipherConst, exists := CipherSuites[cipher]
+			if !exists {
+				//CipherSuite listed in the toml does not exist in our listed
+				return fmt.Errorf("invalid CipherSuite: %s", cipher)
+			}
+			config.CipherSuites = append(config.CipherSuites, cipherConst)
+		}
+	}
+
+	if curvePreferences != nil {
+		config.CurvePreferences = make([]tls.CurveID, 0)
+		for _, curve := range curvePreferences {
+			curveConst, exists := Curves[curve]
+			if !exists {
+				return fmt.Errorf("invalid Curve: %s", curve)
+			}
+			config.CurvePreferences = append(config.CurvePreferences, curveConst)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package tls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPolicy(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		tlsOption     TLS
+		expected      *tls.Config
+		expectedError bool
+	}{
+		{
+			desc:      "no policy",
+			tlsOption: TLS{},
+			expected:  &tls.Config{},
+		},
+		{
+			desc: "explicit settings",
+			tlsOption: TLS{
+				MinVersion:       "VersionTLS11",
+				MaxVersion:       "VersionTLS12",
+				CipherSuites:     []string{"TLS_RSA_WITH_AES_256_GCM_SHA384"},
+				CurvePreferences: []string{"CurveP521", "X25519"},
+			},
+			expected: &tls.Config{
+				PreferServerCipherSuites: true,
+				MinVersion:               tls.VersionTLS11,
+				MaxVersion:               tls.VersionTLS12,
+				CipherSuites:             []uint16{tls.TLS_RSA_WITH_AES_256_GCM_SHA384},
+				CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.X25519},
+			},
+		},
+		{
+			desc:      "modern policy",
+			tlsOption: TLS{Policy: "Modern"},
+			expected: &tls.Config{
+				PreferServerCipherSuites: true,
+				MinVersion:               tls.VersionTLS12,
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+					tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+					tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+					tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+				},
+				CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+			},
+		},
+		{
+			desc: "policy overridden by the explicit settings",
+			tlsOption: TLS{
+				Policy:           "old",
+				MinVersion:       "VersionTLS12",
+				CipherSuites:     []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+				CurvePreferences: []string{"CurveP256"},
+			},
+			expected: &tls.Config{
+				PreferServerCipherSuites: true,
+				MinVersion:               tls.VersionTLS12,
+				CipherSuites:             []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+				CurvePreferences:         []tls.CurveID{tls.CurveP256},
+			},
+		},
+		{
+			desc:          "unknown policy",
+			tlsOption:     TLS{Policy: "strict"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid max version",
+			tlsOption:     TLS{MaxVersion: "VersionTLS99"},
+			expectedError: true,
+		},
+		{
+			desc:          "max version lower than the min version",
+			tlsOption:     TLS{Policy: "modern", MaxVersion: "VersionTLS11"},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid cipher suite",
+			tlsOption:     TLS{CipherSuites: []string{"TLS_FOO"}},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid curve",
+			tlsOption:     TLS{CurvePreferences: []string{"CurveP224"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &tls.Config{}
+			err := test.tlsOption.ApplyPolicy(config)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	for name, policy := range Policies {
+		_, exists := MinVersion[policy.MinVersion]
+		assert.True(t, exists, "policy %s: unknown MinVersion %s", name, policy.MinVersion)
+		for _, cipher := range policy.CipherSuites {
+			_, exists := CipherSuites[cipher]
+			assert.True(t, exists, "policy %s: unknown CipherSuite %s", name, cipher)
+		}
+		for _, curve := range policy.CurvePreferences {
+			_, exists := Curves[curve]
+			assert.True(t, exists, "policy %s: unknown Curve %s", name, curve)
+		}
+	}
+}
@@ -2,24 +2,71 @@ package tls
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// Modes of client certificate authentication
+const (
+	// ClientAuthRequest requests a client certificate without verifying it, the frontends verifying it with their CAs
+	ClientAuthRequest = "request"
+	// ClientAuthVerify verifies the client certificate if one is given
+	ClientAuthVerify = "verify"
+	// ClientAuthRequire requires a valid client certificate
+	ClientAuthRequire = "require"
+)
+
 // ClientCA defines traefik CA files for a entryPoint
 // and it indicates if they are mandatory or have just to be analyzed if provided
 type ClientCA struct {
 	Files    []string
 	Optional bool
+	Mode     string
+}
+
+// ClientAuthType returns the client authentication policy of the mode,
+// of the Optional flag when no mode is set.
+func (c ClientCA) ClientAuthType() (tls.ClientAuthType, error) {
+	switch strings.ToLower(c.Mode) {
+	case "":
+		if len(c.Files) == 0 {
+			return tls.NoClientCert, nil
+		}
+		if c.Optional {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerify:
+		if len(c.Files) == 0 {
+			return tls.NoClientCert, errors.New("no CA files to verify the client certificates")
+		}
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		if len(c.Files) == 0 {
+			return tls.NoClientCert, errors.New("no CA files to verify the client certificates")
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client authentication mode %q", c.Mode)
+	}
 }
 
 // TLS configures TLS for an entry point
 type TLS struct {
-	MinVersion    string `export:"true"`
-	CipherSuites  []string
-	Certificates  Certificates
-	ClientCAFiles []string // Deprecated
-	ClientCA      ClientCA
+	Policy                string `export:"true"`
+	MinVersion            string `export:"true"`
+	MaxVersion            string `export:"true"`
+	CipherSuites          []string
+	CurvePreferences      []string
+	Certificates          Certificates
+	DefaultCertificate    string   `export:"true"`
+	StrictSNI             bool     `export:"true"`
+	CertificatesDirectory string   `export:"true"`
+	ClientCAFiles         []string // Deprecated
+	ClientCA              ClientCA
 }
 
 // RootCAs hold the CA we want to have in root
@@ -0,0 +1,72 @@
+package tls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCAClientAuthType(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		clientCA      ClientCA
+		expected      tls.ClientAuthType
+		expectedError bool
+	}{
+		{
+			desc:     "no client CA",
+			expected: tls.NoClientCert,
+		},
+		{
+			desc:     "mandatory CA files",
+			clientCA: ClientCA{Files: []string{"ca.crt"}},
+			expected: tls.RequireAndVerifyClientCert,
+		},
+		{
+			desc:     "optional CA files",
+			clientCA: ClientCA{Files: []string{"ca.crt"}, Optional: true},
+			expected: tls.VerifyClientCertIfGiven,
+		},
+		{
+			desc:     "request mode",
+			clientCA: ClientCA{Mode: "request"},
+			expected: tls.RequestClientCert,
+		},
+		{
+			desc:     "verify mode",
+			clientCA: ClientCA{Files: []string{"ca.crt"}, Mode: "verify"},
+			expected: tls.VerifyClientCertIfGiven,
+		},
+		{
+			desc:     "require mode overriding optional",
+			clientCA: ClientCA{Files: []string{"ca.crt"}, Optional: true, Mode: "Require"},
+			expected: tls.RequireAndVerifyClientCert,
+		},
+		{
+			desc:          "verify mode without CA files",
+			clientCA:      ClientCA{Mode: "verify"},
+			expectedError: true,
+		},
+		{
+			desc:          "unknown mode",
+			clientCA:      ClientCA{Files: []string{"ca.crt"}, Mode: "always"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clientAuth, err := test.clientCA.ClientAuthType()
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, clientAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// handshakeTimeout is the maximum duration of the handshake test of a certificate
+const handshakeTimeout = 5 * time.Second
+
+// ValidateCertificate checks that a certificate can be served before swapping it in:
+// the certificate must not be used before its validity period, its chain must be ordered,
+// and a TLS handshake using the certificate and its private key must succeed.
+// A chain which isn't trusted by the system roots, e.g. of a private CA, is only logged.
+func ValidateCertificate(cert *tls.Certificate) error {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return errors.New("no certificate in the chain")
+	}
+
+	chain := make([]*x509.Certificate, len(cert.Certificate))
+	for i, der := range cert.Certificate {
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("unable to parse the certificate %d of the chain: %v", i, err)
+		}
+		chain[i] = parsed
+	}
+
+	now := time.Now()
+	leaf := chain[0]
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate %q is not valid before %s", leaf.Subject.CommonName, leaf.NotBefore)
+	}
+	if now.After(leaf.NotAfter) {
+		log.Warnf("Certificate %q has expired on %s", leaf.Subject.CommonName, leaf.NotAfter)
+	}
+
+	if err := checkChain(chain); err != nil {
+		return err
+	}
+	if err := checkTrust(chain); err != nil {
+		log.Warnf("The chain of the certificate %q may be incomplete: %v", leaf.Subject.CommonName, err)
+	}
+
+	return handshake(cert, leaf)
+}
+
+// checkChain checks that each certificate of the chain is issued by the next one.
+func checkChain(chain []*x509.Certificate) error {
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return fmt.Errorf("certificate %q is not issued by the next certificate of the chain %q: %v",
+				chain[i].Subject.CommonName, chain[i+1].Subject.CommonName, err)
+		}
+	}
+	return nil
+}
+
+// checkTrust checks that the last certificate of the chain is either self-signed or issued by a root of the system.
+func checkTrust(chain []*x509.Certificate) error {
+	last := chain[len(chain)-1]
+	if isSelfSigned(last) {
+		return nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		// the chain cannot be checked without the system roots
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if _, ok := err.(x509.UnknownAuthorityError); ok {
+		return fmt.Errorf("issuer %q not found in the system roots", last.Issuer.CommonName)
+	}
+	return nil
+}
+
+// isSelfSigned checks that the certificate is signed by its own key,
+// regardless of its CA constraints.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// handshake performs an in-memory TLS handshake serving the certificate,
+// which fails if the private key does not match the certificate.
+func handshake(cert *tls.Certificate, leaf *x509.Certificate) error {
+	serverName := leaf.Subject.CommonName
+	if len(leaf.DNSNames) > 0 {
+		serverName = leaf.DNSNames[0]
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	deadline := time.Now().Add(handshakeTimeout)
+	serverConn.SetDeadline(deadline)
+	clientConn.SetDeadline(deadline)
+
+	server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{*cert}})
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Handshake()
+	}()
+
+	client := tls.Client(clientConn, &tls.Config{
+		ServerName: serverName,
+		// the chain is checked by checkChain, the handshake tests the private key
+		InsecureSkipVerify: true,
+	})
+	if err := client.Handshake(); err != nil {
+		return fmt.Errorf("TLS handshake failed for the certificate %q: %v", leaf.Subject.CommonName, err)
+	}
+	if err := <-serverErr; err != nil {
+		return fmt.Errorf("TLS handshake failed for the certificate %q: %v", leaf.Subject.CommonName, err)
+	}
+	return nil
+}
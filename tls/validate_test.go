@@ -0,0 +1,128 @@
+package tls
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCertificate(t *testing.T) {
+	now := time.Now()
+
+	caKey, caCert := createCertificate(t, "CA", now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	leafKey, leafCert := createCertificate(t, "foo.com", now.Add(-time.Hour), now.Add(time.Hour), caCert, caKey)
+	otherKey, _ := createCertificate(t, "bar.com", now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	selfSignedKey, selfSignedCert := createCertificate(t, "foo.com", now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	expiredKey, expiredCert := createCertificate(t, "foo.com", now.Add(-2*time.Hour), now.Add(-time.Hour), nil, nil)
+	notYetValidKey, notYetValidCert := createCertificate(t, "foo.com", now.Add(time.Hour), now.Add(2*time.Hour), nil, nil)
+
+	testCases := []struct {
+		desc          string
+		cert          *tls.Certificate
+		expectedError bool
+	}{
+		{
+			desc: "self-signed certificate",
+			cert: &tls.Certificate{Certificate: [][]byte{selfSignedCert.Raw}, PrivateKey: selfSignedKey},
+		},
+		{
+			desc: "complete chain",
+			cert: &tls.Certificate{Certificate: [][]byte{leafCert.Raw, caCert.Raw}, PrivateKey: leafKey},
+		},
+		{
+			desc: "chain of a private CA",
+			cert: &tls.Certificate{Certificate: [][]byte{leafCert.Raw}, PrivateKey: leafKey},
+		},
+		{
+			desc:          "chain out of order",
+			cert:          &tls.Certificate{Certificate: [][]byte{caCert.Raw, leafCert.Raw}, PrivateKey: leafKey},
+			expectedError: true,
+		},
+		{
+			desc:          "private key mismatch",
+			cert:          &tls.Certificate{Certificate: [][]byte{selfSignedCert.Raw}, PrivateKey: otherKey},
+			expectedError: true,
+		},
+		{
+			desc: "expired certificate",
+			cert: &tls.Certificate{Certificate: [][]byte{expiredCert.Raw}, PrivateKey: expiredKey},
+		},
+		{
+			desc:          "not yet valid certificate",
+			cert:          &tls.Certificate{Certificate: [][]byte{notYetValidCert.Raw}, PrivateKey: notYetValidKey},
+			expectedError: true,
+		},
+		{
+			desc:          "empty certificate",
+			cert:          &tls.Certificate{},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateCertificate(test.cert)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckTrust(t *testing.T) {
+	now := time.Now()
+
+	caKey, caCert := createCertificate(t, "CA", now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	_, leafCert := createCertificate(t, "foo.com", now.Add(-time.Hour), now.Add(time.Hour), caCert, caKey)
+
+	assert.NoError(t, checkTrust([]*x509.Certificate{leafCert, caCert}))
+	assert.Error(t, checkTrust([]*x509.Certificate{leafCert}))
+}
+
+// createCertificate creates a certificate signed by the parent, or self-signed if the parent is nil.
+func createCertificate(t *testing.T, domain string, notBefore, notAfter time.Time, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: domain},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		DNSNames:              []string{domain},
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return key, cert
+}
@@ -0,0 +1,66 @@
+package types
+
+// The names of the middlewares of a frontend
+const (
+	MiddlewareErrorPages    = "errorPages"
+	MiddlewareMetrics       = "metrics"
+	MiddlewareLimits        = "limits"
+	MiddlewareCacheStatus   = "cacheStatus"
+	MiddlewareCORS          = "cors"
+	MiddlewareClientCA      = "clientCA"
+	MiddlewareOIDC          = "oidc"
+	MiddlewareIPWhitelist   = "ipWhitelist"
+	MiddlewareSignedURL     = "signedURL"
+	MiddlewareRedirect      = "redirect"
+	MiddlewareRedirectRules = "redirectRules"
+	MiddlewareRedirectMap   = "redirectMap"
+	MiddlewareBasicAuth     = "basicAuth"
+	MiddlewareScript        = "script"
+	MiddlewareBodyRewrite   = "bodyRewrite"
+	MiddlewareHeaders       = "headers"
+	MiddlewareSecureHeaders = "secureHeaders"
+	MiddlewareCache         = "cache"
+	MiddlewareMirror        = "mirror"
+	MiddlewareRateLimit     = "rateLimit"
+)
+
+// MiddlewareMaintenance runs before the other middlewares of the frontend, wherever it's listed in their order
+const MiddlewareMaintenance = "maintenance"
+
+// FrontendMiddlewares lists the middlewares of a frontend following the maintenance, in their default order.
+var FrontendMiddlewares = []string{
+	MiddlewareErrorPages,
+	MiddlewareMetrics,
+	MiddlewareLimits,
+	MiddlewareCacheStatus,
+	MiddlewareCORS,
+	MiddlewareClientCA,
+	MiddlewareOIDC,
+	MiddlewareIPWhitelist,
+	MiddlewareSignedURL,
+	MiddlewareRedirect,
+	MiddlewareRedirectRules,
+	MiddlewareRedirectMap,
+	MiddlewareBasicAuth,
+	MiddlewareScript,
+	MiddlewareBodyRewrite,
+	MiddlewareHeaders,
+	MiddlewareSecureHeaders,
+	MiddlewareCache,
+	MiddlewareMirror,
+	MiddlewareRateLimit,
+}
+
+// OrderMiddlewares returns the middlewares in the order they run: first the ones of the order,
+// then the other ones in their default order, the maintenance always running first.
+func OrderMiddlewares(order []string) []string {
+	names := []string{MiddlewareMaintenance}
+	seen := map[string]bool{MiddlewareMaintenance: true}
+	for _, name := range append(append([]string{}, order...), FrontendMiddlewares...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
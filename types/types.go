@@ -20,11 +20,17 @@ import (
 
 // Backend holds backend configuration.
 type Backend struct {
-	Servers        map[string]Server `json:"servers,omitempty"`
-	CircuitBreaker *CircuitBreaker   `json:"circuitBreaker,omitempty"`
-	LoadBalancer   *LoadBalancer     `json:"loadBalancer,omitempty"`
-	MaxConn        *MaxConn          `json:"maxConn,omitempty"`
-	HealthCheck    *HealthCheck      `json:"healthCheck,omitempty"`
+	Servers          map[string]Server `json:"servers,omitempty"`
+	CircuitBreaker   *CircuitBreaker   `json:"circuitBreaker,omitempty"`
+	LoadBalancer     *LoadBalancer     `json:"loadBalancer,omitempty"`
+	MaxConn          *MaxConn          `json:"maxConn,omitempty"`
+	Queue            *Queue            `json:"queue,omitempty"`
+	HealthCheck      *HealthCheck      `json:"healthCheck,omitempty"`
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty"`
+	TLS              *BackendTLS       `json:"tls,omitempty"`
+	Transport        *BackendTransport `json:"transport,omitempty"`
+	ResponseTimeout  *ResponseTimeout  `json:"responseTimeout,omitempty"`
+	Failover         *Failover         `json:"failover,omitempty"`
 }
 
 // MaxConn holds maximum connection configuration
@@ -33,34 +39,134 @@ type MaxConn struct {
 	ExtractorFunc string `json:"extractorFunc,omitempty"`
 }
 
+// Queue holds the request queue configuration of a backend or a frontend
+type Queue struct {
+	MaxConcurrent int64  `json:"maxConcurrent,omitempty"`
+	MaxSize       int64  `json:"maxSize,omitempty"`
+	Timeout       string `json:"timeout,omitempty"`
+	PerServer     bool   `json:"perServer,omitempty"`
+	StatusCode    int    `json:"statusCode,omitempty"`
+}
+
+// ResponseTimeout holds the time given to a backend or a frontend to answer a request,
+// retries included, and the header propagating the remaining time to the servers.
+// The settings of a frontend override the ones of its backend.
+type ResponseTimeout struct {
+	Timeout flaeg.Duration `json:"timeout,omitempty"`
+	// DeadlineHeader is the request header set to the milliseconds remaining before the timeout
+	DeadlineHeader string `json:"deadlineHeader,omitempty"`
+}
+
+// Failover holds the backend receiving the requests of a backend while too few of its servers are healthy
+type Failover struct {
+	Backend string `json:"backend,omitempty"`
+	// MinHealthyPercent is the percentage of healthy servers below which the requests fail over,
+	// 0 failing over only when no server is healthy
+	MinHealthyPercent int `json:"minHealthyPercent,omitempty"`
+}
+
 // LoadBalancer holds load balancing configuration.
 type LoadBalancer struct {
 	Method     string      `json:"method,omitempty"`
 	Sticky     bool        `json:"sticky,omitempty"` // Deprecated: use Stickiness instead
 	Stickiness *Stickiness `json:"stickiness,omitempty"`
+	// ConsistentHash configures the consistenthash method.
+	ConsistentHash *ConsistentHash `json:"consistentHash,omitempty"`
+	// SlowStart is the duration of the ramp-up of the weight of the new servers.
+	SlowStart flaeg.Duration `json:"slowStart,omitempty"`
+}
+
+// ConsistentHash holds the configuration of the consistent hashing of the requests to the servers.
+type ConsistentHash struct {
+	// Key is built like the key of the rate limiting (default: client.ip)
+	Key string `json:"key,omitempty"`
+	// Replicas is the number of points of a server on the ring, by unit of weight
+	Replicas int `json:"replicas,omitempty"`
+	// LoadFactor bounds the requests in flight of a server to this factor of its share of all the requests in flight
+	LoadFactor float64 `json:"loadFactor,omitempty"`
 }
 
 // Stickiness holds sticky session configuration.
 type Stickiness struct {
 	CookieName string `json:"cookieName,omitempty"`
+	// Header keys the stickiness on a request header, instead of a cookie
+	Header string `json:"header,omitempty"`
+	// Query keys the stickiness on a query parameter, instead of a cookie
+	Query string `json:"query,omitempty"`
+}
+
+// KeyedAffinity returns whether the stickiness is keyed on a request header or a query parameter, instead of a cookie.
+func (s *Stickiness) KeyedAffinity() bool {
+	return len(s.Header) > 0 || len(s.Query) > 0
 }
 
 // CircuitBreaker holds circuit breaker configuration.
 type CircuitBreaker struct {
-	Expression string `json:"expression,omitempty"`
+	Expression       string                  `json:"expression,omitempty"`
+	OpenDuration     flaeg.Duration          `json:"openDuration,omitempty"`
+	HalfOpenRequests int                     `json:"halfOpenRequests,omitempty"`
+	PerServer        bool                    `json:"perServer,omitempty"`
+	Fallback         *CircuitBreakerFallback `json:"fallback,omitempty"`
+}
+
+// CircuitBreakerFallback holds the response of an open circuit breaker
+type CircuitBreakerFallback struct {
+	StatusCode  int    `json:"statusCode,omitempty"`
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
 }
 
 // HealthCheck holds HealthCheck configuration
 type HealthCheck struct {
-	Path     string `json:"path,omitempty"`
-	Port     int    `json:"port,omitempty"`
-	Interval string `json:"interval,omitempty"`
+	Path               string            `json:"path,omitempty"`
+	Port               int               `json:"port,omitempty"`
+	Interval           string            `json:"interval,omitempty"`
+	Timeout            string            `json:"timeout,omitempty"`
+	Method             string            `json:"method,omitempty"`
+	Headers            map[string]string `json:"headers,omitempty"`
+	Status             []string          `json:"status,omitempty"`
+	Body               string            `json:"body,omitempty"`
+	InsecureSkipVerify bool              `json:"insecureSkipVerify,omitempty"`
+	ServerName         string            `json:"serverName,omitempty"`
+	Protocol           string            `json:"protocol,omitempty"`
+	GRPCService        string            `json:"grpcService,omitempty"`
+}
+
+// OutlierDetection holds the passive health checking of the servers of a backend,
+// ejecting from the load balancer the servers failing the requests.
+type OutlierDetection struct {
+	ConsecutiveErrors  int            `json:"consecutiveErrors,omitempty"`
+	Window             flaeg.Duration `json:"window,omitempty"`
+	BaseEjectionTime   flaeg.Duration `json:"baseEjectionTime,omitempty"`
+	MaxEjectionTime    flaeg.Duration `json:"maxEjectionTime,omitempty"`
+	MaxEjectionPercent int            `json:"maxEjectionPercent,omitempty"`
+}
+
+// BackendTLS holds the TLS configuration used to reach the servers of a backend:
+// the client certificate presented to them and the CA verifying their certificates.
+// The certificate, key and CA are either file paths or PEM contents.
+type BackendTLS struct {
+	Cert               string `json:"cert,omitempty" description:"Client certificate presented to the servers"`
+	Key                string `json:"key,omitempty" description:"Key of the client certificate"`
+	CA                 string `json:"ca,omitempty" description:"CA verifying the certificates of the servers, instead of the root CAs"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" description:"Do not verify the certificates of the servers"`
+}
+
+// BackendTransport holds the settings of the connections to the servers of a backend,
+// overriding the ones of the transport shared by the backends.
+type BackendTransport struct {
+	MaxIdleConnsPerHost   int            `json:"maxIdleConnsPerHost,omitempty" description:"Maximum idle connections to keep per server"`
+	IdleConnTimeout       flaeg.Duration `json:"idleConnTimeout,omitempty" description:"Duration an idle connection is kept before being closed"`
+	TLSHandshakeTimeout   flaeg.Duration `json:"tlsHandshakeTimeout,omitempty" description:"Duration to wait for the TLS handshake with a server"`
+	ResponseHeaderTimeout flaeg.Duration `json:"responseHeaderTimeout,omitempty" description:"Duration to wait for the response headers of a server"`
+	DisableKeepAlives     bool           `json:"disableKeepAlives,omitempty" description:"Use a new connection for each request"`
 }
 
 // Server holds server configuration.
 type Server struct {
-	URL    string `json:"url,omitempty"`
-	Weight int    `json:"weight"`
+	URL      string            `json:"url,omitempty"`
+	Weight   int               `json:"weight"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // Route holds route configuration.
@@ -70,9 +176,18 @@ type Route struct {
 
 //ErrorPage holds custom error page configuration
 type ErrorPage struct {
-	Status  []string `json:"status,omitempty"`
-	Backend string   `json:"backend,omitempty"`
-	Query   string   `json:"query,omitempty"`
+	Status   []string `json:"status,omitempty"`
+	Backend  string   `json:"backend,omitempty"`
+	Query    string   `json:"query,omitempty"`
+	File     string   `json:"file,omitempty"`
+	Body     string   `json:"body,omitempty"`
+	JSONFile string   `json:"jsonFile,omitempty"`
+	JSONBody string   `json:"jsonBody,omitempty"`
+}
+
+// HasPage returns true if the error page is rendered by Traefik, instead of being fetched from a backend
+func (e *ErrorPage) HasPage() bool {
+	return len(e.File) > 0 || len(e.Body) > 0 || len(e.JSONFile) > 0 || len(e.JSONBody) > 0
 }
 
 // Rate holds a rate limiting configuration for a specific time period
@@ -149,11 +264,168 @@ type Frontend struct {
 	PassTLSCert          bool                 `json:"passTLSCert,omitempty"`
 	Priority             int                  `json:"priority"`
 	BasicAuth            []string             `json:"basicAuth"`
+	BasicAuthUsersFile   string               `json:"basicAuthUsersFile,omitempty"`
+	BasicAuthHeaderField string               `json:"basicAuthHeaderField,omitempty"`
 	WhitelistSourceRange []string             `json:"whitelistSourceRange,omitempty"`
 	Headers              Headers              `json:"headers,omitempty"`
 	Errors               map[string]ErrorPage `json:"errors,omitempty"`
 	RateLimit            *RateLimit           `json:"ratelimit,omitempty"`
 	Redirect             string               `json:"redirect,omitempty"`
+	RedirectMap          *RedirectMap         `json:"redirectMap,omitempty"`
+	ClientCA             *ClientCA            `json:"clientCA,omitempty"`
+	ACMEResolver         string               `json:"acmeResolver,omitempty"`
+	OIDC                 *OIDC                `json:"oidc,omitempty"`
+	Queue                *Queue               `json:"queue,omitempty"`
+	BodyRewrite          *BodyRewrite         `json:"bodyRewrite,omitempty"`
+	CORS                 *CORS                `json:"cors,omitempty"`
+	RedirectRules        []RedirectRule       `json:"redirectRules,omitempty"`
+	Maintenance          *Maintenance         `json:"maintenance,omitempty"`
+	IPWhitelist          *IPWhitelist         `json:"ipWhitelist,omitempty"`
+	Mirror               *Mirror              `json:"mirror,omitempty"`
+	Script               *Script              `json:"script,omitempty"`
+	Limits               *Limits              `json:"limits,omitempty"`
+	SignedURL            *SignedURL           `json:"signedURL,omitempty"`
+	Cache                *Cache               `json:"cache,omitempty"`
+	AccessLogSampling    *AccessLogSampling   `json:"accessLogSampling,omitempty"`
+	ResponseTimeout      *ResponseTimeout     `json:"responseTimeout,omitempty"`
+	TrafficSplit         map[string]int       `json:"trafficSplit,omitempty"`
+	Middlewares          []string             `json:"middlewares,omitempty"`
+}
+
+// ClientCA holds the CAs verifying the client certificates of a frontend
+type ClientCA struct {
+	Files []traefikTls.FileOrContent `json:"files,omitempty"`
+	Mode  string                     `json:"mode,omitempty"`
+}
+
+// OIDC holds the OpenID Connect authentication of a frontend
+type OIDC struct {
+	Issuer         string            `json:"issuer,omitempty"`
+	ClientID       string            `json:"clientID,omitempty"`
+	ClientSecret   string            `json:"clientSecret,omitempty"`
+	Scopes         []string          `json:"scopes,omitempty"`
+	CallbackPath   string            `json:"callbackPath,omitempty"`
+	RedirectURL    string            `json:"redirectURL,omitempty"`
+	LogoutPath     string            `json:"logoutPath,omitempty"`
+	SessionSecret  string            `json:"sessionSecret,omitempty"`
+	SessionCookie  string            `json:"sessionCookie,omitempty"`
+	GroupsClaim    string            `json:"groupsClaim,omitempty"`
+	AllowedGroups  []string          `json:"allowedGroups,omitempty"`
+	RequiredClaims map[string]string `json:"requiredClaims,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+}
+
+// BodyRewrite holds the rewriting of the request and response bodies of a frontend
+type BodyRewrite struct {
+	Rules   []BodyRewriteRule `json:"rules,omitempty"`
+	MaxSize int64             `json:"maxSize,omitempty"`
+}
+
+// BodyRewriteRule holds a regex replacement of the bodies with the given content types
+type BodyRewriteRule struct {
+	Regex        string   `json:"regex,omitempty"`
+	Replacement  string   `json:"replacement,omitempty"`
+	ContentTypes []string `json:"contentTypes,omitempty"`
+	Request      bool     `json:"request,omitempty"`
+}
+
+// CORS holds the Cross-Origin Resource Sharing policy of a frontend
+type CORS struct {
+	AllowedOrigins      []string `json:"allowedOrigins,omitempty"`
+	AllowedOriginsRegex []string `json:"allowedOriginsRegex,omitempty"`
+	AllowedMethods      []string `json:"allowedMethods,omitempty"`
+	AllowedHeaders      []string `json:"allowedHeaders,omitempty"`
+	ExposedHeaders      []string `json:"exposedHeaders,omitempty"`
+	AllowCredentials    bool     `json:"allowCredentials,omitempty"`
+	MaxAge              int      `json:"maxAge,omitempty"`
+}
+
+// RedirectRule holds a regex redirection of a frontend, or a rewriting of the URL forwarded to the backend
+type RedirectRule struct {
+	Regex       string `json:"regex,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	Permanent   bool   `json:"permanent,omitempty"`
+	Rewrite     bool   `json:"rewrite,omitempty"`
+}
+
+// IPWhitelist holds the source ranges allowed to reach a frontend, static or loaded from files and URLs,
+// and the strategy extracting the client IP
+type IPWhitelist struct {
+	SourceRange     []string       `json:"sourceRange,omitempty"`
+	Files           []string       `json:"files,omitempty"`
+	URLs            []string       `json:"urls,omitempty"`
+	RefreshInterval flaeg.Duration `json:"refreshInterval,omitempty"`
+	Depth           int            `json:"depth,omitempty"`
+	TrustedProxies  []string       `json:"trustedProxies,omitempty"`
+}
+
+// Maintenance holds the maintenance mode of a frontend, answering the requests with a static response
+type Maintenance struct {
+	Enabled           bool     `json:"enabled,omitempty"`
+	StatusCode        int      `json:"statusCode,omitempty"`
+	Body              string   `json:"body,omitempty"`
+	File              string   `json:"file,omitempty"`
+	ContentType       string   `json:"contentType,omitempty"`
+	BypassHeader      string   `json:"bypassHeader,omitempty"`
+	BypassValue       string   `json:"bypassValue,omitempty"`
+	BypassSourceRange []string `json:"bypassSourceRange,omitempty"`
+}
+
+// Mirror holds the mirroring of the requests of a frontend to a secondary backend, whose responses are ignored
+type Mirror struct {
+	Backend string `json:"backend,omitempty"`
+	// Percent is the percentage of the requests mirrored, 100 when omitted, 0 disabling the mirroring
+	Percent     *int  `json:"percent,omitempty"`
+	MaxBodySize int64 `json:"maxBodySize,omitempty"`
+}
+
+// Script holds a Lua script handling the requests and responses of a frontend
+type Script struct {
+	File    string         `json:"file,omitempty"`
+	Timeout flaeg.Duration `json:"timeout,omitempty"`
+}
+
+// Cache holds the in-memory cache of the responses of a frontend
+type Cache struct {
+	MaxSize      int64          `json:"maxSize,omitempty"`
+	MaxEntrySize int64          `json:"maxEntrySize,omitempty"`
+	TTL          flaeg.Duration `json:"ttl,omitempty"`
+	DefaultTTL   flaeg.Duration `json:"defaultTTL,omitempty"`
+	Key          string         `json:"key,omitempty"`
+	Directory    string         `json:"directory,omitempty"`
+}
+
+// AccessLogSampling holds the sampling of the access log entries of a frontend, either a rate or one in N requests.
+// The errors (status >= 500) and the requests slower than the threshold are always logged.
+type AccessLogSampling struct {
+	Rate          float64        `json:"rate,omitempty"`
+	OneIn         int            `json:"oneIn,omitempty"`
+	SlowThreshold flaeg.Duration `json:"slowThreshold,omitempty"`
+}
+
+// SignedURL holds the validation of the HMAC signatures and expiry timestamps of the requests of a frontend
+type SignedURL struct {
+	Secrets         []string `json:"secrets,omitempty"`
+	Algorithm       string   `json:"algorithm,omitempty"`
+	Encoding        string   `json:"encoding,omitempty"`
+	SignatureParam  string   `json:"signatureParam,omitempty"`
+	ExpiresParam    string   `json:"expiresParam,omitempty"`
+	SignatureHeader string   `json:"signatureHeader,omitempty"`
+	ExpiresHeader   string   `json:"expiresHeader,omitempty"`
+}
+
+// Limits holds the maximum sizes of the requests of a frontend, the larger requests being rejected
+type Limits struct {
+	MaxBodySize    int64 `json:"maxBodySize,omitempty"`
+	MaxHeaderCount int   `json:"maxHeaderCount,omitempty"`
+	MaxHeaderSize  int   `json:"maxHeaderSize,omitempty"`
+	MaxURLLength   int   `json:"maxURLLength,omitempty"`
+}
+
+// RedirectMap holds the configuration of redirections loaded from a CSV/TSV file
+type RedirectMap struct {
+	File       string `json:"file,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
 }
 
 // LoadBalancerMethod holds the method of load balancing to use.
@@ -164,11 +436,23 @@ const (
 	Wrr LoadBalancerMethod = iota
 	// Drr = Dynamic Round Robin
 	Drr
+	// LeastConn = the server with the least requests in flight
+	LeastConn
+	// EWMA = the server with the lowest moving average of the latency, weighted by its requests in flight
+	EWMA
+	// PeakEWMA = EWMA, taking the latency spikes into account at once
+	PeakEWMA
+	// ConsistentHashing = the server of the hash of a key of the request on a ring
+	ConsistentHashing
 )
 
 var loadBalancerMethodNames = []string{
 	"Wrr",
 	"Drr",
+	"LeastConn",
+	"EWMA",
+	"PeakEWMA",
+	"ConsistentHash",
 }
 
 // NewLoadBalancerMethod create a new LoadBalancerMethod from a given LoadBalancer.
@@ -201,6 +485,33 @@ type ConfigMessage struct {
 	Configuration *Configuration
 }
 
+// ShadowConfigurations is for shadowConfigurations Map
+type ShadowConfigurations map[string]*ShadowConfiguration
+
+// ShadowConfiguration is the configuration of a shadow provider, which is not applied,
+// and its differences with the active configuration.
+type ShadowConfiguration struct {
+	Configuration *Configuration    `json:"configuration"`
+	Diff          ConfigurationDiff `json:"diff"`
+}
+
+// ConfigurationDiff holds the names of the frontends and backends which differ between
+// the active configuration and the configuration of a shadow provider.
+type ConfigurationDiff struct {
+	MissingFrontends []string `json:"missingFrontends,omitempty"`
+	ExtraFrontends   []string `json:"extraFrontends,omitempty"`
+	ChangedFrontends []string `json:"changedFrontends,omitempty"`
+	MissingBackends  []string `json:"missingBackends,omitempty"`
+	ExtraBackends    []string `json:"extraBackends,omitempty"`
+	ChangedBackends  []string `json:"changedBackends,omitempty"`
+}
+
+// Count returns the number of frontends and backends which differ.
+func (d ConfigurationDiff) Count() int {
+	return len(d.MissingFrontends) + len(d.ExtraFrontends) + len(d.ChangedFrontends) +
+		len(d.MissingBackends) + len(d.ExtraBackends) + len(d.ChangedBackends)
+}
+
 // Constraint hold a parsed constraint expression
 type Constraint struct {
 	Key string `export:"true"`
@@ -331,9 +642,20 @@ type Auth struct {
 	Basic       *Basic   `export:"true"`
 	Digest      *Digest  `export:"true"`
 	Forward     *Forward `export:"true"`
+	Authz       *Authz   `export:"true"`
 	HeaderField string   `export:"true"`
 }
 
+// APIAuth holds the authentication of the users of the API and of the dashboard, and their roles:
+// the admins use all the routes, the other users only read
+type APIAuth struct {
+	Basic             *Basic   `description:"Authenticate the users with the basic authentication" export:"true"`
+	Tokens            []string `description:"Bearer tokens, as name:token"`
+	TokensFile        string   `description:"File of the bearer tokens, one name:token per line"`
+	ClientCertificate bool     `description:"Authenticate the users with the common name of their client certificate, verified by the entry point" export:"true"`
+	Admins            []string `description:"Users, token names or certificate common names with the admin role, the others being read-only" export:"true"`
+}
+
 // Users authentication users
 type Users []string
 
@@ -351,9 +673,45 @@ type Digest struct {
 
 // Forward authentication
 type Forward struct {
-	Address            string     `description:"Authentication server address"`
-	TLS                *ClientTLS `description:"Enable TLS support" export:"true"`
-	TrustForwardHeader bool       `description:"Trust X-Forwarded-* headers" export:"true"`
+	Address            string                 `description:"Authentication server address"`
+	TLS                *ClientTLS             `description:"Enable TLS support" export:"true"`
+	TrustForwardHeader bool                   `description:"Trust X-Forwarded-* headers" export:"true"`
+	Cache              *ForwardCache          `description:"Cache the responses of the authentication server" export:"true"`
+	FailurePolicy      string                 `description:"Policy when the authentication server fails: closed (deny the requests) or open (allow the requests)" export:"true"`
+	CircuitBreaker     *ForwardCircuitBreaker `description:"Stop calling the authentication server after consecutive failures" export:"true"`
+}
+
+// ForwardCache holds the caching of the forward authentication responses, per credentials
+type ForwardCache struct {
+	TTL        flaeg.Duration `description:"Duration the responses are cached" export:"true"`
+	Headers    []string       `description:"Request headers holding the credentials" export:"true"`
+	Cookies    []string       `description:"Request cookies holding the credentials" export:"true"`
+	MaxEntries int            `description:"Maximum number of cached responses" export:"true"`
+}
+
+// ForwardCircuitBreaker holds the circuit breaking toward the forward authentication server
+type ForwardCircuitBreaker struct {
+	Failures     int            `description:"Consecutive failures opening the circuit" export:"true"`
+	OpenDuration flaeg.Duration `description:"Duration the circuit stays open before the authentication server is called again" export:"true"`
+}
+
+// Authz holds the external authorization: the requests are described to a policy server, which allows or denies them
+type Authz struct {
+	Address        string         `description:"Policy server address"`
+	TLS            *ClientTLS     `description:"Enable TLS support" export:"true"`
+	Timeout        flaeg.Duration `description:"Timeout of the calls to the policy server" export:"true"`
+	IncludeHeaders []string       `description:"Request headers sent to the policy server (all by default)" export:"true"`
+	FailurePolicy  string         `description:"Policy when the policy server fails: closed (deny the requests) or open (allow the requests)" export:"true"`
+}
+
+// Compression holds the compression options of an entry point
+type Compression struct {
+	Algorithms           []string `description:"Compression algorithms by order of preference: br, gzip" export:"true"`
+	GzipLevel            int      `description:"Gzip compression level, from 1 (fastest) to 9 (best)" export:"true"`
+	BrotliQuality        int      `description:"Brotli quality, from 1 (fastest) to 11 (best)" export:"true"`
+	MinSize              int      `description:"Minimum size of the compressed responses, in bytes" export:"true"`
+	ContentTypes         []string `description:"Content types of the compressed responses, all when empty" export:"true"`
+	ExcludedContentTypes []string `description:"Content types of the responses never compressed" export:"true"`
 }
 
 // CanonicalDomain returns a lower case domain with trim space
@@ -363,21 +721,30 @@ func CanonicalDomain(domain string) string {
 
 // Statistics provides options for monitoring request and response stats
 type Statistics struct {
-	RecentErrors int `description:"Number of recent errors logged" export:"true"`
+	RecentErrors         int            `description:"Number of recent errors logged" export:"true"`
+	BackendWindow        flaeg.Duration `description:"Rolling window of the latency and error statistics of the backend servers" export:"true"`
+	BackendRetention     flaeg.Duration `description:"Duration the statistics of the servers removed from the configuration are kept" export:"true"`
+	FrontendUnusedWindow flaeg.Duration `description:"Duration without request after which a frontend is reported as unused" export:"true"`
 }
 
 // Metrics provides options to expose and send Traefik metrics to different third party monitoring systems
 type Metrics struct {
-	Prometheus *Prometheus `description:"Prometheus metrics exporter type" export:"true"`
-	Datadog    *Datadog    `description:"DataDog metrics exporter type" export:"true"`
-	StatsD     *Statsd     `description:"StatsD metrics exporter type" export:"true"`
-	InfluxDB   *InfluxDB   `description:"InfluxDB metrics exporter type"`
+	Prometheus  *Prometheus `description:"Prometheus metrics exporter type" export:"true"`
+	Datadog     *Datadog    `description:"DataDog metrics exporter type" export:"true"`
+	StatsD      *Statsd     `description:"StatsD metrics exporter type" export:"true"`
+	InfluxDB    *InfluxDB   `description:"InfluxDB metrics exporter type"`
+	InfluxDB2   *InfluxDB2  `description:"InfluxDB 2.x metrics exporter type"`
+	Granularity string      `description:"Labels of the request and retry metrics: service, entrypoint, frontend or backend" export:"true"`
 }
 
 // Prometheus can contain specific configuration used by the Prometheus Metrics exporter
 type Prometheus struct {
-	Buckets    Buckets `description:"Buckets for latency metrics" export:"true"`
-	EntryPoint string  `description:"EntryPoint" export:"true"`
+	Buckets       Buckets       `description:"Buckets for latency metrics" export:"true"`
+	SizeBuckets   Buckets       `description:"Buckets for request and response size metrics, in bytes" export:"true"`
+	EntryPoint    string        `description:"EntryPoint" export:"true"`
+	FrontendLabel bool          `description:"Label the request metrics with the frontend" export:"true"`
+	BackendLabel  bool          `description:"Label the request metrics with the backend" export:"true"`
+	DropLabels    MetricsLabels `description:"Labels dropped from the request metrics: service, entrypoint, frontend, backend, code or method" export:"true"`
 }
 
 // Datadog contains address and metrics pushing interval configuration
@@ -388,14 +755,44 @@ type Datadog struct {
 
 // Statsd contains address and metrics pushing interval configuration
 type Statsd struct {
-	Address      string `description:"StatsD address"`
-	PushInterval string `description:"StatsD push interval" export:"true"`
+	Address       string        `description:"StatsD address"`
+	PushInterval  string        `description:"StatsD push interval" export:"true"`
+	DogStatsD     bool          `description:"Send the metrics with DogStatsD tags" export:"true"`
+	Tags          MetricsLabels `description:"DogStatsD tags added to all the metrics, like env:production" export:"true"`
+	FrontendLabel bool          `description:"Tag the request metrics with the frontend" export:"true"`
+	BackendLabel  bool          `description:"Tag the request metrics with the backend" export:"true"`
+	DropLabels    MetricsLabels `description:"Labels not tagging the request metrics: service, entrypoint, frontend, backend, code or method" export:"true"`
 }
 
-// InfluxDB contains address and metrics pushing interval configuration
+// InfluxDB contains address, login and metrics pushing interval configuration
 type InfluxDB struct {
-	Address      string `description:"InfluxDB address"`
-	PushInterval string `description:"InfluxDB push interval"`
+	Address         string `description:"InfluxDB address"`
+	Protocol        string `description:"InfluxDB address protocol (udp or http)"`
+	PushInterval    string `description:"InfluxDB push interval"`
+	Database        string `description:"InfluxDB database used when protocol is http" export:"true"`
+	RetentionPolicy string `description:"InfluxDB retention policy used when protocol is http" export:"true"`
+	Username        string `description:"InfluxDB username (only with http)"`
+	Password        string `description:"InfluxDB password (only with http)"`
+}
+
+// InfluxDB2 contains the address, the credentials and the metrics pushing interval of an InfluxDB 2.x server
+type InfluxDB2 struct {
+	Address      string `description:"InfluxDB 2.x URL"`
+	Token        string `description:"InfluxDB 2.x API token"`
+	Organization string `description:"InfluxDB 2.x organization" export:"true"`
+	Bucket       string `description:"InfluxDB 2.x bucket" export:"true"`
+	PushInterval string `description:"InfluxDB 2.x push interval" export:"true"`
+}
+
+// CacheStatus holds the configuration used to detect whether responses were served by an upstream cache (e.g. a CDN)
+type CacheStatus struct {
+	Headers []string `description:"Response headers carrying the cache status, in order of precedence (e.g. CF-Cache-Status, X-Cache)" export:"true"`
+}
+
+// RequestID holds the configuration of the request IDs correlating the requests from end to end
+type RequestID struct {
+	Header string `description:"Header holding the request ID (default X-Request-Id)" export:"true"`
+	Format string `description:"Format of the generated request IDs: uuid or hex (default uuid)" export:"true"`
 }
 
 // Buckets holds Prometheus Buckets
@@ -430,16 +827,150 @@ func (b *Buckets) SetValue(val interface{}) {
 	*b = Buckets(val.(Buckets))
 }
 
+// MetricsLabels is a list of metrics labels or tags
+type MetricsLabels []string
+
+// Set adds the comma-separated labels of str
+func (l *MetricsLabels) Set(str string) error {
+	for _, label := range strings.Split(str, ",") {
+		if label = strings.TrimSpace(label); len(label) > 0 {
+			*l = append(*l, label)
+		}
+	}
+	return nil
+}
+
+// Get returns the labels
+func (l *MetricsLabels) Get() interface{} { return MetricsLabels(*l) }
+
+// String returns the labels as a comma-separated string
+func (l *MetricsLabels) String() string { return strings.Join(*l, ",") }
+
+// SetValue sets the labels
+func (l *MetricsLabels) SetValue(val interface{}) {
+	*l = MetricsLabels(val.(MetricsLabels))
+}
+
+// LogLevels holds the log levels of the modules, by module
+type LogLevels map[string]string
+
+// Set adds the comma-separated module=level pairs of str
+func (l *LogLevels) Set(str string) error {
+	if *l == nil {
+		*l = make(LogLevels)
+	}
+	for _, pair := range strings.Split(str, ",") {
+		if pair = strings.TrimSpace(pair); len(pair) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return fmt.Errorf("invalid module log level %q, expected module=level", pair)
+		}
+		(*l)[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
+
+// Get returns the levels
+func (l *LogLevels) Get() interface{} { return *l }
+
+// String returns the levels
+func (l *LogLevels) String() string { return fmt.Sprintf("%+v", *l) }
+
+// SetValue sets the levels
+func (l *LogLevels) SetValue(val interface{}) {
+	*l = val.(LogLevels)
+}
+
 // TraefikLog holds the configuration settings for the traefik logger.
 type TraefikLog struct {
-	FilePath string `json:"file,omitempty" description:"Traefik log file path. Stdout is used when omitted or empty"`
-	Format   string `json:"format,omitempty" description:"Traefik log format: json | common"`
+	FilePath string    `json:"file,omitempty" description:"Traefik log file path. Stdout is used when omitted or empty"`
+	Format   string    `json:"format,omitempty" description:"Traefik log format: json | common"`
+	Levels   LogLevels `json:"levels,omitempty" description:"Log levels of the modules, overriding the log level, like provider.ecs=debug,server=info"`
 }
 
 // AccessLog holds the configuration settings for the access logger (middlewares/accesslog).
 type AccessLog struct {
-	FilePath string `json:"file,omitempty" description:"Access log file path. Stdout is used when omitted or empty" export:"true"`
-	Format   string `json:"format,omitempty" description:"Access log format: json | common" export:"true"`
+	FilePath       string                   `json:"file,omitempty" description:"Access log file path. Stdout is used when omitted or empty" export:"true"`
+	Format         string                   `json:"format,omitempty" description:"Access log format: json | common" export:"true"`
+	Fields         *AccessLogFields         `json:"fields,omitempty" description:"Fields kept, dropped or redacted in the access log, and their names" export:"true"`
+	Syslog         *AccessLogSyslog         `json:"syslog,omitempty" description:"Send the access log to a syslog server instead of a file" export:"true"`
+	KafkaRESTProxy *AccessLogKafkaRESTProxy `json:"kafkaRESTProxy,omitempty" description:"Send the access log to a Kafka topic through a Kafka REST proxy instead of a file" export:"true"`
+}
+
+// AccessLogSyslog holds the syslog server receiving the access log, in the RFC 5424 format
+type AccessLogSyslog struct {
+	Network  string           `json:"network,omitempty" description:"Network of the syslog server: tcp | udp | unix | unixgram" export:"true"`
+	Address  string           `json:"address,omitempty" description:"Address of the syslog server"`
+	Facility string           `json:"facility,omitempty" description:"Facility of the messages: user | daemon | local0 to local7" export:"true"`
+	AppName  string           `json:"appName,omitempty" description:"Application name of the messages" export:"true"`
+	Buffer   *AccessLogBuffer `json:"buffer,omitempty" description:"Buffering of the entries" export:"true"`
+}
+
+// AccessLogKafkaRESTProxy holds the Kafka topic receiving the access log, through a Kafka REST proxy
+type AccessLogKafkaRESTProxy struct {
+	URL    string           `json:"url,omitempty" description:"URL of the Kafka REST proxy"`
+	Topic  string           `json:"topic,omitempty" description:"Kafka topic" export:"true"`
+	Buffer *AccessLogBuffer `json:"buffer,omitempty" description:"Buffering of the entries" export:"true"`
+}
+
+// AccessLogBuffer holds the buffering and the batching of the access log entries sent to an output
+type AccessLogBuffer struct {
+	Size           int            `json:"size,omitempty" description:"Entries buffered before the buffer overflows" export:"true"`
+	BatchSize      int            `json:"batchSize,omitempty" description:"Entries sent together" export:"true"`
+	FlushInterval  flaeg.Duration `json:"flushInterval,omitempty" description:"Longest time an entry waits for its batch to be sent" export:"true"`
+	OverflowPolicy string         `json:"overflowPolicy,omitempty" description:"Policy when the buffer is full: drop (the new entries) | block (the requests)" export:"true"`
+}
+
+// The modes of the access log fields and headers.
+const (
+	AccessLogKeep   = "keep"
+	AccessLogDrop   = "drop"
+	AccessLogRedact = "redact"
+)
+
+// AccessLogFields holds the fields kept, dropped or redacted in the access log, and the names of the fields in the JSON format
+type AccessLogFields struct {
+	DefaultMode string        `json:"defaultMode,omitempty" description:"Default mode of the fields: keep | drop | redact" export:"true"`
+	Names       FieldNames    `json:"names,omitempty" description:"Mode of the fields, overriding the default mode" export:"true"`
+	Headers     *FieldHeaders `json:"headers,omitempty" description:"Headers kept, dropped or redacted" export:"true"`
+	Rename      FieldNames    `json:"rename,omitempty" description:"Names of the fields in the JSON format" export:"true"`
+}
+
+// FieldHeaders holds the request and response headers kept, dropped or redacted in the access log
+type FieldHeaders struct {
+	DefaultMode string     `json:"defaultMode,omitempty" description:"Default mode of the headers: keep | drop | redact" export:"true"`
+	Names       FieldNames `json:"names,omitempty" description:"Mode of the headers, overriding the default mode" export:"true"`
+}
+
+// FieldNames maps the names of access log fields to a value
+type FieldNames map[string]string
+
+// Set adds the name=value pairs of str, separated by spaces
+func (f *FieldNames) Set(str string) error {
+	if *f == nil {
+		*f = make(FieldNames)
+	}
+	for _, pair := range strings.Fields(str) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid field %q, expected name=value", pair)
+		}
+		(*f)[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+// Get returns the field names
+func (f *FieldNames) Get() interface{} { return *f }
+
+// String returns the field names
+func (f *FieldNames) String() string { return fmt.Sprintf("%+v", *f) }
+
+// SetValue sets the field names
+func (f *FieldNames) SetValue(val interface{}) {
+	*f = val.(FieldNames)
 }
 
 // ClientTLS holds TLS specific configurations as client
@@ -0,0 +1,14 @@
+'use strict';
+var angular = require('angular');
+
+var traefikCoreGraph = 'traefik.core.graph';
+module.exports = traefikCoreGraph;
+
+angular
+  .module(traefikCoreGraph, ['ngResource'])
+  .factory('Graph', Graph);
+
+  /** @ngInject */
+  function Graph($resource) {
+    return $resource('../api/graph');
+  }
@@ -0,0 +1,64 @@
+'use strict';
+
+/** @ngInject */
+function GraphController($scope, $interval, $log, Graph) {
+  const vm = this;
+
+  /**
+   * Build the routes of the frontends from the graph:
+   * entry points -> frontend -> middlewares -> backend -> servers
+   *
+   * @param {Object} graph Routing graph from server
+   */
+  function buildRoutes(graph) {
+    const nodes = {};
+    const outgoing = {};
+    const incoming = {};
+
+    (graph.nodes || []).forEach(node => nodes[node.id] = node);
+    (graph.edges || []).forEach(edge => {
+      (outgoing[edge.from] = outgoing[edge.from] || []).push(nodes[edge.to]);
+      (incoming[edge.to] = incoming[edge.to] || []).push(nodes[edge.from]);
+    });
+
+    return (graph.nodes || [])
+      .filter(node => node.kind === 'frontend')
+      .map(frontend => {
+        const route = {
+          frontend: frontend,
+          entryPoints: incoming[frontend.id] || [],
+          middlewares: [],
+          servers: []
+        };
+
+        let next = (outgoing[frontend.id] || [])[0];
+        while (next && next.kind === 'middleware') {
+          route.middlewares.push(next);
+          next = (outgoing[next.id] || [])[0];
+        }
+
+        if (next && next.kind === 'backend') {
+          route.backend = next;
+          route.servers = outgoing[next.id] || [];
+        }
+        return route;
+      });
+  }
+
+  function loadGraph() {
+    Graph.get(graph => vm.routes = buildRoutes(graph), error => {
+      vm.routes = [];
+      $log.error(error);
+    });
+  }
+
+  loadGraph();
+
+  const intervalId = $interval(loadGraph, 5000);
+
+  $scope.$on('$destroy', function () {
+    $interval.cancel(intervalId);
+  });
+}
+
+module.exports = GraphController;
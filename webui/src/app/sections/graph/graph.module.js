@@ -0,0 +1,24 @@
+'use strict';
+var angular = require('angular');
+var traefikCoreGraph = require('../../core/graph.resource');
+var GraphController = require('./graph.controller');
+
+var traefikSectionGraph = 'traefik.section.graph';
+module.exports = traefikSectionGraph;
+
+angular
+  .module(traefikSectionGraph, [traefikCoreGraph])
+  .controller('GraphController', GraphController)
+  .config(config);
+
+  /** @ngInject */
+  function config($stateProvider) {
+
+    $stateProvider.state('graph', {
+      url: '/graph',
+      template: require('./graph.html'),
+      controller: 'GraphController',
+      controllerAs: 'graphCtrl'
+    });
+
+  }